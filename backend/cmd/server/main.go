@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
@@ -23,6 +22,11 @@ func main() {
 		logrus.WithError(err).Fatal("Failed to load configuration")
 	}
 
+	// Reject missing or malformed settings before any service starts
+	if err := cfg.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid configuration")
+	}
+
 	// Setup logging
 	setupLogging(cfg)
 
@@ -36,7 +40,7 @@ func main() {
 	metricsInstance := metrics.New()
 
 	// Initialize service container with all dependencies
-	container, err := services.NewContainer(cfg, logrus.StandardLogger())
+	container, err := services.NewContainer(cfg, metricsInstance, logrus.StandardLogger())
 	if err != nil {
 		logrus.WithError(err).Fatal("Failed to initialize service container")
 	}
@@ -48,52 +52,62 @@ func main() {
 		}
 	}()
 
-	// Setup HTTP router with all routes and middleware
-	r := routes.SetupRoutes(container, logrus.StandardLogger())
+	// Background workers stop when the server shuts down
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
 
-	// Start metrics server
-	go func() {
-		metricsServer := &http.Server{
-			Addr:    cfg.MetricsAddr,
-			Handler: metricsInstance.Handler(),
-		}
+	// Write match timeline events to the database
+	container.MatchEvents.Start(workersCtx)
+
+	// Deliver realtime events to Centrifugo off the settlement and heat paths
+	container.Publisher.Start(workersCtx)
 
-		logrus.WithField("addr", cfg.MetricsAddr).Info("Starting metrics server")
-		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logrus.WithError(err).Error("Metrics server failed")
+	// Export database connection pool saturation
+	if cfg.DBPoolStatsInterval > 0 {
+		container.DBPoolStats.Start(workersCtx, cfg.DBPoolStatsInterval)
+		if container.ReplicaDBPoolStats != nil {
+			container.ReplicaDBPoolStats.Start(workersCtx, cfg.DBPoolStatsInterval)
 		}
-	}()
+	}
 
-	// Start main HTTP server
-	server := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: r,
+	// Form lobbies from the league queues
+	if err := container.MatchmakerService.StartMatchmakingWorker(workersCtx); err != nil {
+		logrus.WithError(err).Fatal("Failed to start matchmaking worker")
 	}
 
-	// Start server in a goroutine
-	go func() {
-		logrus.WithField("port", cfg.Port).Info("Starting HTTP server")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logrus.WithError(err).Fatal("HTTP server failed")
-		}
-	}()
+	// Remove queued players whose realtime connection dropped
+	if cfg.MatchmakingPresenceCheckInterval > 0 {
+		container.PresenceMonitor.Start(workersCtx, cfg.MatchmakingPresenceCheckInterval)
+	}
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// Setup HTTP router with all routes and middleware
+	r := routes.SetupRoutes(container, logrus.StandardLogger())
 
-	logrus.Info("Shutting down server...")
+	// Metrics and API servers share one lifecycle so SIGTERM releases both ports
+	metricsServer := &http.Server{
+		Addr:    cfg.MetricsAddr,
+		Handler: metricsInstance.Handler(),
+	}
+	server := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: r,
+	}
 
-	// Create a deadline to wait for
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// Run until an interrupt signal arrives, then shut both servers down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// Attempt graceful shutdown
-	if err := server.Shutdown(ctx); err != nil {
-		logrus.WithError(err).Error("Server forced to shutdown")
+	if err := runServers(ctx, 30*time.Second, server, metricsServer); err != nil {
+		logrus.WithError(err).Error("Servers did not shut down cleanly")
 	}
 
+	// Stop background workers, letting in-flight lobby checks finish, queued match
+	// events reach the database and queued realtime events reach Centrifugo
+	stopWorkers()
+	<-container.MatchmakerService.WorkerDone()
+	<-container.MatchEvents.Done()
+	<-container.Publisher.Done()
+
 	logrus.Info("Server exited")
 }
 
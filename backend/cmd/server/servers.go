@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runServers starts all servers and blocks until ctx is cancelled or one of them fails,
+// then shuts every server down, waiting at most shutdownTimeout for in-flight requests
+func runServers(ctx context.Context, shutdownTimeout time.Duration, servers ...*http.Server) error {
+	errCh := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			logrus.WithField("addr", srv.Addr).Info("Starting HTTP server")
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errCh <- fmt.Errorf("server on %s failed: %w", srv.Addr, err)
+			}
+		}(srv)
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+	case runErr = <-errCh:
+	}
+
+	logrus.Info("Shutting down servers...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	shutdownErrs := make([]error, len(servers))
+	var wg sync.WaitGroup
+	for i, srv := range servers {
+		wg.Add(1)
+		go func(i int, srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				shutdownErrs[i] = fmt.Errorf("failed to shut down server on %s: %w", srv.Addr, err)
+			}
+		}(i, srv)
+	}
+	wg.Wait()
+
+	return errors.Join(append([]error{runErr}, shutdownErrs...)...)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer() *http.Server {
+	return &http.Server{
+		Addr:    "127.0.0.1:0",
+		Handler: http.NotFoundHandler(),
+	}
+}
+
+// assertShutDown checks that Shutdown was called: a shut down server refuses to serve again
+func assertShutDown(t *testing.T, servers ...*http.Server) {
+	t.Helper()
+	for _, srv := range servers {
+		assert.ErrorIs(t, srv.ListenAndServe(), http.ErrServerClosed)
+	}
+}
+
+func TestRunServers_ShutsDownAllOnCancel(t *testing.T) {
+	api, metrics := newTestServer(), newTestServer()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runServers(ctx, time.Second, api, metrics)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("servers did not shut down within the deadline")
+	}
+	assertShutDown(t, api, metrics)
+}
+
+func TestRunServers_StartFailureStopsOthers(t *testing.T) {
+	occupied, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer occupied.Close()
+
+	api, conflicting := newTestServer(), newTestServer()
+	conflicting.Addr = occupied.Addr().String()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runServers(context.Background(), time.Second, api, conflicting)
+	}()
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("servers did not shut down after a start failure")
+	}
+	assertShutDown(t, api, conflicting)
+}
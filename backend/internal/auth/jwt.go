@@ -8,8 +8,27 @@ import (
 	"github.com/google/uuid"
 )
 
-// JWTManager handles JWT token generation and validation
-type JWTManager struct {
+// JWTManager issues and validates the application's JWTs
+type JWTManager interface {
+	// GenerateAccessToken generates a token authenticating API requests
+	GenerateAccessToken(userID uuid.UUID, telegramID int64, ttl time.Duration) (string, error)
+
+	// GenerateRefreshToken generates a token that can only be exchanged for a new token pair
+	GenerateRefreshToken(userID uuid.UUID, telegramID int64, ttl time.Duration) (string, error)
+
+	// GenerateCentrifugoToken generates a token for connecting to Centrifugo
+	GenerateCentrifugoToken(userID uuid.UUID, telegramID int64, ttl time.Duration) (string, error)
+
+	// ValidateToken verifies a token's signature and expiry and that it has the expected type
+	ValidateToken(tokenString string, tokenType string) (*Claims, error)
+
+	// ParseClaims returns a token's claims without verifying it.
+	// Only use it where security does not matter, e.g. logging.
+	ParseClaims(tokenString string) (*Claims, error)
+}
+
+// jwtManager implements JWTManager with HMAC-signed tokens
+type jwtManager struct {
 	secretKey []byte
 	issuer    string
 }
@@ -18,60 +37,60 @@ type JWTManager struct {
 type Claims struct {
 	UserID     uuid.UUID `json:"user_id"`
 	TelegramID int64     `json:"telegram_id"`
-	TokenType  string    `json:"token_type"` // "app" or "centrifugo"
+	TokenType  string    `json:"token_type"` // "app", "refresh" or "centrifugo"
 	jwt.RegisteredClaims
 }
 
 // TokenType constants
 const (
-	TokenTypeApp        = "app"
+	TokenTypeApp        = "app" // Access token
+	TokenTypeRefresh    = "refresh"
 	TokenTypeCentrifugo = "centrifugo"
 )
 
+// tokenAudiences maps each token type to the audience it is issued for
+var tokenAudiences = map[string]string{
+	TokenTypeApp:        "ndr-api",
+	TokenTypeRefresh:    "ndr-api",
+	TokenTypeCentrifugo: "centrifugo",
+}
+
 // NewJWTManager creates a new JWT manager
-func NewJWTManager(secretKey string, issuer string) *JWTManager {
-	return &JWTManager{
+func NewJWTManager(secretKey string, issuer string) JWTManager {
+	return &jwtManager{
 		secretKey: []byte(secretKey),
 		issuer:    issuer,
 	}
 }
 
-// GenerateAppToken generates a JWT token for API authentication
-func (m *JWTManager) GenerateAppToken(userID uuid.UUID, telegramID int64, duration time.Duration) (string, error) {
-	now := time.Now()
-
-	claims := &Claims{
-		UserID:     userID,
-		TelegramID: telegramID,
-		TokenType:  TokenTypeApp,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    m.issuer,
-			Subject:   userID.String(),
-			Audience:  []string{"ndr-api"},
-			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
-			NotBefore: jwt.NewNumericDate(now),
-			IssuedAt:  jwt.NewNumericDate(now),
-			ID:        uuid.New().String(),
-		},
-	}
+// GenerateAccessToken generates a JWT token for API authentication
+func (m *jwtManager) GenerateAccessToken(userID uuid.UUID, telegramID int64, ttl time.Duration) (string, error) {
+	return m.generateToken(userID, telegramID, TokenTypeApp, ttl)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secretKey)
+// GenerateRefreshToken generates a JWT token for refreshing the token pair
+func (m *jwtManager) GenerateRefreshToken(userID uuid.UUID, telegramID int64, ttl time.Duration) (string, error) {
+	return m.generateToken(userID, telegramID, TokenTypeRefresh, ttl)
 }
 
 // GenerateCentrifugoToken generates a JWT token for Centrifugo authentication
-func (m *JWTManager) GenerateCentrifugoToken(userID uuid.UUID, telegramID int64, duration time.Duration) (string, error) {
+func (m *jwtManager) GenerateCentrifugoToken(userID uuid.UUID, telegramID int64, ttl time.Duration) (string, error) {
+	return m.generateToken(userID, telegramID, TokenTypeCentrifugo, ttl)
+}
+
+// generateToken signs a token of the given type
+func (m *jwtManager) generateToken(userID uuid.UUID, telegramID int64, tokenType string, ttl time.Duration) (string, error) {
 	now := time.Now()
 
 	claims := &Claims{
 		UserID:     userID,
 		TelegramID: telegramID,
-		TokenType:  TokenTypeCentrifugo,
+		TokenType:  tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    m.issuer,
 			Subject:   userID.String(),
-			Audience:  []string{"centrifugo"},
-			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+			Audience:  []string{tokenAudiences[tokenType]},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 			NotBefore: jwt.NewNumericDate(now),
 			IssuedAt:  jwt.NewNumericDate(now),
 			ID:        uuid.New().String(),
@@ -82,8 +101,8 @@ func (m *JWTManager) GenerateCentrifugoToken(userID uuid.UUID, telegramID int64,
 	return token.SignedString(m.secretKey)
 }
 
-// ValidateToken validates a JWT token and returns the claims
-func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
+// ValidateToken validates a JWT token of the given type and returns the claims
+func (m *jwtManager) ValidateToken(tokenString string, tokenType string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		// Ensure the signing method is HMAC
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -105,83 +124,15 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, fmt.Errorf("invalid token")
 	}
 
-	return claims, nil
-}
-
-// ValidateAppToken validates an app token and ensures it's the correct type
-func (m *JWTManager) ValidateAppToken(tokenString string) (*Claims, error) {
-	claims, err := m.ValidateToken(tokenString)
-	if err != nil {
-		return nil, err
-	}
-
-	if claims.TokenType != TokenTypeApp {
-		return nil, fmt.Errorf("invalid token type: expected %s, got %s", TokenTypeApp, claims.TokenType)
+	if claims.TokenType != tokenType {
+		return nil, fmt.Errorf("invalid token type: expected %s, got %s", tokenType, claims.TokenType)
 	}
 
 	return claims, nil
 }
 
-// ValidateCentrifugoToken validates a Centrifugo token and ensures it's the correct type
-func (m *JWTManager) ValidateCentrifugoToken(tokenString string) (*Claims, error) {
-	claims, err := m.ValidateToken(tokenString)
-	if err != nil {
-		return nil, err
-	}
-
-	if claims.TokenType != TokenTypeCentrifugo {
-		return nil, fmt.Errorf("invalid token type: expected %s, got %s", TokenTypeCentrifugo, claims.TokenType)
-	}
-
-	return claims, nil
-}
-
-// RefreshToken generates a new token with the same claims but extended expiration
-func (m *JWTManager) RefreshToken(tokenString string, duration time.Duration) (string, error) {
-	claims, err := m.ValidateToken(tokenString)
-	if err != nil {
-		return "", fmt.Errorf("cannot refresh invalid token: %w", err)
-	}
-
-	// Generate new token with same user info but new expiration
-	switch claims.TokenType {
-	case TokenTypeApp:
-		return m.GenerateAppToken(claims.UserID, claims.TelegramID, duration)
-	case TokenTypeCentrifugo:
-		return m.GenerateCentrifugoToken(claims.UserID, claims.TelegramID, duration)
-	default:
-		return "", fmt.Errorf("unknown token type: %s", claims.TokenType)
-	}
-}
-
-// ExtractUserIDFromToken extracts user ID from a token without full validation
-// Useful for logging/metrics where we don't need full security validation
-func (m *JWTManager) ExtractUserIDFromToken(tokenString string) (uuid.UUID, error) {
-	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, &Claims{})
-	if err != nil {
-		return uuid.Nil, fmt.Errorf("failed to parse token: %w", err)
-	}
-
-	claims, ok := token.Claims.(*Claims)
-	if !ok {
-		return uuid.Nil, fmt.Errorf("invalid token claims")
-	}
-
-	return claims.UserID, nil
-}
-
-// TokenInfo represents information about a token
-type TokenInfo struct {
-	UserID     uuid.UUID `json:"user_id"`
-	TelegramID int64     `json:"telegram_id"`
-	TokenType  string    `json:"token_type"`
-	ExpiresAt  time.Time `json:"expires_at"`
-	IssuedAt   time.Time `json:"issued_at"`
-	TokenID    string    `json:"token_id"`
-}
-
-// GetTokenInfo extracts information from a token without validating signature
-func (m *JWTManager) GetTokenInfo(tokenString string) (*TokenInfo, error) {
+// ParseClaims extracts the claims from a token without validating its signature
+func (m *jwtManager) ParseClaims(tokenString string) (*Claims, error) {
 	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, &Claims{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -192,20 +143,5 @@ func (m *JWTManager) GetTokenInfo(tokenString string) (*TokenInfo, error) {
 		return nil, fmt.Errorf("invalid token claims")
 	}
 
-	info := &TokenInfo{
-		UserID:     claims.UserID,
-		TelegramID: claims.TelegramID,
-		TokenType:  claims.TokenType,
-		TokenID:    claims.ID,
-	}
-
-	if claims.ExpiresAt != nil {
-		info.ExpiresAt = claims.ExpiresAt.Time
-	}
-
-	if claims.IssuedAt != nil {
-		info.IssuedAt = claims.IssuedAt.Time
-	}
-
-	return info, nil
+	return claims, nil
 }
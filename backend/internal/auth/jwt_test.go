@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTManager_RoundTrips(t *testing.T) {
+	manager := NewJWTManager("test-secret", "ndr")
+	userID := uuid.New()
+
+	tests := []struct {
+		tokenType string
+		generate  func(uuid.UUID, int64, time.Duration) (string, error)
+		audience  string
+	}{
+		{TokenTypeApp, manager.GenerateAccessToken, "ndr-api"},
+		{TokenTypeRefresh, manager.GenerateRefreshToken, "ndr-api"},
+		{TokenTypeCentrifugo, manager.GenerateCentrifugoToken, "centrifugo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tokenType, func(t *testing.T) {
+			token, err := tt.generate(userID, 42, time.Hour)
+			require.NoError(t, err)
+
+			claims, err := manager.ValidateToken(token, tt.tokenType)
+			require.NoError(t, err)
+			assert.Equal(t, userID, claims.UserID)
+			assert.Equal(t, int64(42), claims.TelegramID)
+			assert.Equal(t, tt.tokenType, claims.TokenType)
+			assert.Equal(t, "ndr", claims.Issuer)
+			assert.Equal(t, userID.String(), claims.Subject)
+			assert.Contains(t, claims.Audience, tt.audience)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt.Time, 5*time.Second)
+		})
+	}
+}
+
+func TestJWTManager_RejectsOtherTokenTypes(t *testing.T) {
+	manager := NewJWTManager("test-secret", "ndr")
+	userID := uuid.New()
+
+	accessToken, err := manager.GenerateAccessToken(userID, 42, time.Hour)
+	require.NoError(t, err)
+	refreshToken, err := manager.GenerateRefreshToken(userID, 42, time.Hour)
+	require.NoError(t, err)
+	centrifugoToken, err := manager.GenerateCentrifugoToken(userID, 42, time.Hour)
+	require.NoError(t, err)
+
+	_, err = manager.ValidateToken(refreshToken, TokenTypeApp)
+	assert.Error(t, err)
+	_, err = manager.ValidateToken(centrifugoToken, TokenTypeApp)
+	assert.Error(t, err)
+	_, err = manager.ValidateToken(accessToken, TokenTypeRefresh)
+	assert.Error(t, err)
+	_, err = manager.ValidateToken(centrifugoToken, TokenTypeRefresh)
+	assert.Error(t, err)
+}
+
+func TestJWTManager_RejectsInvalidTokens(t *testing.T) {
+	manager := NewJWTManager("test-secret", "ndr")
+	userID := uuid.New()
+
+	expired, err := manager.GenerateAccessToken(userID, 42, -time.Minute)
+	require.NoError(t, err)
+	_, err = manager.ValidateToken(expired, TokenTypeApp)
+	assert.Error(t, err)
+
+	foreign, err := NewJWTManager("other-secret", "ndr").GenerateAccessToken(userID, 42, time.Hour)
+	require.NoError(t, err)
+	_, err = manager.ValidateToken(foreign, TokenTypeApp)
+	assert.Error(t, err)
+
+	_, err = manager.ValidateToken("not-a-token", TokenTypeApp)
+	assert.Error(t, err)
+}
+
+func TestJWTManager_ParseClaimsSkipsVerification(t *testing.T) {
+	manager := NewJWTManager("test-secret", "ndr")
+	userID := uuid.New()
+
+	// Expired tokens signed with another key still parse
+	token, err := NewJWTManager("other-secret", "ndr").GenerateRefreshToken(userID, 42, -time.Minute)
+	require.NoError(t, err)
+
+	claims, err := manager.ParseClaims(token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.Equal(t, TokenTypeRefresh, claims.TokenType)
+
+	_, err = manager.ParseClaims("not-a-token")
+	assert.Error(t, err)
+}
@@ -45,21 +45,28 @@ func (c *Client) Close() error {
 	return nil
 }
 
+// UserChannelPublishOptions apply to publications on personal user channels.
+// User events carry the latest state (balances, match found), so replaying old
+// ones on reconnect is never useful and they are kept out of channel history.
+var UserChannelPublishOptions = []gocent.PublishOption{gocent.WithSkipHistory(true)}
+
 // PublishToUser publishes a message to a user's personal channel
 func (c *Client) PublishToUser(ctx context.Context, userID string, event string, data interface{}) error {
 	channel := fmt.Sprintf("user:%s", userID)
-	return c.publish(ctx, channel, event, data)
+	return c.publish(ctx, channel, event, data, UserChannelPublishOptions...)
 }
 
-// PublishToMatch publishes a message to a match channel
+// PublishToMatch publishes a message to a match channel. Match publications are
+// kept in history (size and TTL are set on the Centrifugo "match" namespace) so
+// reconnecting clients can catch up.
 func (c *Client) PublishToMatch(ctx context.Context, matchID string, event string, data interface{}) error {
 	channel := fmt.Sprintf("match:%s", matchID)
 	return c.publish(ctx, channel, event, data)
 }
 
 // Publish publishes raw data to a channel (for direct JSON publishing)
-func (c *Client) Publish(ctx context.Context, channel string, data []byte) error {
-	_, err := c.client.Publish(ctx, channel, data)
+func (c *Client) Publish(ctx context.Context, channel string, data []byte, opts ...gocent.PublishOption) error {
+	_, err := c.client.Publish(ctx, channel, data, opts...)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"channel": channel,
@@ -76,7 +83,7 @@ func (c *Client) Publish(ctx context.Context, channel string, data []byte) error
 }
 
 // publish is the internal method for publishing messages
-func (c *Client) publish(ctx context.Context, channel string, event string, data interface{}) error {
+func (c *Client) publish(ctx context.Context, channel string, event string, data interface{}, opts ...gocent.PublishOption) error {
 	// Create the event payload
 	payload := map[string]interface{}{
 		"event":     event,
@@ -91,7 +98,7 @@ func (c *Client) publish(ctx context.Context, channel string, event string, data
 	}
 
 	// Publish to Centrifugo
-	_, err = c.client.Publish(ctx, channel, jsonData)
+	_, err = c.client.Publish(ctx, channel, jsonData, opts...)
 	if err != nil {
 		c.logger.WithFields(logrus.Fields{
 			"channel": channel,
@@ -152,6 +159,34 @@ func (c *Client) GetPresence(ctx context.Context, channel string) (map[string]go
 	return result.Presence, nil
 }
 
+// IsUserOnline reports whether a user has at least one connection subscribed to their personal channel
+func (c *Client) IsUserOnline(ctx context.Context, userID string) (bool, error) {
+	presence, err := c.GetPresence(ctx, fmt.Sprintf("user:%s", userID))
+	if err != nil {
+		return false, err
+	}
+	return len(presence) > 0, nil
+}
+
+// GetChannelUsers returns the distinct IDs of users with at least one connection subscribed to a channel
+func (c *Client) GetChannelUsers(ctx context.Context, channel string) ([]string, error) {
+	presence, err := c.GetPresence(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(presence))
+	users := make([]string, 0, len(presence))
+	for _, info := range presence {
+		if info.User == "" || seen[info.User] {
+			continue
+		}
+		seen[info.User] = true
+		users = append(users, info.User)
+	}
+	return users, nil
+}
+
 // GetPresenceStats returns presence statistics for a channel
 func (c *Client) GetPresenceStats(ctx context.Context, channel string) (*gocent.PresenceStatsResult, error) {
 	result, err := c.client.PresenceStats(ctx, channel)
@@ -161,10 +196,19 @@ func (c *Client) GetPresenceStats(ctx context.Context, channel string) (*gocent.
 	return &result, nil
 }
 
-// GetHistory returns channel history (simplified for gocent v3)
+// GetHistory returns channel history. A positive limit caps the number of
+// publications returned, since continues from a previously seen stream position
+// and reverse returns the newest publications first.
 func (c *Client) GetHistory(ctx context.Context, channel string, limit int, since *gocent.StreamPosition, reverse bool) (*gocent.HistoryResult, error) {
-	// gocent v3 has a simpler History API
-	result, err := c.client.History(ctx, channel)
+	opts := []gocent.HistoryOption{
+		gocent.WithLimit(limit),
+		gocent.WithReverse(reverse),
+	}
+	if since != nil {
+		opts = append(opts, gocent.WithSince(since))
+	}
+
+	result, err := c.client.History(ctx, channel, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get history for channel %s: %w", channel, err)
 	}
@@ -244,6 +288,14 @@ func (c *Client) GetInfo(ctx context.Context) (*gocent.InfoResult, error) {
 	return &result, nil
 }
 
+// Ping verifies that the Centrifugo server API is reachable and responding
+func (c *Client) Ping(ctx context.Context) error {
+	if _, err := c.client.Info(ctx); err != nil {
+		return fmt.Errorf("failed to ping Centrifugo: %w", err)
+	}
+	return nil
+}
+
 // Event types for type safety
 const (
 	// User channel events
@@ -0,0 +1,126 @@
+package centrifugo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/centrifugal/gocent/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// historyRequest mirrors the params of a Centrifugo history API command
+type historyRequest struct {
+	Channel string                 `json:"channel"`
+	Limit   int                    `json:"limit"`
+	Since   *gocent.StreamPosition `json:"since"`
+	Reverse bool                   `json:"reverse"`
+}
+
+// fakeHistoryServer serves a fixed publication stream and records history requests
+type fakeHistoryServer struct {
+	mu       sync.Mutex
+	requests []historyRequest
+	stream   []gocent.Publication
+	epoch    string
+}
+
+func (s *fakeHistoryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var cmd struct {
+		Method string         `json:"method"`
+		Params historyRequest `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil || cmd.Method != "history" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, cmd.Params)
+	s.mu.Unlock()
+
+	publications := make([]gocent.Publication, 0, len(s.stream))
+	for _, pub := range s.stream {
+		if cmd.Params.Since == nil || pub.Offset > cmd.Params.Since.Offset {
+			publications = append(publications, pub)
+		}
+	}
+	if cmd.Params.Limit > 0 && len(publications) > cmd.Params.Limit {
+		publications = publications[:cmd.Params.Limit]
+	}
+
+	reply := map[string]interface{}{
+		"result": gocent.HistoryResult{
+			Publications: publications,
+			Offset:       s.stream[len(s.stream)-1].Offset,
+			Epoch:        s.epoch,
+		},
+	}
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+func newTestHistoryClient(t *testing.T, events int) (*Client, *fakeHistoryServer) {
+	fake := &fakeHistoryServer{epoch: "epoch-1"}
+	for i := 1; i <= events; i++ {
+		fake.stream = append(fake.stream, gocent.Publication{
+			Offset: uint64(i),
+			Data:   json.RawMessage(fmt.Sprintf(`{"event":"heat_tick","seq":%d}`, i)),
+		})
+	}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	client, err := NewClient(Config{GRPCAddr: server.URL, APIKey: "test-key"}, logger)
+	require.NoError(t, err)
+
+	return client, fake
+}
+
+func TestGetHistory_PassesOptions(t *testing.T) {
+	client, fake := newTestHistoryClient(t, 3)
+	since := &gocent.StreamPosition{Offset: 1, Epoch: "epoch-1"}
+
+	_, err := client.GetHistory(context.Background(), "match:abc", 2, since, true)
+	require.NoError(t, err)
+
+	require.Len(t, fake.requests, 1)
+	assert.Equal(t, historyRequest{
+		Channel: "match:abc",
+		Limit:   2,
+		Since:   since,
+		Reverse: true,
+	}, fake.requests[0])
+}
+
+func TestGetHistory_Paginates(t *testing.T) {
+	client, _ := newTestHistoryClient(t, 5)
+	ctx := context.Background()
+
+	var offsets []uint64
+	var since *gocent.StreamPosition
+	for page := 0; page < 3; page++ {
+		result, err := client.GetHistory(ctx, "match:abc", 2, since, false)
+		require.NoError(t, err)
+		for _, pub := range result.Publications {
+			offsets = append(offsets, pub.Offset)
+		}
+		if len(result.Publications) == 0 {
+			break
+		}
+		last := result.Publications[len(result.Publications)-1]
+		since = &gocent.StreamPosition{Offset: last.Offset, Epoch: result.Epoch}
+	}
+
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, offsets)
+}
@@ -0,0 +1,110 @@
+package centrifugo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Channel capabilities understood by Centrifugo's "caps" connection token claim
+const (
+	CapabilitySubscribe = "sub"
+	CapabilityPublish   = "pub"
+	CapabilityPresence  = "prs"
+	CapabilityHistory   = "hst"
+)
+
+// ChannelCapability grants a set of capabilities on a list of channels
+type ChannelCapability struct {
+	Channels []string `json:"channels"`
+	Allow    []string `json:"allow"`
+}
+
+// ConnectionInfo is attached to a connection and visible in presence data
+type ConnectionInfo struct {
+	Role    string `json:"role"`
+	MatchID string `json:"match_id,omitempty"`
+}
+
+// ConnectionClaims represents the claims of a Centrifugo connection token
+type ConnectionClaims struct {
+	Info *ConnectionInfo     `json:"info,omitempty"`
+	Caps []ChannelCapability `json:"caps,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// RoleSpectator marks connections that watch a match without taking part in it
+const RoleSpectator = "spectator"
+
+// MatchChannel returns the channel carrying a match's events
+func MatchChannel(matchID string) string {
+	return fmt.Sprintf("match:%s", matchID)
+}
+
+// SpectatorsChannel returns the presence channel spectators of a match join
+func SpectatorsChannel(matchID string) string {
+	return fmt.Sprintf("spectators:%s", matchID)
+}
+
+// TokenIssuer issues Centrifugo connection tokens signed with the Centrifugo HMAC secret
+type TokenIssuer struct {
+	secret []byte
+}
+
+// NewTokenIssuer creates a new Centrifugo token issuer
+func NewTokenIssuer(secret string) *TokenIssuer {
+	return &TokenIssuer{secret: []byte(secret)}
+}
+
+// GenerateSpectatorToken issues a connection token that may subscribe to a match
+// channel and its spectators presence channel, but never publish to either
+func (i *TokenIssuer) GenerateSpectatorToken(userID, matchID string, duration time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(duration)
+
+	claims := &ConnectionClaims{
+		Info: &ConnectionInfo{
+			Role:    RoleSpectator,
+			MatchID: matchID,
+		},
+		Caps: []ChannelCapability{
+			{
+				Channels: []string{MatchChannel(matchID)},
+				Allow:    []string{CapabilitySubscribe},
+			},
+			{
+				Channels: []string{SpectatorsChannel(matchID)},
+				Allow:    []string{CapabilitySubscribe, CapabilityPresence},
+			},
+		},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign spectator token: %w", err)
+	}
+
+	return token, expiresAt, nil
+}
+
+// ParseConnectionToken validates a connection token issued by this issuer and returns its claims
+func (i *TokenIssuer) ParseConnectionToken(tokenString string) (*ConnectionClaims, error) {
+	claims := &ConnectionClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return i.secret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection token: %w", err)
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,49 @@
+package centrifugo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSpectatorToken_GrantsReadOnlyAccess(t *testing.T) {
+	issuer := NewTokenIssuer("test-secret")
+	userID := uuid.New().String()
+	matchID := uuid.New().String()
+
+	token, expiresAt, err := issuer.GenerateSpectatorToken(userID, matchID, time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Second)
+
+	claims, err := issuer.ParseConnectionToken(token)
+	require.NoError(t, err)
+
+	assert.Equal(t, userID, claims.Subject)
+	require.NotNil(t, claims.Info)
+	assert.Equal(t, RoleSpectator, claims.Info.Role)
+	assert.Equal(t, matchID, claims.Info.MatchID)
+
+	granted := map[string][]string{}
+	for _, capability := range claims.Caps {
+		assert.NotContains(t, capability.Allow, CapabilityPublish)
+		for _, channel := range capability.Channels {
+			granted[channel] = append(granted[channel], capability.Allow...)
+		}
+	}
+
+	assert.Equal(t, []string{CapabilitySubscribe}, granted[MatchChannel(matchID)])
+	assert.ElementsMatch(t, []string{CapabilitySubscribe, CapabilityPresence}, granted[SpectatorsChannel(matchID)])
+	assert.Len(t, granted, 2, "token must not grant access to other channels")
+}
+
+func TestParseConnectionToken_WrongSecret(t *testing.T) {
+	token, _, err := NewTokenIssuer("secret-a").GenerateSpectatorToken("user", uuid.New().String(), time.Hour)
+	require.NoError(t, err)
+
+	_, err = NewTokenIssuer("secret-b").ParseConnectionToken(token)
+
+	assert.Error(t, err)
+}
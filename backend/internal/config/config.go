@@ -1,48 +1,125 @@
 package config
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/shopspring/decimal"
+
+	"github.com/megaherz/ndr/internal/constants"
+	monetary "github.com/megaherz/ndr/internal/decimal"
+	"github.com/megaherz/ndr/internal/modules/gateway/events"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	// Database
-	DatabaseURL string `env:"DATABASE_URL" env-required:"true" env-description:"Database connection URL"`
+	DatabaseURL           string        `env:"DATABASE_URL" env-required:"true" env-description:"Database connection URL"`
+	DatabaseReplicaURL    string        `env:"DATABASE_REPLICA_URL" env-description:"Read replica connection URL for listing, history and stats queries (empty reads from the primary)"`
+	MigrationsDir         string        `env:"MIGRATIONS_DIR" env-description:"Directory with SQL migrations (defaults to migrations embedded in the binary)"`
+	DBQueryTimeout        time.Duration `env:"DB_QUERY_TIMEOUT" env-default:"10s" env-description:"Timeout for repository queries whose context has no deadline"`
+	DBMaxQueryTimeout     time.Duration `env:"DB_MAX_QUERY_TIMEOUT" env-default:"30s" env-description:"Upper bound on any repository query deadline"`
+	DBPoolStatsInterval   time.Duration `env:"DB_POOL_STATS_INTERVAL" env-default:"15s" env-description:"How often database connection pool statistics are exported as metrics (0 disables)"`
+	LedgerBalanceCacheTTL time.Duration `env:"LEDGER_BALANCE_CACHE_TTL" env-default:"5s" env-description:"How long system wallet balances are cached between ledger writes (0 disables)"`
+	MatchCacheTTL         time.Duration `env:"MATCH_CACHE_TTL" env-default:"2s" env-description:"How long match rows are cached between match writes (0 disables)"`
 
 	// Redis
-	RedisURL string `env:"REDIS_URL" env-default:"redis://localhost:6379/0" env-description:"Redis connection URL"`
+	RedisURL          string        `env:"REDIS_URL" env-default:"redis://localhost:6379/0" env-description:"Redis connection URL"`
+	RedisPoolSize     int           `env:"REDIS_POOL_SIZE" env-description:"Redis connection pool size (overrides pool_size in REDIS_URL, 0 uses the default)"`
+	RedisMinIdleConns int           `env:"REDIS_MIN_IDLE_CONNS" env-default:"0" env-description:"Minimum number of idle Redis connections"`
+	RedisDialTimeout  time.Duration `env:"REDIS_DIAL_TIMEOUT" env-default:"5s" env-description:"Redis dial timeout"`
+	RedisReadTimeout  time.Duration `env:"REDIS_READ_TIMEOUT" env-default:"3s" env-description:"Redis read timeout"`
+	RedisMaxRetries   int           `env:"REDIS_MAX_RETRIES" env-default:"3" env-description:"Maximum number of Redis command retries"`
 
 	// JWT
-	JWTSecret string `env:"JWT_SECRET" env-required:"true" env-description:"JWT signing secret"`
+	JWTSecret      string        `env:"JWT_SECRET" env-required:"true" env-description:"JWT signing secret"`
+	AccessTokenTTL time.Duration `env:"ACCESS_TOKEN_TTL" env-default:"24h" env-description:"How long access and Centrifugo tokens are valid; also the advertised token expiry"`
+
+	// Seed commitments
+	SeedCommitKey string `env:"SEED_COMMIT_KEY" env-description:"Hex-encoded 32-byte Ed25519 seed that seed commitments are signed with; derived from JWT_SECRET when empty"`
 
 	// Telegram
-	TelegramBotToken string `env:"TELEGRAM_BOT_TOKEN" env-required:"true" env-description:"Telegram bot token for WebApp authentication"`
+	TelegramBotToken                string        `env:"TELEGRAM_BOT_TOKEN" env-required:"true" env-description:"Telegram bot token for WebApp authentication"`
+	TelegramInitDataValidation      string        `env:"TELEGRAM_INITDATA_VALIDATION" env-default:"hash" env-description:"How initData is verified (hash: bot token HMAC, signature: Telegram's Ed25519 signature)"`
+	TelegramInitDataMaxAge          time.Duration `env:"TELEGRAM_INITDATA_MAX_AGE" env-default:"24h" env-description:"How long Telegram initData is accepted after it was signed"`
+	TelegramInitDataFutureTolerance time.Duration `env:"TELEGRAM_INITDATA_FUTURE_TOLERANCE" env-default:"30s" env-description:"How far ahead of server time an initData auth_date may be before it is rejected as tampered"`
+	TelegramPublicKey               string        `env:"TELEGRAM_PUBLIC_KEY" env-default:"e7bf03a2fa4602af4580703d88dda5bb59f32ed8b02a56c187fe7d34caed242d" env-description:"Hex-encoded Ed25519 public key Telegram signs initData with (production key by default)"`
 
 	// Centrifugo
-	CentrifugoAPIKey   string `env:"CENTRIFUGO_API_KEY" env-required:"true" env-description:"Centrifugo API key"`
-	CentrifugoSecret   string `env:"CENTRIFUGO_SECRET" env-required:"true" env-description:"Centrifugo secret"`
-	CentrifugoGRPCAddr string `env:"CENTRIFUGO_GRPC_ADDR" env-default:"localhost:8001" env-description:"Centrifugo gRPC address"`
+	CentrifugoAPIKey                string   `env:"CENTRIFUGO_API_KEY" env-required:"true" env-description:"Centrifugo API key"`
+	CentrifugoSecret                string   `env:"CENTRIFUGO_SECRET" env-required:"true" env-description:"Centrifugo secret"`
+	CentrifugoGRPCAddr              string   `env:"CENTRIFUGO_GRPC_ADDR" env-default:"localhost:8001" env-description:"Centrifugo gRPC address"`
+	CentrifugoPublisher             string   `env:"CENTRIFUGO_PUBLISHER" env-default:"queue" env-description:"How realtime events are delivered (queue: one bounded queue, failed events dropped; retry: per-channel buffers with retries and backoff)"`
+	CentrifugoPublishQueueSize      int      `env:"CENTRIFUGO_PUBLISH_QUEUE_SIZE" env-default:"1024" env-description:"Maximum number of realtime events waiting for delivery before new ones are dropped"`
+	CentrifugoPreviousVersionEvents []string `env:"CENTRIFUGO_PREVIOUS_VERSION_EVENTS" env-separator:"," env-description:"Comma-separated event types also published in their previous schema version while a payload change rolls out"`
 
 	// TonCenter
 	TonCenterAPIKey string `env:"TONCENTER_API_KEY" env-description:"TonCenter API key (required in production)"`
 
+	// Admin
+	AdminUserIDs           []string `env:"ADMIN_USER_IDS" env-separator:"," env-description:"Comma-separated IDs of users allowed to call admin endpoints"`
+	AdminMaxFuelAdjustment string   `env:"ADMIN_MAX_FUEL_ADJUSTMENT" env-default:"1000.00" env-description:"Largest FUEL amount an admin may credit or debit in a single balance adjustment"`
+
 	// Server
-	Port        string `env:"PORT" env-default:"8080" env-description:"Server port"`
-	MetricsAddr string `env:"METRICS_ADDR" env-default:":9090" env-description:"Metrics server address"`
+	Port            string `env:"PORT" env-default:"8080" env-description:"Server port"`
+	MetricsAddr     string `env:"METRICS_ADDR" env-default:":9090" env-description:"Metrics server address"`
+	MaxRequestBytes int64  `env:"MAX_REQUEST_BYTES" env-default:"1048576" env-description:"Largest request body accepted by the API; larger ones get 413 (0 disables)"`
+
+	// CORS
+	CORSAllowedOrigins []string `env:"CORS_ALLOWED_ORIGINS" env-separator:"," env-default:"http://localhost:5173" env-description:"Comma-separated origins allowed to call the API (* allows any origin, development only)"`
 
 	// Logging
 	LogLevel string `env:"LOG_LEVEL" env-default:"info" env-description:"Log level (debug, info, warn, error)"`
 
 	// Matchmaking
-	MatchmakingTimeoutSeconds int `env:"MATCHMAKING_TIMEOUT_SECONDS" env-default:"20" env-description:"Matchmaking timeout in seconds"`
+	MatchmakingTimeoutSeconds        int            `env:"MATCHMAKING_TIMEOUT_SECONDS" env-default:"20" env-description:"Matchmaking timeout in seconds"`
+	MatchmakingQueueBackend          string         `env:"MATCHMAKING_QUEUE_BACKEND" env-default:"list" env-description:"Matchmaking queue implementation (list, zset)"`
+	MatchmakingPresenceCheckInterval time.Duration  `env:"MATCHMAKING_PRESENCE_CHECK_INTERVAL" env-default:"10s" env-description:"How often queued players' realtime presence is checked (0 disables)"`
+	MatchmakingWorkerTickInterval    time.Duration  `env:"MATCHMAKING_WORKER_TICK_INTERVAL" env-default:"5s" env-description:"How often the matchmaking worker checks league queues for a full lobby"`
+	MatchmakingWorkerConcurrency     int            `env:"MATCHMAKING_WORKER_CONCURRENCY" env-default:"4" env-description:"Maximum number of leagues the matchmaking worker checks at the same time"`
+	MatchmakingHeartbeatTimeout      time.Duration  `env:"MATCHMAKING_HEARTBEAT_TIMEOUT" env-default:"30s" env-description:"How long a queued client may go without a heartbeat before its queue entry is pruned (0 disables)"`
+	GhostFreeLeagues                 []string       `env:"GHOST_FREE_LEAGUES" env-separator:"," env-description:"Comma-separated leagues that only race full grids of live players; queued players time out instead of being matched with ghosts"`
+	MatchCooldown                    time.Duration  `env:"MATCH_COOLDOWN" env-default:"0s" env-description:"How long players must wait after a match settles before queueing again (0 disables)"`
+	MatchCooldownExemptLeagues       []string       `env:"MATCH_COOLDOWN_EXEMPT_LEAGUES" env-separator:"," env-description:"Comma-separated leagues that neither start nor honour the post-match cooldown"`
+	LeagueMinLivePlayers             map[string]int `env:"LEAGUE_MIN_LIVE_PLAYERS" env-separator:"," env-description:"Comma-separated LEAGUE:count live players required before ghosts fill the grid, e.g. PRO:6 (default 2)"`
+	LobbyMinReadyPlayers             int            `env:"LOBBY_MIN_READY_PLAYERS" env-default:"2" env-description:"Ready players a lobby needs when its countdown runs out to start with ghosts replacing the rest"`
+	LobbyRefundNotReady              bool           `env:"LOBBY_REFUND_NOT_READY" env-default:"false" env-description:"Release the buy-in holds of players dropped from a lobby for not readying up"`
+	MaxConcurrentMatches             int            `env:"MAX_CONCURRENT_MATCHES" env-default:"0" env-description:"Matches each server instance runs at once, not shared across replicas; lobbies wait for a free slot once it is reached (0 disables)"`
+	MatchCapacityWait                time.Duration  `env:"MATCH_CAPACITY_WAIT" env-default:"30s" env-description:"How long a ready lobby waits for a free match slot before it is cancelled and its players' buy-in holds released"`
+
+	// Game
+	HeatTickInterval        time.Duration `env:"HEAT_TICK_INTERVAL" env-default:"200ms" env-description:"How often heat_tick events are published during an active heat"`
+	HeatCountdown           time.Duration `env:"HEAT_COUNTDOWN" env-default:"3s" env-description:"How long a heat counts down before going active; one countdown_tick is published per second"`
+	HeatIntermission        time.Duration `env:"HEAT_INTERMISSION" env-default:"5s" env-description:"How long the break between heats lasts before the next countdown"`
+	MatchPresenceInterval   time.Duration `env:"MATCH_PRESENCE_CHECK_INTERVAL" env-default:"2s" env-description:"How often live players' presence on the match channel is checked during a heat; players absent from two checks in a row crash (0 disables)"`
+	LockTimeTiebreakLeagues []string      `env:"LOCK_TIME_TIEBREAK_LEAGUES" env-separator:"," env-description:"Comma-separated leagues where the earlier lock wins when players tie on every heat score"`
+	MatchLateJoinGrace      time.Duration `env:"MATCH_LATE_JOIN_GRACE" env-default:"5s" env-description:"How long after a ghost-filled match is created a late live player may take a ghost's slot (0 disables)"`
+	SeedTargetLineLeagues   []string      `env:"SEED_TARGET_LINE_LEAGUES" env-separator:"," env-description:"Comma-separated leagues whose Heat 2 and 3 target lines are derived from the committed crash seed instead of the leading score"`
+	SettlementTimeout       time.Duration `env:"SETTLEMENT_TIMEOUT" env-default:"30s" env-description:"How long the events of a settlement started without a deadline, e.g. by a background trigger, may take to publish before they are abandoned (0 disables)"`
+
+	// Economy
+	LeagueBuyins          map[string]string `env:"LEAGUE_BUYINS" env-separator:"," env-description:"Comma-separated LEAGUE:amount FUEL buy-in overrides, e.g. PRO:250.00 (defaults ROOKIE:10, STREET:50, PRO:300, TOP_FUEL:3000)"`
+	RakePercentage        string            `env:"RAKE_PERCENTAGE" env-default:"8.00" env-description:"Rake percentage taken from a match's buy-ins"`
+	LeagueRakePercentages map[string]string `env:"LEAGUE_RAKE_PERCENTAGES" env-separator:"," env-description:"Comma-separated LEAGUE:percentage overrides of RAKE_PERCENTAGE, e.g. ROOKIE:5.00"`
+	SignupFuelGrant       string            `env:"SIGNUP_FUEL_GRANT" env-default:"100.00" env-description:"FUEL credited once to every new player's wallet (0 disables)"`
+	ReferralFuelBonus     string            `env:"REFERRAL_FUEL_BONUS" env-default:"0" env-description:"FUEL credited to a player once for every new player who signs up through their referral link (0, the default, disables)"`
 
 	// Environment
 	Environment string `env:"ENVIRONMENT" env-default:"development" env-description:"Application environment (development, production)"`
 }
 
-// Load loads configuration from environment variables and .env file
+// minProductionSecretLength is the shortest signing secret accepted in production
+const minProductionSecretLength = 32
+
+// Load loads configuration from environment variables and .env file.
+// Callers should run Validate before using the configuration.
 func Load() (*Config, error) {
 	var cfg Config
 
@@ -51,24 +128,306 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Additional validation for production-specific requirements
-	if err := cfg.validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
-	}
-
 	return &cfg, nil
 }
 
-// validate ensures production-specific configuration requirements are met
-func (c *Config) validate() error {
+// Validate checks that required settings are present and well-formed, applying
+// stricter rules in production. All problems are reported at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	// Environment must be a known mode so the production rules below cannot be bypassed by a typo
+	check(c.Environment == "development" || c.Environment == "production",
+		"ENVIRONMENT must be one of: development, production")
+
+	// Connections
+	check(hasScheme(c.DatabaseURL, "postgres", "postgresql"), "DATABASE_URL must be a postgres:// URL")
+	check(c.DatabaseReplicaURL == "" || hasScheme(c.DatabaseReplicaURL, "postgres", "postgresql"), "DATABASE_REPLICA_URL must be a postgres:// URL")
+	check(hasScheme(c.RedisURL, "redis", "rediss"), "REDIS_URL must be a redis:// or rediss:// URL")
+	check(c.Port != "" && isNumeric(c.Port), "PORT must be a port number")
+	check(c.MaxRequestBytes >= 0, "MAX_REQUEST_BYTES must not be negative")
+
+	// Secrets must never be empty, and must be long enough to resist brute force in production
+	check(strings.TrimSpace(c.JWTSecret) != "", "JWT_SECRET must not be empty")
+	check(strings.TrimSpace(c.CentrifugoAPIKey) != "", "CENTRIFUGO_API_KEY must not be empty")
+	check(strings.TrimSpace(c.CentrifugoSecret) != "", "CENTRIFUGO_SECRET must not be empty")
+	if c.IsProduction() {
+		check(len(c.JWTSecret) >= minProductionSecretLength,
+			"JWT_SECRET must be at least %d characters in production", minProductionSecretLength)
+		check(len(c.CentrifugoSecret) >= minProductionSecretLength,
+			"CENTRIFUGO_SECRET must be at least %d characters in production", minProductionSecretLength)
+	}
+
+	// Tokens that expire immediately would make every login useless
+	check(c.AccessTokenTTL > 0, "ACCESS_TOKEN_TTL must be positive")
+
+	// Telegram bot tokens look like "123456:ABC-DEF..."; the bot ID is needed for initData checks
+	botID, _, found := strings.Cut(c.TelegramBotToken, ":")
+	check(found && isNumeric(botID), "TELEGRAM_BOT_TOKEN must have the form <bot_id>:<secret>")
+
+	// Signature validation needs Telegram's 32-byte Ed25519 public key
+	check(c.TelegramInitDataValidation == "hash" || c.TelegramInitDataValidation == "signature",
+		"TELEGRAM_INITDATA_VALIDATION must be one of: hash, signature")
+	check(c.TelegramInitDataMaxAge > 0, "TELEGRAM_INITDATA_MAX_AGE must be positive")
+	check(c.TelegramInitDataFutureTolerance >= 0, "TELEGRAM_INITDATA_FUTURE_TOLERANCE must not be negative")
+	if c.TelegramInitDataValidation == "signature" {
+		key, err := hex.DecodeString(c.TelegramPublicKey)
+		check(err == nil && len(key) == 32, "TELEGRAM_PUBLIC_KEY must be a hex-encoded 32-byte Ed25519 key")
+	}
+
+	// Seed commitments are signed with Ed25519, whose key is a 32-byte seed
+	if c.SeedCommitKey != "" {
+		key, err := hex.DecodeString(c.SeedCommitKey)
+		check(err == nil && len(key) == 32, "SEED_COMMIT_KEY must be a hex-encoded 32-byte Ed25519 seed")
+	}
+
 	// TonCenter API key is required in production
-	if c.TonCenterAPIKey == "" && c.Environment == "production" {
-		return fmt.Errorf("TONCENTER_API_KEY is required in production")
+	check(c.TonCenterAPIKey != "" || !c.IsProduction(), "TONCENTER_API_KEY is required in production")
+
+	// Admin user IDs must be valid UUIDs
+	for _, id := range c.AdminUserIDs {
+		_, err := uuid.Parse(id)
+		check(err == nil, "ADMIN_USER_IDS contains an invalid user ID: %q", id)
+	}
+	if limit, err := monetary.NewFromString(c.AdminMaxFuelAdjustment); err != nil {
+		check(false, "ADMIN_MAX_FUEL_ADJUSTMENT must be a decimal number: %q", c.AdminMaxFuelAdjustment)
+	} else {
+		check(limit.IsPositive(), "ADMIN_MAX_FUEL_ADJUSTMENT must be positive")
+	}
+
+	// A wildcard origin is only acceptable while developing locally
+	for _, origin := range c.CORSAllowedOrigins {
+		check(origin != "*" || !c.IsProduction(), "CORS_ALLOWED_ORIGINS must not contain * in production")
+	}
+
+	// Queue backend must be one of the supported implementations
+	check(c.MatchmakingQueueBackend == "list" || c.MatchmakingQueueBackend == "zset",
+		"MATCHMAKING_QUEUE_BACKEND must be one of: list, zset")
+
+	// Realtime publisher must be one of the supported implementations with room for events
+	check(c.CentrifugoPublisher == "queue" || c.CentrifugoPublisher == "retry",
+		"CENTRIFUGO_PUBLISHER must be one of: queue, retry")
+	check(c.CentrifugoPublishQueueSize > 0, "CENTRIFUGO_PUBLISH_QUEUE_SIZE must be positive")
+	for _, eventType := range c.CentrifugoPreviousVersionEvents {
+		check(events.IsKnownEventType(eventType), "CENTRIFUGO_PREVIOUS_VERSION_EVENTS contains an unknown event type: %q", eventType)
+	}
+
+	// The matchmaking worker needs a real ticker and at least one league slot
+	check(c.MatchmakingWorkerTickInterval > 0, "MATCHMAKING_WORKER_TICK_INTERVAL must be positive")
+	check(c.MatchmakingWorkerConcurrency > 0, "MATCHMAKING_WORKER_CONCURRENCY must be positive")
+	check(c.MatchmakingHeartbeatTimeout >= 0, "MATCHMAKING_HEARTBEAT_TIMEOUT must not be negative")
+
+	// Ghost rules must name real leagues, and a ghost-filled grid needs 1-10 live and ready players
+	for _, league := range c.GhostFreeLeagues {
+		check(constants.IsValidLeague(league), "GHOST_FREE_LEAGUES contains an unknown league: %q", league)
+	}
+	for league, count := range c.LeagueMinLivePlayers {
+		check(constants.IsValidLeague(league), "LEAGUE_MIN_LIVE_PLAYERS contains an unknown league: %q", league)
+		check(count >= 1 && count <= 10, "LEAGUE_MIN_LIVE_PLAYERS for %s must be between 1 and 10, got %d", league, count)
+	}
+	check(c.LobbyMinReadyPlayers >= 1 && c.LobbyMinReadyPlayers <= 10, "LOBBY_MIN_READY_PLAYERS must be between 1 and 10, got %d", c.LobbyMinReadyPlayers)
+	check(c.MaxConcurrentMatches >= 0, "MAX_CONCURRENT_MATCHES must not be negative")
+	check(c.MatchCapacityWait > 0, "MATCH_CAPACITY_WAIT must be positive")
+
+	// A negative cooldown would silently behave like a disabled one, and exemptions must name real leagues
+	check(c.MatchCooldown >= 0, "MATCH_COOLDOWN must not be negative")
+	for _, league := range c.MatchCooldownExemptLeagues {
+		check(constants.IsValidLeague(league), "MATCH_COOLDOWN_EXEMPT_LEAGUES contains an unknown league: %q", league)
+	}
+
+	// Negative durations would silently behave like a disabled cache
+	check(c.DBPoolStatsInterval >= 0, "DB_POOL_STATS_INTERVAL must not be negative")
+	check(c.LedgerBalanceCacheTTL >= 0, "LEDGER_BALANCE_CACHE_TTL must not be negative")
+	check(c.MatchCacheTTL >= 0, "MATCH_CACHE_TTL must not be negative")
+
+	// Heat ticks drive client animation, so they must actually fire
+	check(c.HeatTickInterval > 0, "HEAT_TICK_INTERVAL must be positive")
+
+	// Clients need at least one countdown tick before a heat goes active
+	check(c.HeatCountdown > 0, "HEAT_COUNTDOWN must be positive")
+
+	// Without a break the next countdown would start on top of the heat_ended screen
+	check(c.HeatIntermission > 0, "HEAT_INTERMISSION must be positive")
+
+	// Negative durations would silently behave like a disabled check or grace
+	check(c.MatchPresenceInterval >= 0, "MATCH_PRESENCE_CHECK_INTERVAL must not be negative")
+	check(c.MatchLateJoinGrace >= 0, "MATCH_LATE_JOIN_GRACE must not be negative")
+	check(c.SettlementTimeout >= 0, "SETTLEMENT_TIMEOUT must not be negative")
+
+	// Tiebreak leagues must exist, otherwise the rule would silently never apply
+	for _, league := range c.LockTimeTiebreakLeagues {
+		check(constants.IsValidLeague(league), "LOCK_TIME_TIEBREAK_LEAGUES contains an unknown league: %q", league)
+	}
+	for _, league := range c.SeedTargetLineLeagues {
+		check(constants.IsValidLeague(league), "SEED_TARGET_LINE_LEAGUES contains an unknown league: %q", league)
+	}
+
+	// Buy-ins are FUEL amounts every league must charge, and overrides must name real leagues
+	for league, value := range c.LeagueBuyins {
+		check(constants.IsValidLeague(league), "LEAGUE_BUYINS contains an unknown league: %q", league)
+
+		buyin, err := monetary.NewFromString(value)
+		check(err == nil && buyin.IsPositive() && monetary.ValidateMonetary(buyin) == nil,
+			"LEAGUE_BUYINS has an invalid buy-in for %s: %q", league, value)
+	}
+
+	// Rake rates must be sane percentages, and overrides must name real leagues
+	if rate, err := monetary.NewFromString(c.RakePercentage); err != nil {
+		check(false, "RAKE_PERCENTAGE must be a decimal number: %q", c.RakePercentage)
+	} else {
+		check(monetary.ValidateRakePercentage(rate) == nil, "RAKE_PERCENTAGE must be at least 0 and below 100 with at most 2 decimal places")
+	}
+	for league, value := range c.LeagueRakePercentages {
+		check(constants.IsValidLeague(league), "LEAGUE_RAKE_PERCENTAGES contains an unknown league: %q", league)
+
+		rate, err := monetary.NewFromString(value)
+		check(err == nil && monetary.ValidateRakePercentage(rate) == nil,
+			"LEAGUE_RAKE_PERCENTAGES has an invalid percentage for %s: %q", league, value)
 	}
 
+	// The signup grant and referral bonus are credited as FUEL amounts
+	if grant, err := monetary.NewFromString(c.SignupFuelGrant); err != nil {
+		check(false, "SIGNUP_FUEL_GRANT must be a decimal number: %q", c.SignupFuelGrant)
+	} else {
+		check(!grant.IsNegative(), "SIGNUP_FUEL_GRANT must not be negative")
+	}
+	if bonus, err := monetary.NewFromString(c.ReferralFuelBonus); err != nil {
+		check(false, "REFERRAL_FUEL_BONUS must be a decimal number: %q", c.ReferralFuelBonus)
+	} else {
+		check(!bonus.IsNegative(), "REFERRAL_FUEL_BONUS must not be negative")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
 	return nil
 }
 
+// TelegramBotID returns the bot ID part of the bot token, zero if the token is malformed
+func (c *Config) TelegramBotID() int64 {
+	botID, _, _ := strings.Cut(c.TelegramBotToken, ":")
+	id, err := strconv.ParseInt(botID, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// Leagues returns the registry of every league with the configured buy-ins, grid rules and rake.
+// The values are checked by Validate; an invalid set falls back to the launch leagues.
+func (c *Config) Leagues() *constants.LeagueRegistry {
+	defaultRate, err := monetary.NewFromString(c.RakePercentage)
+	if err != nil {
+		defaultRate = monetary.RakePercentage
+	}
+
+	leagues := constants.DefaultLeagues().All()
+	for i := range leagues {
+		league := &leagues[i]
+		if buyin, err := monetary.NewFromString(c.LeagueBuyins[league.Name]); err == nil {
+			league.Buyin = buyin
+		}
+		if count, ok := c.LeagueMinLivePlayers[league.Name]; ok {
+			league.MinLivePlayers = count
+		}
+		league.RakePercentage = defaultRate
+		if rate, err := monetary.NewFromString(c.LeagueRakePercentages[league.Name]); err == nil {
+			league.RakePercentage = rate
+		}
+	}
+	for _, name := range c.GhostFreeLeagues {
+		for i := range leagues {
+			if leagues[i].Name == name {
+				leagues[i].AllowGhosts = false
+			}
+		}
+	}
+
+	registry, err := constants.NewLeagueRegistry(leagues...)
+	if err != nil {
+		return constants.DefaultLeagues()
+	}
+	return registry
+}
+
+// RakeRates returns the configured rake rate of every league.
+// Values are checked by Validate; unparsable ones fall back to the default 8%.
+func (c *Config) RakeRates() *monetary.RakeRates {
+	defaultRate, err := monetary.NewFromString(c.RakePercentage)
+	if err != nil {
+		defaultRate = monetary.RakePercentage
+	}
+
+	leagues := c.Leagues().All()
+	leagueRates := make(map[string]decimal.Decimal, len(leagues))
+	for _, league := range leagues {
+		leagueRates[league.Name] = league.RakePercentage
+	}
+	return monetary.NewRakeRates(defaultRate, leagueRates)
+}
+
+// SignupGrant returns the FUEL credited to every new player.
+// The value is checked by Validate; an unparsable one disables the grant.
+func (c *Config) SignupGrant() decimal.Decimal {
+	grant, err := monetary.NewFromString(c.SignupFuelGrant)
+	if err != nil {
+		return decimal.Zero
+	}
+	return grant
+}
+
+// ReferralBonus returns the FUEL credited to a player for each new player they refer.
+// The value is checked by Validate; an unparsable one disables the bonus.
+func (c *Config) ReferralBonus() decimal.Decimal {
+	bonus, err := monetary.NewFromString(c.ReferralFuelBonus)
+	if err != nil {
+		return decimal.Zero
+	}
+	return bonus
+}
+
+// MaxFuelAdjustment returns the largest FUEL amount an admin may credit or debit at once.
+// The value is checked by Validate; an unparsable one rejects every adjustment.
+func (c *Config) MaxFuelAdjustment() decimal.Decimal {
+	limit, err := monetary.NewFromString(c.AdminMaxFuelAdjustment)
+	if err != nil {
+		return decimal.Zero
+	}
+	return limit
+}
+
+// hasScheme reports whether raw parses as a URL with one of the given schemes
+func hasScheme(raw string, schemes ...string) bool {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	for _, scheme := range schemes {
+		if parsed.Scheme == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+// isNumeric reports whether s is a non-empty string of ASCII digits
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // IsDevelopment returns true if running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.Environment == "development"
@@ -1,6 +1,12 @@
 package constants
 
-import "github.com/shopspring/decimal"
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
 
 // League name constants
 const (
@@ -10,12 +16,110 @@ const (
 	LeagueTopFuel = "TOP_FUEL"
 )
 
-// League buy-in amounts
-var LeagueBuyins = map[string]decimal.Decimal{
-	LeagueRookie:  decimal.NewFromInt(10),   // 10 FUEL
-	LeagueStreet:  decimal.NewFromInt(50),   // 50 FUEL
-	LeaguePro:     decimal.NewFromInt(300),  // 300 FUEL
-	LeagueTopFuel: decimal.NewFromInt(3000), // 3000 FUEL
+// gridSize is the number of grid slots in every match
+const gridSize = 10
+
+// ErrInvalidLeague is returned by NewLeagueRegistry when a league definition is unusable
+var ErrInvalidLeague = errors.New("invalid league definition")
+
+// League defines what a league costs to enter and how its matches fill and pay out
+type League struct {
+	Name           string
+	Buyin          decimal.Decimal // FUEL charged to enter a match
+	MinLivePlayers int             // Live players required before ghosts may fill the rest of the grid
+	AllowGhosts    bool            // Fill empty grid slots with ghosts once the matchmaking timeout runs out
+	RakePercentage decimal.Decimal // Percentage of the buy-ins kept before the prize pool is paid out
+}
+
+// LeagueRegistry holds the definition of every league, cheapest first.
+// A nil registry holds the launch leagues returned by DefaultLeagues.
+type LeagueRegistry struct {
+	leagues []League
+}
+
+// defaultLeagues holds the launch leagues
+var defaultLeagues = &LeagueRegistry{leagues: []League{
+	newDefaultLeague(LeagueRookie, 10),    // 10 FUEL
+	newDefaultLeague(LeagueStreet, 50),    // 50 FUEL
+	newDefaultLeague(LeaguePro, 300),      // 300 FUEL
+	newDefaultLeague(LeagueTopFuel, 3000), // 3000 FUEL
+}}
+
+// newDefaultLeague returns a launch league: ghost-filled from 2 live players with an 8% rake
+func newDefaultLeague(name string, buyin int64) League {
+	return League{
+		Name:           name,
+		Buyin:          decimal.NewFromInt(buyin),
+		MinLivePlayers: 2,
+		AllowGhosts:    true,
+		RakePercentage: decimal.NewFromInt(8),
+	}
+}
+
+// DefaultLeagues returns the registry of the launch leagues
+func DefaultLeagues() *LeagueRegistry {
+	return defaultLeagues
+}
+
+// NewLeagueRegistry creates a registry from a definition of every valid league.
+// Buy-ins must be positive, a ghost-filled grid needs 1-10 live players, the rake must be
+// at least 0 and below 100, and money values have at most 2 decimal places.
+func NewLeagueRegistry(leagues ...League) (*LeagueRegistry, error) {
+	seen := make(map[string]bool, len(leagues))
+	for _, league := range leagues {
+		switch {
+		case !IsValidLeague(league.Name):
+			return nil, fmt.Errorf("%w: unknown league %q", ErrInvalidLeague, league.Name)
+		case seen[league.Name]:
+			return nil, fmt.Errorf("%w: %s is defined twice", ErrInvalidLeague, league.Name)
+		case !league.Buyin.IsPositive() || !league.Buyin.Equal(league.Buyin.Truncate(2)):
+			return nil, fmt.Errorf("%w: %s buy-in must be positive with at most 2 decimal places, got %s", ErrInvalidLeague, league.Name, league.Buyin)
+		case league.MinLivePlayers < 1 || league.MinLivePlayers > gridSize:
+			return nil, fmt.Errorf("%w: %s min live players must be between 1 and %d, got %d", ErrInvalidLeague, league.Name, gridSize, league.MinLivePlayers)
+		case league.RakePercentage.IsNegative() || league.RakePercentage.GreaterThanOrEqual(decimal.NewFromInt(100)) ||
+			!league.RakePercentage.Equal(league.RakePercentage.Truncate(2)):
+			return nil, fmt.Errorf("%w: %s rake must be at least 0 and below 100 with at most 2 decimal places, got %s", ErrInvalidLeague, league.Name, league.RakePercentage)
+		}
+		seen[league.Name] = true
+	}
+	for _, name := range ValidLeagues() {
+		if !seen[name] {
+			return nil, fmt.Errorf("%w: %s is not defined", ErrInvalidLeague, name)
+		}
+	}
+
+	sorted := append([]League(nil), leagues...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Buyin.LessThan(sorted[j].Buyin)
+	})
+	return &LeagueRegistry{leagues: sorted}, nil
+}
+
+// All returns every league, cheapest first
+func (r *LeagueRegistry) All() []League {
+	if r == nil {
+		r = defaultLeagues
+	}
+	return append([]League(nil), r.leagues...)
+}
+
+// Get returns the definition of a league
+func (r *LeagueRegistry) Get(name string) (League, bool) {
+	if r == nil {
+		r = defaultLeagues
+	}
+	for _, league := range r.leagues {
+		if league.Name == name {
+			return league, true
+		}
+	}
+	return League{}, false
+}
+
+// Buyin returns the buy-in amount for a league
+func (r *LeagueRegistry) Buyin(name string) (decimal.Decimal, bool) {
+	league, exists := r.Get(name)
+	return league.Buyin, exists
 }
 
 // ValidLeagues returns a slice of all valid league names
@@ -37,9 +141,3 @@ func IsValidLeague(league string) bool {
 		return false
 	}
 }
-
-// GetLeagueBuyin returns the buy-in amount for a league
-func GetLeagueBuyin(league string) (decimal.Decimal, bool) {
-	buyin, exists := LeagueBuyins[league]
-	return buyin, exists
-}
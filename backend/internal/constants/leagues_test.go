@@ -0,0 +1,112 @@
+package constants
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultLeagues(t *testing.T) {
+	want := map[string]int64{
+		LeagueRookie:  10,
+		LeagueStreet:  50,
+		LeaguePro:     300,
+		LeagueTopFuel: 3000,
+	}
+
+	leagues := DefaultLeagues().All()
+	require.Len(t, leagues, len(ValidLeagues()))
+	for _, league := range leagues {
+		assert.True(t, league.Buyin.Equal(decimal.NewFromInt(want[league.Name])), league.Name)
+		assert.Equal(t, 2, league.MinLivePlayers, league.Name)
+		assert.True(t, league.AllowGhosts, league.Name)
+		assert.Equal(t, "8.00", league.RakePercentage.StringFixed(2), league.Name)
+	}
+
+	// The defaults pass the registry's own validation
+	_, err := NewLeagueRegistry(leagues...)
+	assert.NoError(t, err)
+}
+
+func TestNewLeagueRegistry_ListsCheapestFirst(t *testing.T) {
+	leagues := DefaultLeagues().All()
+	// Reverse the definitions and make STREET the priciest league
+	for i, j := 0, len(leagues)-1; i < j; i, j = i+1, j-1 {
+		leagues[i], leagues[j] = leagues[j], leagues[i]
+	}
+	for i := range leagues {
+		if leagues[i].Name == LeagueStreet {
+			leagues[i].Buyin = decimal.NewFromInt(5000)
+		}
+	}
+
+	registry, err := NewLeagueRegistry(leagues...)
+	require.NoError(t, err)
+
+	var names []string
+	for _, league := range registry.All() {
+		names = append(names, league.Name)
+	}
+	assert.Equal(t, []string{LeagueRookie, LeaguePro, LeagueTopFuel, LeagueStreet}, names)
+
+	buyin, ok := registry.Buyin(LeagueStreet)
+	require.True(t, ok)
+	assert.True(t, buyin.Equal(decimal.NewFromInt(5000)))
+	_, ok = registry.Buyin("GOLD")
+	assert.False(t, ok)
+}
+
+func TestNewLeagueRegistry_RejectsInvalidDefinitions(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(leagues []League) []League
+	}{
+		{name: "missing league", mutate: func(leagues []League) []League { return leagues[1:] }},
+		{name: "unknown league", mutate: func(leagues []League) []League {
+			return append(leagues, League{Name: "GOLD", Buyin: decimal.NewFromInt(20), MinLivePlayers: 2})
+		}},
+		{name: "duplicate league", mutate: func(leagues []League) []League { return append(leagues, leagues[0]) }},
+		{name: "zero buy-in", mutate: func(leagues []League) []League {
+			leagues[0].Buyin = decimal.Zero
+			return leagues
+		}},
+		{name: "sub-cent buy-in", mutate: func(leagues []League) []League {
+			leagues[0].Buyin = decimal.RequireFromString("10.001")
+			return leagues
+		}},
+		{name: "no live players", mutate: func(leagues []League) []League {
+			leagues[1].MinLivePlayers = 0
+			return leagues
+		}},
+		{name: "more live players than grid slots", mutate: func(leagues []League) []League {
+			leagues[1].MinLivePlayers = 11
+			return leagues
+		}},
+		{name: "full rake", mutate: func(leagues []League) []League {
+			leagues[2].RakePercentage = decimal.NewFromInt(100)
+			return leagues
+		}},
+		{name: "negative rake", mutate: func(leagues []League) []League {
+			leagues[2].RakePercentage = decimal.NewFromInt(-1)
+			return leagues
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLeagueRegistry(tt.mutate(DefaultLeagues().All())...)
+			assert.ErrorIs(t, err, ErrInvalidLeague)
+		})
+	}
+}
+
+func TestLeagueRegistry_NilHoldsDefaults(t *testing.T) {
+	var registry *LeagueRegistry
+
+	assert.Equal(t, DefaultLeagues().All(), registry.All())
+	buyin, ok := registry.Buyin(LeagueTopFuel)
+	require.True(t, ok)
+	assert.True(t, buyin.Equal(decimal.NewFromInt(3000)))
+}
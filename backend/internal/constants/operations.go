@@ -8,7 +8,11 @@ const (
 	OperationMatchPrize      = "MATCH_PRIZE"
 	OperationMatchRake       = "MATCH_RAKE"
 	OperationMatchBurnReward = "MATCH_BURN_REWARD"
+	OperationMatchRefund     = "MATCH_REFUND"
 	OperationInitialBalance  = "INITIAL_BALANCE"
+	OperationSignupGrant     = "SIGNUP_GRANT"
+	OperationReferralBonus   = "REFERRAL_BONUS"
+	OperationAdminAdjustment = "ADMIN_ADJUSTMENT"
 )
 
 // ValidOperationTypes returns a slice of all valid operation types
@@ -20,7 +24,11 @@ func ValidOperationTypes() []string {
 		OperationMatchPrize,
 		OperationMatchRake,
 		OperationMatchBurnReward,
+		OperationMatchRefund,
 		OperationInitialBalance,
+		OperationSignupGrant,
+		OperationReferralBonus,
+		OperationAdminAdjustment,
 	}
 }
 
@@ -29,7 +37,8 @@ func IsValidOperationType(operationType string) bool {
 	switch operationType {
 	case OperationDeposit, OperationWithdrawal, OperationMatchBuyin,
 		OperationMatchPrize, OperationMatchRake, OperationMatchBurnReward,
-		OperationInitialBalance:
+		OperationMatchRefund, OperationInitialBalance, OperationSignupGrant,
+		OperationReferralBonus, OperationAdminAdjustment:
 		return true
 	default:
 		return false
@@ -2,14 +2,55 @@ package decimal
 
 import (
 	"database/sql/driver"
+	"errors"
 	"fmt"
 
 	"github.com/shopspring/decimal"
 )
 
 // All monetary values in the system use fixed-point decimal arithmetic
-// with 2 decimal places and ALWAYS round down (floor) to prevent
-// accumulation of rounding errors in the economy.
+// with 2 decimal places.
+//
+// Rounding defaults to RoundDown (truncate toward zero) to prevent the economy
+// from ever paying out more than it holds. ToMonetary, ToMonetaryString, Add,
+// Sub, Mul, Div, TryDiv, Percentage and SumMonetary accept an optional
+// RoundingMode so callers that must not bias results downward can opt into
+// RoundHalfEven. DivRoundDown and the economy helpers (CalculateRake,
+// CalculatePrizePool and CalculatePrizes) always round down.
+
+// ErrDivByZero is returned by TryDiv when the denominator is zero
+var ErrDivByZero = errors.New("division by zero")
+
+// RoundingMode selects how results are rounded to 2 decimal places
+type RoundingMode int
+
+const (
+	// RoundDown truncates toward zero (the default)
+	RoundDown RoundingMode = iota
+
+	// RoundHalfEven rounds to the nearest cent, with ties going to the even cent (banker's rounding)
+	RoundHalfEven
+)
+
+// String returns the name of the rounding mode
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundDown:
+		return "down"
+	case RoundHalfEven:
+		return "half_even"
+	default:
+		return fmt.Sprintf("RoundingMode(%d)", int(m))
+	}
+}
+
+// round rounds d to 2 decimal places using the first given mode, or RoundDown if none
+func round(d decimal.Decimal, mode []RoundingMode) decimal.Decimal {
+	if len(mode) > 0 && mode[0] == RoundHalfEven {
+		return d.RoundBank(2)
+	}
+	return d.Truncate(2)
+}
 
 // Zero represents decimal zero
 var Zero = decimal.Zero
@@ -42,41 +83,43 @@ func MustFromString(value string) decimal.Decimal {
 	return d
 }
 
-// ToMonetary converts a decimal to monetary format (2 decimal places, rounded down)
-func ToMonetary(d decimal.Decimal) decimal.Decimal {
-	// Always round down (floor) to 2 decimal places
-	return d.Truncate(2)
+// ToMonetary converts a decimal to monetary format (2 decimal places, rounded down by default)
+func ToMonetary(d decimal.Decimal, mode ...RoundingMode) decimal.Decimal {
+	return round(d, mode)
 }
 
 // ToMonetaryString converts a decimal to monetary string format
-func ToMonetaryString(d decimal.Decimal) string {
-	return ToMonetary(d).StringFixed(2)
+func ToMonetaryString(d decimal.Decimal, mode ...RoundingMode) string {
+	return ToMonetary(d, mode...).StringFixed(2)
 }
 
-// Add performs monetary addition (result rounded down to 2 decimal places)
-func Add(a, b decimal.Decimal) decimal.Decimal {
-	return ToMonetary(a.Add(b))
+// Add performs monetary addition (result rounded to 2 decimal places, down by default)
+func Add(a, b decimal.Decimal, mode ...RoundingMode) decimal.Decimal {
+	return ToMonetary(a.Add(b), mode...)
 }
 
-// Sub performs monetary subtraction (result rounded down to 2 decimal places)
-func Sub(a, b decimal.Decimal) decimal.Decimal {
-	return ToMonetary(a.Sub(b))
+// Sub performs monetary subtraction (result rounded to 2 decimal places, down by default)
+func Sub(a, b decimal.Decimal, mode ...RoundingMode) decimal.Decimal {
+	return ToMonetary(a.Sub(b), mode...)
 }
 
-// Mul performs monetary multiplication (result rounded down to 2 decimal places)
-func Mul(a, b decimal.Decimal) decimal.Decimal {
-	return ToMonetary(a.Mul(b))
+// Mul performs monetary multiplication (result rounded to 2 decimal places, down by default)
+func Mul(a, b decimal.Decimal, mode ...RoundingMode) decimal.Decimal {
+	return ToMonetary(a.Mul(b), mode...)
 }
 
-// Div performs monetary division (result rounded down to 2 decimal places)
-func Div(a, b decimal.Decimal) decimal.Decimal {
+// Div performs monetary division (result rounded to 2 decimal places, down by default).
+// It panics on a zero denominator, so use it only with constant denominators and
+// TryDiv for anything derived from input or data.
+func Div(a, b decimal.Decimal, mode ...RoundingMode) decimal.Decimal {
 	if b.IsZero() {
 		panic("division by zero")
 	}
-	return ToMonetary(a.Div(b))
+	return ToMonetary(a.Div(b), mode...)
 }
 
-// DivRoundDown performs division with explicit round down
+// DivRoundDown performs division with explicit round down.
+// Like Div, it panics on a zero denominator.
 func DivRoundDown(a, b decimal.Decimal) decimal.Decimal {
 	if b.IsZero() {
 		panic("division by zero")
@@ -84,9 +127,18 @@ func DivRoundDown(a, b decimal.Decimal) decimal.Decimal {
 	return a.Div(b).Truncate(2)
 }
 
-// Percentage calculates percentage of a value (rounded down)
-func Percentage(value decimal.Decimal, percent decimal.Decimal) decimal.Decimal {
-	return ToMonetary(value.Mul(percent).Div(decimal.NewFromInt(100)))
+// TryDiv performs monetary division like Div, but returns ErrDivByZero instead of
+// panicking when the denominator is zero
+func TryDiv(a, b decimal.Decimal, mode ...RoundingMode) (decimal.Decimal, error) {
+	if b.IsZero() {
+		return decimal.Zero, fmt.Errorf("%w: %s / %s", ErrDivByZero, a.String(), b.String())
+	}
+	return ToMonetary(a.Div(b), mode...), nil
+}
+
+// Percentage calculates percentage of a value (rounded down by default)
+func Percentage(value decimal.Decimal, percent decimal.Decimal, mode ...RoundingMode) decimal.Decimal {
+	return ToMonetary(value.Mul(percent).Div(decimal.NewFromInt(100)), mode...)
 }
 
 // IsPositive returns true if decimal is greater than zero
@@ -125,53 +177,78 @@ func Abs(d decimal.Decimal) decimal.Decimal {
 	return d.Abs()
 }
 
-// SumMonetary sums a slice of decimals with monetary rounding
-func SumMonetary(values []decimal.Decimal) decimal.Decimal {
+// SumMonetary sums a slice of decimals with monetary rounding (down by default)
+func SumMonetary(values []decimal.Decimal, mode ...RoundingMode) decimal.Decimal {
 	sum := decimal.Zero
 	for _, v := range values {
 		sum = sum.Add(v)
 	}
-	return ToMonetary(sum)
-}
-
-// League buy-in amounts (constants)
-var (
-	RookieBuyIn  = MustFromString("10.00")   // 10 FUEL
-	StreetBuyIn  = MustFromString("50.00")   // 50 FUEL
-	ProBuyIn     = MustFromString("300.00")  // 300 FUEL
-	TopFuelBuyIn = MustFromString("3000.00") // 3000 FUEL
-)
-
-// GetLeagueBuyIn returns the buy-in amount for a league
-func GetLeagueBuyIn(league string) (decimal.Decimal, error) {
-	switch league {
-	case "ROOKIE":
-		return RookieBuyIn, nil
-	case "STREET":
-		return StreetBuyIn, nil
-	case "PRO":
-		return ProBuyIn, nil
-	case "TOP_FUEL":
-		return TopFuelBuyIn, nil
-	default:
-		return decimal.Zero, fmt.Errorf("unknown league: %s", league)
-	}
+	return ToMonetary(sum, mode...)
 }
 
-// Rake percentage (8%)
+// Default rake percentage (8%)
 var RakePercentage = MustFromString("8.00")
 
-// CalculateRake calculates 8% rake from total buy-ins
+// CalculateRake calculates the default 8% rake from total buy-ins
 func CalculateRake(totalBuyins decimal.Decimal) decimal.Decimal {
-	return Percentage(totalBuyins, RakePercentage)
+	return CalculateRakeAt(totalBuyins, RakePercentage)
 }
 
-// CalculatePrizePool calculates prize pool after rake deduction
+// CalculatePrizePool calculates prize pool after the default rake deduction
 func CalculatePrizePool(totalBuyins decimal.Decimal) decimal.Decimal {
-	rake := CalculateRake(totalBuyins)
+	return CalculatePrizePoolAt(totalBuyins, RakePercentage)
+}
+
+// CalculateRakeAt calculates the rake at the given percentage from total buy-ins
+func CalculateRakeAt(totalBuyins, rakePercentage decimal.Decimal) decimal.Decimal {
+	return Percentage(totalBuyins, rakePercentage)
+}
+
+// CalculatePrizePoolAt calculates prize pool after deducting rake at the given percentage
+func CalculatePrizePoolAt(totalBuyins, rakePercentage decimal.Decimal) decimal.Decimal {
+	rake := CalculateRakeAt(totalBuyins, rakePercentage)
 	return Sub(totalBuyins, rake)
 }
 
+// RakeRates holds the rake percentage charged in each league, so promotions can
+// lower the rake without code changes
+type RakeRates struct {
+	defaultRate decimal.Decimal
+	leagueRates map[string]decimal.Decimal
+}
+
+// NewRakeRates creates rake rates charging defaultRate in every league without its own rate
+func NewRakeRates(defaultRate decimal.Decimal, leagueRates map[string]decimal.Decimal) *RakeRates {
+	rates := make(map[string]decimal.Decimal, len(leagueRates))
+	for league, rate := range leagueRates {
+		rates[league] = rate
+	}
+	return &RakeRates{defaultRate: defaultRate, leagueRates: rates}
+}
+
+// ForLeague returns the rake percentage charged in a league.
+// Nil rates charge the default RakePercentage.
+func (r *RakeRates) ForLeague(league string) decimal.Decimal {
+	if r == nil {
+		return RakePercentage
+	}
+	if rate, ok := r.leagueRates[league]; ok {
+		return rate
+	}
+	return r.defaultRate
+}
+
+// ValidateRakePercentage validates that a rake percentage is a whole number of hundredths in [0, 100)
+func ValidateRakePercentage(d decimal.Decimal) error {
+	if d.IsNegative() || d.GreaterThanOrEqual(decimal.NewFromInt(100)) {
+		return fmt.Errorf("rake percentage must be at least 0 and below 100: %s", d.String())
+	}
+	if !d.Equal(d.Truncate(2)) {
+		return fmt.Errorf("rake percentage cannot have more than 2 decimal places: %s", d.String())
+	}
+	return nil
+}
+
 // Prize distribution percentages (after rake)
 var (
 	FirstPlacePct  = MustFromString("50.00") // 50% of prize pool
@@ -255,3 +332,44 @@ func NewNullDecimalFromString(s string) (NullDecimal, error) {
 
 	return NewNullDecimal(d), nil
 }
+
+// Money is a monetary amount that always marshals to JSON as a fixed
+// 2-decimal string (e.g. "50.00"), so clients see consistent formatting
+type Money struct {
+	decimal.Decimal
+}
+
+// NewMoney wraps a decimal as Money
+func NewMoney(d decimal.Decimal) Money {
+	return Money{Decimal: d}
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + m.StringFixed(2) + `"`), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Only quoted decimal
+// strings with at most 2 decimal places are accepted; JSON numbers are rejected
+// because they may already have lost precision in the client.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("monetary amount must be a JSON string: %s", data)
+	}
+
+	d, err := decimal.NewFromString(string(data[1 : len(data)-1]))
+	if err != nil {
+		return fmt.Errorf("invalid monetary amount: %s", data)
+	}
+
+	if d.Exponent() < -2 {
+		return fmt.Errorf("monetary amount cannot have more than 2 decimal places: %s", data)
+	}
+
+	m.Decimal = d
+	return nil
+}
@@ -0,0 +1,168 @@
+package decimal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToMonetary_RoundingModes(t *testing.T) {
+	tests := []struct {
+		value    string
+		down     string
+		halfEven string
+	}{
+		{value: "0.125", down: "0.12", halfEven: "0.12"},
+		{value: "0.135", down: "0.13", halfEven: "0.14"},
+		{value: "0.126", down: "0.12", halfEven: "0.13"},
+		{value: "0.124", down: "0.12", halfEven: "0.12"},
+		{value: "2.675", down: "2.67", halfEven: "2.68"},
+		{value: "-0.125", down: "-0.12", halfEven: "-0.12"},
+		{value: "-0.135", down: "-0.13", halfEven: "-0.14"},
+		{value: "10", down: "10.00", halfEven: "10.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			value := MustFromString(tt.value)
+
+			assert.Equal(t, tt.down, ToMonetaryString(value))
+			assert.Equal(t, tt.down, ToMonetaryString(value, RoundDown))
+			assert.Equal(t, tt.halfEven, ToMonetaryString(value, RoundHalfEven))
+		})
+	}
+}
+
+func TestArithmetic_RoundingModes(t *testing.T) {
+	a := MustFromString("0.1")
+	b := MustFromString("0.025")
+
+	// Defaults stay backward compatible
+	assert.True(t, MustFromString("0.12").Equal(Add(a, b)))
+	assert.True(t, MustFromString("0.07").Equal(Sub(a, b)))
+	assert.True(t, MustFromString("0.12").Equal(Add(a, b, RoundHalfEven)))
+	assert.True(t, MustFromString("0.08").Equal(Sub(a, b, RoundHalfEven)))
+
+	// 1/3 = 0.333..., 2/3 = 0.666...
+	assert.True(t, MustFromString("0.66").Equal(Div(NewFromInt(2), NewFromInt(3))))
+	assert.True(t, MustFromString("0.67").Equal(Div(NewFromInt(2), NewFromInt(3), RoundHalfEven)))
+	assert.True(t, MustFromString("0.66").Equal(DivRoundDown(NewFromInt(2), NewFromInt(3))))
+
+	// 0.5 * 0.25 = 0.125
+	assert.True(t, MustFromString("0.12").Equal(Mul(MustFromString("0.5"), MustFromString("0.25"))))
+	assert.True(t, MustFromString("0.12").Equal(Mul(MustFromString("0.5"), MustFromString("0.25"), RoundHalfEven)))
+
+	// 8% of 1.69 = 0.1352
+	assert.True(t, MustFromString("0.13").Equal(Percentage(MustFromString("1.69"), RakePercentage)))
+	assert.True(t, MustFromString("0.14").Equal(Percentage(MustFromString("1.69"), RakePercentage, RoundHalfEven)))
+
+	values := []decimal.Decimal{MustFromString("0.005"), MustFromString("0.01")}
+	assert.True(t, MustFromString("0.01").Equal(SumMonetary(values)))
+	assert.True(t, MustFromString("0.02").Equal(SumMonetary(values, RoundHalfEven)))
+}
+
+func TestHalfEven_ReducesDownwardBias(t *testing.T) {
+	// Splitting a pool of 100 into thirds many times: floor always loses a cent per split
+	var exactTotal, downTotal, halfEvenTotal decimal.Decimal
+	for i := 1; i <= 300; i++ {
+		value := NewFromInt(int64(i)).Div(NewFromInt(3))
+		exactTotal = exactTotal.Add(value)
+		downTotal = downTotal.Add(ToMonetary(value))
+		halfEvenTotal = halfEvenTotal.Add(ToMonetary(value, RoundHalfEven))
+	}
+
+	downError := exactTotal.Sub(downTotal).Abs()
+	halfEvenError := exactTotal.Sub(halfEvenTotal).Abs()
+	assert.True(t, halfEvenError.LessThan(downError), "half-even error %s should be below floor error %s", halfEvenError, downError)
+}
+
+func TestRoundingMode_String(t *testing.T) {
+	assert.Equal(t, "down", RoundDown.String())
+	assert.Equal(t, "half_even", RoundHalfEven.String())
+}
+
+func TestTryDiv(t *testing.T) {
+	// An empty match splitting a pool by zero players
+	result, err := TryDiv(MustFromString("92.00"), Zero)
+	assert.ErrorIs(t, err, ErrDivByZero)
+	assert.True(t, result.IsZero())
+
+	result, err = TryDiv(MustFromString("92.00"), NewFromInt(3))
+	require.NoError(t, err)
+	assert.True(t, MustFromString("30.66").Equal(result))
+
+	result, err = TryDiv(MustFromString("92.00"), NewFromInt(3), RoundHalfEven)
+	require.NoError(t, err)
+	assert.True(t, MustFromString("30.67").Equal(result))
+
+	result, err = TryDiv(MustFromString("-10"), NewFromInt(4))
+	require.NoError(t, err)
+	assert.True(t, MustFromString("-2.50").Equal(result))
+}
+
+func TestDiv_PanicsOnZero(t *testing.T) {
+	assert.Panics(t, func() { Div(NewFromInt(1), Zero) })
+	assert.Panics(t, func() { DivRoundDown(NewFromInt(1), Zero) })
+}
+
+func TestMoney_MarshalsFixedString(t *testing.T) {
+	data, err := json.Marshal(NewMoney(MustFromString("50")))
+	require.NoError(t, err)
+	assert.Equal(t, `"50.00"`, string(data))
+
+	data, err = json.Marshal(struct {
+		Amount Money `json:"amount"`
+	}{Amount: NewMoney(MustFromString("-0.5"))})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"-0.50"}`, string(data))
+}
+
+func TestMoney_RoundTrips(t *testing.T) {
+	for _, value := range []string{"50", "0.01", "1234.5", "-12.34", "0"} {
+		original := NewMoney(MustFromString(value))
+
+		data, err := json.Marshal(original)
+		require.NoError(t, err)
+
+		var decoded Money
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.True(t, original.Equal(decoded.Decimal), value)
+	}
+}
+
+func TestMoney_UnmarshalIsStrict(t *testing.T) {
+	for _, input := range []string{`50`, `"abc"`, `""`, `"0.125"`, `true`} {
+		var m Money
+		assert.Error(t, json.Unmarshal([]byte(input), &m), input)
+	}
+
+	var m Money
+	require.NoError(t, json.Unmarshal([]byte(`"12.30"`), &m))
+	assert.True(t, MustFromString("12.3").Equal(m.Decimal))
+}
+
+func TestRakeRates_PerLeague(t *testing.T) {
+	rates := NewRakeRates(RakePercentage, map[string]decimal.Decimal{"ROOKIE": MustFromString("5.00")})
+
+	// 10 ROOKIE buy-ins of 10 FUEL at a 5% promotion
+	total := NewFromInt(100)
+	rookieRate := rates.ForLeague("ROOKIE")
+	assert.Equal(t, "5.00", CalculateRakeAt(total, rookieRate).StringFixed(2))
+	assert.Equal(t, "95.00", CalculatePrizePoolAt(total, rookieRate).StringFixed(2))
+
+	// Leagues without their own rate keep the default
+	assert.Equal(t, "8.00", CalculateRakeAt(total, rates.ForLeague("PRO")).StringFixed(2))
+	var none *RakeRates
+	assert.True(t, RakePercentage.Equal(none.ForLeague("ROOKIE")))
+}
+
+func TestValidateRakePercentage(t *testing.T) {
+	assert.NoError(t, ValidateRakePercentage(MustFromString("0")))
+	assert.NoError(t, ValidateRakePercentage(MustFromString("5.50")))
+	assert.Error(t, ValidateRakePercentage(MustFromString("-1")))
+	assert.Error(t, ValidateRakePercentage(MustFromString("100")))
+	assert.Error(t, ValidateRakePercentage(MustFromString("2.125")))
+}
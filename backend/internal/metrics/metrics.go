@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"database/sql"
 	"net/http"
 	"time"
 
@@ -41,10 +42,44 @@ type Metrics struct {
 	// Settlement metrics
 	SettlementDuration *prometheus.HistogramVec
 	SettlementErrors   *prometheus.CounterVec
+
+	// Ledger metrics
+	LedgerOperationsTotal   *prometheus.CounterVec
+	LedgerOperationDuration *prometheus.HistogramVec
+
+	// Realtime metrics
+	RealtimeEventsDropped     *prometheus.CounterVec
+	RealtimePublishFailures   *prometheus.CounterVec
+	RealtimePublishQueueDepth prometheus.Gauge
+
+	// Database connection pool metrics
+	DBMaxOpenConnections *prometheus.GaugeVec
+	DBOpenConnections    *prometheus.GaugeVec
+	DBInUseConnections   *prometheus.GaugeVec
+	DBIdleConnections    *prometheus.GaugeVec
+	DBWaitCount          *prometheus.GaugeVec
+	DBWaitDuration       *prometheus.GaugeVec
 }
 
-// New creates a new Metrics instance with all metrics registered
+// Ledger operation outcomes used as the status label
+const (
+	LedgerStatusSuccess = "success"
+	LedgerStatusError   = "error"
+)
+
+// Reasons a realtime event is dropped before delivery, used as the reason label
+const (
+	RealtimeDropQueueFull        = "queue_full"
+	RealtimeDropRetriesExhausted = "retries_exhausted"
+)
+
+// New creates a new Metrics instance with all metrics registered on the default registry
 func New() *Metrics {
+	return NewWithRegistry(prometheus.DefaultRegisterer)
+}
+
+// NewWithRegistry creates a new Metrics instance with all metrics registered on reg
+func NewWithRegistry(reg prometheus.Registerer) *Metrics {
 	m := &Metrics{
 		// HTTP metrics
 		HTTPRequestsTotal: prometheus.NewCounterVec(
@@ -124,7 +159,7 @@ func New() *Metrics {
 		MatchDuration: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "match_duration_seconds",
-				Help:    "Duration of matches from start to settlement",
+				Help:    "Duration of matches from start to settlement or abort",
 				Buckets: []float64{60, 120, 180, 240, 300, 360, 420, 480, 600},
 			},
 			[]string{"league"},
@@ -198,10 +233,93 @@ func New() *Metrics {
 			},
 			[]string{"league", "error_type"},
 		),
+
+		// Ledger metrics
+		LedgerOperationsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "ledger_operations_total",
+				Help: "Total number of ledger operations by outcome",
+			},
+			[]string{"operation", "currency", "status"},
+		),
+		LedgerOperationDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "ledger_operation_duration_seconds",
+				Help:    "Duration of ledger operations in seconds",
+				Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0},
+			},
+			[]string{"operation", "currency"},
+		),
+
+		// Realtime metrics
+		RealtimeEventsDropped: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "realtime_events_dropped_total",
+				Help: "Total number of realtime events dropped before delivery",
+			},
+			[]string{"event_type", "reason"},
+		),
+		RealtimePublishFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "realtime_publish_failures_total",
+				Help: "Total number of failed realtime event deliveries to Centrifugo",
+			},
+			[]string{"event_type"},
+		),
+		RealtimePublishQueueDepth: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "realtime_publish_queue_depth",
+				Help: "Number of realtime events waiting for delivery",
+			},
+		),
+
+		// Database connection pool metrics
+		DBMaxOpenConnections: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "db_max_open_connections",
+				Help: "Maximum number of open connections to the database",
+			},
+			[]string{"pool"},
+		),
+		DBOpenConnections: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "db_open_connections",
+				Help: "Number of established connections, both in use and idle",
+			},
+			[]string{"pool"},
+		),
+		DBInUseConnections: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "db_in_use_connections",
+				Help: "Number of connections currently in use",
+			},
+			[]string{"pool"},
+		),
+		DBIdleConnections: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "db_idle_connections",
+				Help: "Number of idle connections",
+			},
+			[]string{"pool"},
+		),
+		DBWaitCount: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "db_wait_count",
+				Help: "Total number of connections waited for because the pool was exhausted",
+			},
+			[]string{"pool"},
+		),
+		DBWaitDuration: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "db_wait_duration_seconds",
+				Help: "Total time spent waiting for a connection from the exhausted pool",
+			},
+			[]string{"pool"},
+		),
 	}
 
 	// Register all metrics
-	prometheus.MustRegister(
+	reg.MustRegister(
 		m.HTTPRequestsTotal,
 		m.HTTPRequestDuration,
 		m.HTTPRequestsInFlight,
@@ -222,6 +340,17 @@ func New() *Metrics {
 		m.TonCenterErrors,
 		m.SettlementDuration,
 		m.SettlementErrors,
+		m.LedgerOperationsTotal,
+		m.LedgerOperationDuration,
+		m.RealtimeEventsDropped,
+		m.RealtimePublishFailures,
+		m.RealtimePublishQueueDepth,
+		m.DBMaxOpenConnections,
+		m.DBOpenConnections,
+		m.DBInUseConnections,
+		m.DBIdleConnections,
+		m.DBWaitCount,
+		m.DBWaitDuration,
 	)
 
 	return m
@@ -264,7 +393,7 @@ func (m *Metrics) SetActiveMatches(count float64) {
 	m.ActiveMatches.Set(count)
 }
 
-// RecordMatchDuration records the duration of a completed match
+// RecordMatchDuration records the duration of a completed or aborted match
 func (m *Metrics) RecordMatchDuration(league string, duration time.Duration) {
 	m.MatchDuration.WithLabelValues(league).Observe(duration.Seconds())
 }
@@ -309,3 +438,34 @@ func (m *Metrics) RecordSettlementDuration(league string, duration time.Duration
 func (m *Metrics) RecordSettlementError(league, errorType string) {
 	m.SettlementErrors.WithLabelValues(league, errorType).Inc()
 }
+
+// RecordLedgerOperation records the outcome and duration of a ledger operation
+func (m *Metrics) RecordLedgerOperation(operation, currency, status string, duration time.Duration) {
+	m.LedgerOperationsTotal.WithLabelValues(operation, currency, status).Inc()
+	m.LedgerOperationDuration.WithLabelValues(operation, currency).Observe(duration.Seconds())
+}
+
+// RecordRealtimeEventDropped records a realtime event dropped before delivery
+func (m *Metrics) RecordRealtimeEventDropped(eventType, reason string) {
+	m.RealtimeEventsDropped.WithLabelValues(eventType, reason).Inc()
+}
+
+// RecordRealtimePublishFailure records a failed realtime event delivery
+func (m *Metrics) RecordRealtimePublishFailure(eventType string) {
+	m.RealtimePublishFailures.WithLabelValues(eventType).Inc()
+}
+
+// SetRealtimePublishQueueDepth sets the number of realtime events waiting for delivery
+func (m *Metrics) SetRealtimePublishQueueDepth(depth float64) {
+	m.RealtimePublishQueueDepth.Set(depth)
+}
+
+// SetDBStats sets the connection pool statistics of a database pool
+func (m *Metrics) SetDBStats(pool string, stats sql.DBStats) {
+	m.DBMaxOpenConnections.WithLabelValues(pool).Set(float64(stats.MaxOpenConnections))
+	m.DBOpenConnections.WithLabelValues(pool).Set(float64(stats.OpenConnections))
+	m.DBInUseConnections.WithLabelValues(pool).Set(float64(stats.InUse))
+	m.DBIdleConnections.WithLabelValues(pool).Set(float64(stats.Idle))
+	m.DBWaitCount.WithLabelValues(pool).Set(float64(stats.WaitCount))
+	m.DBWaitDuration.WithLabelValues(pool).Set(stats.WaitDuration.Seconds())
+}
@@ -0,0 +1,22 @@
+package account
+
+import (
+	"errors"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// Account errors returned by the account service and ledger operations
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrInvalidAmount       = errors.New("invalid amount")
+
+	// ErrUnsupportedCurrency is returned for currencies other than TON, FUEL and BURN
+	ErrUnsupportedCurrency = repository.ErrUnsupportedCurrency
+
+	// ErrWalletNotFound is returned when a user has no wallet
+	ErrWalletNotFound = repository.ErrWalletNotFound
+
+	// ErrNegativeBalance is returned when a balance update would leave a wallet below zero
+	ErrNegativeBalance = repository.ErrNegativeBalance
+)
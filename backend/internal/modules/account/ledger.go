@@ -2,6 +2,8 @@ package account
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/metrics"
 	"github.com/megaherz/ndr/internal/storage/postgres/models"
 	"github.com/megaherz/ndr/internal/storage/postgres/repository"
 )
@@ -34,37 +37,119 @@ type LedgerOperations interface {
 	// RecordEntry records a generic ledger entry
 	RecordEntry(ctx context.Context, entry *models.LedgerEntry) error
 
-	// RecordMatchEntries records multiple ledger entries for a match atomically
+	// RecordMatchEntries records multiple ledger entries for a match and their wallet balance
+	// updates in one transaction
 	RecordMatchEntries(ctx context.Context, entries []*models.LedgerEntry) error
 
+	// Transfer moves an amount of any supported currency between users as a double entry
+	Transfer(ctx context.Context, fromUserID, toUserID uuid.UUID, currency string, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) error
+
 	// TransferFuel transfers FUEL between users
 	TransferFuel(ctx context.Context, fromUserID, toUserID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) error
+
+	// DebitFuelWithBalance debits FUEL like DebitFuel and returns the FUEL balance the same update left
+	DebitFuelWithBalance(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (decimal.Decimal, error)
+
+	// CreditFuelWithBalance credits FUEL like CreditFuel and returns the FUEL balance the same update left
+	CreditFuelWithBalance(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (decimal.Decimal, error)
+
+	// AdjustFuel credits (positive amount) or debits (negative amount) a user's FUEL as an
+	// ADMIN_ADJUSTMENT and writes audit in the same transaction, returning the FUEL balance the
+	// update left. details builds the audit record's details from the updated wallet. It fails with
+	// ErrInsufficientBalance or ErrWalletNotFound, recording nothing, if a debit cannot be covered.
+	AdjustFuel(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, description string, audit *models.AdminAuditEntry, details func(*models.Wallet) (json.RawMessage, error)) (decimal.Decimal, error)
+
+	// RecordMatchEntriesWithBalances records match entries like RecordMatchEntries and returns
+	// each user's wallet as the same transaction left it
+	RecordMatchEntriesWithBalances(ctx context.Context, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error)
+
+	// RecordCoveredMatchEntries records match entries and their wallet balance updates in one
+	// transaction. It fails with ErrInsufficientBalance or ErrWalletNotFound, recording nothing,
+	// if a user cannot cover their debit.
+	RecordCoveredMatchEntries(ctx context.Context, entries []*models.LedgerEntry) error
+
+	// RecordMatchEntriesWithStatus records match entries like RecordMatchEntriesWithBalances and
+	// moves the match to change.To in the same transaction. It fails with
+	// repository.ErrMatchStatusChanged, recording nothing, if the match has left the change.From
+	// statuses, so only one of several concurrent writers of a match gets through.
+	RecordMatchEntriesWithStatus(ctx context.Context, change repository.MatchStatusChange, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error)
 }
 
+// Ledger operation labels reported to metrics
+const (
+	ledgerOpDebitFuel          = "debit_fuel"
+	ledgerOpCreditFuel         = "credit_fuel"
+	ledgerOpCreditBurn         = "credit_burn"
+	ledgerOpDebitSystemWallet  = "debit_system_wallet"
+	ledgerOpCreditSystemWallet = "credit_system_wallet"
+	ledgerOpRecordEntry        = "record_entry"
+	ledgerOpRecordMatchEntries = "record_match_entries"
+	ledgerOpTransfer           = "transfer"
+	ledgerOpAdjustFuel         = "adjust_fuel"
+
+	// ledgerCurrencyMixed labels batches that may span several currencies
+	ledgerCurrencyMixed = "MIXED"
+)
+
 // ledgerOperations implements LedgerOperations
 type ledgerOperations struct {
 	ledgerRepo repository.LedgerRepository
 	walletRepo repository.WalletRepository
+	metrics    *metrics.Metrics
 	logger     *logrus.Logger
 }
 
+// LedgerOperationsOption configures optional ledger operations behaviour
+type LedgerOperationsOption func(*ledgerOperations)
+
+// WithLedgerMetrics records the volume, latency and failures of every ledger operation
+func WithLedgerMetrics(m *metrics.Metrics) LedgerOperationsOption {
+	return func(l *ledgerOperations) {
+		l.metrics = m
+	}
+}
+
 // NewLedgerOperations creates a new ledger operations handler
 func NewLedgerOperations(
 	ledgerRepo repository.LedgerRepository,
 	walletRepo repository.WalletRepository,
 	logger *logrus.Logger,
+	opts ...LedgerOperationsOption,
 ) LedgerOperations {
-	return &ledgerOperations{
+	l := &ledgerOperations{
 		ledgerRepo: ledgerRepo,
 		walletRepo: walletRepo,
 		logger:     logger,
 	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
 }
 
 // DebitFuel debits FUEL from a user's account
-func (l *ledgerOperations) DebitFuel(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) error {
+func (l *ledgerOperations) DebitFuel(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (err error) {
+	defer l.observe(ledgerOpDebitFuel, constants.CurrencyFUEL, time.Now(), &err)
+
+	_, err = l.debitFuel(ctx, userID, amount, operationType, referenceID, description)
+	return err
+}
+
+// DebitFuelWithBalance debits FUEL from a user's account and returns the FUEL balance left by the same update
+func (l *ledgerOperations) DebitFuelWithBalance(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (balance decimal.Decimal, err error) {
+	defer l.observe(ledgerOpDebitFuel, constants.CurrencyFUEL, time.Now(), &err)
+
+	wallet, err := l.debitFuel(ctx, userID, amount, operationType, referenceID, description)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return wallet.FuelBalance, nil
+}
+
+// debitFuel records a FUEL debit and returns the wallet as the balance update left it
+func (l *ledgerOperations) debitFuel(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (*models.Wallet, error) {
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return fmt.Errorf("debit amount must be positive")
+		return nil, fmt.Errorf("%w: debit amount must be positive", ErrInvalidAmount)
 	}
 
 	// Create debit entry (negative amount)
@@ -85,7 +170,7 @@ func (l *ledgerOperations) DebitFuel(ctx context.Context, userID uuid.UUID, amou
 	}
 
 	// Record entry and update wallet balance
-	err := l.recordEntryAndUpdateBalance(ctx, entry)
+	wallet, err := l.recordEntryAndUpdateBalance(ctx, entry)
 	if err != nil {
 		l.logger.WithFields(logrus.Fields{
 			"user_id":        userID,
@@ -93,16 +178,35 @@ func (l *ledgerOperations) DebitFuel(ctx context.Context, userID uuid.UUID, amou
 			"operation_type": operationType,
 			"error":          err,
 		}).Error("Failed to debit FUEL")
-		return fmt.Errorf("failed to debit FUEL: %w", err)
+		return nil, fmt.Errorf("failed to debit FUEL: %w", err)
 	}
 
-	return nil
+	return wallet, nil
 }
 
 // CreditFuel credits FUEL to a user's account
-func (l *ledgerOperations) CreditFuel(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) error {
+func (l *ledgerOperations) CreditFuel(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (err error) {
+	defer l.observe(ledgerOpCreditFuel, constants.CurrencyFUEL, time.Now(), &err)
+
+	_, err = l.creditFuel(ctx, userID, amount, operationType, referenceID, description)
+	return err
+}
+
+// CreditFuelWithBalance credits FUEL to a user's account and returns the FUEL balance left by the same update
+func (l *ledgerOperations) CreditFuelWithBalance(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (balance decimal.Decimal, err error) {
+	defer l.observe(ledgerOpCreditFuel, constants.CurrencyFUEL, time.Now(), &err)
+
+	wallet, err := l.creditFuel(ctx, userID, amount, operationType, referenceID, description)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return wallet.FuelBalance, nil
+}
+
+// creditFuel records a FUEL credit and returns the wallet as the balance update left it
+func (l *ledgerOperations) creditFuel(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (*models.Wallet, error) {
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return fmt.Errorf("credit amount must be positive")
+		return nil, fmt.Errorf("%w: credit amount must be positive", ErrInvalidAmount)
 	}
 
 	// Create credit entry (positive amount)
@@ -123,7 +227,7 @@ func (l *ledgerOperations) CreditFuel(ctx context.Context, userID uuid.UUID, amo
 	}
 
 	// Record entry and update wallet balance
-	err := l.recordEntryAndUpdateBalance(ctx, entry)
+	wallet, err := l.recordEntryAndUpdateBalance(ctx, entry)
 	if err != nil {
 		l.logger.WithFields(logrus.Fields{
 			"user_id":        userID,
@@ -131,16 +235,56 @@ func (l *ledgerOperations) CreditFuel(ctx context.Context, userID uuid.UUID, amo
 			"operation_type": operationType,
 			"error":          err,
 		}).Error("Failed to credit FUEL")
-		return fmt.Errorf("failed to credit FUEL: %w", err)
+		return nil, fmt.Errorf("failed to credit FUEL: %w", err)
 	}
 
-	return nil
+	return wallet, nil
+}
+
+// AdjustFuel records an admin FUEL adjustment and its audit record in one transaction
+func (l *ledgerOperations) AdjustFuel(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, description string, audit *models.AdminAuditEntry, details func(*models.Wallet) (json.RawMessage, error)) (balance decimal.Decimal, err error) {
+	defer l.observe(ledgerOpAdjustFuel, constants.CurrencyFUEL, time.Now(), &err)
+
+	if amount.IsZero() {
+		return decimal.Zero, fmt.Errorf("%w: adjustment amount must not be zero", ErrInvalidAmount)
+	}
+
+	var descPtr *string
+	if description != "" {
+		descPtr = &description
+	}
+
+	entry := &models.LedgerEntry{
+		UserID:        &userID,
+		Currency:      constants.CurrencyFUEL,
+		Amount:        amount,
+		OperationType: models.OperationType(constants.OperationAdminAdjustment),
+		Description:   descPtr,
+		CreatedAt:     time.Now(),
+	}
+
+	wallet, err := l.ledgerRepo.CreateEntryWithAudit(ctx, entry, audit, details)
+	if errors.Is(err, repository.ErrNegativeBalance) && amount.IsNegative() {
+		return decimal.Zero, fmt.Errorf("%w: %w", ErrInsufficientBalance, err)
+	}
+	if err != nil {
+		l.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"amount":  amount,
+			"error":   err,
+		}).Error("Failed to adjust FUEL")
+		return decimal.Zero, fmt.Errorf("failed to adjust FUEL: %w", err)
+	}
+
+	return wallet.FuelBalance, nil
 }
 
 // CreditBurn credits BURN to a user's account
-func (l *ledgerOperations) CreditBurn(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) error {
+func (l *ledgerOperations) CreditBurn(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (err error) {
+	defer l.observe(ledgerOpCreditBurn, constants.CurrencyBURN, time.Now(), &err)
+
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return fmt.Errorf("credit amount must be positive")
+		return fmt.Errorf("%w: credit amount must be positive", ErrInvalidAmount)
 	}
 
 	// Create credit entry (positive amount)
@@ -161,7 +305,7 @@ func (l *ledgerOperations) CreditBurn(ctx context.Context, userID uuid.UUID, amo
 	}
 
 	// Record entry and update wallet balance
-	err := l.recordEntryAndUpdateBalance(ctx, entry)
+	_, err = l.recordEntryAndUpdateBalance(ctx, entry)
 	if err != nil {
 		l.logger.WithFields(logrus.Fields{
 			"user_id":        userID,
@@ -176,9 +320,11 @@ func (l *ledgerOperations) CreditBurn(ctx context.Context, userID uuid.UUID, amo
 }
 
 // DebitSystemWallet debits FUEL from a system wallet
-func (l *ledgerOperations) DebitSystemWallet(ctx context.Context, walletName string, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) error {
+func (l *ledgerOperations) DebitSystemWallet(ctx context.Context, walletName string, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (err error) {
+	defer l.observe(ledgerOpDebitSystemWallet, constants.CurrencyFUEL, time.Now(), &err)
+
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return fmt.Errorf("debit amount must be positive")
+		return fmt.Errorf("%w: debit amount must be positive", ErrInvalidAmount)
 	}
 
 	// Create debit entry (negative amount)
@@ -199,7 +345,7 @@ func (l *ledgerOperations) DebitSystemWallet(ctx context.Context, walletName str
 	}
 
 	// Record entry (system wallets don't have direct balance updates)
-	err := l.ledgerRepo.CreateEntry(ctx, entry)
+	err = l.ledgerRepo.CreateEntry(ctx, entry)
 	if err != nil {
 		l.logger.WithFields(logrus.Fields{
 			"wallet_name":    walletName,
@@ -214,9 +360,11 @@ func (l *ledgerOperations) DebitSystemWallet(ctx context.Context, walletName str
 }
 
 // CreditSystemWallet credits FUEL to a system wallet
-func (l *ledgerOperations) CreditSystemWallet(ctx context.Context, walletName string, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) error {
+func (l *ledgerOperations) CreditSystemWallet(ctx context.Context, walletName string, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (err error) {
+	defer l.observe(ledgerOpCreditSystemWallet, constants.CurrencyFUEL, time.Now(), &err)
+
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return fmt.Errorf("credit amount must be positive")
+		return fmt.Errorf("%w: credit amount must be positive", ErrInvalidAmount)
 	}
 
 	// Create credit entry (positive amount)
@@ -237,7 +385,7 @@ func (l *ledgerOperations) CreditSystemWallet(ctx context.Context, walletName st
 	}
 
 	// Record entry (system wallets don't have direct balance updates)
-	err := l.ledgerRepo.CreateEntry(ctx, entry)
+	err = l.ledgerRepo.CreateEntry(ctx, entry)
 	if err != nil {
 		l.logger.WithFields(logrus.Fields{
 			"wallet_name":    walletName,
@@ -252,7 +400,9 @@ func (l *ledgerOperations) CreditSystemWallet(ctx context.Context, walletName st
 }
 
 // RecordEntry records a generic ledger entry
-func (l *ledgerOperations) RecordEntry(ctx context.Context, entry *models.LedgerEntry) error {
+func (l *ledgerOperations) RecordEntry(ctx context.Context, entry *models.LedgerEntry) (err error) {
+	defer l.observe(ledgerOpRecordEntry, string(entry.Currency), time.Now(), &err)
+
 	// Validate entry
 	if entry.Currency == "" {
 		return fmt.Errorf("currency is required")
@@ -261,7 +411,7 @@ func (l *ledgerOperations) RecordEntry(ctx context.Context, entry *models.Ledger
 		return fmt.Errorf("operation type is required")
 	}
 	if entry.Amount.IsZero() {
-		return fmt.Errorf("amount cannot be zero")
+		return fmt.Errorf("%w: amount cannot be zero", ErrInvalidAmount)
 	}
 
 	// Set created timestamp if not set
@@ -271,7 +421,8 @@ func (l *ledgerOperations) RecordEntry(ctx context.Context, entry *models.Ledger
 
 	// Record entry and update balance if it's a user entry
 	if entry.UserID != nil {
-		return l.recordEntryAndUpdateBalance(ctx, entry)
+		_, err = l.recordEntryAndUpdateBalance(ctx, entry)
+		return err
 	}
 
 	// System wallet entry - just record
@@ -279,55 +430,120 @@ func (l *ledgerOperations) RecordEntry(ctx context.Context, entry *models.Ledger
 }
 
 // RecordMatchEntries records multiple ledger entries for a match atomically
-func (l *ledgerOperations) RecordMatchEntries(ctx context.Context, entries []*models.LedgerEntry) error {
-	if len(entries) == 0 {
-		return nil
+func (l *ledgerOperations) RecordMatchEntries(ctx context.Context, entries []*models.LedgerEntry) (err error) {
+	defer l.observe(ledgerOpRecordMatchEntries, ledgerCurrencyMixed, time.Now(), &err)
+
+	_, err = l.recordMatchEntries(ctx, entries)
+	return err
+}
+
+// RecordMatchEntriesWithBalances records multiple ledger entries for a match atomically and
+// returns each user's wallet as the same transaction left it
+func (l *ledgerOperations) RecordMatchEntriesWithBalances(ctx context.Context, entries []*models.LedgerEntry) (wallets map[uuid.UUID]*models.Wallet, err error) {
+	defer l.observe(ledgerOpRecordMatchEntries, ledgerCurrencyMixed, time.Now(), &err)
+
+	return l.recordMatchEntries(ctx, entries)
+}
+
+// RecordCoveredMatchEntries records a match's ledger entries and wallet balance updates in one transaction
+func (l *ledgerOperations) RecordCoveredMatchEntries(ctx context.Context, entries []*models.LedgerEntry) (err error) {
+	defer l.observe(ledgerOpRecordMatchEntries, ledgerCurrencyMixed, time.Now(), &err)
+
+	_, err = l.recordMatchEntries(ctx, entries)
+	return err
+}
+
+// RecordMatchEntriesWithStatus records a match's ledger entries, wallet balance updates and status
+// change in one transaction
+func (l *ledgerOperations) RecordMatchEntriesWithStatus(ctx context.Context, change repository.MatchStatusChange, entries []*models.LedgerEntry) (wallets map[uuid.UUID]*models.Wallet, err error) {
+	defer l.observe(ledgerOpRecordMatchEntries, ledgerCurrencyMixed, time.Now(), &err)
+
+	matchID, err := matchReference(entries)
+	if err != nil {
+		return nil, err
+	}
+	if matchID != nil && *matchID != change.MatchID {
+		return nil, fmt.Errorf("match entries must reference match %s", change.MatchID)
 	}
 
-	// Validate all entries have the same reference ID (match ID)
+	wallets, err = l.ledgerRepo.CreateMatchEntriesWithStatus(ctx, change, entries)
+	if errors.Is(err, repository.ErrMatchStatusChanged) {
+		// Another writer got to the match first; the caller decides what that means
+		return nil, fmt.Errorf("failed to record match entries: %w", err)
+	}
+	if errors.Is(err, repository.ErrNegativeBalance) {
+		err = fmt.Errorf("%w: %w", ErrInsufficientBalance, err)
+	}
+	if err != nil {
+		l.logger.WithFields(logrus.Fields{
+			"match_id":    change.MatchID,
+			"status":      change.To,
+			"entry_count": len(entries),
+			"error":       err,
+		}).Error("Failed to record match entries with status")
+		return nil, fmt.Errorf("failed to record match entries: %w", err)
+	}
+
+	return wallets, nil
+}
+
+// matchReference returns the match ID every entry of a match batch must reference
+func matchReference(entries []*models.LedgerEntry) (*uuid.UUID, error) {
 	var matchID *uuid.UUID
 	for i, entry := range entries {
 		if i == 0 {
 			matchID = entry.ReferenceID
 		} else if entry.ReferenceID == nil || (matchID != nil && *entry.ReferenceID != *matchID) {
-			return fmt.Errorf("all match entries must have the same reference ID")
+			return nil, fmt.Errorf("all match entries must have the same reference ID")
 		}
 	}
+	return matchID, nil
+}
 
-	// Record all entries atomically
-	err := l.ledgerRepo.CreateEntries(ctx, entries)
+// recordMatchEntries records a match's ledger entries and applies them to the wallets they touch
+// in one transaction, returning each user's wallet as that transaction left it. A missing wallet
+// or an overdraft records nothing.
+func (l *ledgerOperations) recordMatchEntries(ctx context.Context, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error) {
+	if len(entries) == 0 {
+		return make(map[uuid.UUID]*models.Wallet), nil
+	}
+
+	// Validate all entries have the same reference ID (match ID)
+	matchID, err := matchReference(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	wallets, err := l.ledgerRepo.CreateEntriesWithBalances(ctx, entries)
+	if errors.Is(err, repository.ErrNegativeBalance) {
+		err = fmt.Errorf("%w: %w", ErrInsufficientBalance, err)
+	}
 	if err != nil {
 		l.logger.WithFields(logrus.Fields{
 			"match_id":    matchID,
 			"entry_count": len(entries),
 			"error":       err,
 		}).Error("Failed to record match entries")
-		return fmt.Errorf("failed to record match entries: %w", err)
-	}
-
-	// Update wallet balances for user entries
-	for _, entry := range entries {
-		if entry.UserID != nil {
-			err := l.updateWalletBalance(ctx, *entry.UserID, string(entry.Currency), entry.Amount)
-			if err != nil {
-				l.logger.WithFields(logrus.Fields{
-					"user_id":  *entry.UserID,
-					"currency": entry.Currency,
-					"amount":   entry.Amount,
-					"error":    err,
-				}).Error("Failed to update wallet balance after match entry")
-				// Continue with other entries, but log the error
-			}
-		}
+		return nil, fmt.Errorf("failed to record match entries: %w", err)
 	}
 
-	return nil
+	return wallets, nil
 }
 
 // TransferFuel transfers FUEL between users
 func (l *ledgerOperations) TransferFuel(ctx context.Context, fromUserID, toUserID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) error {
+	return l.Transfer(ctx, fromUserID, toUserID, constants.CurrencyFUEL, amount, operationType, referenceID, description)
+}
+
+// Transfer moves an amount of any supported currency between users as a double entry
+func (l *ledgerOperations) Transfer(ctx context.Context, fromUserID, toUserID uuid.UUID, currency string, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) (err error) {
+	defer l.observe(ledgerOpTransfer, currency, time.Now(), &err)
+
+	if !constants.IsValidCurrency(currency) {
+		return fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
 	if amount.LessThanOrEqual(decimal.Zero) {
-		return fmt.Errorf("transfer amount must be positive")
+		return fmt.Errorf("%w: transfer amount must be positive", ErrInvalidAmount)
 	}
 
 	// Create debit entry for sender
@@ -336,7 +552,7 @@ func (l *ledgerOperations) TransferFuel(ctx context.Context, fromUserID, toUserI
 	debitEntry := &models.LedgerEntry{
 		UserID:        &fromUserID,
 		SystemWallet:  nil,
-		Currency:      constants.CurrencyFUEL,
+		Currency:      models.Currency(currency),
 		Amount:        amount.Neg(), // Negative for debit
 		OperationType: models.OperationType(operationType),
 		ReferenceID:   referenceID,
@@ -350,7 +566,7 @@ func (l *ledgerOperations) TransferFuel(ctx context.Context, fromUserID, toUserI
 	creditEntry := &models.LedgerEntry{
 		UserID:        &toUserID,
 		SystemWallet:  nil,
-		Currency:      constants.CurrencyFUEL,
+		Currency:      models.Currency(currency),
 		Amount:        amount, // Positive for credit
 		OperationType: models.OperationType(operationType),
 		ReferenceID:   referenceID,
@@ -358,67 +574,58 @@ func (l *ledgerOperations) TransferFuel(ctx context.Context, fromUserID, toUserI
 		CreatedAt:     time.Now(),
 	}
 
-	// Record both entries atomically
-	entries := []*models.LedgerEntry{debitEntry, creditEntry}
-	err := l.ledgerRepo.CreateEntries(ctx, entries)
+	// Both entries and both balance updates commit together; an overdraft rolls all of them back
+	err = l.ledgerRepo.CreateTransfer(ctx, debitEntry, creditEntry)
+	if errors.Is(err, repository.ErrNegativeBalance) {
+		err = fmt.Errorf("%w: %w", ErrInsufficientBalance, err)
+	}
 	if err != nil {
 		l.logger.WithFields(logrus.Fields{
 			"from_user_id":   fromUserID,
 			"to_user_id":     toUserID,
+			"currency":       currency,
 			"amount":         amount,
 			"operation_type": operationType,
 			"error":          err,
-		}).Error("Failed to transfer FUEL")
-		return fmt.Errorf("failed to transfer FUEL: %w", err)
-	}
-
-	// Update wallet balances
-	err = l.updateWalletBalance(ctx, fromUserID, constants.CurrencyFUEL, amount.Neg())
-	if err != nil {
-		return fmt.Errorf("failed to update sender balance: %w", err)
-	}
-
-	err = l.updateWalletBalance(ctx, toUserID, constants.CurrencyFUEL, amount)
-	if err != nil {
-		return fmt.Errorf("failed to update receiver balance: %w", err)
+		}).Error("Failed to transfer")
+		return fmt.Errorf("failed to transfer %s: %w", currency, err)
 	}
 
 	return nil
 }
 
-// recordEntryAndUpdateBalance records a ledger entry and updates the wallet balance
-func (l *ledgerOperations) recordEntryAndUpdateBalance(ctx context.Context, entry *models.LedgerEntry) error {
-	// Record the ledger entry
-	err := l.ledgerRepo.CreateEntry(ctx, entry)
-	if err != nil {
-		return err
+// observe records a finished ledger operation; it is deferred with a pointer to the operation's error
+func (l *ledgerOperations) observe(operation, currency string, start time.Time, err *error) {
+	if l.metrics == nil {
+		return
 	}
 
-	// Update wallet balance if it's a user entry
-	if entry.UserID != nil {
-		err = l.updateWalletBalance(ctx, *entry.UserID, string(entry.Currency), entry.Amount)
-		if err != nil {
-			return fmt.Errorf("failed to update wallet balance: %w", err)
-		}
+	status := metrics.LedgerStatusSuccess
+	if *err != nil {
+		status = metrics.LedgerStatusError
 	}
-
-	return nil
+	l.metrics.RecordLedgerOperation(operation, currency, status, time.Since(start))
 }
 
-// updateWalletBalance updates the wallet balance for a specific currency
-func (l *ledgerOperations) updateWalletBalance(ctx context.Context, userID uuid.UUID, currency string, delta decimal.Decimal) error {
-	var tonDelta, fuelDelta, burnDelta decimal.Decimal
+// recordEntryAndUpdateBalance records a ledger entry and, for user entries, updates the wallet
+// balance in the same transaction, returning the wallet as the update left it (nil for system
+// wallet entries). A missing wallet or an overdraft leaves the ledger untouched.
+func (l *ledgerOperations) recordEntryAndUpdateBalance(ctx context.Context, entry *models.LedgerEntry) (*models.Wallet, error) {
+	if entry.UserID == nil {
+		return nil, l.ledgerRepo.CreateEntry(ctx, entry)
+	}
 
-	switch currency {
-	case constants.CurrencyTON:
-		tonDelta = delta
-	case constants.CurrencyFUEL:
-		fuelDelta = delta
-	case constants.CurrencyBURN:
-		burnDelta = delta
-	default:
-		return fmt.Errorf("unsupported currency: %s", currency)
+	if !constants.IsValidCurrency(string(entry.Currency)) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, entry.Currency)
+	}
+
+	wallets, err := l.ledgerRepo.CreateEntriesWithBalances(ctx, []*models.LedgerEntry{entry})
+	if errors.Is(err, repository.ErrNegativeBalance) && entry.Amount.IsNegative() {
+		return nil, fmt.Errorf("%w: %w", ErrInsufficientBalance, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update wallet balance: %w", err)
 	}
 
-	return l.walletRepo.UpdateBalances(ctx, userID, tonDelta, fuelDelta, burnDelta)
+	return wallets[*entry.UserID], nil
 }
@@ -0,0 +1,228 @@
+package account
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+type LedgerOperationsIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper   *repository.TestDBHelper
+	userRepo   repository.UserRepository
+	walletRepo repository.WalletRepository
+	ledger     LedgerOperations
+	userID     uuid.UUID
+}
+
+func TestLedgerOperationsIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(LedgerOperationsIntegrationTestSuite))
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = repository.NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	suite.userRepo = repository.NewUserRepository(suite.dbHelper.DB)
+	suite.walletRepo = repository.NewWalletRepository(suite.dbHelper.DB)
+	suite.ledger = NewLedgerOperations(repository.NewLedgerRepository(suite.dbHelper.DB), suite.walletRepo, logger)
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) SetupTest() {
+	suite.dbHelper.CleanupTables("ledger_entries", "wallets", "users")
+
+	ctx := context.Background()
+	suite.userID = uuid.New()
+	require.NoError(suite.T(), suite.userRepo.Create(ctx, &models.User{
+		ID:                suite.userID,
+		TelegramID:        987654321,
+		TelegramFirstName: "Racer",
+		CreatedAt:         time.Now().UTC(),
+		UpdatedAt:         time.Now().UTC(),
+	}))
+	require.NoError(suite.T(), suite.walletRepo.Create(ctx, &models.Wallet{
+		UserID:      suite.userID,
+		FuelBalance: decimal.NewFromInt(100),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}))
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) storedFuel() string {
+	wallet, err := suite.walletRepo.GetByUserID(context.Background(), suite.userID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), wallet)
+	return wallet.FuelBalance.StringFixed(2)
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) TestDebitAndCreditReturnStoredBalance() {
+	ctx := context.Background()
+
+	balance, err := suite.ledger.DebitFuelWithBalance(ctx, suite.userID, decimal.RequireFromString("30.50"), constants.OperationMatchBuyin, nil, "")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "69.50", balance.StringFixed(2))
+	assert.Equal(suite.T(), suite.storedFuel(), balance.StringFixed(2))
+
+	balance, err = suite.ledger.CreditFuelWithBalance(ctx, suite.userID, decimal.NewFromInt(20), constants.OperationMatchPrize, nil, "")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "89.50", balance.StringFixed(2))
+	assert.Equal(suite.T(), suite.storedFuel(), balance.StringFixed(2))
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) TestDebitOverdrawKeepsBalance() {
+	_, err := suite.ledger.DebitFuelWithBalance(context.Background(), suite.userID, decimal.NewFromInt(101), constants.OperationMatchBuyin, nil, "")
+	assert.ErrorIs(suite.T(), err, ErrInsufficientBalance)
+	assert.Equal(suite.T(), "100.00", suite.storedFuel())
+
+	// The rejected debit leaves no entry behind to skew the ledger balance
+	entries, err := repository.NewLedgerRepository(suite.dbHelper.DB).GetUserEntries(context.Background(), suite.userID, 10, 0)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entries)
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) TestCreditWithoutWalletRecordsNothing() {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	_, err := suite.ledger.CreditFuelWithBalance(ctx, userID, decimal.NewFromInt(5), constants.OperationMatchPrize, nil, "")
+	assert.ErrorIs(suite.T(), err, ErrWalletNotFound)
+
+	entries, err := repository.NewLedgerRepository(suite.dbHelper.DB).GetUserEntries(ctx, userID, 10, 0)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entries)
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) TestRecordMatchEntriesReturnsStoredWallets() {
+	ctx := context.Background()
+	matchID := uuid.New()
+	entries := []*models.LedgerEntry{
+		{UserID: &suite.userID, Currency: constants.CurrencyFUEL, Amount: decimal.NewFromInt(46), OperationType: constants.OperationMatchPrize, ReferenceID: &matchID, CreatedAt: time.Now()},
+		{UserID: &suite.userID, Currency: constants.CurrencyBURN, Amount: decimal.NewFromInt(50), OperationType: constants.OperationMatchBurnReward, ReferenceID: &matchID, CreatedAt: time.Now()},
+	}
+
+	wallets, err := suite.ledger.RecordMatchEntriesWithBalances(ctx, entries)
+	require.NoError(suite.T(), err)
+	require.Contains(suite.T(), wallets, suite.userID)
+	assert.Equal(suite.T(), "146.00", wallets[suite.userID].FuelBalance.StringFixed(2))
+	assert.Equal(suite.T(), "50.00", wallets[suite.userID].BurnBalance.StringFixed(2))
+	assert.Equal(suite.T(), suite.storedFuel(), wallets[suite.userID].FuelBalance.StringFixed(2))
+}
+
+// createFundedUser creates a user whose wallet holds the given TON, FUEL and BURN balances
+func (suite *LedgerOperationsIntegrationTestSuite) createFundedUser(telegramID int64, ton, fuel, burn int64) uuid.UUID {
+	ctx := context.Background()
+	userID := uuid.New()
+	require.NoError(suite.T(), suite.userRepo.Create(ctx, &models.User{
+		ID:                userID,
+		TelegramID:        telegramID,
+		TelegramFirstName: "Racer",
+		CreatedAt:         time.Now().UTC(),
+		UpdatedAt:         time.Now().UTC(),
+	}))
+	require.NoError(suite.T(), suite.walletRepo.Create(ctx, &models.Wallet{
+		UserID:      userID,
+		TonBalance:  decimal.NewFromInt(ton),
+		FuelBalance: decimal.NewFromInt(fuel),
+		BurnBalance: decimal.NewFromInt(burn),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	}))
+	return userID
+}
+
+// storedBalance returns a user's stored balance of a currency
+func (suite *LedgerOperationsIntegrationTestSuite) storedBalance(userID uuid.UUID, currency string) string {
+	wallet, err := suite.walletRepo.GetByUserID(context.Background(), userID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), wallet)
+
+	switch currency {
+	case constants.CurrencyTON:
+		return wallet.TonBalance.StringFixed(2)
+	case constants.CurrencyBURN:
+		return wallet.BurnBalance.StringFixed(2)
+	default:
+		return wallet.FuelBalance.StringFixed(2)
+	}
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) TestTransferMovesEveryCurrency() {
+	ctx := context.Background()
+	sender := suite.createFundedUser(111, 50, 0, 40)
+	receiver := suite.createFundedUser(222, 5, 0, 0)
+
+	for _, tt := range []struct {
+		currency     string
+		amount       string
+		wantSender   string
+		wantReceiver string
+	}{
+		{currency: constants.CurrencyBURN, amount: "12.50", wantSender: "27.50", wantReceiver: "12.50"},
+		{currency: constants.CurrencyTON, amount: "20", wantSender: "30.00", wantReceiver: "25.00"},
+	} {
+		err := suite.ledger.Transfer(ctx, sender, receiver, tt.currency, decimal.RequireFromString(tt.amount), constants.OperationReferralBonus, nil, "gift")
+		require.NoError(suite.T(), err, tt.currency)
+		assert.Equal(suite.T(), tt.wantSender, suite.storedBalance(sender, tt.currency), tt.currency)
+		assert.Equal(suite.T(), tt.wantReceiver, suite.storedBalance(receiver, tt.currency), tt.currency)
+	}
+
+	// FUEL balances are untouched by the other currencies' transfers
+	assert.Equal(suite.T(), "0.00", suite.storedBalance(sender, constants.CurrencyFUEL))
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) TestTransferRejectsInsufficientBalance() {
+	ctx := context.Background()
+	sender := suite.createFundedUser(111, 10, 0, 10)
+	receiver := suite.createFundedUser(222, 0, 0, 0)
+
+	for _, currency := range []string{constants.CurrencyBURN, constants.CurrencyTON} {
+		err := suite.ledger.Transfer(ctx, sender, receiver, currency, decimal.RequireFromString("10.01"), constants.OperationReferralBonus, nil, "")
+		assert.ErrorIs(suite.T(), err, ErrInsufficientBalance, currency)
+		assert.Equal(suite.T(), "10.00", suite.storedBalance(sender, currency), currency)
+		assert.Equal(suite.T(), "0.00", suite.storedBalance(receiver, currency), currency)
+	}
+}
+
+func (suite *LedgerOperationsIntegrationTestSuite) TestOverdrawnTransferChangesNothing() {
+	ctx := context.Background()
+	receiver := suite.createFundedUser(222, 0, 40, 0)
+
+	countEntries := func() int {
+		var count int
+		require.NoError(suite.T(), suite.dbHelper.DB.Get(&count, "SELECT COUNT(*) FROM ledger_entries"))
+		return count
+	}
+	before := countEntries()
+
+	err := suite.ledger.TransferFuel(ctx, suite.userID, receiver, decimal.RequireFromString("100.01"), constants.OperationReferralBonus, nil, "")
+	assert.ErrorIs(suite.T(), err, ErrInsufficientBalance)
+
+	// Neither entry is written and neither wallet moves
+	assert.Equal(suite.T(), before, countEntries())
+	assert.Equal(suite.T(), "100.00", suite.storedFuel())
+	assert.Equal(suite.T(), "40.00", suite.storedBalance(receiver, constants.CurrencyFUEL))
+
+	// The full balance still transfers
+	require.NoError(suite.T(), suite.ledger.TransferFuel(ctx, suite.userID, receiver, decimal.NewFromInt(100), constants.OperationReferralBonus, nil, ""))
+	assert.Equal(suite.T(), before+2, countEntries())
+	assert.Equal(suite.T(), "0.00", suite.storedFuel())
+	assert.Equal(suite.T(), "140.00", suite.storedBalance(receiver, constants.CurrencyFUEL))
+}
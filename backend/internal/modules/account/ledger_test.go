@@ -0,0 +1,283 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/metrics"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// stubWalletRepository serves a fixed wallet
+type stubWalletRepository struct {
+	repository.WalletRepository
+	wallet *models.Wallet
+}
+
+func (r *stubWalletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Wallet, error) {
+	return r.wallet, nil
+}
+
+// stubLedgerRepository accepts every entry unless a balance update is set to fail;
+// other methods are not used by these tests
+type stubLedgerRepository struct {
+	repository.LedgerRepository
+	entries    []*models.LedgerEntry
+	balanceErr error
+}
+
+func (r *stubLedgerRepository) CreateEntry(ctx context.Context, entry *models.LedgerEntry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *stubLedgerRepository) CreateEntriesWithBalances(ctx context.Context, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error) {
+	// Like the real transaction, a failed balance update records nothing
+	if r.balanceErr != nil {
+		return nil, r.balanceErr
+	}
+	r.entries = append(r.entries, entries...)
+	wallets := make(map[uuid.UUID]*models.Wallet)
+	for _, entry := range entries {
+		if entry.UserID != nil {
+			wallets[*entry.UserID] = &models.Wallet{UserID: *entry.UserID}
+		}
+	}
+	return wallets, nil
+}
+
+func (r *stubLedgerRepository) CreateTransfer(ctx context.Context, debit, credit *models.LedgerEntry) error {
+	r.entries = append(r.entries, debit, credit)
+	return nil
+}
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	return logger
+}
+
+func TestDebitFuel_InvalidAmount(t *testing.T) {
+	ledger := NewLedgerOperations(&stubLedgerRepository{}, &stubWalletRepository{}, newTestLogger())
+
+	for _, amount := range []decimal.Decimal{decimal.Zero, decimal.NewFromInt(-5)} {
+		err := ledger.DebitFuel(context.Background(), uuid.New(), amount, "MATCH_BUYIN", nil, "")
+
+		assert.ErrorIs(t, err, ErrInvalidAmount)
+	}
+}
+
+func TestDebitFuel_InsufficientBalance(t *testing.T) {
+	constraintErr := fmt.Errorf("%w: pq: new row violates check constraint", repository.ErrNegativeBalance)
+	ledgerRepo := &stubLedgerRepository{balanceErr: constraintErr}
+	ledger := NewLedgerOperations(ledgerRepo, &stubWalletRepository{}, newTestLogger())
+
+	err := ledger.DebitFuel(context.Background(), uuid.New(), decimal.NewFromInt(100), "MATCH_BUYIN", nil, "")
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	assert.ErrorIs(t, err, ErrNegativeBalance)
+	assert.Empty(t, ledgerRepo.entries)
+}
+
+func TestCreditFuel_NegativeBalanceIsNotInsufficient(t *testing.T) {
+	ledgerRepo := &stubLedgerRepository{balanceErr: repository.ErrNegativeBalance}
+	ledger := NewLedgerOperations(ledgerRepo, &stubWalletRepository{}, newTestLogger())
+
+	err := ledger.CreditFuel(context.Background(), uuid.New(), decimal.NewFromInt(10), "MATCH_PRIZE", nil, "")
+
+	assert.ErrorIs(t, err, ErrNegativeBalance)
+	assert.NotErrorIs(t, err, ErrInsufficientBalance)
+}
+
+func TestRecordEntry_UnsupportedCurrency(t *testing.T) {
+	ledger := NewLedgerOperations(&stubLedgerRepository{}, &stubWalletRepository{}, newTestLogger())
+	userID := uuid.New()
+
+	err := ledger.RecordEntry(context.Background(), &models.LedgerEntry{
+		UserID:        &userID,
+		Currency:      "DOGE",
+		Amount:        decimal.NewFromInt(1),
+		OperationType: "MATCH_PRIZE",
+	})
+
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestGetBalance_UnsupportedCurrency(t *testing.T) {
+	// The stub ledger repository has no balance reads, so reaching it would panic
+	service := NewAccountService(&stubWalletRepository{}, &stubLedgerRepository{}, newTestLogger())
+
+	balance, err := service.GetBalance(context.Background(), uuid.New(), "GOLD")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+	assert.True(t, balance.IsZero())
+
+	_, err = service.HasSufficientBalance(context.Background(), uuid.New(), "fuel", decimal.NewFromInt(1))
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestGetWallet_NotFound(t *testing.T) {
+	service := NewAccountService(&stubWalletRepository{}, &stubLedgerRepository{}, newTestLogger())
+
+	wallet, err := service.GetWallet(context.Background(), uuid.New())
+
+	assert.Nil(t, wallet)
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+}
+
+func TestGetWallet_Found(t *testing.T) {
+	userID := uuid.New()
+	streetBuyin, _ := constants.DefaultLeagues().Buyin(constants.LeagueStreet)
+	walletRepo := &stubWalletRepository{wallet: &models.Wallet{
+		UserID:      userID,
+		FuelBalance: streetBuyin,
+	}}
+	service := NewAccountService(walletRepo, &stubLedgerRepository{}, newTestLogger())
+
+	wallet, err := service.GetWallet(context.Background(), userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, wallet.UserID)
+	assert.True(t, wallet.LeagueAccess.Street.Accessible)
+	assert.False(t, wallet.LeagueAccess.Pro.Accessible)
+}
+
+func TestGetWallet_LeagueAccessFollowsRegistry(t *testing.T) {
+	userID := uuid.New()
+	walletRepo := &stubWalletRepository{wallet: &models.Wallet{
+		UserID:      userID,
+		FuelBalance: decimal.NewFromInt(260),
+	}}
+
+	leagues := constants.DefaultLeagues().All()
+	for i := range leagues {
+		if leagues[i].Name == constants.LeaguePro {
+			leagues[i].Buyin = decimal.NewFromInt(250)
+		}
+	}
+	registry, err := constants.NewLeagueRegistry(leagues...)
+	require.NoError(t, err)
+
+	for _, registry := range []*constants.LeagueRegistry{constants.DefaultLeagues(), registry} {
+		service := NewAccountService(walletRepo, &stubLedgerRepository{}, newTestLogger(), WithLeagues(registry))
+		wallet, err := service.GetWallet(context.Background(), userID)
+		require.NoError(t, err)
+
+		// Every league's cost is the registry's buy-in
+		access := map[string]LeagueStatus{
+			constants.LeagueRookie:  wallet.LeagueAccess.Rookie,
+			constants.LeagueStreet:  wallet.LeagueAccess.Street,
+			constants.LeaguePro:     wallet.LeagueAccess.Pro,
+			constants.LeagueTopFuel: wallet.LeagueAccess.TopFuel,
+		}
+		for league, status := range access {
+			buyin, ok := registry.Buyin(league)
+			require.True(t, ok)
+			assert.True(t, buyin.Equal(status.BuyinCost), "%s costs %s, registry says %s", league, status.BuyinCost, buyin)
+		}
+	}
+
+	// A 260 FUEL balance only reaches PRO once its buy-in drops to 250
+	service := NewAccountService(walletRepo, &stubLedgerRepository{}, newTestLogger(), WithLeagues(registry))
+	wallet, err := service.GetWallet(context.Background(), userID)
+	require.NoError(t, err)
+	assert.True(t, wallet.LeagueAccess.Pro.Accessible)
+}
+
+func TestCreditFuel_RecordsMetrics(t *testing.T) {
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	ledger := NewLedgerOperations(&stubLedgerRepository{}, &stubWalletRepository{}, newTestLogger(), WithLedgerMetrics(m))
+
+	err := ledger.CreditFuel(context.Background(), uuid.New(), decimal.NewFromInt(25), "DEPOSIT", nil, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.LedgerOperationsTotal.WithLabelValues(ledgerOpCreditFuel, constants.CurrencyFUEL, metrics.LedgerStatusSuccess)))
+	assert.Zero(t, testutil.ToFloat64(m.LedgerOperationsTotal.WithLabelValues(ledgerOpCreditFuel, constants.CurrencyFUEL, metrics.LedgerStatusError)))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.LedgerOperationDuration, "ledger_operation_duration_seconds"))
+
+	// A rejected credit is counted as a failure
+	err = ledger.CreditFuel(context.Background(), uuid.New(), decimal.Zero, "DEPOSIT", nil, "")
+	require.Error(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.LedgerOperationsTotal.WithLabelValues(ledgerOpCreditFuel, constants.CurrencyFUEL, metrics.LedgerStatusError)))
+}
+
+func TestTransfer_RecordsEntriesInCurrency(t *testing.T) {
+	ledgerRepo := &stubLedgerRepository{}
+	ledger := NewLedgerOperations(ledgerRepo, &stubWalletRepository{wallet: &models.Wallet{}}, newTestLogger())
+
+	err := ledger.Transfer(context.Background(), uuid.New(), uuid.New(), constants.CurrencyBURN, decimal.NewFromInt(5), constants.OperationReferralBonus, nil, "")
+	require.NoError(t, err)
+	require.Len(t, ledgerRepo.entries, 2)
+	for _, entry := range ledgerRepo.entries {
+		assert.Equal(t, models.CurrencyBURN, entry.Currency)
+	}
+	assert.True(t, ledgerRepo.entries[0].Amount.Add(ledgerRepo.entries[1].Amount).IsZero())
+}
+
+func TestTransfer_RejectsUnsupportedCurrency(t *testing.T) {
+	ledgerRepo := &stubLedgerRepository{}
+	ledger := NewLedgerOperations(ledgerRepo, &stubWalletRepository{}, newTestLogger())
+
+	err := ledger.Transfer(context.Background(), uuid.New(), uuid.New(), "GOLD", decimal.NewFromInt(5), constants.OperationReferralBonus, nil, "")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+	assert.Empty(t, ledgerRepo.entries)
+}
+
+// overdrawnLedgerRepository rejects every transfer as an overdraft
+type overdrawnLedgerRepository struct {
+	repository.LedgerRepository
+}
+
+func (r *overdrawnLedgerRepository) CreateTransfer(ctx context.Context, debit, credit *models.LedgerEntry) error {
+	return fmt.Errorf("%w: FUEL balance 0 cannot cover 5", repository.ErrNegativeBalance)
+}
+
+func TestTransfer_OverdraftIsInsufficientBalance(t *testing.T) {
+	ledgerRepo := &overdrawnLedgerRepository{}
+	ledger := NewLedgerOperations(ledgerRepo, &stubWalletRepository{}, newTestLogger())
+
+	err := ledger.TransferFuel(context.Background(), uuid.New(), uuid.New(), decimal.NewFromInt(5), constants.OperationReferralBonus, nil, "")
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+}
+
+func TestRecordCoveredMatchEntries_OverdraftIsInsufficientBalance(t *testing.T) {
+	ledgerRepo := &stubLedgerRepository{balanceErr: fmt.Errorf("%w: pq: new row violates check constraint", repository.ErrNegativeBalance)}
+	ledger := NewLedgerOperations(ledgerRepo, &stubWalletRepository{}, newTestLogger())
+	userID, matchID := uuid.New(), uuid.New()
+	house := constants.SystemWalletHouseFuel
+
+	err := ledger.RecordCoveredMatchEntries(context.Background(), []*models.LedgerEntry{
+		{SystemWallet: &house, Currency: constants.CurrencyFUEL, Amount: decimal.NewFromInt(10), OperationType: constants.OperationMatchBuyin, ReferenceID: &matchID},
+		{UserID: &userID, Currency: constants.CurrencyFUEL, Amount: decimal.NewFromInt(-10), OperationType: constants.OperationMatchBuyin, ReferenceID: &matchID},
+	})
+
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	assert.Empty(t, ledgerRepo.entries)
+}
+
+func TestRecordMatchEntriesWithBalances_FailedWalletUpdateIsReturned(t *testing.T) {
+	ledgerRepo := &stubLedgerRepository{balanceErr: fmt.Errorf("%w for user", repository.ErrWalletNotFound)}
+	ledger := NewLedgerOperations(ledgerRepo, &stubWalletRepository{}, newTestLogger())
+	userID, matchID := uuid.New(), uuid.New()
+	house := constants.SystemWalletHouseFuel
+
+	wallets, err := ledger.RecordMatchEntriesWithBalances(context.Background(), []*models.LedgerEntry{
+		{SystemWallet: &house, Currency: constants.CurrencyFUEL, Amount: decimal.NewFromInt(-40), OperationType: constants.OperationMatchPrize, ReferenceID: &matchID},
+		{UserID: &userID, Currency: constants.CurrencyFUEL, Amount: decimal.NewFromInt(40), OperationType: constants.OperationMatchPrize, ReferenceID: &matchID},
+	})
+
+	assert.ErrorIs(t, err, repository.ErrWalletNotFound)
+	assert.Nil(t, wallets)
+	assert.Empty(t, ledgerRepo.entries)
+}
@@ -18,9 +18,14 @@ type AccountService interface {
 	// GetWallet retrieves wallet information for a user
 	GetWallet(ctx context.Context, userID uuid.UUID) (*WalletInfo, error)
 
-	// GetBalance retrieves current balance for a user and currency
+	// GetBalance retrieves current balance for a user and currency.
+	// It returns ErrUnsupportedCurrency rather than a zero balance for unknown currencies.
 	GetBalance(ctx context.Context, userID uuid.UUID, currency string) (decimal.Decimal, error)
 
+	// GetBalancesSince returns the user's balances if their wallet changed after the since
+	// version (always when since is 0) along with the version for the next call
+	GetBalancesSince(ctx context.Context, userID uuid.UUID, since int64) (*BalanceSnapshot, error)
+
 	// HasSufficientBalance checks if user has enough balance for an operation
 	HasSufficientBalance(ctx context.Context, userID uuid.UUID, currency string, amount decimal.Decimal) (bool, error)
 
@@ -42,6 +47,14 @@ type WalletInfo struct {
 	LeagueAccess         LeagueAccess    `json:"league_access"`
 }
 
+// BalanceSnapshot represents a user's balances as of a wallet version.
+// The version is bumped in the same transaction as every balance change, so it
+// only moves forward and a change is never seen before its balance.
+type BalanceSnapshot struct {
+	Version  int64                      `json:"version"`
+	Balances map[string]decimal.Decimal `json:"balances"` // Keyed by currency, empty if nothing changed
+}
+
 // LeagueAccess represents which leagues the user can access
 type LeagueAccess struct {
 	Rookie  LeagueStatus `json:"rookie"`
@@ -57,32 +70,41 @@ type LeagueStatus struct {
 	Reason     string          `json:"reason,omitempty"` // Why not accessible
 }
 
-// Use league constants from constants package
-var (
-	RookieBuyin  = constants.LeagueBuyins[constants.LeagueRookie]
-	StreetBuyin  = constants.LeagueBuyins[constants.LeagueStreet]
-	ProBuyin     = constants.LeagueBuyins[constants.LeaguePro]
-	TopFuelBuyin = constants.LeagueBuyins[constants.LeagueTopFuel]
-)
-
 // accountService implements AccountService
 type accountService struct {
 	walletRepo repository.WalletRepository
 	ledgerRepo repository.LedgerRepository
+	leagues    *constants.LeagueRegistry
 	logger     *logrus.Logger
 }
 
+// AccountServiceOption configures optional account service behaviour
+type AccountServiceOption func(*accountService)
+
+// WithLeagues sets the league buy-ins used to work out league access.
+// Without it the launch leagues are used.
+func WithLeagues(leagues *constants.LeagueRegistry) AccountServiceOption {
+	return func(s *accountService) {
+		s.leagues = leagues
+	}
+}
+
 // NewAccountService creates a new account service
 func NewAccountService(
 	walletRepo repository.WalletRepository,
 	ledgerRepo repository.LedgerRepository,
 	logger *logrus.Logger,
+	opts ...AccountServiceOption,
 ) AccountService {
-	return &accountService{
+	s := &accountService{
 		walletRepo: walletRepo,
 		ledgerRepo: ledgerRepo,
 		logger:     logger,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // GetWallet retrieves wallet information for a user
@@ -98,7 +120,7 @@ func (s *accountService) GetWallet(ctx context.Context, userID uuid.UUID) (*Wall
 	}
 
 	if wallet == nil {
-		return nil, fmt.Errorf("wallet not found for user %s", userID)
+		return nil, fmt.Errorf("%w for user %s", ErrWalletNotFound, userID)
 	}
 
 	// Calculate league access
@@ -124,6 +146,10 @@ func (s *accountService) GetWallet(ctx context.Context, userID uuid.UUID) (*Wall
 
 // GetBalance retrieves current balance for a user and currency
 func (s *accountService) GetBalance(ctx context.Context, userID uuid.UUID, currency string) (decimal.Decimal, error) {
+	if !constants.IsValidCurrency(currency) {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+
 	balance, err := s.ledgerRepo.GetUserBalance(ctx, userID, currency)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
@@ -137,6 +163,35 @@ func (s *accountService) GetBalance(ctx context.Context, userID uuid.UUID, curre
 	return balance, nil
 }
 
+// GetBalancesSince returns the user's balances if their wallet changed after since, along with its version
+func (s *accountService) GetBalancesSince(ctx context.Context, userID uuid.UUID, since int64) (*BalanceSnapshot, error) {
+	wallet, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"since":   since,
+			"error":   err,
+		}).Error("Failed to get wallet")
+		return nil, fmt.Errorf("failed to get balances: %w", err)
+	}
+
+	if wallet == nil {
+		return nil, fmt.Errorf("%w for user %s", ErrWalletNotFound, userID)
+	}
+
+	snapshot := &BalanceSnapshot{
+		Version:  wallet.Version,
+		Balances: make(map[string]decimal.Decimal, 3),
+	}
+	if since == 0 || wallet.Version > since {
+		snapshot.Balances[constants.CurrencyTON] = wallet.TonBalance
+		snapshot.Balances[constants.CurrencyFUEL] = wallet.FuelBalance
+		snapshot.Balances[constants.CurrencyBURN] = wallet.BurnBalance
+	}
+
+	return snapshot, nil
+}
+
 // HasSufficientBalance checks if user has enough balance for an operation
 func (s *accountService) HasSufficientBalance(ctx context.Context, userID uuid.UUID, currency string, amount decimal.Decimal) (bool, error) {
 	balance, err := s.GetBalance(ctx, userID, currency)
@@ -180,66 +235,70 @@ func (s *accountService) GetTransactionHistory(ctx context.Context, userID uuid.
 // calculateLeagueAccess determines which leagues a user can access
 func (s *accountService) calculateLeagueAccess(wallet *models.Wallet) LeagueAccess {
 	access := LeagueAccess{}
+	rookieBuyin, _ := s.leagues.Buyin(constants.LeagueRookie)
+	streetBuyin, _ := s.leagues.Buyin(constants.LeagueStreet)
+	proBuyin, _ := s.leagues.Buyin(constants.LeaguePro)
+	topFuelBuyin, _ := s.leagues.Buyin(constants.LeagueTopFuel)
 
 	// Rookie league
 	if wallet.RookieRacesCompleted >= 3 {
 		access.Rookie = LeagueStatus{
 			Accessible: false,
-			BuyinCost:  RookieBuyin,
+			BuyinCost:  rookieBuyin,
 			Reason:     "Maximum 3 rookie races completed",
 		}
-	} else if wallet.FuelBalance.LessThan(RookieBuyin) {
+	} else if wallet.FuelBalance.LessThan(rookieBuyin) {
 		access.Rookie = LeagueStatus{
 			Accessible: false,
-			BuyinCost:  RookieBuyin,
+			BuyinCost:  rookieBuyin,
 			Reason:     "Insufficient FUEL balance",
 		}
 	} else {
 		access.Rookie = LeagueStatus{
 			Accessible: true,
-			BuyinCost:  RookieBuyin,
+			BuyinCost:  rookieBuyin,
 		}
 	}
 
 	// Street league
-	if wallet.FuelBalance.LessThan(StreetBuyin) {
+	if wallet.FuelBalance.LessThan(streetBuyin) {
 		access.Street = LeagueStatus{
 			Accessible: false,
-			BuyinCost:  StreetBuyin,
+			BuyinCost:  streetBuyin,
 			Reason:     "Insufficient FUEL balance",
 		}
 	} else {
 		access.Street = LeagueStatus{
 			Accessible: true,
-			BuyinCost:  StreetBuyin,
+			BuyinCost:  streetBuyin,
 		}
 	}
 
 	// Pro league
-	if wallet.FuelBalance.LessThan(ProBuyin) {
+	if wallet.FuelBalance.LessThan(proBuyin) {
 		access.Pro = LeagueStatus{
 			Accessible: false,
-			BuyinCost:  ProBuyin,
+			BuyinCost:  proBuyin,
 			Reason:     "Insufficient FUEL balance",
 		}
 	} else {
 		access.Pro = LeagueStatus{
 			Accessible: true,
-			BuyinCost:  ProBuyin,
+			BuyinCost:  proBuyin,
 		}
 	}
 
 	// Top Fuel league
-	if wallet.FuelBalance.LessThan(TopFuelBuyin) {
+	if wallet.FuelBalance.LessThan(topFuelBuyin) {
 		access.TopFuel = LeagueStatus{
 			Accessible: false,
-			BuyinCost:  TopFuelBuyin,
+			BuyinCost:  topFuelBuyin,
 			Reason:     "Insufficient FUEL balance",
 		}
 	} else {
 		access.TopFuel = LeagueStatus{
 			Accessible: true,
-			BuyinCost:  TopFuelBuyin,
+			BuyinCost:  topFuelBuyin,
 		}
 	}
 
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// referralPrefix marks a start_param as a referral link, e.g. "ref_123456789"
+const referralPrefix = "ref_"
+
+// ParseReferrerTelegramID returns the referrer's Telegram ID from a referral start_param
+func ParseReferrerTelegramID(startParam string) (int64, bool) {
+	if !strings.HasPrefix(startParam, referralPrefix) {
+		return 0, false
+	}
+
+	telegramID, err := strconv.ParseInt(strings.TrimPrefix(startParam, referralPrefix), 10, 64)
+	if err != nil || telegramID <= 0 {
+		return 0, false
+	}
+	return telegramID, true
+}
+
+// creditReferrer credits the referral bonus to the player whose referral link a new player signed up with.
+// The ledger accepts one bonus per referred player, and a failed credit does not fail the login.
+func (s *authService) creditReferrer(ctx context.Context, user *models.User, startParam string) {
+	if s.ledgerOps == nil || !s.referral.IsPositive() {
+		return
+	}
+
+	referrerTelegramID, ok := ParseReferrerTelegramID(startParam)
+	if !ok || referrerTelegramID == user.TelegramID {
+		return
+	}
+
+	referrer, err := s.userRepo.GetByTelegramID(ctx, referrerTelegramID)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"user_id":              user.ID,
+			"referrer_telegram_id": referrerTelegramID,
+			"error":                err,
+		}).Error("Failed to get referrer")
+		return
+	}
+	if referrer == nil {
+		return // Unknown referrer
+	}
+
+	err = s.ledgerOps.CreditFuel(ctx, referrer.ID, s.referral, constants.OperationReferralBonus, &user.ID, "Referral bonus")
+	if errors.Is(err, repository.ErrDuplicate) {
+		return // Already credited for this player
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"user_id":     user.ID,
+			"referrer_id": referrer.ID,
+			"amount":      s.referral,
+			"error":       err,
+		}).Error("Failed to credit referral bonus")
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":     user.ID,
+		"referrer_id": referrer.ID,
+		"amount":      s.referral,
+	}).Info("Credited referral bonus")
+}
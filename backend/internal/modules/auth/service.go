@@ -2,6 +2,8 @@ package auth
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,10 +12,21 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/megaherz/ndr/internal/auth"
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/modules/account"
 	"github.com/megaherz/ndr/internal/storage/postgres/models"
 	"github.com/megaherz/ndr/internal/storage/postgres/repository"
 )
 
+// DefaultAccessTokenTTL is how long access and Centrifugo tokens are valid by default
+const DefaultAccessTokenTTL = 24 * time.Hour
+
+// refreshTokenTTL is how long a refresh token can be exchanged for a new token pair
+const refreshTokenTTL = 7 * 24 * time.Hour
+
+// ErrUserBanned is returned when a banned user signs in or refreshes their tokens
+var ErrUserBanned = errors.New("user is banned")
+
 // AuthService handles authentication operations
 type AuthService interface {
 	// Authenticate validates Telegram initData and returns JWT tokens
@@ -35,8 +48,10 @@ type AuthResult struct {
 // TokenPair represents the token pair returned to the frontend
 type TokenPair struct {
 	AppToken        string `json:"app_token"`
+	RefreshToken    string `json:"refresh_token"` // Exchanged at /auth/refresh for a new token pair
 	CentrifugoToken string `json:"centrifugo_token"`
 	ExpiresAt       string `json:"expires_at"` // ISO 8601 timestamp
+	ExpiresIn       int64  `json:"expires_in"` // Seconds until the app token expires
 }
 
 // TokenClaims represents the claims in a JWT token
@@ -50,34 +65,99 @@ type TokenClaims struct {
 
 // authService implements AuthService
 type authService struct {
-	userRepo   repository.UserRepository
-	walletRepo repository.WalletRepository
-	jwtUtil    *auth.JWTManager
-	botToken   string
-	logger     *logrus.Logger
+	userRepo        repository.UserRepository
+	walletRepo      repository.WalletRepository
+	jwt             auth.JWTManager
+	accessTTL       time.Duration
+	botToken        string
+	ledgerOps       account.LedgerOperations
+	grant           decimal.Decimal
+	referral        decimal.Decimal
+	botID           int64
+	publicKey       ed25519.PublicKey
+	maxAge          time.Duration
+	futureTolerance time.Duration
+	logger          *logrus.Logger
+}
+
+// AuthServiceOption configures optional authentication service behaviour
+type AuthServiceOption func(*authService)
+
+// WithSignupGrant credits every new player's wallet with the given FUEL once, when it is created
+func WithSignupGrant(ledgerOps account.LedgerOperations, amount decimal.Decimal) AuthServiceOption {
+	return func(s *authService) {
+		s.ledgerOps = ledgerOps
+		s.grant = amount
+	}
+}
+
+// WithReferralBonus credits the given FUEL to the player who referred a new player, once per referred player
+func WithReferralBonus(ledgerOps account.LedgerOperations, amount decimal.Decimal) AuthServiceOption {
+	return func(s *authService) {
+		s.ledgerOps = ledgerOps
+		s.referral = amount
+	}
+}
+
+// WithInitDataSignature validates initData by Telegram's Ed25519 signature for the given bot
+// instead of the bot token HMAC hash
+func WithInitDataSignature(botID int64, publicKey ed25519.PublicKey) AuthServiceOption {
+	return func(s *authService) {
+		s.botID = botID
+		s.publicKey = publicKey
+	}
+}
+
+// WithAccessTokenTTL sets how long issued access and Centrifugo tokens are valid
+func WithAccessTokenTTL(ttl time.Duration) AuthServiceOption {
+	return func(s *authService) {
+		s.accessTTL = ttl
+	}
+}
+
+// WithInitDataMaxAge sets how long initData is accepted after Telegram signed it
+func WithInitDataMaxAge(maxAge time.Duration) AuthServiceOption {
+	return func(s *authService) {
+		s.maxAge = maxAge
+	}
+}
+
+// WithInitDataFutureTolerance sets how far ahead of server time an initData auth_date may be
+func WithInitDataFutureTolerance(tolerance time.Duration) AuthServiceOption {
+	return func(s *authService) {
+		s.futureTolerance = tolerance
+	}
 }
 
 // NewAuthService creates a new authentication service
 func NewAuthService(
 	userRepo repository.UserRepository,
 	walletRepo repository.WalletRepository,
-	jwtUtil *auth.JWTManager,
+	jwtManager auth.JWTManager,
 	botToken string,
 	logger *logrus.Logger,
+	opts ...AuthServiceOption,
 ) AuthService {
-	return &authService{
-		userRepo:   userRepo,
-		walletRepo: walletRepo,
-		jwtUtil:    jwtUtil,
-		botToken:   botToken,
-		logger:     logger,
+	s := &authService{
+		userRepo:        userRepo,
+		walletRepo:      walletRepo,
+		jwt:             jwtManager,
+		accessTTL:       DefaultAccessTokenTTL,
+		botToken:        botToken,
+		maxAge:          DefaultInitDataMaxAge,
+		futureTolerance: DefaultInitDataFutureTolerance,
+		logger:          logger,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Authenticate validates Telegram initData and returns JWT tokens
 func (s *authService) Authenticate(ctx context.Context, initData string) (*AuthResult, error) {
 	// Validate Telegram initData
-	telegramData, err := ValidateTelegramInitData(initData, s.botToken)
+	telegramData, err := s.validateInitData(initData)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"error": err,
@@ -85,14 +165,18 @@ func (s *authService) Authenticate(ctx context.Context, initData string) (*AuthR
 		return nil, fmt.Errorf("invalid telegram data: %w", err)
 	}
 
-	// Get or create user
-	user, err := s.userRepo.GetOrCreateByTelegramID(
+	// Get or create user; new users get their wallet and signup grant in the same transaction.
+	// Names are shown to other players, so they are stored sanitized.
+	user, created, err := s.userRepo.GetOrCreateWithWallet(
 		ctx,
 		telegramData.User.ID,
-		telegramData.User.Username,
-		telegramData.User.FirstName,
-		telegramData.User.LastName,
+		models.SanitizeDisplayName(telegramData.User.Username),
+		models.SanitizeDisplayName(telegramData.User.FirstName),
+		models.SanitizeDisplayName(telegramData.User.LastName),
 		telegramData.User.PhotoURL,
+		telegramData.User.LanguageCode,
+		telegramData.User.IsPremium,
+		s.grant,
 	)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
@@ -101,34 +185,42 @@ func (s *authService) Authenticate(ctx context.Context, initData string) (*AuthR
 		}).Error("Failed to get or create user")
 		return nil, fmt.Errorf("failed to get or create user: %w", err)
 	}
-
-	// Ensure user has a wallet
-	err = s.ensureUserWallet(ctx, user)
-	if err != nil {
+	if user.IsBanned() {
 		s.logger.WithFields(logrus.Fields{
 			"user_id": user.ID,
-			"error":   err,
-		}).Error("Failed to ensure user wallet")
-		return nil, fmt.Errorf("failed to ensure user wallet: %w", err)
+		}).Warn("Banned user attempted to sign in")
+		return nil, ErrUserBanned
 	}
 
-	// Generate JWT tokens
-	accessToken, err := s.jwtUtil.GenerateAppToken(user.ID, telegramData.User.ID, 24*time.Hour)
-	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"user_id": user.ID,
-			"error":   err,
-		}).Error("Failed to generate access token")
-		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	// Users created before wallets were created with them may still lack one
+	if !created {
+		walletCreated, err := s.ensureUserWallet(ctx, user)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"user_id": user.ID,
+				"error":   err,
+			}).Error("Failed to ensure user wallet")
+			return nil, fmt.Errorf("failed to ensure user wallet: %w", err)
+		}
+		if walletCreated {
+			s.grantSignupFuel(ctx, user)
+			created = true
+		}
 	}
 
-	refreshToken, err := s.jwtUtil.GenerateCentrifugoToken(user.ID, telegramData.User.ID, 7*24*time.Hour)
+	// Whoever referred a new player receives their bonus
+	if created {
+		s.creditReferrer(ctx, user, telegramData.StartParam)
+	}
+
+	// Generate JWT tokens
+	tokens, err := s.generateTokens(user.ID, telegramData.User.ID)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"user_id": user.ID,
 			"error":   err,
-		}).Error("Failed to generate refresh token")
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		}).Error("Failed to generate tokens")
+		return nil, err
 	}
 
 	s.logger.WithFields(logrus.Fields{
@@ -136,22 +228,15 @@ func (s *authService) Authenticate(ctx context.Context, initData string) (*AuthR
 		"telegram_id": telegramData.User.ID,
 	}).Info("User authenticated successfully")
 
-	// Calculate expiration time (24 hours from now for app token)
-	expiresAt := time.Now().Add(24 * time.Hour)
-
 	return &AuthResult{
-		User: user,
-		Tokens: TokenPair{
-			AppToken:        accessToken,
-			CentrifugoToken: refreshToken,
-			ExpiresAt:       expiresAt.Format(time.RFC3339),
-		},
+		User:   user,
+		Tokens: *tokens,
 	}, nil
 }
 
 // ValidateToken validates a JWT token and returns user info
 func (s *authService) ValidateToken(ctx context.Context, token string) (*TokenClaims, error) {
-	claims, err := s.jwtUtil.ValidateToken(token)
+	claims, err := s.jwt.ValidateToken(token, auth.TokenTypeApp)
 	if err != nil {
 		return nil, fmt.Errorf("invalid token: %w", err)
 	}
@@ -170,17 +255,12 @@ func (s *authService) ValidateToken(ctx context.Context, token string) (*TokenCl
 
 // RefreshToken generates a new access token from a refresh token
 func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*AuthResult, error) {
-	// Validate refresh token
-	claims, err := s.jwtUtil.ValidateToken(refreshToken)
+	// Validate refresh token; access and Centrifugo tokens are rejected
+	claims, err := s.jwt.ValidateToken(refreshToken, auth.TokenTypeRefresh)
 	if err != nil {
 		return nil, fmt.Errorf("invalid refresh token: %w", err)
 	}
 
-	// Ensure it's a refresh token
-	if claims.TokenType != "refresh" {
-		return nil, fmt.Errorf("token is not a refresh token")
-	}
-
 	// Get user to ensure they still exist
 	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
@@ -189,42 +269,74 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*A
 	if user == nil {
 		return nil, fmt.Errorf("user not found")
 	}
+	if user.IsBanned() {
+		return nil, ErrUserBanned
+	}
+
+	// Generate a new token pair
+	tokens, err := s.generateTokens(claims.UserID, claims.TelegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResult{
+		User:   user,
+		Tokens: *tokens,
+	}, nil
+}
 
-	// Generate new access token
-	accessToken, err := s.jwtUtil.GenerateAppToken(claims.UserID, claims.TelegramID, 24*time.Hour)
+// generateTokens issues the access, refresh and Centrifugo tokens of a user
+func (s *authService) generateTokens(userID uuid.UUID, telegramID int64) (*TokenPair, error) {
+	accessToken, err := s.jwt.GenerateAccessToken(userID, telegramID, s.accessTTL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	// Generate new refresh token
-	newRefreshToken, err := s.jwtUtil.GenerateCentrifugoToken(claims.UserID, claims.TelegramID, 7*24*time.Hour)
+	refreshToken, err := s.jwt.GenerateRefreshToken(userID, telegramID, refreshTokenTTL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
-	// Calculate expiration time (24 hours from now for app token)
-	expiresAt := time.Now().Add(24 * time.Hour)
+	centrifugoToken, err := s.jwt.GenerateCentrifugoToken(userID, telegramID, s.accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate centrifugo token: %w", err)
+	}
 
-	return &AuthResult{
-		User: user,
-		Tokens: TokenPair{
-			AppToken:        accessToken,
-			CentrifugoToken: newRefreshToken,
-			ExpiresAt:       expiresAt.Format(time.RFC3339),
-		},
+	// Advertise the expiry the app token actually carries
+	claims, err := s.jwt.ParseClaims(accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read access token expiry: %w", err)
+	}
+	expiresAt := claims.ExpiresAt.Time
+
+	return &TokenPair{
+		AppToken:        accessToken,
+		RefreshToken:    refreshToken,
+		CentrifugoToken: centrifugoToken,
+		ExpiresAt:       expiresAt.Format(time.RFC3339),
+		ExpiresIn:       int64(time.Until(expiresAt).Round(time.Second) / time.Second),
 	}, nil
 }
 
-// ensureUserWallet creates a wallet for the user if it doesn't exist
-func (s *authService) ensureUserWallet(ctx context.Context, user *models.User) error {
+// validateInitData validates initData with the configured method
+func (s *authService) validateInitData(initData string) (*TelegramInitData, error) {
+	if s.publicKey != nil {
+		return ValidateTelegramInitDataSignature(initData, s.botID, s.publicKey, s.maxAge, s.futureTolerance)
+	}
+	return ValidateTelegramInitData(initData, s.botToken, s.maxAge, s.futureTolerance)
+}
+
+// ensureUserWallet creates a wallet for the user if it doesn't exist.
+// It reports whether this call created the wallet.
+func (s *authService) ensureUserWallet(ctx context.Context, user *models.User) (bool, error) {
 	// Check if wallet exists
 	wallet, err := s.walletRepo.GetByUserID(ctx, user.ID)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if wallet != nil {
-		return nil // Wallet already exists
+		return false, nil // Wallet already exists
 	}
 
 	// Create new wallet
@@ -239,5 +351,40 @@ func (s *authService) ensureUserWallet(ctx context.Context, user *models.User) e
 		UpdatedAt:            time.Now(),
 	}
 
-	return s.walletRepo.Create(ctx, newWallet)
+	err = s.walletRepo.Create(ctx, newWallet)
+	if errors.Is(err, repository.ErrDuplicate) {
+		// A concurrent first login created the wallet first
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// grantSignupFuel credits a new player's signup FUEL.
+// The ledger accepts one grant per user, and a failed grant does not fail the login.
+func (s *authService) grantSignupFuel(ctx context.Context, user *models.User) {
+	if s.ledgerOps == nil || !s.grant.IsPositive() {
+		return
+	}
+
+	err := s.ledgerOps.CreditFuel(ctx, user.ID, s.grant, constants.OperationSignupGrant, nil, "Signup FUEL grant")
+	if errors.Is(err, repository.ErrDuplicate) {
+		return // Already granted
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"user_id": user.ID,
+			"amount":  s.grant,
+			"error":   err,
+		}).Error("Failed to credit signup FUEL grant")
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": user.ID,
+		"amount":  s.grant,
+	}).Info("Credited signup FUEL grant")
 }
@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/auth"
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/modules/account"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+type AuthServiceIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper   *repository.TestDBHelper
+	userRepo   repository.UserRepository
+	walletRepo repository.WalletRepository
+	ledgerRepo repository.LedgerRepository
+	service    AuthService
+}
+
+func TestAuthServiceIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(AuthServiceIntegrationTestSuite))
+}
+
+func (suite *AuthServiceIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = repository.NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	suite.userRepo = repository.NewUserRepository(suite.dbHelper.DB)
+	suite.walletRepo = repository.NewWalletRepository(suite.dbHelper.DB)
+	suite.ledgerRepo = repository.NewLedgerRepository(suite.dbHelper.DB)
+	ledgerOps := account.NewLedgerOperations(suite.ledgerRepo, suite.walletRepo, logger)
+	suite.service = NewAuthService(suite.userRepo, suite.walletRepo, auth.NewJWTManager("test-secret", "ndr"), testBotToken, logger,
+		WithSignupGrant(ledgerOps, decimal.RequireFromString("100.00")),
+		WithReferralBonus(ledgerOps, decimal.RequireFromString("10.00")))
+}
+
+func (suite *AuthServiceIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *AuthServiceIntegrationTestSuite) SetupTest() {
+	suite.dbHelper.CleanupTables("ledger_entries", "wallets", "users")
+}
+
+func (suite *AuthServiceIntegrationTestSuite) TestConcurrentFirstLoginsBothSucceed() {
+	ctx := context.Background()
+	initData := signedInitData(suite.T(), TelegramUser{ID: 555000111, FirstName: "Racer"})
+
+	const logins = 2
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	results := make([]*AuthResult, logins)
+	errs := make([]error, logins)
+	for i := 0; i < logins; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i], errs[i] = suite.service.Authenticate(ctx, initData)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i := 0; i < logins; i++ {
+		require.NoError(suite.T(), errs[i])
+	}
+	assert.Equal(suite.T(), results[0].User.ID, results[1].User.ID)
+
+	wallet, err := suite.walletRepo.GetByUserID(ctx, results[0].User.ID)
+	require.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), wallet)
+}
+
+func (suite *AuthServiceIntegrationTestSuite) TestNewUserReceivesSignupGrantOnce() {
+	ctx := context.Background()
+	initData := signedInitData(suite.T(), TelegramUser{ID: 555000222, FirstName: "Racer"})
+
+	var result *AuthResult
+	for i := 0; i < 2; i++ {
+		var err error
+		result, err = suite.service.Authenticate(ctx, initData)
+		require.NoError(suite.T(), err)
+	}
+
+	wallet, err := suite.walletRepo.GetByUserID(ctx, result.User.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), wallet)
+	assert.Equal(suite.T(), "100.00", wallet.FuelBalance.StringFixed(2))
+
+	entries, err := suite.ledgerRepo.GetUserEntries(ctx, result.User.ID, 10, 0)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), entries, 1)
+	assert.Equal(suite.T(), constants.OperationSignupGrant, string(entries[0].OperationType))
+}
+
+func (suite *AuthServiceIntegrationTestSuite) TestProfileFieldsRoundTripToDatabase() {
+	ctx := context.Background()
+	initData := signedInitData(suite.T(), TelegramUser{
+		ID:           555000333,
+		FirstName:    "Racer",
+		PhotoURL:     "https://t.me/i/userpic/320/racer.jpg",
+		LanguageCode: "es",
+		IsPremium:    true,
+	})
+
+	result, err := suite.service.Authenticate(ctx, initData)
+	require.NoError(suite.T(), err)
+
+	user, err := suite.userRepo.GetByID(ctx, result.User.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), user)
+	require.NotNil(suite.T(), user.TelegramPhotoURL)
+	assert.Equal(suite.T(), "https://t.me/i/userpic/320/racer.jpg", *user.TelegramPhotoURL)
+	require.NotNil(suite.T(), user.TelegramLanguageCode)
+	assert.Equal(suite.T(), "es", *user.TelegramLanguageCode)
+	assert.True(suite.T(), user.TelegramIsPremium)
+}
+
+func (suite *AuthServiceIntegrationTestSuite) TestReferralBonusCreditedOnce() {
+	ctx := context.Background()
+
+	referrer, err := suite.service.Authenticate(ctx, signedInitData(suite.T(), TelegramUser{ID: 555000444, FirstName: "Referrer"}))
+	require.NoError(suite.T(), err)
+
+	link := map[string]string{"start_param": "ref_555000444"}
+	for i := 0; i < 2; i++ {
+		_, err = suite.service.Authenticate(ctx, signedInitDataWith(suite.T(), TelegramUser{ID: 555000555, FirstName: "Referred"}, link))
+		require.NoError(suite.T(), err)
+	}
+
+	// Signup grant plus one referral bonus
+	wallet, err := suite.walletRepo.GetByUserID(ctx, referrer.User.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), wallet)
+	assert.Equal(suite.T(), "110.00", wallet.FuelBalance.StringFixed(2))
+}
+
+func (suite *AuthServiceIntegrationTestSuite) TestBannedUserIsRejectedUntilUnbanned() {
+	ctx := context.Background()
+	initData := signedInitData(suite.T(), TelegramUser{ID: 555000666, FirstName: "Cheater"})
+
+	login, err := suite.service.Authenticate(ctx, initData)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), suite.userRepo.BanUser(ctx, login.User.ID, "botting"))
+
+	// Neither signing in nor refreshing works while banned
+	_, err = suite.service.Authenticate(ctx, initData)
+	assert.ErrorIs(suite.T(), err, ErrUserBanned)
+	_, err = suite.service.RefreshToken(ctx, login.Tokens.RefreshToken)
+	assert.ErrorIs(suite.T(), err, ErrUserBanned)
+
+	require.NoError(suite.T(), suite.userRepo.UnbanUser(ctx, login.User.ID))
+
+	relogin, err := suite.service.Authenticate(ctx, initData)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), login.User.ID, relogin.User.ID)
+	_, err = suite.service.RefreshToken(ctx, login.Tokens.RefreshToken)
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *AuthServiceIntegrationTestSuite) TestNewUserCreatedWithFundedWallet() {
+	ctx := context.Background()
+
+	result, err := suite.service.Authenticate(ctx, signedInitData(suite.T(), TelegramUser{ID: 555000777, FirstName: "Racer"}))
+	require.NoError(suite.T(), err)
+
+	user, err := suite.userRepo.GetByTelegramID(ctx, 555000777)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), user)
+	assert.Equal(suite.T(), result.User.ID, user.ID)
+
+	// The wallet balance and the ledger agree on the grant
+	wallet, err := suite.walletRepo.GetByUserID(ctx, user.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), wallet)
+	assert.Equal(suite.T(), "100.00", wallet.FuelBalance.StringFixed(2))
+
+	ledgerBalance, err := suite.ledgerRepo.GetUserBalance(ctx, user.ID, constants.CurrencyFUEL)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), wallet.FuelBalance.Equal(ledgerBalance))
+}
@@ -0,0 +1,396 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	initdata "github.com/telegram-mini-apps/init-data-golang"
+
+	"github.com/megaherz/ndr/internal/auth"
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/modules/account"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+const testBotToken = "123456:ABC-DEF1234ghIkl-zyx57W2v1u123ew11"
+
+// signedInitData returns initData for a Telegram user signed with testBotToken
+func signedInitData(t *testing.T, user TelegramUser) string {
+	return signedInitDataWith(t, user, nil)
+}
+
+// signedInitDataWith returns initData for a Telegram user and extra parameters signed with testBotToken
+func signedInitDataWith(t *testing.T, user TelegramUser, params map[string]string) string {
+	return signedInitDataAt(t, user, params, time.Now())
+}
+
+// signedInitDataAt returns initData for a Telegram user and extra parameters signed with testBotToken at authDate
+func signedInitDataAt(t *testing.T, user TelegramUser, params map[string]string, authDate time.Time) string {
+	userJSON, err := json.Marshal(user)
+	require.NoError(t, err)
+
+	payload := map[string]string{"user": string(userJSON)}
+	for key, value := range params {
+		payload[key] = value
+	}
+
+	values := url.Values{}
+	for key, value := range payload {
+		values.Set(key, value)
+	}
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+	values.Set("hash", initdata.Sign(payload, testBotToken, authDate))
+	return values.Encode()
+}
+
+// stubUserRepository returns the same user for a Telegram ID, creating new users'
+// wallets with their signup grant when it has a wallet repository
+type stubUserRepository struct {
+	repository.UserRepository
+
+	mu      sync.Mutex
+	users   map[int64]*models.User
+	wallets repository.WalletRepository
+}
+
+func (r *stubUserRepository) GetOrCreateWithWallet(ctx context.Context, telegramID int64, username, firstName, lastName, photoURL, languageCode string, isPremium bool, signupGrant decimal.Decimal) (*models.User, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.users == nil {
+		r.users = make(map[int64]*models.User)
+	}
+	if user, ok := r.users[telegramID]; ok {
+		return user, false, nil
+	}
+	user := &models.User{ID: uuid.New(), TelegramID: telegramID, TelegramFirstName: firstName}
+	r.users[telegramID] = user
+	if r.wallets != nil {
+		if err := r.wallets.Create(ctx, &models.Wallet{UserID: user.ID, FuelBalance: signupGrant}); err != nil {
+			return nil, false, err
+		}
+	}
+	return user, true, nil
+}
+
+func (r *stubUserRepository) GetByTelegramID(ctx context.Context, telegramID int64) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.users[telegramID], nil
+}
+
+func (r *stubUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+// racingWalletRepository lets every caller see a missing wallet before any of them creates it,
+// rejecting all but the first create like the unique constraint does
+type racingWalletRepository struct {
+	repository.WalletRepository
+
+	lookups sync.WaitGroup
+	mu      sync.Mutex
+	wallets map[uuid.UUID]*models.Wallet
+}
+
+func (r *racingWalletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Wallet, error) {
+	r.mu.Lock()
+	wallet := r.wallets[userID]
+	r.mu.Unlock()
+
+	r.lookups.Done()
+	r.lookups.Wait()
+	return wallet, nil
+}
+
+func (r *racingWalletRepository) Create(ctx context.Context, wallet *models.Wallet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.wallets[wallet.UserID]; ok {
+		return fmt.Errorf("%w: wallets_pkey", repository.ErrDuplicate)
+	}
+	r.wallets[wallet.UserID] = wallet
+	return nil
+}
+
+func TestAuthenticate_ConcurrentLoginsOfWalletlessUser(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	// A user created before wallets were created with them logs in twice at once
+	const logins = 2
+	walletRepo := &racingWalletRepository{wallets: make(map[uuid.UUID]*models.Wallet)}
+	walletRepo.lookups.Add(logins)
+	users := &stubUserRepository{users: map[int64]*models.User{42: {ID: uuid.New(), TelegramID: 42, TelegramFirstName: "Racer"}}}
+	service := NewAuthService(users, walletRepo, auth.NewJWTManager("test-secret", "ndr"), testBotToken, logger)
+	initData := signedInitData(t, TelegramUser{ID: 42, FirstName: "Racer"})
+
+	var wg sync.WaitGroup
+	results := make([]*AuthResult, logins)
+	errs := make([]error, logins)
+	for i := 0; i < logins; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = service.Authenticate(context.Background(), initData)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < logins; i++ {
+		require.NoError(t, errs[i])
+		assert.NotEmpty(t, results[i].Tokens.AppToken)
+	}
+	assert.Equal(t, results[0].User.ID, results[1].User.ID)
+	assert.Len(t, walletRepo.wallets, 1)
+}
+
+// memoryWalletRepository keeps wallets in memory
+type memoryWalletRepository struct {
+	repository.WalletRepository
+	wallets map[uuid.UUID]*models.Wallet
+}
+
+func (r *memoryWalletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Wallet, error) {
+	return r.wallets[userID], nil
+}
+
+func (r *memoryWalletRepository) Create(ctx context.Context, wallet *models.Wallet) error {
+	r.wallets[wallet.UserID] = wallet
+	return nil
+}
+
+// grantingLedgerOperations credits FUEL to in-memory wallets, accepting one signup grant per user
+// and one referral bonus per referred user
+type grantingLedgerOperations struct {
+	account.LedgerOperations
+	wallets   *memoryWalletRepository
+	grants    map[uuid.UUID]int
+	referrals map[uuid.UUID]uuid.UUID // Referred user to credited referrer
+}
+
+func (l *grantingLedgerOperations) CreditFuel(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, operationType string, referenceID *uuid.UUID, description string) error {
+	switch operationType {
+	case constants.OperationSignupGrant:
+		if l.grants[userID] > 0 {
+			return fmt.Errorf("%w: idx_ledger_entries_signup_grant", repository.ErrDuplicate)
+		}
+		l.grants[userID]++
+	case constants.OperationReferralBonus:
+		if _, ok := l.referrals[*referenceID]; ok {
+			return fmt.Errorf("%w: idx_ledger_entries_referral_bonus", repository.ErrDuplicate)
+		}
+		l.referrals[*referenceID] = userID
+	}
+	wallet := l.wallets.wallets[userID]
+	wallet.FuelBalance = wallet.FuelBalance.Add(amount)
+	return nil
+}
+
+func TestAuthenticate_NewUserReceivesSignupGrantOnce(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	wallets := &memoryWalletRepository{wallets: make(map[uuid.UUID]*models.Wallet)}
+	ledgerOps := &grantingLedgerOperations{wallets: wallets, grants: make(map[uuid.UUID]int)}
+	grant := decimal.RequireFromString("100.00")
+	service := NewAuthService(&stubUserRepository{wallets: wallets}, wallets, auth.NewJWTManager("test-secret", "ndr"), testBotToken, logger,
+		WithSignupGrant(ledgerOps, grant))
+	initData := signedInitData(t, TelegramUser{ID: 42, FirstName: "Racer"})
+
+	// The wallet is created with the grant; logging in again does not grant again
+	var userID uuid.UUID
+	for i := 0; i < 3; i++ {
+		result, err := service.Authenticate(context.Background(), initData)
+		require.NoError(t, err)
+		userID = result.User.ID
+	}
+
+	assert.Zero(t, ledgerOps.grants[userID])
+	assert.Equal(t, "100.00", wallets.wallets[userID].FuelBalance.StringFixed(2))
+}
+
+func TestAuthenticate_WalletlessUserReceivesWalletAndGrantOnce(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	userID := uuid.New()
+	wallets := &memoryWalletRepository{wallets: make(map[uuid.UUID]*models.Wallet)}
+	users := &stubUserRepository{wallets: wallets, users: map[int64]*models.User{42: {ID: userID, TelegramID: 42, TelegramFirstName: "Racer"}}}
+	ledgerOps := &grantingLedgerOperations{wallets: wallets, grants: make(map[uuid.UUID]int)}
+	service := NewAuthService(users, wallets, auth.NewJWTManager("test-secret", "ndr"), testBotToken, logger,
+		WithSignupGrant(ledgerOps, decimal.RequireFromString("100.00")))
+	initData := signedInitData(t, TelegramUser{ID: 42, FirstName: "Racer"})
+
+	for i := 0; i < 3; i++ {
+		_, err := service.Authenticate(context.Background(), initData)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, ledgerOps.grants[userID])
+	assert.Equal(t, "100.00", wallets.wallets[userID].FuelBalance.StringFixed(2))
+}
+
+func TestAuthenticate_NoSignupGrantWhenDisabled(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	wallets := &memoryWalletRepository{wallets: make(map[uuid.UUID]*models.Wallet)}
+	ledgerOps := &grantingLedgerOperations{wallets: wallets, grants: make(map[uuid.UUID]int)}
+	service := NewAuthService(&stubUserRepository{wallets: wallets}, wallets, auth.NewJWTManager("test-secret", "ndr"), testBotToken, logger,
+		WithSignupGrant(ledgerOps, decimal.Zero))
+
+	result, err := service.Authenticate(context.Background(), signedInitData(t, TelegramUser{ID: 42, FirstName: "Racer"}))
+	require.NoError(t, err)
+	assert.Zero(t, ledgerOps.grants[result.User.ID])
+	assert.True(t, wallets.wallets[result.User.ID].FuelBalance.IsZero())
+}
+
+func TestAuthenticate_ReferralCreditedOncePerReferredUser(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	wallets := &memoryWalletRepository{wallets: make(map[uuid.UUID]*models.Wallet)}
+	users := &stubUserRepository{wallets: wallets}
+	ledgerOps := &grantingLedgerOperations{wallets: wallets, grants: make(map[uuid.UUID]int), referrals: make(map[uuid.UUID]uuid.UUID)}
+	service := NewAuthService(users, wallets, auth.NewJWTManager("test-secret", "ndr"), testBotToken, logger,
+		WithReferralBonus(ledgerOps, decimal.RequireFromString("10.00")))
+	ctx := context.Background()
+
+	referrer, err := service.Authenticate(ctx, signedInitData(t, TelegramUser{ID: 1001, FirstName: "Referrer"}))
+	require.NoError(t, err)
+
+	// The referred player signs up through the link, then opens it again
+	link := map[string]string{"start_param": "ref_1001"}
+	referred, err := service.Authenticate(ctx, signedInitDataWith(t, TelegramUser{ID: 2002, FirstName: "Referred"}, link))
+	require.NoError(t, err)
+	_, err = service.Authenticate(ctx, signedInitDataWith(t, TelegramUser{ID: 2002, FirstName: "Referred"}, link))
+	require.NoError(t, err)
+
+	// Existing players and self-referrals earn nothing
+	_, err = service.Authenticate(ctx, signedInitDataWith(t, TelegramUser{ID: 1001, FirstName: "Referrer"}, link))
+	require.NoError(t, err)
+	self, err := service.Authenticate(ctx, signedInitDataWith(t, TelegramUser{ID: 3003, FirstName: "Self"}, map[string]string{"start_param": "ref_3003"}))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[uuid.UUID]uuid.UUID{referred.User.ID: referrer.User.ID}, ledgerOps.referrals)
+	assert.Equal(t, "10.00", wallets.wallets[referrer.User.ID].FuelBalance.StringFixed(2))
+	assert.True(t, wallets.wallets[referred.User.ID].FuelBalance.IsZero())
+	assert.True(t, wallets.wallets[self.User.ID].FuelBalance.IsZero())
+}
+
+func TestAuthenticate_InitDataMaxAge(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	wallets := &memoryWalletRepository{wallets: make(map[uuid.UUID]*models.Wallet)}
+	service := NewAuthService(&stubUserRepository{wallets: wallets}, wallets, auth.NewJWTManager("test-secret", "ndr"), testBotToken, logger,
+		WithInitDataMaxAge(time.Hour))
+	user := TelegramUser{ID: 42, FirstName: "Racer"}
+
+	_, err := service.Authenticate(context.Background(), signedInitDataAt(t, user, nil, time.Now().Add(-30*time.Minute)))
+	assert.NoError(t, err)
+
+	_, err = service.Authenticate(context.Background(), signedInitDataAt(t, user, nil, time.Now().Add(-2*time.Hour)))
+	assert.ErrorIs(t, err, ErrExpiredInitData)
+}
+
+func TestRefreshToken_ExchangesOnlyRefreshTokens(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	wallets := &memoryWalletRepository{wallets: make(map[uuid.UUID]*models.Wallet)}
+	service := NewAuthService(&stubUserRepository{wallets: wallets}, wallets, auth.NewJWTManager("test-secret", "ndr"), testBotToken, logger)
+	ctx := context.Background()
+
+	login, err := service.Authenticate(ctx, signedInitData(t, TelegramUser{ID: 42, FirstName: "Racer"}))
+	require.NoError(t, err)
+	require.NotEmpty(t, login.Tokens.RefreshToken)
+
+	refreshed, err := service.RefreshToken(ctx, login.Tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, login.User.ID, refreshed.User.ID)
+
+	// The new access token authenticates API requests, the refresh token does not
+	claims, err := service.ValidateToken(ctx, refreshed.Tokens.AppToken)
+	require.NoError(t, err)
+	assert.Equal(t, login.User.ID, claims.UserID)
+	_, err = service.ValidateToken(ctx, refreshed.Tokens.RefreshToken)
+	assert.Error(t, err)
+
+	// Access and Centrifugo tokens cannot be exchanged
+	_, err = service.RefreshToken(ctx, login.Tokens.AppToken)
+	assert.Error(t, err)
+	_, err = service.RefreshToken(ctx, login.Tokens.CentrifugoToken)
+	assert.Error(t, err)
+}
+
+func TestAuthenticate_AdvertisedExpiryMatchesAccessToken(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	jwtManager := auth.NewJWTManager("test-secret", "ndr")
+	wallets := &memoryWalletRepository{wallets: make(map[uuid.UUID]*models.Wallet)}
+	service := NewAuthService(&stubUserRepository{wallets: wallets}, wallets, jwtManager, testBotToken, logger,
+		WithAccessTokenTTL(90*time.Minute))
+	ctx := context.Background()
+
+	login, err := service.Authenticate(ctx, signedInitData(t, TelegramUser{ID: 42, FirstName: "Racer"}))
+	require.NoError(t, err)
+	refreshed, err := service.RefreshToken(ctx, login.Tokens.RefreshToken)
+	require.NoError(t, err)
+
+	for _, tokens := range []TokenPair{login.Tokens, refreshed.Tokens} {
+		claims, err := jwtManager.ValidateToken(tokens.AppToken, auth.TokenTypeApp)
+		require.NoError(t, err)
+		remaining := time.Until(claims.ExpiresAt.Time)
+
+		assert.InDelta(t, (90 * time.Minute).Seconds(), remaining.Seconds(), 2)
+		assert.InDelta(t, remaining.Seconds(), float64(tokens.ExpiresIn), 2)
+		expiresAt, err := time.Parse(time.RFC3339, tokens.ExpiresAt)
+		require.NoError(t, err)
+		assert.WithinDuration(t, claims.ExpiresAt.Time, expiresAt, time.Second)
+	}
+}
+
+func TestAuthenticate_StoresSanitizedNames(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	tests := []struct {
+		firstName string
+		want      string
+	}{
+		{firstName: "Nitro \U0001F3CE\ufe0f\U0001F525", want: "Nitro \U0001F3CE\ufe0f\U0001F525"},
+		{firstName: "José Müller", want: "José Müller"},
+		{firstName: "\u202eresaR\u200b\x00\n", want: "resaR"},
+		{firstName: "System", want: ""},
+	}
+
+	for i, tt := range tests {
+		wallets := &memoryWalletRepository{wallets: make(map[uuid.UUID]*models.Wallet)}
+		service := NewAuthService(&stubUserRepository{wallets: wallets}, wallets, auth.NewJWTManager("test-secret", "ndr"), testBotToken, logger)
+
+		result, err := service.Authenticate(context.Background(), signedInitData(t, TelegramUser{ID: int64(100 + i), FirstName: tt.firstName}))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, result.User.TelegramFirstName, tt.firstName)
+	}
+}
@@ -1,8 +1,13 @@
 package auth
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,28 +20,44 @@ var (
 	ErrExpiredInitData     = errors.New("telegram init data expired")
 	ErrInvalidHash         = errors.New("invalid telegram init data hash")
 	ErrMissingRequiredData = errors.New("missing required telegram data")
+	ErrInvalidSignature    = errors.New("invalid telegram init data signature")
+	ErrFutureInitData      = errors.New("telegram init data signed in the future")
 )
 
+// DefaultInitDataMaxAge is how long signed initData is accepted after its auth_date by default
+const DefaultInitDataMaxAge = 24 * time.Hour
+
+// DefaultInitDataFutureTolerance is how far ahead of server time an auth_date may be by default,
+// absorbing clock skew between Telegram and the server
+const DefaultInitDataFutureTolerance = 30 * time.Second
+
+// TelegramProductionPublicKey is the hex-encoded Ed25519 key Telegram signs production initData with
+const TelegramProductionPublicKey = "e7bf03a2fa4602af4580703d88dda5bb59f32ed8b02a56c187fe7d34caed242d"
+
 // TelegramUser represents the user data from Telegram initData
 type TelegramUser struct {
-	ID        int64  `json:"id"`
-	Username  string `json:"username,omitempty"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name,omitempty"`
-	PhotoURL  string `json:"photo_url,omitempty"`
+	ID           int64  `json:"id"`
+	Username     string `json:"username,omitempty"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name,omitempty"`
+	PhotoURL     string `json:"photo_url,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
+	IsPremium    bool   `json:"is_premium,omitempty"`
 }
 
 // TelegramInitData represents the parsed Telegram Web App initData
 type TelegramInitData struct {
-	User     *TelegramUser `json:"user"`
-	AuthDate int64         `json:"auth_date"`
-	Hash     string        `json:"hash"`
-	QueryID  string        `json:"query_id,omitempty"`
+	User       *TelegramUser `json:"user"`
+	AuthDate   int64         `json:"auth_date"`
+	Hash       string        `json:"hash"`
+	QueryID    string        `json:"query_id,omitempty"`
+	StartParam string        `json:"start_param,omitempty"` // Deep link parameter the Mini App was opened with
 }
 
 // ValidateTelegramInitData validates the Telegram Web App initData
-// using the official telegram-mini-apps/init-data-golang package
-func ValidateTelegramInitData(initDataRaw, botToken string) (*TelegramInitData, error) {
+// using the official telegram-mini-apps/init-data-golang package.
+// Data signed more than maxAge ago, or more than futureTolerance ahead of server time, is rejected.
+func ValidateTelegramInitData(initDataRaw, botToken string, maxAge, futureTolerance time.Duration) (*TelegramInitData, error) {
 	if initDataRaw == "" {
 		return nil, ErrInvalidInitData
 	}
@@ -52,21 +73,109 @@ func ValidateTelegramInitData(initDataRaw, botToken string) (*TelegramInitData,
 		return nil, fmt.Errorf("invalid bot ID in token: %w", err)
 	}
 
-	// Set expiration time to 24 hours
-	expIn := 24 * time.Hour
+	// A non-positive age would disable the expiry check in the package
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("initData max age must be positive")
+	}
+	expIn := maxAge
+
+	// The package accepts any future auth_date, so reject tampered ones first
+	if values, err := url.ParseQuery(initDataRaw); err == nil {
+		if authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64); err == nil {
+			if err := checkAuthDateNotFuture(authDate, futureTolerance); err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	// Try both validation methods since we have both hash and signature fields
 
 	// First, try regular bot token validation
-	err = initdata.Validate(initDataRaw, botToken, expIn)
-	if err != nil {
+	hashErr := initdata.Validate(initDataRaw, botToken, expIn)
+	if hashErr != nil {
 		// Try third-party validation with bot ID
 		err = initdata.ValidateThirdParty(initDataRaw, botID, expIn)
+		if err != nil && (errors.Is(hashErr, initdata.ErrExpired) || errors.Is(err, initdata.ErrExpired)) {
+			return nil, fmt.Errorf("%w: validation failed with both methods: %w", ErrExpiredInitData, err)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("validation failed with both methods: %w", err)
 		}
 	}
 
+	return parseTelegramInitData(initDataRaw)
+}
+
+// ValidateTelegramInitDataSignature validates the Telegram Web App initData by its Ed25519 signature,
+// which needs only the bot ID and Telegram's public key rather than the bot token.
+// Data signed more than maxAge ago, or more than futureTolerance ahead of server time, is rejected.
+func ValidateTelegramInitDataSignature(initDataRaw string, botID int64, publicKey ed25519.PublicKey, maxAge, futureTolerance time.Duration) (*TelegramInitData, error) {
+	if initDataRaw == "" {
+		return nil, ErrInvalidInitData
+	}
+
+	values, err := url.ParseQuery(initDataRaw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInitData, err)
+	}
+
+	// The signed payload is every pair except hash and signature, sorted by key
+	pairs := make([]string, 0, len(values))
+	for key, value := range values {
+		if key == "hash" || key == "signature" {
+			continue
+		}
+		pairs = append(pairs, key+"="+value[0])
+	}
+	sort.Strings(pairs)
+
+	// Telegram encodes the signature as unpadded base64url
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(values.Get("signature"), "="))
+	if err != nil || len(signature) == 0 {
+		return nil, ErrInvalidSignature
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid auth_date", ErrInvalidInitData)
+	}
+	if time.Unix(authDate, 0).Add(maxAge).Before(time.Now()) {
+		return nil, ErrExpiredInitData
+	}
+	if err := checkAuthDateNotFuture(authDate, futureTolerance); err != nil {
+		return nil, err
+	}
+
+	payload := fmt.Sprintf("%d:WebAppData\n%s", botID, strings.Join(pairs, "\n"))
+	if len(publicKey) != ed25519.PublicKeySize || !ed25519.Verify(publicKey, []byte(payload), signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	return parseTelegramInitData(initDataRaw)
+}
+
+// checkAuthDateNotFuture rejects an auth_date further ahead of server time than the tolerance
+func checkAuthDateNotFuture(authDate int64, futureTolerance time.Duration) error {
+	if ahead := time.Until(time.Unix(authDate, 0)); ahead > futureTolerance {
+		return fmt.Errorf("%w: auth_date is %s ahead", ErrFutureInitData, ahead.Round(time.Second))
+	}
+	return nil
+}
+
+// ParseTelegramPublicKey decodes a hex-encoded Ed25519 public key
+func ParseTelegramPublicKey(hexKey string) (ed25519.PublicKey, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// parseTelegramInitData converts validated initData into our internal format
+func parseTelegramInitData(initDataRaw string) (*TelegramInitData, error) {
 	// Parse the validated initData
 	parsedData, err := initdata.Parse(initDataRaw)
 	if err != nil {
@@ -79,17 +188,20 @@ func ValidateTelegramInitData(initDataRaw, botToken string) (*TelegramInitData,
 	}
 
 	user := &TelegramUser{
-		ID:        parsedData.User.ID,
-		Username:  parsedData.User.Username,
-		FirstName: parsedData.User.FirstName,
-		LastName:  parsedData.User.LastName,
-		PhotoURL:  parsedData.User.PhotoURL,
+		ID:           parsedData.User.ID,
+		Username:     parsedData.User.Username,
+		FirstName:    parsedData.User.FirstName,
+		LastName:     parsedData.User.LastName,
+		PhotoURL:     parsedData.User.PhotoURL,
+		LanguageCode: parsedData.User.LanguageCode,
+		IsPremium:    parsedData.User.IsPremium,
 	}
 
 	return &TelegramInitData{
-		User:     user,
-		AuthDate: parsedData.AuthDate().Unix(),
-		Hash:     parsedData.Hash,
-		QueryID:  parsedData.QueryID,
+		User:       user,
+		AuthDate:   parsedData.AuthDate().Unix(),
+		Hash:       parsedData.Hash,
+		QueryID:    parsedData.QueryID,
+		StartParam: parsedData.StartParam,
 	}, nil
 }
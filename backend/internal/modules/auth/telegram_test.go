@@ -1,13 +1,28 @@
 package auth
 
 import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/auth"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
 )
 
 func TestValidateTelegramInitData_EmptyInitData(t *testing.T) {
-	result, err := ValidateTelegramInitData("", "123456:ABC-DEF")
+	result, err := ValidateTelegramInitData("", "123456:ABC-DEF", DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
 
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidInitData, err)
@@ -17,7 +32,7 @@ func TestValidateTelegramInitData_EmptyInitData(t *testing.T) {
 func TestValidateTelegramInitData_InvalidBotToken(t *testing.T) {
 	initData := "user=%7B%22id%22%3A123%7D&auth_date=1234567890&hash=abc123"
 
-	result, err := ValidateTelegramInitData(initData, "invalid-token")
+	result, err := ValidateTelegramInitData(initData, "invalid-token", DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid bot token format")
@@ -29,7 +44,7 @@ func TestValidateTelegramInitData_InvalidInitDataFormat(t *testing.T) {
 	initData := "invalid_format"
 	botToken := "123456:ABC-DEF"
 
-	result, err := ValidateTelegramInitData(initData, botToken)
+	result, err := ValidateTelegramInitData(initData, botToken, DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "validation failed with both methods")
@@ -41,7 +56,7 @@ func TestValidateTelegramInitData_ExpiredData(t *testing.T) {
 	initData := "user=%7B%22id%22%3A123%2C%22first_name%22%3A%22Test%22%7D&auth_date=1000000000&hash=invalid"
 	botToken := "123456:ABC-DEF"
 
-	result, err := ValidateTelegramInitData(initData, botToken)
+	result, err := ValidateTelegramInitData(initData, botToken, DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "validation failed with both methods")
@@ -85,7 +100,7 @@ func TestValidateTelegramInitData_BotTokenExtraction(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// We test the bot ID extraction logic indirectly by checking the error messages
-			result, err := ValidateTelegramInitData("dummy", tt.token)
+			result, err := ValidateTelegramInitData("dummy", tt.token, DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -98,3 +113,179 @@ func TestValidateTelegramInitData_BotTokenExtraction(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTelegramInitData_ProfileFields(t *testing.T) {
+	initData := signedInitData(t, TelegramUser{
+		ID:           42,
+		Username:     "racer",
+		FirstName:    "Nitro",
+		LastName:     "Racer",
+		PhotoURL:     "https://t.me/i/userpic/320/racer.jpg",
+		LanguageCode: "pt-br",
+		IsPremium:    true,
+	})
+
+	result, err := ValidateTelegramInitData(initData, testBotToken, DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://t.me/i/userpic/320/racer.jpg", result.User.PhotoURL)
+	assert.Equal(t, "pt-br", result.User.LanguageCode)
+	assert.True(t, result.User.IsPremium)
+}
+
+func TestValidateTelegramInitData_StartParam(t *testing.T) {
+	initData := signedInitDataWith(t, TelegramUser{ID: 42, FirstName: "Nitro"}, map[string]string{"start_param": "ref_1001"})
+
+	result, err := ValidateTelegramInitData(initData, testBotToken, DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ref_1001", result.StartParam)
+}
+
+func TestParseReferrerTelegramID(t *testing.T) {
+	tests := []struct {
+		startParam string
+		want       int64
+		ok         bool
+	}{
+		{startParam: "ref_1001", want: 1001, ok: true},
+		{startParam: "", ok: false},
+		{startParam: "promo_summer", ok: false},
+		{startParam: "ref_", ok: false},
+		{startParam: "ref_abc", ok: false},
+		{startParam: "ref_-5", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.startParam, func(t *testing.T) {
+			got, ok := ParseReferrerTelegramID(tt.startParam)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// testTelegramKey is a fixed Ed25519 key standing in for Telegram's signing key
+var testTelegramKey = ed25519.NewKeyFromSeed([]byte("ndr-test-telegram-signing-key-32"))
+
+// signatureInitData returns initData for a Telegram user signed like Telegram's third-party signature
+func signatureInitData(t *testing.T, botID int64, user TelegramUser, authDate time.Time) string {
+	userJSON, err := json.Marshal(user)
+	require.NoError(t, err)
+
+	values := url.Values{}
+	values.Set("user", string(userJSON))
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+	payload := fmt.Sprintf("%d:WebAppData\nauth_date=%s\nuser=%s", botID, values.Get("auth_date"), userJSON)
+	values.Set("signature", base64.RawURLEncoding.EncodeToString(ed25519.Sign(testTelegramKey, []byte(payload))))
+	values.Set("hash", "unused-by-signature-validation")
+	return values.Encode()
+}
+
+func TestValidateTelegramInitDataSignature(t *testing.T) {
+	publicKey := testTelegramKey.Public().(ed25519.PublicKey)
+	user := TelegramUser{ID: 42, FirstName: "Nitro"}
+	valid := signatureInitData(t, 123456, user, time.Now())
+
+	t.Run("valid signature", func(t *testing.T) {
+		result, err := ValidateTelegramInitDataSignature(valid, 123456, publicKey, DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
+		require.NoError(t, err)
+		assert.Equal(t, int64(42), result.User.ID)
+		assert.Equal(t, "Nitro", result.User.FirstName)
+	})
+
+	t.Run("tampered user", func(t *testing.T) {
+		tampered := strings.Replace(valid, "Nitro", "Admin", 1)
+		_, err := ValidateTelegramInitDataSignature(tampered, 123456, publicKey, DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("other bot", func(t *testing.T) {
+		_, err := ValidateTelegramInitDataSignature(valid, 654321, publicKey, DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		otherKey, err := ParseTelegramPublicKey(TelegramProductionPublicKey)
+		require.NoError(t, err)
+		_, err = ValidateTelegramInitDataSignature(valid, 123456, otherKey, DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := signatureInitData(t, 123456, user, time.Now().Add(-25*time.Hour))
+		_, err := ValidateTelegramInitDataSignature(expired, 123456, publicKey, DefaultInitDataMaxAge, DefaultInitDataFutureTolerance)
+		assert.ErrorIs(t, err, ErrExpiredInitData)
+	})
+}
+
+func TestAuthenticate_SignatureValidation(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	// No bot token: only the bot ID and Telegram's public key are needed
+	wallets := &memoryWalletRepository{wallets: make(map[uuid.UUID]*models.Wallet)}
+	service := NewAuthService(&stubUserRepository{wallets: wallets}, wallets, auth.NewJWTManager("test-secret", "ndr"), "", logger,
+		WithInitDataSignature(123456, testTelegramKey.Public().(ed25519.PublicKey)))
+
+	result, err := service.Authenticate(context.Background(), signatureInitData(t, 123456, TelegramUser{ID: 42, FirstName: "Nitro"}, time.Now()))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), result.User.TelegramID)
+
+	// HMAC-signed initData is not accepted in signature mode
+	_, err = service.Authenticate(context.Background(), signedInitData(t, TelegramUser{ID: 42, FirstName: "Nitro"}))
+	assert.Error(t, err)
+}
+
+func TestValidateTelegramInitData_MaxAge(t *testing.T) {
+	user := TelegramUser{ID: 42, FirstName: "Nitro"}
+
+	t.Run("hash within window", func(t *testing.T) {
+		_, err := ValidateTelegramInitData(signedInitDataAt(t, user, nil, time.Now().Add(-30*time.Minute)), testBotToken, time.Hour, DefaultInitDataFutureTolerance)
+		assert.NoError(t, err)
+	})
+
+	t.Run("hash beyond window", func(t *testing.T) {
+		_, err := ValidateTelegramInitData(signedInitDataAt(t, user, nil, time.Now().Add(-2*time.Hour)), testBotToken, time.Hour, DefaultInitDataFutureTolerance)
+		assert.ErrorIs(t, err, ErrExpiredInitData)
+	})
+
+	t.Run("signature within window", func(t *testing.T) {
+		initData := signatureInitData(t, 123456, user, time.Now().Add(-30*time.Minute))
+		_, err := ValidateTelegramInitDataSignature(initData, 123456, testTelegramKey.Public().(ed25519.PublicKey), time.Hour, DefaultInitDataFutureTolerance)
+		assert.NoError(t, err)
+	})
+
+	t.Run("signature beyond window", func(t *testing.T) {
+		initData := signatureInitData(t, 123456, user, time.Now().Add(-2*time.Hour))
+		_, err := ValidateTelegramInitDataSignature(initData, 123456, testTelegramKey.Public().(ed25519.PublicKey), time.Hour, DefaultInitDataFutureTolerance)
+		assert.ErrorIs(t, err, ErrExpiredInitData)
+	})
+}
+
+func TestValidateTelegramInitData_FutureAuthDate(t *testing.T) {
+	user := TelegramUser{ID: 42, FirstName: "Nitro"}
+	publicKey := testTelegramKey.Public().(ed25519.PublicKey)
+
+	t.Run("hash within tolerance", func(t *testing.T) {
+		_, err := ValidateTelegramInitData(signedInitDataAt(t, user, nil, time.Now().Add(10*time.Second)), testBotToken, time.Hour, 30*time.Second)
+		assert.NoError(t, err)
+	})
+
+	t.Run("hash beyond tolerance", func(t *testing.T) {
+		_, err := ValidateTelegramInitData(signedInitDataAt(t, user, nil, time.Now().Add(5*time.Minute)), testBotToken, time.Hour, 30*time.Second)
+		assert.ErrorIs(t, err, ErrFutureInitData)
+	})
+
+	t.Run("signature within tolerance", func(t *testing.T) {
+		initData := signatureInitData(t, 123456, user, time.Now().Add(10*time.Second))
+		_, err := ValidateTelegramInitDataSignature(initData, 123456, publicKey, time.Hour, 30*time.Second)
+		assert.NoError(t, err)
+	})
+
+	t.Run("signature beyond tolerance", func(t *testing.T) {
+		initData := signatureInitData(t, 123456, user, time.Now().Add(5*time.Minute))
+		_, err := ValidateTelegramInitDataSignature(initData, 123456, publicKey, time.Hour, 30*time.Second)
+		assert.ErrorIs(t, err, ErrFutureInitData)
+	})
+}
@@ -0,0 +1,168 @@
+package gameengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	monetary "github.com/megaherz/ndr/internal/decimal"
+	"github.com/megaherz/ndr/internal/metrics"
+	"github.com/megaherz/ndr/internal/modules/gateway"
+	"github.com/megaherz/ndr/internal/modules/gateway/events"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// ErrMatchNotAbortable is returned when a match has already completed, been paid out or been aborted
+var ErrMatchNotAbortable = errors.New("match cannot be aborted")
+
+// MatchAborter force-aborts matches that can no longer finish normally
+type MatchAborter interface {
+	// AbortMatch stops a match, refunds all buy-ins and releases its runtime state
+	AbortMatch(ctx context.Context, matchID uuid.UUID, reason string) (*AbortResult, error)
+}
+
+// AbortResult describes the outcome of a forced match abort
+type AbortResult struct {
+	MatchID        uuid.UUID             `json:"match_id"`
+	PreviousStatus models.MatchStatus    `json:"previous_status"`
+	Reason         string                `json:"reason"`
+	AbortedAt      time.Time             `json:"aborted_at"`
+	Refunds        []*models.LedgerEntry `json:"refunds"`
+}
+
+// matchAborter implements MatchAborter
+type matchAborter struct {
+	matchRepo    repository.MatchRepository
+	heatManager  HeatManager
+	stateManager MatchStateManager
+	settlement   SettlementService
+	publisher    gateway.CentrifugoPublisher
+	metrics      *metrics.Metrics
+	logger       *logrus.Logger
+}
+
+// MatchAborterOption configures optional match aborter behaviour
+type MatchAborterOption func(*matchAborter)
+
+// WithAbortMetrics records how long each aborted match ran before it was aborted
+func WithAbortMetrics(m *metrics.Metrics) MatchAborterOption {
+	return func(a *matchAborter) {
+		a.metrics = m
+	}
+}
+
+// NewMatchAborter creates a new match aborter
+func NewMatchAborter(
+	matchRepo repository.MatchRepository,
+	heatManager HeatManager,
+	stateManager MatchStateManager,
+	settlement SettlementService,
+	publisher gateway.CentrifugoPublisher,
+	logger *logrus.Logger,
+	opts ...MatchAborterOption,
+) MatchAborter {
+	a := &matchAborter{
+		matchRepo:    matchRepo,
+		heatManager:  heatManager,
+		stateManager: stateManager,
+		settlement:   settlement,
+		publisher:    publisher,
+		logger:       logger,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// AbortMatch stops a match, refunds all buy-ins and releases its runtime state
+func (a *matchAborter) AbortMatch(ctx context.Context, matchID uuid.UUID, reason string) (*AbortResult, error) {
+	ctx = repository.WithPrimaryReads(ctx)
+
+	match, err := a.matchRepo.GetByID(ctx, matchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get match: %w", err)
+	}
+	if match == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMatchNotFound, matchID)
+	}
+
+	if match.Status == models.MatchStatusCompleted || match.Status == models.MatchStatusAborted {
+		return nil, fmt.Errorf("%w: match is %s", ErrMatchNotAbortable, match.Status)
+	}
+
+	// Stop heat transitions first so nothing advances the match while it is torn down
+	a.heatManager.CancelHeatTimers(matchID)
+
+	// The refunds and the ABORTED status commit together: a failed refund leaves the match
+	// abortable, so the operator can retry, and a settlement that got there first wins
+	refunds, err := a.settlement.RefundMatch(ctx, matchID)
+	if errors.Is(err, ErrMatchAlreadySettled) {
+		return nil, fmt.Errorf("%w: %w", ErrMatchNotAbortable, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to refund match: %w", err)
+	}
+
+	if err := a.stateManager.RemoveMatchState(ctx, matchID); err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+			"error":    err,
+		}).Error("Failed to remove match state after abort")
+	}
+
+	result := &AbortResult{
+		MatchID:        matchID,
+		PreviousStatus: match.Status,
+		Reason:         reason,
+		AbortedAt:      time.Now(),
+		Refunds:        refunds,
+	}
+	recordMatchDuration(a.metrics, match, result.AbortedAt)
+
+	if err := a.publishMatchAbortedEvent(ctx, result); err != nil {
+		a.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+			"error":    err,
+		}).Error("Failed to publish match aborted event")
+		// Continue anyway - the match is aborted
+	}
+
+	a.logger.WithFields(logrus.Fields{
+		"match_id":        matchID,
+		"previous_status": match.Status,
+		"reason":          reason,
+		"refund_count":    len(refunds),
+	}).Warn("Match aborted")
+
+	return result, nil
+}
+
+// publishMatchAbortedEvent publishes match_aborted event to match channel
+func (a *matchAborter) publishMatchAbortedEvent(ctx context.Context, result *AbortResult) error {
+	refunds := make([]events.RefundEntry, 0, len(result.Refunds))
+	for _, entry := range result.Refunds {
+		if entry.UserID == nil {
+			continue // Ghost buy-ins return to HOUSE_FUEL
+		}
+		refunds = append(refunds, events.RefundEntry{
+			UserID:   *entry.UserID,
+			Currency: string(entry.Currency),
+			Amount:   monetary.NewMoney(entry.Amount),
+		})
+	}
+
+	abortedEvent := &events.MatchAbortedEvent{
+		MatchID:   result.MatchID,
+		Reason:    result.Reason,
+		AbortedAt: result.AbortedAt,
+		Refunds:   refunds,
+	}
+
+	return a.publisher.PublishToMatch(ctx, result.MatchID, events.EventMatchAborted, abortedEvent)
+}
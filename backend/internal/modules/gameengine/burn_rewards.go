@@ -0,0 +1,91 @@
+package gameengine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// BurnRewardTables holds the BURN paid to live players by final position, per league
+type BurnRewardTables map[string]map[int]decimal.Decimal
+
+// defaultBurnRewardTables are the launch BURN tables, used for leagues the database does not configure
+var defaultBurnRewardTables = BurnRewardTables{
+	constants.LeagueRookie: {
+		// Rookie league has no BURN rewards (FR-038)
+	},
+	constants.LeagueStreet: {
+		1: decimal.NewFromInt(50), // 1st place: 50 BURN
+		2: decimal.NewFromInt(30), // 2nd place: 30 BURN
+		3: decimal.NewFromInt(20), // 3rd place: 20 BURN
+		4: decimal.NewFromInt(10), // 4th place: 10 BURN
+		5: decimal.NewFromInt(5),  // 5th place: 5 BURN
+	},
+	constants.LeaguePro: {
+		1: decimal.NewFromInt(300), // 1st place: 300 BURN
+		2: decimal.NewFromInt(200), // 2nd place: 200 BURN
+		3: decimal.NewFromInt(150), // 3rd place: 150 BURN
+		4: decimal.NewFromInt(100), // 4th place: 100 BURN
+		5: decimal.NewFromInt(75),  // 5th place: 75 BURN
+		6: decimal.NewFromInt(50),  // 6th place: 50 BURN
+		7: decimal.NewFromInt(25),  // 7th place: 25 BURN
+	},
+	constants.LeagueTopFuel: {
+		1:  decimal.NewFromInt(3000), // 1st place: 3000 BURN
+		2:  decimal.NewFromInt(2000), // 2nd place: 2000 BURN
+		3:  decimal.NewFromInt(1500), // 3rd place: 1500 BURN
+		4:  decimal.NewFromInt(1000), // 4th place: 1000 BURN
+		5:  decimal.NewFromInt(750),  // 5th place: 750 BURN
+		6:  decimal.NewFromInt(500),  // 6th place: 500 BURN
+		7:  decimal.NewFromInt(400),  // 7th place: 400 BURN
+		8:  decimal.NewFromInt(300),  // 8th place: 300 BURN
+		9:  decimal.NewFromInt(200),  // 9th place: 200 BURN
+		10: decimal.NewFromInt(100),  // 10th place: 100 BURN
+	},
+}
+
+// DefaultBurnRewardTables returns a copy of the compiled-in BURN tables
+func DefaultBurnRewardTables() BurnRewardTables {
+	tables := make(BurnRewardTables, len(defaultBurnRewardTables))
+	for league := range defaultBurnRewardTables {
+		tables[league] = defaultBurnRewardTables.For(league)
+	}
+	return tables
+}
+
+// LoadBurnRewardTables builds the BURN tables from the database. A league with rows in the
+// database uses exactly those rows; a league without any keeps its compiled-in default table.
+func LoadBurnRewardTables(ctx context.Context, repo repository.BurnRewardRepository) (BurnRewardTables, error) {
+	rewards, err := repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list BURN rewards: %w", err)
+	}
+
+	loaded := make(BurnRewardTables)
+	for _, reward := range rewards {
+		league := string(reward.League)
+		if loaded[league] == nil {
+			loaded[league] = make(map[int]decimal.Decimal)
+		}
+		loaded[league][reward.Position] = reward.Amount
+	}
+
+	tables := DefaultBurnRewardTables()
+	for league, table := range loaded {
+		tables[league] = table
+	}
+	return tables, nil
+}
+
+// For returns a copy of a league's BURN table, empty when the league pays no BURN
+func (t BurnRewardTables) For(league string) map[int]decimal.Decimal {
+	table := make(map[int]decimal.Decimal, len(t[league]))
+	for position, amount := range t[league] {
+		table[position] = amount
+	}
+	return table
+}
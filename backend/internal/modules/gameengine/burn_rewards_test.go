@@ -0,0 +1,103 @@
+package gameengine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// stubBurnRewardRepository serves fixed BURN reward rows
+type stubBurnRewardRepository struct {
+	rewards []*models.BurnReward
+}
+
+func (r *stubBurnRewardRepository) List(ctx context.Context) ([]*models.BurnReward, error) {
+	return r.rewards, nil
+}
+
+// recordingSettlementRepository keeps the settlement records created
+type recordingSettlementRepository struct {
+	repository.MatchSettlementRepository
+	created []*models.MatchSettlement
+}
+
+func (r *recordingSettlementRepository) Create(ctx context.Context, settlement *models.MatchSettlement) error {
+	r.created = append(r.created, settlement)
+	return nil
+}
+
+func TestLoadBurnRewardTables_OverridesConfiguredLeagues(t *testing.T) {
+	repo := &stubBurnRewardRepository{rewards: []*models.BurnReward{
+		{League: models.LeagueStreet, Position: 1, Amount: decimal.NewFromInt(80)},
+		{League: models.LeagueStreet, Position: 2, Amount: decimal.NewFromInt(40)},
+	}}
+
+	tables, err := LoadBurnRewardTables(context.Background(), repo)
+	require.NoError(t, err)
+
+	// A configured league uses exactly its rows
+	street := tables.For(constants.LeagueStreet)
+	require.Len(t, street, 2)
+	assert.True(t, street[1].Equal(decimal.NewFromInt(80)))
+	assert.True(t, street[2].Equal(decimal.NewFromInt(40)))
+
+	// Leagues without rows keep their defaults
+	assert.Equal(t, DefaultBurnRewardTables().For(constants.LeaguePro), tables.For(constants.LeaguePro))
+	assert.Empty(t, tables.For(constants.LeagueRookie))
+	assert.Empty(t, tables.For("UNKNOWN"))
+}
+
+func TestSettleMatch_AppliesAndRecordsCustomBurnTable(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	matchRepo.created[0].League = models.LeagueStreet
+	winner := *participantRepo.created[0].UserID
+	third := *participantRepo.created[2].UserID
+
+	tables, err := LoadBurnRewardTables(context.Background(), &stubBurnRewardRepository{rewards: []*models.BurnReward{
+		{League: models.LeagueStreet, Position: 1, Amount: decimal.NewFromInt(75)},
+		{League: models.LeagueStreet, Position: 2, Amount: decimal.RequireFromString("12.50")},
+	}})
+	require.NoError(t, err)
+
+	settlementRepo := &recordingSettlementRepository{}
+	ledgerOps := &recordingLedgerOperations{}
+	settlement := NewSettlementService(matchRepo, participantRepo, settlementRepo, nil, ledgerOps, nil, &recordingPublisher{}, logger,
+		WithBurnRewardTables(tables))
+
+	result, err := settlement.SettleMatch(context.Background(), matchID)
+	require.NoError(t, err)
+
+	burnByUser := make(map[uuid.UUID]decimal.Decimal)
+	for _, entry := range ledgerOps.entries {
+		if entry.OperationType == models.OperationMatchBurnReward {
+			burnByUser[*entry.UserID] = entry.Amount
+		}
+	}
+	require.Len(t, burnByUser, 2)
+	assert.True(t, burnByUser[winner].Equal(decimal.NewFromInt(75)))
+	assert.True(t, result.Positions[1].BurnReward.Equal(decimal.RequireFromString("12.50")))
+	assert.True(t, result.Positions[2].BurnReward.IsZero(), "third place is not in the custom table")
+	_, paidThird := burnByUser[third]
+	assert.False(t, paidThird)
+
+	require.Len(t, settlementRepo.created, 1)
+	record := settlementRepo.created[0]
+	assert.Equal(t, matchID, record.MatchID)
+	recorded, err := record.GetBurnRewardTable()
+	require.NoError(t, err)
+	require.Len(t, recorded, 2)
+	assert.True(t, recorded[1].Equal(decimal.NewFromInt(75)))
+	assert.True(t, recorded[2].Equal(decimal.RequireFromString("12.50")))
+}
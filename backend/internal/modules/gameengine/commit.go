@@ -0,0 +1,104 @@
+package gameengine
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// ErrCommitUnavailable is returned when a match's seed commitment is no longer served
+var ErrCommitUnavailable = errors.New("seed commitment is only available before the match completes")
+
+// commitKeyLabel separates the commit signing key from other uses of the same secret
+const commitKeyLabel = "ndr-seed-commit"
+
+// SeedCommit is a match's crash seed hash published before play, with a signed
+// timestamp proving the hash was fixed when the match was created
+type SeedCommit struct {
+	MatchID       uuid.UUID          `json:"match_id"`
+	Status        models.MatchStatus `json:"status"`
+	CrashSeedHash string             `json:"crash_seed_hash"`
+	CommittedAt   time.Time          `json:"committed_at"`
+	Signature     string             `json:"signature"`  // Hex Ed25519 signature of match_id:crash_seed_hash:committed_at
+	PublicKey     string             `json:"public_key"` // Hex Ed25519 public key the signature verifies against
+}
+
+// CommitSigner signs seed commitments with an Ed25519 key. Its public key is published
+// with every commitment, so players can verify signatures without any server secret.
+type CommitSigner struct {
+	key ed25519.PrivateKey
+}
+
+// NewCommitSigner creates a commit signer with the given key
+func NewCommitSigner(key ed25519.PrivateKey) *CommitSigner {
+	return &CommitSigner{key: key}
+}
+
+// ParseCommitKey parses a hex-encoded 32-byte Ed25519 seed into a signing key
+func ParseCommitKey(hexSeed string) (ed25519.PrivateKey, error) {
+	seed, err := hex.DecodeString(hexSeed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("seed must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// CommitKeyFromSecret derives a signing key from secret, for deployments without a dedicated key
+func CommitKeyFromSecret(secret string) ed25519.PrivateKey {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(commitKeyLabel))
+	return ed25519.NewKeyFromSeed(mac.Sum(nil))
+}
+
+// PublicKey returns the key commitments are verified against
+func (s *CommitSigner) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// Sign returns the signature for a match's commit hash and commitment time
+func (s *CommitSigner) Sign(matchID uuid.UUID, commitHash string, committedAt time.Time) string {
+	return hex.EncodeToString(ed25519.Sign(s.key, commitMessage(matchID, commitHash, committedAt)))
+}
+
+// VerifySeedCommit reports whether a commit's signature matches its contents under publicKey
+func VerifySeedCommit(publicKey ed25519.PublicKey, commit *SeedCommit) bool {
+	signature, err := hex.DecodeString(commit.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(publicKey, commitMessage(commit.MatchID, commit.CrashSeedHash, commit.CommittedAt), signature)
+}
+
+// commitMessage returns the bytes a commitment signature covers
+func commitMessage(matchID uuid.UUID, commitHash string, committedAt time.Time) []byte {
+	return []byte(fmt.Sprintf("%s:%s:%s", matchID, commitHash, committedAt.UTC().Format(time.RFC3339Nano)))
+}
+
+// BuildSeedCommit returns the signed seed commitment of a match that has not finished yet.
+// Once a match completes its seed is revealed instead, so the commit is no longer served.
+func BuildSeedCommit(match *models.Match, signer *CommitSigner) (*SeedCommit, error) {
+	if match.Status != models.MatchStatusForming && match.Status != models.MatchStatusInProgress {
+		return nil, fmt.Errorf("%w: match is %s", ErrCommitUnavailable, match.Status)
+	}
+
+	committedAt := match.CreatedAt.UTC()
+	return &SeedCommit{
+		MatchID:       match.ID,
+		Status:        match.Status,
+		CrashSeedHash: match.CrashSeedHash,
+		CommittedAt:   committedAt,
+		Signature:     signer.Sign(match.ID, match.CrashSeedHash, committedAt),
+		PublicKey:     hex.EncodeToString(signer.PublicKey()),
+	}, nil
+}
@@ -0,0 +1,55 @@
+package gameengine
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+func TestBuildSeedCommit_SignsUntilCompletion(t *testing.T) {
+	signer := NewCommitSigner(CommitKeyFromSecret("test-secret"))
+	seedData, commitHash, err := GenerateMatchSeeds(uuid.New(), WithSeedSource(NewDeterministicSeedSource("commit")))
+	require.NoError(t, err)
+
+	match := &models.Match{
+		ID:            uuid.MustParse(seedData.MatchID),
+		Status:        models.MatchStatusForming,
+		CrashSeedHash: commitHash,
+		CreatedAt:     time.Now(),
+	}
+
+	commit, err := BuildSeedCommit(match, signer)
+	require.NoError(t, err)
+	assert.Equal(t, commitHash, commit.CrashSeedHash)
+	assert.True(t, VerifySeedCommit(signer.PublicKey(), commit))
+	assert.Equal(t, hex.EncodeToString(signer.PublicKey()), commit.PublicKey)
+
+	// Swapping the hash after the fact breaks the signature
+	tampered := *commit
+	_, tampered.CrashSeedHash, err = GenerateMatchSeeds(match.ID)
+	require.NoError(t, err)
+	assert.False(t, VerifySeedCommit(signer.PublicKey(), &tampered))
+
+	for _, status := range []models.MatchStatus{models.MatchStatusCompleted, models.MatchStatusAborted} {
+		match.Status = status
+		_, err := BuildSeedCommit(match, signer)
+		assert.ErrorIs(t, err, ErrCommitUnavailable, status)
+	}
+}
+
+func TestParseCommitKey(t *testing.T) {
+	key, err := ParseCommitKey("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	require.NoError(t, err)
+	assert.Equal(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a", hex.EncodeToString(NewCommitSigner(key).PublicKey()))
+
+	_, err = ParseCommitKey("not-hex")
+	assert.Error(t, err)
+	_, err = ParseCommitKey("9d61b19d")
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package gameengine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
 	"github.com/megaherz/ndr/internal/storage/postgres/repository"
 )
 
@@ -17,9 +19,19 @@ type EarnPointsService interface {
 	// LockScore locks a player's score for the current heat
 	LockScore(ctx context.Context, matchID, userID uuid.UUID, requestedScore decimal.Decimal) (*EarnPointsResult, error)
 
+	// LockGhostScore locks a ghost's replayed score for the current heat
+	LockGhostScore(ctx context.Context, matchID, ghostPlayerID uuid.UUID, score decimal.Decimal) (*EarnPointsResult, error)
+
+	// PlayGhostHeat schedules every ghost's recorded lock for the current heat.
+	// The heat manager calls it when a heat becomes active.
+	PlayGhostHeat(ctx context.Context, matchID uuid.UUID) error
+
 	// GetCurrentHeatInfo returns information about the current heat
 	GetCurrentHeatInfo(ctx context.Context, matchID uuid.UUID) (*HeatInfo, error)
 
+	// GetTargetToBeat returns the scores a player needs to move up the current standings
+	GetTargetToBeat(ctx context.Context, matchID, userID uuid.UUID) (*TargetToBeat, error)
+
 	// ValidateScoreForTime validates that a score is achievable at the current time
 	ValidateScoreForTime(ctx context.Context, matchID uuid.UUID, score decimal.Decimal) error
 }
@@ -47,34 +59,80 @@ type HeatInfo struct {
 	TotalPlayers  int             `json:"total_players"`
 }
 
+// TargetToBeat describes where a player stands and what it takes to climb the standings
+type TargetToBeat struct {
+	MatchID    uuid.UUID       `json:"match_id"`
+	Heat       int             `json:"heat"`
+	Position   int             `json:"position"` // Current position by total score
+	TotalScore decimal.Decimal `json:"total_score"`
+	Next       *ScoreTarget    `json:"next,omitempty"`  // Overtaking the player one position up, nil when first
+	First      *ScoreTarget    `json:"first,omitempty"` // Overtaking the leader, nil when first
+}
+
+// ScoreTarget is what a player needs to overtake the player at a position
+type ScoreTarget struct {
+	Position   int             `json:"position"`
+	TotalScore decimal.Decimal `json:"total_score"` // Total score of the player to overtake
+	Needed     decimal.Decimal `json:"needed"`      // Points the player must add to pass them outright
+}
+
 // earnPointsService implements EarnPointsService
 type earnPointsService struct {
 	stateManager    MatchStateManager
 	participantRepo repository.MatchParticipantRepository
+	ghostReplayRepo repository.GhostReplayRepository
 	physicsEngine   PhysicsEngine
 	heatManager     HeatManager
 	logger          *logrus.Logger
+
+	// countdownDuration is the countdown before a heat goes active; ghost locks are timed from its end
+	countdownDuration time.Duration
+}
+
+// EarnPointsOption configures optional earn points service behaviour
+type EarnPointsOption func(*earnPointsService)
+
+// WithEarnPointsCountdownDuration sets the heat countdown ghost locks are timed from, which must
+// match the heat manager's; non-positive values keep the default
+func WithEarnPointsCountdownDuration(duration time.Duration) EarnPointsOption {
+	return func(s *earnPointsService) {
+		if duration > 0 {
+			s.countdownDuration = duration
+		}
+	}
 }
 
 // NewEarnPointsService creates a new earn points service
 func NewEarnPointsService(
 	stateManager MatchStateManager,
 	participantRepo repository.MatchParticipantRepository,
+	ghostReplayRepo repository.GhostReplayRepository,
 	physicsEngine PhysicsEngine,
 	heatManager HeatManager,
 	logger *logrus.Logger,
+	opts ...EarnPointsOption,
 ) EarnPointsService {
-	return &earnPointsService{
-		stateManager:    stateManager,
-		participantRepo: participantRepo,
-		physicsEngine:   physicsEngine,
-		heatManager:     heatManager,
-		logger:          logger,
+	s := &earnPointsService{
+		stateManager:      stateManager,
+		participantRepo:   participantRepo,
+		ghostReplayRepo:   ghostReplayRepo,
+		physicsEngine:     physicsEngine,
+		heatManager:       heatManager,
+		logger:            logger,
+		countdownDuration: DefaultCountdownDuration,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // LockScore locks a player's score for the current heat
 func (s *earnPointsService) LockScore(ctx context.Context, matchID, userID uuid.UUID, requestedScore decimal.Decimal) (*EarnPointsResult, error) {
+	if err := validateScore(requestedScore); err != nil {
+		return nil, err
+	}
+
 	// Get match state
 	state, err := s.stateManager.GetMatchState(ctx, matchID)
 	if err != nil {
@@ -88,7 +146,7 @@ func (s *earnPointsService) LockScore(ctx context.Context, matchID, userID uuid.
 
 	// Validate heat is active
 	if state.HeatStatus != HeatStatusActive {
-		return nil, fmt.Errorf("heat is not active (status: %s)", state.HeatStatus)
+		return nil, fmt.Errorf("%w (status: %s)", ErrHeatNotActive, state.HeatStatus)
 	}
 
 	// Find player in match state
@@ -104,11 +162,6 @@ func (s *earnPointsService) LockScore(ctx context.Context, matchID, userID uuid.
 		return nil, fmt.Errorf("player not found in match")
 	}
 
-	// Check if player already locked score for this heat
-	if player.HasLocked {
-		return nil, fmt.Errorf("player has already locked score for heat %d", state.CurrentHeat)
-	}
-
 	// Check if player is alive (hasn't crashed)
 	if !player.IsAlive {
 		return nil, fmt.Errorf("player has crashed and cannot lock score")
@@ -127,10 +180,15 @@ func (s *earnPointsService) LockScore(ctx context.Context, matchID, userID uuid.
 		return nil, fmt.Errorf("invalid score: %w", err)
 	}
 
-	// Lock the score in memory state
+	// Lock the score in memory state. The state manager checks and sets the lock
+	// under its own mutex, so it alone decides which of two concurrent locks wins;
+	// the state read above is a copy and cannot be trusted for that.
 	lockTime := time.Now()
-	err = s.stateManager.LockPlayerScore(ctx, matchID, userID, requestedScore)
+	heatLockTime, err := s.stateManager.LockPlayerScore(ctx, matchID, userID, requestedScore)
 	if err != nil {
+		if errors.Is(err, ErrAlreadyLocked) || errors.Is(err, ErrPlayerCrashed) || errors.Is(err, ErrHeatNotActive) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to lock score in state: %w", err)
 	}
 
@@ -147,6 +205,18 @@ func (s *earnPointsService) LockScore(ctx context.Context, matchID, userID uuid.
 		return nil, fmt.Errorf("failed to update score: %w", err)
 	}
 
+	// Record the lock time for the lock-time tiebreaker
+	err = s.participantRepo.UpdateHeatLockTime(ctx, matchID, userID, state.CurrentHeat, heatLockTime)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"match_id":  matchID,
+			"user_id":   userID,
+			"heat":      state.CurrentHeat,
+			"lock_time": heatLockTime,
+			"error":     err,
+		}).Error("Failed to update heat lock time in database")
+	}
+
 	// Calculate updated total score
 	totalScore := s.calculatePlayerTotal(player, state.CurrentHeat, requestedScore)
 
@@ -194,6 +264,199 @@ func (s *earnPointsService) LockScore(ctx context.Context, matchID, userID uuid.
 	}, nil
 }
 
+// LockGhostScore locks a ghost's replayed score for the current heat.
+// Ghosts are identified by their in-memory player ID, as they have no user ID.
+func (s *earnPointsService) LockGhostScore(ctx context.Context, matchID, ghostPlayerID uuid.UUID, score decimal.Decimal) (*EarnPointsResult, error) {
+	if err := validateScore(score); err != nil {
+		return nil, err
+	}
+
+	// Get match state
+	state, err := s.stateManager.GetMatchState(ctx, matchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get match state: %w", err)
+	}
+
+	// Validate match is in progress
+	if state.Status != MatchStatusInProgress {
+		return nil, fmt.Errorf("match is not in progress")
+	}
+
+	// Validate heat is active
+	if state.HeatStatus != HeatStatusActive {
+		return nil, fmt.Errorf("%w (status: %s)", ErrHeatNotActive, state.HeatStatus)
+	}
+
+	// Find ghost in match state
+	player, exists := state.Players[ghostPlayerID]
+	if !exists || !player.IsGhost || player.GhostReplayID == nil {
+		return nil, fmt.Errorf("ghost not found in match")
+	}
+	if player.ParticipantID == 0 {
+		return nil, fmt.Errorf("ghost has no participant row")
+	}
+
+	// Replayed scores come from real races, so only the absolute bounds are checked
+	if !s.physicsEngine.IsValidSpeed(score) {
+		return nil, fmt.Errorf("invalid score: %s", score.String())
+	}
+
+	// Lock the score in memory state
+	lockTime := time.Now()
+	heatLockTime, err := s.stateManager.LockPlayerScore(ctx, matchID, ghostPlayerID, score)
+	if err != nil {
+		if errors.Is(err, ErrAlreadyLocked) || errors.Is(err, ErrHeatNotActive) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to lock score in state: %w", err)
+	}
+
+	// Update score in database
+	err = s.participantRepo.UpdateGhostHeatScore(ctx, player.ParticipantID, state.CurrentHeat, score)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"match_id":        matchID,
+			"ghost_replay_id": player.GhostReplayID,
+			"participant_id":  player.ParticipantID,
+			"heat":            state.CurrentHeat,
+			"score":           score,
+			"error":           err,
+		}).Error("Failed to update ghost heat score in database")
+		return nil, fmt.Errorf("failed to update score: %w", err)
+	}
+
+	// Record the lock time for the lock-time tiebreaker
+	err = s.participantRepo.UpdateGhostHeatLockTime(ctx, player.ParticipantID, state.CurrentHeat, heatLockTime)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"match_id":        matchID,
+			"ghost_replay_id": player.GhostReplayID,
+			"participant_id":  player.ParticipantID,
+			"heat":            state.CurrentHeat,
+			"lock_time":       heatLockTime,
+			"error":           err,
+		}).Error("Failed to update ghost heat lock time in database")
+	}
+
+	// Calculate and store updated total score
+	totalScore := s.calculatePlayerTotal(player, state.CurrentHeat, score)
+	err = s.participantRepo.UpdateGhostTotalScore(ctx, player.ParticipantID, totalScore)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"match_id":        matchID,
+			"ghost_replay_id": player.GhostReplayID,
+			"participant_id":  player.ParticipantID,
+			"total_score":     totalScore,
+			"error":           err,
+		}).Error("Failed to update ghost total score in database")
+	}
+
+	position := s.calculateCurrentPosition(state, ghostPlayerID, score)
+
+	s.logger.WithFields(logrus.Fields{
+		"match_id":        matchID,
+		"ghost_replay_id": player.GhostReplayID,
+		"heat":            state.CurrentHeat,
+		"score":           score,
+		"total_score":     totalScore,
+	}).Debug("Ghost locked score")
+
+	// Check if all players have locked (early heat end)
+	go func() {
+		if err := s.checkEarlyHeatEnd(ctx, matchID); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"match_id": matchID,
+				"error":    err,
+			}).Error("Failed to check early heat end")
+		}
+	}()
+
+	return &EarnPointsResult{
+		Success:     true,
+		LockedScore: score,
+		Heat:        state.CurrentHeat,
+		LockTime:    lockTime,
+		Position:    position,
+		TotalScore:  totalScore,
+	}, nil
+}
+
+// PlayGhostHeat schedules every ghost's recorded lock for the current heat.
+// Each ghost locks its replay's heat score at the replay's recorded lock time,
+// measured from the end of the heat countdown. The locks are scheduled with the
+// heat manager, so ending the heat or cancelling the match's timers stops them.
+func (s *earnPointsService) PlayGhostHeat(ctx context.Context, matchID uuid.UUID) error {
+	state, err := s.stateManager.GetMatchState(ctx, matchID)
+	if err != nil {
+		return fmt.Errorf("failed to get match state: %w", err)
+	}
+
+	if state.HeatStartTime == nil {
+		return fmt.Errorf("heat has not started")
+	}
+	activeSince := state.HeatStartTime.Add(s.countdownDuration)
+
+	// The locks outlive the call that activated the heat; stopping them is up to the heat manager
+	lockCtx := context.WithoutCancel(ctx)
+
+	for ghostPlayerID, player := range state.Players {
+		if !player.IsGhost || player.GhostReplayID == nil {
+			continue
+		}
+
+		replay, err := s.ghostReplayRepo.GetByID(ctx, *player.GhostReplayID)
+		if err != nil {
+			return fmt.Errorf("failed to get ghost replay %s: %w", *player.GhostReplayID, err)
+		}
+		if replay == nil {
+			return fmt.Errorf("ghost replay not found: %s", *player.GhostReplayID)
+		}
+
+		score, lockAfter, err := ghostHeatLock(replay, state.CurrentHeat)
+		if err != nil {
+			return fmt.Errorf("invalid ghost replay %s: %w", replay.ID, err)
+		}
+
+		ghostPlayerID := ghostPlayerID
+		s.heatManager.ScheduleGhostLock(matchID, time.Until(activeSince.Add(lockAfter)), func() {
+			if _, err := s.LockGhostScore(lockCtx, matchID, ghostPlayerID, score); err != nil {
+				// The heat may have ended first, which simply means the ghost did not finish
+				s.logger.WithFields(logrus.Fields{
+					"match_id":        matchID,
+					"ghost_replay_id": replay.ID,
+					"heat":            state.CurrentHeat,
+					"error":           err,
+				}).Debug("Ghost score not locked")
+			}
+		})
+	}
+
+	return nil
+}
+
+// ghostHeatLock returns a replay's score and lock offset for a heat
+func ghostHeatLock(replay *models.GhostReplay, heat int) (decimal.Decimal, time.Duration, error) {
+	behavior, err := replay.GetBehavioralData()
+	if err != nil {
+		return decimal.Zero, 0, fmt.Errorf("failed to parse behavioral data: %w", err)
+	}
+
+	var score decimal.Decimal
+	var lockTime float64
+	switch heat {
+	case 1:
+		score, lockTime = replay.Heat1Score, behavior.Heat1LockTime
+	case 2:
+		score, lockTime = replay.Heat2Score, behavior.Heat2LockTime
+	case 3:
+		score, lockTime = replay.Heat3Score, behavior.Heat3LockTime
+	default:
+		return decimal.Zero, 0, fmt.Errorf("invalid heat number: %d", heat)
+	}
+
+	return score, time.Duration(lockTime * float64(time.Second)), nil
+}
+
 // GetCurrentHeatInfo returns information about the current heat
 func (s *earnPointsService) GetCurrentHeatInfo(ctx context.Context, matchID uuid.UUID) (*HeatInfo, error) {
 	state, err := s.stateManager.GetMatchState(ctx, matchID)
@@ -230,6 +493,56 @@ func (s *earnPointsService) GetCurrentHeatInfo(ctx context.Context, matchID uuid
 	}, nil
 }
 
+// GetTargetToBeat returns the scores a player needs to move up the current standings.
+// Standings rank total scores with the match's tiebreakers; a player passes another
+// outright by ending one cent above their total.
+func (s *earnPointsService) GetTargetToBeat(ctx context.Context, matchID, userID uuid.UUID) (*TargetToBeat, error) {
+	state, err := s.stateManager.GetMatchState(ctx, matchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get match state: %w", err)
+	}
+
+	// The state is a copy, so ranking it leaves the live positions untouched
+	standings := make([]*InMemoryPlayer, 0, len(state.Players))
+	for _, player := range state.Players {
+		standings = append(standings, player)
+	}
+	rankPlayers(standings, state.LockTimeTiebreak)
+
+	var player *InMemoryPlayer
+	for _, p := range standings {
+		if p.UserID != nil && *p.UserID == userID {
+			player = p
+			break
+		}
+	}
+	if player == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPlayerNotInMatch, userID)
+	}
+
+	target := &TargetToBeat{
+		MatchID:    matchID,
+		Heat:       state.CurrentHeat,
+		Position:   player.Position,
+		TotalScore: player.TotalScore,
+	}
+	if player.Position > 1 {
+		target.Next = scoreTarget(player, standings[player.Position-2])
+		target.First = scoreTarget(player, standings[0])
+	}
+
+	return target, nil
+}
+
+// scoreTarget returns what player needs to overtake the player ahead of them
+func scoreTarget(player, ahead *InMemoryPlayer) *ScoreTarget {
+	return &ScoreTarget{
+		Position:   ahead.Position,
+		TotalScore: ahead.TotalScore,
+		Needed:     ahead.TotalScore.Sub(player.TotalScore).Add(minScoreStep),
+	}
+}
+
 // ValidateScoreForTime validates that a score is achievable at the current time
 func (s *earnPointsService) ValidateScoreForTime(ctx context.Context, matchID uuid.UUID, score decimal.Decimal) error {
 	state, err := s.stateManager.GetMatchState(ctx, matchID)
@@ -366,8 +679,11 @@ func (s *earnPointsService) checkEarlyHeatEnd(ctx context.Context, matchID uuid.
 			"heat":     state.CurrentHeat,
 		}).Info("All alive players locked, triggering early heat end")
 
-		// Use heat manager to end the heat early
-		return s.heatManager.EndHeat(ctx, matchID)
+		// Use heat manager to end the heat early; its end timer or another early end may have got there first
+		if err := s.heatManager.EndHeat(ctx, matchID); err != nil && !errors.Is(err, ErrInvalidHeatTransition) {
+			return err
+		}
+		return nil
 	}
 
 	return nil
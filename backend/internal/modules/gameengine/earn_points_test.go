@@ -0,0 +1,408 @@
+package gameengine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// stubGhostReplayRepository stores and serves ghost replays by ID
+type stubGhostReplayRepository struct {
+	repository.GhostReplayRepository
+	replays map[uuid.UUID]*models.GhostReplay
+}
+
+func (r *stubGhostReplayRepository) GetByID(ctx context.Context, replayID uuid.UUID) (*models.GhostReplay, error) {
+	return r.replays[replayID], nil
+}
+
+func (r *stubGhostReplayRepository) Create(ctx context.Context, replay *models.GhostReplay) error {
+	if r.replays == nil {
+		r.replays = make(map[uuid.UUID]*models.GhostReplay)
+	}
+	r.replays[replay.ID] = replay
+	return nil
+}
+
+// ghostMatchFixture is an in-progress match with 8 live players and 2 ghosts in an active Heat 1
+type ghostMatchFixture struct {
+	match           *models.Match
+	players         []*MatchPlayer
+	ghostIDs        []uuid.UUID // In-memory player IDs of the ghosts, in replay order
+	stateManager    MatchStateManager
+	gameEngine      GameEngineService
+	participantRepo *stubParticipantRepository
+	ghostReplayRepo *stubGhostReplayRepository
+	earnPoints      EarnPointsService
+	settlement      SettlementService
+}
+
+func newGhostMatchFixture(t *testing.T) *ghostMatchFixture {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	gameEngine, matchRepo, participantRepo, stateManager := newTestGameEngineServiceWithState()
+	fixture := &ghostMatchFixture{
+		match:           &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusInProgress},
+		players:         newValidPlayers(),
+		stateManager:    stateManager,
+		gameEngine:      gameEngine,
+		participantRepo: participantRepo,
+		ghostReplayRepo: &stubGhostReplayRepository{replays: make(map[uuid.UUID]*models.GhostReplay)},
+	}
+	matchRepo.created = append(matchRepo.created, fixture.match)
+
+	for i, player := range fixture.players {
+		player.ParticipantID = int64(i + 1)
+		participantRepo.created = append(participantRepo.created, &models.MatchParticipant{
+			ID:                player.ParticipantID,
+			MatchID:           fixture.match.ID,
+			UserID:            player.UserID,
+			IsGhost:           player.IsGhost,
+			GhostReplayID:     player.GhostReplayID,
+			PlayerDisplayName: player.DisplayName,
+			BuyinAmount:       player.BuyinAmount,
+		})
+	}
+
+	require.NoError(t, stateManager.CreateMatchState(ctx, fixture.match.ID, "ROOKIE", fixture.players))
+	require.NoError(t, stateManager.UpdateMatchStatus(ctx, fixture.match.ID, MatchStatusInProgress))
+	require.NoError(t, stateManager.StartHeat(ctx, fixture.match.ID, 1))
+
+	// Backdate the heat so it went active just now, then activate it
+	manager := stateManager.(*matchStateManager)
+	state := manager.states[fixture.match.ID]
+	startedAt := time.Now().Add(-3 * time.Second)
+	state.HeatStartTime = &startedAt
+	activateHeat(t, stateManager, fixture.match.ID)
+
+	for _, player := range fixture.players {
+		if !player.IsGhost {
+			continue
+		}
+		for id, p := range state.Players {
+			if p.GhostReplayID != nil && *p.GhostReplayID == *player.GhostReplayID {
+				fixture.ghostIDs = append(fixture.ghostIDs, id)
+			}
+		}
+	}
+	require.Len(t, fixture.ghostIDs, 2)
+
+	heatManager := NewHeatManager(stateManager, &recordingPublisher{}, logger)
+	fixture.earnPoints = NewEarnPointsService(stateManager, participantRepo, fixture.ghostReplayRepo, NewPhysicsEngine(), heatManager, logger)
+	fixture.settlement = NewSettlementService(matchRepo, participantRepo, nil, nil, nil, stateManager, nil, logger)
+	return fixture
+}
+
+// addReplay registers the replay for the i-th ghost with its Heat 1 score and lock time
+func (f *ghostMatchFixture) addReplay(t *testing.T, i int, heat1Score string, heat1LockTime float64) {
+	ghosts := make([]*MatchPlayer, 0, 2)
+	for _, player := range f.players {
+		if player.IsGhost {
+			ghosts = append(ghosts, player)
+		}
+	}
+
+	replay := &models.GhostReplay{
+		ID:         *ghosts[i].GhostReplayID,
+		League:     models.LeagueRookie,
+		Heat1Score: decimal.RequireFromString(heat1Score),
+		Heat2Score: decimal.NewFromInt(100),
+		Heat3Score: decimal.NewFromInt(100),
+	}
+	require.NoError(t, replay.SetBehavioralData(&models.BehavioralData{
+		Heat1LockTime: heat1LockTime,
+		Heat2LockTime: 10,
+		Heat3LockTime: 10,
+	}))
+	f.ghostReplayRepo.replays[replay.ID] = replay
+}
+
+func TestPlayGhostHeat_GhostScoresReachStandingsAndSettlement(t *testing.T) {
+	ctx := context.Background()
+	fixture := newGhostMatchFixture(t)
+	fixture.addReplay(t, 0, "300.25", 0.02)
+	fixture.addReplay(t, 1, "120.50", 0.04)
+
+	require.NoError(t, fixture.earnPoints.PlayGhostHeat(ctx, fixture.match.ID))
+
+	// Both ghosts lock at their recorded times and their totals are persisted
+	require.Eventually(t, func() bool {
+		participants, _ := fixture.participantRepo.GetByMatchID(ctx, fixture.match.ID)
+		persisted := 0
+		for _, participant := range participants {
+			if participant.IsGhost && participant.TotalScore != nil {
+				persisted++
+			}
+		}
+		return persisted == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// Standings rank the ghosts by their replayed scores
+	matchState, err := fixture.gameEngine.GetMatchState(ctx, fixture.match.ID)
+	require.NoError(t, err)
+	require.True(t, matchState.Players[0].IsGhost)
+	require.NotNil(t, matchState.Players[0].Heat1Score)
+	assert.True(t, decimal.RequireFromString("300.25").Equal(*matchState.Players[0].Heat1Score))
+	assert.True(t, matchState.Players[0].HasLocked)
+	require.True(t, matchState.Players[1].IsGhost)
+	assert.True(t, decimal.RequireFromString("120.50").Equal(matchState.Players[1].TotalScore))
+
+	// Settlement sees the persisted ghost scores
+	positions, err := fixture.settlement.CalculatePositions(ctx, fixture.match.ID)
+	require.NoError(t, err)
+	require.Len(t, positions, 10)
+	assert.True(t, positions[0].IsGhost)
+	assert.Equal(t, 1, positions[0].FinalPosition)
+	assert.True(t, decimal.RequireFromString("300.25").Equal(positions[0].TotalScore))
+	assert.True(t, positions[1].IsGhost)
+	assert.True(t, decimal.RequireFromString("120.50").Equal(positions[1].Heat1Score))
+}
+
+func TestLockGhostScore_SameReplayWritesOnlyItsOwnSlot(t *testing.T) {
+	ctx := context.Background()
+	fixture := newGhostMatchFixture(t)
+
+	// Both ghosts replay the same race
+	players := fixture.stateManager.(*matchStateManager).states[fixture.match.ID].Players
+	first, second := players[fixture.ghostIDs[0]], players[fixture.ghostIDs[1]]
+	for _, participant := range fixture.participantRepo.created {
+		if participant.ID == second.ParticipantID {
+			participant.GhostReplayID = first.GhostReplayID
+		}
+	}
+	second.GhostReplayID = first.GhostReplayID
+
+	_, err := fixture.earnPoints.LockGhostScore(ctx, fixture.match.ID, fixture.ghostIDs[0], decimal.NewFromInt(100))
+	require.NoError(t, err)
+
+	for _, participant := range fixture.participantRepo.created {
+		switch participant.ID {
+		case first.ParticipantID:
+			require.NotNil(t, participant.Heat1Score)
+			assert.True(t, decimal.NewFromInt(100).Equal(*participant.Heat1Score))
+		case second.ParticipantID:
+			assert.Nil(t, participant.Heat1Score)
+			assert.Nil(t, participant.TotalScore)
+		}
+	}
+}
+
+func TestLockGhostScore_RejectsLivePlayersAndRelocks(t *testing.T) {
+	ctx := context.Background()
+	fixture := newGhostMatchFixture(t)
+
+	_, err := fixture.earnPoints.LockGhostScore(ctx, fixture.match.ID, *fixture.players[0].UserID, decimal.NewFromInt(100))
+	assert.Error(t, err)
+
+	result, err := fixture.earnPoints.LockGhostScore(ctx, fixture.match.ID, fixture.ghostIDs[0], decimal.NewFromInt(100))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Heat)
+	assert.True(t, decimal.NewFromInt(100).Equal(result.TotalScore))
+
+	_, err = fixture.earnPoints.LockGhostScore(ctx, fixture.match.ID, fixture.ghostIDs[0], decimal.NewFromInt(120))
+	assert.ErrorIs(t, err, ErrAlreadyLocked)
+}
+
+func TestPlayGhostHeat_MissingReplay(t *testing.T) {
+	fixture := newGhostMatchFixture(t)
+	fixture.addReplay(t, 0, "300", 0.02)
+
+	err := fixture.earnPoints.PlayGhostHeat(context.Background(), fixture.match.ID)
+
+	assert.Error(t, err)
+}
+
+func TestLockScore_ValidatesScorePrecision(t *testing.T) {
+	tests := []struct {
+		name    string
+		score   string
+		wantErr bool
+	}{
+		{name: "negative", score: "-0.10", wantErr: true},
+		{name: "three decimals", score: "0.125", wantErr: true},
+		{name: "valid", score: "0.25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fixture := newGhostMatchFixture(t)
+			userID := *fixture.players[0].UserID
+
+			result, err := fixture.earnPoints.LockScore(ctx, fixture.match.ID, userID, decimal.RequireFromString(tt.score))
+
+			state, stateErr := fixture.stateManager.GetMatchState(ctx, fixture.match.ID)
+			require.NoError(t, stateErr)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidScore)
+				assert.Nil(t, result)
+				assert.False(t, state.Players[userID].HasLocked)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, decimal.RequireFromString(tt.score).Equal(result.LockedScore))
+			assert.True(t, state.Players[userID].HasLocked)
+		})
+	}
+}
+
+func TestLockScore_ConcurrentLocksOnlyOneSucceeds(t *testing.T) {
+	ctx := context.Background()
+	fixture := newGhostMatchFixture(t)
+	userID := *fixture.players[0].UserID
+
+	const attempts = 2
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make([]error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = fixture.earnPoints.LockScore(ctx, fixture.match.ID, userID, decimal.RequireFromString("0.25"))
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded, alreadyLocked := 0, 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrAlreadyLocked):
+			alreadyLocked++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, 1, alreadyLocked)
+}
+
+// heatScores are a player's locked Heat 1 and Heat 2 scores
+type heatScores struct {
+	heat1, heat2 string
+}
+
+// newStandingsMatch creates a match in Heat 3 whose live players locked the given scores,
+// returning an earn points service over it and the players' user IDs in the given order
+func newStandingsMatch(t *testing.T, scores ...heatScores) (EarnPointsService, uuid.UUID, []uuid.UUID) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	matchID := uuid.New()
+	players, userIDs := newLivePlayers(len(scores))
+	require.NoError(t, stateManager.CreateMatchState(context.Background(), matchID, "ROOKIE", players))
+
+	state := stateManager.(*matchStateManager).states[matchID]
+	state.CurrentHeat = 3
+	for i, userID := range userIDs {
+		heat1 := decimal.RequireFromString(scores[i].heat1)
+		heat2 := decimal.RequireFromString(scores[i].heat2)
+		player := state.Players[userID]
+		player.Heat1Score = &heat1
+		player.Heat2Score = &heat2
+		player.TotalScore = heat1.Add(heat2)
+	}
+
+	return NewEarnPointsService(stateManager, nil, nil, NewPhysicsEngine(), nil, logger), matchID, userIDs
+}
+
+func TestGetTargetToBeat_Standings(t *testing.T) {
+	tests := []struct {
+		name         string
+		scores       []heatScores
+		player       int
+		wantPosition int
+		wantNext     string // Points needed to pass the player one position up, empty when first
+		wantFirst    string
+	}{
+		{
+			name:         "already first",
+			scores:       []heatScores{{"150", "150"}, {"100", "100"}, {"50", "100"}},
+			player:       0,
+			wantPosition: 1,
+		},
+		{
+			name:         "second behind the leader",
+			scores:       []heatScores{{"150", "150"}, {"100", "100"}, {"50", "100"}},
+			player:       1,
+			wantPosition: 2,
+			wantNext:     "100.01",
+			wantFirst:    "100.01",
+		},
+		{
+			name:         "last of three",
+			scores:       []heatScores{{"150", "150"}, {"100", "100"}, {"50", "100"}},
+			player:       2,
+			wantPosition: 3,
+			wantNext:     "50.01",
+			wantFirst:    "150.01",
+		},
+		{
+			name:         "tied total lost on the heat tiebreak",
+			scores:       []heatScores{{"100", "50"}, {"50", "100"}, {"10", "10"}},
+			player:       0,
+			wantPosition: 2,
+			wantNext:     "0.01",
+			wantFirst:    "0.01",
+		},
+		{
+			name:         "no scores yet",
+			scores:       []heatScores{{"0", "0"}, {"12.5", "0"}},
+			player:       0,
+			wantPosition: 2,
+			wantNext:     "12.51",
+			wantFirst:    "12.51",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			earnPoints, matchID, userIDs := newStandingsMatch(t, tt.scores...)
+
+			target, err := earnPoints.GetTargetToBeat(context.Background(), matchID, userIDs[tt.player])
+			require.NoError(t, err)
+			assert.Equal(t, 3, target.Heat)
+			assert.Equal(t, tt.wantPosition, target.Position)
+
+			if tt.wantNext == "" {
+				assert.Nil(t, target.Next)
+				assert.Nil(t, target.First)
+				return
+			}
+			require.NotNil(t, target.Next)
+			require.NotNil(t, target.First)
+			assert.Equal(t, tt.wantPosition-1, target.Next.Position)
+			assert.Equal(t, tt.wantNext, target.Next.Needed.StringFixed(2))
+			assert.Equal(t, 1, target.First.Position)
+			assert.Equal(t, tt.wantFirst, target.First.Needed.StringFixed(2))
+		})
+	}
+}
+
+func TestGetTargetToBeat_UnknownPlayerOrMatch(t *testing.T) {
+	earnPoints, matchID, _ := newStandingsMatch(t, heatScores{"10", "10"})
+
+	_, err := earnPoints.GetTargetToBeat(context.Background(), matchID, uuid.New())
+	assert.ErrorIs(t, err, ErrPlayerNotInMatch)
+
+	_, err = earnPoints.GetTargetToBeat(context.Background(), uuid.New(), uuid.New())
+	assert.ErrorIs(t, err, ErrMatchStateNotFound)
+}
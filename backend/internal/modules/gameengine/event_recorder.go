@@ -0,0 +1,164 @@
+package gameengine
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// Default match event recorder settings
+const (
+	defaultEventBufferSize    = 1024
+	defaultEventBatchSize     = 100
+	defaultEventFlushInterval = time.Second
+	eventWriteTimeout         = 5 * time.Second
+)
+
+// MatchEventRecorder writes match transitions to the match event timeline in the background,
+// so analytics never slow down or fail a running match
+type MatchEventRecorder interface {
+	// Record queues an event without blocking; the event is dropped if the queue is full
+	Record(event *models.MatchEvent)
+
+	// Start runs the writer until ctx is cancelled, then writes the events still queued
+	Start(ctx context.Context)
+
+	// Done is closed once the writer has written its last batch and exited
+	Done() <-chan struct{}
+}
+
+// asyncMatchEventRecorder implements MatchEventRecorder with a buffered queue
+// drained by a single writer that inserts events in batches
+type asyncMatchEventRecorder struct {
+	repo          repository.MatchEventRepository
+	queue         chan *models.MatchEvent
+	batchSize     int
+	flushInterval time.Duration
+	done          chan struct{}
+	logger        *logrus.Logger
+}
+
+// MatchEventRecorderOption configures optional match event recorder behaviour
+type MatchEventRecorderOption func(*asyncMatchEventRecorder)
+
+// WithEventBatchSize sets how many events are written per insert; non-positive values keep the default
+func WithEventBatchSize(size int) MatchEventRecorderOption {
+	return func(r *asyncMatchEventRecorder) {
+		if size > 0 {
+			r.batchSize = size
+		}
+	}
+}
+
+// WithEventFlushInterval sets how often a partial batch is written; non-positive values keep the default
+func WithEventFlushInterval(interval time.Duration) MatchEventRecorderOption {
+	return func(r *asyncMatchEventRecorder) {
+		if interval > 0 {
+			r.flushInterval = interval
+		}
+	}
+}
+
+// NewMatchEventRecorder creates a new background match event recorder
+func NewMatchEventRecorder(repo repository.MatchEventRepository, logger *logrus.Logger, opts ...MatchEventRecorderOption) MatchEventRecorder {
+	r := &asyncMatchEventRecorder{
+		repo:          repo,
+		queue:         make(chan *models.MatchEvent, defaultEventBufferSize),
+		batchSize:     defaultEventBatchSize,
+		flushInterval: defaultEventFlushInterval,
+		done:          make(chan struct{}),
+		logger:        logger,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Record queues an event without blocking
+func (r *asyncMatchEventRecorder) Record(event *models.MatchEvent) {
+	select {
+	case r.queue <- event:
+	default:
+		r.logger.WithFields(logrus.Fields{
+			"match_id":   event.MatchID,
+			"event_type": event.EventType,
+			"heat":       event.Heat,
+		}).Warn("Match event queue is full, dropping event")
+	}
+}
+
+// Start runs the writer until ctx is cancelled, then writes the events still queued
+func (r *asyncMatchEventRecorder) Start(ctx context.Context) {
+	r.logger.WithField("flush_interval", r.flushInterval).Info("Starting match event recorder")
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.flushInterval)
+		defer ticker.Stop()
+
+		batch := make([]*models.MatchEvent, 0, r.batchSize)
+		for {
+			select {
+			case event := <-r.queue:
+				batch = append(batch, event)
+				if len(batch) >= r.batchSize {
+					batch = r.write(batch)
+				}
+			case <-ticker.C:
+				batch = r.write(batch)
+			case <-ctx.Done():
+				r.drain(batch)
+				r.logger.Info("Match event recorder stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Done is closed once the writer has written its last batch and exited
+func (r *asyncMatchEventRecorder) Done() <-chan struct{} {
+	return r.done
+}
+
+// drain writes the current batch and every event still queued
+func (r *asyncMatchEventRecorder) drain(batch []*models.MatchEvent) {
+	for {
+		select {
+		case event := <-r.queue:
+			batch = append(batch, event)
+			if len(batch) >= r.batchSize {
+				batch = r.write(batch)
+			}
+		default:
+			r.write(batch)
+			return
+		}
+	}
+}
+
+// write inserts a batch and returns an empty one. Failed batches are logged and dropped,
+// as the timeline is best effort and must not hold events back indefinitely.
+func (r *asyncMatchEventRecorder) write(batch []*models.MatchEvent) []*models.MatchEvent {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	// The writer outlives cancelled request and worker contexts while draining
+	ctx, cancel := context.WithTimeout(context.Background(), eventWriteTimeout)
+	defer cancel()
+
+	if err := r.repo.CreateBatch(ctx, batch); err != nil {
+		r.logger.WithFields(logrus.Fields{
+			"event_count": len(batch),
+			"error":       err,
+		}).Error("Failed to write match events")
+	}
+
+	return make([]*models.MatchEvent, 0, r.batchSize)
+}
@@ -0,0 +1,112 @@
+package gameengine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+type MatchEventRecorderIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper  *repository.TestDBHelper
+	matchRepo repository.MatchRepository
+	eventRepo repository.MatchEventRepository
+	logger    *logrus.Logger
+}
+
+func TestMatchEventRecorderIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(MatchEventRecorderIntegrationTestSuite))
+}
+
+func (suite *MatchEventRecorderIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = repository.NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	suite.matchRepo = repository.NewMatchRepository(suite.dbHelper.DB)
+	suite.eventRepo = repository.NewMatchEventRepository(suite.dbHelper.DB)
+
+	suite.logger = logrus.New()
+	suite.logger.SetLevel(logrus.WarnLevel)
+}
+
+func (suite *MatchEventRecorderIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *MatchEventRecorderIntegrationTestSuite) SetupTest() {
+	suite.dbHelper.CleanupTables("match_events", "matches")
+}
+
+func (suite *MatchEventRecorderIntegrationTestSuite) TestHeatTimelineIsPersisted() {
+	ctx := context.Background()
+	match := &models.Match{
+		ID:               uuid.New(),
+		League:           models.LeagueRookie,
+		Status:           models.MatchStatusInProgress,
+		LivePlayerCount:  8,
+		GhostPlayerCount: 2,
+		PrizePool:        decimal.NewFromInt(92),
+		RakeAmount:       decimal.NewFromInt(8),
+		CrashSeed:        "test-crash-seed",
+		CrashSeedHash:    "test-crash-seed-hash",
+		CreatedAt:        time.Now().UTC(),
+	}
+	require.NoError(suite.T(), suite.matchRepo.Create(ctx, match))
+
+	recorderCtx, stopRecorder := context.WithCancel(ctx)
+	defer stopRecorder()
+	recorder := NewMatchEventRecorder(suite.eventRepo, suite.logger, WithEventFlushInterval(20*time.Millisecond))
+	recorder.Start(recorderCtx)
+
+	stateManager := NewMatchStateManager(suite.logger, WithMatchEventRecorder(recorder))
+	players := newValidPlayers()
+	require.NoError(suite.T(), stateManager.CreateMatchState(ctx, match.ID, "ROOKIE", players))
+
+	// One player locks; the other nine crash when the heat ends
+	require.NoError(suite.T(), stateManager.StartHeat(ctx, match.ID, 1))
+	activateHeat(suite.T(), stateManager, match.ID)
+	_, err := stateManager.LockPlayerScore(ctx, match.ID, *players[0].UserID, decimal.RequireFromString("150.50"))
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), stateManager.EndHeat(ctx, match.ID))
+
+	// Stopping the recorder writes whatever is still queued
+	stopRecorder()
+	<-recorder.Done()
+
+	timeline, err := suite.eventRepo.GetByMatchID(ctx, match.ID)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), timeline, 12)
+
+	assert.Equal(suite.T(), models.MatchEventHeatStarted, timeline[0].EventType)
+	assert.Equal(suite.T(), models.MatchEventScoreLocked, timeline[1].EventType)
+	require.NotNil(suite.T(), timeline[1].UserID)
+	assert.Equal(suite.T(), *players[0].UserID, *timeline[1].UserID)
+	require.NotNil(suite.T(), timeline[1].Score)
+	assert.Equal(suite.T(), "150.50", timeline[1].Score.StringFixed(2))
+	assert.NotNil(suite.T(), timeline[1].HeatTime)
+
+	ghostCrashes := 0
+	for _, event := range timeline[2:11] {
+		assert.Equal(suite.T(), models.MatchEventPlayerCrashed, event.EventType)
+		assert.NotNil(suite.T(), event.HeatTime)
+		if event.GhostReplayID != nil {
+			ghostCrashes++
+		}
+	}
+	assert.Equal(suite.T(), 2, ghostCrashes)
+	assert.Equal(suite.T(), models.MatchEventHeatEnded, timeline[11].EventType)
+
+	for _, event := range timeline {
+		assert.Equal(suite.T(), 1, event.Heat)
+	}
+}
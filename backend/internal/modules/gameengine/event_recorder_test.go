@@ -0,0 +1,57 @@
+package gameengine
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// memoryMatchEventRepository records every written batch
+type memoryMatchEventRepository struct {
+	repository.MatchEventRepository
+
+	mu      sync.Mutex
+	batches [][]*models.MatchEvent
+}
+
+func (r *memoryMatchEventRepository) CreateBatch(ctx context.Context, events []*models.MatchEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, events)
+	return nil
+}
+
+func TestMatchEventRecorder_WritesQueuedEventsOnStop(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	repo := &memoryMatchEventRepository{}
+	recorder := NewMatchEventRecorder(repo, logger, WithEventBatchSize(2))
+
+	// Events queued before the writer starts are still written once it stops
+	matchID := uuid.New()
+	for heat := 1; heat <= 3; heat++ {
+		recorder.Record(&models.MatchEvent{MatchID: matchID, EventType: models.MatchEventHeatStarted, Heat: heat})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder.Start(ctx)
+	<-recorder.Done()
+
+	var heats []int
+	for _, batch := range repo.batches {
+		assert.LessOrEqual(t, len(batch), 2)
+		for _, event := range batch {
+			heats = append(heats, event.Heat)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3}, heats)
+}
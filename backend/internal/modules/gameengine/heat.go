@@ -2,7 +2,10 @@ package gameengine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,20 +14,22 @@ import (
 
 	"github.com/megaherz/ndr/internal/modules/gateway"
 	"github.com/megaherz/ndr/internal/modules/gateway/events"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
 )
 
 // HeatManager manages the lifecycle of heats within a match
 type HeatManager interface {
-	// StartHeatCountdown starts the 3-second countdown for a heat
+	// StartHeatCountdown starts the countdown for a heat (DefaultCountdownDuration unless configured)
 	StartHeatCountdown(ctx context.Context, matchID uuid.UUID, heat int) error
 
 	// StartHeatActive transitions from countdown to active heat
 	StartHeatActive(ctx context.Context, matchID uuid.UUID) error
 
-	// EndHeat ends the current heat and transitions to intermission or next heat
+	// EndHeat ends the current heat and transitions to intermission or next heat.
+	// It returns ErrInvalidHeatTransition if the heat is not active, as when it already ended.
 	EndHeat(ctx context.Context, matchID uuid.UUID) error
 
-	// StartIntermission starts the 5-second intermission between heats
+	// StartIntermission starts the intermission between heats (5 seconds by default)
 	StartIntermission(ctx context.Context, matchID uuid.UUID) error
 
 	// CheckHeatTimeout checks if any heats have timed out
@@ -32,6 +37,23 @@ type HeatManager interface {
 
 	// GetHeatTimeRemaining returns the time remaining in the current heat
 	GetHeatTimeRemaining(ctx context.Context, matchID uuid.UUID) (time.Duration, error)
+
+	// CancelHeatTimers stops any pending heat transition and ghost lock for a match
+	CancelHeatTimers(matchID uuid.UUID)
+
+	// SetGhostHeatPlayer sets what plays the ghosts' recorded locks whenever a heat goes active
+	SetGhostHeatPlayer(player GhostHeatPlayer)
+
+	// ScheduleGhostLock runs lock after delay unless the heat ends or the match's timers are cancelled first
+	ScheduleGhostLock(matchID uuid.UUID, delay time.Duration, lock func())
+
+	// CheckAbsentPlayers crashes live players who stayed off the match channel and ends the heat early if possible
+	CheckAbsentPlayers(ctx context.Context, matchID uuid.UUID) error
+}
+
+// GhostHeatPlayer schedules the ghosts' recorded locks for a heat that just went active
+type GhostHeatPlayer interface {
+	PlayGhostHeat(ctx context.Context, matchID uuid.UUID) error
 }
 
 // HeatLifecycleEvent represents events in the heat lifecycle
@@ -43,31 +65,110 @@ type HeatLifecycleEvent struct {
 	Duration  int        `json:"duration,omitempty"` // Duration in seconds
 }
 
+// DefaultCountdownDuration is how long a heat counts down before going active
+const DefaultCountdownDuration = 3 * time.Second
+
+// DefaultHeatTickInterval is how often heat_tick events are published during an active heat
+const DefaultHeatTickInterval = 200 * time.Millisecond
+
+// countdownTickInterval is how often countdown_tick events are published, one per second left
+const countdownTickInterval = time.Second
+
 // heatManager implements HeatManager
 type heatManager struct {
-	stateManager MatchStateManager
-	publisher    gateway.CentrifugoPublisher
-	logger       *logrus.Logger
+	stateManager  MatchStateManager
+	publisher     gateway.CentrifugoPublisher
+	physicsEngine PhysicsEngine
+	logger        *logrus.Logger
 
 	// Heat configuration
-	countdownDuration    time.Duration // 3 seconds
+	countdownDuration    time.Duration // 3 seconds by default
 	heatDuration         time.Duration // 25 seconds
-	intermissionDuration time.Duration // 5 seconds
+	intermissionDuration time.Duration // 5 seconds by default
+	tickInterval         time.Duration
+	countdownInterval    time.Duration // One countdown tick per interval left
+
+	// Optional presence check crashing disconnected players during active heats
+	presence         MatchPresenceChecker
+	presenceInterval time.Duration
+
+	// Optional seed-derived target lines for the policy's leagues
+	targetLines    *TargetLinePolicy
+	matchRepo      repository.MatchRepository
+	fairnessEngine ProvableFairnessEngine
+
+	// Optional ghost playback, started whenever a heat goes active
+	ghosts GhostHeatPlayer
+
+	// Pending heat transitions and running heat or countdown tickers, at most one of each per match,
+	// and the current heat's pending ghost locks
+	timers      map[uuid.UUID]*time.Timer
+	tickers     map[uuid.UUID]*heatTicker
+	ghostTimers map[uuid.UUID][]*time.Timer
+	timersMu    sync.Mutex
+}
+
+// heatTicker controls a running heat_tick or countdown_tick publisher
+type heatTicker struct {
+	stop chan struct{} // Closed to ask the publisher to exit
+	done chan struct{} // Closed once the publisher has exited
+}
+
+// HeatManagerOption configures optional heat manager behaviour
+type HeatManagerOption func(*heatManager)
+
+// WithTickInterval sets how often heat_tick events are published; non-positive values keep the default
+func WithTickInterval(interval time.Duration) HeatManagerOption {
+	return func(h *heatManager) {
+		if interval > 0 {
+			h.tickInterval = interval
+		}
+	}
+}
+
+// WithIntermissionDuration sets how long the break between heats lasts; non-positive values keep the default
+func WithIntermissionDuration(duration time.Duration) HeatManagerOption {
+	return func(h *heatManager) {
+		if duration > 0 {
+			h.intermissionDuration = duration
+		}
+	}
+}
+
+// WithCountdownDuration sets how long a heat counts down before going active, publishing one
+// countdown_tick per second of it; non-positive values keep the default
+func WithCountdownDuration(duration time.Duration) HeatManagerOption {
+	return func(h *heatManager) {
+		if duration > 0 {
+			h.countdownDuration = duration
+		}
+	}
 }
 
 // NewHeatManager creates a new heat manager
-func NewHeatManager(stateManager MatchStateManager, publisher gateway.CentrifugoPublisher, logger *logrus.Logger) HeatManager {
-	return &heatManager{
+func NewHeatManager(stateManager MatchStateManager, publisher gateway.CentrifugoPublisher, logger *logrus.Logger, opts ...HeatManagerOption) HeatManager {
+	h := &heatManager{
 		stateManager:         stateManager,
 		publisher:            publisher,
+		physicsEngine:        NewPhysicsEngine(),
+		fairnessEngine:       NewProvableFairnessEngine(),
 		logger:               logger,
-		countdownDuration:    3 * time.Second,
+		countdownDuration:    DefaultCountdownDuration,
 		heatDuration:         25 * time.Second,
 		intermissionDuration: 5 * time.Second,
+		tickInterval:         DefaultHeatTickInterval,
+		countdownInterval:    countdownTickInterval,
+		timers:               make(map[uuid.UUID]*time.Timer),
+		tickers:              make(map[uuid.UUID]*heatTicker),
+		ghostTimers:          make(map[uuid.UUID][]*time.Timer),
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-// StartHeatCountdown starts the 3-second countdown for a heat
+// StartHeatCountdown starts the countdown for a heat
 func (h *heatManager) StartHeatCountdown(ctx context.Context, matchID uuid.UUID, heat int) error {
 	if heat < 1 || heat > 3 {
 		return fmt.Errorf("invalid heat number: %d", heat)
@@ -95,17 +196,17 @@ func (h *heatManager) StartHeatCountdown(ctx context.Context, matchID uuid.UUID,
 		// Continue anyway - heat is started
 	}
 
-	// Schedule transition to active after countdown
-	go func() {
-		time.Sleep(h.countdownDuration)
+	// Schedule transition to active after countdown, ticking down to it every second
+	activeAt := time.Now().Add(h.countdownDuration)
+	h.startCountdownTicks(ctx, matchID, heat, activeAt)
+	h.scheduleTransition(matchID, h.countdownDuration, func() {
 		if err := h.StartHeatActive(ctx, matchID); err != nil {
-			h.logger.WithFields(logrus.Fields{
+			h.logTransitionError(logrus.Fields{
 				"match_id": matchID,
 				"heat":     heat,
-				"error":    err,
-			}).Error("Failed to transition heat to active")
+			}, err, "Failed to transition heat to active")
 		}
-	}()
+	})
 
 	return nil
 }
@@ -118,29 +219,40 @@ func (h *heatManager) StartHeatActive(ctx context.Context, matchID uuid.UUID) er
 		return fmt.Errorf("failed to get match state: %w", err)
 	}
 
-	if state.HeatStatus != HeatStatusCountdown {
-		return fmt.Errorf("heat is not in countdown status")
+	// Only a heat still counting down may go active, so a late or repeated transition is rejected
+	err = h.stateManager.SetHeatStatus(ctx, matchID, HeatStatusActive)
+	if err != nil {
+		return fmt.Errorf("failed to activate heat: %w", err)
 	}
 
-	// Update heat status to active
-	// Note: This would require extending the state manager interface
-	// For now, we'll log the transition
 	h.logger.WithFields(logrus.Fields{
 		"match_id": matchID,
 		"heat":     state.CurrentHeat,
 	}).Info("Heat is now active")
 
-	// Schedule heat end after heat duration
-	go func() {
-		time.Sleep(h.heatDuration)
-		if err := h.EndHeat(ctx, matchID); err != nil {
+	// Keep clients' speed animation in sync until the heat ends
+	h.startTicking(ctx, matchID, state.CurrentHeat, time.Now())
+
+	// Ghosts lock at their recorded times; a failure leaves them unlocked but the heat runs on
+	if h.ghosts != nil {
+		if err := h.ghosts.PlayGhostHeat(ctx, matchID); err != nil {
 			h.logger.WithFields(logrus.Fields{
 				"match_id": matchID,
 				"heat":     state.CurrentHeat,
 				"error":    err,
-			}).Error("Failed to end heat")
+			}).Error("Failed to play ghost heat")
 		}
-	}()
+	}
+
+	// Schedule heat end after heat duration
+	h.scheduleTransition(matchID, h.heatDuration, func() {
+		if err := h.EndHeat(ctx, matchID); err != nil {
+			h.logTransitionError(logrus.Fields{
+				"match_id": matchID,
+				"heat":     state.CurrentHeat,
+			}, err, "Failed to end heat")
+		}
+	})
 
 	return nil
 }
@@ -153,12 +265,15 @@ func (h *heatManager) EndHeat(ctx context.Context, matchID uuid.UUID) error {
 		return fmt.Errorf("failed to get match state: %w", err)
 	}
 
-	// End the heat in state manager
+	// End the heat in state manager; a heat that already ended is rejected here
 	err = h.stateManager.EndHeat(ctx, matchID)
 	if err != nil {
 		return fmt.Errorf("failed to end heat in state manager: %w", err)
 	}
 
+	h.stopTicking(matchID)
+	h.stopGhostLocks(matchID)
+
 	h.logger.WithFields(logrus.Fields{
 		"match_id": matchID,
 		"heat":     state.CurrentHeat,
@@ -189,7 +304,7 @@ func (h *heatManager) EndHeat(ctx context.Context, matchID uuid.UUID) error {
 	return h.StartIntermission(ctx, matchID)
 }
 
-// StartIntermission starts the 5-second intermission between heats
+// StartIntermission starts the intermission between heats
 func (h *heatManager) StartIntermission(ctx context.Context, matchID uuid.UUID) error {
 	// Get current match state
 	state, err := h.stateManager.GetMatchState(ctx, matchID)
@@ -202,18 +317,27 @@ func (h *heatManager) StartIntermission(ctx context.Context, matchID uuid.UUID)
 		"heat":     state.CurrentHeat,
 	}).Info("Intermission started")
 
-	// Schedule next heat after intermission
+	// Tell clients to show the between-heats screen until the next countdown
 	nextHeat := state.CurrentHeat + 1
-	go func() {
-		time.Sleep(h.intermissionDuration)
+	err = h.publishIntermissionStartedEvent(ctx, matchID, nextHeat)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"match_id":  matchID,
+			"next_heat": nextHeat,
+			"error":     err,
+		}).Error("Failed to publish intermission started event")
+		// Continue anyway - the next heat still starts on schedule
+	}
+
+	// Schedule next heat after intermission
+	h.scheduleTransition(matchID, h.intermissionDuration, func() {
 		if err := h.StartHeatCountdown(ctx, matchID, nextHeat); err != nil {
-			h.logger.WithFields(logrus.Fields{
+			h.logTransitionError(logrus.Fields{
 				"match_id":  matchID,
 				"next_heat": nextHeat,
-				"error":     err,
-			}).Error("Failed to start next heat after intermission")
+			}, err, "Failed to start next heat after intermission")
 		}
-	}()
+	})
 
 	return nil
 }
@@ -226,10 +350,10 @@ func (h *heatManager) CheckHeatTimeout(ctx context.Context) error {
 	for _, matchID := range activeMatches {
 		state, err := h.stateManager.GetMatchState(ctx, matchID)
 		if err != nil {
-			h.logger.WithFields(logrus.Fields{
+			// The match may have been torn down since it was listed
+			h.logTransitionError(logrus.Fields{
 				"match_id": matchID,
-				"error":    err,
-			}).Error("Failed to get match state for timeout check")
+			}, err, "Failed to get match state for timeout check")
 			continue
 		}
 
@@ -293,6 +417,241 @@ func (h *heatManager) GetHeatTimeRemaining(ctx context.Context, matchID uuid.UUI
 	}
 }
 
+// CancelHeatTimers stops any pending heat transition and ghost lock for a match
+func (h *heatManager) CancelHeatTimers(matchID uuid.UUID) {
+	h.stopTicking(matchID)
+	h.stopGhostLocks(matchID)
+
+	h.timersMu.Lock()
+	defer h.timersMu.Unlock()
+
+	if timer, exists := h.timers[matchID]; exists {
+		timer.Stop()
+		delete(h.timers, matchID)
+
+		h.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+		}).Info("Heat timers cancelled")
+	}
+}
+
+// scheduleTransition runs fn after delay unless the match's timers are cancelled first.
+// Scheduling replaces any transition still pending for the match.
+func (h *heatManager) scheduleTransition(matchID uuid.UUID, delay time.Duration, fn func()) {
+	h.timersMu.Lock()
+	defer h.timersMu.Unlock()
+
+	if timer, exists := h.timers[matchID]; exists {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		h.timersMu.Lock()
+		current := h.timers[matchID] == timer
+		if current {
+			delete(h.timers, matchID)
+		}
+		h.timersMu.Unlock()
+
+		if current {
+			fn()
+		}
+	})
+	h.timers[matchID] = timer
+}
+
+// SetGhostHeatPlayer sets what plays the ghosts' recorded locks whenever a heat goes active
+func (h *heatManager) SetGhostHeatPlayer(player GhostHeatPlayer) {
+	h.ghosts = player
+}
+
+// ScheduleGhostLock runs lock after delay unless the heat ends or the match's timers are cancelled first
+func (h *heatManager) ScheduleGhostLock(matchID uuid.UUID, delay time.Duration, lock func()) {
+	h.timersMu.Lock()
+	defer h.timersMu.Unlock()
+
+	h.ghostTimers[matchID] = append(h.ghostTimers[matchID], time.AfterFunc(delay, lock))
+}
+
+// stopGhostLocks stops the ghost locks still pending for a match
+func (h *heatManager) stopGhostLocks(matchID uuid.UUID) {
+	h.timersMu.Lock()
+	defer h.timersMu.Unlock()
+
+	for _, timer := range h.ghostTimers[matchID] {
+		timer.Stop()
+	}
+	delete(h.ghostTimers, matchID)
+}
+
+// logTransitionError logs a failed scheduled transition. A transition that fires after the match
+// state was removed, or after an early heat end got there first, is the normal end of a match's
+// timers, so it is logged at debug level only.
+func (h *heatManager) logTransitionError(fields logrus.Fields, err error, msg string) {
+	entry := h.logger.WithFields(fields).WithField("error", err)
+	if errors.Is(err, ErrMatchStateNotFound) {
+		entry.Debug("Scheduled transition stopped, match state removed")
+		return
+	}
+	if errors.Is(err, ErrInvalidHeatTransition) {
+		entry.Debug("Scheduled transition skipped, heat already moved on")
+		return
+	}
+	entry.Error(msg)
+}
+
+// startTicking publishes heat_tick events at the tick interval until the heat is stopped.
+// Starting replaces any ticker still running for the match.
+func (h *heatManager) startTicking(ctx context.Context, matchID uuid.UUID, heat int, activeSince time.Time) {
+	h.stopTicking(matchID)
+
+	t := &heatTicker{stop: make(chan struct{}), done: make(chan struct{})}
+	h.timersMu.Lock()
+	h.tickers[matchID] = t
+	h.timersMu.Unlock()
+
+	go func() {
+		defer close(t.done)
+
+		ticker := time.NewTicker(h.tickInterval)
+		defer ticker.Stop()
+
+		// Presence checks share the ticker's lifetime; a nil channel never fires when they are disabled
+		var presenceChecks <-chan time.Time
+		if h.presence != nil {
+			presenceTicker := time.NewTicker(h.presenceInterval)
+			defer presenceTicker.Stop()
+			presenceChecks = presenceTicker.C
+		}
+
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				// A stop may race with a pending tick; it always wins
+				select {
+				case <-t.stop:
+					return
+				default:
+				}
+				h.publishHeatTick(ctx, matchID, heat, now.Sub(activeSince))
+			case <-presenceChecks:
+				// Run outside the ticker, since ending the heat waits for the ticker to exit
+				go func() {
+					if err := h.CheckAbsentPlayers(ctx, matchID); err != nil {
+						h.logger.WithFields(logrus.Fields{
+							"match_id": matchID,
+							"heat":     heat,
+							"error":    err,
+						}).Warn("Failed to check match presence")
+					}
+				}()
+			}
+		}
+	}()
+}
+
+// startCountdownTicks publishes a countdown_tick now and at every countdown interval until activeAt,
+// the time the heat's transition to active is scheduled for. The remaining count is derived from
+// activeAt, so a late tick never repeats or skips a number. Going active stops the ticks.
+func (h *heatManager) startCountdownTicks(ctx context.Context, matchID uuid.UUID, heat int, activeAt time.Time) {
+	h.stopTicking(matchID)
+
+	t := &heatTicker{stop: make(chan struct{}), done: make(chan struct{})}
+	h.timersMu.Lock()
+	h.tickers[matchID] = t
+	h.timersMu.Unlock()
+
+	go func() {
+		defer close(t.done)
+
+		ticker := time.NewTicker(h.countdownInterval)
+		defer ticker.Stop()
+
+		now := time.Now()
+		for {
+			remaining := int(math.Ceil(float64(activeAt.Sub(now)) / float64(h.countdownInterval)))
+			if remaining <= 0 {
+				return
+			}
+			h.publishCountdownTick(ctx, matchID, heat, remaining, activeAt)
+
+			select {
+			case <-t.stop:
+				return
+			case <-ctx.Done():
+				return
+			case now = <-ticker.C:
+				// A stop may race with a pending tick; it always wins
+				select {
+				case <-t.stop:
+					return
+				default:
+				}
+			}
+		}
+	}()
+}
+
+// publishCountdownTick publishes the seconds left until the heat goes active to match:{match_id}
+func (h *heatManager) publishCountdownTick(ctx context.Context, matchID uuid.UUID, heat, remaining int, activeAt time.Time) {
+	event := events.CountdownTickEvent{
+		MatchID:   matchID,
+		Heat:      heat,
+		Remaining: remaining,
+		ActiveAt:  activeAt,
+		Timestamp: time.Now(),
+	}
+
+	if err := h.publisher.PublishToMatch(ctx, matchID, events.EventCountdownTick, event); err != nil {
+		// Clients can still count down to active_at from any tick they received
+		h.logger.WithFields(logrus.Fields{
+			"match_id":  matchID,
+			"heat":      heat,
+			"remaining": remaining,
+			"error":     err,
+		}).Debug("Failed to publish countdown tick")
+	}
+}
+
+// stopTicking stops the heat ticker for a match, if one is running, and waits
+// for it to exit so no tick is published after the call returns
+func (h *heatManager) stopTicking(matchID uuid.UUID) {
+	h.timersMu.Lock()
+	t, exists := h.tickers[matchID]
+	delete(h.tickers, matchID)
+	h.timersMu.Unlock()
+
+	if exists {
+		close(t.stop)
+		<-t.done
+	}
+}
+
+// publishHeatTick publishes the elapsed heat time and current max speed to match:{match_id}
+func (h *heatManager) publishHeatTick(ctx context.Context, matchID uuid.UUID, heat int, elapsed time.Duration) {
+	event := events.HeatTickEvent{
+		MatchID:     matchID,
+		Heat:        heat,
+		ElapsedTime: elapsed.Seconds(),
+		MaxSpeed:    h.physicsEngine.CalculateSpeed(elapsed.Seconds()),
+		Timestamp:   time.Now(),
+	}
+
+	if err := h.publisher.PublishToMatch(ctx, matchID, events.EventHeatTick, event); err != nil {
+		// A missed tick is corrected by the next one
+		h.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+			"heat":     heat,
+			"error":    err,
+		}).Debug("Failed to publish heat tick")
+	}
+}
+
 // CheckEarlyHeatEnd checks if all alive players have locked scores (early end optimization)
 // This implements T053 - early heat end when all players have finished
 func (h *heatManager) CheckEarlyHeatEnd(ctx context.Context, matchID uuid.UUID) error {
@@ -320,7 +679,11 @@ func (h *heatManager) CheckEarlyHeatEnd(ctx context.Context, matchID uuid.UUID)
 			"heat":     state.CurrentHeat,
 		}).Info("All players locked, ending heat early")
 
-		return h.EndHeat(ctx, matchID)
+		// The heat's end timer or another early end may have got there first
+		if err := h.EndHeat(ctx, matchID); err != nil && !errors.Is(err, ErrInvalidHeatTransition) {
+			return err
+		}
+		return nil
 	}
 
 	return nil
@@ -349,14 +712,9 @@ func (h *heatManager) publishHeatStartedEvent(ctx context.Context, matchID uuid.
 	}
 
 	// Calculate target line for Heat 2 and 3
-	var targetLine *decimal.Decimal
-	switch heat {
-	case 2:
-		// Target line is Heat 1 winner's score
-		targetLine = h.calculateHeat1WinnerScore(state)
-	case 3:
-		// Target line is current leader's total score
-		targetLine = h.calculateCurrentLeaderTotal(state)
+	targetLine, err := h.targetLine(ctx, state, heat)
+	if err != nil {
+		return fmt.Errorf("failed to calculate target line: %w", err)
 	}
 
 	// Create heat started event
@@ -397,15 +755,16 @@ func (h *heatManager) publishHeatEndedEvent(ctx context.Context, matchID uuid.UU
 	results := make([]events.HeatResult, 0, len(state.Players))
 	for _, player := range state.Players {
 		var score *decimal.Decimal
+		var lockTime *float64
 		var crashed bool
 
 		switch heat {
 		case 1:
-			score = player.Heat1Score
+			score, lockTime = player.Heat1Score, player.Heat1LockTime
 		case 2:
-			score = player.Heat2Score
+			score, lockTime = player.Heat2Score, player.Heat2LockTime
 		case 3:
-			score = player.Heat3Score
+			score, lockTime = player.Heat3Score, player.Heat3LockTime
 		}
 
 		if score == nil || score.IsZero() {
@@ -417,7 +776,7 @@ func (h *heatManager) publishHeatEndedEvent(ctx context.Context, matchID uuid.UU
 			DisplayName: player.DisplayName,
 			IsGhost:     player.IsGhost,
 			Score:       score,
-			LockTime:    nil, // Could add lock time tracking
+			LockTime:    lockTime,
 			Crashed:     crashed,
 		}
 		results = append(results, result)
@@ -462,6 +821,21 @@ func (h *heatManager) publishHeatEndedEvent(ctx context.Context, matchID uuid.UU
 	return nil
 }
 
+// publishIntermissionStartedEvent publishes intermission_started event to match channel
+func (h *heatManager) publishIntermissionStartedEvent(ctx context.Context, matchID uuid.UUID, nextHeat int) error {
+	event := &events.IntermissionStartedEvent{
+		MatchID:   matchID,
+		NextHeat:  nextHeat,
+		Duration:  h.intermissionDuration.Seconds(),
+		StartTime: time.Now(),
+	}
+
+	if err := h.publisher.PublishToMatch(ctx, matchID, events.EventIntermissionStarted, event); err != nil {
+		return fmt.Errorf("failed to publish intermission started event: %w", err)
+	}
+	return nil
+}
+
 // calculateHeat1WinnerScore calculates the Heat 1 winner's score for target line
 func (h *heatManager) calculateHeat1WinnerScore(state *InMemoryMatchState) *decimal.Decimal {
 	var bestScore decimal.Decimal
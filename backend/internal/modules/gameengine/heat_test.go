@@ -0,0 +1,696 @@
+package gameengine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/modules/gateway/events"
+)
+
+// recordingPublisher records heat and countdown ticks, heat starts, heat results and intermissions published to match channels
+type recordingPublisher struct {
+	mu           sync.Mutex
+	ticks        []events.HeatTickEvent
+	countdown    []events.CountdownTickEvent
+	heatStarted  []*events.HeatStartedEvent
+	heatEnded    []*events.HeatEndedEvent
+	intermission []*events.IntermissionStartedEvent
+	balances     []*events.BalanceUpdatedEvent
+}
+
+func (p *recordingPublisher) PublishToUser(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if eventType == events.EventBalanceUpdated {
+		p.balances = append(p.balances, data.(*events.BalanceUpdatedEvent))
+	}
+	return nil
+}
+
+func (p *recordingPublisher) PublishToMatch(ctx context.Context, matchID uuid.UUID, eventType string, data interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch eventType {
+	case events.EventHeatTick:
+		p.ticks = append(p.ticks, data.(events.HeatTickEvent))
+	case events.EventCountdownTick:
+		p.countdown = append(p.countdown, data.(events.CountdownTickEvent))
+	case events.EventHeatStarted:
+		p.heatStarted = append(p.heatStarted, data.(*events.HeatStartedEvent))
+	case events.EventHeatEnded:
+		p.heatEnded = append(p.heatEnded, data.(*events.HeatEndedEvent))
+	case events.EventIntermissionStarted:
+		p.intermission = append(p.intermission, data.(*events.IntermissionStartedEvent))
+	}
+	return nil
+}
+
+func (p *recordingPublisher) PublishToUsers(ctx context.Context, userIDs []uuid.UUID, eventType string, data interface{}) error {
+	return nil
+}
+
+func (p *recordingPublisher) BroadcastToChannel(ctx context.Context, channel string, eventType string, data interface{}) error {
+	return nil
+}
+
+func (p *recordingPublisher) recordedTicks() []events.HeatTickEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]events.HeatTickEvent(nil), p.ticks...)
+}
+
+func TestHeatTicks_FireAtIntervalAndStopAfterEndHeat(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	publisher := &recordingPublisher{}
+	heatManager := NewHeatManager(stateManager, publisher, logger, WithTickInterval(20*time.Millisecond))
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 2))
+	defer heatManager.CancelHeatTimers(matchID)
+
+	require.NoError(t, heatManager.StartHeatActive(ctx, matchID))
+	time.Sleep(210 * time.Millisecond)
+	require.NoError(t, heatManager.EndHeat(ctx, matchID))
+
+	// Roughly one tick per interval, with slack for scheduler jitter
+	ticks := publisher.recordedTicks()
+	assert.GreaterOrEqual(t, len(ticks), 6)
+	assert.LessOrEqual(t, len(ticks), 11)
+
+	for i, tick := range ticks {
+		assert.Equal(t, matchID, tick.MatchID)
+		assert.Equal(t, 2, tick.Heat)
+		if i > 0 {
+			assert.Greater(t, tick.ElapsedTime, ticks[i-1].ElapsedTime)
+			assert.True(t, tick.MaxSpeed.GreaterThan(ticks[i-1].MaxSpeed))
+		}
+	}
+
+	// No further ticks once the heat has ended
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, publisher.recordedTicks(), len(ticks))
+}
+
+func (p *recordingPublisher) recordedCountdown() []events.CountdownTickEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]events.CountdownTickEvent(nil), p.countdown...)
+}
+
+func TestCountdownTicks_OnePerSecondUntilActive(t *testing.T) {
+	for _, seconds := range []int{1, 3, 5} {
+		t.Run(fmt.Sprintf("%d seconds", seconds), func(t *testing.T) {
+			ctx := context.Background()
+			logger := logrus.New()
+			logger.SetLevel(logrus.PanicLevel)
+
+			// Seconds are scaled down so the test runs quickly
+			const second = 40 * time.Millisecond
+			stateManager := NewMatchStateManager(logger)
+			publisher := &recordingPublisher{}
+			manager := NewHeatManager(stateManager, publisher, logger,
+				WithCountdownDuration(time.Duration(seconds)*second)).(*heatManager)
+			manager.countdownInterval = second
+
+			matchID := uuid.New()
+			require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+			defer manager.CancelHeatTimers(matchID)
+
+			require.NoError(t, manager.StartHeatCountdown(ctx, matchID, 2))
+			require.Eventually(t, func() bool {
+				state, err := stateManager.GetMatchState(ctx, matchID)
+				return err == nil && state.HeatStatus == HeatStatusActive
+			}, time.Duration(seconds+5)*second, second/4)
+
+			// No tick follows the heat going active
+			time.Sleep(2 * second)
+			ticks := publisher.recordedCountdown()
+			require.Len(t, ticks, seconds)
+			for i, tick := range ticks {
+				assert.Equal(t, matchID, tick.MatchID)
+				assert.Equal(t, 2, tick.Heat)
+				assert.Equal(t, seconds-i, tick.Remaining)
+				assert.Equal(t, ticks[0].ActiveAt, tick.ActiveAt)
+			}
+		})
+	}
+}
+
+func TestCountdownTicks_StopOnCancel(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	publisher := &recordingPublisher{}
+	heatManager := NewHeatManager(stateManager, publisher, logger)
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+	require.NoError(t, heatManager.StartHeatCountdown(ctx, matchID, 1))
+	heatManager.CancelHeatTimers(matchID)
+
+	// Only the tick published as the countdown started
+	ticks := publisher.recordedCountdown()
+	require.Len(t, ticks, 1)
+	assert.Equal(t, 3, ticks[0].Remaining)
+}
+
+func TestEndHeat_PublishesIntermissionStarted(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	publisher := &recordingPublisher{}
+	heatManager := NewHeatManager(stateManager, publisher, logger, WithIntermissionDuration(7500*time.Millisecond))
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+	defer heatManager.CancelHeatTimers(matchID)
+
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 2))
+	require.NoError(t, heatManager.StartHeatActive(ctx, matchID))
+	require.NoError(t, heatManager.EndHeat(ctx, matchID))
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	require.Len(t, publisher.intermission, 1)
+	event := publisher.intermission[0]
+	assert.Equal(t, matchID, event.MatchID)
+	assert.Equal(t, 3, event.NextHeat)
+	assert.Equal(t, 7.5, event.Duration)
+	assert.WithinDuration(t, time.Now(), event.StartTime, time.Second)
+}
+
+func TestEndHeat_NoIntermissionAfterFinalHeat(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	publisher := &recordingPublisher{}
+	heatManager := NewHeatManager(stateManager, publisher, logger)
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+	defer heatManager.CancelHeatTimers(matchID)
+
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 3))
+	require.NoError(t, heatManager.StartHeatActive(ctx, matchID))
+	require.NoError(t, heatManager.EndHeat(ctx, matchID))
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	assert.Empty(t, publisher.intermission)
+}
+
+func TestEndHeat_RejectsHeatThatAlreadyEnded(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	publisher := &recordingPublisher{}
+	heatManager := NewHeatManager(stateManager, publisher, logger)
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+	defer heatManager.CancelHeatTimers(matchID)
+
+	// An early end and the heat's end timer both try to end the final heat
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 3))
+	require.NoError(t, heatManager.StartHeatActive(ctx, matchID))
+	require.NoError(t, heatManager.EndHeat(ctx, matchID))
+	assert.ErrorIs(t, heatManager.EndHeat(ctx, matchID), ErrInvalidHeatTransition)
+
+	// A heat that has not gone active cannot end either
+	otherID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, otherID, "ROOKIE", newValidPlayers()))
+	require.NoError(t, stateManager.StartHeat(ctx, otherID, 1))
+	assert.ErrorIs(t, stateManager.EndHeat(ctx, otherID), ErrInvalidHeatTransition)
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	assert.Len(t, publisher.heatEnded, 1)
+}
+
+func TestHeatTicks_StopOnCancel(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	publisher := &recordingPublisher{}
+	heatManager := NewHeatManager(stateManager, publisher, logger, WithTickInterval(10*time.Millisecond))
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 1))
+
+	require.NoError(t, heatManager.StartHeatActive(ctx, matchID))
+	time.Sleep(50 * time.Millisecond)
+	heatManager.CancelHeatTimers(matchID)
+
+	count := len(publisher.recordedTicks())
+	assert.Greater(t, count, 0)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, publisher.recordedTicks(), count)
+}
+
+func TestScheduledTransition_StopsQuietlyAfterMatchStateRemoved(t *testing.T) {
+	ctx := context.Background()
+	logger, hook := logtest.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	manager := NewHeatManager(stateManager, &recordingPublisher{}, logger).(*heatManager)
+	manager.countdownDuration = 20 * time.Millisecond
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+	require.NoError(t, manager.StartHeatCountdown(ctx, matchID, 1))
+
+	// The match is torn down while the countdown's transition is still pending
+	require.NoError(t, stateManager.RemoveMatchState(ctx, matchID))
+	time.Sleep(80 * time.Millisecond)
+
+	var stopped bool
+	for _, entry := range hook.AllEntries() {
+		assert.NotEqual(t, logrus.ErrorLevel, entry.Level, entry.Message)
+		if entry.Message == "Scheduled transition stopped, match state removed" {
+			stopped = true
+			assert.Equal(t, logrus.DebugLevel, entry.Level)
+		}
+	}
+	assert.True(t, stopped)
+}
+
+func TestHeatStarted_SeedTargetLinesFollowTheCrashSeed(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	service, matchRepo, _, stateManager := newTestGameEngineServiceWithState()
+	players := newValidPlayers()
+	match, err := service.CreateMatch(ctx, "ROOKIE", players)
+	require.NoError(t, err)
+	require.NoError(t, stateManager.CreateMatchState(ctx, match.ID, "ROOKIE", players))
+
+	var seedData CrashSeedData
+	require.NoError(t, json.Unmarshal([]byte(match.CrashSeed), &seedData))
+	engine := NewProvableFairnessEngine()
+
+	publisher := &recordingPublisher{}
+	heatManager := NewHeatManager(stateManager, publisher, logger,
+		WithSeedTargetLines(NewTargetLinePolicy("ROOKIE"), matchRepo))
+	defer heatManager.CancelHeatTimers(match.ID)
+
+	require.NoError(t, heatManager.StartHeatCountdown(ctx, match.ID, 2))
+	heatManager.CancelHeatTimers(match.ID)
+	activateHeat(t, stateManager, match.ID)
+	require.NoError(t, stateManager.EndHeat(ctx, match.ID))
+	require.NoError(t, heatManager.StartHeatCountdown(ctx, match.ID, 3))
+
+	// No one has scored, yet both lines are fixed by the committed heat seeds
+	require.Len(t, publisher.heatStarted, 2)
+	require.NotNil(t, publisher.heatStarted[0].TargetLine)
+	assert.True(t, publisher.heatStarted[0].TargetLine.Equal(engine.DeriveTargetLine(seedData.Heat2Seed)))
+	require.NotNil(t, publisher.heatStarted[1].TargetLine)
+	assert.True(t, publisher.heatStarted[1].TargetLine.Equal(engine.DeriveTargetLine(seedData.Heat3Seed)))
+}
+
+func TestHeatStarted_OtherLeaguesFollowTheLeadingScore(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	service, matchRepo, _, stateManager := newTestGameEngineServiceWithState()
+	players := newValidPlayers()
+	match, err := service.CreateMatch(ctx, "ROOKIE", players)
+	require.NoError(t, err)
+	require.NoError(t, stateManager.CreateMatchState(ctx, match.ID, "ROOKIE", players))
+
+	publisher := &recordingPublisher{}
+	heatManager := NewHeatManager(stateManager, publisher, logger,
+		WithSeedTargetLines(NewTargetLinePolicy("PRO"), matchRepo))
+	defer heatManager.CancelHeatTimers(match.ID)
+
+	require.NoError(t, heatManager.StartHeatCountdown(ctx, match.ID, 2))
+
+	// No Heat 1 scores yet, so there is no line to beat
+	require.Len(t, publisher.heatStarted, 1)
+	assert.Nil(t, publisher.heatStarted[0].TargetLine)
+}
+
+// stubPresence reports a mutable set of users as connected to every channel
+type stubPresence struct {
+	mu    sync.Mutex
+	users map[uuid.UUID]bool
+}
+
+func newStubPresence(userIDs ...uuid.UUID) *stubPresence {
+	p := &stubPresence{users: make(map[uuid.UUID]bool)}
+	for _, userID := range userIDs {
+		p.users[userID] = true
+	}
+	return p
+}
+
+func (p *stubPresence) GetChannelUsers(ctx context.Context, channel string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	users := make([]string, 0, len(p.users))
+	for userID := range p.users {
+		users = append(users, userID.String())
+	}
+	return users, nil
+}
+
+func (p *stubPresence) leave(userID uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.users, userID)
+}
+
+func (p *stubPresence) join(userID uuid.UUID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.users[userID] = true
+}
+
+// newLivePlayers returns n live players with the ROOKIE buy-in and their user IDs
+func newLivePlayers(n int) ([]*MatchPlayer, []uuid.UUID) {
+	players := newValidPlayers()[:n]
+	userIDs := make([]uuid.UUID, 0, n)
+	for _, player := range players {
+		userIDs = append(userIDs, *player.UserID)
+	}
+	return players, userIDs
+}
+
+func TestCheckAbsentPlayers_EndsHeatEarly(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	publisher := &recordingPublisher{}
+	players, userIDs := newLivePlayers(3)
+	presence := newStubPresence(userIDs...)
+	heatManager := NewHeatManager(stateManager, publisher, logger, WithMatchPresence(presence, time.Hour))
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", players))
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 1))
+	defer heatManager.CancelHeatTimers(matchID)
+	activateHeat(t, stateManager, matchID)
+
+	_, err := stateManager.LockPlayerScore(ctx, matchID, userIDs[0], decimal.NewFromInt(120))
+	require.NoError(t, err)
+
+	// Everyone is connected: nobody crashes and the heat keeps running
+	require.NoError(t, heatManager.CheckAbsentPlayers(ctx, matchID))
+	state, err := stateManager.GetMatchState(ctx, matchID)
+	require.NoError(t, err)
+	assert.Equal(t, HeatStatusActive, state.HeatStatus)
+
+	// One of the two players still racing disconnects; a single missed check is forgiven
+	presence.leave(userIDs[2])
+	require.NoError(t, heatManager.CheckAbsentPlayers(ctx, matchID))
+	state, err = stateManager.GetMatchState(ctx, matchID)
+	require.NoError(t, err)
+	assert.True(t, state.Players[userIDs[2]].IsAlive)
+
+	// The second missed check crashes them; the other racer keeps the heat running
+	require.NoError(t, heatManager.CheckAbsentPlayers(ctx, matchID))
+	state, err = stateManager.GetMatchState(ctx, matchID)
+	require.NoError(t, err)
+	assert.Equal(t, HeatStatusActive, state.HeatStatus)
+	assert.False(t, state.Players[userIDs[2]].IsAlive)
+
+	// A crashed player cannot lock after reconnecting
+	_, err = stateManager.LockPlayerScore(ctx, matchID, userIDs[2], decimal.NewFromInt(50))
+	assert.ErrorIs(t, err, ErrPlayerCrashed)
+
+	// The last racer disconnecting leaves nobody to wait for
+	presence.leave(userIDs[1])
+	require.NoError(t, heatManager.CheckAbsentPlayers(ctx, matchID))
+	require.NoError(t, heatManager.CheckAbsentPlayers(ctx, matchID))
+	state, err = stateManager.GetMatchState(ctx, matchID)
+	require.NoError(t, err)
+	assert.Equal(t, HeatStatusIntermission, state.HeatStatus)
+
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	require.Len(t, publisher.heatEnded, 1)
+	crashed := map[uuid.UUID]bool{}
+	for _, result := range publisher.heatEnded[0].Results {
+		crashed[*result.UserID] = result.Crashed
+	}
+	assert.Equal(t, map[uuid.UUID]bool{userIDs[0]: false, userIDs[1]: true, userIDs[2]: true}, crashed)
+}
+
+func TestCheckAbsentPlayers_ReconnectResetsMissedChecks(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	players, userIDs := newLivePlayers(2)
+	presence := newStubPresence(userIDs...)
+	heatManager := NewHeatManager(stateManager, &recordingPublisher{}, logger, WithMatchPresence(presence, time.Hour))
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", players))
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 1))
+	defer heatManager.CancelHeatTimers(matchID)
+	activateHeat(t, stateManager, matchID)
+
+	// Missing, back, missing again: no two checks in a row were missed
+	presence.leave(userIDs[1])
+	require.NoError(t, heatManager.CheckAbsentPlayers(ctx, matchID))
+	presence.join(userIDs[1])
+	require.NoError(t, heatManager.CheckAbsentPlayers(ctx, matchID))
+	presence.leave(userIDs[1])
+	require.NoError(t, heatManager.CheckAbsentPlayers(ctx, matchID))
+
+	state, err := stateManager.GetMatchState(ctx, matchID)
+	require.NoError(t, err)
+	assert.True(t, state.Players[userIDs[1]].IsAlive)
+
+	require.NoError(t, heatManager.CheckAbsentPlayers(ctx, matchID))
+	state, err = stateManager.GetMatchState(ctx, matchID)
+	require.NoError(t, err)
+	assert.False(t, state.Players[userIDs[1]].IsAlive)
+}
+
+func TestCheckAbsentPlayers_RunsDuringActiveHeat(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	publisher := &recordingPublisher{}
+	players, userIDs := newLivePlayers(2)
+	presence := newStubPresence(userIDs...)
+	heatManager := NewHeatManager(stateManager, publisher, logger, WithMatchPresence(presence, 10*time.Millisecond))
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", players))
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 1))
+	defer heatManager.CancelHeatTimers(matchID)
+	require.NoError(t, heatManager.StartHeatActive(ctx, matchID))
+
+	_, err := stateManager.LockPlayerScore(ctx, matchID, userIDs[0], decimal.NewFromInt(120))
+	require.NoError(t, err)
+	presence.leave(userIDs[1])
+
+	// The periodic check ends the heat long before its 25 seconds are up
+	require.Eventually(t, func() bool {
+		publisher.mu.Lock()
+		defer publisher.mu.Unlock()
+		return len(publisher.heatEnded) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestCheckAbsentPlayers_IgnoresEmptyPresence(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	players, _ := newLivePlayers(2)
+	heatManager := NewHeatManager(stateManager, &recordingPublisher{}, logger, WithMatchPresence(newStubPresence(), time.Hour))
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", players))
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 1))
+	activateHeat(t, stateManager, matchID)
+
+	// No presence at all means presence is unavailable, so nobody is forfeited
+	require.NoError(t, heatManager.CheckAbsentPlayers(ctx, matchID))
+	state, err := stateManager.GetMatchState(ctx, matchID)
+	require.NoError(t, err)
+	assert.Equal(t, HeatStatusActive, state.HeatStatus)
+	for _, player := range state.Players {
+		assert.True(t, player.IsAlive)
+	}
+}
+
+func TestStartHeatActive_LocksOnlyOnceActive(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	heatManager := NewHeatManager(stateManager, &recordingPublisher{}, logger)
+	players, userIDs := newLivePlayers(2)
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", players))
+
+	// Before the heat starts and during its countdown nobody can lock
+	_, err := stateManager.LockPlayerScore(ctx, matchID, userIDs[0], decimal.NewFromInt(10))
+	assert.Error(t, err)
+	assert.ErrorIs(t, heatManager.StartHeatActive(ctx, matchID), ErrInvalidHeatTransition)
+
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 1))
+	defer heatManager.CancelHeatTimers(matchID)
+	_, err = stateManager.LockPlayerScore(ctx, matchID, userIDs[0], decimal.NewFromInt(10))
+	assert.Error(t, err)
+
+	// Going active is persisted in state, after which locks are accepted
+	require.NoError(t, heatManager.StartHeatActive(ctx, matchID))
+	state, err := stateManager.GetMatchState(ctx, matchID)
+	require.NoError(t, err)
+	assert.Equal(t, HeatStatusActive, state.HeatStatus)
+
+	_, err = stateManager.LockPlayerScore(ctx, matchID, userIDs[0], decimal.NewFromInt(10))
+	assert.NoError(t, err)
+
+	// A repeated transition is rejected instead of restarting the heat timers
+	assert.ErrorIs(t, heatManager.StartHeatActive(ctx, matchID), ErrInvalidHeatTransition)
+}
+
+func TestSetHeatStatus_FollowsHeatLifecycle(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+
+	// A waiting heat cannot skip its countdown
+	assert.ErrorIs(t, stateManager.SetHeatStatus(ctx, matchID, HeatStatusActive), ErrInvalidHeatTransition)
+
+	for _, status := range []HeatStatus{HeatStatusCountdown, HeatStatusActive, HeatStatusCompleted, HeatStatusIntermission, HeatStatusCountdown} {
+		require.NoError(t, stateManager.SetHeatStatus(ctx, matchID, status), status)
+	}
+
+	// An active heat cannot go back to its countdown
+	require.NoError(t, stateManager.SetHeatStatus(ctx, matchID, HeatStatusActive))
+	assert.ErrorIs(t, stateManager.SetHeatStatus(ctx, matchID, HeatStatusCountdown), ErrInvalidHeatTransition)
+
+	assert.ErrorIs(t, stateManager.SetHeatStatus(ctx, uuid.New(), HeatStatusActive), ErrMatchStateNotFound)
+}
+
+// recordingGhostPlayer records the matches whose ghost heats were played
+type recordingGhostPlayer struct {
+	mu      sync.Mutex
+	matches []uuid.UUID
+}
+
+func (p *recordingGhostPlayer) PlayGhostHeat(ctx context.Context, matchID uuid.UUID) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.matches = append(p.matches, matchID)
+	return nil
+}
+
+func TestStartHeatActive_PlaysGhostHeat(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger)
+	heatManager := NewHeatManager(stateManager, &recordingPublisher{}, logger)
+	ghosts := &recordingGhostPlayer{}
+	heatManager.SetGhostHeatPlayer(ghosts)
+
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+	defer heatManager.CancelHeatTimers(matchID)
+
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 1))
+	require.NoError(t, heatManager.StartHeatActive(ctx, matchID))
+
+	ghosts.mu.Lock()
+	defer ghosts.mu.Unlock()
+	assert.Equal(t, []uuid.UUID{matchID}, ghosts.matches)
+}
+
+func TestGhostLocks_StopWhenHeatEndsOrTimersAreCancelled(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(t *testing.T, heatManager HeatManager, matchID uuid.UUID)
+	}{
+		{
+			name: "heat ended",
+			stop: func(t *testing.T, heatManager HeatManager, matchID uuid.UUID) {
+				require.NoError(t, heatManager.EndHeat(context.Background(), matchID))
+			},
+		},
+		{
+			name: "timers cancelled",
+			stop: func(t *testing.T, heatManager HeatManager, matchID uuid.UUID) {
+				heatManager.CancelHeatTimers(matchID)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			logger := logrus.New()
+			logger.SetLevel(logrus.PanicLevel)
+
+			stateManager := NewMatchStateManager(logger)
+			heatManager := NewHeatManager(stateManager, &recordingPublisher{}, logger)
+
+			matchID := uuid.New()
+			require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", newValidPlayers()))
+			defer heatManager.CancelHeatTimers(matchID)
+			require.NoError(t, stateManager.StartHeat(ctx, matchID, 1))
+			require.NoError(t, heatManager.StartHeatActive(ctx, matchID))
+
+			fired := make(chan struct{})
+			heatManager.ScheduleGhostLock(matchID, 20*time.Millisecond, func() { close(fired) })
+			tt.stop(t, heatManager, matchID)
+
+			select {
+			case <-fired:
+				t.Fatal("ghost lock fired after its heat stopped")
+			case <-time.After(60 * time.Millisecond):
+			}
+		})
+	}
+}
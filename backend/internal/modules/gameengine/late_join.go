@@ -0,0 +1,181 @@
+package gameengine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/modules/account"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+var (
+	// ErrMatchNotForming is returned when a late player tries to join a match that has already started
+	ErrMatchNotForming = errors.New("match is not forming")
+
+	// ErrLateJoinClosed is returned when a match's late-join grace has passed or late joins are disabled
+	ErrLateJoinClosed = errors.New("late-join grace has passed")
+
+	// ErrGhostNotFound is returned when the match has no ghost with the given ID to replace
+	ErrGhostNotFound = errors.New("ghost not found in match")
+
+	// ErrAlreadyInMatch is returned when a late player already races in the match
+	ErrAlreadyInMatch = errors.New("player is already in match")
+)
+
+// WithLateJoinGrace lets live players replace ghosts in FORMING matches for the given time after the
+// match is created. Without it, or with a non-positive grace, late joins are rejected.
+func WithLateJoinGrace(grace time.Duration) GameEngineOption {
+	return func(s *gameEngineService) {
+		s.lateJoinGrace = grace
+	}
+}
+
+// WithBuyinLedger records the buy-in moves of late joins: the replaced ghost's buy-in goes back to
+// HOUSE_FUEL and the late player's buy-in is debited from their wallet
+func WithBuyinLedger(ledgerOps account.LedgerOperations) GameEngineOption {
+	return func(s *gameEngineService) {
+		s.ledgerOps = ledgerOps
+	}
+}
+
+// ReplaceGhostWithPlayer gives a ghost's grid slot in a FORMING match to a live player who joined
+// within the late-join grace, returning the ghost's buy-in to HOUSE_FUEL and debiting the player's
+func (s *gameEngineService) ReplaceGhostWithPlayer(ctx context.Context, matchID, ghostPlayerID uuid.UUID, player *MatchPlayer) error {
+	// The grid is checked and changed against the primary's latest state
+	ctx = repository.WithPrimaryReads(ctx)
+
+	match, err := s.GetMatch(ctx, matchID)
+	if err != nil {
+		return err
+	}
+	if match.Status != models.MatchStatusForming {
+		return fmt.Errorf("%w: match is %s", ErrMatchNotForming, match.Status)
+	}
+	if s.lateJoinGrace <= 0 || time.Since(match.CreatedAt) > s.lateJoinGrace {
+		return ErrLateJoinClosed
+	}
+
+	if err := validateLatePlayer(s.leagues, string(match.League), player); err != nil {
+		return fmt.Errorf("invalid late player: %w", err)
+	}
+
+	participants, err := s.participantRepo.GetByMatchID(ctx, matchID)
+	if err != nil {
+		return fmt.Errorf("failed to get match participants: %w", err)
+	}
+	var ghost *models.MatchParticipant
+	for _, participant := range participants {
+		if participant.UserID != nil && *participant.UserID == *player.UserID {
+			return ErrAlreadyInMatch
+		}
+		if participant.IsGhost && participant.GhostReplayID != nil && *participant.GhostReplayID == ghostPlayerID {
+			ghost = participant
+		}
+	}
+	if ghost == nil {
+		return fmt.Errorf("%w: %s", ErrGhostNotFound, ghostPlayerID)
+	}
+
+	// Move the buy-in first so a player who cannot pay never takes the slot: the debit and the
+	// ghost's refund to HOUSE_FUEL commit together or not at all
+	if err := s.recordLateJoinBuyins(ctx, matchID, *player.UserID, ghost.BuyinAmount, player.BuyinAmount, false); err != nil {
+		return fmt.Errorf("failed to record late-join buy-ins: %w", err)
+	}
+
+	replaced, err := s.participantRepo.ReplaceGhost(ctx, matchID, ghostPlayerID, *player.UserID, participantDisplayName(player.DisplayName))
+	if err == nil && !replaced {
+		// The match started or the ghost was taken since it was checked above
+		err = ErrMatchNotForming
+	}
+	if err != nil {
+		if reverseErr := s.recordLateJoinBuyins(ctx, matchID, *player.UserID, ghost.BuyinAmount, player.BuyinAmount, true); reverseErr != nil {
+			s.logger.WithFields(logrus.Fields{
+				"match_id": matchID,
+				"user_id":  *player.UserID,
+				"error":    reverseErr,
+			}).Error("Failed to reverse late-join buy-ins")
+		}
+		if errors.Is(err, ErrMatchNotForming) {
+			return err
+		}
+		return fmt.Errorf("failed to replace ghost: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"match_id":        matchID,
+		"ghost_replay_id": ghostPlayerID,
+		"user_id":         *player.UserID,
+	}).Info("Late player replaced ghost")
+
+	return nil
+}
+
+// validateLatePlayer checks a live player joining a match in place of a ghost
+func validateLatePlayer(leagues *constants.LeagueRegistry, league string, player *MatchPlayer) error {
+	if player == nil {
+		return errors.New("player is nil")
+	}
+	if player.IsGhost || player.UserID == nil {
+		return errors.New("late player must be a live player with a user ID")
+	}
+	if strings.TrimSpace(player.DisplayName) == "" {
+		return errors.New("late player has an empty display name")
+	}
+
+	buyin, _ := leagues.Buyin(league)
+	if !player.BuyinAmount.Equal(buyin) {
+		return fmt.Errorf("%w: buy-in %s does not match %s league buy-in %s",
+			ErrBuyinMismatch, player.BuyinAmount.String(), league, buyin.String())
+	}
+	return nil
+}
+
+// recordLateJoinBuyins returns the ghost's buy-in to HOUSE_FUEL and debits the late player's buy-in,
+// or undoes both when reverse is set. The entries and the player's balance update commit together,
+// so a player who cannot cover the buy-in is rejected with account.ErrInsufficientBalance and
+// nothing moves. Both moves are buy-in entries, so refunding an aborted match nets them out and
+// returns the buy-in to whoever holds the slot.
+func (s *gameEngineService) recordLateJoinBuyins(ctx context.Context, matchID, userID uuid.UUID, ghostBuyin, playerBuyin decimal.Decimal, reverse bool) error {
+	if s.ledgerOps == nil {
+		return nil
+	}
+
+	houseAmount, playerAmount := ghostBuyin, playerBuyin.Neg()
+	description := "Late-join buy-in replacing a ghost"
+	if reverse {
+		houseAmount, playerAmount = houseAmount.Neg(), playerAmount.Neg()
+		description = "Reversed late-join buy-in"
+	}
+
+	houseWallet := constants.SystemWalletHouseFuel
+	now := time.Now()
+	return s.ledgerOps.RecordCoveredMatchEntries(ctx, []*models.LedgerEntry{
+		{
+			SystemWallet:  &houseWallet,
+			Currency:      constants.CurrencyFUEL,
+			Amount:        houseAmount,
+			OperationType: constants.OperationMatchBuyin,
+			ReferenceID:   &matchID,
+			Description:   &description,
+			CreatedAt:     now,
+		},
+		{
+			UserID:        &userID,
+			Currency:      constants.CurrencyFUEL,
+			Amount:        playerAmount,
+			OperationType: constants.OperationMatchBuyin,
+			ReferenceID:   &matchID,
+			Description:   &description,
+			CreatedAt:     now,
+		},
+	})
+}
@@ -0,0 +1,230 @@
+package gameengine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/modules/account"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// replacingParticipantRepository swaps ghosts for live players in the recorded participants,
+// or refuses to when the match is marked as started
+type replacingParticipantRepository struct {
+	*stubParticipantRepository
+	matchStarted bool
+}
+
+func (r *replacingParticipantRepository) ReplaceGhost(ctx context.Context, matchID, ghostReplayID, userID uuid.UUID, displayName string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.matchStarted {
+		return false, nil
+	}
+	for _, participant := range r.created {
+		if participant.IsGhost && *participant.GhostReplayID == ghostReplayID {
+			participant.IsGhost = false
+			participant.GhostReplayID = nil
+			participant.UserID = &userID
+			participant.PlayerDisplayName = displayName
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newLateJoinMatch creates a ROOKIE match of 8 live players and 2 ghosts through a service that
+// accepts late joins for a minute, returning the match and its first ghost's replay ID
+func newLateJoinMatch(t *testing.T) (GameEngineService, *models.Match, uuid.UUID, *replacingParticipantRepository, *recordingLedgerOperations) {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	participantRepo := &replacingParticipantRepository{stubParticipantRepository: &stubParticipantRepository{}}
+	ledgerOps := &recordingLedgerOperations{}
+	service := NewGameEngineService(&stubMatchRepository{participants: participantRepo.stubParticipantRepository}, participantRepo, nil, NewMatchStateManager(logger), logger,
+		WithLateJoinGrace(time.Minute), WithBuyinLedger(ledgerOps))
+
+	players := newValidPlayers()
+	match, err := service.CreateMatch(context.Background(), constants.LeagueRookie, players)
+	require.NoError(t, err)
+	return service, match, *players[8].GhostReplayID, participantRepo, ledgerOps
+}
+
+func newLatePlayer() *MatchPlayer {
+	userID := uuid.New()
+	return &MatchPlayer{UserID: &userID, DisplayName: "Late Racer", BuyinAmount: decimal.NewFromInt(10)}
+}
+
+func TestReplaceGhostWithPlayer_TakesGhostSlot(t *testing.T) {
+	service, match, ghostID, participantRepo, ledgerOps := newLateJoinMatch(t)
+	player := newLatePlayer()
+
+	require.NoError(t, service.ReplaceGhostWithPlayer(context.Background(), match.ID, ghostID, player))
+
+	participants, err := participantRepo.GetByMatchID(context.Background(), match.ID)
+	require.NoError(t, err)
+	ghosts := 0
+	var replaced *models.MatchParticipant
+	for _, participant := range participants {
+		if participant.IsGhost {
+			ghosts++
+		}
+		if participant.UserID != nil && *participant.UserID == *player.UserID {
+			replaced = participant
+		}
+	}
+	assert.Equal(t, 1, ghosts)
+	require.NotNil(t, replaced)
+	assert.Equal(t, "Late Racer", replaced.PlayerDisplayName)
+
+	// The ghost's buy-in goes back to HOUSE_FUEL and the late player pays theirs
+	require.Len(t, ledgerOps.entries, 2)
+	house, paid := ledgerOps.entries[0], ledgerOps.entries[1]
+	require.NotNil(t, house.SystemWallet)
+	assert.Equal(t, constants.SystemWalletHouseFuel, *house.SystemWallet)
+	assert.True(t, house.Amount.Equal(decimal.NewFromInt(10)))
+	assert.Equal(t, player.UserID, paid.UserID)
+	assert.True(t, paid.Amount.Equal(decimal.NewFromInt(-10)))
+	for _, entry := range ledgerOps.entries {
+		assert.Equal(t, constants.OperationMatchBuyin, string(entry.OperationType))
+		assert.Equal(t, match.ID, *entry.ReferenceID)
+	}
+}
+
+func TestReplaceGhostWithPlayer_RejectedAfterMatchStarts(t *testing.T) {
+	service, match, ghostID, participantRepo, ledgerOps := newLateJoinMatch(t)
+	match.Status = models.MatchStatusInProgress
+
+	err := service.ReplaceGhostWithPlayer(context.Background(), match.ID, ghostID, newLatePlayer())
+	assert.ErrorIs(t, err, ErrMatchNotForming)
+	assert.Empty(t, ledgerOps.entries)
+
+	participants, err := participantRepo.GetByMatchID(context.Background(), match.ID)
+	require.NoError(t, err)
+	assert.True(t, participants[8].IsGhost)
+}
+
+func TestReplaceGhostWithPlayer_ReversesBuyinsWhenMatchStartsMeanwhile(t *testing.T) {
+	service, match, ghostID, participantRepo, ledgerOps := newLateJoinMatch(t)
+	participantRepo.matchStarted = true
+
+	err := service.ReplaceGhostWithPlayer(context.Background(), match.ID, ghostID, newLatePlayer())
+	assert.ErrorIs(t, err, ErrMatchNotForming)
+
+	// The buy-ins recorded before the swap are undone, leaving every wallet where it was
+	require.Len(t, ledgerOps.entries, 4)
+	net := make(map[string]decimal.Decimal)
+	for _, entry := range ledgerOps.entries {
+		net[buyinPayer(entry)] = net[buyinPayer(entry)].Add(entry.Amount)
+	}
+	require.Len(t, net, 2)
+	for payer, amount := range net {
+		assert.True(t, amount.IsZero(), payer)
+	}
+}
+
+func TestReplaceGhostWithPlayer_RejectsPlayerWhoCannotPay(t *testing.T) {
+	service, match, ghostID, participantRepo, ledgerOps := newLateJoinMatch(t)
+	ledgerOps.coverErr = fmt.Errorf("failed to record match entries: %w", account.ErrInsufficientBalance)
+
+	err := service.ReplaceGhostWithPlayer(context.Background(), match.ID, ghostID, newLatePlayer())
+	assert.ErrorIs(t, err, account.ErrInsufficientBalance)
+
+	// The ghost keeps its slot and its buy-in stays where it was
+	assert.Empty(t, ledgerOps.entries)
+	participants, err := participantRepo.GetByMatchID(context.Background(), match.ID)
+	require.NoError(t, err)
+	assert.True(t, participants[8].IsGhost)
+	assert.Equal(t, ghostID, *participants[8].GhostReplayID)
+}
+
+func TestReplaceGhostWithPlayer_RejectsLateJoinsAfterGrace(t *testing.T) {
+	service, match, ghostID, _, ledgerOps := newLateJoinMatch(t)
+	match.CreatedAt = time.Now().Add(-2 * time.Minute)
+
+	err := service.ReplaceGhostWithPlayer(context.Background(), match.ID, ghostID, newLatePlayer())
+	assert.ErrorIs(t, err, ErrLateJoinClosed)
+	assert.Empty(t, ledgerOps.entries)
+}
+
+func TestReplaceGhostWithPlayer_RejectsUnknownGhostAndExistingPlayer(t *testing.T) {
+	service, match, ghostID, participantRepo, _ := newLateJoinMatch(t)
+
+	err := service.ReplaceGhostWithPlayer(context.Background(), match.ID, uuid.New(), newLatePlayer())
+	assert.ErrorIs(t, err, ErrGhostNotFound)
+
+	existing := *participantRepo.created[0].UserID
+	err = service.ReplaceGhostWithPlayer(context.Background(), match.ID, ghostID,
+		&MatchPlayer{UserID: &existing, DisplayName: "Racer", BuyinAmount: decimal.NewFromInt(10)})
+	assert.ErrorIs(t, err, ErrAlreadyInMatch)
+}
+
+// matchEntriesLedgerRepository serves fixed ledger entries of a match
+type matchEntriesLedgerRepository struct {
+	repository.LedgerRepository
+	entries []*models.LedgerEntry
+}
+
+func (r *matchEntriesLedgerRepository) GetMatchEntries(ctx context.Context, matchID uuid.UUID) ([]*models.LedgerEntry, error) {
+	return r.entries, nil
+}
+
+func TestRefundMatch_NetsLateJoinBuyins(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	matchID := uuid.New()
+	houseWallet := constants.SystemWalletHouseFuel
+	original, late := uuid.New(), uuid.New()
+	buyin := func(userID *uuid.UUID, amount int64) *models.LedgerEntry {
+		entry := &models.LedgerEntry{
+			UserID:        userID,
+			Currency:      constants.CurrencyFUEL,
+			Amount:        decimal.NewFromInt(amount),
+			OperationType: constants.OperationMatchBuyin,
+			ReferenceID:   &matchID,
+		}
+		if userID == nil {
+			entry.SystemWallet = &houseWallet
+		}
+		return entry
+	}
+
+	// One live player and two ghosts bought in, then a late player took one ghost's slot
+	ledgerRepo := &matchEntriesLedgerRepository{entries: []*models.LedgerEntry{
+		buyin(&original, -10),
+		buyin(nil, -10),
+		buyin(nil, -10),
+		buyin(nil, 10),
+		buyin(&late, -10),
+	}}
+	ledgerOps := &recordingLedgerOperations{}
+	settlement := NewSettlementService(nil, nil, nil, ledgerRepo, ledgerOps, nil, &recordingPublisher{}, logger)
+
+	refunds, err := settlement.RefundMatch(context.Background(), matchID)
+	require.NoError(t, err)
+	require.Len(t, refunds, 3)
+
+	refunded := make(map[string]decimal.Decimal)
+	for _, refund := range refunds {
+		assert.Equal(t, constants.OperationMatchRefund, string(refund.OperationType))
+		refunded[buyinPayer(refund)] = refund.Amount
+	}
+	assert.True(t, refunded[buyinPayer(buyin(&original, 0))].Equal(decimal.NewFromInt(10)))
+	assert.True(t, refunded[buyinPayer(buyin(&late, 0))].Equal(decimal.NewFromInt(10)))
+	assert.True(t, refunded[buyinPayer(buyin(nil, 0))].Equal(decimal.NewFromInt(10)))
+	assert.Equal(t, refunds, ledgerOps.entries)
+}
@@ -0,0 +1,74 @@
+package gameengine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/centrifugo"
+)
+
+// MatchPresenceChecker reports which users are connected to a realtime channel
+type MatchPresenceChecker interface {
+	// GetChannelUsers returns the IDs of users with at least one connection subscribed to a channel
+	GetChannelUsers(ctx context.Context, channel string) ([]string, error)
+}
+
+// presenceMissedChecks is how many presence checks in a row a live player must be missing from
+// the match channel before they crash, so a brief reconnect does not forfeit the heat
+const presenceMissedChecks = 2
+
+// WithMatchPresence crashes live players who are no longer subscribed to the match channel,
+// checking at the given interval during active heats, so the heat can end without waiting on them.
+// Players crash once they have been missing from two checks in a row. A non-positive interval
+// disables the check.
+func WithMatchPresence(checker MatchPresenceChecker, interval time.Duration) HeatManagerOption {
+	return func(h *heatManager) {
+		if interval > 0 {
+			h.presence = checker
+			h.presenceInterval = interval
+		}
+	}
+}
+
+// CheckAbsentPlayers crashes live players missing from the match channel's presence for
+// presenceMissedChecks checks in a row and ends the heat early if everyone still racing has locked
+func (h *heatManager) CheckAbsentPlayers(ctx context.Context, matchID uuid.UUID) error {
+	if h.presence == nil {
+		return nil
+	}
+
+	users, err := h.presence.GetChannelUsers(ctx, centrifugo.MatchChannel(matchID.String()))
+	if err != nil {
+		return fmt.Errorf("failed to get match presence: %w", err)
+	}
+
+	// Nobody at all being present means presence is unavailable, not that every player left
+	present := make(map[uuid.UUID]bool, len(users))
+	for _, user := range users {
+		if userID, err := uuid.Parse(user); err == nil {
+			present[userID] = true
+		}
+	}
+	if len(present) == 0 {
+		return nil
+	}
+
+	crashed, err := h.stateManager.CrashAbsentPlayers(ctx, matchID, present, presenceMissedChecks)
+	if err != nil {
+		return fmt.Errorf("failed to crash absent players: %w", err)
+	}
+	if len(crashed) == 0 {
+		return nil
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"match_id": matchID,
+		"players":  crashed,
+	}).Info("Auto-forfeited disconnected players")
+
+	return h.CheckEarlyHeatEnd(ctx, matchID)
+}
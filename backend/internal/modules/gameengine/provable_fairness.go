@@ -6,9 +6,12 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	mathrand "math/rand/v2"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // CrashSeedData represents the crash seeds for all three heats
@@ -36,14 +39,51 @@ type ProvableFairnessEngine interface {
 
 	// DeriveRandomValue derives a deterministic random value from a seed and context
 	DeriveRandomValue(seed, context string) uint64
+
+	// DeriveTargetLine derives a heat's target line from its seed
+	DeriveTargetLine(seed string) decimal.Decimal
 }
 
 // provableFairnessEngine implements ProvableFairnessEngine
-type provableFairnessEngine struct{}
+type provableFairnessEngine struct {
+	seedSource io.Reader
+	now        func() time.Time
+}
+
+// ProvableFairnessOption configures a ProvableFairnessEngine
+type ProvableFairnessOption func(*provableFairnessEngine)
+
+// WithSeedSource replaces crypto/rand as the source of heat seed bytes.
+// Intended for tests and replays only; production must keep the default.
+func WithSeedSource(source io.Reader) ProvableFairnessOption {
+	return func(p *provableFairnessEngine) {
+		p.seedSource = source
+	}
+}
+
+// WithClock overrides the clock used to timestamp generated seed data
+func WithClock(now func() time.Time) ProvableFairnessOption {
+	return func(p *provableFairnessEngine) {
+		p.now = now
+	}
+}
+
+// NewDeterministicSeedSource returns a reproducible seed source for WithSeedSource:
+// the same seed always yields the same sequence of heat seeds
+func NewDeterministicSeedSource(seed string) io.Reader {
+	return mathrand.NewChaCha8(sha256.Sum256([]byte(seed)))
+}
 
 // NewProvableFairnessEngine creates a new provable fairness engine
-func NewProvableFairnessEngine() ProvableFairnessEngine {
-	return &provableFairnessEngine{}
+func NewProvableFairnessEngine(opts ...ProvableFairnessOption) ProvableFairnessEngine {
+	p := &provableFairnessEngine{
+		seedSource: rand.Reader,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 // GenerateCrashSeeds generates cryptographic seeds for all three heats
@@ -68,7 +108,7 @@ func (p *provableFairnessEngine) GenerateCrashSeeds(matchID uuid.UUID) (*CrashSe
 		Heat2Seed: heat2Seed,
 		Heat3Seed: heat3Seed,
 		MatchID:   matchID.String(),
-		Timestamp: time.Now().Unix(),
+		Timestamp: p.now().Unix(),
 	}
 
 	return seedData, nil
@@ -103,9 +143,9 @@ func (p *provableFairnessEngine) VerifyCommitHash(seedData *CrashSeedData, commi
 
 // GenerateHeatSeed generates a single cryptographic seed for a heat
 func (p *provableFairnessEngine) GenerateHeatSeed() (string, error) {
-	// Generate 32 bytes of cryptographically secure random data
+	// Generate 32 bytes of random data (cryptographically secure unless a seed source is injected)
 	randomBytes := make([]byte, 32)
-	_, err := rand.Read(randomBytes)
+	_, err := io.ReadFull(p.seedSource, randomBytes)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate random bytes: %w", err)
 	}
@@ -131,10 +171,25 @@ func (p *provableFairnessEngine) DeriveRandomValue(seed, context string) uint64
 	return result
 }
 
+// targetTimeResolution is the number of distinct target times per second of a heat
+const targetTimeResolution = 100
+
+// DeriveTargetLine derives a heat's target line from its seed. The line is the speed reached at
+// DeriveRandomValue(seed, "target") modulo the heat length in hundredths of a second, so it is
+// always reachable and anyone holding the revealed seed can recompute it.
+func (p *provableFairnessEngine) DeriveTargetLine(seed string) decimal.Decimal {
+	steps := uint64(MaxHeatDuration * targetTimeResolution)
+	targetTime := float64(p.DeriveRandomValue(seed, "target")%steps) / targetTimeResolution
+	return NewPhysicsEngine().CalculateSpeed(targetTime)
+}
+
 // GenerateMatchSeeds is a convenience function to generate and hash seeds for a match
-func GenerateMatchSeeds(matchID uuid.UUID) (seedData *CrashSeedData, commitHash string, err error) {
-	engine := NewProvableFairnessEngine()
+func GenerateMatchSeeds(matchID uuid.UUID, opts ...ProvableFairnessOption) (seedData *CrashSeedData, commitHash string, err error) {
+	return generateMatchSeeds(NewProvableFairnessEngine(opts...), matchID)
+}
 
+// generateMatchSeeds generates and hashes seeds for a match with the given engine
+func generateMatchSeeds(engine ProvableFairnessEngine, matchID uuid.UUID) (seedData *CrashSeedData, commitHash string, err error) {
 	// Generate crash seeds
 	seedData, err = engine.GenerateCrashSeeds(matchID)
 	if err != nil {
@@ -0,0 +1,105 @@
+package gameengine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// WithReplayRecording records the runs of a settled match's live players as ghost replays,
+// so later matches can fill their ghost slots with them. Players who opted out in userRepo
+// are never recorded.
+func WithReplayRecording(ghostReplayRepo repository.GhostReplayRepository, userRepo repository.UserRepository) SettlementOption {
+	return func(s *settlementService) {
+		s.ghostReplayRepo = ghostReplayRepo
+		s.userRepo = userRepo
+	}
+}
+
+// recordReplays stores a ghost replay for every live player who locked a score in all three heats.
+// A run with a missed heat has no lock time to replay and is left out.
+// Failures are logged, as the settlement has already been applied.
+func (s *settlementService) recordReplays(ctx context.Context, settlement *MatchSettlement) {
+	if s.ghostReplayRepo == nil {
+		return
+	}
+
+	runs := make([]*PlayerPosition, 0, len(settlement.Positions))
+	userIDs := make([]uuid.UUID, 0, len(settlement.Positions))
+	for _, position := range settlement.Positions {
+		if position.IsGhost || position.UserID == nil {
+			continue
+		}
+		if position.Heat1LockTime == nil || position.Heat2LockTime == nil || position.Heat3LockTime == nil {
+			continue
+		}
+		runs = append(runs, position)
+		userIDs = append(userIDs, *position.UserID)
+	}
+	if len(runs) == 0 {
+		return
+	}
+
+	optOuts := map[uuid.UUID]bool{}
+	if s.userRepo != nil {
+		var err error
+		optOuts, err = s.userRepo.GetReplayOptOuts(ctx, userIDs)
+		if err != nil {
+			// Without the opt-outs nobody's consent is known, so nothing is recorded
+			s.logger.WithFields(logrus.Fields{
+				"match_id": settlement.MatchID,
+				"error":    err,
+			}).Error("Failed to get replay opt-outs")
+			return
+		}
+	}
+
+	for _, run := range runs {
+		if optOuts[*run.UserID] {
+			continue
+		}
+
+		replay, err := newGhostReplay(settlement, run)
+		if err == nil {
+			err = s.ghostReplayRepo.Create(ctx, replay)
+		}
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"match_id": settlement.MatchID,
+				"user_id":  run.UserID,
+				"error":    err,
+			}).Error("Failed to record ghost replay")
+		}
+	}
+}
+
+// newGhostReplay builds the ghost replay of a live player's settled run
+func newGhostReplay(settlement *MatchSettlement, run *PlayerPosition) (*models.GhostReplay, error) {
+	replay := &models.GhostReplay{
+		ID:            uuid.New(),
+		SourceMatchID: settlement.MatchID,
+		SourceUserID:  *run.UserID,
+		League:        models.League(settlement.League),
+		DisplayName:   run.DisplayName,
+		Heat1Score:    run.Heat1Score,
+		Heat2Score:    run.Heat2Score,
+		Heat3Score:    run.Heat3Score,
+		TotalScore:    run.TotalScore,
+		CreatedAt:     settlement.SettledAt,
+	}
+
+	err := replay.SetBehavioralData(&models.BehavioralData{
+		Heat1LockTime: *run.Heat1LockTime,
+		Heat2LockTime: *run.Heat2LockTime,
+		Heat3LockTime: *run.Heat3LockTime,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set behavioral data: %w", err)
+	}
+	return replay, nil
+}
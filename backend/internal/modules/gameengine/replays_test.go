@@ -0,0 +1,104 @@
+package gameengine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// stubReplayOptOuts reports fixed replay opt-outs
+type stubReplayOptOuts struct {
+	repository.UserRepository
+	optOuts map[uuid.UUID]bool
+}
+
+func (r *stubReplayOptOuts) GetReplayOptOuts(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	optOuts := make(map[uuid.UUID]bool)
+	for _, userID := range userIDs {
+		if r.optOuts[userID] {
+			optOuts[userID] = true
+		}
+	}
+	return optOuts, nil
+}
+
+// lockedRun sets a participant's heat scores and lock times
+func lockedRun(participant *models.MatchParticipant, scores [3]int64, lockTimes [3]float64) {
+	heat1, heat2, heat3 := decimal.NewFromInt(scores[0]), decimal.NewFromInt(scores[1]), decimal.NewFromInt(scores[2])
+	total := heat1.Add(heat2).Add(heat3)
+	participant.Heat1Score, participant.Heat2Score, participant.Heat3Score = &heat1, &heat2, &heat3
+	participant.Heat1LockTime, participant.Heat2LockTime, participant.Heat3LockTime = &lockTimes[0], &lockTimes[1], &lockTimes[2]
+	participant.TotalScore = &total
+}
+
+func TestSettleMatch_RecordsLiveRunsAsGhostReplays(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	participants := participantRepo.created
+	lockedRun(participants[0], [3]int64{120, 100, 80}, [3]float64{12.5, 11, 9.75})
+	lockedRun(participants[1], [3]int64{90, 60, 50}, [3]float64{10, 8.5, 7})
+	lockedRun(participants[2], [3]int64{30, 40, 30}, [3]float64{4, 5, 4.25})
+
+	// A player who missed a heat has no complete run, and a ghost is never recorded again
+	missed := uuid.New()
+	heat1 := decimal.NewFromInt(40)
+	lockTime := 6.0
+	participantRepo.created = append(participantRepo.created,
+		&models.MatchParticipant{MatchID: matchID, UserID: &missed, PlayerDisplayName: "Crasher", Heat1Score: &heat1, Heat1LockTime: &lockTime, TotalScore: &heat1},
+		&models.MatchParticipant{MatchID: matchID, IsGhost: true, PlayerDisplayName: "Ghost"},
+	)
+	lockedRun(participantRepo.created[4], [3]int64{10, 10, 10}, [3]float64{1, 1, 1})
+
+	// The third player opted out of replay recording
+	optedOut := *participants[2].UserID
+	ghostReplays := &stubGhostReplayRepository{}
+	settlement := NewSettlementService(matchRepo, participantRepo, nil, nil, &recordingLedgerOperations{}, nil, &recordingPublisher{}, logger,
+		WithReplayRecording(ghostReplays, &stubReplayOptOuts{optOuts: map[uuid.UUID]bool{optedOut: true}}))
+
+	result, err := settlement.SettleMatch(context.Background(), matchID)
+	require.NoError(t, err)
+
+	replays := make(map[uuid.UUID]*models.GhostReplay)
+	for _, replay := range ghostReplays.replays {
+		replays[replay.SourceUserID] = replay
+	}
+	require.Len(t, replays, 2)
+
+	winner := replays[*participants[0].UserID]
+	require.NotNil(t, winner)
+	assert.Equal(t, matchID, winner.SourceMatchID)
+	assert.Equal(t, models.LeagueRookie, winner.League)
+	assert.Equal(t, participants[0].PlayerDisplayName, winner.DisplayName)
+	assert.Equal(t, "120", winner.Heat1Score.String())
+	assert.Equal(t, "100", winner.Heat2Score.String())
+	assert.Equal(t, "80", winner.Heat3Score.String())
+	assert.Equal(t, "300", winner.TotalScore.String())
+	assert.True(t, result.SettledAt.Equal(winner.CreatedAt))
+
+	behavior, err := winner.GetBehavioralData()
+	require.NoError(t, err)
+	assert.Equal(t, models.BehavioralData{Heat1LockTime: 12.5, Heat2LockTime: 11, Heat3LockTime: 9.75}, *behavior)
+
+	// The recorded run replays like any other ghost
+	score, lockAfter, err := ghostHeatLock(winner, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "80", score.String())
+	assert.Equal(t, 9750.0, float64(lockAfter.Milliseconds()))
+
+	second := replays[*participants[1].UserID]
+	require.NotNil(t, second)
+	assert.Equal(t, "200", second.TotalScore.String())
+
+	assert.NotContains(t, replays, optedOut)
+	assert.NotContains(t, replays, missed)
+}
@@ -3,25 +3,73 @@ package gameengine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 
+	"github.com/megaherz/ndr/internal/constants"
+	monetary "github.com/megaherz/ndr/internal/decimal"
+	"github.com/megaherz/ndr/internal/modules/account"
 	"github.com/megaherz/ndr/internal/storage/postgres/models"
 	"github.com/megaherz/ndr/internal/storage/postgres/repository"
 )
 
+var (
+	// ErrMatchNotFound is returned when a match does not exist
+	ErrMatchNotFound = errors.New("match not found")
+
+	// ErrBuyinMismatch is returned when player buy-ins don't match the league's configured buy-in
+	ErrBuyinMismatch = errors.New("buy-in does not match league")
+
+	// ErrInvalidScore is returned when a submitted score is negative or has more than 2 decimal places
+	ErrInvalidScore = errors.New("invalid score")
+
+	// ErrAlreadyLocked is returned when a player has already locked a score for the current heat
+	ErrAlreadyLocked = errors.New("score already locked for this heat")
+
+	// ErrPlayerCrashed is returned when a player who crashed out of the current heat tries to lock
+	ErrPlayerCrashed = errors.New("player crashed out of this heat")
+
+	// ErrHeatNotActive is returned when a score is locked outside the active phase of a heat
+	ErrHeatNotActive = errors.New("heat is not active")
+
+	// ErrPlayerNotInMatch is returned when a user has no seat in the match
+	ErrPlayerNotInMatch = errors.New("player not found in match")
+)
+
+// minScoreStep is the smallest score difference, as scores carry at most 2 decimal places
+var minScoreStep = decimal.New(1, -2)
+
+// validateScore checks a submitted score against the monetary precision rules,
+// so over-precise values never reach comparisons or the database
+func validateScore(score decimal.Decimal) error {
+	if err := monetary.ValidateMonetary(score); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidScore, err)
+	}
+	return nil
+}
+
 // GameEngineService handles game engine operations
 type GameEngineService interface {
-	// CreateMatch creates a new match with the given players
+	// CreateMatch creates a new match with the given players, setting each player's ParticipantID
 	CreateMatch(ctx context.Context, league string, players []*MatchPlayer) (*models.Match, error)
 
 	// GetMatch retrieves a match by ID
 	GetMatch(ctx context.Context, matchID uuid.UUID) (*models.Match, error)
 
+	// GetMatchDetails retrieves a match with its participants and settlement (if completed)
+	GetMatchDetails(ctx context.Context, matchID uuid.UUID) (*MatchDetails, error)
+
+	// GetActiveMatchDetails retrieves the forming or in-progress match a user races in, so a returning
+	// player can rejoin it. It returns nil if the user is not in an active match.
+	GetActiveMatchDetails(ctx context.Context, userID uuid.UUID) (*MatchDetails, error)
+
 	// StartMatch starts a match (transitions from FORMING to IN_PROGRESS)
 	StartMatch(ctx context.Context, matchID uuid.UUID) error
 
@@ -33,6 +81,11 @@ type GameEngineService interface {
 
 	// CompleteMatch completes a match and triggers settlement
 	CompleteMatch(ctx context.Context, matchID uuid.UUID) error
+
+	// ReplaceGhostWithPlayer gives a ghost's grid slot in a FORMING match to a live player who joined
+	// within the late-join grace, returning the ghost's buy-in to HOUSE_FUEL and debiting the player's.
+	// A player who cannot cover the buy-in is rejected with account.ErrInsufficientBalance.
+	ReplaceGhostWithPlayer(ctx context.Context, matchID, ghostPlayerID uuid.UUID, player *MatchPlayer) error
 }
 
 // MatchPlayer represents a player participating in a match
@@ -42,6 +95,24 @@ type MatchPlayer struct {
 	IsGhost       bool            `json:"is_ghost"`
 	GhostReplayID *uuid.UUID      `json:"ghost_replay_id,omitempty"`
 	BuyinAmount   decimal.Decimal `json:"buyin_amount"`
+	ParticipantID int64           `json:"participant_id,omitempty"` // Participant row, set by CreateMatch
+}
+
+// MatchDetails aggregates a match with its participants and settlement
+type MatchDetails struct {
+	Match        *models.Match              `json:"match"`
+	Participants []*models.MatchParticipant `json:"participants"`
+	Settlement   *models.MatchSettlement    `json:"settlement,omitempty"` // Set once the match is settled
+}
+
+// HasParticipant returns true if the user took part in the match as a live player
+func (d *MatchDetails) HasParticipant(userID uuid.UUID) bool {
+	for _, participant := range d.Participants {
+		if participant.UserID != nil && *participant.UserID == userID {
+			return true
+		}
+	}
+	return false
 }
 
 // MatchState represents the current state of a match
@@ -76,35 +147,68 @@ type PlayerState struct {
 type gameEngineService struct {
 	matchRepo       repository.MatchRepository
 	participantRepo repository.MatchParticipantRepository
+	settlementRepo  repository.MatchSettlementRepository
+	stateManager    MatchStateManager
 	fairnessEngine  ProvableFairnessEngine
 	physicsEngine   PhysicsEngine
+	rakeRates       *monetary.RakeRates
+	leagues         *constants.LeagueRegistry
+	ledgerOps       account.LedgerOperations
+	lateJoinGrace   time.Duration
 	logger          *logrus.Logger
 }
 
+// GameEngineOption configures optional game engine service behaviour
+type GameEngineOption func(*gameEngineService)
+
+// WithRakeRates sets the per-league rake charged on new matches; without it every league pays the default 8%
+func WithRakeRates(rates *monetary.RakeRates) GameEngineOption {
+	return func(s *gameEngineService) {
+		s.rakeRates = rates
+	}
+}
+
+// WithLeagues sets the league buy-ins new matches and late joins are checked against.
+// Without it the launch leagues are used.
+func WithLeagues(leagues *constants.LeagueRegistry) GameEngineOption {
+	return func(s *gameEngineService) {
+		s.leagues = leagues
+	}
+}
+
 // NewGameEngineService creates a new game engine service
 func NewGameEngineService(
 	matchRepo repository.MatchRepository,
 	participantRepo repository.MatchParticipantRepository,
+	settlementRepo repository.MatchSettlementRepository,
+	stateManager MatchStateManager,
 	logger *logrus.Logger,
+	opts ...GameEngineOption,
 ) GameEngineService {
-	return &gameEngineService{
+	s := &gameEngineService{
 		matchRepo:       matchRepo,
 		participantRepo: participantRepo,
+		settlementRepo:  settlementRepo,
+		stateManager:    stateManager,
 		fairnessEngine:  NewProvableFairnessEngine(),
 		physicsEngine:   NewPhysicsEngine(),
 		logger:          logger,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateMatch creates a new match with the given players
 func (s *gameEngineService) CreateMatch(ctx context.Context, league string, players []*MatchPlayer) (*models.Match, error) {
-	if len(players) != 10 {
-		return nil, fmt.Errorf("match must have exactly 10 players, got %d", len(players))
+	if err := validateMatchPlayers(s.leagues, league, players); err != nil {
+		return nil, fmt.Errorf("invalid match players: %w", err)
 	}
 
 	// Generate crash seeds for provable fairness
 	matchID := uuid.New()
-	seedData, commitHash, err := GenerateMatchSeeds(matchID)
+	seedData, commitHash, err := generateMatchSeeds(s.fairnessEngine, matchID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate crash seeds: %w", err)
 	}
@@ -129,8 +233,16 @@ func (s *gameEngineService) CreateMatch(ctx context.Context, league string, play
 		}
 	}
 
-	// 8% rake
-	rakeAmount := totalBuyin.Mul(decimal.NewFromFloat(0.08)).Truncate(2)
+	// The prize pool must be funded by exactly one league buy-in per player
+	leagueBuyin, _ := s.leagues.Buyin(league)
+	expectedBuyin := leagueBuyin.Mul(decimal.NewFromInt(int64(len(players))))
+	if !totalBuyin.Equal(expectedBuyin) {
+		return nil, fmt.Errorf("%w: total buy-in %s, expected %s", ErrBuyinMismatch, totalBuyin.String(), expectedBuyin.String())
+	}
+
+	// The league's rake rate is stored on the match so settlement reports the rate actually charged
+	rakePercentage := s.rakeRates.ForLeague(league)
+	rakeAmount := monetary.CalculateRakeAt(totalBuyin, rakePercentage)
 	prizePool := totalBuyin.Sub(rakeAmount)
 
 	// Create match
@@ -142,6 +254,7 @@ func (s *gameEngineService) CreateMatch(ctx context.Context, league string, play
 		GhostPlayerCount: ghostPlayerCount,
 		PrizePool:        prizePool,
 		RakeAmount:       rakeAmount,
+		RakePercentage:   rakePercentage,
 		CrashSeed:        string(seedJSON),
 		CrashSeedHash:    commitHash,
 		StartedAt:        nil,
@@ -149,17 +262,6 @@ func (s *gameEngineService) CreateMatch(ctx context.Context, league string, play
 		CreatedAt:        time.Now(),
 	}
 
-	// Save match to database
-	err = s.matchRepo.Create(ctx, match)
-	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"match_id": matchID,
-			"league":   league,
-			"error":    err,
-		}).Error("Failed to create match")
-		return nil, fmt.Errorf("failed to create match: %w", err)
-	}
-
 	// Create match participants
 	participants := make([]*models.MatchParticipant, 0, 10)
 	for _, player := range players {
@@ -168,7 +270,7 @@ func (s *gameEngineService) CreateMatch(ctx context.Context, league string, play
 			UserID:            player.UserID,
 			IsGhost:           player.IsGhost,
 			GhostReplayID:     player.GhostReplayID,
-			PlayerDisplayName: player.DisplayName,
+			PlayerDisplayName: participantDisplayName(player.DisplayName),
 			BuyinAmount:       player.BuyinAmount,
 			Heat1Score:        nil,
 			Heat2Score:        nil,
@@ -182,28 +284,93 @@ func (s *gameEngineService) CreateMatch(ctx context.Context, league string, play
 		participants = append(participants, participant)
 	}
 
-	// Save participants to database
-	err = s.participantRepo.CreateBatch(ctx, participants)
+	// Save the match and its participants together, so a failed participant insert leaves no match behind
+	err = s.matchRepo.CreateWithParticipants(ctx, match, participants)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"match_id": matchID,
+			"league":   league,
 			"error":    err,
-		}).Error("Failed to create match participants")
-		return nil, fmt.Errorf("failed to create match participants: %w", err)
+		}).Error("Failed to create match")
+		return nil, fmt.Errorf("failed to create match: %w", err)
+	}
+
+	// Ghost scores are written to the player's own row, since one replay may fill several slots
+	for i, player := range players {
+		player.ParticipantID = participants[i].ID
 	}
 
 	s.logger.WithFields(logrus.Fields{
-		"match_id":      matchID,
-		"league":        league,
-		"live_players":  livePlayerCount,
-		"ghost_players": ghostPlayerCount,
-		"prize_pool":    prizePool,
-		"rake_amount":   rakeAmount,
+		"match_id":        matchID,
+		"league":          league,
+		"live_players":    livePlayerCount,
+		"ghost_players":   ghostPlayerCount,
+		"prize_pool":      prizePool,
+		"rake_amount":     rakeAmount,
+		"rake_percentage": rakePercentage,
 	}).Info("Match created successfully")
 
 	return match, nil
 }
 
+// validateMatchPlayers checks the player list of a new match
+func validateMatchPlayers(leagues *constants.LeagueRegistry, league string, players []*MatchPlayer) error {
+	if len(players) != 10 {
+		return fmt.Errorf("match must have exactly 10 players, got %d", len(players))
+	}
+
+	buyin, exists := leagues.Buyin(league)
+	if !exists {
+		return fmt.Errorf("invalid league: %s", league)
+	}
+
+	seenUsers := make(map[uuid.UUID]bool, len(players))
+	for i, player := range players {
+		if player == nil {
+			return fmt.Errorf("player %d is nil", i)
+		}
+
+		if strings.TrimSpace(player.DisplayName) == "" {
+			return fmt.Errorf("player %d has an empty display name", i)
+		}
+
+		// Ghost buy-ins are funded by HOUSE_FUEL and must match the league as well
+		if !player.BuyinAmount.Equal(buyin) {
+			return fmt.Errorf("%w: player %d buy-in %s does not match %s league buy-in %s",
+				ErrBuyinMismatch, i, player.BuyinAmount.String(), league, buyin.String())
+		}
+
+		if player.IsGhost {
+			if player.GhostReplayID == nil {
+				return fmt.Errorf("ghost player %d has no ghost replay ID", i)
+			}
+			if player.UserID != nil {
+				return fmt.Errorf("ghost player %d must not have a user ID", i)
+			}
+			continue
+		}
+
+		if player.UserID == nil {
+			return fmt.Errorf("live player %d has no user ID", i)
+		}
+
+		if seenUsers[*player.UserID] {
+			return fmt.Errorf("user %s appears more than once in the match", player.UserID.String())
+		}
+		seenUsers[*player.UserID] = true
+	}
+
+	return nil
+}
+
+// participantDisplayName returns the sanitized name a player races under
+func participantDisplayName(name string) string {
+	if sanitized := models.SanitizeDisplayName(name); sanitized != "" {
+		return sanitized
+	}
+	return models.DefaultDisplayName
+}
+
 // GetMatch retrieves a match by ID
 func (s *gameEngineService) GetMatch(ctx context.Context, matchID uuid.UUID) (*models.Match, error) {
 	match, err := s.matchRepo.GetByID(ctx, matchID)
@@ -212,12 +379,58 @@ func (s *gameEngineService) GetMatch(ctx context.Context, matchID uuid.UUID) (*m
 	}
 
 	if match == nil {
-		return nil, fmt.Errorf("match not found: %s", matchID)
+		return nil, fmt.Errorf("%w: %s", ErrMatchNotFound, matchID)
 	}
 
 	return match, nil
 }
 
+// GetMatchDetails retrieves a match with its participants and settlement (if completed)
+func (s *gameEngineService) GetMatchDetails(ctx context.Context, matchID uuid.UUID) (*MatchDetails, error) {
+	match, err := s.GetMatch(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	participants, err := s.participantRepo.GetByMatchID(ctx, matchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get match participants: %w", err)
+	}
+
+	details := &MatchDetails{
+		Match:        match,
+		Participants: participants,
+	}
+
+	if match.Status == models.MatchStatusCompleted {
+		settlement, err := s.settlementRepo.GetByMatchID(ctx, matchID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get match settlement: %w", err)
+		}
+		details.Settlement = settlement
+	} else {
+		// Crash seeds are only revealed once the match is over
+		redacted := *match
+		redacted.CrashSeed = ""
+		details.Match = &redacted
+	}
+
+	return details, nil
+}
+
+// GetActiveMatchDetails retrieves the forming or in-progress match a user races in
+func (s *gameEngineService) GetActiveMatchDetails(ctx context.Context, userID uuid.UUID) (*MatchDetails, error) {
+	matchID, err := s.participantRepo.GetActiveMatchForUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active match: %w", err)
+	}
+	if matchID == nil {
+		return nil, nil
+	}
+
+	return s.GetMatchDetails(ctx, *matchID)
+}
+
 // StartMatch starts a match (transitions from FORMING to IN_PROGRESS)
 func (s *gameEngineService) StartMatch(ctx context.Context, matchID uuid.UUID) error {
 	// Update match status
@@ -244,6 +457,10 @@ func (s *gameEngineService) StartMatch(ctx context.Context, matchID uuid.UUID) e
 
 // EarnPoints locks a player's score for the current heat
 func (s *gameEngineService) EarnPoints(ctx context.Context, matchID, userID uuid.UUID, score decimal.Decimal) error {
+	if err := validateScore(score); err != nil {
+		return err
+	}
+
 	// Get match to determine current heat
 	match, err := s.GetMatch(ctx, matchID)
 	if err != nil {
@@ -254,9 +471,12 @@ func (s *gameEngineService) EarnPoints(ctx context.Context, matchID, userID uuid
 		return fmt.Errorf("match is not in progress")
 	}
 
-	// TODO: Determine current heat from match state
-	// For now, assume Heat 1
-	currentHeat := 1
+	// The in-memory match state is authoritative for which heat is running
+	state, err := s.stateManager.GetMatchState(ctx, matchID)
+	if err != nil {
+		return fmt.Errorf("failed to get match state: %w", err)
+	}
+	currentHeat := state.CurrentHeat
 
 	// Validate score is achievable (anti-cheat)
 	if !s.physicsEngine.IsValidSpeed(score) {
@@ -297,13 +517,83 @@ func (s *gameEngineService) GetMatchState(ctx context.Context, matchID uuid.UUID
 		return nil, err
 	}
 
+	// Build match state
+	matchState := &MatchState{
+		MatchID:       matchID,
+		League:        string(match.League),
+		Status:        string(match.Status),
+		CurrentHeat:   1,
+		HeatStatus:    HeatStatusWaiting,
+		StartedAt:     match.StartedAt,
+		CompletedAt:   match.CompletedAt,
+		CrashSeed:     match.CrashSeed,
+		CrashSeedHash: match.CrashSeedHash,
+	}
+
+	// A live match is served from the in-memory state, which is ahead of the database
+	if match.Status == models.MatchStatusInProgress {
+		if state, err := s.stateManager.GetMatchState(ctx, matchID); err == nil {
+			matchState.CurrentHeat = state.CurrentHeat
+			matchState.HeatStatus = state.HeatStatus
+			matchState.Players = buildLivePlayerStates(state)
+			return matchState, nil
+		}
+	}
+
 	// Get participants
 	participants, err := s.participantRepo.GetByMatchID(ctx, matchID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get match participants: %w", err)
 	}
 
-	// Build player states
+	completed := match.Status == models.MatchStatusCompleted
+	if completed {
+		matchState.CurrentHeat = 3
+		matchState.HeatStatus = HeatStatusCompleted
+	}
+	matchState.Players = buildStoredPlayerStates(participants, completed)
+
+	return matchState, nil
+}
+
+// buildLivePlayerStates builds player states ranked by their running totals,
+// or by the final positions once the last heat has ended
+func buildLivePlayerStates(state *InMemoryMatchState) []*PlayerState {
+	players := make([]*InMemoryPlayer, 0, len(state.Players))
+	for _, player := range state.Players {
+		players = append(players, player)
+	}
+	if state.Status == MatchStatusCompleted {
+		sort.Slice(players, func(i, j int) bool {
+			return players[i].Position < players[j].Position
+		})
+	} else {
+		// The state is a copy, so ranking it does not touch the heat positions held in memory
+		rankPlayers(players, state.LockTimeTiebreak)
+	}
+
+	playerStates := make([]*PlayerState, 0, len(players))
+	for _, player := range players {
+		playerStates = append(playerStates, &PlayerState{
+			UserID:      player.UserID,
+			DisplayName: player.DisplayName,
+			IsGhost:     player.IsGhost,
+			Heat1Score:  player.Heat1Score,
+			Heat2Score:  player.Heat2Score,
+			Heat3Score:  player.Heat3Score,
+			TotalScore:  player.TotalScore,
+			Position:    player.Position,
+			IsAlive:     player.IsAlive,
+			HasLocked:   player.HasLocked,
+		})
+	}
+
+	return playerStates
+}
+
+// buildStoredPlayerStates builds player states from persisted participants,
+// ordered by final position once the match has been settled
+func buildStoredPlayerStates(participants []*models.MatchParticipant, completed bool) []*PlayerState {
 	playerStates := make([]*PlayerState, 0, len(participants))
 	for _, participant := range participants {
 		playerState := &PlayerState{
@@ -313,34 +603,29 @@ func (s *gameEngineService) GetMatchState(ctx context.Context, matchID uuid.UUID
 			Heat1Score:  participant.Heat1Score,
 			Heat2Score:  participant.Heat2Score,
 			Heat3Score:  participant.Heat3Score,
-			TotalScore: func() decimal.Decimal {
-				if participant.TotalScore != nil {
-					return *participant.TotalScore
-				}
-				return decimal.Zero
-			}(),
-			Position:  0,     // TODO: Calculate position
-			IsAlive:   true,  // TODO: Determine from current heat state
-			HasLocked: false, // TODO: Determine from current heat state
+			TotalScore:  participant.CalculateTotalScore(),
+			IsAlive:     true,
+			HasLocked:   completed && participant.Heat3Score != nil,
+		}
+		if participant.TotalScore != nil {
+			playerState.TotalScore = *participant.TotalScore
+		}
+		if participant.FinalPosition != nil {
+			playerState.Position = *participant.FinalPosition
 		}
 		playerStates = append(playerStates, playerState)
 	}
 
-	// Build match state
-	matchState := &MatchState{
-		MatchID:       matchID,
-		League:        string(match.League),
-		Status:        string(match.Status),
-		CurrentHeat:   1,                 // TODO: Determine from match state
-		HeatStatus:    HeatStatusWaiting, // TODO: Determine from heat manager
-		Players:       playerStates,
-		StartedAt:     match.StartedAt,
-		CompletedAt:   match.CompletedAt,
-		CrashSeed:     match.CrashSeed,
-		CrashSeedHash: match.CrashSeedHash,
-	}
+	// Unranked players (position 0) go last
+	sort.SliceStable(playerStates, func(i, j int) bool {
+		pi, pj := playerStates[i].Position, playerStates[j].Position
+		if pi == 0 || pj == 0 {
+			return pi != 0 && pj == 0
+		}
+		return pi < pj
+	})
 
-	return matchState, nil
+	return playerStates
 }
 
 // CompleteMatch completes a match and triggers settlement
@@ -0,0 +1,261 @@
+package gameengine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+type GameEngineServiceIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper        *repository.TestDBHelper
+	userRepo        repository.UserRepository
+	matchRepo       repository.MatchRepository
+	participantRepo repository.MatchParticipantRepository
+	settlementRepo  repository.MatchSettlementRepository
+	stateManager    MatchStateManager
+	service         GameEngineService
+}
+
+func TestGameEngineServiceIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(GameEngineServiceIntegrationTestSuite))
+}
+
+func (suite *GameEngineServiceIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = repository.NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	suite.userRepo = repository.NewUserRepository(suite.dbHelper.DB)
+	suite.matchRepo = repository.NewMatchRepository(suite.dbHelper.DB)
+	suite.participantRepo = repository.NewMatchParticipantRepository(suite.dbHelper.DB)
+	suite.settlementRepo = repository.NewMatchSettlementRepository(suite.dbHelper.DB)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	suite.stateManager = NewMatchStateManager(logger)
+	suite.service = NewGameEngineService(suite.matchRepo, suite.participantRepo, suite.settlementRepo, suite.stateManager, logger)
+}
+
+func (suite *GameEngineServiceIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *GameEngineServiceIntegrationTestSuite) SetupTest() {
+	suite.dbHelper.CleanupTables("match_settlements", "match_participants", "matches", "users")
+}
+
+// createMatch inserts a match with 10 live participants and returns it with the participant user IDs
+func (suite *GameEngineServiceIntegrationTestSuite) createMatch(status models.MatchStatus) (*models.Match, []uuid.UUID) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	match := &models.Match{
+		ID:               uuid.New(),
+		League:           models.LeagueRookie,
+		Status:           status,
+		LivePlayerCount:  10,
+		GhostPlayerCount: 0,
+		PrizePool:        decimal.NewFromInt(92),
+		RakeAmount:       decimal.NewFromInt(8),
+		CrashSeed:        "test-crash-seed",
+		CrashSeedHash:    "test-crash-seed-hash",
+		CreatedAt:        now,
+	}
+	require.NoError(suite.T(), suite.matchRepo.Create(ctx, match))
+
+	userIDs := make([]uuid.UUID, 0, 10)
+	participants := make([]*models.MatchParticipant, 0, 10)
+	for i := 0; i < 10; i++ {
+		user := &models.User{
+			ID:                uuid.New(),
+			TelegramID:        time.Now().UnixNano() + int64(i),
+			TelegramFirstName: fmt.Sprintf("Racer %d", i),
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+		require.NoError(suite.T(), suite.userRepo.Create(ctx, user))
+		userIDs = append(userIDs, user.ID)
+
+		userID := user.ID
+		participants = append(participants, &models.MatchParticipant{
+			MatchID:           match.ID,
+			UserID:            &userID,
+			PlayerDisplayName: user.TelegramFirstName,
+			BuyinAmount:       decimal.NewFromInt(10),
+			PrizeAmount:       decimal.Zero,
+			BurnReward:        decimal.Zero,
+			CreatedAt:         now,
+		})
+	}
+	require.NoError(suite.T(), suite.participantRepo.CreateBatch(ctx, participants))
+
+	return match, userIDs
+}
+
+func (suite *GameEngineServiceIntegrationTestSuite) TestGetMatchDetails_InProgress() {
+	ctx := context.Background()
+	match, userIDs := suite.createMatch(models.MatchStatusInProgress)
+
+	details, err := suite.service.GetMatchDetails(ctx, match.ID)
+	require.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), match.ID, details.Match.ID)
+	assert.Equal(suite.T(), models.MatchStatusInProgress, details.Match.Status)
+	assert.Len(suite.T(), details.Participants, 10)
+	assert.Nil(suite.T(), details.Settlement)
+	assert.True(suite.T(), details.HasParticipant(userIDs[0]))
+	assert.False(suite.T(), details.HasParticipant(uuid.New()))
+
+	// Crash seeds stay hidden until the match is completed
+	assert.Empty(suite.T(), details.Match.CrashSeed)
+	assert.Equal(suite.T(), match.CrashSeedHash, details.Match.CrashSeedHash)
+}
+
+func (suite *GameEngineServiceIntegrationTestSuite) TestGetMatchDetails_Completed() {
+	ctx := context.Background()
+	match, _ := suite.createMatch(models.MatchStatusCompleted)
+
+	settledAt := time.Now().UTC().Truncate(time.Microsecond)
+	require.NoError(suite.T(), suite.settlementRepo.Create(ctx, &models.MatchSettlement{
+		MatchID:   match.ID,
+		SettledAt: settledAt,
+	}))
+
+	details, err := suite.service.GetMatchDetails(ctx, match.ID)
+	require.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), models.MatchStatusCompleted, details.Match.Status)
+	assert.Len(suite.T(), details.Participants, 10)
+	require.NotNil(suite.T(), details.Settlement)
+	assert.Equal(suite.T(), match.ID, details.Settlement.MatchID)
+	assert.Equal(suite.T(), match.CrashSeed, details.Match.CrashSeed)
+}
+
+func (suite *GameEngineServiceIntegrationTestSuite) TestGetMatchDetails_NotFound() {
+	details, err := suite.service.GetMatchDetails(context.Background(), uuid.New())
+
+	assert.ErrorIs(suite.T(), err, ErrMatchNotFound)
+	assert.Nil(suite.T(), details)
+}
+
+// createLivePlayers creates n users and returns them as ROOKIE match players
+func (suite *GameEngineServiceIntegrationTestSuite) createLivePlayers(n int) []*MatchPlayer {
+	now := time.Now().UTC()
+	players := make([]*MatchPlayer, 0, n)
+	for i := 0; i < n; i++ {
+		user := &models.User{
+			ID:                uuid.New(),
+			TelegramID:        time.Now().UnixNano() + int64(i),
+			TelegramFirstName: fmt.Sprintf("Racer %d", i),
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+		require.NoError(suite.T(), suite.userRepo.Create(context.Background(), user))
+		players = append(players, &MatchPlayer{
+			UserID:      &user.ID,
+			DisplayName: user.TelegramFirstName,
+			BuyinAmount: decimal.NewFromInt(10),
+		})
+	}
+	return players
+}
+
+func (suite *GameEngineServiceIntegrationTestSuite) TestCreateMatch_StoresMatchWithParticipants() {
+	ctx := context.Background()
+
+	match, err := suite.service.CreateMatch(ctx, "ROOKIE", suite.createLivePlayers(10))
+	require.NoError(suite.T(), err)
+
+	stored, err := suite.matchRepo.GetByID(ctx, match.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), stored)
+	assert.Equal(suite.T(), models.MatchStatusForming, stored.Status)
+
+	participants, err := suite.participantRepo.GetByMatchID(ctx, match.ID)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), participants, 10)
+}
+
+func (suite *GameEngineServiceIntegrationTestSuite) TestCreateMatch_ParticipantFailureLeavesNoMatch() {
+	ctx := context.Background()
+
+	// The last player has no user row, so their participant insert violates the foreign key
+	players := suite.createLivePlayers(9)
+	unknownUserID := uuid.New()
+	players = append(players, &MatchPlayer{UserID: &unknownUserID, DisplayName: "Nobody", BuyinAmount: decimal.NewFromInt(10)})
+
+	match, err := suite.service.CreateMatch(ctx, "ROOKIE", players)
+	require.Error(suite.T(), err)
+	assert.ErrorIs(suite.T(), err, repository.ErrForeignKeyViolation)
+	assert.Nil(suite.T(), match)
+
+	var matches, participants int
+	require.NoError(suite.T(), suite.dbHelper.DB.Get(&matches, "SELECT COUNT(*) FROM matches"))
+	require.NoError(suite.T(), suite.dbHelper.DB.Get(&participants, "SELECT COUNT(*) FROM match_participants"))
+	assert.Zero(suite.T(), matches)
+	assert.Zero(suite.T(), participants)
+}
+
+func (suite *GameEngineServiceIntegrationTestSuite) TestGetActiveMatchDetails() {
+	ctx := context.Background()
+	active, activeUserIDs := suite.createMatch(models.MatchStatusInProgress)
+	_, finishedUserIDs := suite.createMatch(models.MatchStatusCompleted)
+
+	details, err := suite.service.GetActiveMatchDetails(ctx, activeUserIDs[3])
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), details)
+	assert.Equal(suite.T(), active.ID, details.Match.ID)
+	assert.Len(suite.T(), details.Participants, 10)
+	assert.Empty(suite.T(), details.Match.CrashSeed)
+
+	// A player whose only match is over has nothing to rejoin
+	details, err = suite.service.GetActiveMatchDetails(ctx, finishedUserIDs[0])
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), details)
+
+	details, err = suite.service.GetActiveMatchDetails(ctx, uuid.New())
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), details)
+}
+
+func (suite *GameEngineServiceIntegrationTestSuite) TestEarnPoints_WritesCurrentHeat() {
+	ctx := context.Background()
+	match, userIDs := suite.createMatch(models.MatchStatusInProgress)
+
+	players := make([]*MatchPlayer, 0, len(userIDs))
+	for i := range userIDs {
+		players = append(players, &MatchPlayer{UserID: &userIDs[i], DisplayName: "Racer", BuyinAmount: decimal.NewFromInt(10)})
+	}
+	require.NoError(suite.T(), suite.stateManager.CreateMatchState(ctx, match.ID, string(match.League), players))
+	defer suite.stateManager.RemoveMatchState(ctx, match.ID)
+	require.NoError(suite.T(), suite.stateManager.StartHeat(ctx, match.ID, 2))
+
+	score := decimal.RequireFromString("123.45")
+	require.NoError(suite.T(), suite.service.EarnPoints(ctx, match.ID, userIDs[0], score))
+
+	participants, err := suite.participantRepo.GetByMatchID(ctx, match.ID)
+	require.NoError(suite.T(), err)
+	for _, participant := range participants {
+		if participant.UserID == nil || *participant.UserID != userIDs[0] {
+			continue
+		}
+		assert.Nil(suite.T(), participant.Heat1Score)
+		require.NotNil(suite.T(), participant.Heat2Score)
+		assert.True(suite.T(), score.Equal(*participant.Heat2Score))
+		assert.Nil(suite.T(), participant.Heat3Score)
+		return
+	}
+	suite.T().Fatal("participant not found")
+}
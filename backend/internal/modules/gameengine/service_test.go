@@ -0,0 +1,567 @@
+package gameengine
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/constants"
+	monetary "github.com/megaherz/ndr/internal/decimal"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// stubMatchRepository records created matches and serves them by ID; other methods are not used by these tests.
+// Participants created with a match are handed to participants when set.
+type stubMatchRepository struct {
+	repository.MatchRepository
+	created      []*models.Match
+	participants *stubParticipantRepository
+}
+
+func (r *stubMatchRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Match, error) {
+	for _, match := range r.created {
+		if match.ID == id {
+			return match, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *stubMatchRepository) Create(ctx context.Context, match *models.Match) error {
+	r.created = append(r.created, match)
+	return nil
+}
+
+func (r *stubMatchRepository) CreateWithParticipants(ctx context.Context, match *models.Match, participants []*models.MatchParticipant) error {
+	r.created = append(r.created, match)
+	if r.participants != nil {
+		return r.participants.CreateBatch(ctx, participants)
+	}
+	return nil
+}
+
+// stubParticipantRepository records created participants and heat scores; other methods are not used by these tests
+type stubParticipantRepository struct {
+	repository.MatchParticipantRepository
+	mu         sync.Mutex
+	created    []*models.MatchParticipant
+	heatScores map[int]decimal.Decimal
+}
+
+func (r *stubParticipantRepository) GetByMatchID(ctx context.Context, matchID uuid.UUID) ([]*models.MatchParticipant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	participants := make([]*models.MatchParticipant, 0, len(r.created))
+	for _, participant := range r.created {
+		participantCopy := *participant
+		participants = append(participants, &participantCopy)
+	}
+	return participants, nil
+}
+
+// GetStandings ranks the recorded participants like the repository query: by total, then Heat 3,
+// Heat 2 and Heat 1 score with missing scores last, earlier participants first on a full tie
+func (r *stubParticipantRepository) GetStandings(ctx context.Context, matchID uuid.UUID) ([]*models.MatchParticipant, error) {
+	participants, err := r.GetByMatchID(ctx, matchID)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(participants, func(i, j int) bool {
+		return standingsLess(participants[i], participants[j])
+	})
+	return participants, nil
+}
+
+// standingsLess reports whether participant a ranks above b in the standings order
+func standingsLess(a, b *models.MatchParticipant) bool {
+	for _, scores := range [][2]*decimal.Decimal{
+		{a.TotalScore, b.TotalScore},
+		{a.Heat3Score, b.Heat3Score},
+		{a.Heat2Score, b.Heat2Score},
+		{a.Heat1Score, b.Heat1Score},
+	} {
+		sa, sb := scores[0], scores[1]
+		switch {
+		case sa == nil && sb == nil:
+			continue
+		case sa == nil:
+			return false
+		case sb == nil:
+			return true
+		case !sa.Equal(*sb):
+			return sa.GreaterThan(*sb)
+		}
+	}
+	return false
+}
+
+func (r *stubParticipantRepository) UpdateTotalScore(ctx context.Context, matchID, userID uuid.UUID, totalScore decimal.Decimal) error {
+	return nil
+}
+
+func (r *stubParticipantRepository) UpdateGhostHeatScore(ctx context.Context, participantID int64, heat int, score decimal.Decimal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, participant := range r.created {
+		if !participant.IsGhost || participant.ID != participantID {
+			continue
+		}
+		switch heat {
+		case 1:
+			participant.Heat1Score = &score
+		case 2:
+			participant.Heat2Score = &score
+		case 3:
+			participant.Heat3Score = &score
+		}
+	}
+	return nil
+}
+
+func (r *stubParticipantRepository) UpdateGhostHeatLockTime(ctx context.Context, participantID int64, heat int, lockTime float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, participant := range r.created {
+		if participant.IsGhost && participant.ID == participantID {
+			setParticipantLockTime(participant, heat, lockTime)
+		}
+	}
+	return nil
+}
+
+func (r *stubParticipantRepository) UpdateGhostTotalScore(ctx context.Context, participantID int64, totalScore decimal.Decimal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, participant := range r.created {
+		if participant.IsGhost && participant.ID == participantID {
+			participant.TotalScore = &totalScore
+		}
+	}
+	return nil
+}
+
+func (r *stubParticipantRepository) UpdateHeatScore(ctx context.Context, matchID, userID uuid.UUID, heat int, score decimal.Decimal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.heatScores == nil {
+		r.heatScores = make(map[int]decimal.Decimal)
+	}
+	r.heatScores[heat] = score
+	return nil
+}
+
+func (r *stubParticipantRepository) UpdateHeatLockTime(ctx context.Context, matchID, userID uuid.UUID, heat int, lockTime float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, participant := range r.created {
+		if participant.UserID != nil && *participant.UserID == userID {
+			setParticipantLockTime(participant, heat, lockTime)
+		}
+	}
+	return nil
+}
+
+// setParticipantLockTime stores a lock time on a participant like the lock time columns
+func setParticipantLockTime(participant *models.MatchParticipant, heat int, lockTime float64) {
+	switch heat {
+	case 1:
+		participant.Heat1LockTime = &lockTime
+	case 2:
+		participant.Heat2LockTime = &lockTime
+	case 3:
+		participant.Heat3LockTime = &lockTime
+	}
+}
+
+func (r *stubParticipantRepository) CreateBatch(ctx context.Context, participants []*models.MatchParticipant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Rows are numbered like the table's serial IDs
+	for _, participant := range participants {
+		participant.ID = int64(len(r.created) + 1)
+		r.created = append(r.created, participant)
+	}
+	return nil
+}
+
+func newTestGameEngineService() (GameEngineService, *stubMatchRepository, *stubParticipantRepository) {
+	service, matchRepo, participantRepo, _ := newTestGameEngineServiceWithState()
+	return service, matchRepo, participantRepo
+}
+
+func newTestGameEngineServiceWithState() (GameEngineService, *stubMatchRepository, *stubParticipantRepository, MatchStateManager) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	participantRepo := &stubParticipantRepository{}
+	matchRepo := &stubMatchRepository{participants: participantRepo}
+	stateManager := NewMatchStateManager(logger)
+	return NewGameEngineService(matchRepo, participantRepo, nil, stateManager, logger), matchRepo, participantRepo, stateManager
+}
+
+// newValidPlayers returns 8 live players and 2 ghosts with the ROOKIE buy-in
+func newValidPlayers() []*MatchPlayer {
+	players := make([]*MatchPlayer, 0, 10)
+	for i := 0; i < 8; i++ {
+		userID := uuid.New()
+		players = append(players, &MatchPlayer{
+			UserID:      &userID,
+			DisplayName: "Racer",
+			BuyinAmount: decimal.NewFromInt(10),
+		})
+	}
+	for i := 0; i < 2; i++ {
+		replayID := uuid.New()
+		players = append(players, &MatchPlayer{
+			DisplayName:   "Ghost",
+			IsGhost:       true,
+			GhostReplayID: &replayID,
+			BuyinAmount:   decimal.NewFromInt(10),
+		})
+	}
+	return players
+}
+
+func TestValidateMatchPlayers_FollowsRegistryBuyin(t *testing.T) {
+	leagues := constants.DefaultLeagues().All()
+	for i := range leagues {
+		if leagues[i].Name == constants.LeagueRookie {
+			leagues[i].Buyin = decimal.NewFromInt(12)
+		}
+	}
+	registry, err := constants.NewLeagueRegistry(leagues...)
+	require.NoError(t, err)
+
+	// Players paying the launch buy-in no longer match the league
+	err = validateMatchPlayers(registry, constants.LeagueRookie, newValidPlayers())
+	assert.ErrorIs(t, err, ErrBuyinMismatch)
+
+	players := newValidPlayers()
+	for _, player := range players {
+		player.BuyinAmount = decimal.NewFromInt(12)
+	}
+	assert.NoError(t, validateMatchPlayers(registry, constants.LeagueRookie, players))
+}
+
+func TestValidateMatchPlayers_Valid(t *testing.T) {
+	err := validateMatchPlayers(constants.DefaultLeagues(), "ROOKIE", newValidPlayers())
+
+	assert.NoError(t, err)
+}
+
+func TestValidateMatchPlayers_WrongPlayerCount(t *testing.T) {
+	players := newValidPlayers()[:9]
+
+	err := validateMatchPlayers(constants.DefaultLeagues(), "ROOKIE", players)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exactly 10 players")
+}
+
+func TestValidateMatchPlayers_InvalidLeague(t *testing.T) {
+	err := validateMatchPlayers(constants.DefaultLeagues(), "MEGA", newValidPlayers())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid league")
+}
+
+func TestValidateMatchPlayers_LivePlayerWithoutUserID(t *testing.T) {
+	players := newValidPlayers()
+	players[3].UserID = nil
+
+	err := validateMatchPlayers(constants.DefaultLeagues(), "ROOKIE", players)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "live player 3 has no user ID")
+}
+
+func TestValidateMatchPlayers_GhostWithoutReplayID(t *testing.T) {
+	players := newValidPlayers()
+	players[9].GhostReplayID = nil
+
+	err := validateMatchPlayers(constants.DefaultLeagues(), "ROOKIE", players)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ghost player 9 has no ghost replay ID")
+}
+
+func TestValidateMatchPlayers_GhostWithUserID(t *testing.T) {
+	players := newValidPlayers()
+	userID := uuid.New()
+	players[8].UserID = &userID
+
+	err := validateMatchPlayers(constants.DefaultLeagues(), "ROOKIE", players)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "must not have a user ID")
+}
+
+func TestValidateMatchPlayers_EmptyDisplayName(t *testing.T) {
+	players := newValidPlayers()
+	players[1].DisplayName = "   "
+
+	err := validateMatchPlayers(constants.DefaultLeagues(), "ROOKIE", players)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "empty display name")
+}
+
+func TestValidateMatchPlayers_BuyinMismatch(t *testing.T) {
+	players := newValidPlayers()
+	players[0].BuyinAmount = decimal.NewFromInt(50)
+
+	err := validateMatchPlayers(constants.DefaultLeagues(), "ROOKIE", players)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not match ROOKIE league buy-in")
+}
+
+func TestValidateMatchPlayers_DuplicateUserID(t *testing.T) {
+	players := newValidPlayers()
+	players[5].UserID = players[2].UserID
+
+	err := validateMatchPlayers(constants.DefaultLeagues(), "ROOKIE", players)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "appears more than once")
+}
+
+func TestValidateMatchPlayers_NilPlayer(t *testing.T) {
+	players := newValidPlayers()
+	players[4] = nil
+
+	err := validateMatchPlayers(constants.DefaultLeagues(), "ROOKIE", players)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "player 4 is nil")
+}
+
+func TestCreateMatch_MismatchedBuyin(t *testing.T) {
+	service, matchRepo, participantRepo := newTestGameEngineService()
+	players := newValidPlayers()
+	players[9].BuyinAmount = decimal.NewFromInt(5) // Underfunded ghost
+
+	match, err := service.CreateMatch(context.Background(), "ROOKIE", players)
+
+	assert.ErrorIs(t, err, ErrBuyinMismatch)
+	assert.Nil(t, match)
+	assert.Empty(t, matchRepo.created)
+	assert.Empty(t, participantRepo.created)
+}
+
+func TestCreateMatch_CorrectLobby(t *testing.T) {
+	service, matchRepo, participantRepo := newTestGameEngineService()
+
+	match, err := service.CreateMatch(context.Background(), "ROOKIE", newValidPlayers())
+
+	require.NoError(t, err)
+	require.Len(t, matchRepo.created, 1)
+	assert.Len(t, participantRepo.created, 10)
+	assert.Equal(t, 8, match.LivePlayerCount)
+	assert.Equal(t, 2, match.GhostPlayerCount)
+
+	// 10 x 10 FUEL buy-ins, minus 8% rake
+	assert.True(t, match.RakeAmount.Equal(decimal.NewFromInt(8)))
+	assert.True(t, match.PrizePool.Equal(decimal.NewFromInt(92)))
+}
+
+func TestCreateMatch_SanitizesParticipantNames(t *testing.T) {
+	service, _, participantRepo := newTestGameEngineService()
+
+	players := newValidPlayers()
+	players[0].DisplayName = "Nitro\u202e\x00\nRacer"
+	players[1].DisplayName = "ADMIN"
+
+	_, err := service.CreateMatch(context.Background(), "ROOKIE", players)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Nitro Racer", participantRepo.created[0].PlayerDisplayName)
+	assert.Equal(t, models.DefaultDisplayName, participantRepo.created[1].PlayerDisplayName)
+}
+
+func TestCreateMatch_ConfiguredRake(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	matchRepo := &stubMatchRepository{}
+	rates := monetary.NewRakeRates(monetary.RakePercentage, map[string]decimal.Decimal{"ROOKIE": decimal.NewFromInt(5)})
+	service := NewGameEngineService(matchRepo, &stubParticipantRepository{}, nil, NewMatchStateManager(logger), logger, WithRakeRates(rates))
+
+	match, err := service.CreateMatch(context.Background(), "ROOKIE", newValidPlayers())
+	require.NoError(t, err)
+
+	// 10 x 10 FUEL buy-ins, minus the 5% promotional rake, with the rate stored on the match
+	assert.Equal(t, "5.00", match.RakeAmount.StringFixed(2))
+	assert.Equal(t, "95.00", match.PrizePool.StringFixed(2))
+	assert.Equal(t, "5.00", match.RakePercentage.StringFixed(2))
+	require.Len(t, matchRepo.created, 1)
+	assert.True(t, matchRepo.created[0].RakePercentage.Equal(decimal.NewFromInt(5)))
+}
+
+func TestEarnPoints_WritesCurrentHeat(t *testing.T) {
+	ctx := context.Background()
+	service, matchRepo, participantRepo, stateManager := newTestGameEngineServiceWithState()
+
+	players := newValidPlayers()
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusInProgress}
+	matchRepo.created = append(matchRepo.created, match)
+	require.NoError(t, stateManager.CreateMatchState(ctx, match.ID, "ROOKIE", players))
+	require.NoError(t, stateManager.StartHeat(ctx, match.ID, 2))
+
+	score := decimal.RequireFromString("250.5")
+	require.NoError(t, service.EarnPoints(ctx, match.ID, *players[0].UserID, score))
+
+	require.Contains(t, participantRepo.heatScores, 2)
+	assert.True(t, score.Equal(participantRepo.heatScores[2]))
+	assert.NotContains(t, participantRepo.heatScores, 1)
+}
+
+func TestEarnPoints_NoMatchState(t *testing.T) {
+	service, matchRepo, participantRepo, _ := newTestGameEngineServiceWithState()
+
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusInProgress}
+	matchRepo.created = append(matchRepo.created, match)
+
+	err := service.EarnPoints(context.Background(), match.ID, uuid.New(), decimal.NewFromInt(100))
+
+	assert.Error(t, err)
+	assert.Empty(t, participantRepo.heatScores)
+}
+
+// activateHeat moves the current heat from its countdown to ACTIVE so scores can be locked
+func activateHeat(t *testing.T, stateManager MatchStateManager, matchID uuid.UUID) {
+	require.NoError(t, stateManager.SetHeatStatus(context.Background(), matchID, HeatStatusActive))
+}
+
+func TestGetMatchState_LivePositionsFollowState(t *testing.T) {
+	ctx := context.Background()
+	service, matchRepo, _, stateManager := newTestGameEngineServiceWithState()
+
+	players := newValidPlayers()
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusInProgress}
+	matchRepo.created = append(matchRepo.created, match)
+	require.NoError(t, stateManager.CreateMatchState(ctx, match.ID, "ROOKIE", players))
+
+	// Scores per heat for the first four live players; the rest never lock
+	heatScores := [][]int64{
+		{100, 300, 200, 50},
+		{200, 50, 100, 250},
+		{150, 100, 0, 300},
+	}
+	for heat, scores := range heatScores {
+		require.NoError(t, stateManager.StartHeat(ctx, match.ID, heat+1))
+		activateHeat(t, stateManager, match.ID)
+		for i, score := range scores {
+			_, err := stateManager.LockPlayerScore(ctx, match.ID, *players[i].UserID, decimal.NewFromInt(score))
+			require.NoError(t, err)
+		}
+
+		// Mid-heat: standings reflect running totals and lock flags
+		matchState, err := service.GetMatchState(ctx, match.ID)
+		require.NoError(t, err)
+		assert.Equal(t, heat+1, matchState.CurrentHeat)
+		assert.Equal(t, HeatStatusActive, matchState.HeatStatus)
+		require.Len(t, matchState.Players, len(players))
+		for i, playerState := range matchState.Players {
+			assert.Equal(t, i+1, playerState.Position)
+			if i > 0 {
+				assert.False(t, playerState.TotalScore.GreaterThan(matchState.Players[i-1].TotalScore))
+			}
+			assert.True(t, playerState.IsAlive)
+			locked := playerState.UserID != nil && (*playerState.UserID == *players[0].UserID ||
+				*playerState.UserID == *players[1].UserID ||
+				*playerState.UserID == *players[2].UserID ||
+				*playerState.UserID == *players[3].UserID)
+			assert.Equal(t, locked, playerState.HasLocked)
+		}
+
+		require.NoError(t, stateManager.EndHeat(ctx, match.ID))
+	}
+
+	// After the final heat the state holds the authoritative final positions
+	state, err := stateManager.GetMatchState(ctx, match.ID)
+	require.NoError(t, err)
+	matchState, err := service.GetMatchState(ctx, match.ID)
+	require.NoError(t, err)
+
+	for _, playerState := range matchState.Players {
+		if playerState.UserID == nil {
+			continue
+		}
+		expected, ok := state.Players[*playerState.UserID]
+		require.True(t, ok)
+		assert.Equal(t, expected.Position, playerState.Position, "position of %s", playerState.UserID)
+		assert.True(t, expected.TotalScore.Equal(playerState.TotalScore))
+	}
+
+	// Totals are 450, 450, 300, 600: player 3 wins, then the Heat 3 tiebreaker favours player 0
+	assert.Equal(t, *players[3].UserID, *matchState.Players[0].UserID)
+	assert.Equal(t, *players[0].UserID, *matchState.Players[1].UserID)
+	assert.Equal(t, *players[1].UserID, *matchState.Players[2].UserID)
+	assert.Equal(t, *players[2].UserID, *matchState.Players[3].UserID)
+}
+
+func TestGetMatchState_CompletedUsesFinalStandings(t *testing.T) {
+	service, matchRepo, participantRepo, _ := newTestGameEngineServiceWithState()
+
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusCompleted}
+	matchRepo.created = append(matchRepo.created, match)
+	for _, position := range []int{3, 1, 2} {
+		finalPosition := position
+		total := decimal.NewFromInt(int64(1000 - position*100))
+		heat3 := decimal.NewFromInt(100)
+		participantRepo.created = append(participantRepo.created, &models.MatchParticipant{
+			MatchID:       match.ID,
+			Heat3Score:    &heat3,
+			TotalScore:    &total,
+			FinalPosition: &finalPosition,
+		})
+	}
+
+	matchState, err := service.GetMatchState(context.Background(), match.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, HeatStatusCompleted, matchState.HeatStatus)
+	require.Len(t, matchState.Players, 3)
+	for i, playerState := range matchState.Players {
+		assert.Equal(t, i+1, playerState.Position)
+		assert.True(t, decimal.NewFromInt(int64(1000-(i+1)*100)).Equal(playerState.TotalScore))
+		assert.True(t, playerState.HasLocked)
+	}
+}
+
+func TestEarnPoints_RejectsInvalidScores(t *testing.T) {
+	ctx := context.Background()
+	service, matchRepo, participantRepo, stateManager := newTestGameEngineServiceWithState()
+
+	players := newValidPlayers()
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusInProgress}
+	matchRepo.created = append(matchRepo.created, match)
+	require.NoError(t, stateManager.CreateMatchState(ctx, match.ID, "ROOKIE", players))
+	require.NoError(t, stateManager.StartHeat(ctx, match.ID, 1))
+
+	for _, score := range []string{"-1.00", "100.125"} {
+		err := service.EarnPoints(ctx, match.ID, *players[0].UserID, decimal.RequireFromString(score))
+		assert.ErrorIs(t, err, ErrInvalidScore, score)
+	}
+	assert.Empty(t, participantRepo.heatScores)
+
+	require.NoError(t, service.EarnPoints(ctx, match.ID, *players[0].UserID, decimal.RequireFromString("100.12")))
+	assert.Contains(t, participantRepo.heatScores, 1)
+}
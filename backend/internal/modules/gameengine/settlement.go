@@ -2,6 +2,7 @@ package gameengine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,8 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/megaherz/ndr/internal/constants"
+	monetary "github.com/megaherz/ndr/internal/decimal"
+	"github.com/megaherz/ndr/internal/metrics"
 	"github.com/megaherz/ndr/internal/modules/account"
 	"github.com/megaherz/ndr/internal/modules/gateway"
 	"github.com/megaherz/ndr/internal/modules/gateway/events"
@@ -17,31 +20,46 @@ import (
 	"github.com/megaherz/ndr/internal/storage/postgres/repository"
 )
 
+// ErrMatchAlreadySettled is returned when a match has already been paid out or aborted
+var ErrMatchAlreadySettled = errors.New("match already settled")
+
 // SettlementService handles match settlement and prize distribution
 type SettlementService interface {
-	// SettleMatch calculates final positions, distributes prizes, and applies ledger entries
+	// SettleMatch calculates final positions, distributes prizes, and applies ledger entries.
+	// A match that was already paid out or aborted is refused with ErrMatchAlreadySettled.
 	SettleMatch(ctx context.Context, matchID uuid.UUID) (*MatchSettlement, error)
 
 	// CalculatePositions calculates final positions with tiebreaker logic
 	CalculatePositions(ctx context.Context, matchID uuid.UUID) ([]*PlayerPosition, error)
 
-	// CalculatePrizes calculates prize distribution based on positions
-	CalculatePrizes(ctx context.Context, matchID uuid.UUID, positions []*PlayerPosition) (*PrizeDistribution, error)
+	// CalculatePrizes calculates prize distribution of an already loaded match based on positions
+	CalculatePrizes(ctx context.Context, match *models.Match, positions []*PlayerPosition) (*PrizeDistribution, error)
 
-	// ApplySettlement applies all ledger entries for the settlement
+	// ApplySettlement applies all ledger entries for the settlement and completes the match in the
+	// same transaction. It returns ErrMatchAlreadySettled, applying nothing, if the match has already
+	// completed or been aborted.
 	ApplySettlement(ctx context.Context, matchID uuid.UUID, settlement *MatchSettlement) error
+
+	// RefundMatch returns every buy-in of an unsettled match to whoever paid it and marks the
+	// match aborted in the same transaction. It returns ErrMatchAlreadySettled, refunding nothing,
+	// if the match has already completed or been aborted.
+	RefundMatch(ctx context.Context, matchID uuid.UUID) ([]*models.LedgerEntry, error)
 }
 
 // MatchSettlement represents the complete settlement of a match
 type MatchSettlement struct {
-	MatchID           uuid.UUID             `json:"match_id"`
-	League            string                `json:"league"`
-	SettledAt         time.Time             `json:"settled_at"`
-	Positions         []*PlayerPosition     `json:"positions"`
-	PrizePool         decimal.Decimal       `json:"prize_pool"`
-	RakeAmount        decimal.Decimal       `json:"rake_amount"`
-	PrizeDistribution *PrizeDistribution    `json:"prize_distribution"`
-	LedgerEntries     []*models.LedgerEntry `json:"ledger_entries"`
+	MatchID           uuid.UUID                    `json:"match_id"`
+	League            string                       `json:"league"`
+	SettledAt         time.Time                    `json:"settled_at"`
+	Positions         []*PlayerPosition            `json:"positions"`
+	PrizePool         decimal.Decimal              `json:"prize_pool"`
+	RakeAmount        decimal.Decimal              `json:"rake_amount"`
+	RakePercentage    decimal.Decimal              `json:"rake_percentage"` // Rate stored on the match when it was created
+	PrizeDistribution *PrizeDistribution           `json:"prize_distribution"`
+	LedgerEntries     []*models.LedgerEntry        `json:"ledger_entries"`
+	Wallets           map[uuid.UUID]*models.Wallet `json:"-"`               // Live players' wallets as the settlement left them
+	CrashSeed         string                       `json:"crash_seed"`      // Revealed once the match is settled
+	CrashSeedHash     string                       `json:"crash_seed_hash"` // Commitment published before play
 }
 
 // PlayerPosition represents a player's final position and scores
@@ -53,6 +71,9 @@ type PlayerPosition struct {
 	Heat1Score    decimal.Decimal `json:"heat1_score"`
 	Heat2Score    decimal.Decimal `json:"heat2_score"`
 	Heat3Score    decimal.Decimal `json:"heat3_score"`
+	Heat1LockTime *float64        `json:"heat1_lock_time,omitempty"` // Seconds into the heat, nil if not locked
+	Heat2LockTime *float64        `json:"heat2_lock_time,omitempty"`
+	Heat3LockTime *float64        `json:"heat3_lock_time,omitempty"`
 	TotalScore    decimal.Decimal `json:"total_score"`
 	PrizeAmount   decimal.Decimal `json:"prize_amount"`
 	BurnReward    decimal.Decimal `json:"burn_reward"`
@@ -67,75 +88,117 @@ type PrizeDistribution struct {
 	BurnRewards    map[int]decimal.Decimal `json:"burn_rewards"` // BURN rewards by position
 }
 
-// League-specific BURN reward tables
-var burnRewardTables = map[string]map[int]decimal.Decimal{
-	constants.LeagueRookie: {
-		// Rookie league has no BURN rewards (FR-038)
-	},
-	constants.LeagueStreet: {
-		1: decimal.NewFromInt(50), // 1st place: 50 BURN
-		2: decimal.NewFromInt(30), // 2nd place: 30 BURN
-		3: decimal.NewFromInt(20), // 3rd place: 20 BURN
-		4: decimal.NewFromInt(10), // 4th place: 10 BURN
-		5: decimal.NewFromInt(5),  // 5th place: 5 BURN
-	},
-	constants.LeaguePro: {
-		1: decimal.NewFromInt(300), // 1st place: 300 BURN
-		2: decimal.NewFromInt(200), // 2nd place: 200 BURN
-		3: decimal.NewFromInt(150), // 3rd place: 150 BURN
-		4: decimal.NewFromInt(100), // 4th place: 100 BURN
-		5: decimal.NewFromInt(75),  // 5th place: 75 BURN
-		6: decimal.NewFromInt(50),  // 6th place: 50 BURN
-		7: decimal.NewFromInt(25),  // 7th place: 25 BURN
-	},
-	constants.LeagueTopFuel: {
-		1:  decimal.NewFromInt(3000), // 1st place: 3000 BURN
-		2:  decimal.NewFromInt(2000), // 2nd place: 2000 BURN
-		3:  decimal.NewFromInt(1500), // 3rd place: 1500 BURN
-		4:  decimal.NewFromInt(1000), // 4th place: 1000 BURN
-		5:  decimal.NewFromInt(750),  // 5th place: 750 BURN
-		6:  decimal.NewFromInt(500),  // 6th place: 500 BURN
-		7:  decimal.NewFromInt(400),  // 7th place: 400 BURN
-		8:  decimal.NewFromInt(300),  // 8th place: 300 BURN
-		9:  decimal.NewFromInt(200),  // 9th place: 200 BURN
-		10: decimal.NewFromInt(100),  // 10th place: 100 BURN
-	},
-}
-
 // settlementService implements SettlementService
 type settlementService struct {
 	matchRepo       repository.MatchRepository
 	participantRepo repository.MatchParticipantRepository
 	settlementRepo  repository.MatchSettlementRepository
+	ledgerRepo      repository.LedgerRepository
 	ledgerOps       account.LedgerOperations
 	stateManager    MatchStateManager
 	publisher       gateway.CentrifugoPublisher
+	tiebreak        *TiebreakPolicy
+	cooldowns       MatchCooldownStarter
+	walletRepo      repository.WalletRepository
+	ghostReplayRepo repository.GhostReplayRepository
+	userRepo        repository.UserRepository
+	burnRewards     BurnRewardTables
+	metrics         *metrics.Metrics
+	timeout         time.Duration
 	logger          *logrus.Logger
 }
 
+// MatchCooldownStarter starts the post-match cooldown of a settled match's live players
+type MatchCooldownStarter interface {
+	StartCooldown(ctx context.Context, userIDs []uuid.UUID, league string) error
+}
+
+// SettlementOption configures optional settlement service behaviour
+type SettlementOption func(*settlementService)
+
+// WithSettlementTiebreakPolicy sets the tiebreak policy used to rank final positions
+func WithSettlementTiebreakPolicy(policy *TiebreakPolicy) SettlementOption {
+	return func(s *settlementService) {
+		s.tiebreak = policy
+	}
+}
+
+// WithSettlementCooldowns starts live players' post-match cooldowns once their match settles
+func WithSettlementCooldowns(cooldowns MatchCooldownStarter) SettlementOption {
+	return func(s *settlementService) {
+		s.cooldowns = cooldowns
+	}
+}
+
+// WithSettlementWallets reads a live player's wallet for their balance_updated event when the
+// settlement's ledger updates did not return it
+func WithSettlementWallets(walletRepo repository.WalletRepository) SettlementOption {
+	return func(s *settlementService) {
+		s.walletRepo = walletRepo
+	}
+}
+
+// WithBurnRewardTables sets the BURN tables settlements pay out with, e.g. ones loaded by
+// LoadBurnRewardTables. Without it the compiled-in defaults apply.
+func WithBurnRewardTables(tables BurnRewardTables) SettlementOption {
+	return func(s *settlementService) {
+		if tables != nil {
+			s.burnRewards = tables
+		}
+	}
+}
+
+// WithSettlementMetrics records how long each settled match ran, from its start to its settlement
+func WithSettlementMetrics(m *metrics.Metrics) SettlementOption {
+	return func(s *settlementService) {
+		s.metrics = m
+	}
+}
+
+// WithSettlementTimeout bounds the event publishing of settlements started without a deadline, as
+// background triggers do, so a stalled publisher cannot hold one forever. The ledger and status writes
+// are never cut off, since a settlement stopped half way would leave prizes paid on an unfinished match.
+// Non-positive values leave publishing unbounded.
+func WithSettlementTimeout(timeout time.Duration) SettlementOption {
+	return func(s *settlementService) {
+		s.timeout = timeout
+	}
+}
+
 // NewSettlementService creates a new settlement service
 func NewSettlementService(
 	matchRepo repository.MatchRepository,
 	participantRepo repository.MatchParticipantRepository,
 	settlementRepo repository.MatchSettlementRepository,
+	ledgerRepo repository.LedgerRepository,
 	ledgerOps account.LedgerOperations,
 	stateManager MatchStateManager,
 	publisher gateway.CentrifugoPublisher,
 	logger *logrus.Logger,
+	opts ...SettlementOption,
 ) SettlementService {
-	return &settlementService{
+	s := &settlementService{
 		matchRepo:       matchRepo,
 		participantRepo: participantRepo,
 		settlementRepo:  settlementRepo,
+		ledgerRepo:      ledgerRepo,
 		ledgerOps:       ledgerOps,
 		stateManager:    stateManager,
 		publisher:       publisher,
+		burnRewards:     DefaultBurnRewardTables(),
 		logger:          logger,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // SettleMatch calculates final positions, distributes prizes, and applies ledger entries
 func (s *settlementService) SettleMatch(ctx context.Context, matchID uuid.UUID) (*MatchSettlement, error) {
+	// Settlement reads the scores and statuses the match has just written
+	ctx = repository.WithPrimaryReads(ctx)
+
 	// Get match information
 	match, err := s.matchRepo.GetByID(ctx, matchID)
 	if err != nil {
@@ -146,18 +209,19 @@ func (s *settlementService) SettleMatch(ctx context.Context, matchID uuid.UUID)
 		return nil, fmt.Errorf("match not found: %s", matchID)
 	}
 
-	// Check if already settled
-	// This would require implementing MatchSettlementRepository first
-	// For now, we'll skip this check
+	// Settlement pays out once; ApplySettlement checks the status again under the match's row lock,
+	// so a concurrent settlement or abort that got there first still stops this one
+	if match.Status == models.MatchStatusCompleted || match.Status == models.MatchStatusAborted {
+		return nil, fmt.Errorf("%w: match is %s", ErrMatchAlreadySettled, match.Status)
+	}
 
-	// Calculate final positions
-	positions, err := s.CalculatePositions(ctx, matchID)
+	// Calculate final positions and prizes from the match loaded above instead of refetching it
+	positions, err := s.calculatePositions(ctx, matchID, s.tiebreak.LockTimeBreaksTies(string(match.League)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate positions: %w", err)
 	}
 
-	// Calculate prizes
-	prizeDistribution, err := s.CalculatePrizes(ctx, matchID, positions)
+	prizeDistribution, err := s.CalculatePrizes(ctx, match, positions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate prizes: %w", err)
 	}
@@ -179,28 +243,31 @@ func (s *settlementService) SettleMatch(ctx context.Context, matchID uuid.UUID)
 		Positions:         positions,
 		PrizePool:         match.PrizePool,
 		RakeAmount:        match.RakeAmount,
+		RakePercentage:    match.RakePercentage,
 		PrizeDistribution: prizeDistribution,
+		CrashSeed:         match.CrashSeed,
+		CrashSeedHash:     match.CrashSeedHash,
 	}
 
-	// Apply settlement to ledger
+	// Apply settlement to ledger, completing the match in the same transaction
 	err = s.ApplySettlement(ctx, matchID, settlement)
 	if err != nil {
 		return nil, fmt.Errorf("failed to apply settlement: %w", err)
 	}
 
-	// Update match status to completed
-	err = s.matchRepo.UpdateStatus(ctx, matchID, string(models.MatchStatusCompleted))
-	if err != nil {
-		return nil, fmt.Errorf("failed to update match status: %w", err)
-	}
+	// Record which BURN table the settlement paid out with
+	s.recordSettlement(ctx, settlement)
 
-	err = s.matchRepo.SetCompletionTime(ctx, matchID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to set completion time: %w", err)
+	// Only publishing is bounded by the settlement timeout; a caller's own deadline is kept
+	publishCtx := ctx
+	if _, ok := ctx.Deadline(); !ok && s.timeout > 0 {
+		var cancel context.CancelFunc
+		publishCtx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
 	}
 
 	// Publish match settled event (T062)
-	err = s.publishMatchSettledEvent(ctx, settlement)
+	err = s.publishMatchSettledEvent(publishCtx, settlement)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"match_id": matchID,
@@ -209,8 +276,16 @@ func (s *settlementService) SettleMatch(ctx context.Context, matchID uuid.UUID)
 		// Continue anyway - settlement is complete
 	}
 
+	recordMatchDuration(s.metrics, match, settlement.SettledAt)
+
+	// Keep the live runs as ghost replays for future matches
+	s.recordReplays(ctx, settlement)
+
+	// Keep live players out of the queue for the post-match cooldown
+	s.startCooldowns(ctx, settlement)
+
 	// Publish balance updated events to all live players (T063)
-	err = s.publishBalanceUpdatedEvents(ctx, settlement)
+	err = s.publishBalanceUpdatedEvents(publishCtx, settlement)
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"match_id": matchID,
@@ -230,12 +305,78 @@ func (s *settlementService) SettleMatch(ctx context.Context, matchID uuid.UUID)
 	return settlement, nil
 }
 
+// recordSettlement stores a settlement's record with the BURN table it applied.
+// Failures are logged, as the ledger entries have already been applied.
+func (s *settlementService) recordSettlement(ctx context.Context, settlement *MatchSettlement) {
+	if s.settlementRepo == nil {
+		return
+	}
+
+	record := &models.MatchSettlement{
+		MatchID:   settlement.MatchID,
+		SettledAt: settlement.SettledAt,
+	}
+	err := record.SetBurnRewardTable(settlement.PrizeDistribution.BurnRewards)
+	if err == nil {
+		err = s.settlementRepo.Create(ctx, record)
+	}
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"match_id": settlement.MatchID,
+			"error":    err,
+		}).Error("Failed to record match settlement")
+	}
+}
+
+// recordMatchDuration records how long a match ran until it ended.
+// Matches that never started have no duration and are skipped.
+func recordMatchDuration(m *metrics.Metrics, match *models.Match, endedAt time.Time) {
+	if m == nil || match.StartedAt == nil {
+		return
+	}
+	m.RecordMatchDuration(string(match.League), endedAt.Sub(*match.StartedAt))
+}
+
+// startCooldowns starts the post-match cooldown of a settlement's live players.
+// Failures are logged, as a missed cooldown must not fail a completed settlement.
+func (s *settlementService) startCooldowns(ctx context.Context, settlement *MatchSettlement) {
+	if s.cooldowns == nil {
+		return
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(settlement.Positions))
+	for _, position := range settlement.Positions {
+		if position.UserID != nil && !position.IsGhost {
+			userIDs = append(userIDs, *position.UserID)
+		}
+	}
+
+	if err := s.cooldowns.StartCooldown(ctx, userIDs, settlement.League); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"match_id": settlement.MatchID,
+			"error":    err,
+		}).Error("Failed to start match cooldowns")
+	}
+}
+
 // CalculatePositions calculates final positions with tiebreaker logic
 func (s *settlementService) CalculatePositions(ctx context.Context, matchID uuid.UUID) ([]*PlayerPosition, error) {
-	// Get all participants
-	participants, err := s.participantRepo.GetByMatchID(ctx, matchID)
+	ctx = repository.WithPrimaryReads(ctx)
+
+	lockTimeTiebreak, err := s.usesLockTimeTiebreak(ctx, matchID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get participants: %w", err)
+		return nil, err
+	}
+
+	return s.calculatePositions(ctx, matchID, lockTimeTiebreak)
+}
+
+// calculatePositions ranks a match's participants, breaking full score ties by lock time if asked to
+func (s *settlementService) calculatePositions(ctx context.Context, matchID uuid.UUID, lockTimeTiebreak bool) ([]*PlayerPosition, error) {
+	// Standings come ranked by total, then Heat 3, Heat 2 and Heat 1 score
+	participants, err := s.participantRepo.GetStandings(ctx, matchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get standings: %w", err)
 	}
 
 	// Convert to PlayerPosition structs
@@ -263,6 +404,9 @@ func (s *settlementService) CalculatePositions(ctx context.Context, matchID uuid
 				}
 				return decimal.Zero
 			}(),
+			Heat1LockTime: p.Heat1LockTime,
+			Heat2LockTime: p.Heat2LockTime,
+			Heat3LockTime: p.Heat3LockTime,
 			TotalScore: func() decimal.Decimal {
 				if p.TotalScore != nil {
 					return *p.TotalScore
@@ -273,8 +417,10 @@ func (s *settlementService) CalculatePositions(ctx context.Context, matchID uuid
 		positions = append(positions, position)
 	}
 
-	// Sort positions using tiebreaker logic
-	s.sortPositionsWithTiebreaker(positions)
+	// Full score ties keep the standings order unless the league breaks them by lock time
+	if lockTimeTiebreak {
+		breakTiesByLockTime(positions)
+	}
 
 	// Assign final positions
 	for i, position := range positions {
@@ -284,11 +430,10 @@ func (s *settlementService) CalculatePositions(ctx context.Context, matchID uuid
 	return positions, nil
 }
 
-// CalculatePrizes calculates prize distribution based on positions
-func (s *settlementService) CalculatePrizes(ctx context.Context, matchID uuid.UUID, positions []*PlayerPosition) (*PrizeDistribution, error) {
-	match, err := s.matchRepo.GetByID(ctx, matchID)
-	if err != nil {
-		return nil, err
+// CalculatePrizes calculates prize distribution of an already loaded match based on positions
+func (s *settlementService) CalculatePrizes(ctx context.Context, match *models.Match, positions []*PlayerPosition) (*PrizeDistribution, error) {
+	if match == nil {
+		return nil, fmt.Errorf("match is required to calculate prizes")
 	}
 
 	prizePool := match.PrizePool
@@ -298,11 +443,8 @@ func (s *settlementService) CalculatePrizes(ctx context.Context, matchID uuid.UU
 	secondPlace := prizePool.Mul(decimal.NewFromFloat(0.3)).Truncate(2) // 30%
 	thirdPlace := prizePool.Mul(decimal.NewFromFloat(0.2)).Truncate(2)  // 20%
 
-	// Get BURN rewards for this league
-	burnRewards := burnRewardTables[string(match.League)]
-	if burnRewards == nil {
-		burnRewards = make(map[int]decimal.Decimal)
-	}
+	// Get BURN rewards for this league from the loaded tables
+	burnRewards := s.burnRewards.For(string(match.League))
 
 	return &PrizeDistribution{
 		TotalPrizePool: prizePool,
@@ -313,7 +455,7 @@ func (s *settlementService) CalculatePrizes(ctx context.Context, matchID uuid.UU
 	}, nil
 }
 
-// ApplySettlement applies all ledger entries for the settlement
+// ApplySettlement applies all ledger entries for the settlement and completes the match
 func (s *settlementService) ApplySettlement(ctx context.Context, matchID uuid.UUID, settlement *MatchSettlement) error {
 	var ledgerEntries []*models.LedgerEntry
 
@@ -368,7 +510,7 @@ func (s *settlementService) ApplySettlement(ctx context.Context, matchID uuid.UU
 			OperationType: constants.OperationMatchRake,
 			ReferenceID:   &matchID,
 			Description: func() *string {
-				desc := fmt.Sprintf("8%% rake from %s league match", settlement.League)
+				desc := fmt.Sprintf("%s%% rake from %s league match", settlement.RakePercentage.String(), settlement.League)
 				return &desc
 			}(),
 			CreatedAt: settlement.SettledAt,
@@ -402,13 +544,23 @@ func (s *settlementService) ApplySettlement(ctx context.Context, matchID uuid.UU
 		}
 	}
 
-	// Apply all ledger entries atomically
-	err := s.ledgerOps.RecordMatchEntries(ctx, ledgerEntries)
+	// Apply all ledger entries and complete the match atomically, keeping the balances they left
+	// for balance_updated events. Only a match still forming or in progress is paid out.
+	completion := repository.MatchStatusChange{
+		MatchID: matchID,
+		From:    []models.MatchStatus{models.MatchStatusForming, models.MatchStatusInProgress},
+		To:      models.MatchStatusCompleted,
+	}
+	wallets, err := s.ledgerOps.RecordMatchEntriesWithStatus(ctx, completion, ledgerEntries)
+	if errors.Is(err, repository.ErrMatchStatusChanged) {
+		return fmt.Errorf("%w: %w", ErrMatchAlreadySettled, err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to record settlement ledger entries: %w", err)
 	}
 
 	settlement.LedgerEntries = ledgerEntries
+	settlement.Wallets = wallets
 
 	s.logger.WithFields(logrus.Fields{
 		"match_id":    matchID,
@@ -420,51 +572,125 @@ func (s *settlementService) ApplySettlement(ctx context.Context, matchID uuid.UU
 	return nil
 }
 
-// sortPositionsWithTiebreaker sorts positions using the tiebreaker logic
-// Tiebreaker: Heat 3 score → Heat 2 score → Heat 1 score
-func (s *settlementService) sortPositionsWithTiebreaker(positions []*PlayerPosition) {
-	// Bubble sort with tiebreaker logic
-	for i := 0; i < len(positions)-1; i++ {
-		for j := i + 1; j < len(positions); j++ {
-			if s.shouldSwapPositions(positions[i], positions[j]) {
-				positions[i], positions[j] = positions[j], positions[i]
+// RefundMatch returns every buy-in of an unsettled match to whoever paid it and marks the match
+// aborted in the same transaction. Live players are credited directly; ghost buy-ins go back to
+// HOUSE_FUEL. Buy-ins are netted per payer, so a ghost buy-in already returned when a late player
+// took its slot is not refunded twice. Buy-ins that were already refunded are not refunded again,
+// and matches that were completed, aborted or paid out are refused with ErrMatchAlreadySettled, as
+// refunding their buy-ins on top of the prizes would create FUEL.
+func (s *settlementService) RefundMatch(ctx context.Context, matchID uuid.UUID) ([]*models.LedgerEntry, error) {
+	// Buy-ins written moments ago must not be missed by a lagging replica
+	ctx = repository.WithPrimaryReads(ctx)
+
+	matchEntries, err := s.ledgerRepo.GetMatchEntries(ctx, matchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get match ledger entries: %w", err)
+	}
+
+	description := "Buy-in refund for aborted match"
+	refunds := make([]*models.LedgerEntry, 0, len(matchEntries))
+	paid := make(map[string]*models.LedgerEntry, len(matchEntries))
+	refunded := false
+	for _, entry := range matchEntries {
+		if refunded {
+			break
+		}
+		switch entry.OperationType {
+		case constants.OperationMatchRefund:
+			s.logger.WithFields(logrus.Fields{
+				"match_id": matchID,
+			}).Warn("Match has already been refunded")
+			refunded = true
+		case constants.OperationMatchPrize, constants.OperationMatchBurnReward, constants.OperationMatchRake:
+			return nil, fmt.Errorf("%w: prizes have already been paid", ErrMatchAlreadySettled)
+		case constants.OperationMatchBuyin:
+			payer := buyinPayer(entry)
+			if refund, ok := paid[payer]; ok {
+				refund.Amount = refund.Amount.Sub(entry.Amount)
+				continue
+			}
+
+			refund := &models.LedgerEntry{
+				UserID:        entry.UserID,
+				SystemWallet:  entry.SystemWallet,
+				Currency:      entry.Currency,
+				Amount:        entry.Amount.Neg(),
+				OperationType: constants.OperationMatchRefund,
+				ReferenceID:   &matchID,
+				Description:   &description,
+				CreatedAt:     time.Now(),
 			}
+			paid[payer] = refund
+			refunds = append(refunds, refund)
 		}
 	}
-}
 
-// shouldSwapPositions determines if two positions should be swapped in sorting
-func (s *settlementService) shouldSwapPositions(p1, p2 *PlayerPosition) bool {
-	// First, compare total scores
-	if p1.TotalScore.GreaterThan(p2.TotalScore) {
-		return false // p1 is better
+	// Payers whose buy-ins net out to nothing, such as a replaced ghost, get no refund
+	owed := refunds[:0]
+	for _, refund := range refunds {
+		if refund.Amount.IsPositive() && !refunded {
+			owed = append(owed, refund)
+		}
 	}
-	if p1.TotalScore.LessThan(p2.TotalScore) {
-		return true // p2 is better
+	refunds = owed
+
+	// The refunds and the abort commit together under the match's row lock, so a settlement
+	// running at the same time either pays out first and this refund is refused, or finds the
+	// match aborted and pays nothing
+	abort := repository.MatchStatusChange{
+		MatchID: matchID,
+		From:    []models.MatchStatus{models.MatchStatusForming, models.MatchStatusInProgress},
+		To:      models.MatchStatusAborted,
+	}
+	_, err = s.ledgerOps.RecordMatchEntriesWithStatus(ctx, abort, refunds)
+	if errors.Is(err, repository.ErrMatchStatusChanged) {
+		return nil, fmt.Errorf("%w: %w", ErrMatchAlreadySettled, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record refund ledger entries: %w", err)
 	}
+	if len(refunds) == 0 {
+		return nil, nil
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"match_id":     matchID,
+		"refund_count": len(refunds),
+	}).Info("Match buy-ins refunded")
 
-	// Total scores are equal, use tiebreaker
-	// Heat 3 tiebreaker
-	if p1.Heat3Score.GreaterThan(p2.Heat3Score) {
-		return false // p1 is better
+	return refunds, nil
+}
+
+// buyinPayer identifies the wallet and currency a buy-in entry was paid from
+func buyinPayer(entry *models.LedgerEntry) string {
+	if entry.UserID != nil {
+		return entry.UserID.String() + ":" + string(entry.Currency)
 	}
-	if p1.Heat3Score.LessThan(p2.Heat3Score) {
-		return true // p2 is better
+	if entry.SystemWallet != nil {
+		return *entry.SystemWallet + ":" + string(entry.Currency)
+	}
+	return string(entry.Currency)
+}
+
+// usesLockTimeTiebreak reports whether the match's league breaks full score ties by lock time
+func (s *settlementService) usesLockTimeTiebreak(ctx context.Context, matchID uuid.UUID) (bool, error) {
+	if s.tiebreak == nil {
+		return false, nil
 	}
 
-	// Heat 2 tiebreaker
-	if p1.Heat2Score.GreaterThan(p2.Heat2Score) {
-		return false // p1 is better
+	match, err := s.matchRepo.GetByID(ctx, matchID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get match: %w", err)
 	}
-	if p1.Heat2Score.LessThan(p2.Heat2Score) {
-		return true // p2 is better
+	if match == nil {
+		return false, nil
 	}
 
-	// Heat 1 tiebreaker
-	return p1.Heat1Score.LessThan(p2.Heat1Score) // p2 is better if their Heat 1 score is higher
+	return s.tiebreak.LockTimeBreaksTies(string(match.League)), nil
 }
 
-// applyPrizesToPositions applies prize amounts and BURN rewards to positions
+// applyPrizesToPositions applies prize amounts and BURN rewards to positions; the BURN
+// rewards come from the league's loaded table carried by the prize distribution
 func (s *settlementService) applyPrizesToPositions(positions []*PlayerPosition, prizes *PrizeDistribution, league string) {
 	for _, position := range positions {
 		// Apply FUEL prizes (top 3 only)
@@ -533,8 +759,11 @@ func (s *settlementService) publishMatchSettledEvent(ctx context.Context, settle
 			Heat1Score:    position.Heat1Score,
 			Heat2Score:    position.Heat2Score,
 			Heat3Score:    position.Heat3Score,
-			PrizeAmount:   position.PrizeAmount,
-			BurnReward:    position.BurnReward,
+			Heat1LockTime: position.Heat1LockTime,
+			Heat2LockTime: position.Heat2LockTime,
+			Heat3LockTime: position.Heat3LockTime,
+			PrizeAmount:   monetary.NewMoney(position.PrizeAmount),
+			BurnReward:    monetary.NewMoney(position.BurnReward),
 		}
 		finalStandings = append(finalStandings, standing)
 	}
@@ -548,8 +777,8 @@ func (s *settlementService) publishMatchSettledEvent(ctx context.Context, settle
 				UserID:      position.UserID,
 				DisplayName: position.DisplayName,
 				IsGhost:     position.IsGhost,
-				PrizeAmount: position.PrizeAmount,
-				BurnReward:  position.BurnReward,
+				PrizeAmount: monetary.NewMoney(position.PrizeAmount),
+				BurnReward:  monetary.NewMoney(position.BurnReward),
 			}
 			prizeDistribution = append(prizeDistribution, entry)
 		}
@@ -562,8 +791,8 @@ func (s *settlementService) publishMatchSettledEvent(ctx context.Context, settle
 		CompletedAt:       settlement.SettledAt,
 		FinalStandings:    finalStandings,
 		PrizeDistribution: prizeDistribution,
-		CrashSeed:         "revealed_seed_data", // TODO: Get actual crash seed from match
-		CrashSeedHash:     "original_hash",      // TODO: Get actual hash from match
+		CrashSeed:         settlement.CrashSeed,
+		CrashSeedHash:     settlement.CrashSeedHash,
 	}
 
 	// Publish to match channel
@@ -584,38 +813,66 @@ func (s *settlementService) publishMatchSettledEvent(ctx context.Context, settle
 
 // publishBalanceUpdatedEvents publishes balance_updated events to all live players (T063)
 func (s *settlementService) publishBalanceUpdatedEvents(ctx context.Context, settlement *MatchSettlement) error {
+	// Only live players (not ghosts) whose balances changed are notified
+	recipients := make([]*PlayerPosition, 0, len(settlement.Positions))
+	userIDs := make([]uuid.UUID, 0, len(settlement.Positions))
 	for _, position := range settlement.Positions {
-		// Only publish to live players (not ghosts)
 		if position.UserID == nil || position.IsGhost {
 			continue
 		}
+		if position.PrizeAmount.IsZero() && position.BurnReward.IsZero() {
+			continue
+		}
+		recipients = append(recipients, position)
+		userIDs = append(userIDs, *position.UserID)
+	}
+
+	// Balances come from the same updates that applied the settlement
+	wallets, err := s.settledWallets(ctx, settlement, userIDs)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"match_id": settlement.MatchID,
+			"error":    err,
+		}).Error("Failed to get balances for balance updated events")
+		// Players whose wallets the ledger returned are still notified
+		wallets = settlement.Wallets
+	}
+
+	for _, position := range recipients {
+		// Past the settlement deadline the remaining players refetch their balances instead
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("failed to publish balance updated events: %w", err)
+		}
 
 		// Calculate balance changes
 		changes := events.BalanceChanges{
-			TONDelta:  decimal.Zero,         // No TON changes from matches
-			FuelDelta: position.PrizeAmount, // FUEL prize (could be zero)
-			BurnDelta: position.BurnReward,  // BURN reward (could be zero)
+			TONDelta:  monetary.NewMoney(decimal.Zero),         // No TON changes from matches
+			FuelDelta: monetary.NewMoney(position.PrizeAmount), // FUEL prize (could be zero)
+			BurnDelta: monetary.NewMoney(position.BurnReward),  // BURN reward (could be zero)
 		}
 
-		// Skip if no changes
-		if changes.FuelDelta.IsZero() && changes.BurnDelta.IsZero() {
+		balances := wallets[*position.UserID]
+		if balances == nil {
+			s.logger.WithFields(logrus.Fields{
+				"match_id": settlement.MatchID,
+				"user_id":  *position.UserID,
+			}).Error("No settled balances for balance updated event")
+			// Clients refetch rather than trust a made-up balance
 			continue
 		}
 
-		// Get updated balances (would need to query wallet)
-		// For now, we'll use placeholder values
 		balanceUpdatedEvent := &events.BalanceUpdatedEvent{
 			UserID:      *position.UserID,
-			TONBalance:  decimal.Zero, // TODO: Get actual balance
-			FuelBalance: decimal.Zero, // TODO: Get actual balance
-			BurnBalance: decimal.Zero, // TODO: Get actual balance
+			TONBalance:  monetary.NewMoney(balances.TonBalance),
+			FuelBalance: monetary.NewMoney(balances.FuelBalance),
+			BurnBalance: monetary.NewMoney(balances.BurnBalance),
 			Changes:     changes,
 			Reason:      "match_settlement",
 			ReferenceID: &settlement.MatchID,
 		}
 
 		// Publish to user's personal channel
-		err := s.publisher.PublishToUser(ctx, *position.UserID, events.EventBalanceUpdated, balanceUpdatedEvent)
+		err = s.publisher.PublishToUser(ctx, *position.UserID, events.EventBalanceUpdated, balanceUpdatedEvent)
 		if err != nil {
 			s.logger.WithFields(logrus.Fields{
 				"match_id": settlement.MatchID,
@@ -633,3 +890,29 @@ func (s *settlementService) publishBalanceUpdatedEvents(ctx context.Context, set
 
 	return nil
 }
+
+// settledWallets returns the given live players' wallets after settlement. Wallets the ledger updates
+// did not return are read in a single query; players without a wallet are left out.
+func (s *settlementService) settledWallets(ctx context.Context, settlement *MatchSettlement, userIDs []uuid.UUID) (map[uuid.UUID]*models.Wallet, error) {
+	wallets := make(map[uuid.UUID]*models.Wallet, len(userIDs))
+	missing := make([]uuid.UUID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if wallet := settlement.Wallets[userID]; wallet != nil {
+			wallets[userID] = wallet
+		} else {
+			missing = append(missing, userID)
+		}
+	}
+	if len(missing) == 0 || s.walletRepo == nil {
+		return wallets, nil
+	}
+
+	read, err := s.walletRepo.GetByUserIDs(ctx, missing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+	for userID, wallet := range read {
+		wallets[userID] = wallet
+	}
+	return wallets, nil
+}
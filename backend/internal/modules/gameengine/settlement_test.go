@@ -0,0 +1,505 @@
+package gameengine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/metrics"
+	"github.com/megaherz/ndr/internal/modules/account"
+	"github.com/megaherz/ndr/internal/modules/gateway"
+	"github.com/megaherz/ndr/internal/modules/gateway/events"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// recordingLedgerOperations keeps the settlement entries instead of writing them;
+// covered entries fail with coverErr, recording nothing, when it is set. Like the match row
+// lock the database takes, status changes of one match are applied one at a time and refused
+// once the match has left the statuses they expect.
+type recordingLedgerOperations struct {
+	account.LedgerOperations
+	mu       sync.Mutex
+	entries  []*models.LedgerEntry
+	wallets  map[uuid.UUID]*models.Wallet
+	statuses map[uuid.UUID]models.MatchStatus
+	coverErr error
+}
+
+func (l *recordingLedgerOperations) RecordCoveredMatchEntries(ctx context.Context, entries []*models.LedgerEntry) error {
+	if l.coverErr != nil {
+		return l.coverErr
+	}
+	l.entries = append(l.entries, entries...)
+	return nil
+}
+
+func (l *recordingLedgerOperations) RecordMatchEntries(ctx context.Context, entries []*models.LedgerEntry) error {
+	l.entries = append(l.entries, entries...)
+	return nil
+}
+
+func (l *recordingLedgerOperations) RecordMatchEntriesWithStatus(ctx context.Context, change repository.MatchStatusChange, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if status, ok := l.statuses[change.MatchID]; ok && !slices.Contains(change.From, status) {
+		return nil, fmt.Errorf("%w: match is %s", repository.ErrMatchStatusChanged, status)
+	}
+	if l.statuses == nil {
+		l.statuses = make(map[uuid.UUID]models.MatchStatus)
+	}
+	l.statuses[change.MatchID] = change.To
+
+	l.entries = append(l.entries, entries...)
+	if l.wallets == nil {
+		return map[uuid.UUID]*models.Wallet{}, nil
+	}
+	return l.wallets, nil
+}
+
+// stubWalletRepository serves fixed wallets and counts lookups, like database round trips
+type stubWalletRepository struct {
+	repository.WalletRepository
+	wallets map[uuid.UUID]*models.Wallet
+	lookups int
+}
+
+func (r *stubWalletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Wallet, error) {
+	r.lookups++
+	return r.wallets[userID], nil
+}
+
+func (r *stubWalletRepository) GetByUserIDs(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*models.Wallet, error) {
+	r.lookups++
+	wallets := make(map[uuid.UUID]*models.Wallet, len(userIDs))
+	for _, userID := range userIDs {
+		if wallet := r.wallets[userID]; wallet != nil {
+			wallets[userID] = wallet
+		}
+	}
+	return wallets, nil
+}
+
+func TestApplySettlement_RakeDescriptionUsesStoredRate(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	ledgerOps := &recordingLedgerOperations{}
+	settlement := NewSettlementService(nil, nil, nil, nil, ledgerOps, nil, nil, logger)
+
+	// A ROOKIE match created during a 5% promotion
+	matchID := uuid.New()
+	err := settlement.ApplySettlement(context.Background(), matchID, &MatchSettlement{
+		MatchID:        matchID,
+		League:         "ROOKIE",
+		SettledAt:      time.Now(),
+		PrizePool:      decimal.NewFromInt(95),
+		RakeAmount:     decimal.NewFromInt(5),
+		RakePercentage: decimal.RequireFromString("5.00"),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, ledgerOps.entries, 1)
+	rake := ledgerOps.entries[0]
+	assert.Equal(t, models.OperationMatchRake, rake.OperationType)
+	assert.Equal(t, "5.00", rake.Amount.StringFixed(2))
+	require.NotNil(t, rake.Description)
+	assert.Equal(t, "5% rake from ROOKIE league match", *rake.Description)
+}
+
+// countingMatchRepository counts match lookups, like database round trips
+type countingMatchRepository struct {
+	stubMatchRepository
+	lookups atomic.Int64
+}
+
+func (r *countingMatchRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Match, error) {
+	r.lookups.Add(1)
+	return r.stubMatchRepository.GetByID(ctx, id)
+}
+
+func (r *countingMatchRepository) UpdateStatus(ctx context.Context, matchID uuid.UUID, status string) error {
+	for _, match := range r.created {
+		if match.ID == matchID {
+			match.Status = models.MatchStatus(status)
+		}
+	}
+	return nil
+}
+
+func (r *countingMatchRepository) SetCompletionTime(ctx context.Context, matchID uuid.UUID) error {
+	return nil
+}
+
+// resultsParticipantRepository accepts the final results written at settlement
+type resultsParticipantRepository struct {
+	*stubParticipantRepository
+}
+
+func (r resultsParticipantRepository) SetFinalPosition(ctx context.Context, matchID, userID uuid.UUID, position int) error {
+	return nil
+}
+
+func (r resultsParticipantRepository) SetPrizeAmount(ctx context.Context, matchID, userID uuid.UUID, amount decimal.Decimal) error {
+	return nil
+}
+
+func (r resultsParticipantRepository) SetBurnReward(ctx context.Context, matchID, userID uuid.UUID, amount decimal.Decimal) error {
+	return nil
+}
+
+// newSettleableMatch returns a ROOKIE match ready for settlement with three scored live players
+func newSettleableMatch() (*countingMatchRepository, resultsParticipantRepository, uuid.UUID) {
+	match := &models.Match{
+		ID:         uuid.New(),
+		League:     models.LeagueRookie,
+		Status:     models.MatchStatusInProgress,
+		PrizePool:  decimal.NewFromInt(92),
+		RakeAmount: decimal.NewFromInt(8),
+	}
+	matchRepo := &countingMatchRepository{stubMatchRepository: stubMatchRepository{created: []*models.Match{match}}}
+
+	participants := &stubParticipantRepository{}
+	for i, total := range []int64{300, 200, 100} {
+		userID := uuid.New()
+		score := decimal.NewFromInt(total)
+		participants.created = append(participants.created, &models.MatchParticipant{
+			MatchID:           match.ID,
+			UserID:            &userID,
+			PlayerDisplayName: fmt.Sprintf("Racer %d", i),
+			Heat3Score:        &score,
+			TotalScore:        &score,
+		})
+	}
+	return matchRepo, resultsParticipantRepository{participants}, match.ID
+}
+
+func newTestSettlementService(matchRepo *countingMatchRepository, participantRepo resultsParticipantRepository) SettlementService {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	return NewSettlementService(matchRepo, participantRepo, nil, nil, &recordingLedgerOperations{}, nil, &recordingPublisher{}, logger)
+}
+
+func TestCalculatePrizes_UsesGivenMatch(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	// No match repository: the prizes come from the match passed in
+	settlement := NewSettlementService(nil, nil, nil, nil, nil, nil, nil, logger)
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, PrizePool: decimal.NewFromInt(92)}
+
+	prizes, err := settlement.CalculatePrizes(context.Background(), match, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "92.00", prizes.TotalPrizePool.StringFixed(2))
+	assert.Equal(t, "46.00", prizes.FirstPlace.StringFixed(2))
+	assert.Equal(t, "27.60", prizes.SecondPlace.StringFixed(2))
+	assert.Equal(t, "18.40", prizes.ThirdPlace.StringFixed(2))
+
+	_, err = settlement.CalculatePrizes(context.Background(), nil, nil)
+	assert.Error(t, err)
+}
+
+func TestSettleMatch_LoadsMatchOnce(t *testing.T) {
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	settlement := newTestSettlementService(matchRepo, participantRepo)
+
+	result, err := settlement.SettleMatch(context.Background(), matchID)
+	require.NoError(t, err)
+	require.Len(t, result.Positions, 3)
+	assert.Equal(t, "46.00", result.Positions[0].PrizeAmount.StringFixed(2))
+	assert.Equal(t, int64(1), matchRepo.lookups.Load())
+}
+
+// matchDurations returns the number and sum of the match durations recorded for a league
+func matchDurations(t *testing.T, reg *prometheus.Registry, league string) (uint64, float64) {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "match_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "league" && label.GetValue() == league {
+					return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+				}
+			}
+		}
+	}
+	return 0, 0
+}
+
+func TestSettleMatch_RecordsMatchDuration(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	startedAt := time.Now().Add(-4 * time.Minute)
+	matchRepo.created[0].StartedAt = &startedAt
+
+	reg := prometheus.NewRegistry()
+	settlement := NewSettlementService(matchRepo, participantRepo, nil, nil, &recordingLedgerOperations{}, nil,
+		&recordingPublisher{}, logger, WithSettlementMetrics(metrics.NewWithRegistry(reg)))
+
+	_, err := settlement.SettleMatch(context.Background(), matchID)
+	require.NoError(t, err)
+
+	count, seconds := matchDurations(t, reg, string(models.LeagueRookie))
+	assert.Equal(t, uint64(1), count)
+	assert.InDelta(t, 240, seconds, 5)
+}
+
+// BenchmarkSettleMatch reports the match lookups made per settlement
+func BenchmarkSettleMatch(b *testing.B) {
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	settlement := newTestSettlementService(matchRepo, participantRepo)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := settlement.SettleMatch(ctx, matchID); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(matchRepo.lookups.Load())/float64(b.N), "match_lookups/op")
+}
+
+func TestSettleMatch_BalanceUpdatedCarriesSettledBalances(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	winner := *participantRepo.created[0].UserID
+	second := *participantRepo.created[1].UserID
+	third := *participantRepo.created[2].UserID
+
+	// The ledger returns the winner's and second's updated wallets; third's is read back
+	ledgerOps := &recordingLedgerOperations{wallets: map[uuid.UUID]*models.Wallet{
+		winner: {UserID: winner, TonBalance: decimal.NewFromInt(2), FuelBalance: decimal.RequireFromString("146.00"), BurnBalance: decimal.NewFromInt(1000)},
+		second: {UserID: second, FuelBalance: decimal.RequireFromString("27.60"), BurnBalance: decimal.NewFromInt(800)},
+	}}
+	walletRepo := &stubWalletRepository{wallets: map[uuid.UUID]*models.Wallet{
+		third: {UserID: third, FuelBalance: decimal.RequireFromString("68.40"), BurnBalance: decimal.NewFromInt(650)},
+	}}
+	publisher := &recordingPublisher{}
+	settlement := NewSettlementService(matchRepo, participantRepo, nil, nil, ledgerOps, nil, publisher, logger, WithSettlementWallets(walletRepo))
+
+	_, err := settlement.SettleMatch(context.Background(), matchID)
+	require.NoError(t, err)
+
+	balances := make(map[uuid.UUID]*events.BalanceUpdatedEvent)
+	for _, event := range publisher.balances {
+		balances[event.UserID] = event
+	}
+	require.Len(t, balances, 3)
+
+	assert.Equal(t, "2.00", balances[winner].TONBalance.StringFixed(2))
+	assert.Equal(t, "146.00", balances[winner].FuelBalance.StringFixed(2))
+	assert.Equal(t, "1000.00", balances[winner].BurnBalance.StringFixed(2))
+	assert.Equal(t, "46.00", balances[winner].Changes.FuelDelta.StringFixed(2))
+	assert.Equal(t, "27.60", balances[second].FuelBalance.StringFixed(2))
+	assert.Equal(t, "68.40", balances[third].FuelBalance.StringFixed(2))
+	assert.Equal(t, "650.00", balances[third].BurnBalance.StringFixed(2))
+	assert.Equal(t, 1, walletRepo.lookups)
+}
+
+func TestSettleMatch_SkipsBalanceUpdatedWithoutWallet(t *testing.T) {
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	publisher := &recordingPublisher{}
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	settlement := NewSettlementService(matchRepo, participantRepo, nil, nil, &recordingLedgerOperations{}, nil, publisher, logger)
+
+	// Zero balances are never pushed in place of unknown ones
+	_, err := settlement.SettleMatch(context.Background(), matchID)
+	require.NoError(t, err)
+	assert.Empty(t, publisher.balances)
+}
+
+// stalledPublisher never delivers, as when Centrifugo stops responding
+type stalledPublisher struct {
+	gateway.CentrifugoPublisher
+}
+
+func (p stalledPublisher) PublishToMatch(ctx context.Context, matchID uuid.UUID, eventType string, data interface{}) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (p stalledPublisher) PublishToUser(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestSettleMatch_CompletesPromptlyWhenPublisherBlocks(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	walletRepo := &stubWalletRepository{wallets: map[uuid.UUID]*models.Wallet{}}
+	for _, participant := range participantRepo.created {
+		walletRepo.wallets[*participant.UserID] = &models.Wallet{UserID: *participant.UserID}
+	}
+
+	publisher := gateway.NewAsyncPublisher(stalledPublisher{}, logger, gateway.WithPublishTimeout(time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	publisher.Start(ctx)
+
+	settlement := NewSettlementService(matchRepo, participantRepo, nil, nil, &recordingLedgerOperations{}, nil, publisher, logger, WithSettlementWallets(walletRepo))
+
+	// Every match_settled and balance_updated event is stuck behind Centrifugo
+	start := time.Now()
+	_, err := settlement.SettleMatch(context.Background(), matchID)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestSettleMatch_TimeoutBoundsStalledPublisher(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	ledgerOps := &recordingLedgerOperations{}
+
+	// Publishing straight to a stalled Centrifugo holds every event until the context ends
+	timeout := 100 * time.Millisecond
+	settlement := NewSettlementService(matchRepo, participantRepo, nil, nil, ledgerOps, nil, stalledPublisher{}, logger, WithSettlementTimeout(timeout))
+
+	start := time.Now()
+	result, err := settlement.SettleMatch(context.Background(), matchID)
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, elapsed, timeout)
+	assert.Less(t, elapsed, time.Second)
+
+	// The settlement itself was recorded before the events gave up
+	require.NotNil(t, result)
+	assert.Len(t, result.Positions, 3)
+	assert.NotEmpty(t, ledgerOps.entries)
+	assert.Equal(t, models.MatchStatusCompleted, ledgerOps.statuses[matchID])
+}
+
+func TestSettleMatch_RefusesSettledMatch(t *testing.T) {
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	ledgerOps := &recordingLedgerOperations{}
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	settlement := NewSettlementService(matchRepo, participantRepo, nil, nil, ledgerOps, nil, &recordingPublisher{}, logger)
+
+	_, err := settlement.SettleMatch(context.Background(), matchID)
+	require.NoError(t, err)
+	paid := len(ledgerOps.entries)
+
+	// A retry of a completed match pays nothing again
+	_, err = settlement.SettleMatch(context.Background(), matchID)
+	assert.ErrorIs(t, err, ErrMatchAlreadySettled)
+	assert.Len(t, ledgerOps.entries, paid)
+}
+
+func TestSettleMatch_ConcurrentSettlementsPayOnce(t *testing.T) {
+	matchRepo, participantRepo, matchID := newSettleableMatch()
+	ledgerOps := &recordingLedgerOperations{}
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	settlement := NewSettlementService(matchRepo, participantRepo, nil, nil, ledgerOps, nil, &recordingPublisher{}, logger)
+
+	// Every attempt reads the match while it is still in progress
+	const attempts = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := settlement.SettleMatch(context.Background(), matchID)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	settled := 0
+	for err := range errs {
+		if err == nil {
+			settled++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrMatchAlreadySettled)
+	}
+	assert.Equal(t, 1, settled)
+
+	prizes := 0
+	for _, entry := range ledgerOps.entries {
+		if entry.OperationType == constants.OperationMatchPrize {
+			prizes++
+		}
+	}
+	assert.Equal(t, 3, prizes)
+	assert.Equal(t, models.MatchStatusCompleted, ledgerOps.statuses[matchID])
+}
+
+func TestSettleMatch_AndRefundMatch_OnlyOneWins(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	// Both sides read the match in progress and its buy-ins, before the other writes anything
+	newRace := func() (SettlementService, *recordingLedgerOperations, uuid.UUID) {
+		matchRepo, participantRepo, matchID := newSettleableMatch()
+		ledgerRepo := &matchEntriesLedgerRepository{}
+		for _, participant := range participantRepo.created {
+			ledgerRepo.entries = append(ledgerRepo.entries, &models.LedgerEntry{
+				UserID:        participant.UserID,
+				Currency:      constants.CurrencyFUEL,
+				Amount:        decimal.NewFromInt(-10),
+				OperationType: constants.OperationMatchBuyin,
+				ReferenceID:   &matchID,
+			})
+		}
+		ledgerOps := &recordingLedgerOperations{}
+		settlement := NewSettlementService(matchRepo, participantRepo, nil, ledgerRepo, ledgerOps, nil, &recordingPublisher{}, logger)
+		return settlement, ledgerOps, matchID
+	}
+
+	t.Run("settlement first", func(t *testing.T) {
+		settlement, ledgerOps, matchID := newRace()
+
+		_, err := settlement.SettleMatch(context.Background(), matchID)
+		require.NoError(t, err)
+		paid := len(ledgerOps.entries)
+
+		refunds, err := settlement.RefundMatch(context.Background(), matchID)
+		assert.ErrorIs(t, err, ErrMatchAlreadySettled)
+		assert.Empty(t, refunds)
+		assert.Len(t, ledgerOps.entries, paid)
+		assert.Equal(t, models.MatchStatusCompleted, ledgerOps.statuses[matchID])
+	})
+
+	t.Run("refund first", func(t *testing.T) {
+		settlement, ledgerOps, matchID := newRace()
+
+		refunds, err := settlement.RefundMatch(context.Background(), matchID)
+		require.NoError(t, err)
+		require.Len(t, refunds, 3)
+
+		_, err = settlement.SettleMatch(context.Background(), matchID)
+		assert.ErrorIs(t, err, ErrMatchAlreadySettled)
+		assert.Equal(t, refunds, ledgerOps.entries)
+		assert.Equal(t, models.MatchStatusAborted, ledgerOps.statuses[matchID])
+	})
+}
@@ -2,6 +2,7 @@ package gameengine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -9,6 +10,9 @@ import (
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/metrics"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
 )
 
 // MatchStatus represents the status of a match
@@ -32,6 +36,32 @@ const (
 	HeatStatusCompleted    HeatStatus = "COMPLETED"    // Heat finished
 )
 
+// ErrMatchStateNotFound is returned when a match has no in-memory state, either because it was
+// never created or because it was removed at the end of the match
+var ErrMatchStateNotFound = errors.New("match state not found")
+
+// ErrInvalidHeatTransition is returned when a heat cannot move from its current status to the requested one
+var ErrInvalidHeatTransition = errors.New("invalid heat status transition")
+
+// heatTransitions lists the statuses each heat status may move to
+var heatTransitions = map[HeatStatus][]HeatStatus{
+	HeatStatusWaiting:      {HeatStatusCountdown},
+	HeatStatusCountdown:    {HeatStatusActive},
+	HeatStatusActive:       {HeatStatusCompleted},
+	HeatStatusCompleted:    {HeatStatusIntermission},
+	HeatStatusIntermission: {HeatStatusCountdown},
+}
+
+// canTransitionHeat reports whether a heat may move from one status to another
+func canTransitionHeat(from, to HeatStatus) bool {
+	for _, next := range heatTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
 // MatchStateManager manages in-memory match states
 type MatchStateManager interface {
 	// CreateMatchState creates a new match state
@@ -46,11 +76,21 @@ type MatchStateManager interface {
 	// StartHeat starts a specific heat
 	StartHeat(ctx context.Context, matchID uuid.UUID, heat int) error
 
-	// EndHeat ends the current heat
+	// SetHeatStatus moves the current heat to a new status, rejecting transitions the heat
+	// lifecycle does not allow. The check and the update happen atomically.
+	SetHeatStatus(ctx context.Context, matchID uuid.UUID, status HeatStatus) error
+
+	// EndHeat ends the current heat. It returns ErrInvalidHeatTransition unless the heat is active.
 	EndHeat(ctx context.Context, matchID uuid.UUID) error
 
-	// LockPlayerScore locks a player's score for the current heat
-	LockPlayerScore(ctx context.Context, matchID, userID uuid.UUID, score decimal.Decimal) error
+	// LockPlayerScore locks a player's score for the current heat and returns the lock time
+	// in seconds since the heat went active
+	LockPlayerScore(ctx context.Context, matchID, userID uuid.UUID, score decimal.Decimal) (float64, error)
+
+	// CrashAbsentPlayers records a missed presence check for every live player still racing in
+	// the active heat who is not in present, crashes those who have now missed missedChecks checks
+	// in a row, and returns the IDs of the players it crashed
+	CrashAbsentPlayers(ctx context.Context, matchID uuid.UUID, present map[uuid.UUID]bool, missedChecks int) ([]uuid.UUID, error)
 
 	// GetActiveMatches returns all active match IDs
 	GetActiveMatches(ctx context.Context) []uuid.UUID
@@ -72,6 +112,9 @@ type InMemoryMatchState struct {
 	CreatedAt     time.Time                     `json:"created_at"`
 	UpdatedAt     time.Time                     `json:"updated_at"`
 
+	// LockTimeTiebreak ranks the earlier locker first when players tie on every score
+	LockTimeTiebreak bool `json:"lock_time_tiebreak"`
+
 	// Synchronization
 	mu sync.RWMutex `json:"-"`
 }
@@ -82,29 +125,85 @@ type InMemoryPlayer struct {
 	DisplayName   string           `json:"display_name"`
 	IsGhost       bool             `json:"is_ghost"`
 	GhostReplayID *uuid.UUID       `json:"ghost_replay_id,omitempty"`
+	ParticipantID int64            `json:"participant_id,omitempty"` // Participant row ghost scores are written to
 	Heat1Score    *decimal.Decimal `json:"heat1_score,omitempty"`
 	Heat2Score    *decimal.Decimal `json:"heat2_score,omitempty"`
 	Heat3Score    *decimal.Decimal `json:"heat3_score,omitempty"`
 	TotalScore    decimal.Decimal  `json:"total_score"`
 	Position      int              `json:"position"`
-	IsAlive       bool             `json:"is_alive"`            // False if crashed in current heat
-	HasLocked     bool             `json:"has_locked"`          // True if locked score in current heat
-	LockTime      *time.Time       `json:"lock_time,omitempty"` // When they locked
+	IsAlive       bool             `json:"is_alive"`                  // False if crashed in current heat
+	HasLocked     bool             `json:"has_locked"`                // True if locked score in current heat
+	LockTime      *time.Time       `json:"lock_time,omitempty"`       // When they locked
+	Heat1LockTime *float64         `json:"heat1_lock_time,omitempty"` // Seconds into Heat 1 when they locked
+	Heat2LockTime *float64         `json:"heat2_lock_time,omitempty"`
+	Heat3LockTime *float64         `json:"heat3_lock_time,omitempty"`
+	MissedChecks  int              `json:"missed_checks,omitempty"` // Consecutive presence checks missed in current heat
+}
+
+// heatLockTimes returns the player's lock times indexed by heat - 1
+func (p *InMemoryPlayer) heatLockTimes() [3]*float64 {
+	return [3]*float64{p.Heat1LockTime, p.Heat2LockTime, p.Heat3LockTime}
 }
 
 // matchStateManager implements MatchStateManager
 type matchStateManager struct {
-	states map[uuid.UUID]*InMemoryMatchState
-	mu     sync.RWMutex
-	logger *logrus.Logger
+	states   map[uuid.UUID]*InMemoryMatchState
+	mu       sync.RWMutex
+	tiebreak *TiebreakPolicy
+	events   MatchEventRecorder
+	metrics  *metrics.Metrics
+	logger   *logrus.Logger
+
+	// countdownDuration is the countdown before a heat goes active; lock times are measured from its end
+	countdownDuration time.Duration
+}
+
+// MatchStateOption configures optional match state manager behaviour
+type MatchStateOption func(*matchStateManager)
+
+// WithStateTiebreakPolicy sets the tiebreak policy used to rank players in new matches
+func WithStateTiebreakPolicy(policy *TiebreakPolicy) MatchStateOption {
+	return func(m *matchStateManager) {
+		m.tiebreak = policy
+	}
+}
+
+// WithMatchEventRecorder records heat starts and ends, locks and crashes to the match timeline
+func WithMatchEventRecorder(recorder MatchEventRecorder) MatchStateOption {
+	return func(m *matchStateManager) {
+		m.events = recorder
+	}
+}
+
+// WithStateMetrics reports the number of matches in progress as the active matches gauge,
+// updated whenever a match starts, completes or is removed
+func WithStateMetrics(m *metrics.Metrics) MatchStateOption {
+	return func(sm *matchStateManager) {
+		sm.metrics = m
+	}
+}
+
+// WithStateCountdownDuration sets the heat countdown lock times are measured from, which must
+// match the heat manager's; non-positive values keep the default
+func WithStateCountdownDuration(duration time.Duration) MatchStateOption {
+	return func(m *matchStateManager) {
+		if duration > 0 {
+			m.countdownDuration = duration
+		}
+	}
 }
 
 // NewMatchStateManager creates a new match state manager
-func NewMatchStateManager(logger *logrus.Logger) MatchStateManager {
-	return &matchStateManager{
-		states: make(map[uuid.UUID]*InMemoryMatchState),
-		logger: logger,
+func NewMatchStateManager(logger *logrus.Logger, opts ...MatchStateOption) MatchStateManager {
+	m := &matchStateManager{
+		states:            make(map[uuid.UUID]*InMemoryMatchState),
+		logger:            logger,
+		countdownDuration: DefaultCountdownDuration,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // CreateMatchState creates a new match state
@@ -133,6 +232,7 @@ func (m *matchStateManager) CreateMatchState(ctx context.Context, matchID uuid.U
 			DisplayName:   player.DisplayName,
 			IsGhost:       player.IsGhost,
 			GhostReplayID: player.GhostReplayID,
+			ParticipantID: player.ParticipantID,
 			Heat1Score:    nil,
 			Heat2Score:    nil,
 			Heat3Score:    nil,
@@ -157,6 +257,8 @@ func (m *matchStateManager) CreateMatchState(ctx context.Context, matchID uuid.U
 		Players:       playerStates,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
+
+		LockTimeTiebreak: m.tiebreak.LockTimeBreaksTies(league),
 	}
 
 	m.states[matchID] = matchState
@@ -177,7 +279,7 @@ func (m *matchStateManager) GetMatchState(ctx context.Context, matchID uuid.UUID
 
 	state, exists := m.states[matchID]
 	if !exists {
-		return nil, fmt.Errorf("match state not found for match %s", matchID)
+		return nil, fmt.Errorf("%w for match %s", ErrMatchStateNotFound, matchID)
 	}
 
 	// Return a copy to prevent external modifications
@@ -192,6 +294,8 @@ func (m *matchStateManager) GetMatchState(ctx context.Context, matchID uuid.UUID
 		CreatedAt:     state.CreatedAt,
 		UpdatedAt:     state.UpdatedAt,
 		Players:       make(map[uuid.UUID]*InMemoryPlayer),
+
+		LockTimeTiebreak: state.LockTimeTiebreak,
 	}
 	for id, player := range state.Players {
 		playerCopy := *player
@@ -208,7 +312,7 @@ func (m *matchStateManager) UpdateMatchStatus(ctx context.Context, matchID uuid.
 
 	state, exists := m.states[matchID]
 	if !exists {
-		return fmt.Errorf("match state not found for match %s", matchID)
+		return fmt.Errorf("%w for match %s", ErrMatchStateNotFound, matchID)
 	}
 
 	state.mu.Lock()
@@ -217,6 +321,7 @@ func (m *matchStateManager) UpdateMatchStatus(ctx context.Context, matchID uuid.
 	oldStatus := state.Status
 	state.Status = status
 	state.UpdatedAt = time.Now()
+	m.reportActiveMatches()
 
 	m.logger.WithFields(logrus.Fields{
 		"match_id":   matchID,
@@ -238,7 +343,7 @@ func (m *matchStateManager) StartHeat(ctx context.Context, matchID uuid.UUID, he
 
 	state, exists := m.states[matchID]
 	if !exists {
-		return fmt.Errorf("match state not found for match %s", matchID)
+		return fmt.Errorf("%w for match %s", ErrMatchStateNotFound, matchID)
 	}
 
 	state.mu.Lock()
@@ -256,9 +361,12 @@ func (m *matchStateManager) StartHeat(ctx context.Context, matchID uuid.UUID, he
 	for _, player := range state.Players {
 		player.IsAlive = true
 		player.HasLocked = false
+		player.MissedChecks = 0
 		player.LockTime = nil
 	}
 
+	m.recordEvent(newMatchEvent(state, models.MatchEventHeatStarted, nil, now))
+
 	m.logger.WithFields(logrus.Fields{
 		"match_id": matchID,
 		"heat":     heat,
@@ -267,6 +375,37 @@ func (m *matchStateManager) StartHeat(ctx context.Context, matchID uuid.UUID, he
 	return nil
 }
 
+// SetHeatStatus moves the current heat to a new status if the heat lifecycle allows it
+func (m *matchStateManager) SetHeatStatus(ctx context.Context, matchID uuid.UUID, status HeatStatus) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, exists := m.states[matchID]
+	if !exists {
+		return fmt.Errorf("%w for match %s", ErrMatchStateNotFound, matchID)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	oldStatus := state.HeatStatus
+	if !canTransitionHeat(oldStatus, status) {
+		return fmt.Errorf("%w: heat %d is %s, cannot become %s", ErrInvalidHeatTransition, state.CurrentHeat, oldStatus, status)
+	}
+
+	state.HeatStatus = status
+	state.UpdatedAt = time.Now()
+
+	m.logger.WithFields(logrus.Fields{
+		"match_id":   matchID,
+		"heat":       state.CurrentHeat,
+		"old_status": oldStatus,
+		"new_status": status,
+	}).Info("Heat status updated")
+
+	return nil
+}
+
 // EndHeat ends the current heat
 func (m *matchStateManager) EndHeat(ctx context.Context, matchID uuid.UUID) error {
 	m.mu.Lock()
@@ -274,12 +413,18 @@ func (m *matchStateManager) EndHeat(ctx context.Context, matchID uuid.UUID) erro
 
 	state, exists := m.states[matchID]
 	if !exists {
-		return fmt.Errorf("match state not found for match %s", matchID)
+		return fmt.Errorf("%w for match %s", ErrMatchStateNotFound, matchID)
 	}
 
 	state.mu.Lock()
 	defer state.mu.Unlock()
 
+	// Only an active heat can end, so a late end timer or a repeated early end is rejected
+	// instead of recording and publishing the heat's end twice
+	if !canTransitionHeat(state.HeatStatus, HeatStatusCompleted) {
+		return fmt.Errorf("%w: heat %d is %s, cannot become %s", ErrInvalidHeatTransition, state.CurrentHeat, state.HeatStatus, HeatStatusCompleted)
+	}
+
 	// Update heat state
 	state.HeatStatus = HeatStatusCompleted
 	now := time.Now()
@@ -289,6 +434,18 @@ func (m *matchStateManager) EndHeat(ctx context.Context, matchID uuid.UUID) erro
 	// Calculate positions for this heat
 	m.calculateHeatPositions(state)
 
+	// Players who never locked crashed out of the heat; forfeited players were recorded when they crashed
+	for _, player := range state.Players {
+		if player.HasLocked || !player.IsAlive {
+			continue
+		}
+		crash := newMatchEvent(state, models.MatchEventPlayerCrashed, player, now)
+		heatTime := m.heatLockSeconds(state.HeatStartTime, now)
+		crash.HeatTime = &heatTime
+		m.recordEvent(crash)
+	}
+	m.recordEvent(newMatchEvent(state, models.MatchEventHeatEnded, nil, now))
+
 	m.logger.WithFields(logrus.Fields{
 		"match_id": matchID,
 		"heat":     state.CurrentHeat,
@@ -298,6 +455,7 @@ func (m *matchStateManager) EndHeat(ctx context.Context, matchID uuid.UUID) erro
 	if state.CurrentHeat == 3 {
 		state.Status = MatchStatusCompleted
 		m.calculateFinalPositions(state)
+		m.reportActiveMatches()
 	} else {
 		// Transition to intermission
 		state.HeatStatus = HeatStatusIntermission
@@ -306,19 +464,25 @@ func (m *matchStateManager) EndHeat(ctx context.Context, matchID uuid.UUID) erro
 	return nil
 }
 
-// LockPlayerScore locks a player's score for the current heat
-func (m *matchStateManager) LockPlayerScore(ctx context.Context, matchID, userID uuid.UUID, score decimal.Decimal) error {
+// LockPlayerScore locks a player's score for the current heat and returns the lock time
+// in seconds since the heat went active
+func (m *matchStateManager) LockPlayerScore(ctx context.Context, matchID, userID uuid.UUID, score decimal.Decimal) (float64, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	state, exists := m.states[matchID]
 	if !exists {
-		return fmt.Errorf("match state not found for match %s", matchID)
+		return 0, fmt.Errorf("%w for match %s", ErrMatchStateNotFound, matchID)
 	}
 
 	state.mu.Lock()
 	defer state.mu.Unlock()
 
+	// Locks are only taken while the heat is active, not before it, in its countdown or after it ended
+	if state.HeatStatus != HeatStatusActive {
+		return 0, fmt.Errorf("%w: heat %d is %s", ErrHeatNotActive, state.CurrentHeat, state.HeatStatus)
+	}
+
 	// Find player
 	var player *InMemoryPlayer
 	for id, p := range state.Players {
@@ -332,30 +496,34 @@ func (m *matchStateManager) LockPlayerScore(ctx context.Context, matchID, userID
 	}
 
 	if player == nil {
-		return fmt.Errorf("player not found in match: %s", userID)
+		return 0, fmt.Errorf("player not found in match: %s", userID)
 	}
 
 	if player.HasLocked {
-		return fmt.Errorf("player has already locked score for this heat")
+		return 0, fmt.Errorf("%w: player %s, heat %d", ErrAlreadyLocked, userID, state.CurrentHeat)
 	}
 
-	if state.HeatStatus != HeatStatusActive {
-		return fmt.Errorf("heat is not active")
+	if !player.IsAlive {
+		return 0, fmt.Errorf("%w: player %s, heat %d", ErrPlayerCrashed, userID, state.CurrentHeat)
 	}
 
 	// Lock the score
 	now := time.Now()
 	player.HasLocked = true
 	player.LockTime = &now
+	lockTime := m.heatLockSeconds(state.HeatStartTime, now)
 
-	// Set score for current heat
+	// Set score and lock time for current heat
 	switch state.CurrentHeat {
 	case 1:
 		player.Heat1Score = &score
+		player.Heat1LockTime = &lockTime
 	case 2:
 		player.Heat2Score = &score
+		player.Heat2LockTime = &lockTime
 	case 3:
 		player.Heat3Score = &score
+		player.Heat3LockTime = &lockTime
 	}
 
 	// Update total score
@@ -363,14 +531,106 @@ func (m *matchStateManager) LockPlayerScore(ctx context.Context, matchID, userID
 
 	state.UpdatedAt = now
 
+	locked := newMatchEvent(state, models.MatchEventScoreLocked, player, now)
+	locked.Score = &score
+	locked.HeatTime = &lockTime
+	m.recordEvent(locked)
+
 	m.logger.WithFields(logrus.Fields{
-		"match_id": matchID,
-		"user_id":  userID,
-		"heat":     state.CurrentHeat,
-		"score":    score,
+		"match_id":  matchID,
+		"user_id":   userID,
+		"heat":      state.CurrentHeat,
+		"score":     score,
+		"lock_time": lockTime,
 	}).Info("Player score locked")
 
-	return nil
+	return lockTime, nil
+}
+
+// CrashAbsentPlayers counts a missed presence check for every live player still racing in the
+// active heat who is not in present and crashes those absent from missedChecks checks in a row
+func (m *matchStateManager) CrashAbsentPlayers(ctx context.Context, matchID uuid.UUID, present map[uuid.UUID]bool, missedChecks int) ([]uuid.UUID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, exists := m.states[matchID]
+	if !exists {
+		return nil, fmt.Errorf("%w for match %s", ErrMatchStateNotFound, matchID)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.HeatStatus != HeatStatusActive {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var crashed []uuid.UUID
+	for _, player := range state.Players {
+		if player.IsGhost || player.UserID == nil || !player.IsAlive || player.HasLocked {
+			continue
+		}
+		// A player seen again starts over, so only an unbroken absence forfeits the heat
+		if present[*player.UserID] {
+			player.MissedChecks = 0
+			continue
+		}
+		player.MissedChecks++
+		if player.MissedChecks < missedChecks {
+			continue
+		}
+
+		player.IsAlive = false
+		crashed = append(crashed, *player.UserID)
+
+		crash := newMatchEvent(state, models.MatchEventPlayerCrashed, player, now)
+		heatTime := m.heatLockSeconds(state.HeatStartTime, now)
+		crash.HeatTime = &heatTime
+		m.recordEvent(crash)
+	}
+
+	if len(crashed) > 0 {
+		state.UpdatedAt = now
+		m.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+			"heat":     state.CurrentHeat,
+			"players":  crashed,
+		}).Info("Absent players crashed out of heat")
+	}
+
+	return crashed, nil
+}
+
+// recordEvent queues a timeline event if a recorder is configured
+func (m *matchStateManager) recordEvent(event *models.MatchEvent) {
+	if m.events != nil {
+		m.events.Record(event)
+	}
+}
+
+// newMatchEvent builds a timeline event for the current heat, about a player if one is given
+func newMatchEvent(state *InMemoryMatchState, eventType models.MatchEventType, player *InMemoryPlayer, at time.Time) *models.MatchEvent {
+	event := &models.MatchEvent{
+		MatchID:    state.MatchID,
+		EventType:  eventType,
+		Heat:       state.CurrentHeat,
+		OccurredAt: at,
+	}
+	if player != nil {
+		event.UserID = player.UserID
+		event.GhostReplayID = player.GhostReplayID
+	}
+	return event
+}
+
+// heatLockSeconds returns how long after the countdown a lock happened, to the millisecond
+func (m *matchStateManager) heatLockSeconds(heatStartTime *time.Time, lockedAt time.Time) float64 {
+	if heatStartTime == nil {
+		return 0
+	}
+	elapsed := lockedAt.Sub(heatStartTime.Add(m.countdownDuration))
+	return max(elapsed.Round(time.Millisecond).Seconds(), 0)
 }
 
 // GetActiveMatches returns all active match IDs
@@ -388,12 +648,29 @@ func (m *matchStateManager) GetActiveMatches(ctx context.Context) []uuid.UUID {
 	return activeMatches
 }
 
+// reportActiveMatches sets the active matches gauge to the number of matches in progress.
+// Callers must hold m.mu.
+func (m *matchStateManager) reportActiveMatches() {
+	if m.metrics == nil {
+		return
+	}
+
+	active := 0
+	for _, state := range m.states {
+		if state.Status == MatchStatusInProgress {
+			active++
+		}
+	}
+	m.metrics.SetActiveMatches(float64(active))
+}
+
 // RemoveMatchState removes a match state from memory
 func (m *matchStateManager) RemoveMatchState(ctx context.Context, matchID uuid.UUID) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	delete(m.states, matchID)
+	m.reportActiveMatches()
 
 	m.logger.WithFields(logrus.Fields{
 		"match_id": matchID,
@@ -468,39 +745,36 @@ func (m *matchStateManager) calculateHeatPositions(state *InMemoryMatchState) {
 
 // calculateFinalPositions calculates final positions based on total scores
 func (m *matchStateManager) calculateFinalPositions(state *InMemoryMatchState) {
-	// Create slice of players for sorting
-	type playerTotal struct {
-		player *InMemoryPlayer
-		total  decimal.Decimal
-	}
-
-	var players []playerTotal
+	players := make([]*InMemoryPlayer, 0, len(state.Players))
 	for _, player := range state.Players {
 		m.calculatePlayerTotalScore(player)
-		players = append(players, playerTotal{
-			player: player,
-			total:  player.TotalScore,
-		})
+		players = append(players, player)
 	}
 
+	rankPlayers(players, state.LockTimeTiebreak)
+}
+
+// rankPlayers orders players by total score (descending) with the heat tiebreaker,
+// then optionally by lock time, and assigns their positions. Totals must already be up to date.
+func rankPlayers(players []*InMemoryPlayer, lockTimeTiebreak bool) {
 	// Sort by total score (descending), with tiebreaker logic
 	for i := 0; i < len(players)-1; i++ {
 		for j := i + 1; j < len(players); j++ {
-			if m.shouldSwapPlayers(players[i].player, players[j].player) {
+			if shouldSwapPlayers(players[i], players[j], lockTimeTiebreak) {
 				players[i], players[j] = players[j], players[i]
 			}
 		}
 	}
 
-	// Assign final positions
-	for i, ps := range players {
-		ps.player.Position = i + 1
+	// Assign positions
+	for i, player := range players {
+		player.Position = i + 1
 	}
 }
 
 // shouldSwapPlayers determines if two players should be swapped in sorting
-// Implements tiebreaker logic: Heat 3 → Heat 2 → Heat 1
-func (m *matchStateManager) shouldSwapPlayers(p1, p2 *InMemoryPlayer) bool {
+// Implements tiebreaker logic: Heat 3 → Heat 2 → Heat 1 → earlier lock (if enabled)
+func shouldSwapPlayers(p1, p2 *InMemoryPlayer, lockTimeTiebreak bool) bool {
 	// First, compare total scores
 	if p1.TotalScore.GreaterThan(p2.TotalScore) {
 		return false // p1 is better
@@ -554,5 +828,13 @@ func (m *matchStateManager) shouldSwapPlayers(p1, p2 *InMemoryPlayer) bool {
 		h2_h1 = *p2.Heat1Score
 	}
 
-	return h1_h1.LessThan(h2_h1) // p2 is better if their Heat 1 score is higher
+	if h1_h1.GreaterThan(h2_h1) {
+		return false // p1 is better
+	}
+	if h1_h1.LessThan(h2_h1) {
+		return true // p2 is better
+	}
+
+	// Every score is tied; the earlier locker wins if the league uses that rule
+	return lockTimeTiebreak && lockTimesFavorSecond(p1.heatLockTimes(), p2.heatLockTimes())
 }
@@ -0,0 +1,182 @@
+package gameengine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/metrics"
+)
+
+func TestActiveMatchesGauge_FollowsMatchStatus(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	stateManager := NewMatchStateManager(logger, WithStateMetrics(m))
+
+	first, second := uuid.New(), uuid.New()
+	for _, matchID := range []uuid.UUID{first, second} {
+		players, _ := newLivePlayers(3)
+		require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", players))
+	}
+	// Forming matches are not running yet
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.ActiveMatches))
+
+	require.NoError(t, stateManager.UpdateMatchStatus(ctx, first, MatchStatusInProgress))
+	require.NoError(t, stateManager.UpdateMatchStatus(ctx, second, MatchStatusInProgress))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.ActiveMatches))
+
+	require.NoError(t, stateManager.UpdateMatchStatus(ctx, first, MatchStatusCompleted))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.ActiveMatches))
+
+	// Dropping a match that never completed frees its slot too
+	require.NoError(t, stateManager.RemoveMatchState(ctx, second))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.ActiveMatches))
+}
+
+func TestLockPlayerScore_OnlyWhileHeatIsActive(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, stateManager MatchStateManager, matchID uuid.UUID)
+		wantErr error
+	}{
+		{
+			name:    "waiting",
+			prepare: func(t *testing.T, stateManager MatchStateManager, matchID uuid.UUID) {},
+			wantErr: ErrHeatNotActive,
+		},
+		{
+			name: "countdown",
+			prepare: func(t *testing.T, stateManager MatchStateManager, matchID uuid.UUID) {
+				require.NoError(t, stateManager.StartHeat(context.Background(), matchID, 1))
+			},
+			wantErr: ErrHeatNotActive,
+		},
+		{
+			name: "active",
+			prepare: func(t *testing.T, stateManager MatchStateManager, matchID uuid.UUID) {
+				require.NoError(t, stateManager.StartHeat(context.Background(), matchID, 1))
+				activateHeat(t, stateManager, matchID)
+			},
+		},
+		{
+			name: "intermission",
+			prepare: func(t *testing.T, stateManager MatchStateManager, matchID uuid.UUID) {
+				require.NoError(t, stateManager.StartHeat(context.Background(), matchID, 1))
+				activateHeat(t, stateManager, matchID)
+				require.NoError(t, stateManager.EndHeat(context.Background(), matchID))
+			},
+			wantErr: ErrHeatNotActive,
+		},
+		{
+			name: "completed",
+			prepare: func(t *testing.T, stateManager MatchStateManager, matchID uuid.UUID) {
+				require.NoError(t, stateManager.StartHeat(context.Background(), matchID, 3))
+				activateHeat(t, stateManager, matchID)
+				require.NoError(t, stateManager.EndHeat(context.Background(), matchID))
+			},
+			wantErr: ErrHeatNotActive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetLevel(logrus.PanicLevel)
+			stateManager := NewMatchStateManager(logger)
+			players, userIDs := newLivePlayers(2)
+
+			matchID := uuid.New()
+			require.NoError(t, stateManager.CreateMatchState(context.Background(), matchID, "ROOKIE", players))
+			tt.prepare(t, stateManager, matchID)
+
+			_, err := stateManager.LockPlayerScore(context.Background(), matchID, userIDs[0], decimal.NewFromInt(50))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLockPlayerScore_MeasuredFromConfiguredCountdown(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger, WithStateCountdownDuration(10*time.Second))
+	players, userIDs := newLivePlayers(2)
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", players))
+	require.NoError(t, stateManager.StartHeat(ctx, matchID, 1))
+	activateHeat(t, stateManager, matchID)
+
+	// The heat started 12s ago, so with a 10s countdown the lock came 2s into it
+	startedAt := time.Now().Add(-12 * time.Second)
+	stateManager.(*matchStateManager).states[matchID].HeatStartTime = &startedAt
+
+	lockTime, err := stateManager.LockPlayerScore(ctx, matchID, userIDs[0], decimal.NewFromInt(50))
+	require.NoError(t, err)
+	assert.InDelta(t, 2.0, lockTime, 0.5)
+}
+
+func TestLockPlayerScore_RacingTheHeatGoingActive(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	// A lock arriving together with the countdown ending is either taken after the heat went
+	// active or rejected as too early, never recorded against the countdown
+	for i := 0; i < 50; i++ {
+		stateManager := NewMatchStateManager(logger)
+		players, userIDs := newLivePlayers(2)
+		matchID := uuid.New()
+		require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", players))
+		require.NoError(t, stateManager.StartHeat(ctx, matchID, 1))
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		var lockErr, activateErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, lockErr = stateManager.LockPlayerScore(ctx, matchID, userIDs[0], decimal.NewFromInt(50))
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			activateErr = stateManager.SetHeatStatus(ctx, matchID, HeatStatusActive)
+		}()
+		close(start)
+		wg.Wait()
+
+		require.NoError(t, activateErr)
+		state, err := stateManager.GetMatchState(ctx, matchID)
+		require.NoError(t, err)
+		assert.Equal(t, HeatStatusActive, state.HeatStatus)
+
+		player := state.Players[userIDs[0]]
+		if lockErr != nil {
+			assert.ErrorIs(t, lockErr, ErrHeatNotActive)
+			assert.False(t, player.HasLocked)
+			assert.Nil(t, player.Heat1Score)
+			continue
+		}
+		assert.True(t, player.HasLocked)
+		require.NotNil(t, player.Heat1Score)
+		assert.Equal(t, "50", player.Heat1Score.String())
+	}
+}
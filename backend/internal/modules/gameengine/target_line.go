@@ -0,0 +1,72 @@
+package gameengine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// TargetLinePolicy decides where the Heat 2 and Heat 3 target lines come from.
+// Leagues listed for seed target lines derive them from the heat's committed crash seed, so they
+// are fixed before the match starts; the others follow the leading score.
+type TargetLinePolicy struct {
+	seedLeagues map[string]bool
+}
+
+// NewTargetLinePolicy creates a target line policy deriving the lines of the given leagues from the crash seed
+func NewTargetLinePolicy(seedLeagues ...string) *TargetLinePolicy {
+	leagues := make(map[string]bool, len(seedLeagues))
+	for _, league := range seedLeagues {
+		leagues[league] = true
+	}
+	return &TargetLinePolicy{seedLeagues: leagues}
+}
+
+// SeedDerived reports whether a league's target lines are derived from the crash seed.
+// A nil policy never derives them from the seed.
+func (p *TargetLinePolicy) SeedDerived(league string) bool {
+	return p != nil && p.seedLeagues[league]
+}
+
+// WithSeedTargetLines derives the Heat 2 and Heat 3 target lines of the policy's leagues from the
+// heat's crash seed, read from the match record. Without it every league's lines follow the leading score.
+func WithSeedTargetLines(policy *TargetLinePolicy, matchRepo repository.MatchRepository) HeatManagerOption {
+	return func(h *heatManager) {
+		h.targetLines = policy
+		h.matchRepo = matchRepo
+	}
+}
+
+// targetLine returns the target line shown at the start of Heat 2 or 3, nil when there is none yet
+func (h *heatManager) targetLine(ctx context.Context, state *InMemoryMatchState, heat int) (*decimal.Decimal, error) {
+	if h.matchRepo != nil && h.targetLines.SeedDerived(state.League) {
+		match, err := h.matchRepo.GetByID(ctx, state.MatchID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get match: %w", err)
+		}
+		if match == nil {
+			return nil, ErrMatchNotFound
+		}
+
+		seed, err := GetHeatSeedFromMatch(match.CrashSeed, heat)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get heat seed: %w", err)
+		}
+
+		targetLine := h.fairnessEngine.DeriveTargetLine(seed)
+		return &targetLine, nil
+	}
+
+	switch heat {
+	case 2:
+		// Target line is Heat 1 winner's score
+		return h.calculateHeat1WinnerScore(state), nil
+	case 3:
+		// Target line is current leader's total score
+		return h.calculateCurrentLeaderTotal(state), nil
+	}
+	return nil, nil
+}
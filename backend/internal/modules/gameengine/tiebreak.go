@@ -0,0 +1,73 @@
+package gameengine
+
+import "sort"
+
+// TiebreakPolicy decides how players tied on their total and every heat score are ordered.
+// Leagues listed for the lock-time rule rank the earlier locker first; the others leave the tie as is.
+type TiebreakPolicy struct {
+	lockTimeLeagues map[string]bool
+}
+
+// NewTiebreakPolicy creates a tiebreak policy applying the lock-time rule to the given leagues
+func NewTiebreakPolicy(lockTimeLeagues ...string) *TiebreakPolicy {
+	leagues := make(map[string]bool, len(lockTimeLeagues))
+	for _, league := range lockTimeLeagues {
+		leagues[league] = true
+	}
+	return &TiebreakPolicy{lockTimeLeagues: leagues}
+}
+
+// LockTimeBreaksTies reports whether the earlier lock wins a full score tie in a league.
+// A nil policy never applies the lock-time rule.
+func (p *TiebreakPolicy) LockTimeBreaksTies(league string) bool {
+	return p != nil && p.lockTimeLeagues[league]
+}
+
+// lockTimesFavorSecond compares two players' lock times, Heat 3 first, and reports whether
+// the second player locked earlier. A locked heat beats one without a lock time.
+func lockTimesFavorSecond(first, second [3]*float64) bool {
+	for heat := 2; heat >= 0; heat-- {
+		t1, t2 := first[heat], second[heat]
+		switch {
+		case t1 == nil && t2 == nil:
+			continue
+		case t1 == nil:
+			return true
+		case t2 == nil:
+			return false
+		case *t1 != *t2:
+			return *t2 < *t1
+		}
+	}
+	return false
+}
+
+// breakTiesByLockTime reorders each run of positions tied on their total and every heat score
+// so the earlier locker ranks first; positions must already be ranked by score
+func breakTiesByLockTime(positions []*PlayerPosition) {
+	for start := 0; start < len(positions); {
+		end := start + 1
+		for end < len(positions) && scoresTied(positions[start], positions[end]) {
+			end++
+		}
+
+		tied := positions[start:end]
+		sort.SliceStable(tied, func(i, j int) bool {
+			return lockTimesFavorSecond(positionLockTimes(tied[j]), positionLockTimes(tied[i]))
+		})
+		start = end
+	}
+}
+
+// scoresTied reports whether two positions have the same total and heat scores
+func scoresTied(p1, p2 *PlayerPosition) bool {
+	return p1.TotalScore.Equal(p2.TotalScore) &&
+		p1.Heat3Score.Equal(p2.Heat3Score) &&
+		p1.Heat2Score.Equal(p2.Heat2Score) &&
+		p1.Heat1Score.Equal(p2.Heat1Score)
+}
+
+// positionLockTimes returns a position's lock times in heat order
+func positionLockTimes(p *PlayerPosition) [3]*float64 {
+	return [3]*float64{p.Heat1LockTime, p.Heat2LockTime, p.Heat3LockTime}
+}
@@ -0,0 +1,228 @@
+package gameengine
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// lockAfter locks a player's score as if the lock came the given time after the heat went active
+func lockAfter(t *testing.T, stateManager MatchStateManager, matchID, userID uuid.UUID, score decimal.Decimal, after time.Duration) float64 {
+	manager := stateManager.(*matchStateManager)
+	startedAt := time.Now().Add(-manager.countdownDuration - after)
+	manager.states[matchID].HeatStartTime = &startedAt
+
+	lockTime, err := stateManager.LockPlayerScore(context.Background(), matchID, userID, score)
+	require.NoError(t, err)
+	return lockTime
+}
+
+func TestFinalPositions_EarlierLockerWinsFullTie(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	stateManager := NewMatchStateManager(logger, WithStateTiebreakPolicy(NewTiebreakPolicy("ROOKIE")))
+	publisher := &recordingPublisher{}
+	heatManager := NewHeatManager(stateManager, publisher, logger)
+
+	slowID, fastID := uuid.New(), uuid.New()
+	players := []*MatchPlayer{
+		{UserID: &slowID, DisplayName: "Slow", BuyinAmount: decimal.NewFromInt(10)},
+		{UserID: &fastID, DisplayName: "Fast", BuyinAmount: decimal.NewFromInt(10)},
+	}
+	matchID := uuid.New()
+	require.NoError(t, stateManager.CreateMatchState(ctx, matchID, "ROOKIE", players))
+	require.NoError(t, stateManager.UpdateMatchStatus(ctx, matchID, MatchStatusInProgress))
+
+	// Both players lock the same score in every heat; Fast always locks earlier
+	for heat := 1; heat <= 3; heat++ {
+		require.NoError(t, stateManager.StartHeat(ctx, matchID, heat))
+		activateHeat(t, stateManager, matchID)
+
+		score := decimal.NewFromInt(int64(100 * heat))
+		assert.InDelta(t, 10.0, lockAfter(t, stateManager, matchID, slowID, score, 10*time.Second), 0.05)
+		assert.InDelta(t, 4.0, lockAfter(t, stateManager, matchID, fastID, score, 4*time.Second), 0.05)
+
+		if heat < 3 {
+			require.NoError(t, stateManager.EndHeat(ctx, matchID))
+		}
+	}
+	require.NoError(t, heatManager.EndHeat(ctx, matchID))
+
+	state, err := stateManager.GetMatchState(ctx, matchID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, state.Players[fastID].Position)
+	assert.Equal(t, 2, state.Players[slowID].Position)
+
+	// heat_ended carries each player's lock time
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+	require.Len(t, publisher.heatEnded, 1)
+	for _, result := range publisher.heatEnded[0].Results {
+		require.NotNil(t, result.LockTime)
+		expected := 10.0
+		if *result.UserID == fastID {
+			expected = 4.0
+		}
+		assert.InDelta(t, expected, *result.LockTime, 0.05)
+	}
+}
+
+// newTiedSettlementFixture returns a settlement service over two participants tied on every
+// heat score, where the first participant locked later in Heat 3
+func newTiedSettlementFixture(opts ...SettlementOption) (SettlementService, uuid.UUID, *models.MatchParticipant, *models.MatchParticipant) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusInProgress}
+	score := decimal.NewFromInt(150)
+	total := decimal.NewFromInt(450)
+	early, late := 3.5, 7.25
+	newParticipant := func(name string, heat3LockTime *float64) *models.MatchParticipant {
+		userID := uuid.New()
+		return &models.MatchParticipant{
+			MatchID:           match.ID,
+			UserID:            &userID,
+			PlayerDisplayName: name,
+			Heat1Score:        &score,
+			Heat2Score:        &score,
+			Heat3Score:        &score,
+			Heat1LockTime:     &early,
+			Heat2LockTime:     &early,
+			Heat3LockTime:     heat3LockTime,
+			TotalScore:        &total,
+		}
+	}
+	slow := newParticipant("Slow", &late)
+	fast := newParticipant("Fast", &early)
+
+	matchRepo := &stubMatchRepository{created: []*models.Match{match}}
+	participantRepo := &stubParticipantRepository{created: []*models.MatchParticipant{slow, fast}}
+	settlement := NewSettlementService(matchRepo, participantRepo, nil, nil, nil, nil, nil, logger, opts...)
+	return settlement, match.ID, slow, fast
+}
+
+func TestCalculatePositions_LockTimeTiebreak(t *testing.T) {
+	ctx := context.Background()
+
+	// With the rule enabled for the league the earlier Heat 3 lock wins
+	settlement, matchID, _, fast := newTiedSettlementFixture(WithSettlementTiebreakPolicy(NewTiebreakPolicy("ROOKIE")))
+	positions, err := settlement.CalculatePositions(ctx, matchID)
+	require.NoError(t, err)
+	require.Len(t, positions, 2)
+	assert.Equal(t, *fast.UserID, *positions[0].UserID)
+	require.NotNil(t, positions[0].Heat3LockTime)
+	assert.Equal(t, 3.5, *positions[0].Heat3LockTime)
+
+	// Leagues without the rule keep the existing order
+	settlement, matchID, slow, _ := newTiedSettlementFixture(WithSettlementTiebreakPolicy(NewTiebreakPolicy("PRO")))
+	positions, err = settlement.CalculatePositions(ctx, matchID)
+	require.NoError(t, err)
+	assert.Equal(t, *slow.UserID, *positions[0].UserID)
+}
+
+func TestLockTimesFavorSecond(t *testing.T) {
+	at := func(seconds float64) *float64 { return &seconds }
+
+	// Heat 3 decides before earlier heats
+	assert.True(t, lockTimesFavorSecond([3]*float64{at(1), at(1), at(9)}, [3]*float64{at(5), at(5), at(2)}))
+	// A lock beats a missing lock time
+	assert.False(t, lockTimesFavorSecond([3]*float64{at(1), at(1), at(20)}, [3]*float64{at(1), at(1), nil}))
+	// Identical lock times are not a reason to swap
+	assert.False(t, lockTimesFavorSecond([3]*float64{at(2), nil, at(3)}, [3]*float64{at(2), nil, at(3)}))
+}
+
+func TestCalculatePositions_FollowsStandingsOrder(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	rng := rand.New(rand.NewSource(42))
+
+	// Scores come from a few values so totals and whole score lines tie often
+	randomScore := func() decimal.Decimal { return decimal.NewFromInt(int64(rng.Intn(3) * 50)) }
+	randomLockTime := func() *float64 {
+		if rng.Intn(4) == 0 {
+			return nil
+		}
+		lockTime := float64(rng.Intn(5))
+		return &lockTime
+	}
+
+	for round := 0; round < 200; round++ {
+		lockTimeTiebreak := round%2 == 0
+		match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusInProgress}
+		participants := make([]*models.MatchParticipant, 2+rng.Intn(9))
+		for i := range participants {
+			userID := uuid.New()
+			heat1, heat2, heat3 := randomScore(), randomScore(), randomScore()
+			total := heat1.Add(heat2).Add(heat3)
+			participants[i] = &models.MatchParticipant{
+				MatchID:       match.ID,
+				UserID:        &userID,
+				Heat1Score:    &heat1,
+				Heat2Score:    &heat2,
+				Heat3Score:    &heat3,
+				Heat1LockTime: randomLockTime(),
+				Heat2LockTime: randomLockTime(),
+				Heat3LockTime: randomLockTime(),
+				TotalScore:    &total,
+			}
+		}
+
+		var opts []SettlementOption
+		if lockTimeTiebreak {
+			opts = append(opts, WithSettlementTiebreakPolicy(NewTiebreakPolicy("ROOKIE")))
+		}
+		participantRepo := &stubParticipantRepository{created: participants}
+		settlement := NewSettlementService(&stubMatchRepository{created: []*models.Match{match}}, participantRepo, nil, nil, nil, nil, nil, logger, opts...)
+
+		positions, err := settlement.CalculatePositions(ctx, match.ID)
+		require.NoError(t, err)
+		require.Len(t, positions, len(participants))
+
+		for i, position := range positions {
+			assert.Equal(t, i+1, position.FinalPosition)
+			if i == 0 {
+				continue
+			}
+			prev := positions[i-1]
+
+			// Scores never improve down the table
+			assert.False(t, standingsLess(participantOf(participants, position), participantOf(participants, prev)), "round %d position %d", round, i+1)
+
+			// Under the lock-time rule a full tie puts the earlier locker first
+			if lockTimeTiebreak && scoresTied(prev, position) {
+				assert.False(t, lockTimesFavorSecond(positionLockTimes(prev), positionLockTimes(position)), "round %d position %d", round, i+1)
+			}
+		}
+
+		// Without it the table is exactly the standings order
+		if !lockTimeTiebreak {
+			standings, err := participantRepo.GetStandings(ctx, match.ID)
+			require.NoError(t, err)
+			for i, position := range positions {
+				assert.Equal(t, *standings[i].UserID, *position.UserID, "round %d position %d", round, i+1)
+			}
+		}
+	}
+}
+
+// participantOf returns the participant a position was ranked from
+func participantOf(participants []*models.MatchParticipant, position *PlayerPosition) *models.MatchParticipant {
+	for _, participant := range participants {
+		if *participant.UserID == *position.UserID {
+			return participant
+		}
+	}
+	return nil
+}
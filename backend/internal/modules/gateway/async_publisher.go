@@ -0,0 +1,242 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/metrics"
+)
+
+// Default async publisher settings
+const (
+	defaultPublishQueueSize    = 1024
+	defaultPublishTimeout      = 5 * time.Second
+	defaultPublishDrainTimeout = 10 * time.Second
+)
+
+// AsyncPublisher is a CentrifugoPublisher that queues events and delivers them in the background,
+// so a slow or unavailable Centrifugo never adds latency to settlement and heat flows
+type AsyncPublisher interface {
+	CentrifugoPublisher
+
+	// Start runs the delivery worker until ctx is cancelled, then delivers the events still queued
+	Start(ctx context.Context)
+
+	// Done is closed once the worker has delivered its last event and exited
+	Done() <-chan struct{}
+}
+
+// publishJob is a queued publish call
+type publishJob struct {
+	eventType string
+	target    string
+	publish   func(ctx context.Context) error
+}
+
+// asyncPublisher implements AsyncPublisher with a bounded queue drained by a single worker,
+// which keeps events in the order they were published
+type asyncPublisher struct {
+	next           CentrifugoPublisher
+	queue          chan publishJob
+	enqueueTimeout time.Duration
+	publishTimeout time.Duration
+	drainTimeout   time.Duration
+	metrics        *metrics.Metrics
+	done           chan struct{}
+	logger         *logrus.Logger
+}
+
+// AsyncPublisherOption configures optional async publisher behaviour
+type AsyncPublisherOption func(*asyncPublisher)
+
+// WithPublishQueueSize sets how many events may wait for delivery; non-positive values keep the default
+func WithPublishQueueSize(size int) AsyncPublisherOption {
+	return func(p *asyncPublisher) {
+		if size > 0 {
+			p.queue = make(chan publishJob, size)
+		}
+	}
+}
+
+// WithPublishEnqueueTimeout makes publishers wait up to timeout for room in a full queue before
+// the event is dropped. By default full queues drop events immediately.
+func WithPublishEnqueueTimeout(timeout time.Duration) AsyncPublisherOption {
+	return func(p *asyncPublisher) {
+		p.enqueueTimeout = timeout
+	}
+}
+
+// WithPublishTimeout bounds every delivery attempt; non-positive values keep the default
+func WithPublishTimeout(timeout time.Duration) AsyncPublisherOption {
+	return func(p *asyncPublisher) {
+		if timeout > 0 {
+			p.publishTimeout = timeout
+		}
+	}
+}
+
+// WithAsyncPublisherMetrics counts the events dropped because the queue was full and the failed deliveries
+func WithAsyncPublisherMetrics(m *metrics.Metrics) AsyncPublisherOption {
+	return func(p *asyncPublisher) {
+		p.metrics = m
+	}
+}
+
+// NewAsyncPublisher creates a publisher that queues events and delivers them through next
+func NewAsyncPublisher(next CentrifugoPublisher, logger *logrus.Logger, opts ...AsyncPublisherOption) AsyncPublisher {
+	p := &asyncPublisher{
+		next:           next,
+		queue:          make(chan publishJob, defaultPublishQueueSize),
+		publishTimeout: defaultPublishTimeout,
+		drainTimeout:   defaultPublishDrainTimeout,
+		done:           make(chan struct{}),
+		logger:         logger,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// PublishToUser queues an event for a user's personal channel
+func (p *asyncPublisher) PublishToUser(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) error {
+	p.enqueue(ctx, publishJob{
+		eventType: eventType,
+		target:    "user:" + userID.String(),
+		publish: func(ctx context.Context) error {
+			return p.next.PublishToUser(ctx, userID, eventType, data)
+		},
+	})
+	return nil
+}
+
+// PublishToMatch queues an event for a match channel
+func (p *asyncPublisher) PublishToMatch(ctx context.Context, matchID uuid.UUID, eventType string, data interface{}) error {
+	p.enqueue(ctx, publishJob{
+		eventType: eventType,
+		target:    "match:" + matchID.String(),
+		publish: func(ctx context.Context) error {
+			return p.next.PublishToMatch(ctx, matchID, eventType, data)
+		},
+	})
+	return nil
+}
+
+// PublishToUsers queues an event for multiple user channels
+func (p *asyncPublisher) PublishToUsers(ctx context.Context, userIDs []uuid.UUID, eventType string, data interface{}) error {
+	recipients := append([]uuid.UUID(nil), userIDs...)
+	p.enqueue(ctx, publishJob{
+		eventType: eventType,
+		target:    "users",
+		publish: func(ctx context.Context) error {
+			return p.next.PublishToUsers(ctx, recipients, eventType, data)
+		},
+	})
+	return nil
+}
+
+// BroadcastToChannel queues an event for a specific channel
+func (p *asyncPublisher) BroadcastToChannel(ctx context.Context, channel string, eventType string, data interface{}) error {
+	p.enqueue(ctx, publishJob{
+		eventType: eventType,
+		target:    channel,
+		publish: func(ctx context.Context) error {
+			return p.next.BroadcastToChannel(ctx, channel, eventType, data)
+		},
+	})
+	return nil
+}
+
+// enqueue adds a job to the queue, dropping it if the queue stays full past the enqueue timeout
+func (p *asyncPublisher) enqueue(ctx context.Context, job publishJob) {
+	select {
+	case p.queue <- job:
+		return
+	default:
+	}
+
+	if p.enqueueTimeout > 0 {
+		timer := time.NewTimer(p.enqueueTimeout)
+		defer timer.Stop()
+
+		select {
+		case p.queue <- job:
+			return
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"event_type": job.eventType,
+		"target":     job.target,
+	}).Warn("Realtime publish queue is full, dropping event")
+
+	if p.metrics != nil {
+		p.metrics.RecordRealtimeEventDropped(job.eventType, metrics.RealtimeDropQueueFull)
+	}
+}
+
+// Start runs the delivery worker until ctx is cancelled, then delivers the events still queued
+func (p *asyncPublisher) Start(ctx context.Context) {
+	p.logger.WithField("queue_size", cap(p.queue)).Info("Starting async realtime publisher")
+
+	go func() {
+		defer close(p.done)
+
+		for {
+			select {
+			case job := <-p.queue:
+				// Deliveries outlive the request and worker contexts that published them
+				p.deliver(context.Background(), job)
+			case <-ctx.Done():
+				p.drain()
+				p.logger.Info("Async realtime publisher stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Done is closed once the worker has delivered its last event and exited
+func (p *asyncPublisher) Done() <-chan struct{} {
+	return p.done
+}
+
+// drain delivers the events still queued, giving up on the rest once the drain timeout passes
+// so an unavailable Centrifugo cannot hold up shutdown
+func (p *asyncPublisher) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.drainTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case job := <-p.queue:
+			p.deliver(ctx, job)
+		default:
+			return
+		}
+	}
+}
+
+// deliver publishes a job within the publish timeout. Failures are logged and the event
+// is dropped, as realtime delivery is best effort.
+func (p *asyncPublisher) deliver(ctx context.Context, job publishJob) {
+	ctx, cancel := context.WithTimeout(ctx, p.publishTimeout)
+	defer cancel()
+
+	if err := job.publish(ctx); err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"event_type": job.eventType,
+			"target":     job.target,
+			"error":      err,
+		}).Error("Failed to publish realtime event")
+
+		if p.metrics != nil {
+			p.metrics.RecordRealtimePublishFailure(job.eventType)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/metrics"
+)
+
+// blockingPublisher records delivered event types, holding every delivery until released
+type blockingPublisher struct {
+	CentrifugoPublisher
+
+	release chan struct{}
+
+	mu        sync.Mutex
+	delivered []string
+}
+
+func newBlockingPublisher() *blockingPublisher {
+	return &blockingPublisher{release: make(chan struct{})}
+}
+
+func (p *blockingPublisher) PublishToMatch(ctx context.Context, matchID uuid.UUID, eventType string, data interface{}) error {
+	select {
+	case <-p.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.delivered = append(p.delivered, eventType)
+	return nil
+}
+
+func (p *blockingPublisher) deliveredTypes() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.delivered...)
+}
+
+func newTestAsyncPublisher(next CentrifugoPublisher, opts ...AsyncPublisherOption) AsyncPublisher {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	return NewAsyncPublisher(next, logger, opts...)
+}
+
+func TestAsyncPublisher_DeliversInPublishOrder(t *testing.T) {
+	next := newBlockingPublisher()
+	close(next.release)
+	publisher := newTestAsyncPublisher(next)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	publisher.Start(ctx)
+
+	matchID := uuid.New()
+	for _, eventType := range []string{"first", "second", "third"} {
+		require.NoError(t, publisher.PublishToMatch(context.Background(), matchID, eventType, nil))
+	}
+
+	assert.Eventually(t, func() bool { return len(next.deliveredTypes()) == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"first", "second", "third"}, next.deliveredTypes())
+
+	cancel()
+	<-publisher.Done()
+}
+
+func TestAsyncPublisher_FullQueueDropsAndCountsEvents(t *testing.T) {
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	next := newBlockingPublisher()
+	publisher := newTestAsyncPublisher(next, WithPublishQueueSize(2), WithAsyncPublisherMetrics(m))
+
+	// Without a running worker the queue fills after two events and later ones return at once
+	matchID := uuid.New()
+	start := time.Now()
+	for _, eventType := range []string{"kept", "kept", "dropped", "dropped"} {
+		require.NoError(t, publisher.PublishToMatch(context.Background(), matchID, eventType, nil))
+	}
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.RealtimeEventsDropped.WithLabelValues("dropped", metrics.RealtimeDropQueueFull)))
+	assert.Zero(t, testutil.ToFloat64(m.RealtimeEventsDropped.WithLabelValues("kept", metrics.RealtimeDropQueueFull)))
+
+	// Stopping the worker still delivers what was queued
+	close(next.release)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	publisher.Start(ctx)
+	<-publisher.Done()
+	assert.Equal(t, []string{"kept", "kept"}, next.deliveredTypes())
+}
+
+func TestAsyncPublisher_EnqueueTimeoutWaitsForRoom(t *testing.T) {
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	next := newBlockingPublisher()
+	publisher := newTestAsyncPublisher(next,
+		WithPublishQueueSize(1),
+		WithPublishEnqueueTimeout(time.Second),
+		WithAsyncPublisherMetrics(m),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	publisher.Start(ctx)
+
+	// The worker holds the first event and the second fills the queue
+	matchID := uuid.New()
+	require.NoError(t, publisher.PublishToMatch(context.Background(), matchID, "first", nil))
+	require.Eventually(t, func() bool { return len(publisher.(*asyncPublisher).queue) == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, publisher.PublishToMatch(context.Background(), matchID, "second", nil))
+
+	// The third waits for room instead of being dropped
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(next.release)
+	}()
+	require.NoError(t, publisher.PublishToMatch(context.Background(), matchID, "third", nil))
+
+	assert.Eventually(t, func() bool { return len(next.deliveredTypes()) == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"first", "second", "third"}, next.deliveredTypes())
+	assert.Zero(t, testutil.ToFloat64(m.RealtimeEventsDropped.WithLabelValues("third", metrics.RealtimeDropQueueFull)))
+}
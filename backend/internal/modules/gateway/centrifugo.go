@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/centrifugal/gocent/v3"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
 	"github.com/megaherz/ndr/internal/centrifugo"
+	"github.com/megaherz/ndr/internal/modules/gateway/events"
 )
 
 // CentrifugoPublisher handles publishing events to Centrifugo channels
@@ -29,29 +31,56 @@ type CentrifugoPublisher interface {
 
 // centrifugoPublisher implements CentrifugoPublisher
 type centrifugoPublisher struct {
-	client *centrifugo.Client
-	logger *logrus.Logger
+	client           *centrifugo.Client
+	previousVersions map[string]bool
+	logger           *logrus.Logger
+}
+
+// CentrifugoPublisherOption configures optional Centrifugo publisher behaviour
+type CentrifugoPublisherOption func(*centrifugoPublisher)
+
+// WithPreviousEventVersions also publishes the previous schema version of the given event types
+// while a payload change rolls out. Only payloads implementing events.PreviousVersionPayload
+// are published twice; the previous version goes out first.
+func WithPreviousEventVersions(eventTypes ...string) CentrifugoPublisherOption {
+	return func(p *centrifugoPublisher) {
+		for _, eventType := range eventTypes {
+			p.previousVersions[eventType] = true
+		}
+	}
 }
 
 // NewCentrifugoPublisher creates a new Centrifugo publisher
-func NewCentrifugoPublisher(client *centrifugo.Client, logger *logrus.Logger) CentrifugoPublisher {
-	return &centrifugoPublisher{
-		client: client,
-		logger: logger,
+func NewCentrifugoPublisher(client *centrifugo.Client, logger *logrus.Logger, opts ...CentrifugoPublisherOption) CentrifugoPublisher {
+	p := &centrifugoPublisher{
+		client:           client,
+		previousVersions: make(map[string]bool),
+		logger:           logger,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
-// EventMessage represents a standardized event message structure
+// EventMessage represents a standardized event message structure.
+// Version is the schema version of Data, see events.VersionOf.
 type EventMessage struct {
 	Type      string      `json:"type"`
+	Version   int         `json:"version"`
 	Data      interface{} `json:"data"`
 	Timestamp int64       `json:"timestamp"`
 }
 
 // PublishToUser publishes an event to a user's personal channel
 func (p *centrifugoPublisher) PublishToUser(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) error {
+	messages, err := p.prepareEventMessages(eventType, data)
+	if err != nil {
+		return fmt.Errorf("failed to prepare event message: %w", err)
+	}
+
 	channel := fmt.Sprintf("user:%s", userID.String())
-	return p.BroadcastToChannel(ctx, channel, eventType, data)
+	return p.publishMessages(ctx, channel, messages, centrifugo.UserChannelPublishOptions...)
 }
 
 // PublishToMatch publishes an event to a match channel
@@ -62,8 +91,8 @@ func (p *centrifugoPublisher) PublishToMatch(ctx context.Context, matchID uuid.U
 
 // PublishToUsers publishes an event to multiple user channels
 func (p *centrifugoPublisher) PublishToUsers(ctx context.Context, userIDs []uuid.UUID, eventType string, data interface{}) error {
-	// Prepare the event message once
-	message, err := p.prepareEventMessage(eventType, data)
+	// Prepare the event messages once
+	messages, err := p.prepareEventMessages(eventType, data)
 	if err != nil {
 		return fmt.Errorf("failed to prepare event message: %w", err)
 	}
@@ -71,7 +100,7 @@ func (p *centrifugoPublisher) PublishToUsers(ctx context.Context, userIDs []uuid
 	// Publish to each user channel
 	for _, userID := range userIDs {
 		channel := fmt.Sprintf("user:%s", userID.String())
-		if err := p.publishMessage(ctx, channel, message); err != nil {
+		if err := p.publishMessages(ctx, channel, messages, centrifugo.UserChannelPublishOptions...); err != nil {
 			// Log error but continue with other users
 			p.logger.WithFields(logrus.Fields{
 				"user_id":    userID,
@@ -87,27 +116,52 @@ func (p *centrifugoPublisher) PublishToUsers(ctx context.Context, userIDs []uuid
 
 // BroadcastToChannel publishes an event to a specific channel
 func (p *centrifugoPublisher) BroadcastToChannel(ctx context.Context, channel string, eventType string, data interface{}) error {
-	message, err := p.prepareEventMessage(eventType, data)
+	messages, err := p.prepareEventMessages(eventType, data)
 	if err != nil {
 		return fmt.Errorf("failed to prepare event message: %w", err)
 	}
 
-	return p.publishMessage(ctx, channel, message)
+	return p.publishMessages(ctx, channel, messages)
 }
 
-// prepareEventMessage creates a standardized event message
-func (p *centrifugoPublisher) prepareEventMessage(eventType string, data interface{}) (*EventMessage, error) {
-	message := &EventMessage{
+// prepareEventMessages creates the standardized event messages of an event: the current
+// schema version, preceded by the previous one while that version is still being rolled out
+func (p *centrifugoPublisher) prepareEventMessages(eventType string, data interface{}) ([]*EventMessage, error) {
+	timestamp := getCurrentTimestamp()
+	version := events.VersionOf(eventType)
+
+	messages := make([]*EventMessage, 0, 2)
+	if payload, ok := data.(events.PreviousVersionPayload); ok && p.previousVersions[eventType] {
+		previousVersion, previousData := payload.PreviousVersion()
+		messages = append(messages, &EventMessage{
+			Type:      eventType,
+			Version:   previousVersion,
+			Data:      previousData,
+			Timestamp: timestamp,
+		})
+	}
+	messages = append(messages, &EventMessage{
 		Type:      eventType,
+		Version:   version,
 		Data:      data,
-		Timestamp: getCurrentTimestamp(),
-	}
+		Timestamp: timestamp,
+	})
 
-	return message, nil
+	return messages, nil
+}
+
+// publishMessages publishes every message of an event to a channel in order
+func (p *centrifugoPublisher) publishMessages(ctx context.Context, channel string, messages []*EventMessage, opts ...gocent.PublishOption) error {
+	for _, message := range messages {
+		if err := p.publishMessage(ctx, channel, message, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // publishMessage publishes a message to a specific channel
-func (p *centrifugoPublisher) publishMessage(ctx context.Context, channel string, message *EventMessage) error {
+func (p *centrifugoPublisher) publishMessage(ctx context.Context, channel string, message *EventMessage, opts ...gocent.PublishOption) error {
 	// Serialize the message
 	messageData, err := json.Marshal(message)
 	if err != nil {
@@ -115,7 +169,7 @@ func (p *centrifugoPublisher) publishMessage(ctx context.Context, channel string
 	}
 
 	// Publish to Centrifugo
-	err = p.client.Publish(ctx, channel, messageData)
+	err = p.client.Publish(ctx, channel, messageData, opts...)
 	if err != nil {
 		p.logger.WithFields(logrus.Fields{
 			"channel":    channel,
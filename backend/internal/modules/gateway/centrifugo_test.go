@@ -0,0 +1,139 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/centrifugo"
+	"github.com/megaherz/ndr/internal/modules/gateway/events"
+)
+
+// publishRequest mirrors the params of a Centrifugo publish API command
+type publishRequest struct {
+	Channel     string `json:"channel"`
+	SkipHistory bool   `json:"skip_history"`
+}
+
+// recordingCentrifugo accepts every publish command and records its params and published messages
+type recordingCentrifugo struct {
+	mu       sync.Mutex
+	requests []publishRequest
+	messages []EventMessage
+}
+
+func (s *recordingCentrifugo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var cmd struct {
+		Method string `json:"method"`
+		Params struct {
+			publishRequest
+			Data EventMessage `json:"data"`
+		} `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil || cmd.Method != "publish" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, cmd.Params.publishRequest)
+	s.messages = append(s.messages, cmd.Params.Data)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(`{"result":{}}` + "\n"))
+}
+
+func newTestPublisher(t *testing.T, opts ...CentrifugoPublisherOption) (CentrifugoPublisher, *recordingCentrifugo) {
+	recorder := &recordingCentrifugo{}
+	server := httptest.NewServer(recorder)
+	t.Cleanup(server.Close)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	client, err := centrifugo.NewClient(centrifugo.Config{GRPCAddr: server.URL, APIKey: "test-key"}, logger)
+	require.NoError(t, err)
+
+	return NewCentrifugoPublisher(client, logger, opts...), recorder
+}
+
+func TestPublisher_HistoryOptionsByChannel(t *testing.T) {
+	publisher, recorder := newTestPublisher(t)
+	ctx := context.Background()
+	matchID := uuid.New()
+	userIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	require.NoError(t, publisher.PublishToMatch(ctx, matchID, "heat_started", map[string]int{"heat": 1}))
+	require.NoError(t, publisher.PublishToUser(ctx, userIDs[0], "balance_updated", map[string]string{}))
+	require.NoError(t, publisher.PublishToUsers(ctx, userIDs, "match_found", map[string]string{}))
+
+	assert.Equal(t, []publishRequest{
+		{Channel: "match:" + matchID.String(), SkipHistory: false},
+		{Channel: "user:" + userIDs[0].String(), SkipHistory: true},
+		{Channel: "user:" + userIDs[0].String(), SkipHistory: true},
+		{Channel: "user:" + userIDs[1].String(), SkipHistory: true},
+	}, recorder.requests)
+}
+
+func TestPublisher_EnvelopeCarriesSchemaVersion(t *testing.T) {
+	expected := map[string]int{
+		events.EventMatchFound:         events.EventMatchFoundVersion,
+		events.EventHeatStarted:        events.EventHeatStartedVersion,
+		events.EventHeatTick:           events.EventHeatTickVersion,
+		events.EventHeatEnded:          events.EventHeatEndedVersion,
+		events.EventMatchSettled:       events.EventMatchSettledVersion,
+		events.EventMatchAborted:       events.EventMatchAbortedVersion,
+		events.EventBalanceUpdated:     events.EventBalanceUpdatedVersion,
+		events.EventMatchmakingTimeout: events.EventMatchmakingTimeoutVersion,
+		"unregistered_event":           events.DefaultEventVersion,
+	}
+
+	for eventType, version := range expected {
+		t.Run(eventType, func(t *testing.T) {
+			publisher, recorder := newTestPublisher(t)
+			require.NoError(t, publisher.PublishToMatch(context.Background(), uuid.New(), eventType, map[string]int{"heat": 1}))
+
+			require.Len(t, recorder.messages, 1)
+			assert.Equal(t, eventType, recorder.messages[0].Type)
+			assert.Equal(t, version, recorder.messages[0].Version)
+			assert.NotZero(t, recorder.messages[0].Timestamp)
+		})
+	}
+}
+
+// renamedFieldPayload is a version 2 payload that renamed its v1 "speed" field to "max_speed"
+type renamedFieldPayload struct {
+	MaxSpeed int `json:"max_speed"`
+}
+
+func (p renamedFieldPayload) PreviousVersion() (int, interface{}) {
+	return 1, map[string]int{"speed": p.MaxSpeed}
+}
+
+func TestPublisher_PreviousVersionPublishedDuringRollout(t *testing.T) {
+	ctx := context.Background()
+	payload := renamedFieldPayload{MaxSpeed: 300}
+
+	// Event types in rollout are published in both shapes, the previous one first
+	publisher, recorder := newTestPublisher(t, WithPreviousEventVersions(events.EventHeatTick))
+	require.NoError(t, publisher.PublishToMatch(ctx, uuid.New(), events.EventHeatTick, payload))
+
+	require.Len(t, recorder.messages, 2)
+	assert.Equal(t, 1, recorder.messages[0].Version)
+	assert.Equal(t, map[string]interface{}{"speed": 300.0}, recorder.messages[0].Data)
+	assert.Equal(t, events.EventHeatTickVersion, recorder.messages[1].Version)
+	assert.Equal(t, map[string]interface{}{"max_speed": 300.0}, recorder.messages[1].Data)
+
+	// Other event types are published once
+	require.NoError(t, publisher.PublishToMatch(ctx, uuid.New(), events.EventHeatEnded, payload))
+	assert.Len(t, recorder.messages, 3)
+}
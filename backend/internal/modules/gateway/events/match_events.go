@@ -5,25 +5,43 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+
+	monetary "github.com/megaherz/ndr/internal/decimal"
 )
 
+// Monetary amounts use monetary.Money so they always serialize as fixed
+// 2-decimal strings; scores and speeds keep the plain decimal form.
+
 // Event types for match-related events
 const (
-	EventMatchFound     = "match_found"
-	EventHeatStarted    = "heat_started"
-	EventHeatEnded      = "heat_ended"
-	EventMatchSettled   = "match_settled"
-	EventBalanceUpdated = "balance_updated"
+	EventMatchFound          = "match_found"
+	EventHeatStarted         = "heat_started"
+	EventCountdownTick       = "countdown_tick"
+	EventHeatTick            = "heat_tick"
+	EventHeatEnded           = "heat_ended"
+	EventIntermissionStarted = "intermission_started"
+	EventMatchSettled        = "match_settled"
+	EventMatchAborted        = "match_aborted"
+	EventBalanceUpdated      = "balance_updated"
+
+	EventMatchmakingTimeout = "matchmaking_timeout"
 )
 
 // MatchFoundEvent is published to user:{user_id} when a match is found
 type MatchFoundEvent struct {
-	MatchID        uuid.UUID       `json:"match_id"`
-	League         string          `json:"league"`
-	PlayerCount    int             `json:"player_count"`
-	BuyinAmount    decimal.Decimal `json:"buyin_amount"`
-	PrizePool      decimal.Decimal `json:"prize_pool"`
-	CountdownStart time.Time       `json:"countdown_start"`
+	MatchID        uuid.UUID      `json:"match_id"`
+	League         string         `json:"league"`
+	PlayerCount    int            `json:"player_count"`
+	BuyinAmount    monetary.Money `json:"buyin_amount"`
+	PrizePool      monetary.Money `json:"prize_pool"`
+	CountdownStart time.Time      `json:"countdown_start"`
+}
+
+// MatchmakingTimeoutEvent is published to user:{user_id} when a player is dropped from a ghost-free
+// league's queue after waiting out the matchmaking timeout without a full grid forming
+type MatchmakingTimeoutEvent struct {
+	League        string `json:"league"`
+	WaitedSeconds int    `json:"waited_seconds"`
 }
 
 // HeatStartedEvent is published to match:{match_id} when a heat begins
@@ -36,6 +54,24 @@ type HeatStartedEvent struct {
 	Participants []ParticipantInfo `json:"participants"`
 }
 
+// CountdownTickEvent is published to match:{match_id} every second of a heat's countdown
+type CountdownTickEvent struct {
+	MatchID   uuid.UUID `json:"match_id"`
+	Heat      int       `json:"heat"`      // 1, 2, or 3
+	Remaining int       `json:"remaining"` // Seconds left until the heat goes active (3, 2, 1)
+	ActiveAt  time.Time `json:"active_at"` // When the heat goes active
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// HeatTickEvent is published to match:{match_id} periodically while a heat is active
+type HeatTickEvent struct {
+	MatchID     uuid.UUID       `json:"match_id"`
+	Heat        int             `json:"heat"`         // 1, 2, or 3
+	ElapsedTime float64         `json:"elapsed_time"` // Seconds since the heat went active
+	MaxSpeed    decimal.Decimal `json:"max_speed"`    // Maximum achievable speed at this time
+	Timestamp   time.Time       `json:"timestamp"`
+}
+
 // HeatEndedEvent is published to match:{match_id} when a heat ends
 type HeatEndedEvent struct {
 	MatchID        uuid.UUID       `json:"match_id"`
@@ -47,6 +83,14 @@ type HeatEndedEvent struct {
 	Standings      []StandingEntry `json:"standings"`       // Current standings after this heat
 }
 
+// IntermissionStartedEvent is published to match:{match_id} when the break between two heats begins
+type IntermissionStartedEvent struct {
+	MatchID   uuid.UUID `json:"match_id"`
+	NextHeat  int       `json:"next_heat"`  // 2 or 3
+	Duration  float64   `json:"duration"`   // Intermission duration in seconds
+	StartTime time.Time `json:"start_time"` // When the intermission started
+}
+
 // MatchSettledEvent is published to match:{match_id} when the match is complete
 type MatchSettledEvent struct {
 	MatchID           uuid.UUID       `json:"match_id"`
@@ -58,15 +102,23 @@ type MatchSettledEvent struct {
 	CrashSeedHash     string          `json:"crash_seed_hash"` // Original hash for verification
 }
 
+// MatchAbortedEvent is published to match:{match_id} when a match is aborted and buy-ins are refunded
+type MatchAbortedEvent struct {
+	MatchID   uuid.UUID     `json:"match_id"`
+	Reason    string        `json:"reason"`
+	AbortedAt time.Time     `json:"aborted_at"`
+	Refunds   []RefundEntry `json:"refunds"`
+}
+
 // BalanceUpdatedEvent is published to user:{user_id} when balance changes
 type BalanceUpdatedEvent struct {
-	UserID      uuid.UUID       `json:"user_id"`
-	TONBalance  decimal.Decimal `json:"ton_balance"`
-	FuelBalance decimal.Decimal `json:"fuel_balance"`
-	BurnBalance decimal.Decimal `json:"burn_balance"`
-	Changes     BalanceChanges  `json:"changes"`
-	Reason      string          `json:"reason"`                 // "match_settlement", "deposit", etc.
-	ReferenceID *uuid.UUID      `json:"reference_id,omitempty"` // Match ID, payment ID, etc.
+	UserID      uuid.UUID      `json:"user_id"`
+	TONBalance  monetary.Money `json:"ton_balance"`
+	FuelBalance monetary.Money `json:"fuel_balance"`
+	BurnBalance monetary.Money `json:"burn_balance"`
+	Changes     BalanceChanges `json:"changes"`
+	Reason      string         `json:"reason"`                 // "match_settlement", "deposit", etc.
+	ReferenceID *uuid.UUID     `json:"reference_id,omitempty"` // Match ID, payment ID, etc.
 }
 
 // Supporting data structures
@@ -112,23 +164,33 @@ type FinalStanding struct {
 	Heat1Score    decimal.Decimal `json:"heat1_score"`
 	Heat2Score    decimal.Decimal `json:"heat2_score"`
 	Heat3Score    decimal.Decimal `json:"heat3_score"`
-	PrizeAmount   decimal.Decimal `json:"prize_amount"` // FUEL won
-	BurnReward    decimal.Decimal `json:"burn_reward"`  // BURN earned
+	Heat1LockTime *float64        `json:"heat1_lock_time,omitempty"` // Seconds into the heat, null if not locked
+	Heat2LockTime *float64        `json:"heat2_lock_time,omitempty"`
+	Heat3LockTime *float64        `json:"heat3_lock_time,omitempty"`
+	PrizeAmount   monetary.Money  `json:"prize_amount"` // FUEL won
+	BurnReward    monetary.Money  `json:"burn_reward"`  // BURN earned
 }
 
 // PrizeEntry represents prize distribution
 type PrizeEntry struct {
-	Position    int             `json:"position"`
-	UserID      *uuid.UUID      `json:"user_id,omitempty"` // Null for ghosts
-	DisplayName string          `json:"display_name"`
-	IsGhost     bool            `json:"is_ghost"`
-	PrizeAmount decimal.Decimal `json:"prize_amount"`
-	BurnReward  decimal.Decimal `json:"burn_reward"`
+	Position    int            `json:"position"`
+	UserID      *uuid.UUID     `json:"user_id,omitempty"` // Null for ghosts
+	DisplayName string         `json:"display_name"`
+	IsGhost     bool           `json:"is_ghost"`
+	PrizeAmount monetary.Money `json:"prize_amount"`
+	BurnReward  monetary.Money `json:"burn_reward"`
 }
 
 // BalanceChanges represents changes to user balances
 type BalanceChanges struct {
-	TONDelta  decimal.Decimal `json:"ton_delta"`
-	FuelDelta decimal.Decimal `json:"fuel_delta"`
-	BurnDelta decimal.Decimal `json:"burn_delta"`
+	TONDelta  monetary.Money `json:"ton_delta"`
+	FuelDelta monetary.Money `json:"fuel_delta"`
+	BurnDelta monetary.Money `json:"burn_delta"`
+}
+
+// RefundEntry represents a buy-in returned to a player
+type RefundEntry struct {
+	UserID   uuid.UUID      `json:"user_id"`
+	Currency string         `json:"currency"`
+	Amount   monetary.Money `json:"amount"`
 }
@@ -0,0 +1,56 @@
+package events
+
+// Schema versions of every event payload, sent as the envelope's version field.
+// Bump an event's version whenever its payload shape changes in a way older clients
+// cannot read (a field removed, renamed or retyped); adding an optional field does not
+// need a bump. Clients must ignore versions of an event they do not understand.
+const (
+	EventMatchFoundVersion          = 1
+	EventHeatStartedVersion         = 1
+	EventCountdownTickVersion       = 1
+	EventHeatTickVersion            = 1
+	EventHeatEndedVersion           = 1
+	EventIntermissionStartedVersion = 1
+	EventMatchSettledVersion        = 1
+	EventMatchAbortedVersion        = 1
+	EventBalanceUpdatedVersion      = 1
+	EventMatchmakingTimeoutVersion  = 1
+)
+
+// DefaultEventVersion is the version of event types without a registered schema version
+const DefaultEventVersion = 1
+
+// eventVersions maps every event type to its current schema version
+var eventVersions = map[string]int{
+	EventMatchFound:          EventMatchFoundVersion,
+	EventHeatStarted:         EventHeatStartedVersion,
+	EventCountdownTick:       EventCountdownTickVersion,
+	EventHeatTick:            EventHeatTickVersion,
+	EventHeatEnded:           EventHeatEndedVersion,
+	EventIntermissionStarted: EventIntermissionStartedVersion,
+	EventMatchSettled:        EventMatchSettledVersion,
+	EventMatchAborted:        EventMatchAbortedVersion,
+	EventBalanceUpdated:      EventBalanceUpdatedVersion,
+	EventMatchmakingTimeout:  EventMatchmakingTimeoutVersion,
+}
+
+// VersionOf returns the current schema version of an event type
+func VersionOf(eventType string) int {
+	if version, ok := eventVersions[eventType]; ok {
+		return version
+	}
+	return DefaultEventVersion
+}
+
+// IsKnownEventType reports whether an event type has a registered schema version
+func IsKnownEventType(eventType string) bool {
+	_, ok := eventVersions[eventType]
+	return ok
+}
+
+// PreviousVersionPayload is implemented by payloads whose previous schema version is still
+// published during a rollout, so clients that have not upgraded keep receiving a shape they read.
+// PreviousVersion returns that schema version and the payload converted to its shape.
+type PreviousVersionPayload interface {
+	PreviousVersion() (version int, data interface{})
+}
@@ -0,0 +1,804 @@
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/metrics"
+	"github.com/megaherz/ndr/internal/modules/account"
+	"github.com/megaherz/ndr/internal/modules/gameengine"
+	"github.com/megaherz/ndr/internal/modules/gateway/events"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// stubMatchRepository serves a single match and records status changes
+type stubMatchRepository struct {
+	repository.MatchRepository
+	match *models.Match
+}
+
+func (r *stubMatchRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Match, error) {
+	if r.match == nil || r.match.ID != id {
+		return nil, nil
+	}
+	return r.match, nil
+}
+
+func (r *stubMatchRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	r.match.Status = models.MatchStatus(status)
+	return nil
+}
+
+// stubLedgerRepository serves fixed match entries and records new ones, accumulating their FUEL
+// balance changes per user and applying status changes to match, or fails with createErr
+type stubLedgerRepository struct {
+	repository.LedgerRepository
+	match        *models.Match
+	matchEntries []*models.LedgerEntry
+	created      []*models.LedgerEntry
+	allEntries   []*models.LedgerEntry
+	fuelDeltas   map[uuid.UUID]decimal.Decimal
+	createErr    error
+}
+
+func (r *stubLedgerRepository) GetMatchEntries(ctx context.Context, matchID uuid.UUID) ([]*models.LedgerEntry, error) {
+	return append(r.matchEntries, r.created...), nil
+}
+
+func (r *stubLedgerRepository) StreamEntries(ctx context.Context, from, to time.Time, fn func(*models.LedgerEntry) error) error {
+	for _, entry := range r.allEntries {
+		if entry.CreatedAt.Before(from) || !entry.CreatedAt.Before(to) {
+			continue
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *stubLedgerRepository) CreateMatchEntriesWithStatus(ctx context.Context, change repository.MatchStatusChange, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error) {
+	if r.createErr != nil {
+		return nil, r.createErr
+	}
+	if !slices.Contains(change.From, r.match.Status) {
+		return nil, fmt.Errorf("%w: match is %s", repository.ErrMatchStatusChanged, r.match.Status)
+	}
+	r.match.Status = change.To
+	r.created = append(r.created, entries...)
+
+	wallets := make(map[uuid.UUID]*models.Wallet)
+	for _, entry := range entries {
+		if entry.UserID == nil {
+			continue
+		}
+		r.fuelDeltas[*entry.UserID] = r.fuelDeltas[*entry.UserID].Add(entry.Amount)
+		wallets[*entry.UserID] = &models.Wallet{UserID: *entry.UserID, FuelBalance: r.fuelDeltas[*entry.UserID]}
+	}
+	return wallets, nil
+}
+
+// stubPublisher records published event types per match
+type stubPublisher struct {
+	mu     sync.Mutex
+	events map[uuid.UUID][]string
+}
+
+func (p *stubPublisher) PublishToUser(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) error {
+	return nil
+}
+
+func (p *stubPublisher) PublishToMatch(ctx context.Context, matchID uuid.UUID, eventType string, data interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events[matchID] = append(p.events[matchID], eventType)
+	return nil
+}
+
+func (p *stubPublisher) PublishToUsers(ctx context.Context, userIDs []uuid.UUID, eventType string, data interface{}) error {
+	return nil
+}
+
+func (p *stubPublisher) BroadcastToChannel(ctx context.Context, channel string, eventType string, data interface{}) error {
+	return nil
+}
+
+func (p *stubPublisher) published(matchID uuid.UUID) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.events[matchID]...)
+}
+
+// abortFixture wires the real game engine components around stub repositories
+type abortFixture struct {
+	router       chi.Router
+	match        *models.Match
+	players      []uuid.UUID
+	stateManager gameengine.MatchStateManager
+	heatManager  gameengine.HeatManager
+	ledgerRepo   *stubLedgerRepository
+	publisher    *stubPublisher
+	metrics      *metrics.Metrics
+}
+
+func newAbortFixture(t *testing.T, status models.MatchStatus) *abortFixture {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	match := &models.Match{ID: uuid.New(), League: constants.LeagueStreet, Status: status}
+	buyin, _ := constants.DefaultLeagues().Buyin(constants.LeagueStreet)
+	houseWallet := constants.SystemWalletHouseFuel
+
+	fixture := &abortFixture{
+		match:      match,
+		ledgerRepo: &stubLedgerRepository{match: match, fuelDeltas: make(map[uuid.UUID]decimal.Decimal)},
+		publisher:  &stubPublisher{events: make(map[uuid.UUID][]string)},
+		metrics:    metrics.NewWithRegistry(prometheus.NewRegistry()),
+	}
+
+	players := make([]*gameengine.MatchPlayer, 0, 3)
+	for i := 0; i < 2; i++ {
+		userID := uuid.New()
+		fixture.players = append(fixture.players, userID)
+		players = append(players, &gameengine.MatchPlayer{UserID: &userID, DisplayName: "Racer", BuyinAmount: buyin})
+		fixture.ledgerRepo.matchEntries = append(fixture.ledgerRepo.matchEntries, &models.LedgerEntry{
+			UserID:        &userID,
+			Currency:      constants.CurrencyFUEL,
+			Amount:        buyin.Neg(),
+			OperationType: constants.OperationMatchBuyin,
+			ReferenceID:   &match.ID,
+		})
+	}
+	fixture.ledgerRepo.matchEntries = append(fixture.ledgerRepo.matchEntries, &models.LedgerEntry{
+		SystemWallet:  &houseWallet,
+		Currency:      constants.CurrencyFUEL,
+		Amount:        buyin.Neg(),
+		OperationType: constants.OperationMatchBuyin,
+		ReferenceID:   &match.ID,
+	})
+
+	matchRepo := &stubMatchRepository{match: match}
+	fixture.stateManager = gameengine.NewMatchStateManager(logger)
+	require.NoError(t, fixture.stateManager.CreateMatchState(context.Background(), match.ID, constants.LeagueStreet, players))
+	fixture.heatManager = gameengine.NewHeatManager(fixture.stateManager, fixture.publisher, logger)
+
+	ledgerOps := account.NewLedgerOperations(fixture.ledgerRepo, nil, logger)
+	settlement := gameengine.NewSettlementService(matchRepo, nil, nil, fixture.ledgerRepo, ledgerOps, fixture.stateManager, fixture.publisher, logger)
+	aborter := gameengine.NewMatchAborter(matchRepo, fixture.heatManager, fixture.stateManager, settlement, fixture.publisher, logger,
+		gameengine.WithAbortMetrics(fixture.metrics))
+
+	fixture.router = chi.NewRouter()
+	NewAdminHandler(aborter, fixture.ledgerRepo, nil, logger).RegisterRoutes(fixture.router)
+	return fixture
+}
+
+func TestAbortMatch_RefundsAndCleansUpState(t *testing.T) {
+	fixture := newAbortFixture(t, models.MatchStatusInProgress)
+	ctx := context.Background()
+	matchID := fixture.match.ID
+
+	// A heat countdown leaves a pending transition that the abort must cancel
+	require.NoError(t, fixture.heatManager.StartHeatCountdown(ctx, matchID, 1))
+
+	rec := serveAs(fixture.router, http.MethodPost, "/admin/matches/"+matchID.String()+"/abort", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Match is marked aborted
+	assert.Equal(t, models.MatchStatusAborted, fixture.match.Status)
+
+	// Every live player got their buy-in back and the ghost buy-in returned to HOUSE_FUEL
+	buyin, _ := constants.DefaultLeagues().Buyin(constants.LeagueStreet)
+	for _, userID := range fixture.players {
+		assert.True(t, buyin.Equal(fixture.ledgerRepo.fuelDeltas[userID]), "player %s was not refunded", userID)
+	}
+	require.Len(t, fixture.ledgerRepo.created, 3)
+	for _, entry := range fixture.ledgerRepo.created {
+		assert.Equal(t, models.OperationType(constants.OperationMatchRefund), entry.OperationType)
+		assert.True(t, buyin.Equal(entry.Amount))
+	}
+
+	// In-memory state is gone
+	_, err := fixture.stateManager.GetMatchState(ctx, matchID)
+	assert.Error(t, err)
+
+	// Players are told the match was aborted
+	assert.Contains(t, fixture.publisher.published(matchID), events.EventMatchAborted)
+}
+
+func TestAbortMatch_RecordsMatchDuration(t *testing.T) {
+	fixture := newAbortFixture(t, models.MatchStatusInProgress)
+	startedAt := time.Now().Add(-2 * time.Minute)
+	fixture.match.StartedAt = &startedAt
+
+	rec := serveAs(fixture.router, http.MethodPost, "/admin/matches/"+fixture.match.ID.String()+"/abort", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 1, testutil.CollectAndCount(fixture.metrics.MatchDuration))
+}
+
+func TestAbortMatch_IsNotRepeatable(t *testing.T) {
+	fixture := newAbortFixture(t, models.MatchStatusInProgress)
+	path := "/admin/matches/" + fixture.match.ID.String() + "/abort"
+
+	rec := serveAs(fixture.router, http.MethodPost, path, uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveAs(fixture.router, http.MethodPost, path, uuid.New())
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Len(t, fixture.ledgerRepo.created, 3, "refunds must only be issued once")
+}
+
+func TestAbortMatch_RetriesAfterFailedRefund(t *testing.T) {
+	fixture := newAbortFixture(t, models.MatchStatusInProgress)
+	path := "/admin/matches/" + fixture.match.ID.String() + "/abort"
+	fixture.ledgerRepo.createErr = errors.New("connection reset")
+
+	rec := serveAs(fixture.router, http.MethodPost, path, uuid.New())
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, models.MatchStatusInProgress, fixture.match.Status, "a failed refund must leave the match abortable")
+
+	// Once the ledger is back the operator's retry refunds every buy-in
+	fixture.ledgerRepo.createErr = nil
+	rec = serveAs(fixture.router, http.MethodPost, path, uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, models.MatchStatusAborted, fixture.match.Status)
+	assert.Len(t, fixture.ledgerRepo.created, 3)
+}
+
+func TestAbortMatch_RefusesPaidOutMatch(t *testing.T) {
+	fixture := newAbortFixture(t, models.MatchStatusInProgress)
+	winner := fixture.players[0]
+	fixture.ledgerRepo.matchEntries = append(fixture.ledgerRepo.matchEntries, &models.LedgerEntry{
+		UserID:        &winner,
+		Currency:      constants.CurrencyFUEL,
+		Amount:        decimal.NewFromInt(92),
+		OperationType: constants.OperationMatchPrize,
+		ReferenceID:   &fixture.match.ID,
+	})
+
+	// A settlement stopped after paying prizes: refunding the buy-ins as well would create FUEL
+	rec := serveAs(fixture.router, http.MethodPost, "/admin/matches/"+fixture.match.ID.String()+"/abort", uuid.New())
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Empty(t, fixture.ledgerRepo.created)
+	assert.Equal(t, models.MatchStatusInProgress, fixture.match.Status)
+}
+
+func TestAbortMatch_CompletedMatch(t *testing.T) {
+	fixture := newAbortFixture(t, models.MatchStatusCompleted)
+
+	rec := serveAs(fixture.router, http.MethodPost, "/admin/matches/"+fixture.match.ID.String()+"/abort", uuid.New())
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Empty(t, fixture.ledgerRepo.created)
+}
+
+func TestAbortMatch_NotFound(t *testing.T) {
+	fixture := newAbortFixture(t, models.MatchStatusInProgress)
+
+	rec := serveAs(fixture.router, http.MethodPost, "/admin/matches/"+uuid.New().String()+"/abort", uuid.New())
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAbortMatch_InvalidBody(t *testing.T) {
+	fixture := newAbortFixture(t, models.MatchStatusInProgress)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/matches/"+fixture.match.ID.String()+"/abort", strings.NewReader("{"))
+	req = req.WithContext(WithUserID(req.Context(), uuid.New()))
+	rec := httptest.NewRecorder()
+	fixture.router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, models.MatchStatusInProgress, fixture.match.Status)
+}
+
+func newLedgerExportRouter(entries []*models.LedgerEntry) chi.Router {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	r := chi.NewRouter()
+	NewAdminHandler(nil, &stubLedgerRepository{allEntries: entries}, nil, logger).RegisterRoutes(r)
+	return r
+}
+
+func newExportEntries() []*models.LedgerEntry {
+	userID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	matchID := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	houseWallet := constants.SystemWalletHouseFuel
+	description := "Street buy-in, heat 1"
+
+	return []*models.LedgerEntry{
+		{ID: 1, UserID: &userID, Currency: constants.CurrencyFUEL, Amount: decimal.RequireFromString("50"),
+			OperationType: constants.OperationInitialBalance, CreatedAt: time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC)},
+		{ID: 2, UserID: &userID, Currency: constants.CurrencyFUEL, Amount: decimal.RequireFromString("-10"),
+			OperationType: constants.OperationMatchBuyin, ReferenceID: &matchID, Description: &description,
+			CreatedAt: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: 3, SystemWallet: &houseWallet, Currency: constants.CurrencyFUEL, Amount: decimal.RequireFromString("0.8"),
+			OperationType: constants.OperationMatchRake, ReferenceID: &matchID, CreatedAt: time.Date(2026, 4, 2, 12, 30, 0, 0, time.UTC)},
+		{ID: 4, UserID: &userID, Currency: constants.CurrencyTON, Amount: decimal.RequireFromString("1.5"),
+			OperationType: constants.OperationDeposit, CreatedAt: time.Date(2026, 4, 3, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+func TestExportLedger_CSV(t *testing.T) {
+	router := newLedgerExportRouter(newExportEntries())
+
+	rec := serveAs(router, http.MethodGet, "/admin/ledger/export?from=2026-04-01&to=2026-04-02&format=csv", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "ledger_2026-04-01_2026-04-03.csv")
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3, "header plus the two entries inside the range")
+
+	assert.Equal(t, []string{
+		"id", "user_id", "system_wallet", "currency", "amount",
+		"operation_type", "reference_id", "description", "created_at",
+	}, rows[0])
+	assert.Equal(t, []string{
+		"2", "11111111-1111-1111-1111-111111111111", "", "FUEL", "-10.00",
+		"MATCH_BUYIN", "22222222-2222-2222-2222-222222222222", "Street buy-in, heat 1", "2026-04-01T00:00:00Z",
+	}, rows[1])
+	assert.Equal(t, []string{
+		"3", "", constants.SystemWalletHouseFuel, "FUEL", "0.80",
+		"MATCH_RAKE", "22222222-2222-2222-2222-222222222222", "", "2026-04-02T12:30:00Z",
+	}, rows[2])
+}
+
+func TestExportLedger_TimestampRangeIsExclusive(t *testing.T) {
+	router := newLedgerExportRouter(newExportEntries())
+
+	rec := serveAs(router, http.MethodGet, "/admin/ledger/export?from=2026-03-31T23:59:59Z&to=2026-04-03T00:00:00Z", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		ids = append(ids, row[0])
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+}
+
+func TestExportLedger_JSON(t *testing.T) {
+	router := newLedgerExportRouter(newExportEntries())
+
+	rec := serveAs(router, http.MethodGet, "/admin/ledger/export?from=2026-04-03&to=2026-04-03&format=json", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var records []map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &records))
+	require.Len(t, records, 1)
+	assert.Equal(t, "4", records[0]["id"])
+	assert.Equal(t, "TON", records[0]["currency"])
+	assert.Equal(t, "1.50", records[0]["amount"])
+}
+
+func TestExportLedger_RejectsInvalidParameters(t *testing.T) {
+	router := newLedgerExportRouter(nil)
+
+	for _, query := range []string{
+		"to=2026-04-01",
+		"from=2026-04-01",
+		"from=yesterday&to=2026-04-01",
+		"from=2026-04-02&to=2026-04-01",
+		"from=2026-04-01&to=2026-04-02&format=xlsx",
+	} {
+		rec := serveAs(router, http.MethodGet, "/admin/ledger/export?"+query, uuid.New())
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func (r *stubUserRepository) BanUser(ctx context.Context, userID uuid.UUID, reason string) error {
+	user, ok := r.users[userID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	if user.BannedAt == nil {
+		now := time.Now()
+		user.BannedAt = &now
+	}
+	user.BannedReason = &reason
+	return nil
+}
+
+func (r *stubUserRepository) UnbanUser(ctx context.Context, userID uuid.UUID) error {
+	user, ok := r.users[userID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	user.BannedAt, user.BannedReason = nil, nil
+	return nil
+}
+
+func newUserBanRouter(users ...*models.User) chi.Router {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	repo := &stubUserRepository{users: make(map[uuid.UUID]*models.User)}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+
+	r := chi.NewRouter()
+	NewAdminHandler(nil, nil, repo, logger).RegisterRoutes(r)
+	return r
+}
+
+// serveJSONAs sends a request with a JSON body as the given user
+func serveJSONAs(router http.Handler, method, path, body string, userID uuid.UUID) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(WithUserID(req.Context(), userID))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBanUser_BansAndUnbans(t *testing.T) {
+	user := &models.User{ID: uuid.New(), TelegramFirstName: "Cheater"}
+	router := newUserBanRouter(user)
+	adminID := uuid.New()
+
+	rec := serveJSONAs(router, http.MethodPost, "/admin/users/"+user.ID.String()+"/ban", `{"reason":"botting"}`, adminID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data models.User `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.NotNil(t, response.Data.BannedAt)
+	assert.Equal(t, "botting", *response.Data.BannedReason)
+	assert.True(t, user.IsBanned())
+
+	rec = serveAs(router, http.MethodPost, "/admin/users/"+user.ID.String()+"/unban", adminID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.False(t, user.IsBanned())
+	assert.Nil(t, user.BannedReason)
+}
+
+func TestBanUser_RejectsInvalidRequests(t *testing.T) {
+	user := &models.User{ID: uuid.New()}
+	adminID := uuid.New()
+	router := newUserBanRouter(user, &models.User{ID: adminID})
+	banPath := "/admin/users/" + user.ID.String() + "/ban"
+
+	rec := serveJSONAs(router, http.MethodPost, banPath, `{"reason":"  "}`, adminID)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSONAs(router, http.MethodPost, "/admin/users/not-a-uuid/ban", `{"reason":"botting"}`, adminID)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSONAs(router, http.MethodPost, "/admin/users/"+adminID.String()+"/ban", `{"reason":"oops"}`, adminID)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSONAs(router, http.MethodPost, "/admin/users/"+uuid.New().String()+"/ban", `{"reason":"botting"}`, adminID)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveAs(router, http.MethodPost, "/admin/users/"+uuid.New().String()+"/unban", adminID)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.False(t, user.IsBanned())
+}
+
+// stubAdjustmentLedger applies FUEL adjustments and their audit records to in-memory state,
+// refusing overdrafts and, like the database transaction, keeping nothing when the audit fails
+type stubAdjustmentLedger struct {
+	account.LedgerOperations
+	balances map[uuid.UUID]decimal.Decimal
+	entries  []*models.LedgerEntry
+	audits   []*models.AdminAuditEntry
+	auditErr error
+}
+
+func (l *stubAdjustmentLedger) AdjustFuel(ctx context.Context, userID uuid.UUID, amount decimal.Decimal, description string, audit *models.AdminAuditEntry, details func(*models.Wallet) (json.RawMessage, error)) (decimal.Decimal, error) {
+	balance, ok := l.balances[userID]
+	if !ok {
+		return decimal.Zero, account.ErrWalletNotFound
+	}
+	if balance.Add(amount).IsNegative() {
+		return decimal.Zero, account.ErrInsufficientBalance
+	}
+	if l.auditErr != nil {
+		return decimal.Zero, l.auditErr
+	}
+
+	var err error
+	if audit.Details, err = details(&models.Wallet{UserID: userID, FuelBalance: balance.Add(amount)}); err != nil {
+		return decimal.Zero, err
+	}
+	audit.ID = int64(len(l.audits) + 1)
+	audit.CreatedAt = time.Now()
+	l.audits = append(l.audits, audit)
+
+	l.balances[userID] = balance.Add(amount)
+	l.entries = append(l.entries, &models.LedgerEntry{
+		UserID:        &userID,
+		Currency:      constants.CurrencyFUEL,
+		Amount:        amount,
+		OperationType: models.OperationType(constants.OperationAdminAdjustment),
+		Description:   &description,
+	})
+	return l.balances[userID], nil
+}
+
+func newBalanceAdjustmentRouter(balances map[uuid.UUID]decimal.Decimal) (chi.Router, *stubAdjustmentLedger) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	ledger := &stubAdjustmentLedger{balances: balances}
+
+	r := chi.NewRouter()
+	NewAdminHandler(nil, nil, nil, logger, WithBalanceAdjustments(ledger, decimal.NewFromInt(500))).RegisterRoutes(r)
+	return r, ledger
+}
+
+func TestAdjustBalance_CreditsAndRecordsAudit(t *testing.T) {
+	userID, adminID := uuid.New(), uuid.New()
+	router, ledger := newBalanceAdjustmentRouter(map[uuid.UUID]decimal.Decimal{userID: decimal.NewFromInt(20)})
+
+	rec := serveJSONAs(router, http.MethodPost, "/admin/users/"+userID.String()+"/adjust",
+		`{"amount":"150.50","reason":" compensation for crashed match "}`, adminID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data AdjustBalanceResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.Data.FuelBalance.Equal(decimal.RequireFromString("170.50")))
+	assert.Contains(t, rec.Body.String(), `"fuel_balance":"170.50"`)
+	require.NotNil(t, response.Data.Audit)
+	assert.Equal(t, int64(1), response.Data.Audit.ID)
+
+	require.Len(t, ledger.entries, 1)
+	assert.True(t, ledger.entries[0].Amount.Equal(decimal.RequireFromString("150.50")))
+	assert.Equal(t, constants.OperationAdminAdjustment, string(ledger.entries[0].OperationType))
+
+	require.Len(t, ledger.audits, 1)
+	record := ledger.audits[0]
+	assert.Equal(t, adminID, record.AdminID)
+	assert.Equal(t, userID, record.TargetUserID)
+	assert.Equal(t, models.AdminActionAdjustBalance, record.Action)
+	assert.Equal(t, "compensation for crashed match", record.Reason)
+
+	var details adjustmentAuditDetails
+	require.NoError(t, json.Unmarshal(record.Details, &details))
+	assert.Equal(t, constants.CurrencyFUEL, details.Currency)
+	assert.True(t, details.Amount.Equal(decimal.RequireFromString("150.50")))
+	assert.True(t, details.BalanceAfter.Equal(decimal.RequireFromString("170.50")))
+}
+
+func TestAdjustBalance_Debits(t *testing.T) {
+	userID := uuid.New()
+	router, ledger := newBalanceAdjustmentRouter(map[uuid.UUID]decimal.Decimal{userID: decimal.NewFromInt(100)})
+
+	rec := serveJSONAs(router, http.MethodPost, "/admin/users/"+userID.String()+"/adjust",
+		`{"amount":-40,"reason":"duplicate deposit"}`, uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.True(t, ledger.balances[userID].Equal(decimal.NewFromInt(60)))
+	require.Len(t, ledger.entries, 1)
+	assert.True(t, ledger.entries[0].Amount.Equal(decimal.NewFromInt(-40)))
+	require.Len(t, ledger.audits, 1)
+	assert.Equal(t, "duplicate deposit", ledger.audits[0].Reason)
+
+	// Debits never overdraw the wallet
+	rec = serveJSONAs(router, http.MethodPost, "/admin/users/"+userID.String()+"/adjust",
+		`{"amount":-60.01,"reason":"duplicate deposit"}`, uuid.New())
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.True(t, ledger.balances[userID].Equal(decimal.NewFromInt(60)))
+	assert.Len(t, ledger.audits, 1)
+}
+
+func TestAdjustBalance_RejectsAmountsOverLimit(t *testing.T) {
+	userID := uuid.New()
+	router, ledger := newBalanceAdjustmentRouter(map[uuid.UUID]decimal.Decimal{userID: decimal.NewFromInt(1000)})
+	path := "/admin/users/" + userID.String() + "/adjust"
+
+	for _, body := range []string{`{"amount":500.01,"reason":"bonus"}`, `{"amount":-501,"reason":"clawback"}`} {
+		rec := serveJSONAs(router, http.MethodPost, path, body, uuid.New())
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	assert.Empty(t, ledger.entries)
+	assert.Empty(t, ledger.audits)
+
+	// The limit itself is allowed
+	rec := serveJSONAs(router, http.MethodPost, path, `{"amount":-500,"reason":"clawback"}`, uuid.New())
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestAdjustBalance_RejectsInvalidRequests(t *testing.T) {
+	userID := uuid.New()
+	router, ledger := newBalanceAdjustmentRouter(map[uuid.UUID]decimal.Decimal{userID: decimal.NewFromInt(100)})
+	path := "/admin/users/" + userID.String() + "/adjust"
+
+	for _, body := range []string{
+		`{"amount":10,"reason":"  "}`,
+		`{"amount":0,"reason":"nothing"}`,
+		`{"amount":"1.005","reason":"fractions"}`,
+		`{"amount":"ten","reason":"bonus"}`,
+	} {
+		rec := serveJSONAs(router, http.MethodPost, path, body, uuid.New())
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+
+	rec := serveJSONAs(router, http.MethodPost, "/admin/users/not-a-uuid/adjust", `{"amount":10,"reason":"bonus"}`, uuid.New())
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSONAs(router, http.MethodPost, "/admin/users/"+uuid.New().String()+"/adjust", `{"amount":10,"reason":"bonus"}`, uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	assert.Empty(t, ledger.entries)
+	assert.Empty(t, ledger.audits)
+}
+
+// walletLedgerRepository applies user entries to in-memory FUEL balances and, like the database
+// transaction, records nothing when a wallet is missing or would be overdrawn
+type walletLedgerRepository struct {
+	repository.LedgerRepository
+	balances map[uuid.UUID]decimal.Decimal
+	entries  []*models.LedgerEntry
+	audits   []*models.AdminAuditEntry
+}
+
+func (r *walletLedgerRepository) CreateEntriesWithBalances(ctx context.Context, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error) {
+	balances := make(map[uuid.UUID]decimal.Decimal)
+	for _, entry := range entries {
+		balance, ok := balances[*entry.UserID]
+		if !ok {
+			if balance, ok = r.balances[*entry.UserID]; !ok {
+				return nil, repository.ErrWalletNotFound
+			}
+		}
+		if balance.Add(entry.Amount).IsNegative() {
+			return nil, repository.ErrNegativeBalance
+		}
+		balances[*entry.UserID] = balance.Add(entry.Amount)
+	}
+
+	wallets := make(map[uuid.UUID]*models.Wallet)
+	for userID, balance := range balances {
+		r.balances[userID] = balance
+		wallets[userID] = &models.Wallet{UserID: userID, FuelBalance: balance}
+	}
+	r.entries = append(r.entries, entries...)
+	return wallets, nil
+}
+
+func (r *walletLedgerRepository) CreateEntryWithAudit(ctx context.Context, entry *models.LedgerEntry, audit *models.AdminAuditEntry, details func(*models.Wallet) (json.RawMessage, error)) (*models.Wallet, error) {
+	wallets, err := r.CreateEntriesWithBalances(ctx, []*models.LedgerEntry{entry})
+	if err != nil {
+		return nil, err
+	}
+	if audit.Details, err = details(wallets[*entry.UserID]); err != nil {
+		return nil, err
+	}
+	r.audits = append(r.audits, audit)
+	return wallets[*entry.UserID], nil
+}
+
+func TestAdjustBalance_RejectedDebitLeavesLedgerUnchanged(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	userID := uuid.New()
+	ledgerRepo := &walletLedgerRepository{balances: map[uuid.UUID]decimal.Decimal{userID: decimal.NewFromInt(30)}}
+	ledgerOps := account.NewLedgerOperations(ledgerRepo, nil, logger)
+
+	router := chi.NewRouter()
+	NewAdminHandler(nil, nil, nil, logger, WithBalanceAdjustments(ledgerOps, decimal.NewFromInt(500))).RegisterRoutes(router)
+
+	rec := serveJSONAs(router, http.MethodPost, "/admin/users/"+userID.String()+"/adjust",
+		`{"amount":-30.01,"reason":"clawback"}`, uuid.New())
+	assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+
+	rec = serveJSONAs(router, http.MethodPost, "/admin/users/"+uuid.New().String()+"/adjust",
+		`{"amount":-5,"reason":"clawback"}`, uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+	// Neither rejection wrote an entry or touched the balance
+	assert.Empty(t, ledgerRepo.entries)
+	assert.True(t, ledgerRepo.balances[userID].Equal(decimal.NewFromInt(30)))
+	assert.Empty(t, ledgerRepo.audits)
+
+	rec = serveJSONAs(router, http.MethodPost, "/admin/users/"+userID.String()+"/adjust",
+		`{"amount":-30,"reason":"clawback"}`, uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Len(t, ledgerRepo.entries, 1)
+	assert.True(t, ledgerRepo.balances[userID].IsZero())
+	require.Len(t, ledgerRepo.audits, 1)
+	assert.Equal(t, "clawback", ledgerRepo.audits[0].Reason)
+}
+
+func TestAdjustBalance_FailedAuditWriteFailsRequest(t *testing.T) {
+	userID := uuid.New()
+	router, ledger := newBalanceAdjustmentRouter(map[uuid.UUID]decimal.Decimal{userID: decimal.NewFromInt(100)})
+	ledger.auditErr = errors.New("audit log unavailable")
+
+	rec := serveJSONAs(router, http.MethodPost, "/admin/users/"+userID.String()+"/adjust",
+		`{"amount":25,"reason":"bonus"}`, uuid.New())
+	assert.Equal(t, http.StatusInternalServerError, rec.Code, rec.Body.String())
+
+	// The adjustment rolled back with its audit record
+	assert.Empty(t, ledger.entries)
+	assert.Empty(t, ledger.audits)
+	assert.True(t, ledger.balances[userID].Equal(decimal.NewFromInt(100)))
+}
+
+func newAdminSeasonRouter(repo *stubSeasonRepository) chi.Router {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	r := chi.NewRouter()
+	NewAdminHandler(nil, nil, nil, logger, WithSeasons(repo)).RegisterRoutes(r)
+	return r
+}
+
+func TestCreateSeason_StartsAndClosesSeasons(t *testing.T) {
+	repo := &stubSeasonRepository{}
+	router := newAdminSeasonRouter(repo)
+	adminID := uuid.New()
+
+	rec := serveJSONAs(router, http.MethodPost, "/admin/seasons", `{"name":" Season 1 "}`, adminID)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data models.Season `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "Season 1", response.Data.Name)
+	assert.True(t, response.Data.IsOpen())
+	require.Len(t, repo.seasons, 1)
+
+	// A second season cannot start while the first is open
+	rec = serveJSONAs(router, http.MethodPost, "/admin/seasons", `{"name":"Season 2"}`, adminID)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	closePath := "/admin/seasons/" + response.Data.ID.String() + "/close"
+	rec = serveAs(router, http.MethodPost, closePath, adminID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.False(t, repo.seasons[0].IsOpen())
+
+	rec = serveAs(router, http.MethodPost, closePath, adminID)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serveJSONAs(router, http.MethodPost, "/admin/seasons", `{"name":"Season 2"}`, adminID)
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Len(t, repo.seasons, 2)
+}
+
+func TestCreateSeason_RejectsInvalidRequests(t *testing.T) {
+	router := newAdminSeasonRouter(&stubSeasonRepository{})
+
+	for _, body := range []string{`{"name":"  "}`, `{"name":"` + strings.Repeat("S", maxSeasonNameLength+1) + `"}`, `not json`} {
+		rec := serveJSONAs(router, http.MethodPost, "/admin/seasons", body, uuid.New())
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+
+	rec := serveAs(router, http.MethodPost, "/admin/seasons/not-a-uuid/close", uuid.New())
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveAs(router, http.MethodPost, "/admin/seasons/"+uuid.New().String()+"/close", uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
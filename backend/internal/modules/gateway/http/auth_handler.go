@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -53,15 +54,13 @@ func (h *AuthHandler) AuthenticateTelegram(w http.ResponseWriter, r *http.Reques
 			"error": err,
 		}).Warn("Failed to decode authentication request")
 
-		render.Status(r, http.StatusBadRequest)
-		render.Render(w, r, NewErrorResponse("Invalid request body"))
+		renderDecodeError(w, r, err)
 		return
 	}
 
 	// Validate required fields
 	if req.InitData == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.Render(w, r, NewErrorResponse("init_data is required"))
+		RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "init_data is required")
 		return
 	}
 
@@ -72,8 +71,11 @@ func (h *AuthHandler) AuthenticateTelegram(w http.ResponseWriter, r *http.Reques
 			"error": err,
 		}).Warn("Authentication failed")
 
-		render.Status(r, http.StatusUnauthorized)
-		render.Render(w, r, NewErrorResponse("Authentication failed"))
+		if errors.Is(err, auth.ErrUserBanned) {
+			RenderError(w, r, http.StatusForbidden, ErrCodeForbidden, "Account banned")
+			return
+		}
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication failed")
 		return
 	}
 
@@ -98,15 +100,13 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 			"error": err,
 		}).Warn("Failed to decode refresh token request")
 
-		render.Status(r, http.StatusBadRequest)
-		render.Render(w, r, NewErrorResponse("Invalid request body"))
+		renderDecodeError(w, r, err)
 		return
 	}
 
 	// Validate required fields
 	if req.RefreshToken == "" {
-		render.Status(r, http.StatusBadRequest)
-		render.Render(w, r, NewErrorResponse("refresh_token is required"))
+		RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "refresh_token is required")
 		return
 	}
 
@@ -117,8 +117,11 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 			"error": err,
 		}).Warn("Token refresh failed")
 
-		render.Status(r, http.StatusUnauthorized)
-		render.Render(w, r, NewErrorResponse("Token refresh failed"))
+		if errors.Is(err, auth.ErrUserBanned) {
+			RenderError(w, r, http.StatusForbidden, ErrCodeForbidden, "Account banned")
+			return
+		}
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Token refresh failed")
 		return
 	}
 
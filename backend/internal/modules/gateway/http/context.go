@@ -0,0 +1,35 @@
+package http
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Context key type to avoid collisions with other packages
+type contextKey string
+
+const (
+	userIDKey contextKey = "user_id"
+)
+
+// WithUserID returns a copy of ctx carrying the authenticated user ID
+func WithUserID(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext extracts the authenticated user ID set by the authentication middleware
+func UserIDFromContext(ctx context.Context) (uuid.UUID, error) {
+	userIDValue := ctx.Value(userIDKey)
+	if userIDValue == nil {
+		return uuid.Nil, fmt.Errorf("user ID not found in context")
+	}
+
+	userID, ok := userIDValue.(uuid.UUID)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("invalid user ID format in context")
+	}
+
+	return userID, nil
+}
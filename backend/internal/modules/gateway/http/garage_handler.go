@@ -1,7 +1,6 @@
 package http
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -10,19 +9,11 @@ import (
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 
-	"github.com/megaherz/ndr/internal/constants"
 	"github.com/megaherz/ndr/internal/modules/account"
 	"github.com/megaherz/ndr/internal/storage/postgres/models"
 	"github.com/megaherz/ndr/internal/storage/postgres/repository"
 )
 
-// Context key type to match middleware
-type contextKey string
-
-const (
-	userIDKey contextKey = "user_id"
-)
-
 // GarageResponse represents the garage API response
 type GarageResponse struct {
 	User    GarageUser     `json:"user"`
@@ -85,8 +76,7 @@ func (h *GarageHandler) GetGarageInfo(w http.ResponseWriter, r *http.Request) {
 			"error": err,
 		}).Warn("Failed to get user ID from context")
 
-		render.Status(r, http.StatusUnauthorized)
-		render.Render(w, r, NewErrorResponse("Authentication required"))
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
 		return
 	}
 
@@ -98,8 +88,7 @@ func (h *GarageHandler) GetGarageInfo(w http.ResponseWriter, r *http.Request) {
 			"error":   err,
 		}).Error("Failed to get user information")
 
-		render.Status(r, http.StatusInternalServerError)
-		render.Render(w, r, NewErrorResponse("Failed to get user information"))
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get user information")
 		return
 	}
 
@@ -111,8 +100,7 @@ func (h *GarageHandler) GetGarageInfo(w http.ResponseWriter, r *http.Request) {
 			"error":   err,
 		}).Error("Failed to get wallet information")
 
-		render.Status(r, http.StatusInternalServerError)
-		render.Render(w, r, NewErrorResponse("Failed to get garage information"))
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get garage information")
 		return
 	}
 
@@ -143,22 +131,7 @@ func (h *GarageHandler) GetGarageInfo(w http.ResponseWriter, r *http.Request) {
 
 // getUserIDFromContext extracts user ID from the request context
 func (h *GarageHandler) getUserIDFromContext(r *http.Request) (uuid.UUID, error) {
-	// In a real implementation, this would extract the user ID from JWT claims
-	// stored in the context by the authentication middleware
-	// For now, we'll return a placeholder
-
-	// Check if user_id is set in context (by auth middleware)
-	userIDValue := r.Context().Value(userIDKey)
-	if userIDValue == nil {
-		return uuid.Nil, fmt.Errorf("user ID not found in context")
-	}
-
-	userID, ok := userIDValue.(uuid.UUID)
-	if !ok {
-		return uuid.Nil, fmt.Errorf("invalid user ID format in context")
-	}
-
-	return userID, nil
+	return UserIDFromContext(r.Context())
 }
 
 // getDisplayName creates a display name from user information
@@ -175,27 +148,28 @@ func getDisplayName(user *models.User) string {
 	return displayName
 }
 
-// buildLeaguesList creates the leagues array with availability status
+// buildLeaguesList creates the leagues array with availability status.
+// Buy-ins come from the wallet's league access, so they match the league registry.
 func buildLeaguesList(walletInfo *account.WalletInfo) []GarageLeague {
 	leagues := []GarageLeague{
 		{
 			Name:      "ROOKIE",
-			Buyin:     constants.LeagueBuyins["ROOKIE"].String(),
+			Buyin:     walletInfo.LeagueAccess.Rookie.BuyinCost.String(),
 			Available: true,
 		},
 		{
 			Name:      "STREET",
-			Buyin:     constants.LeagueBuyins["STREET"].String(),
+			Buyin:     walletInfo.LeagueAccess.Street.BuyinCost.String(),
 			Available: true,
 		},
 		{
 			Name:      "PRO",
-			Buyin:     constants.LeagueBuyins["PRO"].String(),
+			Buyin:     walletInfo.LeagueAccess.Pro.BuyinCost.String(),
 			Available: true,
 		},
 		{
 			Name:      "TOP_FUEL",
-			Buyin:     constants.LeagueBuyins["TOP_FUEL"].String(),
+			Buyin:     walletInfo.LeagueAccess.TopFuel.BuyinCost.String(),
 			Available: true,
 		},
 	}
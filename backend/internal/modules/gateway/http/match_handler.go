@@ -0,0 +1,461 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/centrifugal/gocent/v3"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/centrifugo"
+	"github.com/megaherz/ndr/internal/modules/gameengine"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// spectatorTokenTTL is how long a spectator connection token stays valid
+const spectatorTokenTTL = time.Hour
+
+// Page size bounds for match event history
+const (
+	defaultMatchEventsLimit = 50
+	maxMatchEventsLimit     = 100
+)
+
+// PresenceStatsProvider reports presence statistics for realtime channels
+type PresenceStatsProvider interface {
+	GetPresenceStats(ctx context.Context, channel string) (*gocent.PresenceStatsResult, error)
+}
+
+// EventHistoryProvider reads recent publications from a realtime channel
+type EventHistoryProvider interface {
+	GetHistory(ctx context.Context, channel string, limit int, since *gocent.StreamPosition, reverse bool) (*gocent.HistoryResult, error)
+}
+
+// SpectateResponse represents the spectator connection details for a match
+type SpectateResponse struct {
+	Token             string    `json:"token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	MatchChannel      string    `json:"match_channel"`
+	SpectatorsChannel string    `json:"spectators_channel"`
+}
+
+// SpectatorsResponse represents the spectator counts for a match
+type SpectatorsResponse struct {
+	MatchID     string `json:"match_id"`
+	Spectators  int32  `json:"spectators"`
+	Connections int32  `json:"connections"`
+}
+
+// MatchEvent is a single published match event with its position in the channel stream
+type MatchEvent struct {
+	Offset uint64          `json:"offset"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// MatchEventsResponse represents a page of a match's event history.
+// Clients request the next page by passing NextSince and Epoch back as since and epoch.
+type MatchEventsResponse struct {
+	MatchID   string       `json:"match_id"`
+	Events    []MatchEvent `json:"events"`
+	Epoch     string       `json:"epoch"`
+	NextSince uint64       `json:"next_since"`
+	HasMore   bool         `json:"has_more"`
+}
+
+// MatchHandler handles match-related HTTP endpoints
+type MatchHandler struct {
+	gameEngine gameengine.GameEngineService
+	tokens     *centrifugo.TokenIssuer
+	commits    *gameengine.CommitSigner
+	presence   PresenceStatsProvider
+	history    EventHistoryProvider
+	earnPoints gameengine.EarnPointsService
+	logger     *logrus.Logger
+}
+
+// MatchHandlerOption configures optional match endpoints
+type MatchHandlerOption func(*MatchHandler)
+
+// WithTargetToBeat enables the endpoint telling participants what they need to move up the
+// standings of their running match. A nil earnPoints leaves it unregistered.
+func WithTargetToBeat(earnPoints gameengine.EarnPointsService) MatchHandlerOption {
+	return func(h *MatchHandler) {
+		h.earnPoints = earnPoints
+	}
+}
+
+// NewMatchHandler creates a new match handler
+func NewMatchHandler(
+	gameEngine gameengine.GameEngineService,
+	tokens *centrifugo.TokenIssuer,
+	commits *gameengine.CommitSigner,
+	presence PresenceStatsProvider,
+	history EventHistoryProvider,
+	logger *logrus.Logger,
+	opts ...MatchHandlerOption,
+) *MatchHandler {
+	h := &MatchHandler{
+		gameEngine: gameEngine,
+		tokens:     tokens,
+		commits:    commits,
+		presence:   presence,
+		history:    history,
+		logger:     logger,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// RegisterRoutes registers match routes
+func (h *MatchHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/matches", func(r chi.Router) {
+		r.Get("/active", h.GetActiveMatch)
+		r.Get("/{id}", h.GetMatch)
+		r.Post("/{id}/spectate", h.Spectate)
+		r.Get("/{id}/spectators", h.GetSpectators)
+		r.Get("/{id}/events", h.GetEvents)
+		r.Get("/{id}/commit", h.GetCommit)
+		if h.earnPoints != nil {
+			r.Get("/{id}/target", h.GetTarget)
+		}
+	})
+}
+
+// GetMatch handles GET /api/v1/matches/{id}
+// Participants can view their matches at any time; completed matches are public.
+func (h *MatchHandler) GetMatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context (set by authentication middleware)
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err,
+		}).Warn("Failed to get user ID from context")
+
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
+		return
+	}
+
+	matchID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid match ID")
+		return
+	}
+
+	details, err := h.gameEngine.GetMatchDetails(ctx, matchID)
+	if err != nil {
+		if errors.Is(err, gameengine.ErrMatchNotFound) {
+			RenderError(w, r, http.StatusNotFound, ErrCodeNotFound, "Match not found")
+			return
+		}
+
+		h.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+			"user_id":  userID,
+			"error":    err,
+		}).Error("Failed to get match details")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get match details")
+		return
+	}
+
+	// Only participants may view matches that are not yet completed
+	if details.Match.Status != models.MatchStatusCompleted && !details.HasParticipant(userID) {
+		RenderError(w, r, http.StatusForbidden, ErrCodeForbidden, "Access to this match is not allowed")
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(details))
+}
+
+// GetActiveMatch handles GET /api/v1/matches/active
+// It returns the caller's forming or in-progress match so a returning player can rejoin it,
+// or 204 No Content when the caller is not in an active match.
+func (h *MatchHandler) GetActiveMatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
+		return
+	}
+
+	details, err := h.gameEngine.GetActiveMatchDetails(ctx, userID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err,
+		}).Error("Failed to get active match")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get active match")
+		return
+	}
+	if details == nil {
+		render.NoContent(w, r)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(details))
+}
+
+// Spectate handles POST /api/v1/matches/{id}/spectate
+// It issues a read-only Centrifugo connection token for a running match to a non-participant.
+func (h *MatchHandler) Spectate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err,
+		}).Warn("Failed to get user ID from context")
+
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
+		return
+	}
+
+	matchID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid match ID")
+		return
+	}
+
+	details, err := h.gameEngine.GetMatchDetails(ctx, matchID)
+	if err != nil {
+		if errors.Is(err, gameengine.ErrMatchNotFound) {
+			RenderError(w, r, http.StatusNotFound, ErrCodeNotFound, "Match not found")
+			return
+		}
+
+		h.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+			"user_id":  userID,
+			"error":    err,
+		}).Error("Failed to get match details")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get match details")
+		return
+	}
+
+	// Participants already receive match events through their regular connection
+	if details.HasParticipant(userID) {
+		RenderError(w, r, http.StatusConflict, ErrCodeConflict, "Participants cannot spectate their own match")
+		return
+	}
+
+	if details.Match.Status == models.MatchStatusCompleted || details.Match.Status == models.MatchStatusAborted {
+		RenderError(w, r, http.StatusConflict, ErrCodeConflict, "Match has already finished")
+		return
+	}
+
+	token, expiresAt, err := h.tokens.GenerateSpectatorToken(userID.String(), matchID.String(), spectatorTokenTTL)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+			"user_id":  userID,
+			"error":    err,
+		}).Error("Failed to generate spectator token")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to generate spectator token")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"match_id": matchID,
+		"user_id":  userID,
+	}).Info("Issued spectator token")
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(&SpectateResponse{
+		Token:             token,
+		ExpiresAt:         expiresAt,
+		MatchChannel:      centrifugo.MatchChannel(matchID.String()),
+		SpectatorsChannel: centrifugo.SpectatorsChannel(matchID.String()),
+	}))
+}
+
+// GetSpectators handles GET /api/v1/matches/{id}/spectators
+func (h *MatchHandler) GetSpectators(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	matchID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid match ID")
+		return
+	}
+
+	stats, err := h.presence.GetPresenceStats(ctx, centrifugo.SpectatorsChannel(matchID.String()))
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+			"error":    err,
+		}).Error("Failed to get spectator presence stats")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get spectators")
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(&SpectatorsResponse{
+		MatchID:     matchID.String(),
+		Spectators:  stats.NumUsers,
+		Connections: stats.NumClients,
+	}))
+}
+
+// GetCommit handles GET /api/v1/matches/{id}/commit
+// It publishes the crash seed hash of a match that has not finished yet, with a signed
+// commitment time, so players can later check the revealed seed against it.
+func (h *MatchHandler) GetCommit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	matchID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid match ID")
+		return
+	}
+
+	match, err := h.gameEngine.GetMatch(ctx, matchID)
+	if err != nil {
+		if errors.Is(err, gameengine.ErrMatchNotFound) {
+			RenderError(w, r, http.StatusNotFound, ErrCodeNotFound, "Match not found")
+			return
+		}
+
+		h.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+			"error":    err,
+		}).Error("Failed to get match")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get match")
+		return
+	}
+
+	commit, err := gameengine.BuildSeedCommit(match, h.commits)
+	if err != nil {
+		if errors.Is(err, gameengine.ErrCommitUnavailable) {
+			RenderError(w, r, http.StatusConflict, ErrCodeConflict, "Seed commitment is only available before the match completes")
+			return
+		}
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to build seed commitment")
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(commit))
+}
+
+// GetTarget handles GET /api/v1/matches/{id}/target
+// It returns the caller's position in their running match and the points they need to
+// overtake the player one position up and the leader.
+func (h *MatchHandler) GetTarget(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
+		return
+	}
+
+	matchID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid match ID")
+		return
+	}
+
+	target, err := h.earnPoints.GetTargetToBeat(ctx, matchID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, gameengine.ErrMatchStateNotFound):
+			RenderError(w, r, http.StatusNotFound, ErrCodeNotFound, "Match is not running")
+		case errors.Is(err, gameengine.ErrPlayerNotInMatch):
+			RenderError(w, r, http.StatusForbidden, ErrCodeForbidden, "Only participants have a target to beat")
+		default:
+			h.logger.WithFields(logrus.Fields{
+				"match_id": matchID,
+				"user_id":  userID,
+				"error":    err,
+			}).Error("Failed to get target to beat")
+
+			RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get target to beat")
+		}
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(target))
+}
+
+// GetEvents handles GET /api/v1/matches/{id}/events
+// It returns recent match channel events so reconnecting clients can catch up.
+// Query parameters: limit (1-100, default 50), since (stream offset) and epoch.
+func (h *MatchHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	matchID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid match ID")
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit := defaultMatchEventsLimit
+	if raw := query.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxMatchEventsLimit {
+			RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	var since *gocent.StreamPosition
+	if raw := query.Get("since"); raw != "" {
+		offset, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid since offset")
+			return
+		}
+		since = &gocent.StreamPosition{Offset: offset, Epoch: query.Get("epoch")}
+	}
+
+	result, err := h.history.GetHistory(ctx, centrifugo.MatchChannel(matchID.String()), limit, since, false)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"match_id": matchID,
+			"error":    err,
+		}).Error("Failed to get match event history")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get match events")
+		return
+	}
+
+	response := &MatchEventsResponse{
+		MatchID: matchID.String(),
+		Events:  make([]MatchEvent, 0, len(result.Publications)),
+		Epoch:   result.Epoch,
+	}
+	if since != nil {
+		response.NextSince = since.Offset
+	}
+	for _, pub := range result.Publications {
+		response.Events = append(response.Events, MatchEvent{Offset: pub.Offset, Data: pub.Data})
+		response.NextSince = pub.Offset
+	}
+	response.HasMore = response.NextSince < result.Offset
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(response))
+}
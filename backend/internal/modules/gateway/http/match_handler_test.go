@@ -0,0 +1,401 @@
+package http
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/centrifugal/gocent/v3"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/centrifugo"
+	"github.com/megaherz/ndr/internal/modules/gameengine"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// stubGameEngine serves a fixed match and its details, active for its participants until it ends; other methods are not used by these tests
+type stubGameEngine struct {
+	gameengine.GameEngineService
+	details *gameengine.MatchDetails
+}
+
+func (s *stubGameEngine) GetMatchDetails(ctx context.Context, matchID uuid.UUID) (*gameengine.MatchDetails, error) {
+	if s.details == nil || s.details.Match.ID != matchID {
+		return nil, gameengine.ErrMatchNotFound
+	}
+	return s.details, nil
+}
+
+func (s *stubGameEngine) GetMatch(ctx context.Context, matchID uuid.UUID) (*models.Match, error) {
+	if s.details == nil || s.details.Match.ID != matchID {
+		return nil, gameengine.ErrMatchNotFound
+	}
+	return s.details.Match, nil
+}
+
+func (s *stubGameEngine) GetActiveMatchDetails(ctx context.Context, userID uuid.UUID) (*gameengine.MatchDetails, error) {
+	if s.details == nil || !s.details.HasParticipant(userID) {
+		return nil, nil
+	}
+	if status := s.details.Match.Status; status != models.MatchStatusForming && status != models.MatchStatusInProgress {
+		return nil, nil
+	}
+	return s.details, nil
+}
+
+// stubEarnPoints serves fixed targets to beat of a single running match
+type stubEarnPoints struct {
+	gameengine.EarnPointsService
+	matchID uuid.UUID
+	targets map[uuid.UUID]*gameengine.TargetToBeat
+}
+
+func (s *stubEarnPoints) GetTargetToBeat(ctx context.Context, matchID, userID uuid.UUID) (*gameengine.TargetToBeat, error) {
+	if matchID != s.matchID {
+		return nil, gameengine.ErrMatchStateNotFound
+	}
+	target, ok := s.targets[userID]
+	if !ok {
+		return nil, gameengine.ErrPlayerNotInMatch
+	}
+	return target, nil
+}
+
+// stubPresence returns fixed presence stats and records the requested channel
+type stubPresence struct {
+	stats   gocent.PresenceStatsResult
+	channel string
+}
+
+func (s *stubPresence) GetPresenceStats(ctx context.Context, channel string) (*gocent.PresenceStatsResult, error) {
+	s.channel = channel
+	return &s.stats, nil
+}
+
+// stubHistory serves a fixed publication stream and records the requested options
+type stubHistory struct {
+	stream  []gocent.Publication
+	channel string
+	limit   int
+	since   *gocent.StreamPosition
+}
+
+func (s *stubHistory) GetHistory(ctx context.Context, channel string, limit int, since *gocent.StreamPosition, reverse bool) (*gocent.HistoryResult, error) {
+	s.channel, s.limit, s.since = channel, limit, since
+
+	result := &gocent.HistoryResult{Epoch: "epoch-1"}
+	for _, pub := range s.stream {
+		if since == nil || pub.Offset > since.Offset {
+			result.Publications = append(result.Publications, pub)
+		}
+	}
+	if len(result.Publications) > limit {
+		result.Publications = result.Publications[:limit]
+	}
+	if len(s.stream) > 0 {
+		result.Offset = s.stream[len(s.stream)-1].Offset
+	}
+	return result, nil
+}
+
+const (
+	testCentrifugoSecret = "test-centrifugo-secret"
+	testCommitSecret     = "test-commit-secret"
+)
+
+func newTestMatchHandler(details *gameengine.MatchDetails, presence *stubPresence) chi.Router {
+	return newTestMatchHandlerWithHistory(details, presence, &stubHistory{})
+}
+
+func newTestMatchHandlerWithHistory(details *gameengine.MatchDetails, presence *stubPresence, history *stubHistory) chi.Router {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	handler := NewMatchHandler(
+		&stubGameEngine{details: details},
+		centrifugo.NewTokenIssuer(testCentrifugoSecret),
+		gameengine.NewCommitSigner(gameengine.CommitKeyFromSecret(testCommitSecret)),
+		presence,
+		history,
+		logger,
+	)
+
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+	return r
+}
+
+func newInProgressMatch(participantID uuid.UUID) *gameengine.MatchDetails {
+	matchID := uuid.New()
+	return &gameengine.MatchDetails{
+		Match: &models.Match{ID: matchID, Status: models.MatchStatusInProgress},
+		Participants: []*models.MatchParticipant{
+			{MatchID: matchID, UserID: &participantID},
+		},
+	}
+}
+
+func serveAs(router http.Handler, method, path string, userID uuid.UUID) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req = req.WithContext(WithUserID(req.Context(), userID))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSpectate_IssuesReadOnlyToken(t *testing.T) {
+	details := newInProgressMatch(uuid.New())
+	router := newTestMatchHandler(details, &stubPresence{})
+	spectatorID := uuid.New()
+
+	rec := serveAs(router, http.MethodPost, "/matches/"+details.Match.ID.String()+"/spectate", spectatorID)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Data SpectateResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	matchID := details.Match.ID.String()
+	assert.Equal(t, centrifugo.MatchChannel(matchID), response.Data.MatchChannel)
+	assert.Equal(t, centrifugo.SpectatorsChannel(matchID), response.Data.SpectatorsChannel)
+
+	claims, err := centrifugo.NewTokenIssuer(testCentrifugoSecret).ParseConnectionToken(response.Data.Token)
+	require.NoError(t, err)
+	assert.Equal(t, spectatorID.String(), claims.Subject)
+	for _, capability := range claims.Caps {
+		assert.NotContains(t, capability.Allow, centrifugo.CapabilityPublish)
+	}
+}
+
+func TestSpectate_RejectsParticipant(t *testing.T) {
+	participantID := uuid.New()
+	details := newInProgressMatch(participantID)
+	router := newTestMatchHandler(details, &stubPresence{})
+
+	rec := serveAs(router, http.MethodPost, "/matches/"+details.Match.ID.String()+"/spectate", participantID)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestSpectate_RejectsFinishedMatch(t *testing.T) {
+	details := newInProgressMatch(uuid.New())
+	details.Match.Status = models.MatchStatusCompleted
+	router := newTestMatchHandler(details, &stubPresence{})
+
+	rec := serveAs(router, http.MethodPost, "/matches/"+details.Match.ID.String()+"/spectate", uuid.New())
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestSpectate_MatchNotFound(t *testing.T) {
+	router := newTestMatchHandler(nil, &stubPresence{})
+
+	rec := serveAs(router, http.MethodPost, "/matches/"+uuid.New().String()+"/spectate", uuid.New())
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetSpectators_ReflectsPresenceStats(t *testing.T) {
+	details := newInProgressMatch(uuid.New())
+	presence := &stubPresence{stats: gocent.PresenceStatsResult{NumUsers: 3, NumClients: 4}}
+	router := newTestMatchHandler(details, presence)
+
+	rec := serveAs(router, http.MethodGet, "/matches/"+details.Match.ID.String()+"/spectators", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Data SpectatorsResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	assert.Equal(t, centrifugo.SpectatorsChannel(details.Match.ID.String()), presence.channel)
+	assert.Equal(t, int32(3), response.Data.Spectators)
+	assert.Equal(t, int32(4), response.Data.Connections)
+}
+
+func TestGetEvents_Paginates(t *testing.T) {
+	history := &stubHistory{}
+	for i := 1; i <= 5; i++ {
+		history.stream = append(history.stream, gocent.Publication{
+			Offset: uint64(i),
+			Data:   json.RawMessage(fmt.Sprintf(`{"event":"heat_tick","seq":%d}`, i)),
+		})
+	}
+	matchID := uuid.New()
+	router := newTestMatchHandlerWithHistory(nil, &stubPresence{}, history)
+
+	var offsets []uint64
+	path := "/matches/" + matchID.String() + "/events?limit=2"
+	for page := 0; page < 3; page++ {
+		rec := serveAs(router, http.MethodGet, path, uuid.New())
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var response struct {
+			Data MatchEventsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		for _, event := range response.Data.Events {
+			offsets = append(offsets, event.Offset)
+		}
+
+		assert.Equal(t, page < 2, response.Data.HasMore)
+		path = fmt.Sprintf("/matches/%s/events?limit=2&since=%d&epoch=%s", matchID, response.Data.NextSince, response.Data.Epoch)
+	}
+
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, offsets)
+	assert.Equal(t, centrifugo.MatchChannel(matchID.String()), history.channel)
+	assert.Equal(t, 2, history.limit)
+	assert.Equal(t, &gocent.StreamPosition{Offset: 4, Epoch: "epoch-1"}, history.since)
+}
+
+func TestGetEvents_RejectsInvalidLimit(t *testing.T) {
+	router := newTestMatchHandler(nil, &stubPresence{})
+
+	for _, limit := range []string{"0", "101", "abc"} {
+		rec := serveAs(router, http.MethodGet, "/matches/"+uuid.New().String()+"/events?limit="+limit, uuid.New())
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "limit=%s", limit)
+	}
+}
+
+// newCommittedMatch returns an in-progress match with freshly generated crash seeds
+func newCommittedMatch(t *testing.T) *gameengine.MatchDetails {
+	details := newInProgressMatch(uuid.New())
+	seedData, commitHash, err := gameengine.GenerateMatchSeeds(details.Match.ID)
+	require.NoError(t, err)
+	seedJSON, err := json.Marshal(seedData)
+	require.NoError(t, err)
+
+	details.Match.CrashSeed = string(seedJSON)
+	details.Match.CrashSeedHash = commitHash
+	details.Match.CreatedAt = time.Now().Add(-time.Minute)
+	return details
+}
+
+func TestGetCommit_AvailableBeforeCompletion(t *testing.T) {
+	details := newCommittedMatch(t)
+	router := newTestMatchHandler(details, &stubPresence{})
+
+	rec := serveAs(router, http.MethodGet, "/matches/"+details.Match.ID.String()+"/commit", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data gameengine.SeedCommit `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	commit := response.Data
+
+	assert.Equal(t, details.Match.ID, commit.MatchID)
+	assert.Equal(t, details.Match.CrashSeedHash, commit.CrashSeedHash)
+	assert.True(t, details.Match.CreatedAt.Equal(commit.CommittedAt))
+
+	// Players verify the signature with the published public key alone
+	publicKey, err := hex.DecodeString(commit.PublicKey)
+	require.NoError(t, err)
+	assert.True(t, gameengine.VerifySeedCommit(publicKey, &commit))
+	other := gameengine.NewCommitSigner(gameengine.CommitKeyFromSecret("other-secret"))
+	assert.False(t, gameengine.VerifySeedCommit(other.PublicKey(), &commit))
+
+	// The seed revealed after the match verifies against the published commitment
+	var revealed gameengine.CrashSeedData
+	require.NoError(t, json.Unmarshal([]byte(details.Match.CrashSeed), &revealed))
+	assert.True(t, gameengine.VerifyMatchSeeds(&revealed, commit.CrashSeedHash))
+	assert.NotContains(t, rec.Body.String(), revealed.Heat1Seed, "the seed itself stays secret until settlement")
+}
+
+func TestGetCommit_UnavailableAfterCompletion(t *testing.T) {
+	details := newCommittedMatch(t)
+	details.Match.Status = models.MatchStatusCompleted
+	router := newTestMatchHandler(details, &stubPresence{})
+
+	rec := serveAs(router, http.MethodGet, "/matches/"+details.Match.ID.String()+"/commit", uuid.New())
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serveAs(router, http.MethodGet, "/matches/"+uuid.New().String()+"/commit", uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetTarget_ReturnsCallerTarget(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	matchID, userID := uuid.New(), uuid.New()
+	earnPoints := &stubEarnPoints{matchID: matchID, targets: map[uuid.UUID]*gameengine.TargetToBeat{
+		userID: {
+			MatchID:    matchID,
+			Heat:       2,
+			Position:   3,
+			TotalScore: decimal.NewFromInt(80),
+			Next:       &gameengine.ScoreTarget{Position: 2, TotalScore: decimal.NewFromInt(95), Needed: decimal.RequireFromString("15.01")},
+			First:      &gameengine.ScoreTarget{Position: 1, TotalScore: decimal.NewFromInt(120), Needed: decimal.RequireFromString("40.01")},
+		},
+	}}
+	router := chi.NewRouter()
+	NewMatchHandler(&stubGameEngine{}, centrifugo.NewTokenIssuer(testCentrifugoSecret), gameengine.NewCommitSigner(gameengine.CommitKeyFromSecret(testCommitSecret)),
+		&stubPresence{}, &stubHistory{}, logger, WithTargetToBeat(earnPoints)).RegisterRoutes(router)
+
+	rec := serveAs(router, http.MethodGet, "/matches/"+matchID.String()+"/target", userID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data gameengine.TargetToBeat `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 3, response.Data.Position)
+	require.NotNil(t, response.Data.Next)
+	assert.Equal(t, "15.01", response.Data.Next.Needed.StringFixed(2))
+	require.NotNil(t, response.Data.First)
+	assert.Equal(t, "40.01", response.Data.First.Needed.StringFixed(2))
+
+	// Spectators have no target and finished matches have no standings left to climb
+	rec = serveAs(router, http.MethodGet, "/matches/"+matchID.String()+"/target", uuid.New())
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveAs(router, http.MethodGet, "/matches/"+uuid.New().String()+"/target", userID)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveAs(router, http.MethodGet, "/matches/not-a-uuid/target", userID)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGetTarget_NotRegisteredWithoutEarnPoints(t *testing.T) {
+	details := newInProgressMatch(uuid.New())
+	router := newTestMatchHandler(details, &stubPresence{})
+
+	rec := serveAs(router, http.MethodGet, "/matches/"+details.Match.ID.String()+"/target", uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetActiveMatch_ReturnsRunningMatch(t *testing.T) {
+	playerID := uuid.New()
+	details := newInProgressMatch(playerID)
+	router := newTestMatchHandler(details, &stubPresence{})
+
+	rec := serveAs(router, http.MethodGet, "/matches/active", playerID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data gameengine.MatchDetails `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, details.Match.ID, response.Data.Match.ID)
+
+	// Players outside any active match get no content
+	rec = serveAs(router, http.MethodGet, "/matches/active", uuid.New())
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	details.Match.Status = models.MatchStatusCompleted
+	rec = serveAs(router, http.MethodGet, "/matches/active", playerID)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/modules/matchmaker"
+)
+
+// LeagueQueuesResponse represents the queue information of every league
+type LeagueQueuesResponse struct {
+	Leagues []*matchmaker.QueueInfo `json:"leagues"`
+}
+
+// MatchmakingHandler handles matchmaking-related HTTP endpoints
+type MatchmakingHandler struct {
+	matchmaker matchmaker.MatchmakerService
+	leagues    *constants.LeagueRegistry
+	logger     *logrus.Logger
+}
+
+// NewMatchmakingHandler creates a new matchmaking handler listing the registry's leagues
+func NewMatchmakingHandler(matchmakerService matchmaker.MatchmakerService, leagues *constants.LeagueRegistry, logger *logrus.Logger) *MatchmakingHandler {
+	return &MatchmakingHandler{
+		matchmaker: matchmakerService,
+		leagues:    leagues,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes registers matchmaking routes
+func (h *MatchmakingHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/matchmaking", func(r chi.Router) {
+		r.Get("/leagues", h.GetLeagueQueues)
+		r.Get("/status", h.GetQueueStatus)
+		r.Post("/heartbeat", h.Heartbeat)
+	})
+}
+
+// GetLeagueQueues handles GET /api/v1/matchmaking/leagues
+// It returns the queue size, players needed and average wait of every league, cheapest first.
+func (h *MatchmakingHandler) GetLeagueQueues(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	leagues := h.leagues.All()
+	response := &LeagueQueuesResponse{Leagues: make([]*matchmaker.QueueInfo, 0, len(leagues))}
+	for _, league := range leagues {
+		info, err := h.matchmaker.GetQueueInfo(ctx, league.Name)
+		if err != nil {
+			h.logger.WithFields(logrus.Fields{
+				"league": league.Name,
+				"error":  err,
+			}).Error("Failed to get queue info")
+
+			RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get queue info")
+			return
+		}
+		response.Leagues = append(response.Leagues, info)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(response))
+}
+
+// GetQueueStatus handles GET /api/v1/matchmaking/status
+// It returns the caller's queue status; in_queue is false when they are not queued.
+func (h *MatchmakingHandler) GetQueueStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context (set by authentication middleware)
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err,
+		}).Warn("Failed to get user ID from context")
+
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
+		return
+	}
+
+	status, err := h.matchmaker.GetQueueStatus(ctx, userID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err,
+		}).Error("Failed to get queue status")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get queue status")
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(status))
+}
+
+// Heartbeat handles POST /api/v1/matchmaking/heartbeat
+// Queued clients call it periodically to keep their queue entry; it returns their queue status.
+func (h *MatchmakingHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context (set by authentication middleware)
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err,
+		}).Warn("Failed to get user ID from context")
+
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
+		return
+	}
+
+	if err := h.matchmaker.Heartbeat(ctx, userID); err != nil {
+		if errors.Is(err, matchmaker.ErrNotInQueue) {
+			RenderError(w, r, http.StatusNotFound, ErrCodeNotFound, "Not in queue")
+			return
+		}
+		h.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err,
+		}).Error("Failed to record queue heartbeat")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to record heartbeat")
+		return
+	}
+
+	status, err := h.matchmaker.GetQueueStatus(ctx, userID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err,
+		}).Error("Failed to get queue status")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get queue status")
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(status))
+}
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/modules/matchmaker"
+)
+
+// stubMatchmaker serves fixed queue sizes and a single queued user
+type stubMatchmaker struct {
+	matchmaker.MatchmakerService
+	sizes  map[string]int64
+	queued map[uuid.UUID]*matchmaker.QueueStatus
+}
+
+func (s *stubMatchmaker) GetQueueInfo(ctx context.Context, league string) (*matchmaker.QueueInfo, error) {
+	size := s.sizes[league]
+	return &matchmaker.QueueInfo{League: league, QueueSize: size, PlayersNeeded: 10 - int(size)}, nil
+}
+
+func (s *stubMatchmaker) GetQueueStatus(ctx context.Context, userID uuid.UUID) (*matchmaker.QueueStatus, error) {
+	if status, ok := s.queued[userID]; ok {
+		return status, nil
+	}
+	return &matchmaker.QueueStatus{InQueue: false}, nil
+}
+
+func (s *stubMatchmaker) Heartbeat(ctx context.Context, userID uuid.UUID) error {
+	if _, ok := s.queued[userID]; !ok {
+		return matchmaker.ErrNotInQueue
+	}
+	return nil
+}
+
+func newTestMatchmakingHandler(service *stubMatchmaker) chi.Router {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	r := chi.NewRouter()
+	NewMatchmakingHandler(service, constants.DefaultLeagues(), logger).RegisterRoutes(r)
+	return r
+}
+
+func TestGetLeagueQueues_ReturnsEveryLeague(t *testing.T) {
+	router := newTestMatchmakingHandler(&stubMatchmaker{
+		sizes: map[string]int64{constants.LeagueRookie: 3, constants.LeaguePro: 7},
+	})
+
+	rec := serveAs(router, http.MethodGet, "/matchmaking/leagues", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data LeagueQueuesResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+
+	// Leagues are listed cheapest first
+	leagues := response.Data.Leagues
+	require.Len(t, leagues, len(constants.ValidLeagues()))
+	assert.Equal(t, constants.LeagueRookie, leagues[0].League)
+	assert.Equal(t, int64(3), leagues[0].QueueSize)
+	assert.Equal(t, 7, leagues[0].PlayersNeeded)
+	assert.Equal(t, constants.LeagueStreet, leagues[1].League)
+	assert.Zero(t, leagues[1].QueueSize)
+	assert.Equal(t, constants.LeaguePro, leagues[2].League)
+	assert.Equal(t, int64(7), leagues[2].QueueSize)
+	assert.Equal(t, constants.LeagueTopFuel, leagues[3].League)
+}
+
+func TestGetQueueStatus_QueuedUser(t *testing.T) {
+	userID := uuid.New()
+	router := newTestMatchmakingHandler(&stubMatchmaker{
+		queued: map[uuid.UUID]*matchmaker.QueueStatus{
+			userID: {InQueue: true, League: constants.LeagueStreet, Position: 2, QueueSize: 5},
+		},
+	})
+
+	rec := serveAs(router, http.MethodGet, "/matchmaking/status", userID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data matchmaker.QueueStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.Data.InQueue)
+	assert.Equal(t, constants.LeagueStreet, response.Data.League)
+	assert.Equal(t, int64(2), response.Data.Position)
+	assert.Equal(t, int64(5), response.Data.QueueSize)
+}
+
+func TestGetQueueStatus_NotInQueue(t *testing.T) {
+	router := newTestMatchmakingHandler(&stubMatchmaker{})
+
+	rec := serveAs(router, http.MethodGet, "/matchmaking/status", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data matchmaker.QueueStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.False(t, response.Data.InQueue)
+	assert.Empty(t, response.Data.League)
+}
+
+func TestGetQueueStatus_RequiresAuthentication(t *testing.T) {
+	router := newTestMatchmakingHandler(&stubMatchmaker{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/matchmaking/status", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestHeartbeat(t *testing.T) {
+	userID := uuid.New()
+	router := newTestMatchmakingHandler(&stubMatchmaker{
+		queued: map[uuid.UUID]*matchmaker.QueueStatus{
+			userID: {InQueue: true, League: constants.LeagueRookie, QueueSize: 4},
+		},
+	})
+
+	rec := serveAs(router, http.MethodPost, "/matchmaking/heartbeat", userID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var response struct {
+		Data matchmaker.QueueStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.Data.InQueue)
+
+	// Players outside every queue have no entry to keep alive
+	rec = serveAs(router, http.MethodPost, "/matchmaking/heartbeat", uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
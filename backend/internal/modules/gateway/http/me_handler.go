@@ -0,0 +1,164 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/modules/account"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// MeResponse represents the authenticated user's profile and wallet summary
+type MeResponse struct {
+	User   *models.User `json:"user"`
+	Wallet MeWallet     `json:"wallet"`
+}
+
+// MeWallet represents the wallet summary in the profile response
+type MeWallet struct {
+	TonBalance           string `json:"ton_balance"`
+	FuelBalance          string `json:"fuel_balance"`
+	BurnBalance          string `json:"burn_balance"`
+	RookieRacesCompleted int    `json:"rookie_races_completed"`
+}
+
+// ReplayOptOutRequest represents a request to opt in or out of ghost replay recording
+type ReplayOptOutRequest struct {
+	OptOut *bool `json:"opt_out"`
+}
+
+// MeHandler handles the authenticated user's profile endpoint
+type MeHandler struct {
+	accountService account.AccountService
+	userRepo       repository.UserRepository
+	logger         *logrus.Logger
+}
+
+// NewMeHandler creates a new profile handler
+func NewMeHandler(accountService account.AccountService, userRepo repository.UserRepository, logger *logrus.Logger) *MeHandler {
+	return &MeHandler{
+		accountService: accountService,
+		userRepo:       userRepo,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes registers profile routes
+func (h *MeHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/me", h.GetMe)
+	r.Put("/me/replay-opt-out", h.SetReplayOptOut)
+}
+
+// GetMe handles GET /api/v1/me
+// It returns the caller's user record and wallet balances.
+func (h *MeHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context (set by authentication middleware)
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err,
+		}).Warn("Failed to get user ID from context")
+
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err,
+		}).Error("Failed to get user information")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get user information")
+		return
+	}
+	if user == nil {
+		RenderError(w, r, http.StatusNotFound, ErrCodeNotFound, "User not found")
+		return
+	}
+
+	walletInfo, err := h.accountService.GetWallet(ctx, userID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err,
+		}).Error("Failed to get wallet information")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get wallet information")
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(&MeResponse{
+		User: user,
+		Wallet: MeWallet{
+			TonBalance:           walletInfo.TonBalance.String(),
+			FuelBalance:          walletInfo.FuelBalance.String(),
+			BurnBalance:          walletInfo.BurnBalance.String(),
+			RookieRacesCompleted: walletInfo.RookieRacesCompleted,
+		},
+	}))
+}
+
+// SetReplayOptOut handles PUT /api/v1/me/replay-opt-out
+// While opted out, the caller's settled runs are not recorded as ghost replays for other players' matches.
+func (h *MeHandler) SetReplayOptOut(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := UserIDFromContext(ctx)
+	if err != nil {
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
+		return
+	}
+
+	var req ReplayOptOutRequest
+	if err := render.DecodeJSON(r.Body, &req); err != nil {
+		renderDecodeError(w, r, err)
+		return
+	}
+	if req.OptOut == nil {
+		RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "opt_out is required")
+		return
+	}
+
+	if err := h.userRepo.SetReplayOptOut(ctx, userID, *req.OptOut); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			RenderError(w, r, http.StatusNotFound, ErrCodeNotFound, "User not found")
+			return
+		}
+		h.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"opt_out": *req.OptOut,
+			"error":   err,
+		}).Error("Failed to set replay opt-out")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to update replay preference")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err,
+		}).Error("Failed to get user information")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get user information")
+		return
+	}
+	if user == nil {
+		RenderError(w, r, http.StatusNotFound, ErrCodeNotFound, "User not found")
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(user))
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/modules/account"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// stubUserRepository serves users by ID and stores their replay opt-outs
+type stubUserRepository struct {
+	repository.UserRepository
+	users map[uuid.UUID]*models.User
+}
+
+func (r *stubUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.users[id], nil
+}
+
+func (r *stubUserRepository) SetReplayOptOut(ctx context.Context, userID uuid.UUID, optOut bool) error {
+	user, ok := r.users[userID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	user.ReplayOptOut = optOut
+	return nil
+}
+
+// stubAccountService serves wallets by user ID
+type stubAccountService struct {
+	account.AccountService
+	wallets map[uuid.UUID]*account.WalletInfo
+}
+
+func (s *stubAccountService) GetWallet(ctx context.Context, userID uuid.UUID) (*account.WalletInfo, error) {
+	return s.wallets[userID], nil
+}
+
+func newTestMeHandler(user *models.User, wallet *account.WalletInfo) chi.Router {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	users := &stubUserRepository{users: map[uuid.UUID]*models.User{user.ID: user}}
+	accounts := &stubAccountService{wallets: map[uuid.UUID]*account.WalletInfo{user.ID: wallet}}
+
+	r := chi.NewRouter()
+	NewMeHandler(accounts, users, logger).RegisterRoutes(r)
+	return r
+}
+
+func TestGetMe_ReturnsUserAndWallet(t *testing.T) {
+	user := &models.User{ID: uuid.New(), TelegramID: 42, TelegramFirstName: "Racer"}
+	router := newTestMeHandler(user, &account.WalletInfo{
+		UserID:               user.ID,
+		TonBalance:           decimal.Zero,
+		FuelBalance:          decimal.RequireFromString("120.5"),
+		BurnBalance:          decimal.RequireFromString("3"),
+		RookieRacesCompleted: 2,
+	})
+
+	rec := serveAs(router, http.MethodGet, "/me", user.ID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data MeResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, user.ID, response.Data.User.ID)
+	assert.Equal(t, int64(42), response.Data.User.TelegramID)
+	assert.Equal(t, "Racer", response.Data.User.TelegramFirstName)
+	assert.Equal(t, MeWallet{TonBalance: "0", FuelBalance: "120.5", BurnBalance: "3", RookieRacesCompleted: 2}, response.Data.Wallet)
+}
+
+func TestGetMe_UnknownUser(t *testing.T) {
+	router := newTestMeHandler(&models.User{ID: uuid.New()}, &account.WalletInfo{})
+
+	rec := serveAs(router, http.MethodGet, "/me", uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetMe_RequiresAuthentication(t *testing.T) {
+	router := newTestMeHandler(&models.User{ID: uuid.New()}, &account.WalletInfo{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/me", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestSetReplayOptOut_UpdatesPreference(t *testing.T) {
+	user := &models.User{ID: uuid.New(), TelegramFirstName: "Racer"}
+	router := newTestMeHandler(user, &account.WalletInfo{})
+
+	rec := serveJSONAs(router, http.MethodPut, "/me/replay-opt-out", `{"opt_out": true}`, user.ID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data models.User `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.Data.ReplayOptOut)
+	assert.True(t, user.ReplayOptOut)
+
+	rec = serveJSONAs(router, http.MethodPut, "/me/replay-opt-out", `{"opt_out": false}`, user.ID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.False(t, user.ReplayOptOut)
+}
+
+func TestSetReplayOptOut_RejectsInvalidRequests(t *testing.T) {
+	user := &models.User{ID: uuid.New()}
+	router := newTestMeHandler(user, &account.WalletInfo{})
+
+	for _, body := range []string{`{}`, `{"opt_out": "yes"}`, `not json`} {
+		rec := serveJSONAs(router, http.MethodPut, "/me/replay-opt-out", body, user.ID)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+
+	rec := serveJSONAs(router, http.MethodPut, "/me/replay-opt-out", `{"opt_out": true}`, uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -1,15 +1,18 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
 )
 
-// APIResponse represents a standardized API response structure
+// APIResponse represents a standardized successful API response structure
 type APIResponse struct {
 	Success   bool        `json:"success"`
 	Data      interface{} `json:"data,omitempty"`
-	Error     string      `json:"error,omitempty"`
 	Timestamp string      `json:"timestamp"`
 }
 
@@ -31,15 +34,53 @@ func NewSuccessResponse(data interface{}) *APIResponse {
 	}
 }
 
-// NewErrorResponse creates an error API response
-func NewErrorResponse(message string) *APIResponse {
-	return &APIResponse{
+// Error codes returned in ErrorResponse.Code
+const (
+	ErrCodeInvalidRequest = "INVALID_REQUEST"
+	ErrCodeUnauthorized   = "UNAUTHORIZED"
+	ErrCodeForbidden      = "FORBIDDEN"
+	ErrCodeNotFound       = "NOT_FOUND"
+	ErrCodeConflict       = "CONFLICT"
+	ErrCodeTooLarge       = "PAYLOAD_TOO_LARGE"
+	ErrCodeInternal       = "INTERNAL_ERROR"
+)
+
+// ErrorResponse is the error envelope returned by every endpoint
+type ErrorResponse struct {
+	Success   bool   `json:"success"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+// Render implements chi/render.Renderer interface
+func (er *ErrorResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	if er.RequestID == "" {
+		er.RequestID = middleware.GetReqID(r.Context())
+	}
+	if er.Timestamp == "" {
+		er.Timestamp = time.Now().Format(time.RFC3339)
+	}
+	return nil
+}
+
+// NewErrorResponse creates an error response; the request ID is filled in when rendered
+func NewErrorResponse(code, message string) *ErrorResponse {
+	return &ErrorResponse{
 		Success:   false,
-		Error:     message,
+		Code:      code,
+		Message:   message,
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 }
 
+// RenderError writes an error response with the given HTTP status
+func RenderError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	render.Status(r, status)
+	render.Render(w, r, NewErrorResponse(code, message))
+}
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
 	Status    string `json:"status"`
@@ -94,3 +135,14 @@ func NewAPIInfoResponse(message, status string) *APIInfoResponse {
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 }
+
+// renderDecodeError writes the error response for a request body that could not be decoded.
+// Bodies cut off by the request size limit get 413, anything else 400.
+func renderDecodeError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		RenderError(w, r, http.StatusRequestEntityTooLarge, ErrCodeTooLarge, "Request body too large")
+		return
+	}
+	RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid request body")
+}
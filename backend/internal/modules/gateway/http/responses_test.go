@@ -0,0 +1,65 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/centrifugo"
+	"github.com/megaherz/ndr/internal/modules/gameengine"
+)
+
+func TestRenderError_SameEnvelopeForEveryStatus(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	matchHandler := NewMatchHandler(&stubGameEngine{}, centrifugo.NewTokenIssuer(testCentrifugoSecret), gameengine.NewCommitSigner(gameengine.CommitKeyFromSecret(testCommitSecret)), &stubPresence{}, &stubHistory{}, logger)
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	matchHandler.RegisterRoutes(r)
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Something went wrong")
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		code   string
+	}{
+		{name: "bad request", path: "/matches/not-a-uuid/events", status: http.StatusBadRequest, code: ErrCodeInvalidRequest},
+		{name: "unauthorized", path: "/matches/" + uuid.New().String(), status: http.StatusUnauthorized, code: ErrCodeUnauthorized},
+		{name: "internal error", path: "/fail", status: http.StatusInternalServerError, code: ErrCodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			require.Equal(t, tt.status, rec.Code)
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+			keys := make([]string, 0, len(body))
+			for key := range body {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			assert.Equal(t, []string{"code", "message", "request_id", "success", "timestamp"}, keys)
+			assert.Equal(t, false, body["success"])
+			assert.Equal(t, tt.code, body["code"])
+			assert.NotEmpty(t, body["message"])
+			assert.NotEmpty(t, body["request_id"])
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// Season leaderboard page size bounds
+const (
+	defaultLeaderboardLimit = 50
+	maxLeaderboardLimit     = 100
+)
+
+// currentSeasonID addresses the open season in place of a season ID
+const currentSeasonID = "current"
+
+// SeasonLeaderboardResponse represents a season's leaderboard
+type SeasonLeaderboardResponse struct {
+	Season  *models.Season                 `json:"season"`
+	Entries []*repository.LeaderboardEntry `json:"entries"`
+}
+
+// SeasonStatsResponse represents the caller's stats in a season
+type SeasonStatsResponse struct {
+	Season *models.Season        `json:"season"`
+	Stats  *repository.UserStats `json:"stats"`
+}
+
+// SeasonHandler handles season, leaderboard and season stats endpoints
+type SeasonHandler struct {
+	seasonRepo repository.SeasonRepository
+	logger     *logrus.Logger
+}
+
+// NewSeasonHandler creates a new season handler
+func NewSeasonHandler(seasonRepo repository.SeasonRepository, logger *logrus.Logger) *SeasonHandler {
+	return &SeasonHandler{
+		seasonRepo: seasonRepo,
+		logger:     logger,
+	}
+}
+
+// RegisterRoutes registers season routes
+func (h *SeasonHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/seasons", func(r chi.Router) {
+		r.Get("/{id}", h.GetSeason)
+		r.Get("/{id}/leaderboard", h.GetLeaderboard)
+		r.Get("/{id}/stats", h.GetStats)
+	})
+}
+
+// GetSeason handles GET /api/v1/seasons/{id}
+// The ID "current" addresses the open season.
+func (h *SeasonHandler) GetSeason(w http.ResponseWriter, r *http.Request) {
+	season, ok := h.resolveSeason(w, r)
+	if !ok {
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(season))
+}
+
+// GetLeaderboard handles GET /api/v1/seasons/{id}/leaderboard?limit=
+// It ranks players by prize money won in the season's completed matches, then wins and podiums.
+// Query parameters: limit (1-100, default 50).
+func (h *SeasonHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit := defaultLeaderboardLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxLeaderboardLimit {
+			RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	season, ok := h.resolveSeason(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.seasonRepo.GetLeaderboard(r.Context(), season, limit)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"season_id": season.ID,
+			"error":     err,
+		}).Error("Failed to get season leaderboard")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get leaderboard")
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(&SeasonLeaderboardResponse{Season: season, Entries: entries}))
+}
+
+// GetStats handles GET /api/v1/seasons/{id}/stats
+// It returns the caller's stats across the season's completed matches.
+func (h *SeasonHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	userID, err := UserIDFromContext(r.Context())
+	if err != nil {
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
+		return
+	}
+
+	season, ok := h.resolveSeason(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.seasonRepo.GetUserStats(r.Context(), season, userID)
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"season_id": season.ID,
+			"user_id":   userID,
+			"error":     err,
+		}).Error("Failed to get season stats")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get season stats")
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(&SeasonStatsResponse{Season: season, Stats: stats}))
+}
+
+// resolveSeason loads the season addressed by the {id} URL parameter, rendering an error
+// response and reporting false when it cannot
+func (h *SeasonHandler) resolveSeason(w http.ResponseWriter, r *http.Request) (*models.Season, bool) {
+	ctx := r.Context()
+	rawID := chi.URLParam(r, "id")
+
+	var season *models.Season
+	var err error
+	if rawID == currentSeasonID {
+		season, err = h.seasonRepo.GetCurrent(ctx)
+	} else {
+		seasonID, parseErr := uuid.Parse(rawID)
+		if parseErr != nil {
+			RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid season ID")
+			return nil, false
+		}
+		season, err = h.seasonRepo.GetByID(ctx, seasonID)
+	}
+	if err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"season_id": rawID,
+			"error":     err,
+		}).Error("Failed to get season")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get season")
+		return nil, false
+	}
+	if season == nil {
+		RenderError(w, r, http.StatusNotFound, ErrCodeNotFound, "Season not found")
+		return nil, false
+	}
+	return season, true
+}
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// stubSeasonRepository keeps seasons in memory and serves fixed per-season leaderboards and stats
+type stubSeasonRepository struct {
+	repository.SeasonRepository
+	seasons     []*models.Season
+	leaderboard map[uuid.UUID][]*repository.LeaderboardEntry
+	stats       map[uuid.UUID]*repository.UserStats
+	lastLimit   int
+}
+
+func (r *stubSeasonRepository) Create(ctx context.Context, season *models.Season) error {
+	if current, _ := r.GetCurrent(ctx); current != nil && season.IsOpen() {
+		return repository.ErrDuplicate
+	}
+	r.seasons = append(r.seasons, season)
+	return nil
+}
+
+func (r *stubSeasonRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Season, error) {
+	for _, season := range r.seasons {
+		if season.ID == id {
+			return season, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *stubSeasonRepository) GetCurrent(ctx context.Context) (*models.Season, error) {
+	for _, season := range r.seasons {
+		if season.IsOpen() {
+			return season, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *stubSeasonRepository) Close(ctx context.Context, id uuid.UUID) (*models.Season, error) {
+	season, _ := r.GetByID(ctx, id)
+	if season == nil {
+		return nil, repository.ErrSeasonNotFound
+	}
+	if !season.IsOpen() {
+		return nil, repository.ErrSeasonClosed
+	}
+	now := time.Now()
+	season.EndsAt = &now
+	return season, nil
+}
+
+func (r *stubSeasonRepository) GetLeaderboard(ctx context.Context, season *models.Season, limit int) ([]*repository.LeaderboardEntry, error) {
+	r.lastLimit = limit
+	return r.leaderboard[season.ID], nil
+}
+
+func (r *stubSeasonRepository) GetUserStats(ctx context.Context, season *models.Season, userID uuid.UUID) (*repository.UserStats, error) {
+	if stats, ok := r.stats[userID]; ok {
+		return stats, nil
+	}
+	return &repository.UserStats{UserID: userID}, nil
+}
+
+func newSeasonRouter(repo *stubSeasonRepository) chi.Router {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	r := chi.NewRouter()
+	NewSeasonHandler(repo, logger).RegisterRoutes(r)
+	return r
+}
+
+func TestGetLeaderboard_CurrentSeason(t *testing.T) {
+	endsAt := time.Now().Add(-time.Hour)
+	previous := &models.Season{ID: uuid.New(), Name: "Season 1", StartsAt: endsAt.Add(-24 * time.Hour), EndsAt: &endsAt}
+	current := &models.Season{ID: uuid.New(), Name: "Season 2", StartsAt: endsAt}
+	leader := uuid.New()
+	repo := &stubSeasonRepository{
+		seasons: []*models.Season{previous, current},
+		leaderboard: map[uuid.UUID][]*repository.LeaderboardEntry{
+			current.ID: {{Rank: 1, UserID: leader, DisplayName: "Nitro", TotalEarnings: decimal.NewFromInt(60)}},
+		},
+	}
+	router := newSeasonRouter(repo)
+
+	rec := serveAs(router, http.MethodGet, "/seasons/current/leaderboard?limit=10", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 10, repo.lastLimit)
+
+	var response struct {
+		Data SeasonLeaderboardResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, current.ID, response.Data.Season.ID)
+	require.Len(t, response.Data.Entries, 1)
+	assert.Equal(t, leader, response.Data.Entries[0].UserID)
+
+	// Closed seasons stay addressable by ID
+	rec = serveAs(router, http.MethodGet, "/seasons/"+previous.ID.String()+"/leaderboard", uuid.New())
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, defaultLeaderboardLimit, repo.lastLimit)
+}
+
+func TestGetLeaderboard_RejectsInvalidRequests(t *testing.T) {
+	router := newSeasonRouter(&stubSeasonRepository{})
+
+	// No season is open yet
+	rec := serveAs(router, http.MethodGet, "/seasons/current/leaderboard", uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveAs(router, http.MethodGet, "/seasons/"+uuid.New().String()+"/leaderboard", uuid.New())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveAs(router, http.MethodGet, "/seasons/not-a-uuid/leaderboard", uuid.New())
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	for _, limit := range []string{"0", "101", "many"} {
+		rec = serveAs(router, http.MethodGet, "/seasons/current/leaderboard?limit="+limit, uuid.New())
+		assert.Equal(t, http.StatusBadRequest, rec.Code, limit)
+	}
+}
+
+func TestGetSeasonStats_ReturnsCallerStats(t *testing.T) {
+	season := &models.Season{ID: uuid.New(), Name: "Season 1", StartsAt: time.Now().Add(-time.Hour)}
+	userID := uuid.New()
+	router := newSeasonRouter(&stubSeasonRepository{
+		seasons: []*models.Season{season},
+		stats:   map[uuid.UUID]*repository.UserStats{userID: {UserID: userID, TotalMatches: 3, TotalWins: 1}},
+	})
+
+	rec := serveAs(router, http.MethodGet, "/seasons/current/stats", userID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response struct {
+		Data SeasonStatsResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, season.ID, response.Data.Season.ID)
+	assert.Equal(t, userID, response.Data.Stats.UserID)
+	assert.Equal(t, int64(3), response.Data.Stats.TotalMatches)
+	assert.Equal(t, int64(1), response.Data.Stats.TotalWins)
+}
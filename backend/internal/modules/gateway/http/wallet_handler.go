@@ -1,28 +1,48 @@
 package http
 
 import (
-	"fmt"
+	"context"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
+	monetary "github.com/megaherz/ndr/internal/decimal"
 	"github.com/megaherz/ndr/internal/modules/account"
 )
 
+const (
+	// maxBalancePollWait caps how long a balance long-poll is held open
+	maxBalancePollWait = 25 * time.Second
+
+	// defaultBalancePollInterval is how often a held long-poll re-reads the ledger
+	defaultBalancePollInterval = time.Second
+)
+
 // WalletHandler handles wallet-related HTTP endpoints
 type WalletHandler struct {
-	accountService account.AccountService
-	logger         *logrus.Logger
+	accountService      account.AccountService
+	balancePollInterval time.Duration
+	logger              *logrus.Logger
+}
+
+// BalancePollResponse is returned by the balance polling endpoint
+type BalancePollResponse struct {
+	Version  int64                     `json:"version"`  // Pass back as since to receive only later changes
+	Changed  bool                      `json:"changed"`  // Whether any balance changed after since
+	Balances map[string]monetary.Money `json:"balances"` // Every balance keyed by currency, empty if nothing changed
 }
 
 // NewWalletHandler creates a new wallet handler
 func NewWalletHandler(accountService account.AccountService, logger *logrus.Logger) *WalletHandler {
 	return &WalletHandler{
-		accountService: accountService,
-		logger:         logger,
+		accountService:      accountService,
+		balancePollInterval: defaultBalancePollInterval,
+		logger:              logger,
 	}
 }
 
@@ -30,6 +50,7 @@ func NewWalletHandler(accountService account.AccountService, logger *logrus.Logg
 func (h *WalletHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/wallet", func(r chi.Router) {
 		r.Get("/", h.GetWallet)
+		r.Get("/balance", h.GetBalance)
 	})
 }
 
@@ -44,8 +65,7 @@ func (h *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
 			"error": err,
 		}).Warn("Failed to get user ID from context")
 
-		render.Status(r, http.StatusUnauthorized)
-		render.Render(w, r, NewErrorResponse("Authentication required"))
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
 		return
 	}
 
@@ -57,8 +77,7 @@ func (h *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
 			"error":   err,
 		}).Error("Failed to get wallet information")
 
-		render.Status(r, http.StatusInternalServerError)
-		render.Render(w, r, NewErrorResponse("Failed to get wallet information"))
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get wallet information")
 		return
 	}
 
@@ -73,22 +92,87 @@ func (h *WalletHandler) GetWallet(w http.ResponseWriter, r *http.Request) {
 	render.Render(w, r, NewSuccessResponse(walletInfo))
 }
 
-// getUserIDFromContext extracts user ID from the request context
-func (h *WalletHandler) getUserIDFromContext(r *http.Request) (uuid.UUID, error) {
-	// In a real implementation, this would extract the user ID from JWT claims
-	// stored in the context by the authentication middleware
-	// For now, we'll return a placeholder
-
-	// Check if user_id is set in context (by auth middleware)
-	userIDValue := r.Context().Value("user_id")
-	if userIDValue == nil {
-		return uuid.Nil, fmt.Errorf("user ID not found in context")
+// GetBalance handles GET /api/v1/wallet/balance?since=&wait=
+// It is a polling fallback for clients that cannot receive balance_updated events.
+// Without since it returns every balance; with since it returns them only once the wallet
+// version moved past since, holding the request open for up to wait seconds until it does.
+func (h *WalletHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := h.getUserIDFromContext(r)
+	if err != nil {
+		RenderError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Authentication required")
+		return
+	}
+
+	var since int64
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		since, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || since < 0 {
+			RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "since must be a non-negative integer")
+			return
+		}
+	}
+
+	var wait time.Duration
+	if raw := r.URL.Query().Get("wait"); raw != "" {
+		seconds, err := strconv.Atoi(raw)
+		if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > maxBalancePollWait {
+			RenderError(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "wait must be between 0 and 25 seconds")
+			return
+		}
+		wait = time.Duration(seconds) * time.Second
 	}
 
-	userID, ok := userIDValue.(uuid.UUID)
-	if !ok {
-		return uuid.Nil, fmt.Errorf("invalid user ID format in context")
+	snapshot, err := h.pollBalances(ctx, userID, since, wait)
+	if err != nil {
+		if ctx.Err() != nil {
+			return // Client went away while the poll was held
+		}
+		h.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"since":   since,
+			"error":   err,
+		}).Error("Failed to get balances")
+
+		RenderError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Failed to get balances")
+		return
+	}
+
+	balances := make(map[string]monetary.Money, len(snapshot.Balances))
+	for currency, balance := range snapshot.Balances {
+		balances[currency] = monetary.NewMoney(balance)
 	}
 
-	return userID, nil
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, NewSuccessResponse(BalancePollResponse{
+		Version:  snapshot.Version,
+		Changed:  since == 0 || len(snapshot.Balances) > 0,
+		Balances: balances,
+	}))
+}
+
+// pollBalances reads the balances if they changed after since, re-reading until they do or wait elapses
+func (h *WalletHandler) pollBalances(ctx context.Context, userID uuid.UUID, since int64, wait time.Duration) (*account.BalanceSnapshot, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		snapshot, err := h.accountService.GetBalancesSince(ctx, userID, since)
+		if err != nil {
+			return nil, err
+		}
+		if since == 0 || len(snapshot.Balances) > 0 || !time.Now().Before(deadline) {
+			return snapshot, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(h.balancePollInterval):
+		}
+	}
+}
+
+// getUserIDFromContext extracts user ID from the request context
+func (h *WalletHandler) getUserIDFromContext(r *http.Request) (uuid.UUID, error) {
+	return UserIDFromContext(r.Context())
 }
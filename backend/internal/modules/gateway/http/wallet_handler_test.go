@@ -0,0 +1,149 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/modules/account"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// memoryWalletRepository keeps one user's wallet in memory, bumping its version with every balance change
+type memoryWalletRepository struct {
+	repository.WalletRepository
+
+	mu     sync.Mutex
+	wallet models.Wallet
+}
+
+func (r *memoryWalletRepository) credit(currency models.Currency, amount string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delta := decimal.RequireFromString(amount)
+	switch currency {
+	case models.CurrencyTON:
+		r.wallet.TonBalance = r.wallet.TonBalance.Add(delta)
+	case models.CurrencyFUEL:
+		r.wallet.FuelBalance = r.wallet.FuelBalance.Add(delta)
+	case models.CurrencyBURN:
+		r.wallet.BurnBalance = r.wallet.BurnBalance.Add(delta)
+	}
+	r.wallet.Version++
+}
+
+func (r *memoryWalletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Wallet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if userID != r.wallet.UserID {
+		return nil, nil
+	}
+	wallet := r.wallet
+	return &wallet, nil
+}
+
+func newTestWalletHandler(wallets *memoryWalletRepository, userID uuid.UUID) chi.Router {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	handler := NewWalletHandler(account.NewAccountService(wallets, nil, logger), logger)
+	handler.balancePollInterval = 10 * time.Millisecond
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			next.ServeHTTP(w, req.WithContext(WithUserID(req.Context(), userID)))
+		})
+	})
+	handler.RegisterRoutes(r)
+	return r
+}
+
+func getBalance(t *testing.T, router chi.Router, query string) BalancePollResponse {
+	req := httptest.NewRequest(http.MethodGet, "/wallet/balance"+query, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body struct {
+		Data BalancePollResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body.Data
+}
+
+func TestGetBalance_CursorAdvancesAfterCredit(t *testing.T) {
+	userID := uuid.New()
+	wallets := &memoryWalletRepository{wallet: models.Wallet{UserID: userID}}
+	wallets.credit(models.CurrencyFUEL, "100.00")
+	wallets.credit(models.CurrencyTON, "1.50")
+	router := newTestWalletHandler(wallets, userID)
+
+	// Without since every currency is returned
+	full := getBalance(t, router, "")
+	assert.True(t, full.Changed)
+	assert.Equal(t, int64(2), full.Version)
+	require.Len(t, full.Balances, 3)
+	assert.Equal(t, "100.00", full.Balances["FUEL"].StringFixed(2))
+	assert.Equal(t, "1.50", full.Balances["TON"].StringFixed(2))
+	assert.Equal(t, "0.00", full.Balances["BURN"].StringFixed(2))
+
+	// Nothing changed since the cursor
+	idle := getBalance(t, router, fmt.Sprintf("?since=%d", full.Version))
+	assert.False(t, idle.Changed)
+	assert.Equal(t, full.Version, idle.Version)
+	assert.Empty(t, idle.Balances)
+
+	// A credit advances the cursor and the balances are returned again
+	wallets.credit(models.CurrencyFUEL, "25.00")
+	changed := getBalance(t, router, fmt.Sprintf("?since=%d", full.Version))
+	assert.True(t, changed.Changed)
+	assert.Equal(t, full.Version+1, changed.Version)
+	assert.Equal(t, "125.00", changed.Balances["FUEL"].StringFixed(2))
+	assert.Equal(t, "1.50", changed.Balances["TON"].StringFixed(2))
+}
+
+func TestGetBalance_LongPollReturnsOnCredit(t *testing.T) {
+	userID := uuid.New()
+	wallets := &memoryWalletRepository{wallet: models.Wallet{UserID: userID}}
+	wallets.credit(models.CurrencyFUEL, "10.00")
+	router := newTestWalletHandler(wallets, userID)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		wallets.credit(models.CurrencyBURN, "3.00")
+	}()
+
+	start := time.Now()
+	changed := getBalance(t, router, "?since=1&wait=5")
+	assert.Less(t, time.Since(start), 5*time.Second, "the poll returns as soon as the balance changes")
+	assert.True(t, changed.Changed)
+	assert.Equal(t, int64(2), changed.Version)
+	assert.Equal(t, "3.00", changed.Balances["BURN"].StringFixed(2))
+}
+
+func TestGetBalance_RejectsInvalidParameters(t *testing.T) {
+	router := newTestWalletHandler(&memoryWalletRepository{}, uuid.New())
+
+	for _, query := range []string{"?since=-1", "?since=abc", "?wait=60", "?wait=x"} {
+		req := httptest.NewRequest(http.MethodGet, "/wallet/balance"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
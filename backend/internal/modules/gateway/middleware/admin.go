@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	httpHandlers "github.com/megaherz/ndr/internal/modules/gateway/http"
+)
+
+// AdminOnly creates a middleware that only lets configured admin users through.
+// It must run after JWTAuth so the user ID is available in the request context.
+func AdminOnly(adminUserIDs []string, logger *logrus.Logger) func(http.Handler) http.Handler {
+	admins := make(map[uuid.UUID]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		// Config validation guarantees well-formed IDs; parsing normalizes case
+		if parsed, err := uuid.Parse(id); err == nil {
+			admins[parsed] = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := httpHandlers.UserIDFromContext(r.Context())
+			if err != nil {
+				httpHandlers.RenderError(w, r, http.StatusUnauthorized, httpHandlers.ErrCodeUnauthorized, "Authentication required")
+				return
+			}
+
+			if !admins[userID] {
+				logger.WithFields(logrus.Fields{
+					"user_id": userID,
+					"path":    r.URL.Path,
+				}).Warn("Non-admin user attempted to access admin endpoint")
+				httpHandlers.RenderError(w, r, http.StatusForbidden, httpHandlers.ErrCodeForbidden, "Admin access required")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/go-chi/render"
 	"github.com/sirupsen/logrus"
 
 	"github.com/megaherz/ndr/internal/auth"
@@ -16,29 +15,26 @@ import (
 type contextKey string
 
 const (
-	userIDKey     contextKey = "user_id"
 	telegramIDKey contextKey = "telegram_id"
 	tokenTypeKey  contextKey = "token_type"
 )
 
 // JWTAuth creates a JWT authentication middleware
-func JWTAuth(jwtManager *auth.JWTManager, logger *logrus.Logger) func(http.Handler) http.Handler {
+func JWTAuth(jwtManager auth.JWTManager, logger *logrus.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Extract token from Authorization header
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				logger.Debug("Missing Authorization header")
-				render.Status(r, http.StatusUnauthorized)
-				render.Render(w, r, httpHandlers.NewErrorResponse("Authorization header required"))
+				httpHandlers.RenderError(w, r, http.StatusUnauthorized, httpHandlers.ErrCodeUnauthorized, "Authorization header required")
 				return
 			}
 
 			// Check for Bearer token format
 			if !strings.HasPrefix(authHeader, "Bearer ") {
 				logger.Debug("Invalid Authorization header format")
-				render.Status(r, http.StatusUnauthorized)
-				render.Render(w, r, httpHandlers.NewErrorResponse("Invalid authorization format"))
+				httpHandlers.RenderError(w, r, http.StatusUnauthorized, httpHandlers.ErrCodeUnauthorized, "Invalid authorization format")
 				return
 			}
 
@@ -46,24 +42,22 @@ func JWTAuth(jwtManager *auth.JWTManager, logger *logrus.Logger) func(http.Handl
 			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 			if tokenString == "" {
 				logger.Debug("Empty token in Authorization header")
-				render.Status(r, http.StatusUnauthorized)
-				render.Render(w, r, httpHandlers.NewErrorResponse("Token required"))
+				httpHandlers.RenderError(w, r, http.StatusUnauthorized, httpHandlers.ErrCodeUnauthorized, "Token required")
 				return
 			}
 
 			// Validate token
-			claims, err := jwtManager.ValidateAppToken(tokenString)
+			claims, err := jwtManager.ValidateToken(tokenString, auth.TokenTypeApp)
 			if err != nil {
 				logger.WithFields(logrus.Fields{
 					"error": err,
 				}).Debug("Invalid JWT token")
-				render.Status(r, http.StatusUnauthorized)
-				render.Render(w, r, httpHandlers.NewErrorResponse("Invalid token"))
+				httpHandlers.RenderError(w, r, http.StatusUnauthorized, httpHandlers.ErrCodeUnauthorized, "Invalid token")
 				return
 			}
 
 			// Add user information to context
-			ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+			ctx := httpHandlers.WithUserID(r.Context(), claims.UserID)
 			ctx = context.WithValue(ctx, telegramIDKey, claims.TelegramID)
 			ctx = context.WithValue(ctx, tokenTypeKey, claims.TokenType)
 
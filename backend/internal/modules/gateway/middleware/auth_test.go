@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/auth"
+	httpHandlers "github.com/megaherz/ndr/internal/modules/gateway/http"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// serveWithToken sends a request through JWTAuth and returns the user ID the next handler saw
+func serveWithToken(jwtManager auth.JWTManager, authorization string) (*httptest.ResponseRecorder, uuid.UUID) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	var seen uuid.UUID
+	handler := JWTAuth(jwtManager, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = httpHandlers.UserIDFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, seen
+}
+
+func TestJWTAuth_SetsUserIDFromAccessToken(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", "ndr")
+	userID := uuid.New()
+	token, err := jwtManager.GenerateAccessToken(userID, 42, time.Hour)
+	require.NoError(t, err)
+
+	rec, seen := serveWithToken(jwtManager, "Bearer "+token)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, userID, seen)
+}
+
+func TestJWTAuth_RejectsMissingAndNonAccessTokens(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", "ndr")
+	refreshToken, err := jwtManager.GenerateRefreshToken(uuid.New(), 42, time.Hour)
+	require.NoError(t, err)
+
+	for _, authorization := range []string{"", "Token abc", "Bearer " + refreshToken} {
+		rec, seen := serveWithToken(jwtManager, authorization)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, authorization)
+		assert.Equal(t, uuid.Nil, seen)
+	}
+}
+
+// stubUserRepository serves users by ID
+type stubUserRepository struct {
+	repository.UserRepository
+	users map[uuid.UUID]*models.User
+}
+
+func (r *stubUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return r.users[id], nil
+}
+
+func TestRejectBanned(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	bannedAt := time.Now()
+	active := &models.User{ID: uuid.New()}
+	banned := &models.User{ID: uuid.New(), BannedAt: &bannedAt}
+	users := &stubUserRepository{users: map[uuid.UUID]*models.User{active.ID: active, banned.ID: banned}}
+
+	handler := RejectBanned(users, logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(userID uuid.UUID) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		req = req.WithContext(httpHandlers.WithUserID(req.Context(), userID))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve(active.ID))
+	assert.Equal(t, http.StatusForbidden, serve(banned.ID))
+	assert.Equal(t, http.StatusUnauthorized, serve(uuid.New()))
+
+	// Lifting the ban restores access without a new token
+	banned.BannedAt = nil
+	assert.Equal(t, http.StatusNoContent, serve(banned.ID))
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+
+	httpHandlers "github.com/megaherz/ndr/internal/modules/gateway/http"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+)
+
+// RejectBanned creates a middleware that rejects requests from banned users with 403, so access
+// tokens issued before a ban stop working immediately.
+// It must run after JWTAuth so the user ID is available in the request context.
+func RejectBanned(userRepo repository.UserRepository, logger *logrus.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, err := httpHandlers.UserIDFromContext(r.Context())
+			if err != nil {
+				httpHandlers.RenderError(w, r, http.StatusUnauthorized, httpHandlers.ErrCodeUnauthorized, "Authentication required")
+				return
+			}
+
+			user, err := userRepo.GetByID(r.Context(), userID)
+			if err != nil {
+				logger.WithFields(logrus.Fields{
+					"user_id": userID,
+					"error":   err,
+				}).Error("Failed to check user ban")
+				httpHandlers.RenderError(w, r, http.StatusInternalServerError, httpHandlers.ErrCodeInternal, "Failed to check user")
+				return
+			}
+			if user == nil {
+				httpHandlers.RenderError(w, r, http.StatusUnauthorized, httpHandlers.ErrCodeUnauthorized, "User not found")
+				return
+			}
+
+			if user.IsBanned() {
+				logger.WithFields(logrus.Fields{
+					"user_id": userID,
+					"path":    r.URL.Path,
+				}).Warn("Banned user attempted to access the API")
+				httpHandlers.RenderError(w, r, http.StatusForbidden, httpHandlers.ErrCodeForbidden, "Account banned")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
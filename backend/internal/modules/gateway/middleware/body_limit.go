@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	httpHandlers "github.com/megaherz/ndr/internal/modules/gateway/http"
+)
+
+// MaxBodySize caps request bodies at limit bytes.
+// Requests announcing a larger Content-Length are rejected with 413 straight away; other bodies
+// fail to read past the limit, which handlers report as 413. Non-positive limits disable the cap.
+func MaxBodySize(limit int64) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				httpHandlers.RenderError(w, r, http.StatusRequestEntityTooLarge, httpHandlers.ErrCodeTooLarge, "Request body too large")
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	authservice "github.com/megaherz/ndr/internal/modules/auth"
+	httpHandlers "github.com/megaherz/ndr/internal/modules/gateway/http"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+const testMaxBodyBytes = 1024
+
+// stubAuthService authenticates any initData and counts the calls
+type stubAuthService struct {
+	authservice.AuthService
+	calls int
+}
+
+func (s *stubAuthService) Authenticate(ctx context.Context, initData string) (*authservice.AuthResult, error) {
+	s.calls++
+	return &authservice.AuthResult{User: &models.User{ID: uuid.New()}}, nil
+}
+
+// serveAuth posts body to the auth endpoint behind MaxBodySize; chunked requests carry no Content-Length
+func serveAuth(service *stubAuthService, body string, chunked bool) *httptest.ResponseRecorder {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	r := chi.NewRouter()
+	r.Use(MaxBodySize(testMaxBodyBytes))
+	httpHandlers.NewAuthHandler(service, logger).RegisterRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/telegram", strings.NewReader(body))
+	if chunked {
+		req.ContentLength = -1
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func authBody(t *testing.T, initDataLen int) string {
+	body, err := json.Marshal(httpHandlers.TelegramAuthRequest{InitData: strings.Repeat("a", initDataLen)})
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMaxBodySize_AcceptsNormalBody(t *testing.T) {
+	service := &stubAuthService{}
+
+	rec := serveAuth(service, authBody(t, 100), false)
+
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 1, service.calls)
+}
+
+func TestMaxBodySize_RejectsOversizedBody(t *testing.T) {
+	for _, chunked := range []bool{false, true} {
+		service := &stubAuthService{}
+
+		rec := serveAuth(service, authBody(t, 4*testMaxBodyBytes), chunked)
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, "chunked=%v: %s", chunked, rec.Body.String())
+		var response httpHandlers.ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, httpHandlers.ErrCodeTooLarge, response.Code)
+		assert.Zero(t, service.calls, "chunked=%v", chunked)
+	}
+}
+
+func TestMaxBodySize_DisabledWithoutLimit(t *testing.T) {
+	reached := false
+	handler := MaxBodySize(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/auth/telegram", strings.NewReader(strings.Repeat("a", 4*testMaxBodyBytes))))
+
+	assert.True(t, reached)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
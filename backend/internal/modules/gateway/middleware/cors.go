@@ -4,15 +4,38 @@ import (
 	"net/http"
 )
 
-// CORS creates a CORS middleware for Telegram Mini App
-func CORS() func(next http.Handler) http.Handler {
+// CORSAllowAll is the allowlist entry that accepts any origin (development only)
+const CORSAllowAll = "*"
+
+// CORS creates a CORS middleware for the Telegram Mini App.
+// The request origin is echoed back only when it is in allowedOrigins;
+// an allowedOrigins entry of CORSAllowAll accepts every origin.
+func CORS(allowedOrigins []string) func(next http.Handler) http.Handler {
+	allowAll := false
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin == CORSAllowAll {
+			allowAll = true
+			continue
+		}
+		allowed[origin] = true
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+			// The response depends on the Origin header, so caches must key on it
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin != "" && (allowAll || allowed[origin]) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
+			}
 
-			if r.Method == "OPTIONS" {
+			// Preflight requests are answered here; browsers enforce the missing
+			// Allow-Origin header for origins that are not allowed
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 				w.WriteHeader(http.StatusOK)
 				return
 			}
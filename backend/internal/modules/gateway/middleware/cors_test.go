@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMiniAppOrigin = "https://app.nitrodrag.example"
+
+func serveCORS(allowedOrigins []string, method, origin string, preflight bool) (*httptest.ResponseRecorder, bool) {
+	reached := false
+	handler := CORS(allowedOrigins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(method, "/api/v1/", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if preflight {
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, reached
+}
+
+func TestCORS_AllowedOrigin(t *testing.T) {
+	rec, reached := serveCORS([]string{testMiniAppOrigin}, http.MethodGet, testMiniAppOrigin, false)
+
+	assert.True(t, reached)
+	assert.Equal(t, testMiniAppOrigin, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rec.Header().Get("Vary"))
+}
+
+func TestCORS_DisallowedOrigin(t *testing.T) {
+	rec, reached := serveCORS([]string{testMiniAppOrigin}, http.MethodGet, "https://evil.example", false)
+
+	assert.True(t, reached)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Origin", rec.Header().Get("Vary"))
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	tests := []struct {
+		name        string
+		origin      string
+		allowOrigin string
+	}{
+		{name: "allowed origin", origin: testMiniAppOrigin, allowOrigin: testMiniAppOrigin},
+		{name: "disallowed origin", origin: "https://evil.example", allowOrigin: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, reached := serveCORS([]string{testMiniAppOrigin}, http.MethodOptions, tt.origin, true)
+
+			assert.False(t, reached)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.allowOrigin, rec.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestCORS_WildcardEchoesAnyOrigin(t *testing.T) {
+	rec, _ := serveCORS([]string{CORSAllowAll}, http.MethodGet, "http://localhost:5173", false)
+
+	assert.Equal(t, "http://localhost:5173", rec.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/megaherz/ndr/internal/metrics"
+)
+
+// unmatchedRoute is the endpoint label of requests that matched no route
+const unmatchedRoute = "unmatched"
+
+// Metrics creates a middleware that records request counts, durations and in-flight requests.
+// Endpoints are labelled by their Chi route pattern so path parameters don't create new series.
+func Metrics(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if m == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			m.HTTPRequestsInFlight.Inc()
+			defer m.HTTPRequestsInFlight.Dec()
+
+			// Create a response writer wrapper to capture status code
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			// Handlers that never write a header implicitly respond 200
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			m.RecordHTTPRequest(r.Method, routePattern(r), strconv.Itoa(status), time.Since(start))
+		})
+	}
+}
+
+// routePattern returns the Chi route pattern the request was served by.
+// It is only complete once routing finished, i.e. after the next handler returned.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return unmatchedRoute
+	}
+	if pattern := rctx.RoutePattern(); pattern != "" {
+		return pattern
+	}
+	return unmatchedRoute
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/metrics"
+)
+
+// histogramSampleCount returns how many observations a histogram series recorded
+func histogramSampleCount(t *testing.T, reg *prometheus.Registry, name string, labels map[string]string) uint64 {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matched := 0
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] == label.GetValue() {
+					matched++
+				}
+			}
+			if matched == len(labels) {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func newMetricsRouter(m *metrics.Metrics, inFlight *float64) chi.Router {
+	r := chi.NewRouter()
+	r.Use(Metrics(m))
+	r.Get("/matches/{id}", func(w http.ResponseWriter, r *http.Request) {
+		*inFlight = testutil.ToFloat64(m.HTTPRequestsInFlight)
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Post("/auth/telegram", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	return r
+}
+
+func TestMetrics_RecordsRequests(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := metrics.NewWithRegistry(reg)
+	var inFlight float64
+	router := newMetricsRouter(m, &inFlight)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/matches/42", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/auth/telegram", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequestsTotal.WithLabelValues(http.MethodGet, "/matches/{id}", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.HTTPRequestsTotal.WithLabelValues(http.MethodPost, "/auth/telegram", "401")))
+	assert.Equal(t, uint64(1), histogramSampleCount(t, reg, "http_request_duration_seconds", map[string]string{"method": http.MethodGet, "endpoint": "/matches/{id}"}))
+	assert.Equal(t, uint64(1), histogramSampleCount(t, reg, "http_request_duration_seconds", map[string]string{"method": http.MethodPost, "endpoint": "/auth/telegram"}))
+
+	// The request counted itself while in flight and is done now
+	assert.Equal(t, 1.0, inFlight)
+	assert.Zero(t, testutil.ToFloat64(m.HTTPRequestsInFlight))
+}
+
+func TestMetrics_UnmatchedRoutesShareOneLabel(t *testing.T) {
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	var inFlight float64
+	router := newMetricsRouter(m, &inFlight)
+
+	for _, path := range []string{"/nope", "/wp-admin.php", "/matches"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusNotFound, rec.Code, path)
+	}
+
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.HTTPRequestsTotal.WithLabelValues(http.MethodGet, unmatchedRoute, "404")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.HTTPRequestsTotal))
+}
+
+func TestMetrics_MatchIDsShareOneEndpointLabel(t *testing.T) {
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+
+	// Nested like the API router: versioned route, protected group, match routes
+	r := chi.NewRouter()
+	r.Use(Metrics(m))
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Route("/matches", func(r chi.Router) {
+				r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {})
+				r.Post("/{id}/spectate", func(w http.ResponseWriter, r *http.Request) {})
+			})
+		})
+	})
+
+	matchIDs := []string{
+		"5f0c7e64-3f0e-4a8e-9d53-0c1c2b4a9e11",
+		"a2b9d0e8-7c55-4f7a-8e0e-6d2f9c3b1a22",
+		"0d4e8f1a-2b3c-4d5e-8f6a-7b8c9d0e1f33",
+	}
+	for _, id := range matchIDs {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			path := "/api/v1/matches/" + id
+			if method == http.MethodPost {
+				path += "/spectate"
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+			require.Equal(t, http.StatusOK, rec.Code, path)
+		}
+	}
+
+	// One series per route, whatever the match ID
+	assert.Equal(t, 2, testutil.CollectAndCount(m.HTTPRequestsTotal))
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.HTTPRequestsTotal.WithLabelValues(http.MethodGet, "/api/v1/matches/{id}", "200")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.HTTPRequestsTotal.WithLabelValues(http.MethodPost, "/api/v1/matches/{id}/spectate", "200")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.HTTPRequestDuration))
+}
@@ -0,0 +1,355 @@
+package gateway
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/metrics"
+)
+
+// Default retrying publisher settings
+const (
+	defaultRetryMaxAttempts   = 5
+	defaultRetryBaseBackoff   = 100 * time.Millisecond
+	defaultRetryMaxBackoff    = 5 * time.Second
+	defaultRetryFlushInterval = 50 * time.Millisecond
+)
+
+// queuedEvent is an event waiting for delivery on its channel
+type queuedEvent struct {
+	eventType string
+	publish   func(ctx context.Context) error
+}
+
+// channelBuffer holds a channel's undelivered events in publish order
+type channelBuffer struct {
+	events   []*queuedEvent
+	attempts int       // Failed deliveries of the first event
+	retryAt  time.Time // When the first event may be retried
+}
+
+// retryingPublisher implements AsyncPublisher with a buffer per channel. A worker flushes
+// every buffer in order, retrying a failed event with exponential backoff before the events
+// queued behind it, so a failing channel never reorders or holds up other channels.
+type retryingPublisher struct {
+	next           CentrifugoPublisher
+	maxQueued      int
+	maxAttempts    int
+	baseBackoff    time.Duration
+	maxBackoff     time.Duration
+	flushInterval  time.Duration
+	publishTimeout time.Duration
+	drainTimeout   time.Duration
+	metrics        *metrics.Metrics
+	wake           chan struct{}
+	done           chan struct{}
+	logger         *logrus.Logger
+
+	mu       sync.Mutex
+	channels map[string]*channelBuffer
+	queued   int
+}
+
+// RetryingPublisherOption configures optional retrying publisher behaviour
+type RetryingPublisherOption func(*retryingPublisher)
+
+// WithRetryQueueSize sets how many events may wait for delivery across all channels;
+// non-positive values keep the default
+func WithRetryQueueSize(size int) RetryingPublisherOption {
+	return func(p *retryingPublisher) {
+		if size > 0 {
+			p.maxQueued = size
+		}
+	}
+}
+
+// WithRetryMaxAttempts sets how many times an event is tried before it is dropped;
+// non-positive values keep the default
+func WithRetryMaxAttempts(attempts int) RetryingPublisherOption {
+	return func(p *retryingPublisher) {
+		if attempts > 0 {
+			p.maxAttempts = attempts
+		}
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry, doubled on every further failure up to
+// max; non-positive values keep the defaults
+func WithRetryBackoff(base, max time.Duration) RetryingPublisherOption {
+	return func(p *retryingPublisher) {
+		if base > 0 {
+			p.baseBackoff = base
+		}
+		if max > 0 {
+			p.maxBackoff = max
+		}
+	}
+}
+
+// WithRetryFlushInterval sets how often buffers waiting on a retry are checked;
+// non-positive values keep the default
+func WithRetryFlushInterval(interval time.Duration) RetryingPublisherOption {
+	return func(p *retryingPublisher) {
+		if interval > 0 {
+			p.flushInterval = interval
+		}
+	}
+}
+
+// WithRetryPublisherMetrics records the queue depth, failed deliveries and dropped events
+func WithRetryPublisherMetrics(m *metrics.Metrics) RetryingPublisherOption {
+	return func(p *retryingPublisher) {
+		p.metrics = m
+	}
+}
+
+// NewRetryingPublisher creates a publisher that buffers events per channel and delivers them
+// through next, retrying failed deliveries with backoff
+func NewRetryingPublisher(next CentrifugoPublisher, logger *logrus.Logger, opts ...RetryingPublisherOption) AsyncPublisher {
+	p := &retryingPublisher{
+		next:           next,
+		maxQueued:      defaultPublishQueueSize,
+		maxAttempts:    defaultRetryMaxAttempts,
+		baseBackoff:    defaultRetryBaseBackoff,
+		maxBackoff:     defaultRetryMaxBackoff,
+		flushInterval:  defaultRetryFlushInterval,
+		publishTimeout: defaultPublishTimeout,
+		drainTimeout:   defaultPublishDrainTimeout,
+		wake:           make(chan struct{}, 1),
+		done:           make(chan struct{}),
+		logger:         logger,
+		channels:       make(map[string]*channelBuffer),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// PublishToUser buffers an event for a user's personal channel
+func (p *retryingPublisher) PublishToUser(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) error {
+	p.enqueue("user:"+userID.String(), eventType, func(ctx context.Context) error {
+		return p.next.PublishToUser(ctx, userID, eventType, data)
+	})
+	return nil
+}
+
+// PublishToMatch buffers an event for a match channel
+func (p *retryingPublisher) PublishToMatch(ctx context.Context, matchID uuid.UUID, eventType string, data interface{}) error {
+	p.enqueue("match:"+matchID.String(), eventType, func(ctx context.Context) error {
+		return p.next.PublishToMatch(ctx, matchID, eventType, data)
+	})
+	return nil
+}
+
+// PublishToUsers buffers an event for every user's personal channel, so each user's
+// delivery is retried independently
+func (p *retryingPublisher) PublishToUsers(ctx context.Context, userIDs []uuid.UUID, eventType string, data interface{}) error {
+	for _, userID := range userIDs {
+		if err := p.PublishToUser(ctx, userID, eventType, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BroadcastToChannel buffers an event for a specific channel
+func (p *retryingPublisher) BroadcastToChannel(ctx context.Context, channel string, eventType string, data interface{}) error {
+	p.enqueue(channel, eventType, func(ctx context.Context) error {
+		return p.next.BroadcastToChannel(ctx, channel, eventType, data)
+	})
+	return nil
+}
+
+// enqueue appends an event to its channel's buffer, dropping it if every buffer slot is taken
+func (p *retryingPublisher) enqueue(channel, eventType string, publish func(ctx context.Context) error) {
+	p.mu.Lock()
+	if p.queued >= p.maxQueued {
+		p.mu.Unlock()
+
+		p.logger.WithFields(logrus.Fields{
+			"event_type": eventType,
+			"channel":    channel,
+		}).Warn("Realtime publish queue is full, dropping event")
+		p.recordDropped(eventType, metrics.RealtimeDropQueueFull)
+		return
+	}
+
+	buffer, ok := p.channels[channel]
+	if !ok {
+		buffer = &channelBuffer{}
+		p.channels[channel] = buffer
+	}
+	buffer.events = append(buffer.events, &queuedEvent{eventType: eventType, publish: publish})
+	p.queued++
+	p.setQueueDepth()
+	p.mu.Unlock()
+
+	// Wake the worker without waiting for the next flush tick
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the flush worker until ctx is cancelled, then delivers the events still buffered
+func (p *retryingPublisher) Start(ctx context.Context) {
+	p.logger.WithFields(logrus.Fields{
+		"queue_size":   p.maxQueued,
+		"max_attempts": p.maxAttempts,
+	}).Info("Starting retrying realtime publisher")
+
+	go func() {
+		defer close(p.done)
+
+		ticker := time.NewTicker(p.flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.wake:
+				p.flush(context.Background(), false)
+			case <-ticker.C:
+				p.flush(context.Background(), false)
+			case <-ctx.Done():
+				p.drain()
+				p.logger.Info("Retrying realtime publisher stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Done is closed once the worker has flushed its last buffer and exited
+func (p *retryingPublisher) Done() <-chan struct{} {
+	return p.done
+}
+
+// drain tries every buffered event once more, giving up on the rest once the drain timeout
+// passes so an unavailable Centrifugo cannot hold up shutdown
+func (p *retryingPublisher) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.drainTimeout)
+	defer cancel()
+
+	p.flush(ctx, true)
+}
+
+// flush delivers the buffered events of every channel that is not backing off.
+// The final flush ignores backoff and drops events that fail instead of retrying them.
+func (p *retryingPublisher) flush(ctx context.Context, final bool) {
+	now := time.Now()
+
+	p.mu.Lock()
+	ready := make([]string, 0, len(p.channels))
+	for channel, buffer := range p.channels {
+		if final || !now.Before(buffer.retryAt) {
+			ready = append(ready, channel)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, channel := range ready {
+		p.flushChannel(ctx, channel, final)
+	}
+}
+
+// flushChannel delivers a channel's events in order until its buffer is empty or a delivery fails
+func (p *retryingPublisher) flushChannel(ctx context.Context, channel string, final bool) {
+	for {
+		p.mu.Lock()
+		buffer, ok := p.channels[channel]
+		if !ok {
+			p.mu.Unlock()
+			return
+		}
+		event := buffer.events[0]
+		p.mu.Unlock()
+
+		err := p.deliver(ctx, event)
+
+		p.mu.Lock()
+		if err == nil {
+			p.pop(channel)
+			p.mu.Unlock()
+			continue
+		}
+
+		buffer.attempts++
+		attempts := buffer.attempts
+		exhausted := final || attempts >= p.maxAttempts
+		if exhausted {
+			p.pop(channel)
+		} else {
+			buffer.retryAt = time.Now().Add(p.backoff(attempts))
+		}
+		p.mu.Unlock()
+
+		logger := p.logger.WithFields(logrus.Fields{
+			"event_type": event.eventType,
+			"channel":    channel,
+			"attempts":   attempts,
+			"error":      err,
+		})
+		if p.metrics != nil {
+			p.metrics.RecordRealtimePublishFailure(event.eventType)
+		}
+
+		if !exhausted {
+			logger.Warn("Failed to publish realtime event, retrying")
+			return
+		}
+		logger.Error("Failed to publish realtime event, dropping it")
+		p.recordDropped(event.eventType, metrics.RealtimeDropRetriesExhausted)
+	}
+}
+
+// deliver publishes an event within the publish timeout
+func (p *retryingPublisher) deliver(ctx context.Context, event *queuedEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, p.publishTimeout)
+	defer cancel()
+
+	return event.publish(ctx)
+}
+
+// pop removes the first event of a channel's buffer; callers must hold p.mu
+func (p *retryingPublisher) pop(channel string) {
+	buffer := p.channels[channel]
+	buffer.events = buffer.events[1:]
+	buffer.attempts = 0
+	buffer.retryAt = time.Time{}
+	if len(buffer.events) == 0 {
+		delete(p.channels, channel)
+	}
+	p.queued--
+	p.setQueueDepth()
+}
+
+// backoff returns the delay before retrying an event that failed the given number of times
+func (p *retryingPublisher) backoff(attempts int) time.Duration {
+	delay := p.baseBackoff
+	for i := 1; i < attempts && delay < p.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.maxBackoff {
+		return p.maxBackoff
+	}
+	return delay
+}
+
+// setQueueDepth reports the number of buffered events; callers must hold p.mu
+func (p *retryingPublisher) setQueueDepth() {
+	if p.metrics != nil {
+		p.metrics.SetRealtimePublishQueueDepth(float64(p.queued))
+	}
+}
+
+// recordDropped counts an event dropped before delivery
+func (p *retryingPublisher) recordDropped(eventType, reason string) {
+	if p.metrics != nil {
+		p.metrics.RecordRealtimeEventDropped(eventType, reason)
+	}
+}
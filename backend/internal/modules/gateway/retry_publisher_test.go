@@ -0,0 +1,174 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/metrics"
+)
+
+// flakyPublisher fails the match deliveries its fail func rejects and records the rest in delivery order
+type flakyPublisher struct {
+	CentrifugoPublisher
+
+	mu        sync.Mutex
+	fail      func(eventType string, attempt int) bool
+	attempts  map[string]int
+	delivered []string
+}
+
+func newFlakyPublisher(fail func(eventType string, attempt int) bool) *flakyPublisher {
+	return &flakyPublisher{fail: fail, attempts: make(map[string]int)}
+}
+
+func (p *flakyPublisher) PublishToMatch(ctx context.Context, matchID uuid.UUID, eventType string, data interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.attempts[eventType]++
+	if p.fail != nil && p.fail(eventType, p.attempts[eventType]) {
+		return errors.New("centrifugo unavailable")
+	}
+	p.delivered = append(p.delivered, eventType)
+	return nil
+}
+
+func (p *flakyPublisher) setFail(fail func(eventType string, attempt int) bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fail = fail
+}
+
+func (p *flakyPublisher) deliveredTypes() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.delivered...)
+}
+
+func newTestRetryingPublisher(next CentrifugoPublisher, m *metrics.Metrics, opts ...RetryingPublisherOption) AsyncPublisher {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	opts = append([]RetryingPublisherOption{
+		WithRetryBackoff(5*time.Millisecond, 20*time.Millisecond),
+		WithRetryFlushInterval(time.Millisecond),
+		WithRetryPublisherMetrics(m),
+	}, opts...)
+	return NewRetryingPublisher(next, logger, opts...)
+}
+
+func TestRetryingPublisher_BuffersUntilCentrifugoRecovers(t *testing.T) {
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	next := newFlakyPublisher(func(string, int) bool { return true })
+	publisher := newTestRetryingPublisher(next, m, WithRetryMaxAttempts(1000))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	publisher.Start(ctx)
+
+	matchID := uuid.New()
+	for _, eventType := range []string{"first", "second", "third"} {
+		require.NoError(t, publisher.PublishToMatch(context.Background(), matchID, eventType, nil))
+	}
+
+	// While Centrifugo is down every event stays buffered
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(m.RealtimePublishFailures.WithLabelValues("first")) >= 2
+	}, time.Second, time.Millisecond)
+	assert.Empty(t, next.deliveredTypes())
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.RealtimePublishQueueDepth))
+	assert.Zero(t, testutil.ToFloat64(m.RealtimePublishFailures.WithLabelValues("second")))
+
+	// Once it recovers the buffer is flushed in publish order
+	next.setFail(nil)
+	require.Eventually(t, func() bool { return len(next.deliveredTypes()) == 3 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"first", "second", "third"}, next.deliveredTypes())
+	assert.Zero(t, testutil.ToFloat64(m.RealtimePublishQueueDepth))
+}
+
+func TestRetryingPublisher_RetriesKeepChannelOrder(t *testing.T) {
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+
+	// The first event of one match fails once; the other match is healthy
+	next := newFlakyPublisher(func(eventType string, attempt int) bool {
+		return eventType == "a1" && attempt == 1
+	})
+	publisher := newTestRetryingPublisher(next, m)
+
+	matchA, matchB := uuid.New(), uuid.New()
+	require.NoError(t, publisher.PublishToMatch(context.Background(), matchA, "a1", nil))
+	require.NoError(t, publisher.PublishToMatch(context.Background(), matchA, "a2", nil))
+	require.NoError(t, publisher.PublishToMatch(context.Background(), matchB, "b1", nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	publisher.Start(ctx)
+
+	require.Eventually(t, func() bool { return len(next.deliveredTypes()) == 3 }, time.Second, time.Millisecond)
+
+	// The retried event is still delivered before the one queued behind it,
+	// and the other match was not held up by the retry
+	assert.Equal(t, []string{"b1", "a1", "a2"}, next.deliveredTypes())
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RealtimePublishFailures.WithLabelValues("a1")))
+}
+
+func TestRetryingPublisher_FullQueueDropsNewEvents(t *testing.T) {
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	next := newFlakyPublisher(nil)
+	publisher := newTestRetryingPublisher(next, m, WithRetryQueueSize(2))
+
+	// Without a running worker the buffers fill after two events, across channels
+	for _, eventType := range []string{"kept", "kept", "dropped"} {
+		require.NoError(t, publisher.PublishToMatch(context.Background(), uuid.New(), eventType, nil))
+	}
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.RealtimePublishQueueDepth))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RealtimeEventsDropped.WithLabelValues("dropped", metrics.RealtimeDropQueueFull)))
+
+	// Stopping the worker still flushes what was buffered
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	publisher.Start(ctx)
+	<-publisher.Done()
+	assert.Equal(t, []string{"kept", "kept"}, next.deliveredTypes())
+	assert.Zero(t, testutil.ToFloat64(m.RealtimePublishQueueDepth))
+}
+
+func TestRetryingPublisher_DropsEventAfterMaxAttempts(t *testing.T) {
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	next := newFlakyPublisher(func(eventType string, attempt int) bool { return eventType == "poison" })
+	publisher := newTestRetryingPublisher(next, m, WithRetryMaxAttempts(3))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	publisher.Start(ctx)
+
+	matchID := uuid.New()
+	require.NoError(t, publisher.PublishToMatch(context.Background(), matchID, "poison", nil))
+	require.NoError(t, publisher.PublishToMatch(context.Background(), matchID, "after", nil))
+
+	// The failing event gives way to the next one once its attempts run out
+	require.Eventually(t, func() bool { return len(next.deliveredTypes()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"after"}, next.deliveredTypes())
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.RealtimePublishFailures.WithLabelValues("poison")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RealtimeEventsDropped.WithLabelValues("poison", metrics.RealtimeDropRetriesExhausted)))
+}
+
+func TestRetryingPublisher_BackoffDoublesUpToMax(t *testing.T) {
+	p := NewRetryingPublisher(nil, logrus.New(), WithRetryBackoff(100*time.Millisecond, time.Second)).(*retryingPublisher)
+
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, p.backoff(4))
+	assert.Equal(t, time.Second, p.backoff(5))
+	assert.Equal(t, time.Second, p.backoff(50))
+}
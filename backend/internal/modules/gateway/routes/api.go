@@ -22,17 +22,37 @@ func SetupRoutes(container *services.Container, logger *logrus.Logger) chi.Route
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(gatewayMiddleware.LogrusMiddleware(logger))
+	r.Use(gatewayMiddleware.Metrics(container.Metrics))
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
 
+	// Oversized request bodies are rejected before handlers decode them
+	r.Use(gatewayMiddleware.MaxBodySize(container.Config.MaxRequestBytes))
+
 	// CORS middleware for Telegram Mini App
-	r.Use(gatewayMiddleware.CORS())
+	r.Use(gatewayMiddleware.CORS(container.Config.CORSAllowedOrigins))
+
+	// Unknown routes use the same error envelope as handlers
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		httpHandlers.RenderError(w, r, http.StatusNotFound, httpHandlers.ErrCodeNotFound, "Route not found")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		httpHandlers.RenderError(w, r, http.StatusMethodNotAllowed, httpHandlers.ErrCodeInvalidRequest, "Method not allowed")
+	})
 
 	// Initialize handlers
 	authHandler := httpHandlers.NewAuthHandler(container.AuthService, logger)
 	healthHandler := httpHandlers.NewHealthHandler(container, logger)
 	walletHandler := httpHandlers.NewWalletHandler(container.AccountService, logger)
 	garageHandler := httpHandlers.NewGarageHandler(container.AccountService, container.UserRepo, logger)
+	meHandler := httpHandlers.NewMeHandler(container.AccountService, container.UserRepo, logger)
+	adminHandler := httpHandlers.NewAdminHandler(container.MatchAborter, container.LedgerRepo, container.UserRepo, logger,
+		httpHandlers.WithBalanceAdjustments(container.LedgerOps, container.Config.MaxFuelAdjustment()),
+		httpHandlers.WithSeasons(container.SeasonRepo))
+	seasonHandler := httpHandlers.NewSeasonHandler(container.SeasonRepo, logger)
+	matchmakingHandler := httpHandlers.NewMatchmakingHandler(container.MatchmakerService, container.Leagues, logger)
+	matchHandler := httpHandlers.NewMatchHandler(container.GameEngineService, container.CentrifugoTokens, container.SeedCommits, container.CentrifugoClient, container.CentrifugoClient, logger,
+		httpHandlers.WithTargetToBeat(container.EarnPoints))
 
 	// Health check endpoint (outside of API versioning)
 	healthHandler.RegisterRoutes(r)
@@ -55,11 +75,33 @@ func SetupRoutes(container *services.Container, logger *logrus.Logger) chi.Route
 			// JWT authentication middleware
 			r.Use(gatewayMiddleware.JWTAuth(container.JWTManager, logger))
 
+			// Banned users are rejected even with an unexpired token
+			r.Use(gatewayMiddleware.RejectBanned(container.UserRepo, logger))
+
+			// Profile routes
+			meHandler.RegisterRoutes(r)
+
 			// Wallet routes
 			walletHandler.RegisterRoutes(r)
 
 			// Garage routes
 			garageHandler.RegisterRoutes(r)
+
+			// Match routes
+			matchHandler.RegisterRoutes(r)
+
+			// Matchmaking routes
+			matchmakingHandler.RegisterRoutes(r)
+
+			// Season leaderboard and stats routes
+			seasonHandler.RegisterRoutes(r)
+
+			// Admin routes (require an admin user)
+			r.Group(func(r chi.Router) {
+				r.Use(gatewayMiddleware.AdminOnly(container.Config.AdminUserIDs, logger))
+
+				adminHandler.RegisterRoutes(r)
+			})
 		})
 	})
 
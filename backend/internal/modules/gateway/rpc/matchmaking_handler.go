@@ -51,6 +51,17 @@ type CancelMatchmakingResponse struct {
 	Error   string `json:"error,omitempty"`
 }
 
+// MatchmakingHeartbeatRequest represents a queued client's heartbeat
+type MatchmakingHeartbeatRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// MatchmakingHeartbeatResponse represents the response to a matchmaking heartbeat
+type MatchmakingHeartbeatResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
 // HandleJoinMatchmaking handles the matchmaking.join RPC call
 func (h *MatchmakingHandler) HandleJoinMatchmaking(ctx context.Context, data []byte) ([]byte, error) {
 	var req JoinMatchmakingRequest
@@ -159,6 +170,46 @@ func (h *MatchmakingHandler) HandleCancelMatchmaking(ctx context.Context, data [
 	return json.Marshal(response)
 }
 
+// HandleMatchmakingHeartbeat handles the matchmaking.heartbeat RPC call, which queued clients
+// send periodically to keep their queue entry
+func (h *MatchmakingHandler) HandleMatchmakingHeartbeat(ctx context.Context, data []byte) ([]byte, error) {
+	var req MatchmakingHeartbeatRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"error": err,
+		}).Warn("Failed to unmarshal matchmaking heartbeat request")
+
+		return h.errorResponse("Invalid request format")
+	}
+
+	// Validate request
+	if req.UserID == "" {
+		return h.errorResponse("user_id is required")
+	}
+
+	// Parse user ID
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return h.errorResponse("Invalid user_id format")
+	}
+
+	if err := h.matchmakerService.Heartbeat(ctx, userID); err != nil {
+		h.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err,
+		}).Warn("Failed to record matchmaking heartbeat")
+
+		return h.errorResponse(fmt.Sprintf("Failed to record heartbeat: %s", err.Error()))
+	}
+
+	// Return success response
+	response := MatchmakingHeartbeatResponse{
+		Success: true,
+	}
+
+	return json.Marshal(response)
+}
+
 // errorResponse creates an error response
 func (h *MatchmakingHandler) errorResponse(message string) ([]byte, error) {
 	response := JoinMatchmakingResponse{
@@ -0,0 +1,51 @@
+package matchmaker
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultCapacityWait is how long a ready lobby waits for a free match slot before it is cancelled
+const defaultCapacityWait = 30 * time.Second
+
+// ActiveMatchCounter reports the matches running on this server.
+// The game engine's match state manager implements it.
+type ActiveMatchCounter interface {
+	// GetActiveMatches returns the IDs of the matches in progress
+	GetActiveMatches(ctx context.Context) []uuid.UUID
+}
+
+// WithMaxConcurrentMatches caps how many matches this server runs at once. The cap is per instance:
+// active is this process's own match state, so every replica may run max matches. A lobby whose
+// countdown runs out while every slot is taken keeps waiting and starts on a later check once a
+// match ends, or is cancelled once it has waited the capacity wait. Non-positive values or a nil
+// counter leave the number of matches unlimited.
+func WithMaxConcurrentMatches(max int, active ActiveMatchCounter) LobbyManagerOption {
+	return func(lm *lobbyManager) {
+		lm.maxMatches = max
+		lm.activeMatches = active
+	}
+}
+
+// WithMatchCapacityWait sets how long a ready lobby waits for a free match slot before it is
+// cancelled and its ready players' buy-in holds are released; non-positive values keep the default
+func WithMatchCapacityWait(wait time.Duration) LobbyManagerOption {
+	return func(lm *lobbyManager) {
+		if wait > 0 {
+			lm.capacityWait = wait
+		}
+	}
+}
+
+// hasMatchCapacity reports whether this server may start another match on top of the started
+// matches that the active match count does not include yet
+func (lm *lobbyManager) hasMatchCapacity(ctx context.Context, started int) bool {
+	if lm.activeMatches == nil {
+		return true
+	}
+
+	count := len(lm.activeMatches.GetActiveMatches(ctx))
+	return lm.maxMatches <= 0 || count+started < lm.maxMatches
+}
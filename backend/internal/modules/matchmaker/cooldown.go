@@ -0,0 +1,85 @@
+package matchmaker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrCooldownActive is returned by JoinQueue while a player's post-match cooldown is running
+type ErrCooldownActive struct {
+	RemainingSeconds int
+}
+
+// Error implements the error interface
+func (e *ErrCooldownActive) Error() string {
+	return fmt.Sprintf("match cooldown active: %ds remaining", e.RemainingSeconds)
+}
+
+// MatchCooldowns blocks players from queueing again for a while after their match settles
+type MatchCooldowns interface {
+	// StartCooldown starts the cooldown of every given player after a match in a league.
+	// Matches in exempt leagues start no cooldown.
+	StartCooldown(ctx context.Context, userIDs []uuid.UUID, league string) error
+
+	// Remaining returns how long a player must wait before joining a league's queue, zero if they may join now
+	Remaining(ctx context.Context, userID uuid.UUID, league string) (time.Duration, error)
+}
+
+// redisMatchCooldowns implements MatchCooldowns with an expiring Redis key per player
+type redisMatchCooldowns struct {
+	client   *redis.Client
+	duration time.Duration
+	exempt   map[string]bool
+}
+
+// NewMatchCooldowns creates a Redis-based cooldown store; exempt leagues neither start nor honour cooldowns
+func NewMatchCooldowns(client *redis.Client, duration time.Duration, exemptLeagues ...string) MatchCooldowns {
+	exempt := make(map[string]bool, len(exemptLeagues))
+	for _, league := range exemptLeagues {
+		exempt[league] = true
+	}
+	return &redisMatchCooldowns{client: client, duration: duration, exempt: exempt}
+}
+
+// getCooldownKey returns the Redis key marking a player's cooldown
+func (c *redisMatchCooldowns) getCooldownKey(userID uuid.UUID) string {
+	return fmt.Sprintf("matchmaking:cooldown:%s", userID.String())
+}
+
+// StartCooldown starts the cooldown of every given player after a match in a league
+func (c *redisMatchCooldowns) StartCooldown(ctx context.Context, userIDs []uuid.UUID, league string) error {
+	if c.duration <= 0 || c.exempt[league] || len(userIDs) == 0 {
+		return nil
+	}
+
+	pipe := c.client.Pipeline()
+	for _, userID := range userIDs {
+		pipe.Set(ctx, c.getCooldownKey(userID), league, c.duration)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to start match cooldowns: %w", err)
+	}
+	return nil
+}
+
+// Remaining returns how long a player must wait before joining a league's queue
+func (c *redisMatchCooldowns) Remaining(ctx context.Context, userID uuid.UUID, league string) (time.Duration, error) {
+	if c.duration <= 0 || c.exempt[league] {
+		return 0, nil
+	}
+
+	ttl, err := c.client.PTTL(ctx, c.getCooldownKey(userID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get match cooldown: %w", err)
+	}
+
+	// Missing keys report a negative TTL
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
@@ -0,0 +1,79 @@
+package matchmaker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// QueueHeartbeats tracks the heartbeats queued clients send while they wait for a match,
+// so entries of clients that crashed or vanished can be pruned
+type QueueHeartbeats interface {
+	// Beat records a heartbeat for a player, keeping their queue entry alive for another timeout
+	Beat(ctx context.Context, userID uuid.UUID) error
+
+	// Alive returns the subset of the given players whose heartbeat has not lapsed
+	Alive(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+
+	// Clear drops a player's heartbeat once they leave the queue
+	Clear(ctx context.Context, userID uuid.UUID) error
+}
+
+// redisQueueHeartbeats implements QueueHeartbeats with an expiring Redis key per player
+type redisQueueHeartbeats struct {
+	client  *redis.Client
+	timeout time.Duration
+}
+
+// NewQueueHeartbeats creates a Redis-based heartbeat store; a heartbeat lapses once timeout passes without another
+func NewQueueHeartbeats(client *redis.Client, timeout time.Duration) QueueHeartbeats {
+	return &redisQueueHeartbeats{client: client, timeout: timeout}
+}
+
+// getHeartbeatKey returns the Redis key holding a player's last heartbeat
+func (h *redisQueueHeartbeats) getHeartbeatKey(userID uuid.UUID) string {
+	return fmt.Sprintf("matchmaking:heartbeat:%s", userID.String())
+}
+
+// Beat records a heartbeat for a player
+func (h *redisQueueHeartbeats) Beat(ctx context.Context, userID uuid.UUID) error {
+	if err := h.client.Set(ctx, h.getHeartbeatKey(userID), time.Now().Unix(), h.timeout).Err(); err != nil {
+		return fmt.Errorf("failed to record queue heartbeat: %w", err)
+	}
+	return nil
+}
+
+// Alive returns the subset of the given players whose heartbeat has not lapsed
+func (h *redisQueueHeartbeats) Alive(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	alive := make(map[uuid.UUID]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return alive, nil
+	}
+
+	pipe := h.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(userIDs))
+	for i, userID := range userIDs {
+		cmds[i] = pipe.Exists(ctx, h.getHeartbeatKey(userID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to check queue heartbeats: %w", err)
+	}
+
+	for i, cmd := range cmds {
+		if cmd.Val() > 0 {
+			alive[userIDs[i]] = true
+		}
+	}
+	return alive, nil
+}
+
+// Clear drops a player's heartbeat
+func (h *redisQueueHeartbeats) Clear(ctx context.Context, userID uuid.UUID) error {
+	if err := h.client.Del(ctx, h.getHeartbeatKey(userID)).Err(); err != nil {
+		return fmt.Errorf("failed to clear queue heartbeat: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package matchmaker
+
+import (
+	"errors"
+
+	"github.com/megaherz/ndr/internal/constants"
+)
+
+// LobbySize is the number of grid slots in every match
+const LobbySize = 10
+
+// defaultMinLivePlayers is how many live players a ghost-filled lobby needs by default
+const defaultMinLivePlayers = 2
+
+// ErrLobbyNotReady is returned by FormLobby when a league's queue cannot form a lobby yet
+var ErrLobbyNotReady = errors.New("lobby not ready")
+
+// LeagueRules controls how a league fills a lobby when fewer than LobbySize players are queued
+type LeagueRules struct {
+	AllowGhosts    bool // Fill empty grid slots with ghosts once the oldest player waited out the matchmaking timeout
+	MinLivePlayers int  // Live players required before ghosts may fill the rest of the grid
+}
+
+// DefaultLeagueRules returns the rules of a league without overrides
+func DefaultLeagueRules() LeagueRules {
+	return LeagueRules{AllowGhosts: true, MinLivePlayers: defaultMinLivePlayers}
+}
+
+// NewLeagueRules builds the rules of every league in the registry.
+// A nil registry gives the rules of the launch leagues.
+func NewLeagueRules(leagues *constants.LeagueRegistry) map[string]LeagueRules {
+	definitions := leagues.All()
+	rules := make(map[string]LeagueRules, len(definitions))
+	for _, league := range definitions {
+		rules[league.Name] = LeagueRules{
+			AllowGhosts:    league.AllowGhosts,
+			MinLivePlayers: league.MinLivePlayers,
+		}
+	}
+	return rules
+}
+
+// requiredLivePlayers returns how many live players must be popped to form a lobby
+func (r LeagueRules) requiredLivePlayers() int {
+	if !r.AllowGhosts || r.MinLivePlayers > LobbySize {
+		return LobbySize
+	}
+	if r.MinLivePlayers < 1 {
+		return 1
+	}
+	return r.MinLivePlayers
+}
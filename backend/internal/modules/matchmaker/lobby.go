@@ -2,15 +2,19 @@ package matchmaker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 
+	"github.com/megaherz/ndr/internal/constants"
+	monetary "github.com/megaherz/ndr/internal/decimal"
 	"github.com/megaherz/ndr/internal/modules/gameengine"
 	"github.com/megaherz/ndr/internal/modules/gateway"
 	"github.com/megaherz/ndr/internal/modules/gateway/events"
@@ -21,22 +25,26 @@ type LobbyManager interface {
 	// FormLobby attempts to form a lobby from the queue
 	FormLobby(ctx context.Context, league string) (*Lobby, error)
 
-	// CheckTimeout checks if any lobbies have timed out
+	// CheckTimeout resolves the ready check of every lobby whose countdown has run out
 	CheckTimeout(ctx context.Context) error
 
+	// SetPlayerReady marks a player ready in their forming lobby
+	SetPlayerReady(ctx context.Context, userID uuid.UUID) error
+
 	// GetActiveLobby returns an active lobby for a user
 	GetActiveLobby(ctx context.Context, userID uuid.UUID) (*Lobby, error)
 }
 
 // Lobby represents a formed lobby waiting to start a match
 type Lobby struct {
-	ID        uuid.UUID      `json:"id"`
-	League    string         `json:"league"`
-	Players   []*LobbyPlayer `json:"players"`
-	Status    LobbyStatus    `json:"status"`
-	CreatedAt time.Time      `json:"created_at"`
-	StartTime *time.Time     `json:"start_time,omitempty"`
-	TimeoutAt time.Time      `json:"timeout_at"`
+	ID         uuid.UUID      `json:"id"`
+	League     string         `json:"league"`
+	Players    []*LobbyPlayer `json:"players"`
+	Status     LobbyStatus    `json:"status"`
+	CreatedAt  time.Time      `json:"created_at"`
+	StartTime  *time.Time     `json:"start_time,omitempty"`
+	TimeoutAt  time.Time      `json:"timeout_at"`
+	GhostSlots int            `json:"ghost_slots"` // Grid slots left for ghosts to fill
 }
 
 // LobbyPlayer represents a player in a lobby
@@ -45,6 +53,7 @@ type LobbyPlayer struct {
 	DisplayName string    `json:"display_name"`
 	IsReady     bool      `json:"is_ready"`
 	JoinedAt    time.Time `json:"joined_at"`
+	QueuedAt    time.Time `json:"queued_at"` // When the player originally joined the queue
 }
 
 // LobbyStatus represents the status of a lobby
@@ -57,14 +66,81 @@ const (
 	LobbyStatusAborted   LobbyStatus = "ABORTED"   // Lobby was cancelled
 )
 
+// defaultMinReadyPlayers is how many ready players a lobby needs to start once its countdown runs out
+const defaultMinReadyPlayers = 2
+
+// ErrNotInLobby is returned by SetPlayerReady when the player is not in a forming lobby
+var ErrNotInLobby = errors.New("player is not in a forming lobby")
+
 // lobbyManager implements LobbyManager
 type lobbyManager struct {
-	queueOps     QueueOperations
-	gameEngine   gameengine.GameEngineService
-	publisher    gateway.CentrifugoPublisher
-	activeLobies map[uuid.UUID]*Lobby    // In-memory lobby storage
-	userToLobby  map[uuid.UUID]uuid.UUID // User to lobby mapping
-	logger       *logrus.Logger
+	queueOps        QueueOperations
+	gameEngine      gameengine.GameEngineService
+	publisher       gateway.CentrifugoPublisher
+	reservations    BalanceReservations
+	rakeRates       *monetary.RakeRates
+	leagues         *constants.LeagueRegistry
+	leagueRules     map[string]LeagueRules
+	minReadyPlayers int                     // Ready players needed to start once the countdown runs out
+	refundNotReady  bool                    // Release the buy-in holds of players dropped for not readying up
+	maxMatches      int                     // Matches this server may run at once, unlimited when not positive
+	activeMatches   ActiveMatchCounter      // Matches running on this server, nil when unlimited
+	capacityWait    time.Duration           // How long a ready lobby waits for a free match slot
+	mu              sync.Mutex              // Guards activeLobies and userToLobby across league workers
+	activeLobies    map[uuid.UUID]*Lobby    // In-memory lobby storage
+	userToLobby     map[uuid.UUID]uuid.UUID // User to lobby mapping
+	logger          *logrus.Logger
+}
+
+// LobbyManagerOption configures optional lobby manager behaviour
+type LobbyManagerOption func(*lobbyManager)
+
+// WithLobbyReservations releases players' buy-in holds when their match starts
+// or when their lobby is cancelled
+func WithLobbyReservations(reservations BalanceReservations) LobbyManagerOption {
+	return func(lm *lobbyManager) {
+		lm.reservations = reservations
+	}
+}
+
+// WithLobbyRakeRates sets the per-league rake used for the prize pool announced in match_found
+func WithLobbyRakeRates(rates *monetary.RakeRates) LobbyManagerOption {
+	return func(lm *lobbyManager) {
+		lm.rakeRates = rates
+	}
+}
+
+// WithLobbyLeagues sets the league buy-ins released from holds and announced in match_found.
+// Without it the launch leagues are used.
+func WithLobbyLeagues(leagues *constants.LeagueRegistry) LobbyManagerOption {
+	return func(lm *lobbyManager) {
+		lm.leagues = leagues
+	}
+}
+
+// WithLeagueRules sets per-league ghost filling rules; leagues without rules use DefaultLeagueRules
+func WithLeagueRules(rules map[string]LeagueRules) LobbyManagerOption {
+	return func(lm *lobbyManager) {
+		lm.leagueRules = rules
+	}
+}
+
+// WithMinReadyPlayers sets how many players must be ready when a lobby's countdown runs out for
+// its match to start with ghosts in the not-ready players' slots; non-positive values keep the default
+func WithMinReadyPlayers(min int) LobbyManagerOption {
+	return func(lm *lobbyManager) {
+		if min > 0 {
+			lm.minReadyPlayers = min
+		}
+	}
+}
+
+// WithNotReadyRefund releases the buy-in holds of players dropped from a lobby for not readying up.
+// By default their holds are kept until they expire, as a penalty for stalling the lobby.
+func WithNotReadyRefund(refund bool) LobbyManagerOption {
+	return func(lm *lobbyManager) {
+		lm.refundNotReady = refund
+	}
 }
 
 // NewLobbyManager creates a new lobby manager
@@ -73,15 +149,22 @@ func NewLobbyManager(
 	gameEngine gameengine.GameEngineService,
 	publisher gateway.CentrifugoPublisher,
 	logger *logrus.Logger,
+	opts ...LobbyManagerOption,
 ) LobbyManager {
-	return &lobbyManager{
-		queueOps:     queueOps,
-		gameEngine:   gameEngine,
-		publisher:    publisher,
-		activeLobies: make(map[uuid.UUID]*Lobby),
-		userToLobby:  make(map[uuid.UUID]uuid.UUID),
-		logger:       logger,
+	lm := &lobbyManager{
+		queueOps:        queueOps,
+		gameEngine:      gameEngine,
+		publisher:       publisher,
+		minReadyPlayers: defaultMinReadyPlayers,
+		capacityWait:    defaultCapacityWait,
+		activeLobies:    make(map[uuid.UUID]*Lobby),
+		userToLobby:     make(map[uuid.UUID]uuid.UUID),
+		logger:          logger,
 	}
+	for _, opt := range opts {
+		opt(lm)
+	}
+	return lm
 }
 
 // FormLobby attempts to form a lobby from the queue
@@ -92,17 +175,32 @@ func (lm *lobbyManager) FormLobby(ctx context.Context, league string) (*Lobby, e
 		return nil, fmt.Errorf("failed to get queue size: %w", err)
 	}
 
-	if queueSize < 10 {
-		return nil, fmt.Errorf("not enough players in queue: %d/10", queueSize)
+	rules := lm.rulesFor(league)
+	required := rules.requiredLivePlayers()
+
+	if queueSize < LobbySize {
+		if !rules.AllowGhosts {
+			// Ghost-free leagues wait for a full grid; players who waited too long are timed out
+			lm.expireQueue(ctx, league, queueSize)
+			return nil, fmt.Errorf("%w: %d/%d live players queued", ErrLobbyNotReady, queueSize, LobbySize)
+		}
+
+		ready, err := lm.oldestWaitedOut(ctx, league)
+		if err != nil {
+			return nil, err
+		}
+		if queueSize < int64(required) || !ready {
+			return nil, fmt.Errorf("%w: %d/%d live players queued", ErrLobbyNotReady, queueSize, required)
+		}
 	}
 
-	// Pop 10 players from the queue
-	queueEntries, err := lm.queueOps.PopPlayersFromQueue(ctx, league, 10)
+	// Pop up to a full grid of players from the queue
+	queueEntries, err := lm.queueOps.PopPlayersFromQueue(ctx, league, LobbySize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pop players from queue: %w", err)
 	}
 
-	if len(queueEntries) < 10 {
+	if len(queueEntries) < required {
 		// Put players back in queue if we didn't get enough
 		for _, entry := range queueEntries {
 			if addErr := lm.queueOps.AddToQueue(ctx, league, entry); addErr != nil {
@@ -111,28 +209,32 @@ func (lm *lobbyManager) FormLobby(ctx context.Context, league string) (*Lobby, e
 					"league":  league,
 					"error":   addErr,
 				}).Error("Failed to re-add player to queue")
+				lm.releaseBuyin(ctx, entry.UserID, league)
 			}
 		}
-		return nil, fmt.Errorf("insufficient players popped from queue: %d/10", len(queueEntries))
+		return nil, fmt.Errorf("insufficient players popped from queue: %d/%d", len(queueEntries), required)
 	}
 
 	// Create lobby
 	lobby := &Lobby{
-		ID:        uuid.New(),
-		League:    league,
-		Status:    LobbyStatusForming,
-		CreatedAt: time.Now(),
-		TimeoutAt: time.Now().Add(getMatchmakingTimeout()),
-		Players:   make([]*LobbyPlayer, 0, 10),
+		ID:         uuid.New(),
+		League:     league,
+		Status:     LobbyStatusForming,
+		CreatedAt:  time.Now(),
+		TimeoutAt:  time.Now().Add(getMatchmakingTimeout()),
+		Players:    make([]*LobbyPlayer, 0, LobbySize),
+		GhostSlots: LobbySize - len(queueEntries),
 	}
 
 	// Add players to lobby
+	lm.mu.Lock()
 	for _, entry := range queueEntries {
 		player := &LobbyPlayer{
 			UserID:      entry.UserID,
 			DisplayName: entry.DisplayName,
 			IsReady:     false, // Players need to ready up
 			JoinedAt:    time.Now(),
+			QueuedAt:    entry.JoinedAt,
 		}
 		lobby.Players = append(lobby.Players, player)
 
@@ -142,11 +244,13 @@ func (lm *lobbyManager) FormLobby(ctx context.Context, league string) (*Lobby, e
 
 	// Store lobby
 	lm.activeLobies[lobby.ID] = lobby
+	lm.mu.Unlock()
 
 	lm.logger.WithFields(logrus.Fields{
 		"lobby_id":     lobby.ID,
 		"league":       league,
 		"player_count": len(lobby.Players),
+		"ghost_slots":  lobby.GhostSlots,
 	}).Info("Lobby formed successfully")
 
 	// Notify players via Centrifugo that match was found (T059)
@@ -162,24 +266,25 @@ func (lm *lobbyManager) FormLobby(ctx context.Context, league string) (*Lobby, e
 	return lobby, nil
 }
 
-// CheckTimeout checks if any lobbies have timed out
+// CheckTimeout resolves the ready check of every lobby whose countdown has run out
 func (lm *lobbyManager) CheckTimeout(ctx context.Context) error {
 	now := time.Now()
 
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	// Matches started in this pass take their slots before the active match count sees them
+	started := 0
 	for lobbyID, lobby := range lm.activeLobies {
 		if now.After(lobby.TimeoutAt) && lobby.Status == LobbyStatusForming {
-			lm.logger.WithFields(logrus.Fields{
-				"lobby_id": lobbyID,
-				"league":   lobby.League,
-			}).Info("Lobby timed out, aborting")
-
-			// Abort the lobby
-			err := lm.abortLobby(ctx, lobby)
-			if err != nil {
+			if err := lm.resolveReadyCheck(ctx, lobby, started); err != nil {
 				lm.logger.WithFields(logrus.Fields{
 					"lobby_id": lobbyID,
 					"error":    err,
-				}).Error("Failed to abort timed out lobby")
+				}).Error("Failed to resolve lobby ready check")
+			}
+			if lobby.Status == LobbyStatusStarted {
+				started++
 			}
 		}
 	}
@@ -187,8 +292,30 @@ func (lm *lobbyManager) CheckTimeout(ctx context.Context) error {
 	return nil
 }
 
+// SetPlayerReady marks a player ready in their forming lobby
+func (lm *lobbyManager) SetPlayerReady(ctx context.Context, userID uuid.UUID) error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	lobby, exists := lm.activeLobies[lm.userToLobby[userID]]
+	if !exists || lobby.Status != LobbyStatusForming {
+		return ErrNotInLobby
+	}
+
+	for _, player := range lobby.Players {
+		if player.UserID == userID {
+			player.IsReady = true
+			return nil
+		}
+	}
+	return ErrNotInLobby
+}
+
 // GetActiveLobby returns an active lobby for a user
 func (lm *lobbyManager) GetActiveLobby(ctx context.Context, userID uuid.UUID) (*Lobby, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
 	lobbyID, exists := lm.userToLobby[userID]
 	if !exists {
 		return nil, nil // User not in any lobby
@@ -204,47 +331,94 @@ func (lm *lobbyManager) GetActiveLobby(ctx context.Context, userID uuid.UUID) (*
 	return lobby, nil
 }
 
-// abortLobby aborts a lobby and returns players to queue
-func (lm *lobbyManager) abortLobby(ctx context.Context, lobby *Lobby) error {
-	// Change status
-	lobby.Status = LobbyStatusAborted
-
-	// Return players to queue
+// resolveReadyCheck ends a lobby's countdown: with enough ready players the match starts and
+// ghosts take the not-ready players' slots, otherwise the lobby is cancelled and the ready
+// players' buy-in holds are released. started counts the matches already started in the current
+// timeout check. Callers must hold lm.mu.
+func (lm *lobbyManager) resolveReadyCheck(ctx context.Context, lobby *Lobby, started int) error {
+	ready := make([]*LobbyPlayer, 0, len(lobby.Players))
+	notReady := make([]*LobbyPlayer, 0, len(lobby.Players))
 	for _, player := range lobby.Players {
-		// Create queue entry
-		queueEntry := &QueueEntry{
-			UserID:      player.UserID,
-			DisplayName: player.DisplayName,
-			League:      lobby.League,
-			BuyinAmount: LeagueBuyins[lobby.League],
-			JoinedAt:    time.Now(),
+		if player.IsReady {
+			ready = append(ready, player)
+		} else {
+			notReady = append(notReady, player)
 		}
+	}
 
-		// Add back to queue
-		err := lm.queueOps.AddToQueue(ctx, lobby.League, queueEntry)
-		if err != nil {
-			lm.logger.WithFields(logrus.Fields{
-				"user_id": player.UserID,
-				"league":  lobby.League,
-				"error":   err,
-			}).Error("Failed to return player to queue after lobby abort")
+	logger := lm.logger.WithFields(logrus.Fields{
+		"lobby_id":  lobby.ID,
+		"league":    lobby.League,
+		"ready":     len(ready),
+		"not_ready": len(notReady),
+	})
+
+	if len(ready) < lm.requiredReadyPlayers(lobby) {
+		logger.Info("Not enough players ready when lobby countdown ran out, cancelling lobby")
+		lm.cancelLobby(ctx, lobby, ready, notReady)
+		return nil
+	}
+
+	// The lobby stays forming and is checked again on the next timeout check, until it has waited
+	// too long for a slot
+	if !lm.hasMatchCapacity(ctx, started) {
+		if waited := time.Since(lobby.TimeoutAt); waited > lm.capacityWait {
+			logger.WithField("waited", waited).Warn("Lobby waited too long for a free match slot, cancelling lobby")
+			lm.cancelLobby(ctx, lobby, ready, notReady)
+			return nil
 		}
+		logger.Warn("Server is running its maximum number of matches, lobby waits for a free slot")
+		return nil
+	}
+
+	if len(notReady) > 0 {
+		logger.Info("Starting match with ghosts replacing players who did not ready up")
+		lm.dropNotReady(ctx, lobby, notReady)
+		lobby.Players = ready
+		lobby.GhostSlots += len(notReady)
+	}
 
-		// Clean up user mapping
+	return lm.startMatch(ctx, lobby)
+}
+
+// requiredReadyPlayers returns how many players must be ready for a lobby's match to start.
+// Ghost-free leagues cannot replace anyone, so every player must be ready.
+func (lm *lobbyManager) requiredReadyPlayers(lobby *Lobby) int {
+	if !lm.rulesFor(lobby.League).AllowGhosts || lm.minReadyPlayers > len(lobby.Players) {
+		return len(lobby.Players)
+	}
+	return lm.minReadyPlayers
+}
+
+// cancelLobby removes a lobby whose ready check failed, releasing the ready players' buy-in
+// holds and dropping the players who did not ready up; callers must hold lm.mu
+func (lm *lobbyManager) cancelLobby(ctx context.Context, lobby *Lobby, ready, notReady []*LobbyPlayer) {
+	lobby.Status = LobbyStatusAborted
+
+	for _, player := range ready {
 		delete(lm.userToLobby, player.UserID)
+		lm.releaseBuyin(ctx, player.UserID, lobby.League)
 	}
+	lm.dropNotReady(ctx, lobby, notReady)
 
-	// Remove lobby
 	delete(lm.activeLobies, lobby.ID)
 
-	// TODO: Notify players via Centrifugo that lobby was aborted
+	// TODO: Notify players via Centrifugo that lobby was cancelled
+}
 
-	return nil
+// dropNotReady removes players who did not ready up from a lobby, keeping their buy-in holds
+// until they expire unless not-ready players are refunded; callers must hold lm.mu
+func (lm *lobbyManager) dropNotReady(ctx context.Context, lobby *Lobby, notReady []*LobbyPlayer) {
+	for _, player := range notReady {
+		delete(lm.userToLobby, player.UserID)
+		if lm.refundNotReady {
+			lm.releaseBuyin(ctx, player.UserID, lobby.League)
+		}
+	}
 }
 
-// startMatch starts a match from a ready lobby
-// TODO: This function will be used when match starting is implemented
-func (lm *lobbyManager) startMatch(ctx context.Context, lobby *Lobby) error { //nolint:unused
+// startMatch starts a match from a ready lobby; callers must hold lm.mu
+func (lm *lobbyManager) startMatch(ctx context.Context, lobby *Lobby) error {
 	// Validate all players are ready
 	for _, player := range lobby.Players {
 		if !player.IsReady {
@@ -266,9 +440,10 @@ func (lm *lobbyManager) startMatch(ctx context.Context, lobby *Lobby) error { //
 	// Change status to started
 	lobby.Status = LobbyStatusStarted
 
-	// Clean up lobby (players are now in match)
+	// Clean up lobby (players are now in match and their buy-ins are no longer held)
 	for _, player := range lobby.Players {
 		delete(lm.userToLobby, player.UserID)
+		lm.releaseBuyin(ctx, player.UserID, lobby.League)
 	}
 	delete(lm.activeLobies, lobby.ID)
 
@@ -280,25 +455,113 @@ func (lm *lobbyManager) startMatch(ctx context.Context, lobby *Lobby) error { //
 	return nil
 }
 
+// rulesFor returns the ghost filling rules of a league
+func (lm *lobbyManager) rulesFor(league string) LeagueRules {
+	if rules, ok := lm.leagueRules[league]; ok {
+		return rules
+	}
+	return DefaultLeagueRules()
+}
+
+// oldestWaitedOut reports whether the player at the head of the queue has waited out the matchmaking timeout
+func (lm *lobbyManager) oldestWaitedOut(ctx context.Context, league string) (bool, error) {
+	entries, err := lm.queueOps.PeekQueue(ctx, league, 1)
+	if err != nil {
+		return false, fmt.Errorf("failed to peek queue: %w", err)
+	}
+	if len(entries) == 0 {
+		return false, nil
+	}
+	return time.Since(entries[0].JoinedAt) >= getMatchmakingTimeout(), nil
+}
+
+// expireQueue removes players who waited out the matchmaking timeout in a league that cannot
+// fill its grid with ghosts, releasing their buy-in holds and telling them the search timed out
+func (lm *lobbyManager) expireQueue(ctx context.Context, league string, queueSize int64) {
+	if queueSize == 0 {
+		return
+	}
+
+	entries, err := lm.queueOps.PeekQueue(ctx, league, int(queueSize))
+	if err != nil {
+		lm.logger.WithFields(logrus.Fields{
+			"league": league,
+			"error":  err,
+		}).Error("Failed to peek queue for timed out players")
+		return
+	}
+
+	timeout := getMatchmakingTimeout()
+	for _, entry := range entries {
+		waited := time.Since(entry.JoinedAt)
+		if waited < timeout {
+			continue
+		}
+
+		if err := lm.queueOps.RemoveFromQueue(ctx, league, entry.UserID); err != nil {
+			lm.logger.WithFields(logrus.Fields{
+				"user_id": entry.UserID,
+				"league":  league,
+				"error":   err,
+			}).Error("Failed to remove timed out player from queue")
+			continue
+		}
+		lm.releaseBuyin(ctx, entry.UserID, league)
+
+		lm.logger.WithFields(logrus.Fields{
+			"user_id": entry.UserID,
+			"league":  league,
+			"waited":  waited,
+		}).Info("Matchmaking timed out for player in ghost-free league")
+
+		event := &events.MatchmakingTimeoutEvent{
+			League:        league,
+			WaitedSeconds: int(waited.Seconds()),
+		}
+		if err := lm.publisher.PublishToUser(ctx, entry.UserID, events.EventMatchmakingTimeout, event); err != nil {
+			lm.logger.WithFields(logrus.Fields{
+				"user_id": entry.UserID,
+				"error":   err,
+			}).Error("Failed to publish matchmaking timeout event")
+		}
+	}
+}
+
+// releaseBuyin drops one held buy-in for a player, logging failures since the hold expires on its own
+func (lm *lobbyManager) releaseBuyin(ctx context.Context, userID uuid.UUID, league string) {
+	if lm.reservations == nil {
+		return
+	}
+
+	buyin, _ := lm.leagues.Buyin(league)
+	if err := lm.reservations.Release(ctx, userID, league, buyin); err != nil {
+		lm.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"league":  league,
+			"error":   err,
+		}).Error("Failed to release buy-in reservation")
+	}
+}
+
 // publishMatchFoundEvents publishes match_found events to all players in the lobby
 func (lm *lobbyManager) publishMatchFoundEvents(ctx context.Context, lobby *Lobby) error {
 	// Calculate total buyin amount for prize pool
+	buyin, _ := lm.leagues.Buyin(lobby.League)
 	totalBuyin := decimal.Zero
 	for range lobby.Players {
-		totalBuyin = totalBuyin.Add(LeagueBuyins[lobby.League])
+		totalBuyin = totalBuyin.Add(buyin)
 	}
 
-	// Calculate prize pool (after 8% rake)
-	rakeAmount := totalBuyin.Mul(decimal.NewFromFloat(0.08)).Truncate(2)
-	prizePool := totalBuyin.Sub(rakeAmount)
+	// Calculate prize pool after the league's rake
+	prizePool := monetary.CalculatePrizePoolAt(totalBuyin, lm.rakeRates.ForLeague(lobby.League))
 
 	// Create match found event
 	matchFoundEvent := &events.MatchFoundEvent{
 		MatchID:        lobby.ID, // Using lobby ID as match ID for now
 		League:         lobby.League,
 		PlayerCount:    len(lobby.Players),
-		BuyinAmount:    LeagueBuyins[lobby.League],
-		PrizePool:      prizePool,
+		BuyinAmount:    monetary.NewMoney(buyin),
+		PrizePool:      monetary.NewMoney(prizePool),
 		CountdownStart: time.Now().Add(5 * time.Second), // 5 seconds from now
 	}
 
@@ -0,0 +1,516 @@
+package matchmaker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/modules/gateway"
+	"github.com/megaherz/ndr/internal/modules/gateway/events"
+)
+
+// memoryQueueOperations keeps league queues in join order in memory
+type memoryQueueOperations struct {
+	QueueOperations
+
+	mu     sync.Mutex
+	queues map[string][]*QueueEntry
+}
+
+func newMemoryQueueOperations() *memoryQueueOperations {
+	return &memoryQueueOperations{queues: make(map[string][]*QueueEntry)}
+}
+
+func (q *memoryQueueOperations) AddToQueue(ctx context.Context, league string, entry *QueueEntry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queues[league] = append(q.queues[league], entry)
+	return nil
+}
+
+func (q *memoryQueueOperations) RemoveFromQueue(ctx context.Context, league string, userID uuid.UUID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	kept := q.queues[league][:0]
+	for _, entry := range q.queues[league] {
+		if entry.UserID != userID {
+			kept = append(kept, entry)
+		}
+	}
+	q.queues[league] = kept
+	return nil
+}
+
+func (q *memoryQueueOperations) GetQueueSize(ctx context.Context, league string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.queues[league])), nil
+}
+
+func (q *memoryQueueOperations) PopPlayersFromQueue(ctx context.Context, league string, count int) ([]*QueueEntry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := q.queues[league]
+	if count > len(queue) {
+		count = len(queue)
+	}
+	popped := append([]*QueueEntry(nil), queue[:count]...)
+	q.queues[league] = queue[count:]
+	return popped, nil
+}
+
+func (q *memoryQueueOperations) PeekQueue(ctx context.Context, league string, count int) ([]*QueueEntry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := q.queues[league]
+	if count > len(queue) {
+		count = len(queue)
+	}
+	return append([]*QueueEntry(nil), queue[:count]...), nil
+}
+
+func (q *memoryQueueOperations) IsUserInQueue(ctx context.Context, userID uuid.UUID) (bool, string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for league, queue := range q.queues {
+		for _, entry := range queue {
+			if entry.UserID == userID {
+				return true, league, nil
+			}
+		}
+	}
+	return false, "", nil
+}
+
+func (q *memoryQueueOperations) GetQueuePosition(ctx context.Context, league string, userID uuid.UUID) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, entry := range q.queues[league] {
+		if entry.UserID == userID {
+			return int64(i), nil
+		}
+	}
+	return -1, nil
+}
+
+// enqueue adds n players to a league queue who joined the given duration ago
+func (q *memoryQueueOperations) enqueue(t *testing.T, league string, n int, waited time.Duration) {
+	buyin, _ := constants.DefaultLeagues().Buyin(league)
+	for i := 0; i < n; i++ {
+		require.NoError(t, q.AddToQueue(context.Background(), league, &QueueEntry{
+			UserID:      uuid.New(),
+			DisplayName: "Racer",
+			League:      league,
+			BuyinAmount: buyin,
+			JoinedAt:    time.Now().Add(-waited),
+		}))
+	}
+}
+
+// recordingUserPublisher records the event types published to each user
+type recordingUserPublisher struct {
+	gateway.CentrifugoPublisher
+
+	mu     sync.Mutex
+	events map[uuid.UUID][]string
+}
+
+func (p *recordingUserPublisher) PublishToUser(ctx context.Context, userID uuid.UUID, eventType string, data interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.events == nil {
+		p.events = make(map[uuid.UUID][]string)
+	}
+	p.events[userID] = append(p.events[userID], eventType)
+	return nil
+}
+
+func (p *recordingUserPublisher) count(eventType string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := 0
+	for _, types := range p.events {
+		for _, t := range types {
+			if t == eventType {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// releaseRecorder records the buy-in holds released for each user
+type releaseRecorder struct {
+	BalanceReservations
+
+	mu       sync.Mutex
+	released map[uuid.UUID]int
+	amounts  map[uuid.UUID]decimal.Decimal
+}
+
+func (r *releaseRecorder) Release(ctx context.Context, userID uuid.UUID, league string, amount decimal.Decimal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.released == nil {
+		r.released = make(map[uuid.UUID]int)
+		r.amounts = make(map[uuid.UUID]decimal.Decimal)
+	}
+	r.released[userID]++
+	r.amounts[userID] = r.amounts[userID].Add(amount)
+	return nil
+}
+
+func (r *releaseRecorder) count(userID uuid.UUID) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.released[userID]
+}
+
+func (r *releaseRecorder) amount(userID uuid.UUID) decimal.Decimal {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.amounts[userID]
+}
+
+func newTestLobbyManager(queue QueueOperations, publisher gateway.CentrifugoPublisher, rules map[string]LeagueRules, opts ...LobbyManagerOption) LobbyManager {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	return NewLobbyManager(queue, nil, publisher, logger, append([]LobbyManagerOption{WithLeagueRules(rules)}, opts...)...)
+}
+
+// newTestLeagueRules builds the rules of the launch leagues after edit changed one league's definition
+func newTestLeagueRules(t *testing.T, name string, edit func(*constants.League)) map[string]LeagueRules {
+	t.Helper()
+
+	leagues := constants.DefaultLeagues().All()
+	for i := range leagues {
+		if leagues[i].Name == name {
+			edit(&leagues[i])
+		}
+	}
+	registry, err := constants.NewLeagueRegistry(leagues...)
+	require.NoError(t, err)
+	return NewLeagueRules(registry)
+}
+
+// formCountedDownLobby forms a ghost-filled lobby of n players, marks the first ready of them ready
+// and runs the lobby's countdown out
+func formCountedDownLobby(t *testing.T, lobbies LobbyManager, league string, n, ready int) *Lobby {
+	t.Helper()
+	ctx := context.Background()
+
+	queue := lobbies.(*lobbyManager).queueOps.(*memoryQueueOperations)
+	queue.enqueue(t, league, n, 2*time.Minute)
+	lobby, err := lobbies.FormLobby(ctx, league)
+	require.NoError(t, err)
+	require.Len(t, lobby.Players, n)
+
+	for _, player := range lobby.Players[:ready] {
+		require.NoError(t, lobbies.SetPlayerReady(ctx, player.UserID))
+	}
+	lobby.TimeoutAt = time.Now().Add(-time.Second)
+	return lobby
+}
+
+func TestFormLobby_GhostFreeLeagueTimesOutInsteadOfGhostFilling(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	ctx := context.Background()
+	league := constants.LeagueTopFuel
+
+	queue := newMemoryQueueOperations()
+	publisher := &recordingUserPublisher{}
+	rules := newTestLeagueRules(t, league, func(l *constants.League) { l.AllowGhosts = false })
+	lobbies := newTestLobbyManager(queue, publisher, rules)
+
+	// Nine fresh players keep waiting for a tenth live racer
+	queue.enqueue(t, league, 9, time.Second)
+	lobby, err := lobbies.FormLobby(ctx, league)
+	assert.ErrorIs(t, err, ErrLobbyNotReady)
+	assert.Nil(t, lobby)
+	size, _ := queue.GetQueueSize(ctx, league)
+	assert.Equal(t, int64(9), size)
+
+	// Once they wait out the timeout they are dropped rather than matched with ghosts
+	queue.queues[league] = nil
+	queue.enqueue(t, league, 9, 2*time.Minute)
+	lobby, err = lobbies.FormLobby(ctx, league)
+	assert.ErrorIs(t, err, ErrLobbyNotReady)
+	assert.Nil(t, lobby)
+	size, _ = queue.GetQueueSize(ctx, league)
+	assert.Zero(t, size)
+	assert.Equal(t, 9, publisher.count(events.EventMatchmakingTimeout))
+	assert.Zero(t, publisher.count(events.EventMatchFound))
+}
+
+func TestFormLobby_GhostFreeLeagueFormsFullGrid(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	league := constants.LeagueTopFuel
+
+	queue := newMemoryQueueOperations()
+	publisher := &recordingUserPublisher{}
+	lobbies := newTestLobbyManager(queue, publisher, newTestLeagueRules(t, league, func(l *constants.League) { l.AllowGhosts = false }))
+
+	queue.enqueue(t, league, 10, 2*time.Minute)
+	lobby, err := lobbies.FormLobby(context.Background(), league)
+	require.NoError(t, err)
+	assert.Len(t, lobby.Players, LobbySize)
+	assert.Zero(t, lobby.GhostSlots)
+	assert.Zero(t, publisher.count(events.EventMatchmakingTimeout))
+}
+
+func TestFormLobby_GhostsFillAfterTimeout(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	ctx := context.Background()
+	league := constants.LeaguePro
+
+	queue := newMemoryQueueOperations()
+	publisher := &recordingUserPublisher{}
+	lobbies := newTestLobbyManager(queue, publisher, newTestLeagueRules(t, league, func(l *constants.League) { l.MinLivePlayers = 4 }))
+
+	// Below the league's minimum, ghosts never fill the grid
+	queue.enqueue(t, league, 3, 2*time.Minute)
+	_, err := lobbies.FormLobby(ctx, league)
+	assert.ErrorIs(t, err, ErrLobbyNotReady)
+
+	// Enough live players, but nobody has waited out the timeout yet
+	queue.queues[league] = nil
+	queue.enqueue(t, league, 4, time.Second)
+	_, err = lobbies.FormLobby(ctx, league)
+	assert.ErrorIs(t, err, ErrLobbyNotReady)
+
+	// Once the oldest player has waited long enough, ghosts take the empty slots
+	queue.queues[league] = nil
+	queue.enqueue(t, league, 4, 2*time.Minute)
+	lobby, err := lobbies.FormLobby(ctx, league)
+	require.NoError(t, err)
+	assert.Len(t, lobby.Players, 4)
+	assert.Equal(t, 6, lobby.GhostSlots)
+	assert.Equal(t, 4, publisher.count(events.EventMatchFound))
+}
+
+func TestCheckTimeout_StartsWithGhostsReplacingNotReadyPlayers(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	ctx := context.Background()
+	league := constants.LeaguePro
+
+	reservations := &releaseRecorder{}
+	lobbies := newTestLobbyManager(newMemoryQueueOperations(), &recordingUserPublisher{}, NewLeagueRules(nil),
+		WithLobbyReservations(reservations), WithMinReadyPlayers(3))
+
+	lobby := formCountedDownLobby(t, lobbies, league, 5, 3)
+	players := append([]*LobbyPlayer(nil), lobby.Players...)
+
+	require.NoError(t, lobbies.CheckTimeout(ctx))
+
+	assert.Equal(t, LobbyStatusStarted, lobby.Status)
+	assert.Equal(t, players[:3], lobby.Players)
+	assert.Equal(t, 7, lobby.GhostSlots)
+
+	// Ready players race and have their holds released; the others forfeit theirs
+	for i, player := range players {
+		active, err := lobbies.GetActiveLobby(ctx, player.UserID)
+		require.NoError(t, err)
+		assert.Nil(t, active)
+		if i < 3 {
+			assert.Equal(t, 1, reservations.count(player.UserID))
+		} else {
+			assert.Zero(t, reservations.count(player.UserID))
+		}
+	}
+}
+
+func TestCheckTimeout_RefundsNotReadyPlayersWhenConfigured(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	league := constants.LeaguePro
+
+	reservations := &releaseRecorder{}
+	lobbies := newTestLobbyManager(newMemoryQueueOperations(), &recordingUserPublisher{}, NewLeagueRules(nil),
+		WithLobbyReservations(reservations), WithNotReadyRefund(true))
+
+	lobby := formCountedDownLobby(t, lobbies, league, 4, 2)
+	players := append([]*LobbyPlayer(nil), lobby.Players...)
+
+	require.NoError(t, lobbies.CheckTimeout(context.Background()))
+
+	assert.Equal(t, LobbyStatusStarted, lobby.Status)
+	assert.Len(t, lobby.Players, 2)
+	for _, player := range players {
+		assert.Equal(t, 1, reservations.count(player.UserID))
+	}
+}
+
+func TestCheckTimeout_ReleasesRegistryBuyin(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	league := constants.LeaguePro
+
+	leagues := constants.DefaultLeagues().All()
+	for i := range leagues {
+		if leagues[i].Name == league {
+			leagues[i].Buyin = decimal.NewFromInt(250)
+		}
+	}
+	registry, err := constants.NewLeagueRegistry(leagues...)
+	require.NoError(t, err)
+
+	reservations := &releaseRecorder{}
+	lobbies := newTestLobbyManager(newMemoryQueueOperations(), &recordingUserPublisher{}, NewLeagueRules(registry),
+		WithLobbyLeagues(registry), WithLobbyReservations(reservations), WithNotReadyRefund(true))
+
+	lobby := formCountedDownLobby(t, lobbies, league, 4, 2)
+	players := append([]*LobbyPlayer(nil), lobby.Players...)
+
+	require.NoError(t, lobbies.CheckTimeout(context.Background()))
+
+	// Holds are released at the registry's buy-in, not the launch one
+	for _, player := range players {
+		assert.Equal(t, "250", reservations.amount(player.UserID).String(), "player %s", player.UserID)
+	}
+}
+
+func TestCheckTimeout_CancelsLobbyWithTooFewReadyPlayers(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	ctx := context.Background()
+	league := constants.LeaguePro
+
+	queue := newMemoryQueueOperations()
+	reservations := &releaseRecorder{}
+	lobbies := newTestLobbyManager(queue, &recordingUserPublisher{}, NewLeagueRules(nil),
+		WithLobbyReservations(reservations), WithMinReadyPlayers(3))
+
+	lobby := formCountedDownLobby(t, lobbies, league, 5, 2)
+
+	require.NoError(t, lobbies.CheckTimeout(ctx))
+
+	assert.Equal(t, LobbyStatusAborted, lobby.Status)
+	size, _ := queue.GetQueueSize(ctx, league)
+	assert.Zero(t, size)
+
+	// Ready players get their buy-ins back; players who never readied forfeit theirs
+	for i, player := range lobby.Players {
+		active, err := lobbies.GetActiveLobby(ctx, player.UserID)
+		require.NoError(t, err)
+		assert.Nil(t, active)
+		if i < 2 {
+			assert.Equal(t, 1, reservations.count(player.UserID))
+		} else {
+			assert.Zero(t, reservations.count(player.UserID))
+		}
+	}
+}
+
+func TestCheckTimeout_GhostFreeLeagueNeedsEveryPlayerReady(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	league := constants.LeagueTopFuel
+
+	lobbies := newTestLobbyManager(newMemoryQueueOperations(), &recordingUserPublisher{}, newTestLeagueRules(t, league, func(l *constants.League) { l.AllowGhosts = false }))
+
+	lobby := formCountedDownLobby(t, lobbies, league, LobbySize, LobbySize-1)
+
+	require.NoError(t, lobbies.CheckTimeout(context.Background()))
+	assert.Equal(t, LobbyStatusAborted, lobby.Status)
+}
+
+// fixedMatchCounter reports a settable number of running matches
+type fixedMatchCounter struct {
+	count int
+}
+
+func (c *fixedMatchCounter) GetActiveMatches(ctx context.Context) []uuid.UUID {
+	return make([]uuid.UUID, c.count)
+}
+
+func TestCheckTimeout_WaitsWhileServerIsAtMatchCapacity(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	ctx := context.Background()
+	league := constants.LeaguePro
+
+	running := &fixedMatchCounter{count: 2}
+	lobbies := newTestLobbyManager(newMemoryQueueOperations(), &recordingUserPublisher{}, NewLeagueRules(nil),
+		WithMaxConcurrentMatches(2, running))
+
+	lobby := formCountedDownLobby(t, lobbies, league, 4, 4)
+
+	// Every slot is taken: the lobby keeps its players and waits
+	require.NoError(t, lobbies.CheckTimeout(ctx))
+	assert.Equal(t, LobbyStatusForming, lobby.Status)
+	active, err := lobbies.GetActiveLobby(ctx, lobby.Players[0].UserID)
+	require.NoError(t, err)
+	assert.Equal(t, lobby.ID, active.ID)
+
+	// Once a match ends the waiting lobby starts on the next check
+	running.count = 1
+	require.NoError(t, lobbies.CheckTimeout(ctx))
+	assert.Equal(t, LobbyStatusStarted, lobby.Status)
+}
+
+func TestCheckTimeout_CancelsLobbyThatWaitedTooLongForCapacity(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	ctx := context.Background()
+	league := constants.LeaguePro
+
+	reservations := &releaseRecorder{}
+	lobbies := newTestLobbyManager(newMemoryQueueOperations(), &recordingUserPublisher{}, NewLeagueRules(nil),
+		WithLobbyReservations(reservations), WithMaxConcurrentMatches(1, &fixedMatchCounter{count: 1}), WithMatchCapacityWait(time.Minute))
+
+	lobby := formCountedDownLobby(t, lobbies, league, 4, 4)
+
+	// Within the capacity wait the lobby keeps waiting
+	require.NoError(t, lobbies.CheckTimeout(ctx))
+	assert.Equal(t, LobbyStatusForming, lobby.Status)
+
+	// Past it the lobby is cancelled and its players get their buy-ins back
+	lobby.TimeoutAt = time.Now().Add(-2 * time.Minute)
+	require.NoError(t, lobbies.CheckTimeout(ctx))
+	assert.Equal(t, LobbyStatusAborted, lobby.Status)
+	for _, player := range lobby.Players {
+		active, err := lobbies.GetActiveLobby(ctx, player.UserID)
+		require.NoError(t, err)
+		assert.Nil(t, active)
+		assert.Equal(t, 1, reservations.count(player.UserID))
+	}
+}
+
+func TestSetPlayerReady_RejectsPlayersOutsideLobbies(t *testing.T) {
+	lobbies := newTestLobbyManager(newMemoryQueueOperations(), &recordingUserPublisher{}, nil)
+
+	err := lobbies.SetPlayerReady(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrNotInLobby)
+}
+
+func TestCheckTimeout_StartsNoMoreMatchesThanFreeSlots(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	ctx := context.Background()
+	league := constants.LeaguePro
+
+	lobbies := newTestLobbyManager(newMemoryQueueOperations(), &recordingUserPublisher{}, NewLeagueRules(nil),
+		WithMaxConcurrentMatches(3, &fixedMatchCounter{count: 1}))
+
+	formed := make([]*Lobby, 0, 4)
+	for i := 0; i < 4; i++ {
+		formed = append(formed, formCountedDownLobby(t, lobbies, league, 4, 4))
+	}
+
+	// Two slots are free, so only two of the four lobbies timing out in the same check start
+	require.NoError(t, lobbies.CheckTimeout(ctx))
+
+	statuses := map[LobbyStatus]int{}
+	for _, lobby := range formed {
+		statuses[lobby.Status]++
+	}
+	assert.Equal(t, 2, statuses[LobbyStatusStarted])
+	assert.Equal(t, 2, statuses[LobbyStatusForming])
+}
@@ -0,0 +1,150 @@
+package matchmaker
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/constants"
+)
+
+// presenceGracePeriod gives freshly queued players time to establish their realtime connection
+const presenceGracePeriod = 15 * time.Second
+
+// PresenceChecker reports whether a user currently has a realtime connection
+type PresenceChecker interface {
+	// IsUserOnline returns true if the user is connected to their personal channel
+	IsUserOnline(ctx context.Context, userID string) (bool, error)
+}
+
+// PresenceMonitor removes queued players whose realtime connection has dropped
+type PresenceMonitor interface {
+	// HandlePresenceLeave cancels the queue entry of a user who disconnected
+	HandlePresenceLeave(ctx context.Context, userID uuid.UUID) error
+
+	// CheckQueuedPlayers checks presence of all queued players and cancels disconnected ones
+	CheckQueuedPlayers(ctx context.Context) error
+
+	// Start runs presence checks periodically until the context is cancelled
+	Start(ctx context.Context, interval time.Duration)
+}
+
+// presenceMonitor implements PresenceMonitor
+type presenceMonitor struct {
+	matchmaker MatchmakerService
+	queueOps   QueueOperations
+	presence   PresenceChecker
+	logger     *logrus.Logger
+}
+
+// NewPresenceMonitor creates a new presence monitor
+func NewPresenceMonitor(
+	matchmaker MatchmakerService,
+	queueOps QueueOperations,
+	presence PresenceChecker,
+	logger *logrus.Logger,
+) PresenceMonitor {
+	return &presenceMonitor{
+		matchmaker: matchmaker,
+		queueOps:   queueOps,
+		presence:   presence,
+		logger:     logger,
+	}
+}
+
+// HandlePresenceLeave cancels the queue entry of a user who disconnected
+func (m *presenceMonitor) HandlePresenceLeave(ctx context.Context, userID uuid.UUID) error {
+	inQueue, league, err := m.queueOps.IsUserInQueue(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !inQueue {
+		return nil // Nothing to cancel
+	}
+
+	if err := m.matchmaker.CancelQueue(ctx, userID); err != nil {
+		return err
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"league":  league,
+	}).Info("Removed disconnected user from matchmaking queue")
+
+	return nil
+}
+
+// CheckQueuedPlayers checks presence of all queued players and cancels disconnected ones
+func (m *presenceMonitor) CheckQueuedPlayers(ctx context.Context) error {
+	for _, league := range constants.ValidLeagues() {
+		queueSize, err := m.queueOps.GetQueueSize(ctx, league)
+		if err != nil {
+			return err
+		}
+
+		if queueSize == 0 {
+			continue
+		}
+
+		entries, err := m.queueOps.PeekQueue(ctx, league, int(queueSize))
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if time.Since(entry.JoinedAt) < presenceGracePeriod {
+				continue
+			}
+
+			online, err := m.presence.IsUserOnline(ctx, entry.UserID.String())
+			if err != nil {
+				// Don't cancel anyone when presence is unavailable
+				m.logger.WithFields(logrus.Fields{
+					"user_id": entry.UserID,
+					"league":  league,
+					"error":   err,
+				}).Warn("Failed to check user presence")
+				continue
+			}
+
+			if online {
+				continue
+			}
+
+			if err := m.HandlePresenceLeave(ctx, entry.UserID); err != nil {
+				m.logger.WithFields(logrus.Fields{
+					"user_id": entry.UserID,
+					"league":  league,
+					"error":   err,
+				}).Error("Failed to cancel queue for disconnected user")
+			}
+		}
+	}
+
+	return nil
+}
+
+// Start runs presence checks periodically until the context is cancelled
+func (m *presenceMonitor) Start(ctx context.Context, interval time.Duration) {
+	m.logger.WithField("interval", interval).Info("Starting matchmaking presence monitor")
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				m.logger.Info("Matchmaking presence monitor stopped")
+				return
+			case <-ticker.C:
+				if err := m.CheckQueuedPlayers(ctx); err != nil {
+					m.logger.WithError(err).Error("Failed to check queued players presence")
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,131 @@
+package matchmaker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakePresenceChecker reports users as online unless marked offline
+type fakePresenceChecker struct {
+	offline map[string]bool
+}
+
+func (f *fakePresenceChecker) IsUserOnline(ctx context.Context, userID string) (bool, error) {
+	return !f.offline[userID], nil
+}
+
+type PresenceMonitorIntegrationTestSuite struct {
+	suite.Suite
+	redisHelper *TestRedisHelper
+	queueOps    QueueOperations
+	presence    *fakePresenceChecker
+	monitor     PresenceMonitor
+}
+
+func TestPresenceMonitorIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(PresenceMonitorIntegrationTestSuite))
+}
+
+func (suite *PresenceMonitorIntegrationTestSuite) SetupSuite() {
+	suite.redisHelper = NewTestRedisHelper(suite.T())
+	suite.redisHelper.SetupRedis()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	suite.queueOps = NewQueueOperations(suite.redisHelper.Client)
+	suite.presence = &fakePresenceChecker{offline: make(map[string]bool)}
+	matchmaker := NewMatchmakerService(suite.queueOps, nil, nil, logger)
+	suite.monitor = NewPresenceMonitor(matchmaker, suite.queueOps, suite.presence, logger)
+}
+
+func (suite *PresenceMonitorIntegrationTestSuite) TearDownSuite() {
+	suite.redisHelper.TeardownRedis()
+}
+
+func (suite *PresenceMonitorIntegrationTestSuite) SetupTest() {
+	suite.redisHelper.FlushAll()
+	suite.presence.offline = make(map[string]bool)
+}
+
+func (suite *PresenceMonitorIntegrationTestSuite) addToQueue(joinedAt time.Time) *QueueEntry {
+	entry := &QueueEntry{
+		UserID:      uuid.New(),
+		DisplayName: "Racer",
+		League:      "ROOKIE",
+		BuyinAmount: decimal.NewFromInt(10),
+		JoinedAt:    joinedAt,
+	}
+	require.NoError(suite.T(), suite.queueOps.AddToQueue(context.Background(), "ROOKIE", entry))
+	return entry
+}
+
+func (suite *PresenceMonitorIntegrationTestSuite) TestPresenceLeaveRemovesUserFromQueue() {
+	ctx := context.Background()
+	client := suite.redisHelper.Client
+
+	entry := suite.addToQueue(time.Now())
+	userKey := fmt.Sprintf("matchmaking:user:%s", entry.UserID)
+
+	exists, err := client.Exists(ctx, userKey).Result()
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), int64(1), exists)
+
+	err = suite.monitor.HandlePresenceLeave(ctx, entry.UserID)
+	require.NoError(suite.T(), err)
+
+	queueLen, err := client.LLen(ctx, "matchmaking:queue:ROOKIE").Result()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), queueLen)
+
+	exists, err = client.Exists(ctx, userKey).Result()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), exists)
+}
+
+func (suite *PresenceMonitorIntegrationTestSuite) TestPresenceLeaveForUserNotInQueue() {
+	err := suite.monitor.HandlePresenceLeave(context.Background(), uuid.New())
+
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *PresenceMonitorIntegrationTestSuite) TestCheckQueuedPlayersCancelsOnlyDisconnected() {
+	ctx := context.Background()
+	joinedAt := time.Now().Add(-time.Minute)
+
+	connected := suite.addToQueue(joinedAt)
+	disconnected := suite.addToQueue(joinedAt)
+	justJoined := suite.addToQueue(time.Now())
+
+	suite.presence.offline[disconnected.UserID.String()] = true
+	suite.presence.offline[justJoined.UserID.String()] = true
+
+	err := suite.monitor.CheckQueuedPlayers(ctx)
+	require.NoError(suite.T(), err)
+
+	inQueue, _, err := suite.queueOps.IsUserInQueue(ctx, connected.UserID)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), inQueue)
+
+	inQueue, _, err = suite.queueOps.IsUserInQueue(ctx, disconnected.UserID)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), inQueue)
+
+	// Players still within the grace period are not cancelled yet
+	inQueue, _, err = suite.queueOps.IsUserInQueue(ctx, justJoined.UserID)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), inQueue)
+
+	size, err := suite.queueOps.GetQueueSize(ctx, "ROOKIE")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), size)
+}
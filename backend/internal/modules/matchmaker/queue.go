@@ -3,6 +3,7 @@ package matchmaker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -20,9 +21,30 @@ type QueueEntry struct {
 	JoinedAt    time.Time       `json:"joined_at"`
 }
 
+// ErrAlreadyInQueue is returned when a user is already queued in some league
+var ErrAlreadyInQueue = errors.New("user is already in queue")
+
+// ErrNotInQueue is returned when a user is not queued in any league
+var ErrNotInQueue = errors.New("user is not in any queue")
+
+// userQueueTTL bounds how long a user's queue tracking key survives as a safety net
+const userQueueTTL = time.Hour
+
+// enqueueListScript claims the user tracking key with NX and only pushes the entry
+// if the claim succeeded. It returns an empty string on success, or the league the
+// user is already queued in.
+var enqueueListScript = redis.NewScript(`
+if not redis.call('SET', KEYS[2], ARGV[2], 'NX', 'EX', ARGV[3]) then
+	return redis.call('GET', KEYS[2])
+end
+redis.call('RPUSH', KEYS[1], ARGV[1])
+return ''
+`)
+
 // QueueOperations handles Redis queue operations for matchmaking
 type QueueOperations interface {
-	// AddToQueue adds a player to the matchmaking queue for a specific league
+	// AddToQueue adds a player to the matchmaking queue for a specific league.
+	// It returns ErrAlreadyInQueue if the user is already queued in any league.
 	AddToQueue(ctx context.Context, league string, entry *QueueEntry) error
 
 	// RemoveFromQueue removes a player from the matchmaking queue
@@ -65,6 +87,7 @@ func (q *redisQueueOperations) getUserQueueKey(userID uuid.UUID) string {
 }
 
 // AddToQueue adds a player to the matchmaking queue for a specific league
+// unless they are already queued
 func (q *redisQueueOperations) AddToQueue(ctx context.Context, league string, entry *QueueEntry) error {
 	// Serialize the queue entry
 	data, err := json.Marshal(entry)
@@ -72,22 +95,15 @@ func (q *redisQueueOperations) AddToQueue(ctx context.Context, league string, en
 		return fmt.Errorf("failed to marshal queue entry: %w", err)
 	}
 
-	// Use a transaction to ensure atomicity
-	pipe := q.client.TxPipeline()
-
-	// Add to the league queue (FIFO using RPUSH)
-	queueKey := q.getQueueKey(league)
-	pipe.RPush(ctx, queueKey, data)
-
-	// Track which queue the user is in
-	userKey := q.getUserQueueKey(entry.UserID)
-	pipe.Set(ctx, userKey, league, time.Hour) // Expire after 1 hour as safety
-
-	// Execute the transaction
-	_, err = pipe.Exec(ctx)
+	// Claim the user key and push in one script so retries cannot enqueue twice
+	keys := []string{q.getQueueKey(league), q.getUserQueueKey(entry.UserID)}
+	current, err := enqueueListScript.Run(ctx, q.client, keys, data, league, int(userQueueTTL.Seconds())).Text()
 	if err != nil {
 		return fmt.Errorf("failed to add to queue: %w", err)
 	}
+	if current != "" {
+		return fmt.Errorf("%w for league %s", ErrAlreadyInQueue, current)
+	}
 
 	return nil
 }
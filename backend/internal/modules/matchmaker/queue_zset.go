@@ -0,0 +1,215 @@
+package matchmaker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// popEntriesScript atomically pops the lowest-scored members from the queue
+// sorted set and returns (and removes) their serialized entries from the hash.
+var popEntriesScript = redis.NewScript(`
+local popped = redis.call('ZPOPMIN', KEYS[1], ARGV[1])
+local result = {}
+for i = 1, #popped, 2 do
+	local member = popped[i]
+	local data = redis.call('HGET', KEYS[2], member)
+	redis.call('HDEL', KEYS[2], member)
+	if data then
+		table.insert(result, data)
+	end
+end
+return result
+`)
+
+// enqueueSortedSetScript claims the user tracking key with NX and only adds the
+// entry if the claim succeeded. It returns an empty string on success, or the
+// league the user is already queued in.
+var enqueueSortedSetScript = redis.NewScript(`
+if not redis.call('SET', KEYS[3], ARGV[4], 'NX', 'EX', ARGV[5]) then
+	return redis.call('GET', KEYS[3])
+end
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+return ''
+`)
+
+// sortedSetQueueOperations implements QueueOperations using a Redis sorted set
+// scored by join time, so players re-added with their original join time
+// (e.g. after a lobby abort) keep their place near the front of the queue.
+type sortedSetQueueOperations struct {
+	client *redis.Client
+}
+
+// NewSortedSetQueueOperations creates a new sorted set based queue operations handler
+func NewSortedSetQueueOperations(client *redis.Client) QueueOperations {
+	return &sortedSetQueueOperations{client: client}
+}
+
+// getQueueKey returns the Redis key for a league queue sorted set
+func (q *sortedSetQueueOperations) getQueueKey(league string) string {
+	return fmt.Sprintf("matchmaking:zqueue:%s", league)
+}
+
+// getEntriesKey returns the Redis key for the hash holding serialized queue entries
+func (q *sortedSetQueueOperations) getEntriesKey(league string) string {
+	return fmt.Sprintf("matchmaking:zqueue:%s:entries", league)
+}
+
+// getUserQueueKey returns the Redis key for tracking which queue a user is in
+func (q *sortedSetQueueOperations) getUserQueueKey(userID uuid.UUID) string {
+	return fmt.Sprintf("matchmaking:user:%s", userID.String())
+}
+
+// queueScore returns the sorted set score for an entry (earlier joins sort first)
+func queueScore(entry *QueueEntry) float64 {
+	joinedAt := entry.JoinedAt
+	if joinedAt.IsZero() {
+		joinedAt = time.Now()
+	}
+	return float64(joinedAt.UnixMicro())
+}
+
+// AddToQueue adds a player to the matchmaking queue for a specific league
+// unless they are already queued
+func (q *sortedSetQueueOperations) AddToQueue(ctx context.Context, league string, entry *QueueEntry) error {
+	// Serialize the queue entry
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queue entry: %w", err)
+	}
+
+	// Claim the user key and add in one script so retries cannot enqueue twice
+	keys := []string{q.getQueueKey(league), q.getEntriesKey(league), q.getUserQueueKey(entry.UserID)}
+	args := []interface{}{
+		strconv.FormatFloat(queueScore(entry), 'f', -1, 64),
+		entry.UserID.String(),
+		data,
+		league,
+		int(userQueueTTL.Seconds()),
+	}
+	current, err := enqueueSortedSetScript.Run(ctx, q.client, keys, args...).Text()
+	if err != nil {
+		return fmt.Errorf("failed to add to queue: %w", err)
+	}
+	if current != "" {
+		return fmt.Errorf("%w for league %s", ErrAlreadyInQueue, current)
+	}
+
+	return nil
+}
+
+// RemoveFromQueue removes a player from the matchmaking queue
+func (q *sortedSetQueueOperations) RemoveFromQueue(ctx context.Context, league string, userID uuid.UUID) error {
+	member := userID.String()
+
+	pipe := q.client.TxPipeline()
+	pipe.ZRem(ctx, q.getQueueKey(league), member)
+	pipe.HDel(ctx, q.getEntriesKey(league), member)
+	pipe.Del(ctx, q.getUserQueueKey(userID))
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to remove from queue: %w", err)
+	}
+
+	return nil
+}
+
+// GetQueueSize returns the current queue size for a league
+func (q *sortedSetQueueOperations) GetQueueSize(ctx context.Context, league string) (int64, error) {
+	size, err := q.client.ZCard(ctx, q.getQueueKey(league)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue size: %w", err)
+	}
+	return size, nil
+}
+
+// PopPlayersFromQueue removes and returns up to N players from the queue
+func (q *sortedSetQueueOperations) PopPlayersFromQueue(ctx context.Context, league string, count int) ([]*QueueEntry, error) {
+	keys := []string{q.getQueueKey(league), q.getEntriesKey(league)}
+
+	entryDataList, err := popEntriesScript.Run(ctx, q.client, keys, count).StringSlice()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to pop from queue: %w", err)
+	}
+
+	var entries []*QueueEntry
+	for _, entryData := range entryDataList {
+		var entry QueueEntry
+		if err := json.Unmarshal([]byte(entryData), &entry); err != nil {
+			continue // Skip invalid entries
+		}
+
+		entries = append(entries, &entry)
+
+		// Clean up user tracking
+		q.client.Del(ctx, q.getUserQueueKey(entry.UserID))
+	}
+
+	return entries, nil
+}
+
+// PeekQueue returns the first N players in the queue without removing them
+func (q *sortedSetQueueOperations) PeekQueue(ctx context.Context, league string, count int) ([]*QueueEntry, error) {
+	members, err := q.client.ZRange(ctx, q.getQueueKey(league), 0, int64(count-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to peek queue: %w", err)
+	}
+
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	values, err := q.client.HMGet(ctx, q.getEntriesKey(league), members...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue entries: %w", err)
+	}
+
+	var entries []*QueueEntry
+	for _, value := range values {
+		entryData, ok := value.(string)
+		if !ok {
+			continue // Entry removed concurrently
+		}
+
+		var entry QueueEntry
+		if err := json.Unmarshal([]byte(entryData), &entry); err != nil {
+			continue // Skip invalid entries
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, nil
+}
+
+// IsUserInQueue checks if a user is currently in any queue
+func (q *sortedSetQueueOperations) IsUserInQueue(ctx context.Context, userID uuid.UUID) (bool, string, error) {
+	league, err := q.client.Get(ctx, q.getUserQueueKey(userID)).Result()
+	if err == redis.Nil {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("failed to check user queue status: %w", err)
+	}
+
+	return true, league, nil
+}
+
+// GetQueuePosition returns the position of a user in the queue (0-based)
+func (q *sortedSetQueueOperations) GetQueuePosition(ctx context.Context, league string, userID uuid.UUID) (int64, error) {
+	position, err := q.client.ZRank(ctx, q.getQueueKey(league), userID.String()).Result()
+	if err == redis.Nil {
+		return -1, nil // User not found in queue
+	}
+	if err != nil {
+		return -1, fmt.Errorf("failed to get queue position: %w", err)
+	}
+
+	return position, nil
+}
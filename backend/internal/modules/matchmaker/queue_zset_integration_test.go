@@ -0,0 +1,151 @@
+package matchmaker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type SortedSetQueueIntegrationTestSuite struct {
+	suite.Suite
+	redisHelper *TestRedisHelper
+	queueOps    QueueOperations
+}
+
+func TestSortedSetQueueIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(SortedSetQueueIntegrationTestSuite))
+}
+
+func (suite *SortedSetQueueIntegrationTestSuite) SetupSuite() {
+	suite.redisHelper = NewTestRedisHelper(suite.T())
+	suite.redisHelper.SetupRedis()
+
+	suite.queueOps = NewSortedSetQueueOperations(suite.redisHelper.Client)
+}
+
+func (suite *SortedSetQueueIntegrationTestSuite) TearDownSuite() {
+	suite.redisHelper.TeardownRedis()
+}
+
+func (suite *SortedSetQueueIntegrationTestSuite) SetupTest() {
+	suite.redisHelper.FlushAll()
+}
+
+func (suite *SortedSetQueueIntegrationTestSuite) newEntry(name string, joinedAt time.Time) *QueueEntry {
+	return &QueueEntry{
+		UserID:      uuid.New(),
+		DisplayName: name,
+		League:      "ROOKIE",
+		BuyinAmount: decimal.NewFromInt(10),
+		JoinedAt:    joinedAt,
+	}
+}
+
+func (suite *SortedSetQueueIntegrationTestSuite) TestPopReturnsPlayersInJoinOrder() {
+	ctx := context.Background()
+	base := time.Now().Add(-time.Minute)
+
+	var entries []*QueueEntry
+	for i := 0; i < 5; i++ {
+		entry := suite.newEntry(fmt.Sprintf("player-%d", i), base.Add(time.Duration(i)*time.Second))
+		entries = append(entries, entry)
+	}
+
+	// Insert out of order; the sorted set orders by join time
+	for _, i := range []int{3, 0, 4, 1, 2} {
+		require.NoError(suite.T(), suite.queueOps.AddToQueue(ctx, "ROOKIE", entries[i]))
+	}
+
+	size, err := suite.queueOps.GetQueueSize(ctx, "ROOKIE")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(5), size)
+
+	position, err := suite.queueOps.GetQueuePosition(ctx, "ROOKIE", entries[2].UserID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), position)
+
+	popped, err := suite.queueOps.PopPlayersFromQueue(ctx, "ROOKIE", 3)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), popped, 3)
+	for i, entry := range popped {
+		assert.Equal(suite.T(), entries[i].UserID, entry.UserID)
+
+		inQueue, _, err := suite.queueOps.IsUserInQueue(ctx, entry.UserID)
+		require.NoError(suite.T(), err)
+		assert.False(suite.T(), inQueue)
+	}
+
+	size, err = suite.queueOps.GetQueueSize(ctx, "ROOKIE")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), size)
+}
+
+func (suite *SortedSetQueueIntegrationTestSuite) TestAbortedPlayersRetainNearFrontOrdering() {
+	ctx := context.Background()
+	base := time.Now().Add(-time.Minute)
+
+	// Two players join, get popped into a lobby, then fresh players join
+	early := []*QueueEntry{
+		suite.newEntry("early-0", base),
+		suite.newEntry("early-1", base.Add(time.Second)),
+	}
+	for _, entry := range early {
+		require.NoError(suite.T(), suite.queueOps.AddToQueue(ctx, "ROOKIE", entry))
+	}
+
+	popped, err := suite.queueOps.PopPlayersFromQueue(ctx, "ROOKIE", 2)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), popped, 2)
+
+	fresh := suite.newEntry("fresh", time.Now())
+	require.NoError(suite.T(), suite.queueOps.AddToQueue(ctx, "ROOKIE", fresh))
+
+	// The lobby aborts and the players are re-added with their original join time
+	for _, entry := range popped {
+		require.NoError(suite.T(), suite.queueOps.AddToQueue(ctx, "ROOKIE", entry))
+	}
+
+	peeked, err := suite.queueOps.PeekQueue(ctx, "ROOKIE", 3)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), peeked, 3)
+	assert.Equal(suite.T(), early[0].UserID, peeked[0].UserID)
+	assert.Equal(suite.T(), early[1].UserID, peeked[1].UserID)
+	assert.Equal(suite.T(), fresh.UserID, peeked[2].UserID)
+
+	position, err := suite.queueOps.GetQueuePosition(ctx, "ROOKIE", fresh.UserID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), position)
+}
+
+func (suite *SortedSetQueueIntegrationTestSuite) TestRemoveFromQueue() {
+	ctx := context.Background()
+	entry := suite.newEntry("leaver", time.Now())
+
+	require.NoError(suite.T(), suite.queueOps.AddToQueue(ctx, "ROOKIE", entry))
+
+	inQueue, league, err := suite.queueOps.IsUserInQueue(ctx, entry.UserID)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), inQueue)
+	assert.Equal(suite.T(), "ROOKIE", league)
+
+	require.NoError(suite.T(), suite.queueOps.RemoveFromQueue(ctx, "ROOKIE", entry.UserID))
+
+	position, err := suite.queueOps.GetQueuePosition(ctx, "ROOKIE", entry.UserID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(-1), position)
+
+	inQueue, _, err = suite.queueOps.IsUserInQueue(ctx, entry.UserID)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), inQueue)
+
+	popped, err := suite.queueOps.PopPlayersFromQueue(ctx, "ROOKIE", 10)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), popped)
+}
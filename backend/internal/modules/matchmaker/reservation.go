@@ -0,0 +1,120 @@
+package matchmaker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+
+	"github.com/megaherz/ndr/internal/modules/account"
+)
+
+// reserveScript adds a hold of ARGV[2] minor units for league ARGV[1] if the user's
+// existing holds plus the new one stay within the available balance ARGV[3].
+// Returns 1 if the hold was placed and 0 if it would overdraw.
+var reserveScript = redis.NewScript(`
+local held = 0
+for _, value in ipairs(redis.call('HVALS', KEYS[1])) do
+	held = held + tonumber(value)
+end
+if held + tonumber(ARGV[2]) > tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('HINCRBY', KEYS[1], ARGV[1], ARGV[2])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+return 1
+`)
+
+// releaseScript removes one hold of ARGV[2] minor units for league ARGV[1],
+// dropping the field once nothing is held for the league
+var releaseScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+if redis.call('HINCRBY', KEYS[1], ARGV[1], -tonumber(ARGV[2])) <= 0 then
+	redis.call('HDEL', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// BalanceReservations holds queued players' buy-ins so the same balance cannot back
+// several queue entries or lobbies at once
+type BalanceReservations interface {
+	// Reserve adds a hold of amount for a league, failing with account.ErrInsufficientBalance
+	// if the user's holds would exceed the available balance
+	Reserve(ctx context.Context, userID uuid.UUID, league string, amount, available decimal.Decimal) error
+
+	// Release removes one hold of amount for a league; releasing a missing hold is a no-op
+	Release(ctx context.Context, userID uuid.UUID, league string, amount decimal.Decimal) error
+
+	// Reserved returns the total amount currently held for a user
+	Reserved(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error)
+}
+
+// redisBalanceReservations implements BalanceReservations with a Redis hash per user
+// mapping league to the total held amount in minor units (hundredths). Holds add up,
+// so a player waiting in a lobby and a queue of the same league holds two buy-ins.
+type redisBalanceReservations struct {
+	client *redis.Client
+}
+
+// NewBalanceReservations creates a new Redis-based balance reservation store
+func NewBalanceReservations(client *redis.Client) BalanceReservations {
+	return &redisBalanceReservations{client: client}
+}
+
+// getReservationKey returns the Redis key holding a user's reservations
+func (r *redisBalanceReservations) getReservationKey(userID uuid.UUID) string {
+	return fmt.Sprintf("matchmaking:reserved:%s", userID.String())
+}
+
+// toMinorUnits converts an amount to whole hundredths so the script compares integers
+func toMinorUnits(amount decimal.Decimal) int64 {
+	return amount.Shift(2).Floor().IntPart()
+}
+
+// Reserve adds a hold for a league if the user's holds stay within the available balance
+func (r *redisBalanceReservations) Reserve(ctx context.Context, userID uuid.UUID, league string, amount, available decimal.Decimal) error {
+	keys := []string{r.getReservationKey(userID)}
+	args := []interface{}{league, toMinorUnits(amount), toMinorUnits(available), int(userQueueTTL.Seconds())}
+
+	placed, err := reserveScript.Run(ctx, r.client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("failed to reserve balance: %w", err)
+	}
+	if placed == 0 {
+		return fmt.Errorf("%w: reserving %s FUEL would exceed the unreserved balance", account.ErrInsufficientBalance, amount.String())
+	}
+
+	return nil
+}
+
+// Release removes one hold of amount for a league
+func (r *redisBalanceReservations) Release(ctx context.Context, userID uuid.UUID, league string, amount decimal.Decimal) error {
+	keys := []string{r.getReservationKey(userID)}
+	if err := releaseScript.Run(ctx, r.client, keys, league, toMinorUnits(amount)).Err(); err != nil {
+		return fmt.Errorf("failed to release reservation: %w", err)
+	}
+	return nil
+}
+
+// Reserved returns the total amount currently held for a user
+func (r *redisBalanceReservations) Reserved(ctx context.Context, userID uuid.UUID) (decimal.Decimal, error) {
+	values, err := r.client.HVals(ctx, r.getReservationKey(userID)).Result()
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to get reservations: %w", err)
+	}
+
+	total := decimal.Zero
+	for _, value := range values {
+		minor, err := decimal.NewFromString(value)
+		if err != nil {
+			continue // Skip invalid holds
+		}
+		total = total.Add(minor.Shift(-2))
+	}
+
+	return total, nil
+}
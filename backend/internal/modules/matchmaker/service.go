@@ -2,10 +2,14 @@ package matchmaker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 
 	"github.com/megaherz/ndr/internal/constants"
@@ -21,14 +25,20 @@ type MatchmakerService interface {
 	// CancelQueue removes a player from the matchmaking queue
 	CancelQueue(ctx context.Context, userID uuid.UUID) error
 
+	// Heartbeat refreshes a queued player's heartbeat; entries whose heartbeat lapses are pruned
+	Heartbeat(ctx context.Context, userID uuid.UUID) error
+
 	// GetQueueStatus returns the current queue status for a user
 	GetQueueStatus(ctx context.Context, userID uuid.UUID) (*QueueStatus, error)
 
 	// GetQueueInfo returns information about a league's queue
 	GetQueueInfo(ctx context.Context, league string) (*QueueInfo, error)
 
-	// StartMatchmakingWorker starts the background worker that forms lobbies
+	// StartMatchmakingWorker starts the background worker that forms lobbies until ctx is cancelled
 	StartMatchmakingWorker(ctx context.Context) error
+
+	// WorkerDone is closed once the started matchmaking worker has stopped and its league checks have returned
+	WorkerDone() <-chan struct{}
 }
 
 // QueueStatus represents a player's status in the matchmaking queue
@@ -49,15 +59,87 @@ type QueueInfo struct {
 	AvgWaitTime   int    `json:"avg_wait_time"`  // Average wait time in seconds
 }
 
-// Use league constants from constants package
-var LeagueBuyins = constants.LeagueBuyins
+const (
+	// defaultWorkerTickInterval is how often the matchmaking worker checks the league queues
+	defaultWorkerTickInterval = 5 * time.Second
+
+	// defaultWorkerConcurrency is the maximum number of leagues checked at the same time
+	defaultWorkerConcurrency = 4
+)
 
 // matchmakerService implements MatchmakerService
 type matchmakerService struct {
-	queueOps       QueueOperations
-	accountService account.AccountService
-	publisher      gateway.CentrifugoPublisher
-	logger         *logrus.Logger
+	queueOps          QueueOperations
+	accountService    account.AccountService
+	publisher         gateway.CentrifugoPublisher
+	lobbyManager      LobbyManager
+	reservations      BalanceReservations
+	cooldowns         MatchCooldowns
+	heartbeats        QueueHeartbeats
+	leagues           *constants.LeagueRegistry
+	workerTick        time.Duration
+	workerConcurrency int
+	workerDone        chan struct{}
+	logger            *logrus.Logger
+}
+
+// MatchmakerOption configures optional matchmaker service behaviour
+type MatchmakerOption func(*matchmakerService)
+
+// WithWorkerTickInterval sets how often the matchmaking worker checks the queues; non-positive values keep the default
+func WithWorkerTickInterval(interval time.Duration) MatchmakerOption {
+	return func(s *matchmakerService) {
+		if interval > 0 {
+			s.workerTick = interval
+		}
+	}
+}
+
+// WithWorkerConcurrency bounds how many leagues the worker checks at once; non-positive values keep the default
+func WithWorkerConcurrency(n int) MatchmakerOption {
+	return func(s *matchmakerService) {
+		if n > 0 {
+			s.workerConcurrency = n
+		}
+	}
+}
+
+// WithLobbyManager lets the worker form lobbies once a league queue is full
+func WithLobbyManager(lobbyManager LobbyManager) MatchmakerOption {
+	return func(s *matchmakerService) {
+		s.lobbyManager = lobbyManager
+	}
+}
+
+// WithBalanceReservations holds each queued player's buy-in until their match starts,
+// so one balance cannot back several queue entries or lobbies
+func WithBalanceReservations(reservations BalanceReservations) MatchmakerOption {
+	return func(s *matchmakerService) {
+		s.reservations = reservations
+	}
+}
+
+// WithMatchCooldowns rejects joins from players whose post-match cooldown is still running
+func WithMatchCooldowns(cooldowns MatchCooldowns) MatchmakerOption {
+	return func(s *matchmakerService) {
+		s.cooldowns = cooldowns
+	}
+}
+
+// WithQueueHeartbeats requires queued clients to send heartbeats; entries whose heartbeat
+// lapses are pruned before each lobby formation. A nil store keeps entries until they are matched or cancelled.
+func WithQueueHeartbeats(heartbeats QueueHeartbeats) MatchmakerOption {
+	return func(s *matchmakerService) {
+		s.heartbeats = heartbeats
+	}
+}
+
+// WithLeagues sets the leagues players can queue for and their buy-ins.
+// Without it the launch leagues are used.
+func WithLeagues(leagues *constants.LeagueRegistry) MatchmakerOption {
+	return func(s *matchmakerService) {
+		s.leagues = leagues
+	}
 }
 
 // NewMatchmakerService creates a new matchmaker service
@@ -66,19 +148,27 @@ func NewMatchmakerService(
 	accountService account.AccountService,
 	publisher gateway.CentrifugoPublisher,
 	logger *logrus.Logger,
+	opts ...MatchmakerOption,
 ) MatchmakerService {
-	return &matchmakerService{
-		queueOps:       queueOps,
-		accountService: accountService,
-		publisher:      publisher,
-		logger:         logger,
+	s := &matchmakerService{
+		queueOps:          queueOps,
+		accountService:    accountService,
+		publisher:         publisher,
+		workerTick:        defaultWorkerTickInterval,
+		workerConcurrency: defaultWorkerConcurrency,
+		workerDone:        make(chan struct{}),
+		logger:            logger,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // JoinQueue adds a player to the matchmaking queue
 func (s *matchmakerService) JoinQueue(ctx context.Context, userID uuid.UUID, displayName, league string) (*QueueStatus, error) {
 	// Validate league
-	buyinAmount, exists := LeagueBuyins[league]
+	buyinAmount, exists := s.leagues.Buyin(league)
 	if !exists {
 		return nil, fmt.Errorf("invalid league: %s", league)
 	}
@@ -94,23 +184,25 @@ func (s *matchmakerService) JoinQueue(ctx context.Context, userID uuid.UUID, dis
 	}
 
 	if inQueue {
-		return nil, fmt.Errorf("user is already in queue for league %s", currentLeague)
+		return s.existingQueueStatus(ctx, userID, league, currentLeague)
 	}
 
-	// Check if user has sufficient balance
-	hasSufficientBalance, err := s.accountService.HasSufficientBalance(ctx, userID, constants.CurrencyFUEL, buyinAmount)
-	if err != nil {
-		s.logger.WithFields(logrus.Fields{
-			"user_id": userID,
-			"league":  league,
-			"amount":  buyinAmount,
-			"error":   err,
-		}).Error("Failed to check user balance")
-		return nil, fmt.Errorf("failed to check balance: %w", err)
+	// Players who just finished a match wait out their cooldown first
+	if err := s.checkCooldown(ctx, userID, league); err != nil {
+		return nil, err
+	}
+
+	// Check the balance, holding the buy-in when reservations are enabled
+	if err := s.reserveBuyin(ctx, userID, league, buyinAmount); err != nil {
+		return nil, err
 	}
 
-	if !hasSufficientBalance {
-		return nil, fmt.Errorf("insufficient FUEL balance for %s league (need %s FUEL)", league, buyinAmount.String())
+	// The join counts as the first heartbeat, so the sweeper never sees the entry without one
+	if s.heartbeats != nil {
+		if err := s.heartbeats.Beat(ctx, userID); err != nil {
+			s.releaseBuyin(ctx, userID, league)
+			return nil, fmt.Errorf("failed to join queue: %w", err)
+		}
 	}
 
 	// Create queue entry
@@ -124,6 +216,18 @@ func (s *matchmakerService) JoinQueue(ctx context.Context, userID uuid.UUID, dis
 
 	// Add to queue
 	err = s.queueOps.AddToQueue(ctx, league, queueEntry)
+	if err != nil {
+		// The entry was not added, so its hold must not linger
+		s.releaseBuyin(ctx, userID, league)
+	}
+	if errors.Is(err, ErrAlreadyInQueue) {
+		// A concurrent join for the same user won the race
+		_, currentLeague, err := s.queueOps.IsUserInQueue(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check queue status: %w", err)
+		}
+		return s.existingQueueStatus(ctx, userID, league, currentLeague)
+	}
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"user_id":      userID,
@@ -145,6 +249,94 @@ func (s *matchmakerService) JoinQueue(ctx context.Context, userID uuid.UUID, dis
 	return s.GetQueueStatus(ctx, userID)
 }
 
+// checkCooldown returns ErrCooldownActive if the user's post-match cooldown blocks joining a league
+func (s *matchmakerService) checkCooldown(ctx context.Context, userID uuid.UUID, league string) error {
+	if s.cooldowns == nil {
+		return nil
+	}
+
+	remaining, err := s.cooldowns.Remaining(ctx, userID, league)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"league":  league,
+			"error":   err,
+		}).Error("Failed to check match cooldown")
+		return fmt.Errorf("failed to check cooldown: %w", err)
+	}
+
+	if remaining > 0 {
+		return &ErrCooldownActive{RemainingSeconds: int(math.Ceil(remaining.Seconds()))}
+	}
+	return nil
+}
+
+// reserveBuyin checks that the user can afford a league's buy-in. With reservations
+// enabled the buy-in is also held against the balance left after the user's other holds.
+func (s *matchmakerService) reserveBuyin(ctx context.Context, userID uuid.UUID, league string, buyinAmount decimal.Decimal) error {
+	if s.reservations == nil {
+		hasSufficientBalance, err := s.accountService.HasSufficientBalance(ctx, userID, constants.CurrencyFUEL, buyinAmount)
+		if err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"user_id": userID,
+				"league":  league,
+				"amount":  buyinAmount,
+				"error":   err,
+			}).Error("Failed to check user balance")
+			return fmt.Errorf("failed to check balance: %w", err)
+		}
+
+		if !hasSufficientBalance {
+			return fmt.Errorf("%w: %s league needs %s FUEL", account.ErrInsufficientBalance, league, buyinAmount.String())
+		}
+		return nil
+	}
+
+	balance, err := s.accountService.GetBalance(ctx, userID, constants.CurrencyFUEL)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"league":  league,
+			"amount":  buyinAmount,
+			"error":   err,
+		}).Error("Failed to get user balance")
+		return fmt.Errorf("failed to check balance: %w", err)
+	}
+
+	if err := s.reservations.Reserve(ctx, userID, league, buyinAmount, balance); err != nil {
+		if errors.Is(err, account.ErrInsufficientBalance) {
+			return fmt.Errorf("%w: %s league needs %s FUEL", account.ErrInsufficientBalance, league, buyinAmount.String())
+		}
+		return err
+	}
+	return nil
+}
+
+// releaseBuyin drops one held buy-in for a league, logging failures since the hold expires on its own
+func (s *matchmakerService) releaseBuyin(ctx context.Context, userID uuid.UUID, league string) {
+	if s.reservations == nil {
+		return
+	}
+
+	buyin, _ := s.leagues.Buyin(league)
+	if err := s.reservations.Release(ctx, userID, league, buyin); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"league":  league,
+			"error":   err,
+		}).Error("Failed to release buy-in reservation")
+	}
+}
+
+// existingQueueStatus makes JoinQueue idempotent: a repeated join for the league the user
+// is already queued in returns their current status, while a join for another league fails
+func (s *matchmakerService) existingQueueStatus(ctx context.Context, userID uuid.UUID, league, currentLeague string) (*QueueStatus, error) {
+	if currentLeague != league {
+		return nil, fmt.Errorf("%w for league %s", ErrAlreadyInQueue, currentLeague)
+	}
+	return s.GetQueueStatus(ctx, userID)
+}
+
 // CancelQueue removes a player from the matchmaking queue
 func (s *matchmakerService) CancelQueue(ctx context.Context, userID uuid.UUID) error {
 	// Check if user is in a queue
@@ -154,7 +346,7 @@ func (s *matchmakerService) CancelQueue(ctx context.Context, userID uuid.UUID) e
 	}
 
 	if !inQueue {
-		return fmt.Errorf("user is not in any queue")
+		return ErrNotInQueue
 	}
 
 	// Remove from queue
@@ -168,6 +360,9 @@ func (s *matchmakerService) CancelQueue(ctx context.Context, userID uuid.UUID) e
 		return fmt.Errorf("failed to cancel queue: %w", err)
 	}
 
+	s.releaseBuyin(ctx, userID, league)
+	s.clearHeartbeat(ctx, userID)
+
 	s.logger.WithFields(logrus.Fields{
 		"user_id": userID,
 		"league":  league,
@@ -176,6 +371,91 @@ func (s *matchmakerService) CancelQueue(ctx context.Context, userID uuid.UUID) e
 	return nil
 }
 
+// Heartbeat refreshes a queued player's heartbeat
+func (s *matchmakerService) Heartbeat(ctx context.Context, userID uuid.UUID) error {
+	inQueue, _, err := s.queueOps.IsUserInQueue(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check queue status: %w", err)
+	}
+
+	if !inQueue {
+		return ErrNotInQueue
+	}
+
+	if s.heartbeats == nil {
+		return nil
+	}
+	return s.heartbeats.Beat(ctx, userID)
+}
+
+// clearHeartbeat drops a player's heartbeat, logging failures since it expires on its own
+func (s *matchmakerService) clearHeartbeat(ctx context.Context, userID uuid.UUID) {
+	if s.heartbeats == nil {
+		return
+	}
+
+	if err := s.heartbeats.Clear(ctx, userID); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err,
+		}).Error("Failed to clear queue heartbeat")
+	}
+}
+
+// pruneStaleEntries removes a league's queue entries whose heartbeat has lapsed and releases
+// their buy-in holds, so crashed clients never occupy a lobby slot
+func (s *matchmakerService) pruneStaleEntries(ctx context.Context, league string) error {
+	if s.heartbeats == nil {
+		return nil
+	}
+
+	queueSize, err := s.queueOps.GetQueueSize(ctx, league)
+	if err != nil {
+		return err
+	}
+	if queueSize == 0 {
+		return nil
+	}
+
+	entries, err := s.queueOps.PeekQueue(ctx, league, int(queueSize))
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]uuid.UUID, len(entries))
+	for i, entry := range entries {
+		userIDs[i] = entry.UserID
+	}
+	alive, err := s.heartbeats.Alive(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if alive[entry.UserID] {
+			continue
+		}
+
+		if err := s.queueOps.RemoveFromQueue(ctx, league, entry.UserID); err != nil {
+			s.logger.WithFields(logrus.Fields{
+				"user_id": entry.UserID,
+				"league":  league,
+				"error":   err,
+			}).Error("Failed to remove stale queue entry")
+			continue
+		}
+		s.releaseBuyin(ctx, entry.UserID, league)
+
+		s.logger.WithFields(logrus.Fields{
+			"user_id":   entry.UserID,
+			"league":    league,
+			"joined_at": entry.JoinedAt,
+		}).Info("Pruned queue entry with lapsed heartbeat")
+	}
+
+	return nil
+}
+
 // GetQueueStatus returns the current queue status for a user
 func (s *matchmakerService) GetQueueStatus(ctx context.Context, userID uuid.UUID) (*QueueStatus, error) {
 	// Check if user is in a queue
@@ -218,7 +498,7 @@ func (s *matchmakerService) GetQueueStatus(ctx context.Context, userID uuid.UUID
 // GetQueueInfo returns information about a league's queue
 func (s *matchmakerService) GetQueueInfo(ctx context.Context, league string) (*QueueInfo, error) {
 	// Validate league
-	if _, exists := LeagueBuyins[league]; !exists {
+	if _, exists := s.leagues.Get(league); !exists {
 		return nil, fmt.Errorf("invalid league: %s", league)
 	}
 
@@ -228,50 +508,75 @@ func (s *matchmakerService) GetQueueInfo(ctx context.Context, league string) (*Q
 		return nil, fmt.Errorf("failed to get queue size: %w", err)
 	}
 
-	// Calculate players needed for next match
-	playersNeeded := 10 - (int(queueSize) % 10)
-	if playersNeeded == 10 && queueSize > 0 {
-		playersNeeded = 0 // Queue has exact multiples of 10
-	}
-
 	// Calculate average wait time (simplified)
 	avgWaitTime := s.calculateAverageWaitTime(queueSize)
 
 	return &QueueInfo{
 		League:        league,
 		QueueSize:     queueSize,
-		PlayersNeeded: playersNeeded,
+		PlayersNeeded: playersNeeded(queueSize),
 		AvgWaitTime:   avgWaitTime,
 	}, nil
 }
 
-// StartMatchmakingWorker starts the background worker that forms lobbies
+// StartMatchmakingWorker starts the background worker that forms lobbies.
+// Each tick fans the leagues out to a bounded pool of workers, so a slow league
+// only delays itself; a league still being checked from an earlier tick is skipped.
 func (s *matchmakerService) StartMatchmakingWorker(ctx context.Context) error {
-	s.logger.Info("Starting matchmaking worker")
-
-	// TODO: Implement the actual matchmaking worker
-	// This would run in a goroutine and periodically check queues
-	// to form lobbies when enough players are available
+	s.logger.WithFields(logrus.Fields{
+		"tick_interval": s.workerTick,
+		"concurrency":   s.workerConcurrency,
+	}).Info("Starting matchmaking worker")
 
 	go func() {
-		ticker := time.NewTicker(5 * time.Second) // Check every 5 seconds
+		defer close(s.workerDone)
+
+		ticker := time.NewTicker(s.workerTick)
 		defer ticker.Stop()
 
+		slots := make(chan struct{}, s.workerConcurrency)
+		var (
+			mu       sync.Mutex
+			leagues  = s.leagues.All()
+			inFlight = make(map[string]bool, len(leagues))
+			wg       sync.WaitGroup
+		)
+		defer wg.Wait()
+
 		for {
 			select {
 			case <-ctx.Done():
 				s.logger.Info("Matchmaking worker stopped")
 				return
 			case <-ticker.C:
-				// Check each league for lobby formation
-				for league := range LeagueBuyins {
-					err := s.checkAndFormLobby(ctx, league)
-					if err != nil {
-						s.logger.WithFields(logrus.Fields{
-							"league": league,
-							"error":  err,
-						}).Error("Failed to check/form lobby")
+				for _, definition := range leagues {
+					league := definition.Name
+					mu.Lock()
+					busy := inFlight[league]
+					inFlight[league] = true
+					mu.Unlock()
+					if busy {
+						continue
 					}
+
+					wg.Add(1)
+					go func(league string) {
+						defer wg.Done()
+						defer func() {
+							mu.Lock()
+							delete(inFlight, league)
+							mu.Unlock()
+						}()
+
+						select {
+						case slots <- struct{}{}:
+						case <-ctx.Done():
+							return
+						}
+						defer func() { <-slots }()
+
+						s.checkLeague(ctx, league)
+					}(league)
 				}
 			}
 		}
@@ -280,6 +585,40 @@ func (s *matchmakerService) StartMatchmakingWorker(ctx context.Context) error {
 	return nil
 }
 
+// WorkerDone is closed once the started matchmaking worker has stopped and its league checks have returned
+func (s *matchmakerService) WorkerDone() <-chan struct{} {
+	return s.workerDone
+}
+
+// checkLeague runs a single league check, logging failures and recovering panics
+// so one league never takes down the worker
+func (s *matchmakerService) checkLeague(ctx context.Context, league string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.WithFields(logrus.Fields{
+				"league": league,
+				"panic":  r,
+			}).Error("Recovered from panic while checking league")
+		}
+	}()
+
+	if err := s.checkAndFormLobby(ctx, league); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"league": league,
+			"error":  err,
+		}).Error("Failed to check/form lobby")
+	}
+}
+
+// playersNeeded returns how many more players must join before the next full lobby pops.
+// A full lobby is ready at every multiple of LobbySize, so only an empty queue needs a whole grid.
+func playersNeeded(queueSize int64) int {
+	if queueSize <= 0 {
+		return LobbySize
+	}
+	return (LobbySize - int(queueSize%LobbySize)) % LobbySize
+}
+
 // calculateEstimatedWaitTime calculates estimated wait time based on queue position
 func (s *matchmakerService) calculateEstimatedWaitTime(position, queueSize int64) int {
 	if position == 0 {
@@ -309,28 +648,36 @@ func (s *matchmakerService) calculateAverageWaitTime(queueSize int64) int {
 
 // checkAndFormLobby checks if a lobby can be formed for a league
 func (s *matchmakerService) checkAndFormLobby(ctx context.Context, league string) error {
+	// Entries of clients that stopped sending heartbeats must not fill lobby slots
+	if err := s.pruneStaleEntries(ctx, league); err != nil {
+		return fmt.Errorf("failed to prune stale queue entries: %w", err)
+	}
+
 	// Get queue size
 	queueSize, err := s.queueOps.GetQueueSize(ctx, league)
 	if err != nil {
 		return err
 	}
 
-	// Need at least 10 players to form a lobby
-	if queueSize < 10 {
+	// Nobody to match; the lobby manager decides whether a short queue can be ghost-filled
+	if queueSize == 0 {
 		return nil
 	}
 
-	// TODO: Implement actual lobby formation
-	// This would:
-	// 1. Pop 10 players from the queue
-	// 2. Create a match
-	// 3. Notify players via Centrifugo
-	// 4. Start the match countdown
+	if s.lobbyManager == nil {
+		s.logger.WithFields(logrus.Fields{
+			"league":     league,
+			"queue_size": queueSize,
+		}).Debug("Could form lobby (no lobby manager configured)")
+		return nil
+	}
 
-	s.logger.WithFields(logrus.Fields{
-		"league":     league,
-		"queue_size": queueSize,
-	}).Debug("Could form lobby (not implemented yet)")
+	if _, err := s.lobbyManager.FormLobby(ctx, league); err != nil {
+		if errors.Is(err, ErrLobbyNotReady) {
+			return nil
+		}
+		return fmt.Errorf("failed to form lobby: %w", err)
+	}
 
 	return nil
 }
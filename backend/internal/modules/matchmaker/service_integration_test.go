@@ -0,0 +1,267 @@
+package matchmaker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/modules/account"
+)
+
+// fixedBalanceAccountService reports the same FUEL balance for every user
+type fixedBalanceAccountService struct {
+	account.AccountService
+	balance decimal.Decimal
+}
+
+func (a *fixedBalanceAccountService) GetBalance(ctx context.Context, userID uuid.UUID, currency string) (decimal.Decimal, error) {
+	return a.balance, nil
+}
+
+func (a *fixedBalanceAccountService) HasSufficientBalance(ctx context.Context, userID uuid.UUID, currency string, amount decimal.Decimal) (bool, error) {
+	return a.balance.GreaterThanOrEqual(amount), nil
+}
+
+// richAccountService reports a balance that covers every buy-in
+func richAccountService() *fixedBalanceAccountService {
+	return &fixedBalanceAccountService{balance: decimal.NewFromInt(1_000_000)}
+}
+
+type JoinQueueIntegrationTestSuite struct {
+	suite.Suite
+	redisHelper *TestRedisHelper
+	logger      *logrus.Logger
+}
+
+func TestJoinQueueIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(JoinQueueIntegrationTestSuite))
+}
+
+func (suite *JoinQueueIntegrationTestSuite) SetupSuite() {
+	suite.redisHelper = NewTestRedisHelper(suite.T())
+	suite.redisHelper.SetupRedis()
+
+	suite.logger = logrus.New()
+	suite.logger.SetLevel(logrus.WarnLevel)
+}
+
+func (suite *JoinQueueIntegrationTestSuite) TearDownSuite() {
+	suite.redisHelper.TeardownRedis()
+}
+
+func (suite *JoinQueueIntegrationTestSuite) SetupTest() {
+	suite.redisHelper.FlushAll()
+}
+
+func (suite *JoinQueueIntegrationTestSuite) backends() map[string]QueueOperations {
+	return map[string]QueueOperations{
+		"list": NewQueueOperations(suite.redisHelper.Client),
+		"zset": NewSortedSetQueueOperations(suite.redisHelper.Client),
+	}
+}
+
+func (suite *JoinQueueIntegrationTestSuite) TestConcurrentJoinsCreateSingleEntry() {
+	for name, queueOps := range suite.backends() {
+		suite.Run(name, func() {
+			ctx := context.Background()
+			service := NewMatchmakerService(queueOps, richAccountService(), nil, suite.logger)
+			userID := uuid.New()
+
+			// Two joins race the way a client retry would
+			var wg sync.WaitGroup
+			errs := make(chan error, 2)
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := service.JoinQueue(ctx, userID, "Racer", "ROOKIE")
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			// Both calls succeed: the retry is answered with the existing queue status
+			for err := range errs {
+				require.NoError(suite.T(), err)
+			}
+
+			size, err := queueOps.GetQueueSize(ctx, "ROOKIE")
+			require.NoError(suite.T(), err)
+			assert.Equal(suite.T(), int64(1), size)
+		})
+	}
+}
+
+func (suite *JoinQueueIntegrationTestSuite) TestJoinOtherLeagueWhileQueuedFails() {
+	for name, queueOps := range suite.backends() {
+		suite.Run(name, func() {
+			ctx := context.Background()
+			service := NewMatchmakerService(queueOps, richAccountService(), nil, suite.logger)
+			userID := uuid.New()
+
+			_, err := service.JoinQueue(ctx, userID, "Racer", "ROOKIE")
+			require.NoError(suite.T(), err)
+
+			_, err = service.JoinQueue(ctx, userID, "Racer", "STREET")
+			assert.ErrorIs(suite.T(), err, ErrAlreadyInQueue)
+
+			size, err := queueOps.GetQueueSize(ctx, "STREET")
+			require.NoError(suite.T(), err)
+			assert.Zero(suite.T(), size)
+		})
+	}
+}
+
+func (suite *JoinQueueIntegrationTestSuite) TestAddToQueueRejectsQueuedUser() {
+	for name, queueOps := range suite.backends() {
+		suite.Run(name, func() {
+			ctx := context.Background()
+			entry := &QueueEntry{
+				UserID:      uuid.New(),
+				DisplayName: "Racer",
+				League:      "ROOKIE",
+				BuyinAmount: decimal.NewFromInt(10),
+			}
+
+			require.NoError(suite.T(), queueOps.AddToQueue(ctx, "ROOKIE", entry))
+			err := queueOps.AddToQueue(ctx, "ROOKIE", entry)
+			assert.ErrorIs(suite.T(), err, ErrAlreadyInQueue)
+
+			size, err := queueOps.GetQueueSize(ctx, "ROOKIE")
+			require.NoError(suite.T(), err)
+			assert.Equal(suite.T(), int64(1), size)
+		})
+	}
+}
+
+func (suite *JoinQueueIntegrationTestSuite) TestReservationRejectsOverdraw() {
+	for name, queueOps := range suite.backends() {
+		suite.Run(name, func() {
+			ctx := context.Background()
+			reservations := NewBalanceReservations(suite.redisHelper.Client)
+			accounts := &fixedBalanceAccountService{balance: decimal.NewFromInt(60)}
+			service := NewMatchmakerService(queueOps, accounts, nil, suite.logger, WithBalanceReservations(reservations))
+			userID := uuid.New()
+
+			// Join ROOKIE, then get popped into a lobby: the 10 FUEL buy-in stays held
+			_, err := service.JoinQueue(ctx, userID, "Racer", "ROOKIE")
+			require.NoError(suite.T(), err)
+			_, err = queueOps.PopPlayersFromQueue(ctx, "ROOKIE", 1)
+			require.NoError(suite.T(), err)
+
+			// 10 held + 50 for STREET exactly covers the balance
+			_, err = service.JoinQueue(ctx, userID, "Racer", "STREET")
+			require.NoError(suite.T(), err)
+			_, err = queueOps.PopPlayersFromQueue(ctx, "STREET", 1)
+			require.NoError(suite.T(), err)
+
+			// Another ROOKIE buy-in would overdraw even though the ledger balance alone covers it
+			_, err = service.JoinQueue(ctx, userID, "Racer", "ROOKIE")
+			assert.ErrorIs(suite.T(), err, account.ErrInsufficientBalance)
+
+			inQueue, _, err := queueOps.IsUserInQueue(ctx, userID)
+			require.NoError(suite.T(), err)
+			assert.False(suite.T(), inQueue)
+
+			reserved, err := reservations.Reserved(ctx, userID)
+			require.NoError(suite.T(), err)
+			assert.True(suite.T(), decimal.NewFromInt(60).Equal(reserved), "reserved %s", reserved)
+		})
+	}
+}
+
+func (suite *JoinQueueIntegrationTestSuite) TestCancelReleasesReservation() {
+	for name, queueOps := range suite.backends() {
+		suite.Run(name, func() {
+			ctx := context.Background()
+			reservations := NewBalanceReservations(suite.redisHelper.Client)
+			accounts := &fixedBalanceAccountService{balance: decimal.NewFromInt(10)}
+			service := NewMatchmakerService(queueOps, accounts, nil, suite.logger, WithBalanceReservations(reservations))
+			userID := uuid.New()
+
+			_, err := service.JoinQueue(ctx, userID, "Racer", "ROOKIE")
+			require.NoError(suite.T(), err)
+			require.NoError(suite.T(), service.CancelQueue(ctx, userID))
+
+			reserved, err := reservations.Reserved(ctx, userID)
+			require.NoError(suite.T(), err)
+			assert.True(suite.T(), reserved.IsZero())
+
+			// The released balance can back a new join
+			_, err = service.JoinQueue(ctx, userID, "Racer", "ROOKIE")
+			require.NoError(suite.T(), err)
+		})
+	}
+}
+
+func (suite *JoinQueueIntegrationTestSuite) TestConcurrentJoinsHoldSingleBuyin() {
+	for name, queueOps := range suite.backends() {
+		suite.Run(name, func() {
+			ctx := context.Background()
+			reservations := NewBalanceReservations(suite.redisHelper.Client)
+			accounts := &fixedBalanceAccountService{balance: decimal.NewFromInt(100)}
+			service := NewMatchmakerService(queueOps, accounts, nil, suite.logger, WithBalanceReservations(reservations))
+			userID := uuid.New()
+
+			var wg sync.WaitGroup
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, _ = service.JoinQueue(ctx, userID, "Racer", "ROOKIE")
+				}()
+			}
+			wg.Wait()
+
+			// The losing join releases its own hold
+			reserved, err := reservations.Reserved(ctx, userID)
+			require.NoError(suite.T(), err)
+			assert.True(suite.T(), decimal.NewFromInt(10).Equal(reserved), "reserved %s", reserved)
+		})
+	}
+}
+
+func (suite *JoinQueueIntegrationTestSuite) TestCooldownBlocksJoinUntilExpiry() {
+	ctx := context.Background()
+	cooldowns := NewMatchCooldowns(suite.redisHelper.Client, 300*time.Millisecond, "PRO")
+	service := NewMatchmakerService(NewQueueOperations(suite.redisHelper.Client), richAccountService(), nil, suite.logger, WithMatchCooldowns(cooldowns))
+	userID := uuid.New()
+
+	require.NoError(suite.T(), cooldowns.StartCooldown(ctx, []uuid.UUID{userID}, "ROOKIE"))
+
+	_, err := service.JoinQueue(ctx, userID, "Racer", "ROOKIE")
+	var cooldownErr *ErrCooldownActive
+	require.ErrorAs(suite.T(), err, &cooldownErr)
+	assert.Equal(suite.T(), 1, cooldownErr.RemainingSeconds)
+
+	// Exempt leagues stay open during the cooldown
+	_, err = service.JoinQueue(ctx, userID, "Racer", "PRO")
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), service.CancelQueue(ctx, userID))
+
+	// The key expires on its own and the join goes through
+	time.Sleep(400 * time.Millisecond)
+	_, err = service.JoinQueue(ctx, userID, "Racer", "ROOKIE")
+	require.NoError(suite.T(), err)
+}
+
+func (suite *JoinQueueIntegrationTestSuite) TestExemptLeagueStartsNoCooldown() {
+	ctx := context.Background()
+	cooldowns := NewMatchCooldowns(suite.redisHelper.Client, time.Minute, "PRO")
+	userID := uuid.New()
+
+	require.NoError(suite.T(), cooldowns.StartCooldown(ctx, []uuid.UUID{userID}, "PRO"))
+
+	remaining, err := cooldowns.Remaining(ctx, userID, "ROOKIE")
+	require.NoError(suite.T(), err)
+	assert.Zero(suite.T(), remaining)
+}
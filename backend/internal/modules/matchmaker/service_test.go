@@ -0,0 +1,304 @@
+package matchmaker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/constants"
+)
+
+// fullQueueOperations reports every league queue as ready for a lobby
+type fullQueueOperations struct {
+	QueueOperations
+}
+
+func (q *fullQueueOperations) GetQueueSize(ctx context.Context, league string) (int64, error) {
+	return 10, nil
+}
+
+// slowLobbyManager blocks lobby formation for one league and records the others
+type slowLobbyManager struct {
+	LobbyManager
+	slowLeague string
+
+	mu     sync.Mutex
+	formed map[string]int
+}
+
+func (m *slowLobbyManager) FormLobby(ctx context.Context, league string) (*Lobby, error) {
+	if league == m.slowLeague {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.formed[league]++
+	return &Lobby{League: league}, nil
+}
+
+func (m *slowLobbyManager) formedLeagues() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	formed := make(map[string]int, len(m.formed))
+	for league, n := range m.formed {
+		formed[league] = n
+	}
+	return formed
+}
+
+func TestMatchmakingWorker_SlowLeagueDoesNotBlockOthers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	lobbies := &slowLobbyManager{slowLeague: constants.LeagueRookie, formed: make(map[string]int)}
+	service := NewMatchmakerService(
+		&fullQueueOperations{},
+		nil,
+		nil,
+		logger,
+		WithWorkerTickInterval(20*time.Millisecond),
+		WithWorkerConcurrency(2),
+		WithLobbyManager(lobbies),
+	)
+	require.NoError(t, service.StartMatchmakingWorker(ctx))
+
+	// With only two slots, a stuck league that kept being rescheduled would starve the rest
+	assert.Eventually(t, func() bool {
+		formed := lobbies.formedLeagues()
+		for _, league := range constants.ValidLeagues() {
+			if league != lobbies.slowLeague && formed[league] < 3 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Zero(t, lobbies.formedLeagues()[lobbies.slowLeague])
+}
+
+func TestMatchmakingWorker_DoneOnceChecksReturn(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	lobbies := &slowLobbyManager{slowLeague: constants.LeagueRookie, formed: make(map[string]int)}
+	service := NewMatchmakerService(
+		&fullQueueOperations{},
+		nil,
+		nil,
+		logger,
+		WithWorkerTickInterval(10*time.Millisecond),
+		WithLobbyManager(lobbies),
+	)
+	require.NoError(t, service.StartMatchmakingWorker(ctx))
+
+	// The slow league's check is still running, so the worker is not done
+	require.Eventually(t, func() bool { return len(lobbies.formedLeagues()) > 0 }, time.Second, 5*time.Millisecond)
+	select {
+	case <-service.WorkerDone():
+		t.Fatal("worker done before it was stopped")
+	default:
+	}
+
+	cancel()
+	select {
+	case <-service.WorkerDone():
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop")
+	}
+}
+
+func TestPlayersNeeded(t *testing.T) {
+	tests := []struct {
+		queueSize int64
+		want      int
+	}{
+		{queueSize: 0, want: 10},
+		{queueSize: 3, want: 7},
+		{queueSize: 10, want: 0},
+		{queueSize: 13, want: 7},
+		{queueSize: 20, want: 0},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, playersNeeded(tt.queueSize), "queue size %d", tt.queueSize)
+	}
+}
+
+func TestGetQueueInfo_PlayersNeeded(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	queue := newMemoryQueueOperations()
+	service := NewMatchmakerService(queue, nil, nil, logger)
+
+	info, err := service.GetQueueInfo(context.Background(), constants.LeagueRookie)
+	require.NoError(t, err)
+	assert.Equal(t, 10, info.PlayersNeeded)
+
+	queue.enqueue(t, constants.LeagueRookie, 13, time.Second)
+	info, err = service.GetQueueInfo(context.Background(), constants.LeagueRookie)
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), info.QueueSize)
+	assert.Equal(t, 7, info.PlayersNeeded)
+}
+
+// clockMatchCooldowns keeps cooldown deadlines in memory against an adjustable clock
+type clockMatchCooldowns struct {
+	duration time.Duration
+	now      time.Time
+	until    map[uuid.UUID]time.Time
+}
+
+func (c *clockMatchCooldowns) StartCooldown(ctx context.Context, userIDs []uuid.UUID, league string) error {
+	for _, userID := range userIDs {
+		c.until[userID] = c.now.Add(c.duration)
+	}
+	return nil
+}
+
+func (c *clockMatchCooldowns) Remaining(ctx context.Context, userID uuid.UUID, league string) (time.Duration, error) {
+	if remaining := c.until[userID].Sub(c.now); remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+func TestJoinQueue_RejectedDuringCooldown(t *testing.T) {
+	ctx := context.Background()
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	cooldowns := &clockMatchCooldowns{duration: time.Minute, now: time.Now(), until: make(map[uuid.UUID]time.Time)}
+	queue := newMemoryQueueOperations()
+	service := NewMatchmakerService(queue, richAccountService(), nil, logger, WithMatchCooldowns(cooldowns))
+	userID := uuid.New()
+
+	require.NoError(t, cooldowns.StartCooldown(ctx, []uuid.UUID{userID}, constants.LeagueRookie))
+	cooldowns.now = cooldowns.now.Add(30*time.Second + time.Millisecond)
+
+	_, err := service.JoinQueue(ctx, userID, "Racer", constants.LeagueRookie)
+	var cooldownErr *ErrCooldownActive
+	require.ErrorAs(t, err, &cooldownErr)
+	assert.Equal(t, 30, cooldownErr.RemainingSeconds)
+	size, _ := queue.GetQueueSize(ctx, constants.LeagueRookie)
+	assert.Zero(t, size)
+
+	// Once the cooldown expires the join goes through
+	cooldowns.now = cooldowns.now.Add(30 * time.Second)
+	status, err := service.JoinQueue(ctx, userID, "Racer", constants.LeagueRookie)
+	require.NoError(t, err)
+	assert.True(t, status.InQueue)
+}
+
+// memoryQueueHeartbeats keeps the players whose heartbeat has not lapsed in memory
+type memoryQueueHeartbeats struct {
+	mu    sync.Mutex
+	alive map[uuid.UUID]bool
+}
+
+func (h *memoryQueueHeartbeats) Beat(ctx context.Context, userID uuid.UUID) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.alive[userID] = true
+	return nil
+}
+
+func (h *memoryQueueHeartbeats) Alive(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	alive := make(map[uuid.UUID]bool, len(userIDs))
+	for _, userID := range userIDs {
+		alive[userID] = h.alive[userID]
+	}
+	return alive, nil
+}
+
+func (h *memoryQueueHeartbeats) Clear(ctx context.Context, userID uuid.UUID) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.alive, userID)
+	return nil
+}
+
+// recordingLobbyManager records the lobbies formed by the wrapped lobby manager
+type recordingLobbyManager struct {
+	LobbyManager
+	formed []*Lobby
+}
+
+func (m *recordingLobbyManager) FormLobby(ctx context.Context, league string) (*Lobby, error) {
+	lobby, err := m.LobbyManager.FormLobby(ctx, league)
+	if err == nil {
+		m.formed = append(m.formed, lobby)
+	}
+	return lobby, err
+}
+
+func TestCheckAndFormLobby_PrunesEntriesWithLapsedHeartbeat(t *testing.T) {
+	t.Setenv("MATCHMAKING_TIMEOUT_SECONDS", "60")
+	ctx := context.Background()
+	league := constants.LeagueRookie
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	queue := newMemoryQueueOperations()
+	heartbeats := &memoryQueueHeartbeats{alive: make(map[uuid.UUID]bool)}
+	reservations := &releaseRecorder{}
+	lobbies := &recordingLobbyManager{LobbyManager: newTestLobbyManager(queue, &recordingUserPublisher{}, nil)}
+	service := NewMatchmakerService(queue, nil, nil, logger,
+		WithLobbyManager(lobbies),
+		WithBalanceReservations(reservations),
+		WithQueueHeartbeats(heartbeats),
+	).(*matchmakerService)
+
+	// A crashed client sits at the head of the queue, ahead of a full grid of live clients
+	queue.enqueue(t, league, LobbySize+1, time.Minute)
+	entries, err := queue.PeekQueue(ctx, league, LobbySize+1)
+	require.NoError(t, err)
+	stale := entries[0].UserID
+	for _, entry := range entries[1:] {
+		require.NoError(t, service.Heartbeat(ctx, entry.UserID))
+	}
+
+	require.NoError(t, service.checkAndFormLobby(ctx, league))
+
+	inQueue, _, err := queue.IsUserInQueue(ctx, stale)
+	require.NoError(t, err)
+	assert.False(t, inQueue)
+	assert.Equal(t, 1, reservations.count(stale))
+
+	require.Len(t, lobbies.formed, 1)
+	lobby := lobbies.formed[0]
+	assert.Len(t, lobby.Players, LobbySize)
+	for _, player := range lobby.Players {
+		assert.NotEqual(t, stale, player.UserID)
+	}
+}
+
+func TestHeartbeat_RejectsPlayersOutsideQueues(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+
+	heartbeats := &memoryQueueHeartbeats{alive: make(map[uuid.UUID]bool)}
+	service := NewMatchmakerService(newMemoryQueueOperations(), nil, nil, logger, WithQueueHeartbeats(heartbeats))
+
+	userID := uuid.New()
+	assert.ErrorIs(t, service.Heartbeat(context.Background(), userID), ErrNotInQueue)
+	assert.False(t, heartbeats.alive[userID])
+}
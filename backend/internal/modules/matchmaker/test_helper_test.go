@@ -0,0 +1,67 @@
+package matchmaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedisHelper provides shared Redis setup and teardown for integration tests
+type TestRedisHelper struct {
+	Pool     *dockertest.Pool
+	Resource *dockertest.Resource
+	Client   *redis.Client
+	t        *testing.T
+}
+
+// NewTestRedisHelper creates a new test Redis helper
+func NewTestRedisHelper(t *testing.T) *TestRedisHelper {
+	return &TestRedisHelper{t: t}
+}
+
+// SetupRedis starts a Redis container and connects to it
+func (h *TestRedisHelper) SetupRedis() {
+	var err error
+
+	h.Pool, err = dockertest.NewPool("")
+	require.NoError(h.t, err)
+	h.Pool.MaxWait = 60 * time.Second
+
+	h.Resource, err = h.Pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "redis",
+		Tag:        "7-alpine",
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	require.NoError(h.t, err)
+
+	h.Client = redis.NewClient(&redis.Options{
+		Addr: h.Resource.GetHostPort("6379/tcp"),
+	})
+
+	err = h.Pool.Retry(func() error {
+		return h.Client.Ping(context.Background()).Err()
+	})
+	require.NoError(h.t, err)
+}
+
+// TeardownRedis closes the client and removes the container
+func (h *TestRedisHelper) TeardownRedis() {
+	if h.Client != nil {
+		_ = h.Client.Close() // Ignore close error during cleanup
+	}
+	if h.Resource != nil {
+		require.NoError(h.t, h.Pool.Purge(h.Resource))
+	}
+}
+
+// FlushAll removes all keys for a clean test state
+func (h *TestRedisHelper) FlushAll() {
+	require.NoError(h.t, h.Client.FlushDB(context.Background()).Err())
+}
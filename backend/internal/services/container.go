@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -12,6 +13,8 @@ import (
 	"github.com/megaherz/ndr/internal/auth"
 	"github.com/megaherz/ndr/internal/centrifugo"
 	"github.com/megaherz/ndr/internal/config"
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/metrics"
 	"github.com/megaherz/ndr/internal/modules/account"
 	authservice "github.com/megaherz/ndr/internal/modules/auth"
 	"github.com/megaherz/ndr/internal/modules/gameengine"
@@ -22,14 +25,24 @@ import (
 	"github.com/megaherz/ndr/internal/storage/redis"
 )
 
+// centrifugoHealthCheckTimeout bounds how long the health check waits for Centrifugo
+const centrifugoHealthCheckTimeout = 2 * time.Second
+
 // Container holds all application services and dependencies
 type Container struct {
 	// Configuration
 	Config *config.Config
 
+	// Metrics
+	Metrics *metrics.Metrics
+
 	// Storage
 	DB          *postgres.DB
-	RedisClient *redis.Client
+	DBPoolStats postgres.PoolStatsExporter
+	ReplicaDB   *postgres.DB // nil when no read replica is configured
+
+	ReplicaDBPoolStats postgres.PoolStatsExporter
+	RedisClient        *redis.Client
 
 	// Repositories
 	UserRepo             repository.UserRepository
@@ -38,26 +51,43 @@ type Container struct {
 	MatchRepo            repository.MatchRepository
 	MatchParticipantRepo repository.MatchParticipantRepository
 	MatchSettlementRepo  repository.MatchSettlementRepository
+	GhostReplayRepo      repository.GhostReplayRepository
+	MatchEventRepo       repository.MatchEventRepository
+	AdminAuditRepo       repository.AdminAuditRepository
+	SeasonRepo           repository.SeasonRepository
+	BurnRewardRepo       repository.BurnRewardRepository
+
+	// Leagues holds every league's buy-in, grid rules and rake, shared by the modules below
+	Leagues *constants.LeagueRegistry
 
 	// Utilities
-	JWTManager       *auth.JWTManager
+	JWTManager       auth.JWTManager
 	CentrifugoClient *centrifugo.Client
+	CentrifugoTokens *centrifugo.TokenIssuer
+	SeedCommits      *gameengine.CommitSigner
 
 	// Services
 	AuthService       authservice.AuthService
 	AccountService    account.AccountService
+	LedgerOps         account.LedgerOperations
 	GameEngineService gameengine.GameEngineService
 	MatchmakerService matchmaker.MatchmakerService
+	PresenceMonitor   matchmaker.PresenceMonitor
+	MatchAborter      gameengine.MatchAborter
+	EarnPoints        gameengine.EarnPointsService
+	MatchEvents       gameengine.MatchEventRecorder
+	Publisher         gateway.AsyncPublisher
 
 	// Logger
 	Logger *logrus.Logger
 }
 
 // NewContainer creates and initializes a new service container
-func NewContainer(cfg *config.Config, logger *logrus.Logger) (*Container, error) {
+func NewContainer(cfg *config.Config, m *metrics.Metrics, logger *logrus.Logger) (*Container, error) {
 	container := &Container{
-		Config: cfg,
-		Logger: logger,
+		Config:  cfg,
+		Metrics: m,
+		Logger:  logger,
 	}
 
 	// Initialize in dependency order
@@ -98,18 +128,41 @@ func (c *Container) initializeStorage() error {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 	c.DB = db
+	c.DBPoolStats = postgres.NewPoolStatsExporter(postgres.PrimaryPool, c.DB, c.Metrics, c.Logger)
 
 	// Run database migrations
 	if err := c.runMigrations(); err != nil {
 		return fmt.Errorf("failed to run database migrations: %w", err)
 	}
 
+	// Initialize the optional read replica with the primary's pool settings
+	if c.Config.DatabaseReplicaURL != "" {
+		replicaConfig := dbConfig
+		replicaConfig.URL = c.Config.DatabaseReplicaURL
+
+		replica, err := postgres.NewDB(replicaConfig, c.Logger)
+		if err != nil {
+			return fmt.Errorf("failed to initialize read replica: %w", err)
+		}
+		c.ReplicaDB = replica
+		c.ReplicaDBPoolStats = postgres.NewPoolStatsExporter(postgres.ReplicaPool, c.ReplicaDB, c.Metrics, c.Logger)
+	}
+
 	// Initialize Redis
 	redisConfig, err := parseRedisURL(c.Config.RedisURL)
 	if err != nil {
 		return fmt.Errorf("failed to parse Redis URL: %w", err)
 	}
 
+	// Apply connection pool settings from config
+	if c.Config.RedisPoolSize > 0 {
+		redisConfig.PoolSize = c.Config.RedisPoolSize
+	}
+	redisConfig.MinIdleConns = c.Config.RedisMinIdleConns
+	redisConfig.DialTimeout = c.Config.RedisDialTimeout
+	redisConfig.ReadTimeout = c.Config.RedisReadTimeout
+	redisConfig.MaxRetries = c.Config.RedisMaxRetries
+
 	redisClient, err := redis.NewClient(*redisConfig, c.Logger)
 	if err != nil {
 		return fmt.Errorf("failed to initialize Redis: %w", err)
@@ -121,12 +174,34 @@ func (c *Container) initializeStorage() error {
 
 // initializeRepositories creates all repository instances
 func (c *Container) initializeRepositories() error {
-	c.UserRepo = repository.NewUserRepository(c.DB.DB)
-	c.WalletRepo = repository.NewWalletRepository(c.DB.DB)
-	c.LedgerRepo = repository.NewLedgerRepository(c.DB.DB)
-	c.MatchRepo = repository.NewMatchRepository(c.DB.DB)
-	c.MatchParticipantRepo = repository.NewMatchParticipantRepository(c.DB.DB)
-	c.MatchSettlementRepo = repository.NewMatchSettlementRepository(c.DB.DB)
+	queryTimeouts := repository.WithQueryTimeouts(repository.QueryTimeouts{
+		Default: c.Config.DBQueryTimeout,
+		Max:     c.Config.DBMaxQueryTimeout,
+	})
+	readReplica := repository.WithReadReplica(nil)
+	if c.ReplicaDB != nil {
+		readReplica = repository.WithReadReplica(c.ReplicaDB.DB)
+	}
+
+	c.UserRepo = repository.NewUserRepository(c.DB.DB, queryTimeouts, readReplica)
+	c.WalletRepo = repository.NewWalletRepository(c.DB.DB, queryTimeouts)
+	// System wallet balances are polled often and only change on ledger writes
+	c.LedgerRepo = repository.NewCachedLedgerRepository(
+		repository.NewLedgerRepository(c.DB.DB, queryTimeouts, readReplica),
+		c.Config.LedgerBalanceCacheTTL,
+	)
+	// Match rows are looked up repeatedly while a match runs and settles
+	c.MatchRepo = repository.NewCachedMatchRepository(
+		repository.NewMatchRepository(c.DB.DB, queryTimeouts, readReplica),
+		c.Config.MatchCacheTTL,
+	)
+	c.MatchParticipantRepo = repository.NewMatchParticipantRepository(c.DB.DB, queryTimeouts, readReplica)
+	c.MatchSettlementRepo = repository.NewMatchSettlementRepository(c.DB.DB, queryTimeouts, readReplica)
+	c.GhostReplayRepo = repository.NewGhostReplayRepository(c.DB.DB, queryTimeouts, readReplica)
+	c.MatchEventRepo = repository.NewMatchEventRepository(c.DB.DB, queryTimeouts, readReplica)
+	c.AdminAuditRepo = repository.NewAdminAuditRepository(c.DB.DB, queryTimeouts, readReplica)
+	c.SeasonRepo = repository.NewSeasonRepository(c.DB.DB, queryTimeouts, readReplica)
+	c.BurnRewardRepo = repository.NewBurnRewardRepository(c.DB.DB, queryTimeouts)
 
 	c.Logger.Info("Repositories initialized")
 	return nil
@@ -147,43 +222,220 @@ func (c *Container) initializeUtilities() error {
 	}
 	c.CentrifugoClient = centrifugoClient
 
+	// Centrifugo connection tokens are signed with the Centrifugo HMAC secret
+	c.CentrifugoTokens = centrifugo.NewTokenIssuer(c.Config.CentrifugoSecret)
+
+	// Seed commitments are signed with Ed25519, with a key derived from the JWT secret unless one is configured
+	commitKey := gameengine.CommitKeyFromSecret(c.Config.JWTSecret)
+	if c.Config.SeedCommitKey != "" {
+		key, err := gameengine.ParseCommitKey(c.Config.SeedCommitKey)
+		if err != nil {
+			return fmt.Errorf("failed to parse seed commit key: %w", err)
+		}
+		commitKey = key
+	}
+	c.SeedCommits = gameengine.NewCommitSigner(commitKey)
+
 	c.Logger.Info("Utilities initialized")
 	return nil
 }
 
 // initializeServices creates all service instances
 func (c *Container) initializeServices() error {
-	// Auth Service - needs user repo, wallet repo, JWT manager
+	// Auth Service - needs user repo, wallet repo, JWT manager, and ledger operations for signup credits
+	signupLedgerOps := account.NewLedgerOperations(c.LedgerRepo, c.WalletRepo, c.Logger, account.WithLedgerMetrics(c.Metrics))
+	authOptions := []authservice.AuthServiceOption{
+		authservice.WithSignupGrant(signupLedgerOps, c.Config.SignupGrant()),
+		authservice.WithReferralBonus(signupLedgerOps, c.Config.ReferralBonus()),
+		authservice.WithAccessTokenTTL(c.Config.AccessTokenTTL),
+		authservice.WithInitDataMaxAge(c.Config.TelegramInitDataMaxAge),
+		authservice.WithInitDataFutureTolerance(c.Config.TelegramInitDataFutureTolerance),
+	}
+	if c.Config.TelegramInitDataValidation == "signature" {
+		publicKey, err := authservice.ParseTelegramPublicKey(c.Config.TelegramPublicKey)
+		if err != nil {
+			return fmt.Errorf("failed to parse Telegram public key: %w", err)
+		}
+		authOptions = append(authOptions, authservice.WithInitDataSignature(c.Config.TelegramBotID(), publicKey))
+	}
 	c.AuthService = authservice.NewAuthService(
 		c.UserRepo,
 		c.WalletRepo,
 		c.JWTManager,
 		c.Config.TelegramBotToken,
 		c.Logger,
+		authOptions...,
 	)
 
+	// League Registry - read once from the configuration and shared by every module
+	c.Leagues = c.Config.Leagues()
+
 	// Account Service - needs wallet repo, ledger repo
 	c.AccountService = account.NewAccountService(
 		c.WalletRepo,
 		c.LedgerRepo,
 		c.Logger,
+		account.WithLeagues(c.Leagues),
+	)
+
+	// Ledger Operations - used directly by admin balance adjustments
+	c.LedgerOps = account.NewLedgerOperations(c.LedgerRepo, c.WalletRepo, c.Logger, account.WithLedgerMetrics(c.Metrics))
+
+	// Match Event Recorder - persists heat transitions for analytics in the background
+	c.MatchEvents = gameengine.NewMatchEventRecorder(c.MatchEventRepo, c.Logger)
+
+	// Match State Manager - in-memory heat state shared by the game engine components
+	tiebreak := gameengine.NewTiebreakPolicy(c.Config.LockTimeTiebreakLeagues...)
+	stateManager := gameengine.NewMatchStateManager(
+		c.Logger,
+		gameengine.WithStateTiebreakPolicy(tiebreak),
+		gameengine.WithMatchEventRecorder(c.MatchEvents),
+		gameengine.WithStateMetrics(c.Metrics),
+		gameengine.WithStateCountdownDuration(c.Config.HeatCountdown),
 	)
 
-	// Game Engine Service - needs match repos and participant repo
+	// Game Engine Service - needs match, participant and settlement repos and the match state
+	rakeRates := c.Config.RakeRates()
 	c.GameEngineService = gameengine.NewGameEngineService(
 		c.MatchRepo,
 		c.MatchParticipantRepo,
+		c.MatchSettlementRepo,
+		stateManager,
 		c.Logger,
+		gameengine.WithRakeRates(rakeRates),
+		gameengine.WithLeagues(c.Leagues),
+		gameengine.WithLateJoinGrace(c.Config.MatchLateJoinGrace),
+		gameengine.WithBuyinLedger(account.NewLedgerOperations(c.LedgerRepo, c.WalletRepo, c.Logger, account.WithLedgerMetrics(c.Metrics))),
 	)
 
 	// Matchmaker Service - needs queue operations, account service, and publisher
-	queueOps := matchmaker.NewQueueOperations(c.RedisClient.GetClient())
-	publisher := gateway.NewCentrifugoPublisher(c.CentrifugoClient, c.Logger)
+	var queueOps matchmaker.QueueOperations
+	switch c.Config.MatchmakingQueueBackend {
+	case "zset":
+		queueOps = matchmaker.NewSortedSetQueueOperations(c.RedisClient.GetClient())
+	default:
+		queueOps = matchmaker.NewQueueOperations(c.RedisClient.GetClient())
+	}
+	// Realtime events are delivered in the background so Centrifugo outages never stall settlement
+	directPublisher := gateway.NewCentrifugoPublisher(
+		c.CentrifugoClient,
+		c.Logger,
+		gateway.WithPreviousEventVersions(c.Config.CentrifugoPreviousVersionEvents...),
+	)
+	switch c.Config.CentrifugoPublisher {
+	case "retry":
+		c.Publisher = gateway.NewRetryingPublisher(
+			directPublisher,
+			c.Logger,
+			gateway.WithRetryQueueSize(c.Config.CentrifugoPublishQueueSize),
+			gateway.WithRetryPublisherMetrics(c.Metrics),
+		)
+	default:
+		c.Publisher = gateway.NewAsyncPublisher(
+			directPublisher,
+			c.Logger,
+			gateway.WithPublishQueueSize(c.Config.CentrifugoPublishQueueSize),
+			gateway.WithAsyncPublisherMetrics(c.Metrics),
+		)
+	}
+	publisher := c.Publisher
+	reservations := matchmaker.NewBalanceReservations(c.RedisClient.GetClient())
+	cooldowns := matchmaker.NewMatchCooldowns(c.RedisClient.GetClient(), c.Config.MatchCooldown, c.Config.MatchCooldownExemptLeagues...)
+	// Without a heartbeat timeout queue entries are only pruned by the presence monitor
+	var heartbeats matchmaker.QueueHeartbeats
+	if c.Config.MatchmakingHeartbeatTimeout > 0 {
+		heartbeats = matchmaker.NewQueueHeartbeats(c.RedisClient.GetClient(), c.Config.MatchmakingHeartbeatTimeout)
+	}
+	lobbyManager := matchmaker.NewLobbyManager(
+		queueOps,
+		c.GameEngineService,
+		publisher,
+		c.Logger,
+		matchmaker.WithLobbyReservations(reservations),
+		matchmaker.WithLobbyRakeRates(rakeRates),
+		matchmaker.WithLobbyLeagues(c.Leagues),
+		matchmaker.WithLeagueRules(matchmaker.NewLeagueRules(c.Leagues)),
+		matchmaker.WithMinReadyPlayers(c.Config.LobbyMinReadyPlayers),
+		matchmaker.WithNotReadyRefund(c.Config.LobbyRefundNotReady),
+		matchmaker.WithMaxConcurrentMatches(c.Config.MaxConcurrentMatches, stateManager),
+		matchmaker.WithMatchCapacityWait(c.Config.MatchCapacityWait),
+	)
 	c.MatchmakerService = matchmaker.NewMatchmakerService(
 		queueOps,
 		c.AccountService,
 		publisher,
 		c.Logger,
+		matchmaker.WithWorkerTickInterval(c.Config.MatchmakingWorkerTickInterval),
+		matchmaker.WithWorkerConcurrency(c.Config.MatchmakingWorkerConcurrency),
+		matchmaker.WithLobbyManager(lobbyManager),
+		matchmaker.WithBalanceReservations(reservations),
+		matchmaker.WithMatchCooldowns(cooldowns),
+		matchmaker.WithQueueHeartbeats(heartbeats),
+		matchmaker.WithLeagues(c.Leagues),
+	)
+
+	// Match Aborter - needs heat, state and settlement components of the game engine
+	heatManager := gameengine.NewHeatManager(
+		stateManager,
+		publisher,
+		c.Logger,
+		gameengine.WithTickInterval(c.Config.HeatTickInterval),
+		gameengine.WithCountdownDuration(c.Config.HeatCountdown),
+		gameengine.WithIntermissionDuration(c.Config.HeatIntermission),
+		gameengine.WithMatchPresence(c.CentrifugoClient, c.Config.MatchPresenceInterval),
+		gameengine.WithSeedTargetLines(gameengine.NewTargetLinePolicy(c.Config.SeedTargetLineLeagues...), c.MatchRepo),
+	)
+	// BURN reward tables are read once at startup; leagues without rows keep the defaults
+	burnRewards, err := gameengine.LoadBurnRewardTables(context.Background(), c.BurnRewardRepo)
+	if err != nil {
+		return fmt.Errorf("failed to load BURN reward tables: %w", err)
+	}
+	settlementService := gameengine.NewSettlementService(
+		c.MatchRepo,
+		c.MatchParticipantRepo,
+		c.MatchSettlementRepo,
+		c.LedgerRepo,
+		account.NewLedgerOperations(c.LedgerRepo, c.WalletRepo, c.Logger, account.WithLedgerMetrics(c.Metrics)),
+		stateManager,
+		publisher,
+		c.Logger,
+		gameengine.WithSettlementTiebreakPolicy(tiebreak),
+		gameengine.WithSettlementCooldowns(cooldowns),
+		gameengine.WithSettlementWallets(c.WalletRepo),
+		gameengine.WithBurnRewardTables(burnRewards),
+		gameengine.WithSettlementMetrics(c.Metrics),
+		gameengine.WithReplayRecording(c.GhostReplayRepo, c.UserRepo),
+		gameengine.WithSettlementTimeout(c.Config.SettlementTimeout),
+	)
+	c.MatchAborter = gameengine.NewMatchAborter(
+		c.MatchRepo,
+		heatManager,
+		stateManager,
+		settlementService,
+		publisher,
+		c.Logger,
+		gameengine.WithAbortMetrics(c.Metrics),
+	)
+
+	// Earn Points Service - score locks and standings of running matches
+	c.EarnPoints = gameengine.NewEarnPointsService(
+		stateManager,
+		c.MatchParticipantRepo,
+		c.GhostReplayRepo,
+		gameengine.NewPhysicsEngine(),
+		heatManager,
+		c.Logger,
+		gameengine.WithEarnPointsCountdownDuration(c.Config.HeatCountdown),
+	)
+	// Ghost locks are scheduled by the earn points service, which itself needs the heat manager
+	heatManager.SetGhostHeatPlayer(c.EarnPoints)
+
+	// Presence Monitor - cancels queue entries of players who disconnected
+	c.PresenceMonitor = matchmaker.NewPresenceMonitor(
+		c.MatchmakerService,
+		queueOps,
+		c.CentrifugoClient,
+		c.Logger,
 	)
 
 	c.Logger.Info("Services initialized")
@@ -208,7 +460,12 @@ func (c *Container) Close() error {
 		}
 	}
 
-	// Close database connection
+	// Close database connections
+	if c.ReplicaDB != nil {
+		if err := c.ReplicaDB.Close(); err != nil {
+			errors = append(errors, fmt.Errorf("failed to close read replica: %w", err))
+		}
+	}
 	if c.DB != nil {
 		if err := c.DB.Close(); err != nil {
 			errors = append(errors, fmt.Errorf("failed to close database: %w", err))
@@ -230,32 +487,69 @@ func (c *Container) HealthCheck(ctx context.Context) error {
 	if err := c.DB.HealthCheck(ctx); err != nil {
 		return fmt.Errorf("database health check failed: %w", err)
 	}
+	if c.ReplicaDB != nil {
+		if err := c.ReplicaDB.HealthCheck(ctx); err != nil {
+			return fmt.Errorf("read replica health check failed: %w", err)
+		}
+	}
 
 	// Check Redis
 	if err := c.RedisClient.GetClient().Ping(ctx).Err(); err != nil {
 		return fmt.Errorf("redis health check failed: %w", err)
 	}
 
-	// Check Centrifugo (if needed)
-	// This would depend on the Centrifugo client implementation
+	// Check Centrifugo
+	if err := c.checkCentrifugo(ctx); err != nil {
+		return fmt.Errorf("centrifugo health check failed: %w", err)
+	}
 
 	return nil
 }
 
-// parseRedisURL parses a Redis URL into a Redis config
+// checkCentrifugo pings the Centrifugo server API with a short timeout
+func (c *Container) checkCentrifugo(ctx context.Context) error {
+	if c.CentrifugoClient == nil {
+		return fmt.Errorf("centrifugo client is not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, centrifugoHealthCheckTimeout)
+	defer cancel()
+
+	return c.CentrifugoClient.Ping(ctx)
+}
+
+// parseRedisURL parses a Redis URL into a Redis config.
+// Supported form: redis[s]://[[username]:password@]host[:port][/db][?pool_size=N]
 func parseRedisURL(redisURL string) (*redis.Config, error) {
 	u, err := url.Parse(redisURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid Redis URL: %w", err)
 	}
 
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid Redis URL: missing host")
+	}
+
 	config := &redis.Config{
 		Addr: u.Host,
 		DB:   0, // Default database
 	}
 
-	// Extract password if present
+	// Enable TLS for rediss:// URLs
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		config.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: u.Hostname(),
+		}
+	default:
+		return nil, fmt.Errorf("invalid Redis URL scheme %q: expected redis or rediss", u.Scheme)
+	}
+
+	// Extract ACL username and password if present
 	if u.User != nil {
+		config.Username = u.User.Username()
 		if password, ok := u.User.Password(); ok {
 			config.Password = password
 		}
@@ -265,9 +559,21 @@ func parseRedisURL(redisURL string) (*redis.Config, error) {
 	if u.Path != "" && u.Path != "/" {
 		// Remove leading slash and parse as integer
 		dbStr := u.Path[1:]
-		if db, err := strconv.Atoi(dbStr); err == nil {
-			config.DB = db
+		db, err := strconv.Atoi(dbStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis database number %q: %w", dbStr, err)
 		}
+		config.DB = db
+	}
+
+	// Extract connection options from query parameters
+	query := u.Query()
+	if poolSize := query.Get("pool_size"); poolSize != "" {
+		size, err := strconv.Atoi(poolSize)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid Redis pool_size %q", poolSize)
+		}
+		config.PoolSize = size
 	}
 
 	return config, nil
@@ -280,9 +586,6 @@ func (c *Container) runMigrations() error {
 
 	migrationRunner := postgres.NewMigrationRunner(c.DB, c.Config.DatabaseURL, c.Logger)
 
-	// Determine migrations directory path
-	// This assumes the migrations are in the standard location relative to the binary
-	migrationsDir := "internal/storage/postgres/migrations"
-
-	return migrationRunner.RunMigrations(ctx, migrationsDir)
+	// An empty directory makes the runner use the migrations embedded in the binary
+	return migrationRunner.RunMigrations(ctx, c.Config.MigrationsDir)
 }
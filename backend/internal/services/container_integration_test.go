@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/centrifugo"
+	"github.com/megaherz/ndr/internal/storage/postgres"
+	"github.com/megaherz/ndr/internal/storage/postgres/repository"
+	"github.com/megaherz/ndr/internal/storage/redis"
+)
+
+type HealthCheckIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper      *repository.TestDBHelper
+	redisPool     *dockertest.Pool
+	redisResource *dockertest.Resource
+	redisClient   *redis.Client
+	logger        *logrus.Logger
+}
+
+func TestHealthCheckIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(HealthCheckIntegrationTestSuite))
+}
+
+func (suite *HealthCheckIntegrationTestSuite) SetupSuite() {
+	suite.logger = logrus.New()
+	suite.logger.SetLevel(logrus.PanicLevel)
+
+	suite.dbHelper = repository.NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	var err error
+	suite.redisPool, err = dockertest.NewPool("")
+	require.NoError(suite.T(), err)
+	suite.redisPool.MaxWait = 60 * time.Second
+
+	suite.redisResource, err = suite.redisPool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "redis",
+		Tag:        "7-alpine",
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	require.NoError(suite.T(), err)
+
+	err = suite.redisPool.Retry(func() error {
+		suite.redisClient, err = redis.NewClient(redis.Config{
+			Addr: suite.redisResource.GetHostPort("6379/tcp"),
+		}, suite.logger)
+		return err
+	})
+	require.NoError(suite.T(), err)
+}
+
+func (suite *HealthCheckIntegrationTestSuite) TearDownSuite() {
+	if suite.redisClient != nil {
+		_ = suite.redisClient.Close() // Ignore close error during cleanup
+	}
+	if suite.redisResource != nil {
+		require.NoError(suite.T(), suite.redisPool.Purge(suite.redisResource))
+	}
+	suite.dbHelper.TeardownDatabase()
+}
+
+// newContainer returns a container backed by the suite's database and Redis and by a
+// Centrifugo API served by handler, or without a Centrifugo client when handler is nil
+func (suite *HealthCheckIntegrationTestSuite) newContainer(handler http.HandlerFunc) *Container {
+	container := &Container{
+		DB:          &postgres.DB{DB: suite.dbHelper.DB},
+		RedisClient: suite.redisClient,
+		Logger:      suite.logger,
+	}
+	if handler == nil {
+		return container
+	}
+
+	server := httptest.NewServer(handler)
+	suite.T().Cleanup(server.Close)
+
+	client, err := centrifugo.NewClient(centrifugo.Config{
+		GRPCAddr: server.URL,
+		APIKey:   "test-key",
+	}, suite.logger)
+	require.NoError(suite.T(), err)
+	container.CentrifugoClient = client
+
+	return container
+}
+
+func (suite *HealthCheckIntegrationTestSuite) TestHealthyCentrifugo() {
+	container := suite.newContainer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"result":{"nodes":[]}}` + "\n"))
+	})
+
+	assert.NoError(suite.T(), container.HealthCheck(context.Background()))
+}
+
+func (suite *HealthCheckIntegrationTestSuite) TestFailingCentrifugo() {
+	container := suite.newContainer(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	err := container.HealthCheck(context.Background())
+
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "centrifugo health check failed")
+	assert.Contains(suite.T(), err.Error(), "failed to ping Centrifugo")
+}
+
+func (suite *HealthCheckIntegrationTestSuite) TestCentrifugoNotInitialized() {
+	container := suite.newContainer(nil)
+
+	err := container.HealthCheck(context.Background())
+
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "centrifugo health check failed")
+}
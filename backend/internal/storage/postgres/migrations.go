@@ -2,15 +2,33 @@ package postgres
 
 import (
 	"context"
+	"embed"
 	"fmt"
+	"io/fs"
 	"path/filepath"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/sirupsen/logrus"
 )
 
+// embeddedMigrations holds the SQL migrations compiled into the binary
+//
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// Migrations returns the SQL migration files embedded in the binary
+func Migrations() fs.FS {
+	migrations, err := fs.Sub(embeddedMigrations, "migrations")
+	if err != nil {
+		// The directory is fixed at compile time, so this cannot fail
+		panic(fmt.Sprintf("embedded migrations directory missing: %v", err))
+	}
+	return migrations
+}
+
 // MigrationRunner handles database migrations using golang-migrate
 type MigrationRunner struct {
 	db     *DB
@@ -27,23 +45,17 @@ func NewMigrationRunner(db *DB, dbURL string, logger *logrus.Logger) *MigrationR
 	}
 }
 
-// RunMigrations executes all pending migrations using golang-migrate
+// RunMigrations executes all pending migrations using golang-migrate.
+// When migrationsDir is empty, the migrations embedded in the binary are used.
 func (m *MigrationRunner) RunMigrations(ctx context.Context, migrationsDir string) error {
-	m.logger.Info("Starting database migrations")
+	m.logger.WithFields(logrus.Fields{
+		"migrations_dir": migrationsDir,
+		"embedded":       migrationsDir == "",
+	}).Info("Starting database migrations")
 
-	// Get absolute path for migrations
-	absPath, err := filepath.Abs(migrationsDir)
+	migrator, err := m.newMigrator(migrationsDir)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path for migrations: %w", err)
-	}
-
-	// Use direct database URL connection to avoid interfering with the main connection pool
-	migrator, err := migrate.New(
-		fmt.Sprintf("file://%s", absPath),
-		m.dbURL,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create migrator: %w", err)
+		return err
 	}
 	defer func() {
 		if sourceErr, dbErr := migrator.Close(); sourceErr != nil || dbErr != nil {
@@ -97,21 +109,12 @@ func (m *MigrationRunner) GetAppliedMigrations(ctx context.Context) ([]string, e
 	return migrations, rows.Err()
 }
 
-// GetMigrationVersion returns the current migration version
+// GetMigrationVersion returns the current migration version.
+// When migrationsDir is empty, the migrations embedded in the binary are used.
 func (m *MigrationRunner) GetMigrationVersion(migrationsDir string) (uint, bool, error) {
-	// Get absolute path for migrations
-	absPath, err := filepath.Abs(migrationsDir)
+	migrator, err := m.newMigrator(migrationsDir)
 	if err != nil {
-		return 0, false, fmt.Errorf("failed to get absolute path for migrations: %w", err)
-	}
-
-	// Create migrate instance using database URL
-	migrator, err := migrate.New(
-		fmt.Sprintf("file://%s", absPath),
-		m.dbURL,
-	)
-	if err != nil {
-		return 0, false, fmt.Errorf("failed to create migrator: %w", err)
+		return 0, false, err
 	}
 	defer func() {
 		if sourceErr, dbErr := migrator.Close(); sourceErr != nil || dbErr != nil {
@@ -132,3 +135,36 @@ func (m *MigrationRunner) GetMigrationVersion(migrationsDir string) (uint, bool,
 
 	return version, dirty, nil
 }
+
+// newMigrator creates a migrate instance reading from migrationsDir, or from the
+// embedded migrations when migrationsDir is empty. It connects using the database
+// URL directly to avoid interfering with the main connection pool.
+func (m *MigrationRunner) newMigrator(migrationsDir string) (*migrate.Migrate, error) {
+	if migrationsDir == "" {
+		source, err := iofs.New(embeddedMigrations, "migrations")
+		if err != nil {
+			return nil, fmt.Errorf("failed to open embedded migrations: %w", err)
+		}
+
+		migrator, err := migrate.NewWithSourceInstance("iofs", source, m.dbURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create migrator: %w", err)
+		}
+		return migrator, nil
+	}
+
+	// Get absolute path for migrations
+	absPath, err := filepath.Abs(migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path for migrations: %w", err)
+	}
+
+	migrator, err := migrate.New(
+		fmt.Sprintf("file://%s", absPath),
+		m.dbURL,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+	return migrator, nil
+}
@@ -0,0 +1,2 @@
+-- Refunds return buy-ins to players when a match is aborted
+ALTER TYPE operation_type ADD VALUE IF NOT EXISTS 'MATCH_REFUND';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_ledger_system_currency_latest;
+DROP INDEX IF EXISTS idx_ledger_user_currency_latest;
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS balance_after;
@@ -0,0 +1,20 @@
+-- Running balance of the entry's wallet (user or system) in its currency after the entry
+ALTER TABLE ledger_entries ADD COLUMN balance_after DECIMAL(16,2);
+
+-- Backfill existing entries with the running total in insertion order
+UPDATE ledger_entries le
+SET balance_after = running.balance_after
+FROM (
+    SELECT id, SUM(amount) OVER (
+        PARTITION BY user_id, system_wallet, currency
+        ORDER BY id
+    ) AS balance_after
+    FROM ledger_entries
+) running
+WHERE le.id = running.id;
+
+ALTER TABLE ledger_entries ALTER COLUMN balance_after SET NOT NULL;
+
+-- The latest entry per wallet and currency holds the authoritative balance
+CREATE INDEX idx_ledger_user_currency_latest ON ledger_entries(user_id, currency, id DESC) WHERE user_id IS NOT NULL;
+CREATE INDEX idx_ledger_system_currency_latest ON ledger_entries(system_wallet, currency, id DESC) WHERE system_wallet IS NOT NULL;
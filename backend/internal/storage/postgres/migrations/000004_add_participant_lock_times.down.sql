@@ -0,0 +1,4 @@
+ALTER TABLE match_participants
+    DROP COLUMN IF EXISTS heat3_lock_time,
+    DROP COLUMN IF EXISTS heat2_lock_time,
+    DROP COLUMN IF EXISTS heat1_lock_time;
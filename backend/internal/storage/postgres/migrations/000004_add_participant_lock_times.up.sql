@@ -0,0 +1,6 @@
+-- Seconds into each heat (after the countdown) at which the participant locked their score.
+-- NULL when the participant crashed or the heat has not been played yet.
+ALTER TABLE match_participants
+    ADD COLUMN heat1_lock_time DECIMAL(6,3) CHECK (heat1_lock_time >= 0),
+    ADD COLUMN heat2_lock_time DECIMAL(6,3) CHECK (heat2_lock_time >= 0),
+    ADD COLUMN heat3_lock_time DECIMAL(6,3) CHECK (heat3_lock_time >= 0);
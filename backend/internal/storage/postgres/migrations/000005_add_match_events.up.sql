@@ -0,0 +1,18 @@
+-- Heat-by-heat match timeline kept for analytics after the in-memory state is dropped
+CREATE TYPE match_event_type AS ENUM ('HEAT_STARTED', 'HEAT_ENDED', 'SCORE_LOCKED', 'PLAYER_CRASHED');
+
+CREATE TABLE match_events (
+    id BIGSERIAL PRIMARY KEY,
+    match_id UUID NOT NULL REFERENCES matches(id) ON DELETE CASCADE,
+    event_type match_event_type NOT NULL,
+    heat INT NOT NULL CHECK (heat >= 1 AND heat <= 3),
+    user_id UUID,          -- Live player the event is about; no FK so analytics survive user deletion
+    ghost_replay_id UUID,  -- Ghost the event is about
+    score DECIMAL(8,2) CHECK (score >= 0),
+    heat_time DECIMAL(6,3) CHECK (heat_time >= 0), -- Seconds since the heat went active
+    occurred_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Timeline queries read a match's events in order
+CREATE INDEX idx_match_events_match_occurred ON match_events(match_id, occurred_at, id);
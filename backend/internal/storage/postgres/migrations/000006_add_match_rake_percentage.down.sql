@@ -0,0 +1,2 @@
+ALTER TABLE matches
+    DROP COLUMN IF EXISTS rake_percentage;
@@ -0,0 +1,5 @@
+-- Rake percentage applied when the match was created. Matches created before the rate
+-- became configurable were all charged the flat 8%.
+ALTER TABLE matches
+    ADD COLUMN rake_percentage DECIMAL(5,2) NOT NULL DEFAULT 8.00
+        CHECK (rake_percentage >= 0 AND rake_percentage < 100);
@@ -0,0 +1,2 @@
+-- PostgreSQL cannot drop a value from an ENUM type; SIGNUP_GRANT is left in place
+SELECT 1;
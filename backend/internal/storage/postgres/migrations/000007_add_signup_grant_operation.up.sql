@@ -0,0 +1,2 @@
+-- New players are granted FUEL once when their wallet is created
+ALTER TYPE operation_type ADD VALUE IF NOT EXISTS 'SIGNUP_GRANT';
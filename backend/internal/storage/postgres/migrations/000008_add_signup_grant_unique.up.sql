@@ -0,0 +1,5 @@
+-- A user receives at most one signup grant. Kept apart from 000007 because a new
+-- ENUM value cannot be used in the transaction that added it.
+CREATE UNIQUE INDEX idx_ledger_entries_signup_grant
+    ON ledger_entries (user_id)
+    WHERE operation_type = 'SIGNUP_GRANT';
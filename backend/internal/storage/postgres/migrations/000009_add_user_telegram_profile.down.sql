@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS telegram_is_premium,
+    DROP COLUMN IF EXISTS telegram_language_code;
@@ -0,0 +1,4 @@
+-- Telegram profile details shown in the UI
+ALTER TABLE users
+    ADD COLUMN telegram_language_code VARCHAR(35),
+    ADD COLUMN telegram_is_premium BOOLEAN NOT NULL DEFAULT FALSE;
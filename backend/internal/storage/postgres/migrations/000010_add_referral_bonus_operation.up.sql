@@ -0,0 +1,2 @@
+-- Players are credited FUEL when someone signs up through their referral link
+ALTER TYPE operation_type ADD VALUE IF NOT EXISTS 'REFERRAL_BONUS';
@@ -0,0 +1,5 @@
+-- A referral bonus references the referred user, who is credited to at most one referrer.
+-- Kept apart from 000010 because a new ENUM value cannot be used in the transaction that added it.
+CREATE UNIQUE INDEX idx_ledger_entries_referral_bonus
+    ON ledger_entries (reference_id)
+    WHERE operation_type = 'REFERRAL_BONUS';
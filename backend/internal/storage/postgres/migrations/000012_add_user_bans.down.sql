@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS banned_reason,
+    DROP COLUMN IF EXISTS banned_at;
@@ -0,0 +1,4 @@
+-- Banned users keep their row and history but can no longer sign in or use the API
+ALTER TABLE users
+    ADD COLUMN banned_at TIMESTAMP,
+    ADD COLUMN banned_reason TEXT;
@@ -0,0 +1,2 @@
+-- Keyset pagination of users walks (created_at, id) newest first
+CREATE INDEX idx_users_created_at_id ON users(created_at DESC, id DESC);
@@ -0,0 +1,2 @@
+-- Admins can credit or debit a player's FUEL by hand, e.g. to compensate for an incident
+ALTER TYPE operation_type ADD VALUE IF NOT EXISTS 'ADMIN_ADJUSTMENT';
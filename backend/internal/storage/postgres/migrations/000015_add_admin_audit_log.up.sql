@@ -0,0 +1,13 @@
+-- Every admin action that changes a player's account is recorded with who did it and why
+CREATE TABLE admin_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    admin_id UUID NOT NULL,        -- No FK: admins are configured by ID and need not be players
+    action VARCHAR(64) NOT NULL,
+    target_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Support looks up a player's audit history newest first
+CREATE INDEX idx_admin_audit_log_target_created ON admin_audit_log(target_user_id, created_at DESC, id DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_matches_completed_at;
+DROP TABLE IF EXISTS seasons;
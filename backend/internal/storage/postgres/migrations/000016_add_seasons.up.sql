@@ -0,0 +1,17 @@
+-- Competitive seasons scope leaderboards and stats to a time window. A match counts towards
+-- the season its completion time falls in, so participants need no season column.
+CREATE TABLE seasons (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP,              -- NULL while the season is open
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT seasons_window_check CHECK (ends_at IS NULL OR ends_at > starts_at)
+);
+
+-- At most one season is open at a time
+CREATE UNIQUE INDEX idx_seasons_single_open ON seasons((ends_at IS NULL)) WHERE ends_at IS NULL;
+
+-- Season leaderboards read completed matches by completion time
+CREATE INDEX idx_matches_completed_at ON matches(completed_at) WHERE status = 'COMPLETED';
@@ -0,0 +1,4 @@
+ALTER TABLE match_settlements
+    DROP COLUMN IF EXISTS burn_reward_table;
+
+DROP TABLE IF EXISTS burn_rewards;
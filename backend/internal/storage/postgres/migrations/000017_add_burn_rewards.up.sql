@@ -0,0 +1,23 @@
+-- BURN rewards by league and final position, loaded at startup so the BURN economy can be
+-- tuned without a redeploy. Leagues without rows fall back to the compiled-in defaults.
+CREATE TABLE burn_rewards (
+    league league_type NOT NULL,
+    position INT NOT NULL CHECK (position >= 1 AND position <= 10),
+    amount DECIMAL(16,2) NOT NULL CHECK (amount >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (league, position)
+);
+
+-- Seed with the launch values; ROOKIE has no BURN rewards (FR-038)
+INSERT INTO burn_rewards (league, position, amount) VALUES
+    ('STREET', 1, 50), ('STREET', 2, 30), ('STREET', 3, 20), ('STREET', 4, 10), ('STREET', 5, 5),
+    ('PRO', 1, 300), ('PRO', 2, 200), ('PRO', 3, 150), ('PRO', 4, 100), ('PRO', 5, 75),
+    ('PRO', 6, 50), ('PRO', 7, 25),
+    ('TOP_FUEL', 1, 3000), ('TOP_FUEL', 2, 2000), ('TOP_FUEL', 3, 1500), ('TOP_FUEL', 4, 1000),
+    ('TOP_FUEL', 5, 750), ('TOP_FUEL', 6, 500), ('TOP_FUEL', 7, 400), ('TOP_FUEL', 8, 300),
+    ('TOP_FUEL', 9, 200), ('TOP_FUEL', 10, 100);
+
+-- Each settlement records the BURN table it paid out with, as {"position": "amount"}
+ALTER TABLE match_settlements
+    ADD COLUMN burn_reward_table JSONB NOT NULL DEFAULT '{}';
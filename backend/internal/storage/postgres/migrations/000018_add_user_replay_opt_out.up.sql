@@ -0,0 +1,3 @@
+-- Settled live runs are recorded as ghost replays unless the player opted out
+ALTER TABLE users
+    ADD COLUMN replay_opt_out BOOLEAN NOT NULL DEFAULT FALSE;
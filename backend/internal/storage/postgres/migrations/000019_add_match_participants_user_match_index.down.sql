@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_match_participants_user_id_match_id;
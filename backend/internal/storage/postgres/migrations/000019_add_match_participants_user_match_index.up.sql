@@ -0,0 +1,3 @@
+-- Finding a player's active match reads their match IDs straight from the index
+CREATE INDEX idx_match_participants_user_id_match_id ON match_participants(user_id, match_id)
+WHERE user_id IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Bumped with every balance change, in the same transaction, so balance polls have a per-user cursor
+ALTER TABLE wallets
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
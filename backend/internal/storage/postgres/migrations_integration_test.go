@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrationRunnerIntegrationTestSuite struct {
+	suite.Suite
+	pool     *dockertest.Pool
+	resource *dockertest.Resource
+	dbURL    string
+	db       *DB
+}
+
+func TestMigrationRunnerIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(MigrationRunnerIntegrationTestSuite))
+}
+
+func (suite *MigrationRunnerIntegrationTestSuite) SetupSuite() {
+	var err error
+
+	suite.pool, err = dockertest.NewPool("")
+	require.NoError(suite.T(), err)
+	suite.pool.MaxWait = 120 * time.Second
+
+	suite.resource, err = suite.pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "postgres",
+		Tag:        "17-alpine",
+		Env: []string{
+			"POSTGRES_PASSWORD=testpass",
+			"POSTGRES_USER=testuser",
+			"POSTGRES_DB=testdb",
+		},
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{Name: "no"}
+	})
+	require.NoError(suite.T(), err)
+
+	suite.dbURL = fmt.Sprintf("postgres://testuser:testpass@%s/testdb?sslmode=disable", suite.resource.GetHostPort("5432/tcp"))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	err = suite.pool.Retry(func() error {
+		suite.db, err = NewDB(Config{URL: suite.dbURL}, logger)
+		return err
+	})
+	require.NoError(suite.T(), err)
+}
+
+func (suite *MigrationRunnerIntegrationTestSuite) TearDownSuite() {
+	if suite.db != nil {
+		_ = suite.db.Close() // Ignore close error during cleanup
+	}
+	if suite.resource != nil {
+		require.NoError(suite.T(), suite.pool.Purge(suite.resource))
+	}
+}
+
+func (suite *MigrationRunnerIntegrationTestSuite) TestRunEmbeddedMigrationsFromAnyWorkingDirectory() {
+	ctx := context.Background()
+
+	// Run from a directory that has no relation to the source tree
+	suite.T().Chdir(suite.T().TempDir())
+
+	runner := NewMigrationRunner(suite.db, suite.dbURL, suite.db.logger)
+
+	err := runner.RunMigrations(ctx, "")
+	require.NoError(suite.T(), err)
+
+	// Running again is a no-op
+	err = runner.RunMigrations(ctx, "")
+	require.NoError(suite.T(), err)
+
+	version, dirty, err := runner.GetMigrationVersion("")
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), dirty)
+	assert.GreaterOrEqual(suite.T(), version, uint(1))
+
+	var walletCount int
+	err = suite.db.GetContext(ctx, &walletCount, `SELECT COUNT(*) FROM system_wallets`)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, walletCount)
+}
+
+func (suite *MigrationRunnerIntegrationTestSuite) TestEmbeddedMigrationsContainInitialSchema() {
+	entries, err := embeddedMigrations.ReadDir("migrations")
+	require.NoError(suite.T(), err)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	assert.Contains(suite.T(), names, "000001_initial_schema.up.sql")
+	assert.Contains(suite.T(), names, "000001_initial_schema.down.sql")
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminAuditEntry records an admin action taken on a player's account
+type AdminAuditEntry struct {
+	ID           int64            `db:"id" json:"id"`
+	AdminID      uuid.UUID        `db:"admin_id" json:"admin_id"`
+	Action       AdminAuditAction `db:"action" json:"action"`
+	TargetUserID uuid.UUID        `db:"target_user_id" json:"target_user_id"`
+	Reason       string           `db:"reason" json:"reason"`
+	Details      json.RawMessage  `db:"details" json:"details"` // Action-specific data, e.g. the adjusted amount
+	CreatedAt    time.Time        `db:"created_at" json:"created_at"`
+}
+
+// AdminAuditAction represents the kind of admin action recorded
+type AdminAuditAction string
+
+const (
+	AdminActionAdjustBalance AdminAuditAction = "ADJUST_BALANCE"
+)
+
+// String returns the string representation
+func (a AdminAuditAction) String() string {
+	return string(a)
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BurnReward is the BURN paid to a live player finishing a league's match in a given position
+type BurnReward struct {
+	League    League          `db:"league" json:"league"`
+	Position  int             `db:"position" json:"position"`
+	Amount    decimal.Decimal `db:"amount" json:"amount"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxDisplayNameLength is the maximum number of characters kept in a display name; combining
+// marks count with the character they modify
+const MaxDisplayNameLength = 32
+
+// DefaultDisplayName replaces display names with nothing printable left after sanitization
+const DefaultDisplayName = "Racer"
+
+// maxCombiningMarks limits how many combining marks may stack on a single character
+const maxCombiningMarks = 2
+
+// zeroWidthJoiner joins emoji into a single glyph, e.g. family and profession emoji
+const zeroWidthJoiner = '\u200d'
+
+// reservedDisplayNames are names of system wallets and staff roles that players must not impersonate,
+// compared after lowercasing and dropping everything but letters and digits
+var reservedDisplayNames = map[string]bool{
+	"system":        true,
+	"admin":         true,
+	"administrator": true,
+	"moderator":     true,
+	"support":       true,
+	"housefuel":     true,
+	"rakefuel":      true,
+}
+
+// SanitizeDisplayName makes a player-supplied name safe to store and show to other players.
+// It drops control, format (zero-width, bidi override) and private-use characters, keeps
+// zero-width joiners only inside emoji sequences, limits stacked combining marks, collapses
+// whitespace and truncates to MaxDisplayNameLength characters. Names that impersonate system
+// strings, or have nothing printable left, sanitize to the empty string.
+func SanitizeDisplayName(name string) string {
+	runes := []rune(strings.ToValidUTF8(name, ""))
+
+	var b strings.Builder
+	kept := 0
+	marks := 0
+	var prev rune
+	pendingSpace := false
+	for i, r := range runes {
+		if kept >= MaxDisplayNameLength {
+			break
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			pendingSpace = kept > 0
+			continue
+		case r == zeroWidthJoiner:
+			// Only joins two symbols, so it cannot hide between letters
+			if !unicode.Is(unicode.So, prev) || i+1 >= len(runes) || !unicode.Is(unicode.So, runes[i+1]) {
+				continue
+			}
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r), unicode.Is(unicode.Co, r), unicode.Is(unicode.Cs, r), r == utf8.RuneError:
+			continue
+		case unicode.Is(unicode.M, r):
+			if kept == 0 || marks >= maxCombiningMarks {
+				continue
+			}
+			marks++
+			b.WriteRune(r)
+			continue
+		case !unicode.IsPrint(r) && !unicode.Is(unicode.So, r):
+			continue
+		}
+
+		if pendingSpace {
+			b.WriteRune(' ')
+			kept++
+			pendingSpace = false
+			if kept >= MaxDisplayNameLength {
+				break
+			}
+		}
+		b.WriteRune(r)
+		kept++
+		marks = 0
+		prev = r
+	}
+
+	sanitized := strings.TrimRight(b.String(), " "+string(zeroWidthJoiner))
+	if isReservedDisplayName(sanitized) {
+		return ""
+	}
+	return sanitized
+}
+
+// isReservedDisplayName reports whether a name reads as a reserved system name
+func isReservedDisplayName(name string) bool {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return reservedDisplayNames[b.String()]
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeDisplayName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "plain", input: "Racer", want: "Racer"},
+		{name: "accented", input: "José Müller", want: "José Müller"},
+		{name: "cyrillic", input: "Гонщик", want: "Гонщик"},
+		{name: "cjk", input: "赛车手", want: "赛车手"},
+		{name: "emoji", input: "Nitro \U0001F3CE\ufe0f\U0001F525", want: "Nitro \U0001F3CE\ufe0f\U0001F525"},
+		{name: "zwj emoji sequence", input: "Family \U0001F468\u200d\U0001F469\u200d\U0001F467", want: "Family \U0001F468\u200d\U0001F469\u200d\U0001F467"},
+		{name: "skin tone modifier", input: "👍🏽 Fast", want: "👍🏽 Fast"},
+		{name: "surrounding whitespace", input: "  Nitro \t Racer\n", want: "Nitro Racer"},
+		{name: "control characters", input: "Ni\x00tr\x07o\x1b[31m", want: "Nitro[31m"},
+		{name: "newline injection", input: "Nitro\nSYSTEM: you won", want: "Nitro SYSTEM: you won"},
+		{name: "zero-width characters", input: "Ni\u200btr\u200co\ufeff", want: "Nitro"},
+		{name: "zero-width joiner between letters", input: "Ni\u200dtro", want: "Nitro"},
+		{name: "bidi override", input: "\u202eresaR", want: "resaR"},
+		{name: "private use", input: "Ni\ue000tro", want: "Nitro"},
+		{name: "invalid utf-8", input: "Nitro\xff\xfe", want: "Nitro"},
+		{name: "stacked combining marks", input: "Z\u0301\u0302\u0303\u0304o", want: "Z\u0301\u0302o"},
+		{name: "leading combining mark", input: "\u0301Nitro", want: "Nitro"},
+		{name: "only invisible characters", input: "\u200b\u2060\u202e", want: ""},
+		{name: "system impersonation", input: "SYSTEM", want: ""},
+		{name: "spaced admin impersonation", input: "A d m i n", want: ""},
+		{name: "system wallet impersonation", input: "HOUSE_FUEL", want: ""},
+		{name: "admin inside longer name", input: "Admin Slayer", want: "Admin Slayer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SanitizeDisplayName(tt.input))
+		})
+	}
+}
+
+func TestSanitizeDisplayName_TruncatesByCharacter(t *testing.T) {
+	assert.Equal(t, strings.Repeat("a", MaxDisplayNameLength), SanitizeDisplayName(strings.Repeat("a", 100)))
+
+	// Multi-byte characters are never cut in half
+	sanitized := SanitizeDisplayName(strings.Repeat("🏎", 100))
+	assert.True(t, utf8.ValidString(sanitized))
+	assert.Equal(t, MaxDisplayNameLength, utf8.RuneCountInString(sanitized))
+
+	// A cut never leaves a trailing space
+	sanitized = SanitizeDisplayName(strings.Repeat("a", MaxDisplayNameLength-1) + " b")
+	assert.Equal(t, strings.Repeat("a", MaxDisplayNameLength-1), sanitized)
+}
+
+func TestSanitizeDisplayName_IsIdempotent(t *testing.T) {
+	for _, input := range []string{"Nitro \U0001F3CE\ufe0f", "  Ni\u200btro  Racer ", "Z\u0301\u0302\u0303o", strings.Repeat("\u0413\u043e\u043d\u0449\u0438\u043a ", 10)} {
+		once := SanitizeDisplayName(input)
+		assert.Equal(t, once, SanitizeDisplayName(once), input)
+	}
+}
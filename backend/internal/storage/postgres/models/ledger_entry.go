@@ -17,6 +17,7 @@ type LedgerEntry struct {
 	OperationType OperationType   `db:"operation_type" json:"operation_type"`
 	ReferenceID   *uuid.UUID      `db:"reference_id" json:"reference_id,omitempty"`
 	Description   *string         `db:"description" json:"description,omitempty"`
+	BalanceAfter  decimal.Decimal `db:"balance_after" json:"balance_after"`
 	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
 }
 
@@ -53,7 +54,11 @@ const (
 	OperationMatchPrize      OperationType = "MATCH_PRIZE"
 	OperationMatchRake       OperationType = "MATCH_RAKE"
 	OperationMatchBurnReward OperationType = "MATCH_BURN_REWARD"
+	OperationMatchRefund     OperationType = "MATCH_REFUND"
 	OperationInitialBalance  OperationType = "INITIAL_BALANCE"
+	OperationSignupGrant     OperationType = "SIGNUP_GRANT"
+	OperationReferralBonus   OperationType = "REFERRAL_BONUS"
+	OperationAdminAdjustment OperationType = "ADMIN_ADJUSTMENT"
 )
 
 // String returns the string representation
@@ -66,7 +71,8 @@ func (o OperationType) IsValid() bool {
 	switch o {
 	case OperationDeposit, OperationWithdrawal, OperationMatchBuyin,
 		OperationMatchPrize, OperationMatchRake, OperationMatchBurnReward,
-		OperationInitialBalance:
+		OperationMatchRefund, OperationInitialBalance, OperationSignupGrant,
+		OperationReferralBonus, OperationAdminAdjustment:
 		return true
 	}
 	return false
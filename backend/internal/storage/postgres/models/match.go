@@ -16,6 +16,7 @@ type Match struct {
 	GhostPlayerCount int             `db:"ghost_player_count" json:"ghost_player_count"`
 	PrizePool        decimal.Decimal `db:"prize_pool" json:"prize_pool"`
 	RakeAmount       decimal.Decimal `db:"rake_amount" json:"rake_amount"`
+	RakePercentage   decimal.Decimal `db:"rake_percentage" json:"rake_percentage"` // Rake rate applied at creation
 	CrashSeed        string          `db:"crash_seed" json:"crash_seed"`
 	CrashSeedHash    string          `db:"crash_seed_hash" json:"crash_seed_hash"`
 	StartedAt        *time.Time      `db:"started_at" json:"started_at,omitempty"`
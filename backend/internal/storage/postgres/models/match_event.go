@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// MatchEvent is one transition in a match's heat-by-heat timeline, kept for analytics
+type MatchEvent struct {
+	ID            int64            `db:"id" json:"id"`
+	MatchID       uuid.UUID        `db:"match_id" json:"match_id"`
+	EventType     MatchEventType   `db:"event_type" json:"event_type"`
+	Heat          int              `db:"heat" json:"heat"`
+	UserID        *uuid.UUID       `db:"user_id" json:"user_id,omitempty"`                 // Set for live player events
+	GhostReplayID *uuid.UUID       `db:"ghost_replay_id" json:"ghost_replay_id,omitempty"` // Set for ghost events
+	Score         *decimal.Decimal `db:"score" json:"score,omitempty"`                     // Locked score
+	HeatTime      *float64         `db:"heat_time" json:"heat_time,omitempty"`             // Seconds since the heat went active
+	OccurredAt    time.Time        `db:"occurred_at" json:"occurred_at"`
+	CreatedAt     time.Time        `db:"created_at" json:"created_at"`
+}
+
+// MatchEventType represents the kind of match transition (PostgreSQL ENUM: match_event_type)
+type MatchEventType string
+
+const (
+	MatchEventHeatStarted   MatchEventType = "HEAT_STARTED"
+	MatchEventHeatEnded     MatchEventType = "HEAT_ENDED"
+	MatchEventScoreLocked   MatchEventType = "SCORE_LOCKED"
+	MatchEventPlayerCrashed MatchEventType = "PLAYER_CRASHED"
+)
+
+// String returns the string representation
+func (t MatchEventType) String() string {
+	return string(t)
+}
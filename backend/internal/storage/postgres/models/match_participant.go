@@ -19,6 +19,9 @@ type MatchParticipant struct {
 	Heat1Score        *decimal.Decimal `db:"heat1_score" json:"heat1_score,omitempty"`
 	Heat2Score        *decimal.Decimal `db:"heat2_score" json:"heat2_score,omitempty"`
 	Heat3Score        *decimal.Decimal `db:"heat3_score" json:"heat3_score,omitempty"`
+	Heat1LockTime     *float64         `db:"heat1_lock_time" json:"heat1_lock_time,omitempty"` // Seconds into the heat, nil if not locked
+	Heat2LockTime     *float64         `db:"heat2_lock_time" json:"heat2_lock_time,omitempty"`
+	Heat3LockTime     *float64         `db:"heat3_lock_time" json:"heat3_lock_time,omitempty"`
 	TotalScore        *decimal.Decimal `db:"total_score" json:"total_score,omitempty"`
 	FinalPosition     *int             `db:"final_position" json:"final_position,omitempty"`
 	PrizeAmount       decimal.Decimal  `db:"prize_amount" json:"prize_amount"`
@@ -1,13 +1,41 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 // MatchSettlement ensures idempotent settlement (applied exactly once per match)
 type MatchSettlement struct {
-	MatchID   uuid.UUID `db:"match_id" json:"match_id"`
-	SettledAt time.Time `db:"settled_at" json:"settled_at"`
+	MatchID         uuid.UUID       `db:"match_id" json:"match_id"`
+	SettledAt       time.Time       `db:"settled_at" json:"settled_at"`
+	BurnRewardTable json.RawMessage `db:"burn_reward_table" json:"burn_reward_table"` // BURN paid by position when settled
+}
+
+// GetBurnRewardTable parses the burn_reward_table JSONB field
+func (ms *MatchSettlement) GetBurnRewardTable() (map[int]decimal.Decimal, error) {
+	table := make(map[int]decimal.Decimal)
+	if len(ms.BurnRewardTable) == 0 {
+		return table, nil
+	}
+	if err := json.Unmarshal(ms.BurnRewardTable, &table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// SetBurnRewardTable sets the burn_reward_table JSONB field
+func (ms *MatchSettlement) SetBurnRewardTable(table map[int]decimal.Decimal) error {
+	if table == nil {
+		table = map[int]decimal.Decimal{}
+	}
+	data, err := json.Marshal(table)
+	if err != nil {
+		return err
+	}
+	ms.BurnRewardTable = data
+	return nil
 }
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Season is a competitive window that leaderboards and stats are scoped to. A match belongs to
+// the season its completion time falls in.
+type Season struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	Name      string     `db:"name" json:"name"`
+	StartsAt  time.Time  `db:"starts_at" json:"starts_at"`
+	EndsAt    *time.Time `db:"ends_at" json:"ends_at,omitempty"` // Nil while the season is open
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+// IsOpen reports whether the season has not been closed yet
+func (s *Season) IsOpen() bool {
+	return s.EndsAt == nil
+}
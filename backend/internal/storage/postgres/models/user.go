@@ -8,12 +8,22 @@ import (
 
 // User represents a player account
 type User struct {
-	ID                uuid.UUID `db:"id" json:"id"`
-	TelegramID        int64     `db:"telegram_id" json:"telegram_id"`
-	TelegramUsername  *string   `db:"telegram_username" json:"telegram_username,omitempty"`
-	TelegramFirstName string    `db:"telegram_first_name" json:"telegram_first_name"`
-	TelegramLastName  *string   `db:"telegram_last_name" json:"telegram_last_name,omitempty"`
-	TelegramPhotoURL  *string   `db:"telegram_photo_url" json:"telegram_photo_url,omitempty"`
-	CreatedAt         time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+	ID                   uuid.UUID  `db:"id" json:"id"`
+	TelegramID           int64      `db:"telegram_id" json:"telegram_id"`
+	TelegramUsername     *string    `db:"telegram_username" json:"telegram_username,omitempty"`
+	TelegramFirstName    string     `db:"telegram_first_name" json:"telegram_first_name"`
+	TelegramLastName     *string    `db:"telegram_last_name" json:"telegram_last_name,omitempty"`
+	TelegramPhotoURL     *string    `db:"telegram_photo_url" json:"telegram_photo_url,omitempty"`
+	TelegramLanguageCode *string    `db:"telegram_language_code" json:"telegram_language_code,omitempty"` // IETF language tag of the user's Telegram client
+	TelegramIsPremium    bool       `db:"telegram_is_premium" json:"telegram_is_premium"`
+	BannedAt             *time.Time `db:"banned_at" json:"banned_at,omitempty"`
+	BannedReason         *string    `db:"banned_reason" json:"banned_reason,omitempty"`
+	ReplayOptOut         bool       `db:"replay_opt_out" json:"replay_opt_out"` // Keeps the user's runs from being recorded as ghost replays
+	CreatedAt            time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt            time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// IsBanned reports whether the user is banned from signing in and using the API
+func (u *User) IsBanned() bool {
+	return u.BannedAt != nil
 }
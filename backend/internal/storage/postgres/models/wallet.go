@@ -15,6 +15,7 @@ type Wallet struct {
 	BurnBalance          decimal.Decimal `db:"burn_balance" json:"burn_balance"`
 	RookieRacesCompleted int             `db:"rookie_races_completed" json:"rookie_races_completed"`
 	TonWalletAddress     *string         `db:"ton_wallet_address" json:"ton_wallet_address,omitempty"`
+	Version              int64           `db:"version" json:"version"` // Bumped with every balance change
 	CreatedAt            time.Time       `db:"created_at" json:"created_at"`
 	UpdatedAt            time.Time       `db:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/megaherz/ndr/internal/metrics"
+)
+
+// Pool labels of the database connection pool metrics
+const (
+	PrimaryPool = "primary"
+	ReplicaPool = "replica"
+)
+
+// PoolStatsSource reports the statistics of a connection pool; *sql.DB and *sqlx.DB implement it
+type PoolStatsSource interface {
+	Stats() sql.DBStats
+}
+
+// PoolStatsExporter publishes the statistics of a database connection pool as metrics,
+// so pool saturation is visible during settlement bursts
+type PoolStatsExporter interface {
+	// Export records the current pool statistics
+	Export()
+
+	// Start exports the pool statistics periodically until the context is cancelled
+	Start(ctx context.Context, interval time.Duration)
+}
+
+// poolStatsExporter implements PoolStatsExporter
+type poolStatsExporter struct {
+	pool    string
+	source  PoolStatsSource
+	metrics *metrics.Metrics
+	logger  *logrus.Logger
+}
+
+// NewPoolStatsExporter creates an exporter of a connection pool's statistics labelled with the pool name
+func NewPoolStatsExporter(pool string, source PoolStatsSource, m *metrics.Metrics, logger *logrus.Logger) PoolStatsExporter {
+	return &poolStatsExporter{
+		pool:    pool,
+		source:  source,
+		metrics: m,
+		logger:  logger,
+	}
+}
+
+// Export records the current pool statistics
+func (e *poolStatsExporter) Export() {
+	e.metrics.SetDBStats(e.pool, e.source.Stats())
+}
+
+// Start exports the pool statistics periodically until the context is cancelled
+func (e *poolStatsExporter) Start(ctx context.Context, interval time.Duration) {
+	e.logger.WithFields(logrus.Fields{
+		"pool":     e.pool,
+		"interval": interval,
+	}).Info("Starting database pool stats exporter")
+
+	e.Export()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				e.logger.WithField("pool", e.pool).Info("Database pool stats exporter stopped")
+				return
+			case <-ticker.C:
+				e.Export()
+			}
+		}
+	}()
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/metrics"
+)
+
+// stubDriver opens connections that support nothing but being held and closed
+type stubDriver struct{}
+
+func (stubDriver) Open(name string) (driver.Conn, error) { return stubConn{}, nil }
+
+type stubConn struct{}
+
+func (stubConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (stubConn) Close() error                              { return nil }
+func (stubConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func init() {
+	sql.Register("postgres-pool-stats-stub", stubDriver{})
+}
+
+func TestPoolStatsExporter_ReflectsOpenConnections(t *testing.T) {
+	db, err := sql.Open("postgres-pool-stats-stub", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	m := metrics.NewWithRegistry(prometheus.NewRegistry())
+	exporter := NewPoolStatsExporter(PrimaryPool, db, m, logger)
+
+	// Hold three connections and return one to the pool
+	ctx := context.Background()
+	conns := make([]*sql.Conn, 3)
+	for i := range conns {
+		conns[i], err = db.Conn(ctx)
+		require.NoError(t, err)
+	}
+	require.NoError(t, conns[2].Close())
+
+	exporter.Export()
+	assert.Equal(t, 10.0, testutil.ToFloat64(m.DBMaxOpenConnections.WithLabelValues(PrimaryPool)))
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.DBOpenConnections.WithLabelValues(PrimaryPool)))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.DBInUseConnections.WithLabelValues(PrimaryPool)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.DBIdleConnections.WithLabelValues(PrimaryPool)))
+	assert.Zero(t, testutil.ToFloat64(m.DBWaitCount.WithLabelValues(PrimaryPool)))
+
+	// Releasing the rest shows up on the next export
+	require.NoError(t, conns[0].Close())
+	require.NoError(t, conns[1].Close())
+	exporter.Export()
+	assert.Zero(t, testutil.ToFloat64(m.DBInUseConnections.WithLabelValues(PrimaryPool)))
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.DBIdleConnections.WithLabelValues(PrimaryPool)))
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// AdminAuditRepository defines the interface for admin audit log data access
+type AdminAuditRepository interface {
+	// Create records an admin action, filling in its ID and creation time
+	Create(ctx context.Context, entry *models.AdminAuditEntry) error
+
+	// GetByTargetUser retrieves the most recent admin actions taken on a user, newest first
+	GetByTargetUser(ctx context.Context, userID uuid.UUID, limit int) ([]*models.AdminAuditEntry, error)
+}
+
+// adminAuditRepository implements AdminAuditRepository
+type adminAuditRepository struct {
+	db *timeoutDB
+}
+
+// NewAdminAuditRepository creates a new admin audit repository
+func NewAdminAuditRepository(db *sqlx.DB, opts ...Option) AdminAuditRepository {
+	return &adminAuditRepository{db: newTimeoutDB(db, opts...)}
+}
+
+// Create records an admin action, filling in its ID and creation time
+func (r *adminAuditRepository) Create(ctx context.Context, entry *models.AdminAuditEntry) error {
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+	return timeoutError(ctx, insertAuditEntry(ctx, r.db.DB, entry))
+}
+
+// insertAuditEntry records an admin action through q, filling in its ID and creation time
+func insertAuditEntry(ctx context.Context, q sqlx.QueryerContext, entry *models.AdminAuditEntry) error {
+	details := entry.Details
+	if len(details) == 0 {
+		details = []byte("{}")
+	}
+
+	query := `
+		INSERT INTO admin_audit_log (admin_id, action, target_user_id, reason, details)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	var created struct {
+		ID        int64     `db:"id"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+	err := sqlx.GetContext(ctx, q, &created, query, entry.AdminID, entry.Action, entry.TargetUserID, entry.Reason, []byte(details))
+	if err != nil {
+		return mapConstraintError(err)
+	}
+	entry.ID = created.ID
+	entry.Details = details
+	entry.CreatedAt = created.CreatedAt
+	return nil
+}
+
+// GetByTargetUser retrieves the most recent admin actions taken on a user, newest first
+func (r *adminAuditRepository) GetByTargetUser(ctx context.Context, userID uuid.UUID, limit int) ([]*models.AdminAuditEntry, error) {
+	entries := []*models.AdminAuditEntry{}
+	query := `
+		SELECT id, admin_id, action, target_user_id, reason, details, created_at
+		FROM admin_audit_log
+		WHERE target_user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+
+	err := r.db.reader(ctx).SelectContext(ctx, &entries, query, userID, limit)
+	return entries, err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+type AdminAuditRepositoryIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper  *TestDBHelper
+	auditRepo AdminAuditRepository
+	userRepo  UserRepository
+}
+
+func TestAdminAuditRepositoryIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(AdminAuditRepositoryIntegrationTestSuite))
+}
+
+func (suite *AdminAuditRepositoryIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	suite.auditRepo = NewAdminAuditRepository(suite.dbHelper.DB)
+	suite.userRepo = NewUserRepository(suite.dbHelper.DB)
+}
+
+func (suite *AdminAuditRepositoryIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *AdminAuditRepositoryIntegrationTestSuite) SetupTest() {
+	suite.dbHelper.CleanupTables("admin_audit_log", "users")
+}
+
+func (suite *AdminAuditRepositoryIntegrationTestSuite) createUser(telegramID int64) uuid.UUID {
+	user := &models.User{
+		ID:                uuid.New(),
+		TelegramID:        telegramID,
+		TelegramFirstName: "Racer",
+		CreatedAt:         time.Now().UTC(),
+		UpdatedAt:         time.Now().UTC(),
+	}
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), user))
+	return user.ID
+}
+
+func (suite *AdminAuditRepositoryIntegrationTestSuite) TestCreate_ListsNewestFirst() {
+	ctx := context.Background()
+	userID := suite.createUser(1001)
+	otherUserID := suite.createUser(1002)
+	adminID := uuid.New()
+
+	for i, reason := range []string{"first", "second", "third"} {
+		entry := &models.AdminAuditEntry{
+			AdminID:      adminID,
+			Action:       models.AdminActionAdjustBalance,
+			TargetUserID: userID,
+			Reason:       reason,
+			Details:      json.RawMessage(`{"currency":"FUEL","amount":"10"}`),
+		}
+		require.NoError(suite.T(), suite.auditRepo.Create(ctx, entry))
+		assert.NotZero(suite.T(), entry.ID, i)
+		assert.False(suite.T(), entry.CreatedAt.IsZero(), i)
+	}
+	require.NoError(suite.T(), suite.auditRepo.Create(ctx, &models.AdminAuditEntry{
+		AdminID:      adminID,
+		Action:       models.AdminActionAdjustBalance,
+		TargetUserID: otherUserID,
+		Reason:       "other player",
+	}))
+
+	entries, err := suite.auditRepo.GetByTargetUser(ctx, userID, 2)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), entries, 2)
+	assert.Equal(suite.T(), "third", entries[0].Reason)
+	assert.Equal(suite.T(), "second", entries[1].Reason)
+	assert.Equal(suite.T(), adminID, entries[0].AdminID)
+	assert.JSONEq(suite.T(), `{"currency":"FUEL","amount":"10"}`, string(entries[0].Details))
+
+	// Entries without details store an empty object
+	entries, err = suite.auditRepo.GetByTargetUser(ctx, otherUserID, 10)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), entries, 1)
+	assert.JSONEq(suite.T(), `{}`, string(entries[0].Details))
+}
+
+func (suite *AdminAuditRepositoryIntegrationTestSuite) TestCreate_RejectsUnknownUser() {
+	err := suite.auditRepo.Create(context.Background(), &models.AdminAuditEntry{
+		AdminID:      uuid.New(),
+		Action:       models.AdminActionAdjustBalance,
+		TargetUserID: uuid.New(),
+		Reason:       "missing player",
+	})
+	assert.ErrorIs(suite.T(), err, ErrForeignKeyViolation)
+}
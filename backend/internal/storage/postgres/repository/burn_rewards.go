@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// BurnRewardRepository defines the interface for BURN reward table data access
+type BurnRewardRepository interface {
+	// List retrieves every configured BURN reward, ordered by league and position
+	List(ctx context.Context) ([]*models.BurnReward, error)
+}
+
+// burnRewardRepository implements BurnRewardRepository
+type burnRewardRepository struct {
+	db *timeoutDB
+}
+
+// NewBurnRewardRepository creates a new BURN reward repository
+func NewBurnRewardRepository(db *sqlx.DB, opts ...Option) BurnRewardRepository {
+	return &burnRewardRepository{db: newTimeoutDB(db, opts...)}
+}
+
+// List retrieves every configured BURN reward, ordered by league and position
+func (r *burnRewardRepository) List(ctx context.Context) ([]*models.BurnReward, error) {
+	rewards := []*models.BurnReward{}
+	query := `
+		SELECT league, position, amount, updated_at
+		FROM burn_rewards
+		ORDER BY league, position`
+
+	err := r.db.SelectContext(ctx, &rewards, query)
+	return rewards, err
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+type BurnRewardRepositoryIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper       *TestDBHelper
+	burnRepo       BurnRewardRepository
+	settlementRepo MatchSettlementRepository
+	matchRepo      MatchRepository
+}
+
+func TestBurnRewardRepositoryIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(BurnRewardRepositoryIntegrationTestSuite))
+}
+
+func (suite *BurnRewardRepositoryIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	suite.burnRepo = NewBurnRewardRepository(suite.dbHelper.DB)
+	suite.settlementRepo = NewMatchSettlementRepository(suite.dbHelper.DB)
+	suite.matchRepo = NewMatchRepository(suite.dbHelper.DB)
+}
+
+func (suite *BurnRewardRepositoryIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *BurnRewardRepositoryIntegrationTestSuite) SetupTest() {
+	suite.dbHelper.CleanupTables("match_settlements", "matches")
+}
+
+func (suite *BurnRewardRepositoryIntegrationTestSuite) TestList_ReturnsSeededTables() {
+	rewards, err := suite.burnRepo.List(context.Background())
+	require.NoError(suite.T(), err)
+
+	byLeague := make(map[models.League]map[int]decimal.Decimal)
+	for _, reward := range rewards {
+		if byLeague[reward.League] == nil {
+			byLeague[reward.League] = make(map[int]decimal.Decimal)
+		}
+		byLeague[reward.League][reward.Position] = reward.Amount
+	}
+
+	assert.Empty(suite.T(), byLeague[models.LeagueRookie])
+	assert.Len(suite.T(), byLeague[models.LeagueStreet], 5)
+	assert.Len(suite.T(), byLeague[models.LeaguePro], 7)
+	assert.Len(suite.T(), byLeague[models.LeagueTopFuel], 10)
+	assert.True(suite.T(), byLeague[models.LeagueStreet][1].Equal(decimal.NewFromInt(50)))
+	assert.True(suite.T(), byLeague[models.LeagueTopFuel][10].Equal(decimal.NewFromInt(100)))
+}
+
+func (suite *BurnRewardRepositoryIntegrationTestSuite) TestSettlementRecordsBurnTable() {
+	ctx := context.Background()
+	match := &models.Match{
+		ID:               uuid.New(),
+		League:           models.LeagueStreet,
+		Status:           models.MatchStatusCompleted,
+		LivePlayerCount:  10,
+		GhostPlayerCount: 0,
+		PrizePool:        decimal.NewFromInt(92),
+		RakeAmount:       decimal.NewFromInt(8),
+		CrashSeed:        "test-crash-seed",
+		CrashSeedHash:    "test-crash-seed-hash",
+		CreatedAt:        time.Now().UTC(),
+	}
+	require.NoError(suite.T(), suite.matchRepo.Create(ctx, match))
+
+	settlement := &models.MatchSettlement{MatchID: match.ID, SettledAt: time.Now().UTC()}
+	require.NoError(suite.T(), settlement.SetBurnRewardTable(map[int]decimal.Decimal{
+		1: decimal.NewFromInt(75),
+		2: decimal.RequireFromString("12.50"),
+	}))
+	require.NoError(suite.T(), suite.settlementRepo.Create(ctx, settlement))
+
+	stored, err := suite.settlementRepo.GetByMatchID(ctx, match.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), stored)
+	table, err := stored.GetBurnRewardTable()
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), table, 2)
+	assert.True(suite.T(), table[1].Equal(decimal.NewFromInt(75)))
+	assert.True(suite.T(), table[2].Equal(decimal.RequireFromString("12.50")))
+
+	// A match settles once
+	err = suite.settlementRepo.Create(ctx, &models.MatchSettlement{MatchID: match.ID, SettledAt: time.Now().UTC()})
+	assert.ErrorIs(suite.T(), err, ErrDuplicate)
+}
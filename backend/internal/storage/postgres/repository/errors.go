@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// PostgreSQL error codes for constraint violations
+const (
+	pgCodeForeignKeyViolation = "23503"
+	pgCodeUniqueViolation     = "23505"
+	pgCodeCheckViolation      = "23514"
+)
+
+// Repository errors returned when a write violates a database constraint
+var (
+	ErrNegativeBalance     = errors.New("balance cannot be negative")
+	ErrRookieRaceLimit     = errors.New("rookie race limit reached")
+	ErrDuplicate           = errors.New("record already exists")
+	ErrForeignKeyViolation = errors.New("referenced record does not exist")
+)
+
+// ErrWalletNotFound is returned by wallet writes that target a user without a wallet
+var ErrWalletNotFound = errors.New("wallet not found")
+
+// ErrMatchStatusChanged is returned by match writes that found the match in a status they do not apply to
+var ErrMatchStatusChanged = errors.New("match status changed")
+
+// ErrUnsupportedCurrency is returned for currencies other than TON, FUEL and BURN
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// mapConstraintError translates PostgreSQL constraint violations into repository errors.
+// The original driver error stays in the chain so its details are still logged.
+func mapConstraintError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code {
+	case pgCodeCheckViolation:
+		switch pqErr.Constraint {
+		case "wallets_ton_balance_check", "wallets_fuel_balance_check", "wallets_burn_balance_check":
+			return fmt.Errorf("%w: %w", ErrNegativeBalance, err)
+		case "wallets_rookie_races_completed_check":
+			return fmt.Errorf("%w: %w", ErrRookieRaceLimit, err)
+		}
+	case pgCodeUniqueViolation:
+		return fmt.Errorf("%w: %w", ErrDuplicate, err)
+	case pgCodeForeignKeyViolation:
+		return fmt.Errorf("%w: %w", ErrForeignKeyViolation, err)
+	}
+
+	return err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapConstraintError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{
+			name:     "negative fuel balance",
+			err:      &pq.Error{Code: pgCodeCheckViolation, Constraint: "wallets_fuel_balance_check"},
+			expected: ErrNegativeBalance,
+		},
+		{
+			name:     "negative ton balance",
+			err:      &pq.Error{Code: pgCodeCheckViolation, Constraint: "wallets_ton_balance_check"},
+			expected: ErrNegativeBalance,
+		},
+		{
+			name:     "rookie race limit",
+			err:      &pq.Error{Code: pgCodeCheckViolation, Constraint: "wallets_rookie_races_completed_check"},
+			expected: ErrRookieRaceLimit,
+		},
+		{
+			name:     "unique violation",
+			err:      &pq.Error{Code: pgCodeUniqueViolation, Constraint: "wallets_pkey"},
+			expected: ErrDuplicate,
+		},
+		{
+			name:     "foreign key violation",
+			err:      &pq.Error{Code: pgCodeForeignKeyViolation, Constraint: "wallets_user_id_fkey"},
+			expected: ErrForeignKeyViolation,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := mapConstraintError(tc.err)
+
+			assert.ErrorIs(t, err, tc.expected)
+
+			var pqErr *pq.Error
+			assert.True(t, errors.As(err, &pqErr), "driver error should remain in the chain")
+		})
+	}
+}
+
+func TestMapConstraintError_Unmapped(t *testing.T) {
+	plainErr := errors.New("connection reset")
+	assert.Equal(t, plainErr, mapConstraintError(plainErr))
+
+	otherCheck := &pq.Error{Code: pgCodeCheckViolation, Constraint: "matches_status_check"}
+	err := mapConstraintError(otherCheck)
+	assert.Equal(t, error(otherCheck), err)
+	assert.NotErrorIs(t, err, ErrNegativeBalance)
+}
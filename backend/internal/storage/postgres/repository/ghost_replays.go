@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// GhostReplayRepository defines the interface for ghost replay data access
+type GhostReplayRepository interface {
+	// Create creates a new ghost replay
+	Create(ctx context.Context, replay *models.GhostReplay) error
+
+	// GetByID retrieves a ghost replay by ID
+	GetByID(ctx context.Context, replayID uuid.UUID) (*models.GhostReplay, error)
+}
+
+// ghostReplayRepository implements GhostReplayRepository
+type ghostReplayRepository struct {
+	db *timeoutDB
+}
+
+// NewGhostReplayRepository creates a new ghost replay repository
+func NewGhostReplayRepository(db *sqlx.DB, opts ...Option) GhostReplayRepository {
+	return &ghostReplayRepository{db: newTimeoutDB(db, opts...)}
+}
+
+// Create creates a new ghost replay
+func (r *ghostReplayRepository) Create(ctx context.Context, replay *models.GhostReplay) error {
+	query := `
+		INSERT INTO ghost_replays (id, source_match_id, source_user_id, league, display_name,
+		                          heat1_score, heat2_score, heat3_score, total_score,
+		                          behavioral_data, created_at)
+		VALUES (:id, :source_match_id, :source_user_id, :league, :display_name,
+		        :heat1_score, :heat2_score, :heat3_score, :total_score,
+		        :behavioral_data, :created_at)`
+
+	_, err := r.db.NamedExecContext(ctx, query, replay)
+	return err
+}
+
+// GetByID retrieves a ghost replay by ID
+func (r *ghostReplayRepository) GetByID(ctx context.Context, replayID uuid.UUID) (*models.GhostReplay, error) {
+	replay := &models.GhostReplay{}
+	query := `
+		SELECT id, source_match_id, source_user_id, league, display_name,
+		       heat1_score, heat2_score, heat3_score, total_score,
+		       behavioral_data, created_at
+		FROM ghost_replays
+		WHERE id = $1`
+
+	err := r.db.reader(ctx).GetContext(ctx, replay, query, replayID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return replay, nil
+}
@@ -3,9 +3,15 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 
 	"github.com/megaherz/ndr/internal/constants"
@@ -20,70 +26,414 @@ type LedgerRepository interface {
 	// CreateEntries creates multiple ledger entries in a transaction
 	CreateEntries(ctx context.Context, entries []*models.LedgerEntry) error
 
+	// CreateTransfer records a transfer between two users' wallets in one transaction: the
+	// sender's debit entry, the receiver's credit entry and both wallet balance updates.
+	// It returns ErrNegativeBalance, changing nothing, if the sender cannot cover the debit.
+	CreateTransfer(ctx context.Context, debit, credit *models.LedgerEntry) error
+
+	// CreateEntriesWithBalances records entries and applies each user entry to its wallet in one
+	// transaction, returning every touched wallet as the update left it, keyed by user ID.
+	// It returns ErrWalletNotFound or ErrNegativeBalance, changing nothing, if a user has no
+	// wallet or cannot cover a debit.
+	CreateEntriesWithBalances(ctx context.Context, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error)
+
+	// CreateEntryWithAudit records a user entry and its wallet update like CreateEntriesWithBalances
+	// and writes audit in the same transaction, so the entry never lands without its audit record.
+	// details builds the audit record's details from the wallet as the update left it.
+	CreateEntryWithAudit(ctx context.Context, entry *models.LedgerEntry, audit *models.AdminAuditEntry, details func(*models.Wallet) (json.RawMessage, error)) (*models.Wallet, error)
+
+	// CreateMatchEntriesWithStatus records a match's entries like CreateEntriesWithBalances and
+	// moves the match to change.To in the same transaction. The match row is locked first, so
+	// concurrent calls for one match run one after the other. It returns ErrMatchStatusChanged,
+	// changing nothing, if the match is no longer in one of the change.From statuses.
+	CreateMatchEntriesWithStatus(ctx context.Context, change MatchStatusChange, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error)
+
 	// GetUserEntries retrieves ledger entries for a user with pagination
 	GetUserEntries(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.LedgerEntry, error)
 
 	// GetMatchEntries retrieves all ledger entries for a match
 	GetMatchEntries(ctx context.Context, matchID uuid.UUID) ([]*models.LedgerEntry, error)
 
-	// GetUserBalance calculates current balance for a user and currency
+	// GetUserBalance returns the current balance for a user and currency.
+	// It returns ErrUnsupportedCurrency for currencies other than TON, FUEL and BURN.
 	GetUserBalance(ctx context.Context, userID uuid.UUID, currency string) (decimal.Decimal, error)
 
-	// GetSystemWalletBalance calculates current balance for a system wallet
+	// GetSystemWalletBalance returns the current FUEL balance for a system wallet
 	GetSystemWalletBalance(ctx context.Context, walletName string) (decimal.Decimal, error)
 
 	// ValidateMatchLedgerBalance validates that all entries for a match sum to zero
 	ValidateMatchLedgerBalance(ctx context.Context, matchID uuid.UUID) (bool, error)
+
+	// StreamEntries calls fn for every entry created in [from, to), oldest first,
+	// reading in batches so the full range is never held in memory
+	StreamEntries(ctx context.Context, from, to time.Time, fn func(*models.LedgerEntry) error) error
 }
 
+// MatchStatusChange moves a match to status To, provided it is still in one of the From statuses
+type MatchStatusChange struct {
+	MatchID uuid.UUID
+	From    []models.MatchStatus
+	To      models.MatchStatus
+}
+
+// ledgerStreamBatchSize is the number of entries StreamEntries reads per query
+const ledgerStreamBatchSize = 1000
+
 // ledgerRepository implements LedgerRepository
 type ledgerRepository struct {
-	db *sqlx.DB
+	db *timeoutDB
 }
 
 // NewLedgerRepository creates a new ledger repository
-func NewLedgerRepository(db *sqlx.DB) LedgerRepository {
-	return &ledgerRepository{db: db}
+func NewLedgerRepository(db *sqlx.DB, opts ...Option) LedgerRepository {
+	return &ledgerRepository{db: newTimeoutDB(db, opts...)}
 }
 
 // CreateEntry creates a new ledger entry
 func (r *ledgerRepository) CreateEntry(ctx context.Context, entry *models.LedgerEntry) error {
-	query := `
-		INSERT INTO ledger_entries (user_id, system_wallet, currency, amount, 
-		                           operation_type, reference_id, description, created_at)
-		VALUES (:user_id, :system_wallet, :currency, :amount, 
-		        :operation_type, :reference_id, :description, :created_at)`
-
-	_, err := r.db.NamedExecContext(ctx, query, entry)
-	return err
+	return r.CreateEntries(ctx, []*models.LedgerEntry{entry})
 }
 
-// CreateEntries creates multiple ledger entries in a transaction
+// CreateEntries creates multiple ledger entries in a transaction.
+// Each entry's BalanceAfter is set to the running balance of its wallet and currency.
 func (r *ledgerRepository) CreateEntries(ctx context.Context, entries []*models.LedgerEntry) error {
 	if len(entries) == 0 {
 		return nil
 	}
 
+	// The whole transaction shares a single query timeout
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := insertEntries(ctx, tx, entries); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// walletBalanceColumns maps each currency to its wallet balance column
+var walletBalanceColumns = map[string]string{
+	constants.CurrencyTON:  "ton_balance",
+	constants.CurrencyFUEL: "fuel_balance",
+	constants.CurrencyBURN: "burn_balance",
+}
+
+// CreateTransfer records a transfer's ledger entries and wallet balance updates in one transaction
+func (r *ledgerRepository) CreateTransfer(ctx context.Context, debit, credit *models.LedgerEntry) error {
+	if debit.UserID == nil || credit.UserID == nil {
+		return fmt.Errorf("transfer entries must belong to users")
+	}
+	if debit.Currency != credit.Currency || !debit.Amount.Neg().Equal(credit.Amount) {
+		return fmt.Errorf("transfer entries must move the same amount of one currency")
+	}
+	column, ok := walletBalanceColumns[string(debit.Currency)]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedCurrency, debit.Currency)
+	}
+
+	// The whole transaction shares a single query timeout
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	// Lock both wallets in a fixed order so opposite transfers cannot deadlock
+	var balances []struct {
+		UserID  uuid.UUID       `db:"user_id"`
+		Balance decimal.Decimal `db:"balance"`
+	}
+	query := fmt.Sprintf(`SELECT user_id, %s AS balance FROM wallets WHERE user_id IN ($1, $2) ORDER BY user_id FOR UPDATE`, column)
+	if err := tx.SelectContext(ctx, &balances, query, *debit.UserID, *credit.UserID); err != nil {
+		return fmt.Errorf("failed to lock wallets: %w", err)
+	}
+
+	senderBalance, senderFound, receiverFound := decimal.Zero, false, false
+	for _, wallet := range balances {
+		if wallet.UserID == *debit.UserID {
+			senderBalance, senderFound = wallet.Balance, true
+		}
+		if wallet.UserID == *credit.UserID {
+			receiverFound = true
+		}
+	}
+	if !senderFound || !receiverFound {
+		return ErrWalletNotFound
+	}
+	if senderBalance.Add(debit.Amount).IsNegative() {
+		return fmt.Errorf("%w: %s balance %s cannot cover %s", ErrNegativeBalance, debit.Currency, senderBalance, debit.Amount.Neg())
+	}
+
+	if err := insertEntries(ctx, tx, []*models.LedgerEntry{debit, credit}); err != nil {
+		return err
+	}
+
+	update := fmt.Sprintf(`UPDATE wallets SET %[1]s = %[1]s + $2, version = version + 1, updated_at = NOW() WHERE user_id = $1`, column)
+	for _, entry := range []*models.LedgerEntry{debit, credit} {
+		if _, err := tx.ExecContext(ctx, update, *entry.UserID, entry.Amount); err != nil {
+			return mapConstraintError(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CreateEntriesWithBalances records ledger entries and their wallet balance updates in one transaction
+func (r *ledgerRepository) CreateEntriesWithBalances(ctx context.Context, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error) {
+	if len(entries) == 0 {
+		return make(map[uuid.UUID]*models.Wallet), nil
+	}
+
+	// The whole transaction shares a single query timeout
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	wallets, err := applyEntriesWithBalances(ctx, tx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// CreateEntryWithAudit records a user ledger entry, its wallet balance update and an admin audit
+// record in one transaction
+func (r *ledgerRepository) CreateEntryWithAudit(ctx context.Context, entry *models.LedgerEntry, audit *models.AdminAuditEntry, details func(*models.Wallet) (json.RawMessage, error)) (*models.Wallet, error) {
+	if entry.UserID == nil {
+		return nil, fmt.Errorf("audited entries must belong to a user")
+	}
+
+	// The whole transaction shares a single query timeout
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	wallets, err := applyEntriesWithBalances(ctx, tx, []*models.LedgerEntry{entry})
+	if err != nil {
+		return nil, err
+	}
+	wallet := wallets[*entry.UserID]
+
+	if audit.Details, err = details(wallet); err != nil {
+		return nil, fmt.Errorf("failed to build audit details: %w", err)
+	}
+	if err := insertAuditEntry(ctx, tx, audit); err != nil {
+		return nil, fmt.Errorf("failed to create audit record: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// CreateMatchEntriesWithStatus records a match's ledger entries and wallet balance updates and
+// changes the match's status in one transaction
+func (r *ledgerRepository) CreateMatchEntriesWithStatus(ctx context.Context, change MatchStatusChange, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error) {
+	// The whole transaction shares a single query timeout
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// The match row is locked before any wallet, so writers of one match queue up here
+	var status models.MatchStatus
+	err = tx.GetContext(ctx, &status, `SELECT status FROM matches WHERE id = $1 FOR UPDATE`, change.MatchID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("match %s not found", change.MatchID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock match: %w", err)
+	}
+	if !slices.Contains(change.From, status) {
+		return nil, fmt.Errorf("%w: match is %s", ErrMatchStatusChanged, status)
+	}
+
+	wallets, err := applyEntriesWithBalances(ctx, tx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE matches
+		SET status = $2::match_status_type,
+		    completed_at = CASE WHEN $2::match_status_type = 'COMPLETED' THEN NOW() ELSE completed_at END
+		WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, change.MatchID, change.To); err != nil {
+		return nil, fmt.Errorf("failed to update match status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
+// applyEntriesWithBalances records entries within tx and applies each user entry to its wallet,
+// returning every touched wallet as the update left it
+func applyEntriesWithBalances(ctx context.Context, tx *sqlx.Tx, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error) {
+	wallets := make(map[uuid.UUID]*models.Wallet)
+	var userIDs []string
+	for _, entry := range entries {
+		if entry.UserID == nil {
+			continue
+		}
+		if _, ok := walletBalanceColumns[string(entry.Currency)]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, entry.Currency)
+		}
+		userIDs = append(userIDs, entry.UserID.String())
+	}
+
+	// Lock the wallets in the same order as CreateTransfer so the two cannot deadlock
+	if len(userIDs) > 0 {
+		var locked []uuid.UUID
+		query := `SELECT DISTINCT user_id FROM wallets WHERE user_id = ANY($1::uuid[]) ORDER BY user_id FOR UPDATE`
+		if err := tx.SelectContext(ctx, &locked, query, pq.Array(userIDs)); err != nil {
+			return nil, fmt.Errorf("failed to lock wallets: %w", err)
+		}
+		found := make(map[uuid.UUID]bool, len(locked))
+		for _, userID := range locked {
+			found[userID] = true
+		}
+		for _, entry := range entries {
+			if entry.UserID != nil && !found[*entry.UserID] {
+				return nil, fmt.Errorf("%w for user %s", ErrWalletNotFound, *entry.UserID)
+			}
+		}
+	}
+
+	if err := insertEntries(ctx, tx, entries); err != nil {
+		return nil, err
+	}
+
+	// A debit past the balance violates the wallet check constraint and rolls everything back
+	for _, entry := range entries {
+		if entry.UserID == nil {
+			continue
+		}
+		wallet := &models.Wallet{}
+		query := fmt.Sprintf(`
+			UPDATE wallets
+			SET %[1]s = %[1]s + $2, version = version + 1, updated_at = NOW()
+			WHERE user_id = $1
+			RETURNING user_id, ton_balance, fuel_balance, burn_balance,
+			          rookie_races_completed, ton_wallet_address, version, created_at, updated_at`, walletBalanceColumns[string(entry.Currency)])
+		if err := tx.GetContext(ctx, wallet, query, *entry.UserID, entry.Amount); err != nil {
+			return nil, mapConstraintError(err)
+		}
+		wallets[*entry.UserID] = wallet
+	}
+
+	return wallets, nil
+}
+
+// insertEntries inserts ledger entries within tx, setting each entry's BalanceAfter
+func insertEntries(ctx context.Context, tx *sqlx.Tx, entries []*models.LedgerEntry) error {
+	// Serialize writers per wallet and currency so running balances never interleave.
+	// Locks are taken in sorted order to avoid deadlocks between concurrent batches.
+	for _, key := range ledgerBalanceKeys(entries) {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key); err != nil {
+			return fmt.Errorf("failed to lock ledger balance: %w", err)
+		}
+	}
+
 	query := `
 		INSERT INTO ledger_entries (user_id, system_wallet, currency, amount, 
-		                           operation_type, reference_id, description, created_at)
+		                           operation_type, reference_id, description, balance_after, created_at)
 		VALUES (:user_id, :system_wallet, :currency, :amount, 
-		        :operation_type, :reference_id, :description, :created_at)`
+		        :operation_type, :reference_id, :description, :balance_after, :created_at)`
 
 	for _, entry := range entries {
-		_, err := tx.NamedExecContext(ctx, query, entry)
+		previous, err := latestBalance(ctx, tx, entry)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to get previous balance: %w", err)
+		}
+		entry.BalanceAfter = previous.Add(entry.Amount)
+
+		_, err = tx.NamedExecContext(ctx, query, entry)
+		if err != nil {
+			return mapConstraintError(err)
 		}
 	}
 
-	return tx.Commit()
+	return nil
+}
+
+// ledgerBalanceKeys returns the sorted, unique wallet and currency keys touched by entries
+func ledgerBalanceKeys(entries []*models.LedgerEntry) []string {
+	seen := make(map[string]struct{}, len(entries))
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		var key string
+		switch {
+		case entry.UserID != nil:
+			key = "ledger:user:" + entry.UserID.String() + ":" + string(entry.Currency)
+		case entry.SystemWallet != nil:
+			key = "ledger:system:" + *entry.SystemWallet + ":" + string(entry.Currency)
+		default:
+			continue // Rejected by the owner check constraint on insert
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// latestBalance returns the balance_after of the newest entry for the entry's wallet and currency
+func latestBalance(ctx context.Context, tx *sqlx.Tx, entry *models.LedgerEntry) (decimal.Decimal, error) {
+	var (
+		query string
+		owner interface{}
+	)
+	switch {
+	case entry.UserID != nil:
+		query = `SELECT balance_after FROM ledger_entries WHERE user_id = $1 AND currency = $2 ORDER BY id DESC LIMIT 1`
+		owner = *entry.UserID
+	case entry.SystemWallet != nil:
+		query = `SELECT balance_after FROM ledger_entries WHERE system_wallet = $1 AND currency = $2 ORDER BY id DESC LIMIT 1`
+		owner = *entry.SystemWallet
+	default:
+		return decimal.Zero, nil
+	}
+
+	var balance decimal.Decimal
+	err := tx.GetContext(ctx, &balance, query, owner, entry.Currency)
+	if err == sql.ErrNoRows {
+		return decimal.Zero, nil
+	}
+	return balance, err
 }
 
 // GetUserEntries retrieves ledger entries for a user with pagination
@@ -91,13 +441,13 @@ func (r *ledgerRepository) GetUserEntries(ctx context.Context, userID uuid.UUID,
 	entries := []*models.LedgerEntry{}
 	query := `
 		SELECT id, user_id, system_wallet, currency, amount, operation_type, 
-		       reference_id, description, created_at
+		       reference_id, description, balance_after, created_at
 		FROM ledger_entries 
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3`
 
-	err := r.db.SelectContext(ctx, &entries, query, userID, limit, offset)
+	err := r.db.reader(ctx).SelectContext(ctx, &entries, query, userID, limit, offset)
 	return entries, err
 }
 
@@ -106,53 +456,60 @@ func (r *ledgerRepository) GetMatchEntries(ctx context.Context, matchID uuid.UUI
 	entries := []*models.LedgerEntry{}
 	query := `
 		SELECT id, user_id, system_wallet, currency, amount, operation_type, 
-		       reference_id, description, created_at
+		       reference_id, description, balance_after, created_at
 		FROM ledger_entries 
 		WHERE reference_id = $1
 		ORDER BY created_at ASC`
 
-	err := r.db.SelectContext(ctx, &entries, query, matchID)
+	err := r.db.reader(ctx).SelectContext(ctx, &entries, query, matchID)
 	return entries, err
 }
 
-// GetUserBalance calculates current balance for a user and currency
+// GetUserBalance returns the current balance for a user and currency from the latest entry
 func (r *ledgerRepository) GetUserBalance(ctx context.Context, userID uuid.UUID, currency string) (decimal.Decimal, error) {
-	var balance sql.NullString
+	// An unknown currency has no entries, so it would otherwise read as a zero balance
+	if !constants.IsValidCurrency(currency) {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+
+	var balance decimal.Decimal
 	query := `
-		SELECT COALESCE(SUM(amount), 0)::text
+		SELECT balance_after
 		FROM ledger_entries 
-		WHERE user_id = $1 AND currency = $2`
+		WHERE user_id = $1 AND currency = $2
+		ORDER BY id DESC
+		LIMIT 1`
 
 	err := r.db.GetContext(ctx, &balance, query, userID, currency)
+	if err == sql.ErrNoRows {
+		return decimal.Zero, nil
+	}
 	if err != nil {
 		return decimal.Zero, err
 	}
 
-	if !balance.Valid {
-		return decimal.Zero, nil
-	}
-
-	return decimal.NewFromString(balance.String)
+	return balance, nil
 }
 
-// GetSystemWalletBalance calculates current balance for a system wallet
+// GetSystemWalletBalance returns the current FUEL balance for a system wallet from the latest entry
 func (r *ledgerRepository) GetSystemWalletBalance(ctx context.Context, walletName string) (decimal.Decimal, error) {
-	var balance sql.NullString
+	var balance decimal.Decimal
 	query := `
-		SELECT COALESCE(SUM(amount), 0)::text
+		SELECT balance_after
 		FROM ledger_entries 
-		WHERE system_wallet = $1 AND currency = $2`
+		WHERE system_wallet = $1 AND currency = $2
+		ORDER BY id DESC
+		LIMIT 1`
 
 	err := r.db.GetContext(ctx, &balance, query, walletName, constants.CurrencyFUEL)
+	if err == sql.ErrNoRows {
+		return decimal.Zero, nil
+	}
 	if err != nil {
 		return decimal.Zero, err
 	}
 
-	if !balance.Valid {
-		return decimal.Zero, nil
-	}
-
-	return decimal.NewFromString(balance.String)
+	return balance, nil
 }
 
 // ValidateMatchLedgerBalance validates that all entries for a match sum to zero
@@ -179,3 +536,39 @@ func (r *ledgerRepository) ValidateMatchLedgerBalance(ctx context.Context, match
 
 	return balanceDecimal.IsZero(), nil
 }
+
+// StreamEntries calls fn for every entry created in [from, to), oldest first.
+// Entries are read in keyset-paginated batches ordered by (created_at, id), so each
+// query stays short and memory use is bounded by the batch size.
+func (r *ledgerRepository) StreamEntries(ctx context.Context, from, to time.Time, fn func(*models.LedgerEntry) error) error {
+	query := `
+		SELECT id, user_id, system_wallet, currency, amount, operation_type, 
+		       reference_id, description, balance_after, created_at
+		FROM ledger_entries 
+		WHERE created_at >= $1 AND created_at < $2
+		  AND (created_at, id) > ($3, $4)
+		ORDER BY created_at ASC, id ASC
+		LIMIT $5`
+
+	// The cursor starts just before the range so the first batch includes its first entry
+	cursorTime, cursorID := from, int64(0)
+	for {
+		batch := []*models.LedgerEntry{}
+		err := r.db.reader(ctx).SelectContext(ctx, &batch, query, from, to, cursorTime, cursorID, ledgerStreamBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range batch {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < ledgerStreamBatchSize {
+			return nil
+		}
+		last := batch[len(batch)-1]
+		cursorTime, cursorID = last.CreatedAt, last.ID
+	}
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// cachedBalance is a system wallet balance and when it stops being served
+type cachedBalance struct {
+	balance   decimal.Decimal
+	expiresAt time.Time
+}
+
+// cachedLedgerRepository serves system wallet balances from memory between ledger writes
+type cachedLedgerRepository struct {
+	LedgerRepository
+	ttl time.Duration
+	now func() time.Time
+
+	mu       sync.Mutex
+	balances map[string]cachedBalance
+	// generations counts writes per wallet, so a read that raced a write is not cached
+	generations map[string]uint64
+}
+
+// NewCachedLedgerRepository wraps a ledger repository so GetSystemWalletBalance is
+// served from memory for up to ttl. Entries written through the returned repository
+// invalidate the affected wallets immediately; writes made by other processes are
+// picked up once the TTL expires. A non-positive ttl disables caching.
+func NewCachedLedgerRepository(inner LedgerRepository, ttl time.Duration) LedgerRepository {
+	if ttl <= 0 {
+		return inner
+	}
+	return &cachedLedgerRepository{
+		LedgerRepository: inner,
+		ttl:              ttl,
+		now:              time.Now,
+		balances:         make(map[string]cachedBalance),
+		generations:      make(map[string]uint64),
+	}
+}
+
+// GetSystemWalletBalance returns the cached balance, querying the ledger when it is missing or stale
+func (r *cachedLedgerRepository) GetSystemWalletBalance(ctx context.Context, walletName string) (decimal.Decimal, error) {
+	r.mu.Lock()
+	cached, ok := r.balances[walletName]
+	generation := r.generations[walletName]
+	r.mu.Unlock()
+
+	if ok && r.now().Before(cached.expiresAt) {
+		return cached.balance, nil
+	}
+
+	balance, err := r.LedgerRepository.GetSystemWalletBalance(ctx, walletName)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	r.mu.Lock()
+	if r.generations[walletName] == generation {
+		r.balances[walletName] = cachedBalance{balance: balance, expiresAt: r.now().Add(r.ttl)}
+	}
+	r.mu.Unlock()
+
+	return balance, nil
+}
+
+// CreateEntry creates a ledger entry and invalidates the balance of its system wallet
+func (r *cachedLedgerRepository) CreateEntry(ctx context.Context, entry *models.LedgerEntry) error {
+	// Invalidate even on failure: the write may have reached the database
+	defer r.invalidate([]*models.LedgerEntry{entry})
+	return r.LedgerRepository.CreateEntry(ctx, entry)
+}
+
+// CreateEntries creates ledger entries and invalidates the balances of their system wallets
+func (r *cachedLedgerRepository) CreateEntries(ctx context.Context, entries []*models.LedgerEntry) error {
+	defer r.invalidate(entries)
+	return r.LedgerRepository.CreateEntries(ctx, entries)
+}
+
+// CreateEntriesWithBalances creates ledger entries with their wallet updates and invalidates
+// the balances of their system wallets
+func (r *cachedLedgerRepository) CreateEntriesWithBalances(ctx context.Context, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error) {
+	defer r.invalidate(entries)
+	return r.LedgerRepository.CreateEntriesWithBalances(ctx, entries)
+}
+
+// CreateMatchEntriesWithStatus creates a match's ledger entries with their wallet updates and status
+// change and invalidates the balances of their system wallets
+func (r *cachedLedgerRepository) CreateMatchEntriesWithStatus(ctx context.Context, change MatchStatusChange, entries []*models.LedgerEntry) (map[uuid.UUID]*models.Wallet, error) {
+	defer r.invalidate(entries)
+	return r.LedgerRepository.CreateMatchEntriesWithStatus(ctx, change, entries)
+}
+
+// invalidate drops cached balances of every system wallet touched by entries
+func (r *cachedLedgerRepository) invalidate(entries []*models.LedgerEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, entry := range entries {
+		if entry.SystemWallet == nil {
+			continue
+		}
+		delete(r.balances, *entry.SystemWallet)
+		r.generations[*entry.SystemWallet]++
+	}
+}
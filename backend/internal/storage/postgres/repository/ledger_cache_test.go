@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// countingLedgerRepository sums system wallet entries in memory and counts balance queries
+type countingLedgerRepository struct {
+	LedgerRepository
+	entries []*models.LedgerEntry
+	queries int
+}
+
+func (r *countingLedgerRepository) GetSystemWalletBalance(ctx context.Context, walletName string) (decimal.Decimal, error) {
+	r.queries++
+	balance := decimal.Zero
+	for _, entry := range r.entries {
+		if entry.SystemWallet != nil && *entry.SystemWallet == walletName {
+			balance = balance.Add(entry.Amount)
+		}
+	}
+	return balance, nil
+}
+
+func (r *countingLedgerRepository) CreateEntry(ctx context.Context, entry *models.LedgerEntry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *countingLedgerRepository) CreateEntries(ctx context.Context, entries []*models.LedgerEntry) error {
+	r.entries = append(r.entries, entries...)
+	return nil
+}
+
+func newCachedTestLedger(ttl time.Duration) (*cachedLedgerRepository, *countingLedgerRepository, *time.Time) {
+	inner := &countingLedgerRepository{}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cached := NewCachedLedgerRepository(inner, ttl).(*cachedLedgerRepository)
+	cached.now = func() time.Time { return now }
+	return cached, inner, &now
+}
+
+func systemEntry(wallet, amount string) *models.LedgerEntry {
+	return &models.LedgerEntry{
+		SystemWallet:  &wallet,
+		Currency:      constants.CurrencyFUEL,
+		Amount:        decimal.RequireFromString(amount),
+		OperationType: constants.OperationMatchRake,
+	}
+}
+
+func TestCachedLedger_RepeatedReadsHitCache(t *testing.T) {
+	cached, inner, now := newCachedTestLedger(time.Minute)
+	ctx := context.Background()
+	inner.entries = []*models.LedgerEntry{systemEntry(constants.SystemWalletRakeFuel, "8")}
+
+	for i := 0; i < 3; i++ {
+		balance, err := cached.GetSystemWalletBalance(ctx, constants.SystemWalletRakeFuel)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(8).Equal(balance))
+	}
+	assert.Equal(t, 1, inner.queries)
+
+	// The cached value expires after the TTL
+	*now = now.Add(time.Minute)
+	_, err := cached.GetSystemWalletBalance(ctx, constants.SystemWalletRakeFuel)
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.queries)
+}
+
+func TestCachedLedger_WriteInvalidatesWallet(t *testing.T) {
+	cached, inner, _ := newCachedTestLedger(time.Minute)
+	ctx := context.Background()
+
+	_, err := cached.GetSystemWalletBalance(ctx, constants.SystemWalletRakeFuel)
+	require.NoError(t, err)
+	_, err = cached.GetSystemWalletBalance(ctx, constants.SystemWalletHouseFuel)
+	require.NoError(t, err)
+
+	require.NoError(t, cached.CreateEntries(ctx, []*models.LedgerEntry{systemEntry(constants.SystemWalletRakeFuel, "0.80")}))
+
+	balance, err := cached.GetSystemWalletBalance(ctx, constants.SystemWalletRakeFuel)
+	require.NoError(t, err)
+	assert.True(t, decimal.RequireFromString("0.80").Equal(balance))
+	assert.Equal(t, 3, inner.queries, "the rake wallet is re-read after the write")
+
+	// Other wallets stay cached
+	_, err = cached.GetSystemWalletBalance(ctx, constants.SystemWalletHouseFuel)
+	require.NoError(t, err)
+	assert.Equal(t, 3, inner.queries)
+}
+
+func TestNewCachedLedgerRepository_ZeroTTLDisablesCache(t *testing.T) {
+	inner := &countingLedgerRepository{}
+
+	assert.Same(t, LedgerRepository(inner), NewCachedLedgerRepository(inner, 0))
+}
@@ -0,0 +1,364 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/constants"
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+type LedgerRepositoryIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper   *TestDBHelper
+	ledgerRepo LedgerRepository
+	userRepo   UserRepository
+	testUserID uuid.UUID
+}
+
+func TestLedgerRepositoryIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(LedgerRepositoryIntegrationTestSuite))
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	suite.ledgerRepo = NewLedgerRepository(suite.dbHelper.DB)
+	suite.userRepo = NewUserRepository(suite.dbHelper.DB)
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) SetupTest() {
+	suite.dbHelper.CleanupTables("admin_audit_log", "ledger_entries", "wallets", "users")
+
+	suite.testUserID = uuid.New()
+	testUser := &models.User{
+		ID:                suite.testUserID,
+		TelegramID:        987654321,
+		TelegramFirstName: "Ledger",
+		CreatedAt:         time.Now().UTC(),
+		UpdatedAt:         time.Now().UTC(),
+	}
+
+	err := suite.userRepo.Create(context.Background(), testUser)
+	require.NoError(suite.T(), err)
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) userEntry(currency models.Currency, amount string, op models.OperationType) *models.LedgerEntry {
+	userID := suite.testUserID
+	return &models.LedgerEntry{
+		UserID:        &userID,
+		Currency:      currency,
+		Amount:        decimal.RequireFromString(amount),
+		OperationType: op,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+func systemLedgerEntry(wallet, amount string, op models.OperationType) *models.LedgerEntry {
+	return &models.LedgerEntry{
+		SystemWallet:  &wallet,
+		Currency:      models.CurrencyFUEL,
+		Amount:        decimal.RequireFromString(amount),
+		OperationType: op,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) TestBalanceAfter_TracksDebitsAndCredits() {
+	ctx := context.Background()
+
+	steps := []struct {
+		amount   string
+		op       models.OperationType
+		expected string
+	}{
+		{"100.00", models.OperationInitialBalance, "100.00"},
+		{"-10.00", models.OperationMatchBuyin, "90.00"},
+		{"25.50", models.OperationMatchPrize, "115.50"},
+		{"-115.50", models.OperationWithdrawal, "0.00"},
+		{"-5.25", models.OperationMatchBuyin, "-5.25"},
+	}
+
+	for _, step := range steps {
+		entry := suite.userEntry(models.CurrencyFUEL, step.amount, step.op)
+		require.NoError(suite.T(), suite.ledgerRepo.CreateEntry(ctx, entry))
+		assert.True(suite.T(), decimal.RequireFromString(step.expected).Equal(entry.BalanceAfter),
+			"expected %s after %s, got %s", step.expected, step.amount, entry.BalanceAfter)
+	}
+
+	balance, err := suite.ledgerRepo.GetUserBalance(ctx, suite.testUserID, constants.CurrencyFUEL)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), decimal.RequireFromString("-5.25").Equal(balance))
+
+	// Stored history carries the running total
+	entries, err := suite.ledgerRepo.GetUserEntries(ctx, suite.testUserID, 10, 0)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), entries, len(steps))
+	for i, step := range steps {
+		stored := entries[len(entries)-1-i] // Newest first
+		assert.True(suite.T(), decimal.RequireFromString(step.expected).Equal(stored.BalanceAfter))
+	}
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) TestBalanceAfter_SeparatesCurrenciesAndWallets() {
+	ctx := context.Background()
+
+	entries := []*models.LedgerEntry{
+		suite.userEntry(models.CurrencyFUEL, "50.00", models.OperationInitialBalance),
+		suite.userEntry(models.CurrencyTON, "3.00", models.OperationDeposit),
+		suite.userEntry(models.CurrencyFUEL, "-20.00", models.OperationMatchBuyin),
+		systemLedgerEntry(constants.SystemWalletHouseFuel, "20.00", models.OperationMatchBuyin),
+		systemLedgerEntry(constants.SystemWalletHouseFuel, "-7.50", models.OperationMatchPrize),
+		suite.userEntry(models.CurrencyFUEL, "7.50", models.OperationMatchPrize),
+	}
+	require.NoError(suite.T(), suite.ledgerRepo.CreateEntries(ctx, entries))
+
+	expected := []string{"50.00", "3.00", "30.00", "20.00", "12.50", "37.50"}
+	for i, entry := range entries {
+		assert.True(suite.T(), decimal.RequireFromString(expected[i]).Equal(entry.BalanceAfter),
+			"entry %d: expected %s, got %s", i, expected[i], entry.BalanceAfter)
+	}
+
+	fuel, err := suite.ledgerRepo.GetUserBalance(ctx, suite.testUserID, constants.CurrencyFUEL)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), decimal.RequireFromString("37.50").Equal(fuel))
+
+	ton, err := suite.ledgerRepo.GetUserBalance(ctx, suite.testUserID, constants.CurrencyTON)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), decimal.RequireFromString("3.00").Equal(ton))
+
+	house, err := suite.ledgerRepo.GetSystemWalletBalance(ctx, constants.SystemWalletHouseFuel)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), decimal.RequireFromString("12.50").Equal(house))
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) TestBalanceAfter_ConcurrentWrites() {
+	ctx := context.Background()
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- suite.ledgerRepo.CreateEntry(ctx, suite.userEntry(models.CurrencyFUEL, "1.00", models.OperationDeposit))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(suite.T(), err)
+	}
+
+	balance, err := suite.ledgerRepo.GetUserBalance(ctx, suite.testUserID, constants.CurrencyFUEL)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), decimal.NewFromInt(writers).Equal(balance))
+
+	// Every running balance from 1 to writers appears exactly once
+	var balances []decimal.Decimal
+	err = suite.dbHelper.DB.Select(&balances, "SELECT balance_after FROM ledger_entries WHERE user_id = $1 ORDER BY id", suite.testUserID)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), balances, writers)
+	for i, b := range balances {
+		assert.True(suite.T(), decimal.NewFromInt(int64(i+1)).Equal(b))
+	}
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) TestGetUserBalance_NoEntries() {
+	balance, err := suite.ledgerRepo.GetUserBalance(context.Background(), uuid.New(), constants.CurrencyFUEL)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), balance.IsZero())
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) TestCreateEntry_OneSignupGrantPerUser() {
+	ctx := context.Background()
+
+	require.NoError(suite.T(), suite.ledgerRepo.CreateEntry(ctx, suite.userEntry(models.CurrencyFUEL, "100.00", models.OperationSignupGrant)))
+
+	err := suite.ledgerRepo.CreateEntry(ctx, suite.userEntry(models.CurrencyFUEL, "100.00", models.OperationSignupGrant))
+	assert.ErrorIs(suite.T(), err, ErrDuplicate)
+
+	balance, err := suite.ledgerRepo.GetUserBalance(ctx, suite.testUserID, constants.CurrencyFUEL)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "100.00", balance.StringFixed(2))
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) TestCreateEntriesWithBalances_RollsBackOverdraft() {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	walletRepo := NewWalletRepository(suite.dbHelper.DB)
+	require.NoError(suite.T(), walletRepo.Create(ctx, &models.Wallet{
+		UserID:      suite.testUserID,
+		FuelBalance: decimal.NewFromInt(10),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}))
+
+	// A covered debit is recorded with the balance its update left
+	wallets, err := suite.ledgerRepo.CreateEntriesWithBalances(ctx, []*models.LedgerEntry{
+		systemLedgerEntry(constants.SystemWalletHouseFuel, "4.00", models.OperationMatchBuyin),
+		suite.userEntry(models.CurrencyFUEL, "-4.00", models.OperationMatchBuyin),
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "6.00", wallets[suite.testUserID].FuelBalance.StringFixed(2))
+	assert.Equal(suite.T(), int64(1), wallets[suite.testUserID].Version)
+
+	// An overdraft leaves neither the ledger nor the wallet changed
+	_, err = suite.ledgerRepo.CreateEntriesWithBalances(ctx, []*models.LedgerEntry{
+		systemLedgerEntry(constants.SystemWalletHouseFuel, "10.00", models.OperationMatchBuyin),
+		suite.userEntry(models.CurrencyFUEL, "-10.00", models.OperationMatchBuyin),
+	})
+	assert.ErrorIs(suite.T(), err, ErrNegativeBalance)
+
+	entries, err := suite.ledgerRepo.GetUserEntries(ctx, suite.testUserID, 10, 0)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+	houseBalance, err := suite.ledgerRepo.GetSystemWalletBalance(ctx, constants.SystemWalletHouseFuel)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "4.00", houseBalance.StringFixed(2))
+	wallet, err := walletRepo.GetByUserID(ctx, suite.testUserID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "6.00", wallet.FuelBalance.StringFixed(2))
+	assert.Equal(suite.T(), int64(1), wallet.Version, "the rolled back update must not bump the version")
+
+	// A user without a wallet is rejected before anything is written
+	otherUserID := uuid.New()
+	entry := suite.userEntry(models.CurrencyFUEL, "5.00", models.OperationMatchPrize)
+	entry.UserID = &otherUserID
+	_, err = suite.ledgerRepo.CreateEntriesWithBalances(ctx, []*models.LedgerEntry{entry})
+	assert.ErrorIs(suite.T(), err, ErrWalletNotFound)
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) TestCreateEntryWithAudit_CommitsTogether() {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	walletRepo := NewWalletRepository(suite.dbHelper.DB)
+	auditRepo := NewAdminAuditRepository(suite.dbHelper.DB)
+	require.NoError(suite.T(), walletRepo.Create(ctx, &models.Wallet{
+		UserID:      suite.testUserID,
+		FuelBalance: decimal.NewFromInt(10),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}))
+	balanceDetails := func(wallet *models.Wallet) (json.RawMessage, error) {
+		return json.Marshal(map[string]string{"balance_after": wallet.FuelBalance.StringFixed(2)})
+	}
+
+	// The entry, the wallet update and the audit record land together
+	audit := &models.AdminAuditEntry{
+		AdminID:      uuid.New(),
+		Action:       models.AdminActionAdjustBalance,
+		TargetUserID: suite.testUserID,
+		Reason:       "compensation",
+	}
+	wallet, err := suite.ledgerRepo.CreateEntryWithAudit(ctx, suite.userEntry(models.CurrencyFUEL, "5.00", models.OperationAdminAdjustment), audit, balanceDetails)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "15.00", wallet.FuelBalance.StringFixed(2))
+	assert.NotZero(suite.T(), audit.ID)
+
+	audits, err := auditRepo.GetByTargetUser(ctx, suite.testUserID, 10)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), audits, 1)
+	assert.JSONEq(suite.T(), `{"balance_after":"15.00"}`, string(audits[0].Details))
+
+	// A failed audit write rolls back the entry and the wallet update
+	failing := &models.AdminAuditEntry{
+		AdminID:      uuid.New(),
+		Action:       models.AdminActionAdjustBalance,
+		TargetUserID: uuid.New(),
+		Reason:       "compensation",
+	}
+	_, err = suite.ledgerRepo.CreateEntryWithAudit(ctx, suite.userEntry(models.CurrencyFUEL, "5.00", models.OperationAdminAdjustment), failing, balanceDetails)
+	assert.Error(suite.T(), err)
+
+	entries, err := suite.ledgerRepo.GetUserEntries(ctx, suite.testUserID, 10, 0)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+	wallet, err = walletRepo.GetByUserID(ctx, suite.testUserID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "15.00", wallet.FuelBalance.StringFixed(2))
+}
+
+func (suite *LedgerRepositoryIntegrationTestSuite) TestCreateMatchEntriesWithStatus_OneWriterWins() {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	suite.dbHelper.CleanupTables("matches")
+	require.NoError(suite.T(), NewWalletRepository(suite.dbHelper.DB).Create(ctx, &models.Wallet{
+		UserID:    suite.testUserID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}))
+	match := &models.Match{
+		ID:            uuid.New(),
+		League:        models.LeagueStreet,
+		Status:        models.MatchStatusInProgress,
+		PrizePool:     decimal.NewFromInt(92),
+		RakeAmount:    decimal.NewFromInt(8),
+		CrashSeed:     "test-crash-seed",
+		CrashSeedHash: "test-crash-seed-hash",
+		CreatedAt:     now,
+	}
+	matchRepo := NewMatchRepository(suite.dbHelper.DB)
+	require.NoError(suite.T(), matchRepo.Create(ctx, match))
+
+	completion := MatchStatusChange{
+		MatchID: match.ID,
+		From:    []models.MatchStatus{models.MatchStatusForming, models.MatchStatusInProgress},
+		To:      models.MatchStatusCompleted,
+	}
+	prize := func() []*models.LedgerEntry {
+		house := systemLedgerEntry(constants.SystemWalletHouseFuel, "-46.00", models.OperationMatchPrize)
+		winner := suite.userEntry(models.CurrencyFUEL, "46.00", models.OperationMatchPrize)
+		house.ReferenceID, winner.ReferenceID = &match.ID, &match.ID
+		return []*models.LedgerEntry{house, winner}
+	}
+
+	// Concurrent settlements of one match queue on its row lock; only the first pays out
+	const attempts = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := suite.ledgerRepo.CreateMatchEntriesWithStatus(ctx, completion, prize())
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(suite.T(), err, ErrMatchStatusChanged)
+	}
+	assert.Equal(suite.T(), 1, succeeded)
+
+	entries, err := suite.ledgerRepo.GetMatchEntries(ctx, match.ID)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 2)
+
+	stored, err := matchRepo.GetByID(ctx, match.ID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), models.MatchStatusCompleted, stored.Status)
+	assert.NotNil(suite.T(), stored.CompletedAt)
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// cachedMatch is a match row and when it stops being served
+type cachedMatch struct {
+	match     models.Match
+	expiresAt time.Time
+}
+
+// cachedMatchRepository serves match rows from memory between match writes
+type cachedMatchRepository struct {
+	MatchRepository
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	matches map[uuid.UUID]cachedMatch
+	// writes counts match writes, so a read that raced a write is not cached
+	writes uint64
+	// nextSweep is when expired rows are next dropped from matches
+	nextSweep time.Time
+}
+
+// NewCachedMatchRepository wraps a match repository so GetByID is served from memory
+// for up to ttl, sparing the repeated lookups a match gets during its lifecycle.
+// Writes made through the returned repository invalidate the match immediately;
+// writes made by other processes are picked up once the TTL expires, and reads that
+// ask for the primary with WithPrimaryReads always query it. Completed and aborted
+// matches are not kept, and expired rows are dropped, so the cache only holds matches
+// that are still being played. A non-positive ttl disables caching.
+func NewCachedMatchRepository(inner MatchRepository, ttl time.Duration) MatchRepository {
+	if ttl <= 0 {
+		return inner
+	}
+	return &cachedMatchRepository{
+		MatchRepository: inner,
+		ttl:             ttl,
+		now:             time.Now,
+		matches:         make(map[uuid.UUID]cachedMatch),
+	}
+}
+
+// GetByID returns a copy of the cached match, querying the database when it is missing or stale
+// or when ctx asks for primary reads. Missing and finished matches are not cached.
+func (r *cachedMatchRepository) GetByID(ctx context.Context, matchID uuid.UUID) (*models.Match, error) {
+	// Status guards read with WithPrimaryReads and must not act on a row another instance changed
+	if primaryReads(ctx) {
+		return r.MatchRepository.GetByID(ctx, matchID)
+	}
+
+	r.mu.Lock()
+	cached, ok := r.matches[matchID]
+	writes := r.writes
+	r.mu.Unlock()
+
+	if ok && r.now().Before(cached.expiresAt) {
+		match := cached.match
+		return &match, nil
+	}
+
+	match, err := r.MatchRepository.GetByID(ctx, matchID)
+	if err != nil || match == nil {
+		return match, err
+	}
+
+	r.mu.Lock()
+	now := r.now()
+	if r.writes == writes && !finishedMatch(match.Status) {
+		r.matches[matchID] = cachedMatch{match: *match, expiresAt: now.Add(r.ttl)}
+	}
+	r.sweep(now)
+	r.mu.Unlock()
+
+	return match, nil
+}
+
+// finishedMatch reports whether a match status is final
+func finishedMatch(status models.MatchStatus) bool {
+	return status == models.MatchStatusCompleted || status == models.MatchStatusAborted
+}
+
+// sweep drops expired rows, at most once per TTL. Callers must hold r.mu.
+func (r *cachedMatchRepository) sweep(now time.Time) {
+	if now.Before(r.nextSweep) {
+		return
+	}
+	for matchID, cached := range r.matches {
+		if !now.Before(cached.expiresAt) {
+			delete(r.matches, matchID)
+		}
+	}
+	r.nextSweep = now.Add(r.ttl)
+}
+
+// Create creates a match and invalidates any cached row with its ID
+func (r *cachedMatchRepository) Create(ctx context.Context, match *models.Match) error {
+	// Invalidate even on failure: the write may have reached the database
+	defer r.invalidate(match.ID)
+	return r.MatchRepository.Create(ctx, match)
+}
+
+// CreateWithParticipants creates a match with its participants and invalidates any cached row with its ID
+func (r *cachedMatchRepository) CreateWithParticipants(ctx context.Context, match *models.Match, participants []*models.MatchParticipant) error {
+	defer r.invalidate(match.ID)
+	return r.MatchRepository.CreateWithParticipants(ctx, match, participants)
+}
+
+// UpdateStatus updates the match status and invalidates the cached match
+func (r *cachedMatchRepository) UpdateStatus(ctx context.Context, matchID uuid.UUID, status string) error {
+	defer r.invalidate(matchID)
+	return r.MatchRepository.UpdateStatus(ctx, matchID, status)
+}
+
+// SetStartTime sets the match start timestamp and invalidates the cached match
+func (r *cachedMatchRepository) SetStartTime(ctx context.Context, matchID uuid.UUID) error {
+	defer r.invalidate(matchID)
+	return r.MatchRepository.SetStartTime(ctx, matchID)
+}
+
+// SetCompletionTime sets the match completion timestamp and invalidates the cached match
+func (r *cachedMatchRepository) SetCompletionTime(ctx context.Context, matchID uuid.UUID) error {
+	defer r.invalidate(matchID)
+	return r.MatchRepository.SetCompletionTime(ctx, matchID)
+}
+
+// invalidate drops the cached row of a match
+func (r *cachedMatchRepository) invalidate(matchID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.matches, matchID)
+	r.writes++
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// countingMatchRepository keeps matches in memory and counts GetByID queries
+type countingMatchRepository struct {
+	MatchRepository
+	matches map[uuid.UUID]*models.Match
+	queries int
+}
+
+func (r *countingMatchRepository) GetByID(ctx context.Context, matchID uuid.UUID) (*models.Match, error) {
+	r.queries++
+	match, ok := r.matches[matchID]
+	if !ok {
+		return nil, nil
+	}
+	matchCopy := *match
+	return &matchCopy, nil
+}
+
+func (r *countingMatchRepository) UpdateStatus(ctx context.Context, matchID uuid.UUID, status string) error {
+	r.matches[matchID].Status = models.MatchStatus(status)
+	return nil
+}
+
+func newCachedTestMatches(ttl time.Duration) (*cachedMatchRepository, *countingMatchRepository, *time.Time) {
+	inner := &countingMatchRepository{matches: make(map[uuid.UUID]*models.Match)}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cached := NewCachedMatchRepository(inner, ttl).(*cachedMatchRepository)
+	cached.now = func() time.Time { return now }
+	return cached, inner, &now
+}
+
+func TestCachedMatches_RepeatedReadsHitCache(t *testing.T) {
+	cached, inner, now := newCachedTestMatches(time.Second)
+	ctx := context.Background()
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusInProgress}
+	inner.matches[match.ID] = match
+
+	for i := 0; i < 3; i++ {
+		got, err := cached.GetByID(ctx, match.ID)
+		require.NoError(t, err)
+		assert.Equal(t, match.ID, got.ID)
+
+		// Callers get copies, so changing one never leaks into the cache
+		got.Status = models.MatchStatusAborted
+	}
+	assert.Equal(t, 1, inner.queries)
+
+	got, err := cached.GetByID(ctx, match.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.MatchStatusInProgress, got.Status)
+
+	// The cached row expires after the TTL
+	*now = now.Add(time.Second)
+	_, err = cached.GetByID(ctx, match.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, inner.queries)
+}
+
+func TestCachedMatches_WriteInvalidatesMatch(t *testing.T) {
+	cached, inner, _ := newCachedTestMatches(time.Minute)
+	ctx := context.Background()
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusInProgress}
+	inner.matches[match.ID] = match
+
+	_, err := cached.GetByID(ctx, match.ID)
+	require.NoError(t, err)
+	require.NoError(t, cached.UpdateStatus(ctx, match.ID, string(models.MatchStatusCompleted)))
+
+	got, err := cached.GetByID(ctx, match.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.MatchStatusCompleted, got.Status)
+	assert.Equal(t, 2, inner.queries)
+}
+
+func TestCachedMatches_MissingMatchNotCached(t *testing.T) {
+	cached, inner, _ := newCachedTestMatches(time.Minute)
+	ctx := context.Background()
+	matchID := uuid.New()
+
+	got, err := cached.GetByID(ctx, matchID)
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	// A match created afterwards is found straight away
+	inner.matches[matchID] = &models.Match{ID: matchID}
+	got, err = cached.GetByID(ctx, matchID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, 2, inner.queries)
+}
+
+func TestNewCachedMatchRepository_ZeroTTLDisablesCache(t *testing.T) {
+	inner := &countingMatchRepository{}
+	assert.Same(t, MatchRepository(inner), NewCachedMatchRepository(inner, 0))
+}
+
+func TestCachedMatches_PrimaryReadsBypassCache(t *testing.T) {
+	cached, inner, _ := newCachedTestMatches(time.Minute)
+	ctx := context.Background()
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusInProgress}
+	inner.matches[match.ID] = match
+
+	_, err := cached.GetByID(ctx, match.ID)
+	require.NoError(t, err)
+
+	// Another instance completes the match behind this cache's back
+	match.Status = models.MatchStatusCompleted
+
+	got, err := cached.GetByID(WithPrimaryReads(ctx), match.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.MatchStatusCompleted, got.Status)
+	assert.Equal(t, 2, inner.queries)
+}
+
+func TestCachedMatches_FinishedMatchNotCached(t *testing.T) {
+	cached, inner, _ := newCachedTestMatches(time.Minute)
+	ctx := context.Background()
+	match := &models.Match{ID: uuid.New(), League: models.LeagueRookie, Status: models.MatchStatusAborted}
+	inner.matches[match.ID] = match
+
+	for i := 0; i < 2; i++ {
+		_, err := cached.GetByID(ctx, match.ID)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, inner.queries)
+	assert.Empty(t, cached.matches)
+}
+
+func TestCachedMatches_SweepsExpiredMatches(t *testing.T) {
+	cached, inner, now := newCachedTestMatches(time.Second)
+	ctx := context.Background()
+	first := &models.Match{ID: uuid.New(), Status: models.MatchStatusInProgress}
+	second := &models.Match{ID: uuid.New(), Status: models.MatchStatusInProgress}
+	inner.matches[first.ID] = first
+	inner.matches[second.ID] = second
+
+	_, err := cached.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+
+	// Once the first row has expired, caching another match drops it
+	*now = now.Add(2 * time.Second)
+	_, err = cached.GetByID(ctx, second.ID)
+	require.NoError(t, err)
+
+	assert.Len(t, cached.matches, 1)
+	assert.Contains(t, cached.matches, second.ID)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// MatchEventRepository defines the interface for match event timeline data access
+type MatchEventRepository interface {
+	// CreateBatch inserts several match events in a single statement
+	CreateBatch(ctx context.Context, events []*models.MatchEvent) error
+
+	// GetByMatchID retrieves a match's events in the order they occurred
+	GetByMatchID(ctx context.Context, matchID uuid.UUID) ([]*models.MatchEvent, error)
+}
+
+// matchEventRepository implements MatchEventRepository
+type matchEventRepository struct {
+	db *timeoutDB
+}
+
+// NewMatchEventRepository creates a new match event repository
+func NewMatchEventRepository(db *sqlx.DB, opts ...Option) MatchEventRepository {
+	return &matchEventRepository{db: newTimeoutDB(db, opts...)}
+}
+
+// CreateBatch inserts several match events in a single statement
+func (r *matchEventRepository) CreateBatch(ctx context.Context, events []*models.MatchEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO match_events (match_id, event_type, heat, user_id, ghost_replay_id,
+		                          score, heat_time, occurred_at)
+		VALUES (:match_id, :event_type, :heat, :user_id, :ghost_replay_id,
+		        :score, :heat_time, :occurred_at)`
+
+	_, err := r.db.NamedExecContext(ctx, query, events)
+	return err
+}
+
+// GetByMatchID retrieves a match's events in the order they occurred
+func (r *matchEventRepository) GetByMatchID(ctx context.Context, matchID uuid.UUID) ([]*models.MatchEvent, error) {
+	events := []*models.MatchEvent{}
+	query := `
+		SELECT id, match_id, event_type, heat, user_id, ghost_replay_id,
+		       score, heat_time, occurred_at, created_at
+		FROM match_events
+		WHERE match_id = $1
+		ORDER BY occurred_at ASC, id ASC`
+
+	err := r.db.reader(ctx).SelectContext(ctx, &events, query, matchID)
+	return events, err
+}
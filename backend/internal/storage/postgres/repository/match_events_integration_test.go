@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+type MatchEventRepositoryIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper  *TestDBHelper
+	eventRepo MatchEventRepository
+	matchRepo MatchRepository
+}
+
+func TestMatchEventRepositoryIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(MatchEventRepositoryIntegrationTestSuite))
+}
+
+func (suite *MatchEventRepositoryIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	suite.eventRepo = NewMatchEventRepository(suite.dbHelper.DB)
+	suite.matchRepo = NewMatchRepository(suite.dbHelper.DB)
+}
+
+func (suite *MatchEventRepositoryIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *MatchEventRepositoryIntegrationTestSuite) SetupTest() {
+	suite.dbHelper.CleanupTables("match_events", "matches")
+}
+
+func (suite *MatchEventRepositoryIntegrationTestSuite) createMatch() uuid.UUID {
+	match := &models.Match{
+		ID:               uuid.New(),
+		League:           models.LeagueRookie,
+		Status:           models.MatchStatusInProgress,
+		LivePlayerCount:  10,
+		GhostPlayerCount: 0,
+		PrizePool:        decimal.NewFromInt(92),
+		RakeAmount:       decimal.NewFromInt(8),
+		CrashSeed:        "test-crash-seed",
+		CrashSeedHash:    "test-crash-seed-hash",
+		CreatedAt:        time.Now().UTC(),
+	}
+	require.NoError(suite.T(), suite.matchRepo.Create(context.Background(), match))
+	return match.ID
+}
+
+func (suite *MatchEventRepositoryIntegrationTestSuite) TestCreateBatch_TimelineIsOrdered() {
+	ctx := context.Background()
+	matchID := suite.createMatch()
+	otherMatchID := suite.createMatch()
+
+	start := time.Now().UTC().Truncate(time.Millisecond)
+	userID := uuid.New()
+	ghostReplayID := uuid.New()
+	score := decimal.RequireFromString("187.25")
+	lockTime := 6.125
+	crashTime := 25.0
+
+	// Inserted out of order; the timeline is read back by occurrence
+	events := []*models.MatchEvent{
+		{MatchID: matchID, EventType: models.MatchEventHeatEnded, Heat: 1, OccurredAt: start.Add(28 * time.Second)},
+		{MatchID: matchID, EventType: models.MatchEventHeatStarted, Heat: 1, OccurredAt: start},
+		{MatchID: matchID, EventType: models.MatchEventScoreLocked, Heat: 1, UserID: &userID, Score: &score, HeatTime: &lockTime, OccurredAt: start.Add(9 * time.Second)},
+		{MatchID: matchID, EventType: models.MatchEventPlayerCrashed, Heat: 1, GhostReplayID: &ghostReplayID, HeatTime: &crashTime, OccurredAt: start.Add(28 * time.Second)},
+		{MatchID: otherMatchID, EventType: models.MatchEventHeatStarted, Heat: 1, OccurredAt: start},
+	}
+	require.NoError(suite.T(), suite.eventRepo.CreateBatch(ctx, events))
+
+	timeline, err := suite.eventRepo.GetByMatchID(ctx, matchID)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), timeline, 4)
+
+	types := make([]models.MatchEventType, 0, len(timeline))
+	for _, event := range timeline {
+		types = append(types, event.EventType)
+		assert.Equal(suite.T(), matchID, event.MatchID)
+	}
+	// Events at the same instant keep their insertion order
+	assert.Equal(suite.T(), []models.MatchEventType{
+		models.MatchEventHeatStarted,
+		models.MatchEventScoreLocked,
+		models.MatchEventHeatEnded,
+		models.MatchEventPlayerCrashed,
+	}, types)
+
+	locked := timeline[1]
+	require.NotNil(suite.T(), locked.UserID)
+	assert.Equal(suite.T(), userID, *locked.UserID)
+	require.NotNil(suite.T(), locked.Score)
+	assert.True(suite.T(), score.Equal(*locked.Score))
+	require.NotNil(suite.T(), locked.HeatTime)
+	assert.Equal(suite.T(), lockTime, *locked.HeatTime)
+
+	crashed := timeline[3]
+	assert.Nil(suite.T(), crashed.UserID)
+	require.NotNil(suite.T(), crashed.GhostReplayID)
+	assert.Equal(suite.T(), ghostReplayID, *crashed.GhostReplayID)
+	assert.Nil(suite.T(), crashed.Score)
+}
+
+func (suite *MatchEventRepositoryIntegrationTestSuite) TestGetByMatchID_NoEvents() {
+	timeline, err := suite.eventRepo.GetByMatchID(context.Background(), suite.createMatch())
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), timeline)
+}
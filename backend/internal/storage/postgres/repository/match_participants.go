@@ -28,9 +28,23 @@ type MatchParticipantRepository interface {
 	// UpdateHeatScore updates a participant's score for a specific heat
 	UpdateHeatScore(ctx context.Context, matchID, userID uuid.UUID, heat int, score decimal.Decimal) error
 
+	// UpdateHeatLockTime records when a participant locked their score in a specific heat,
+	// in seconds since the heat went active
+	UpdateHeatLockTime(ctx context.Context, matchID, userID uuid.UUID, heat int, lockTime float64) error
+
 	// UpdateTotalScore updates a participant's total score
 	UpdateTotalScore(ctx context.Context, matchID, userID uuid.UUID, totalScore decimal.Decimal) error
 
+	// UpdateGhostHeatScore updates a ghost participant's score for a specific heat.
+	// Ghosts are keyed on their participant row, as one replay may fill several slots.
+	UpdateGhostHeatScore(ctx context.Context, participantID int64, heat int, score decimal.Decimal) error
+
+	// UpdateGhostHeatLockTime records when a ghost participant locked their score in a specific heat
+	UpdateGhostHeatLockTime(ctx context.Context, participantID int64, heat int, lockTime float64) error
+
+	// UpdateGhostTotalScore updates a ghost participant's total score
+	UpdateGhostTotalScore(ctx context.Context, participantID int64, totalScore decimal.Decimal) error
+
 	// SetFinalPosition sets the final position for a participant
 	SetFinalPosition(ctx context.Context, matchID, userID uuid.UUID, position int) error
 
@@ -40,17 +54,27 @@ type MatchParticipantRepository interface {
 	// SetBurnReward sets the BURN reward for a participant
 	SetBurnReward(ctx context.Context, matchID, userID uuid.UUID, burnReward decimal.Decimal) error
 
+	// ReplaceGhost turns a ghost participant of a FORMING match into a live player and moves one
+	// slot from the match's ghost count to its live count, in one transaction. It reports false
+	// when the match is no longer FORMING or has no such ghost.
+	ReplaceGhost(ctx context.Context, matchID, ghostReplayID, userID uuid.UUID, displayName string) (bool, error)
+
 	// GetLiveParticipants retrieves only live (non-ghost) participants for a match
 	GetLiveParticipants(ctx context.Context, matchID uuid.UUID) ([]*models.MatchParticipant, error)
 
 	// GetGhostParticipants retrieves only ghost participants for a match
 	GetGhostParticipants(ctx context.Context, matchID uuid.UUID) ([]*models.MatchParticipant, error)
 
-	// GetStandings retrieves participants ordered by total score (for standings)
+	// GetStandings retrieves participants ranked by total score, then Heat 3, Heat 2 and Heat 1 score,
+	// earlier participants first on a full tie. Settlement takes final positions from this order.
 	GetStandings(ctx context.Context, matchID uuid.UUID) ([]*models.MatchParticipant, error)
 
 	// GetUserStats retrieves statistics for a user across all matches
 	GetUserStats(ctx context.Context, userID uuid.UUID) (*UserStats, error)
+
+	// GetActiveMatchForUser retrieves the ID of the FORMING or IN_PROGRESS match a user takes part in
+	// as a live player. It returns nil if the user is not in an active match.
+	GetActiveMatchForUser(ctx context.Context, userID uuid.UUID) (*uuid.UUID, error)
 }
 
 // UserStats represents statistics for a user across all matches
@@ -68,12 +92,12 @@ type UserStats struct {
 
 // matchParticipantRepository implements MatchParticipantRepository
 type matchParticipantRepository struct {
-	db *sqlx.DB
+	db *timeoutDB
 }
 
 // NewMatchParticipantRepository creates a new match participant repository
-func NewMatchParticipantRepository(db *sqlx.DB) MatchParticipantRepository {
-	return &matchParticipantRepository{db: db}
+func NewMatchParticipantRepository(db *sqlx.DB, opts ...Option) MatchParticipantRepository {
+	return &matchParticipantRepository{db: newTimeoutDB(db, opts...)}
 }
 
 // Create creates a new match participant
@@ -81,11 +105,13 @@ func (r *matchParticipantRepository) Create(ctx context.Context, participant *mo
 	query := `
 		INSERT INTO match_participants (match_id, user_id, is_ghost, ghost_replay_id,
 		                               player_display_name, buyin_amount, heat1_score,
-		                               heat2_score, heat3_score, total_score,
+		                               heat2_score, heat3_score, heat1_lock_time,
+		                               heat2_lock_time, heat3_lock_time, total_score,
 		                               final_position, prize_amount, burn_reward, created_at)
 		VALUES (:match_id, :user_id, :is_ghost, :ghost_replay_id,
 		        :player_display_name, :buyin_amount, :heat1_score,
-		        :heat2_score, :heat3_score, :total_score,
+		        :heat2_score, :heat3_score, :heat1_lock_time,
+		        :heat2_lock_time, :heat3_lock_time, :total_score,
 		        :final_position, :prize_amount, :burn_reward, :created_at)`
 
 	_, err := r.db.NamedExecContext(ctx, query, participant)
@@ -98,30 +124,50 @@ func (r *matchParticipantRepository) CreateBatch(ctx context.Context, participan
 		return nil
 	}
 
+	// The whole transaction shares a single query timeout
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	if err := insertParticipants(ctx, tx, participants); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertParticipants inserts match participants within a transaction, setting each one's ID
+func insertParticipants(ctx context.Context, tx *sqlx.Tx, participants []*models.MatchParticipant) error {
 	query := `
 		INSERT INTO match_participants (match_id, user_id, is_ghost, ghost_replay_id,
 		                               player_display_name, buyin_amount, heat1_score,
-		                               heat2_score, heat3_score, total_score,
+		                               heat2_score, heat3_score, heat1_lock_time,
+		                               heat2_lock_time, heat3_lock_time, total_score,
 		                               final_position, prize_amount, burn_reward, created_at)
 		VALUES (:match_id, :user_id, :is_ghost, :ghost_replay_id,
 		        :player_display_name, :buyin_amount, :heat1_score,
-		        :heat2_score, :heat3_score, :total_score,
-		        :final_position, :prize_amount, :burn_reward, :created_at)`
+		        :heat2_score, :heat3_score, :heat1_lock_time,
+		        :heat2_lock_time, :heat3_lock_time, :total_score,
+		        :final_position, :prize_amount, :burn_reward, :created_at)
+		RETURNING id`
+
+	stmt, err := tx.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
 
 	for _, participant := range participants {
-		_, err := tx.NamedExecContext(ctx, query, participant)
-		if err != nil {
+		if err := stmt.GetContext(ctx, &participant.ID, participant); err != nil {
 			return err
 		}
 	}
-
-	return tx.Commit()
+	return nil
 }
 
 // GetByMatchID retrieves all participants for a match
@@ -129,13 +175,14 @@ func (r *matchParticipantRepository) GetByMatchID(ctx context.Context, matchID u
 	participants := []*models.MatchParticipant{}
 	query := `
 		SELECT match_id, user_id, is_ghost, ghost_replay_id, player_display_name,
-		       buyin_amount, heat1_score, heat2_score, heat3_score, total_score,
+		       buyin_amount, heat1_score, heat2_score, heat3_score,
+		       heat1_lock_time, heat2_lock_time, heat3_lock_time, total_score,
 		       final_position, prize_amount, burn_reward, created_at
 		FROM match_participants 
 		WHERE match_id = $1
 		ORDER BY created_at ASC`
 
-	err := r.db.SelectContext(ctx, &participants, query, matchID)
+	err := r.db.reader(ctx).SelectContext(ctx, &participants, query, matchID)
 	return participants, err
 }
 
@@ -144,12 +191,13 @@ func (r *matchParticipantRepository) GetByMatchAndUser(ctx context.Context, matc
 	participant := &models.MatchParticipant{}
 	query := `
 		SELECT match_id, user_id, is_ghost, ghost_replay_id, player_display_name,
-		       buyin_amount, heat1_score, heat2_score, heat3_score, total_score,
+		       buyin_amount, heat1_score, heat2_score, heat3_score,
+		       heat1_lock_time, heat2_lock_time, heat3_lock_time, total_score,
 		       final_position, prize_amount, burn_reward, created_at
 		FROM match_participants 
 		WHERE match_id = $1 AND user_id = $2`
 
-	err := r.db.GetContext(ctx, participant, query, matchID, userID)
+	err := r.db.reader(ctx).GetContext(ctx, participant, query, matchID, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -160,6 +208,29 @@ func (r *matchParticipantRepository) GetByMatchAndUser(ctx context.Context, matc
 	return participant, nil
 }
 
+// GetActiveMatchForUser retrieves the ID of the active match a user takes part in.
+// A user should never be in two active matches; if they are, the latest one is returned.
+func (r *matchParticipantRepository) GetActiveMatchForUser(ctx context.Context, userID uuid.UUID) (*uuid.UUID, error) {
+	var matchID uuid.UUID
+	query := `
+		SELECT mp.match_id
+		FROM match_participants mp
+		INNER JOIN matches m ON m.id = mp.match_id
+		WHERE mp.user_id = $1 AND m.status IN ('FORMING', 'IN_PROGRESS')
+		ORDER BY m.created_at DESC
+		LIMIT 1`
+
+	err := r.db.reader(ctx).GetContext(ctx, &matchID, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &matchID, nil
+}
+
 // UpdateHeatScore updates a participant's score for a specific heat
 func (r *matchParticipantRepository) UpdateHeatScore(ctx context.Context, matchID, userID uuid.UUID, heat int, score decimal.Decimal) error {
 	var query string
@@ -178,6 +249,24 @@ func (r *matchParticipantRepository) UpdateHeatScore(ctx context.Context, matchI
 	return err
 }
 
+// UpdateHeatLockTime records when a participant locked their score in a specific heat
+func (r *matchParticipantRepository) UpdateHeatLockTime(ctx context.Context, matchID, userID uuid.UUID, heat int, lockTime float64) error {
+	var query string
+	switch heat {
+	case 1:
+		query = `UPDATE match_participants SET heat1_lock_time = $3 WHERE match_id = $1 AND user_id = $2`
+	case 2:
+		query = `UPDATE match_participants SET heat2_lock_time = $3 WHERE match_id = $1 AND user_id = $2`
+	case 3:
+		query = `UPDATE match_participants SET heat3_lock_time = $3 WHERE match_id = $1 AND user_id = $2`
+	default:
+		return sql.ErrNoRows
+	}
+
+	_, err := r.db.ExecContext(ctx, query, matchID, userID, lockTime)
+	return err
+}
+
 // UpdateTotalScore updates a participant's total score
 func (r *matchParticipantRepository) UpdateTotalScore(ctx context.Context, matchID, userID uuid.UUID, totalScore decimal.Decimal) error {
 	query := `UPDATE match_participants SET total_score = $3 WHERE match_id = $1 AND user_id = $2`
@@ -185,6 +274,49 @@ func (r *matchParticipantRepository) UpdateTotalScore(ctx context.Context, match
 	return err
 }
 
+// UpdateGhostHeatScore updates a ghost participant's score for a specific heat
+func (r *matchParticipantRepository) UpdateGhostHeatScore(ctx context.Context, participantID int64, heat int, score decimal.Decimal) error {
+	var query string
+	switch heat {
+	case 1:
+		query = `UPDATE match_participants SET heat1_score = $2 WHERE id = $1 AND is_ghost = TRUE`
+	case 2:
+		query = `UPDATE match_participants SET heat2_score = $2 WHERE id = $1 AND is_ghost = TRUE`
+	case 3:
+		query = `UPDATE match_participants SET heat3_score = $2 WHERE id = $1 AND is_ghost = TRUE`
+	default:
+		return sql.ErrNoRows
+	}
+
+	_, err := r.db.ExecContext(ctx, query, participantID, score)
+	return err
+}
+
+// UpdateGhostHeatLockTime records when a ghost participant locked their score in a specific heat
+func (r *matchParticipantRepository) UpdateGhostHeatLockTime(ctx context.Context, participantID int64, heat int, lockTime float64) error {
+	var query string
+	switch heat {
+	case 1:
+		query = `UPDATE match_participants SET heat1_lock_time = $2 WHERE id = $1 AND is_ghost = TRUE`
+	case 2:
+		query = `UPDATE match_participants SET heat2_lock_time = $2 WHERE id = $1 AND is_ghost = TRUE`
+	case 3:
+		query = `UPDATE match_participants SET heat3_lock_time = $2 WHERE id = $1 AND is_ghost = TRUE`
+	default:
+		return sql.ErrNoRows
+	}
+
+	_, err := r.db.ExecContext(ctx, query, participantID, lockTime)
+	return err
+}
+
+// UpdateGhostTotalScore updates a ghost participant's total score
+func (r *matchParticipantRepository) UpdateGhostTotalScore(ctx context.Context, participantID int64, totalScore decimal.Decimal) error {
+	query := `UPDATE match_participants SET total_score = $2 WHERE id = $1 AND is_ghost = TRUE`
+	_, err := r.db.ExecContext(ctx, query, participantID, totalScore)
+	return err
+}
+
 // SetFinalPosition sets the final position for a participant
 func (r *matchParticipantRepository) SetFinalPosition(ctx context.Context, matchID, userID uuid.UUID, position int) error {
 	query := `UPDATE match_participants SET final_position = $3 WHERE match_id = $1 AND user_id = $2`
@@ -206,18 +338,75 @@ func (r *matchParticipantRepository) SetBurnReward(ctx context.Context, matchID,
 	return err
 }
 
+// ReplaceGhost turns a ghost participant of a FORMING match into a live player
+func (r *matchParticipantRepository) ReplaceGhost(ctx context.Context, matchID, ghostReplayID, userID uuid.UUID, displayName string) (bool, error) {
+	// The whole transaction shares a single query timeout
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Lock the match so it cannot start while its grid changes
+	var status string
+	err = tx.GetContext(ctx, &status, `SELECT status FROM matches WHERE id = $1 FOR UPDATE`, matchID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if status != string(models.MatchStatusForming) {
+		return false, nil
+	}
+
+	query := `
+		UPDATE match_participants
+		SET user_id = $3, is_ghost = FALSE, ghost_replay_id = NULL, player_display_name = $4
+		WHERE id = (
+			SELECT id FROM match_participants
+			WHERE match_id = $1 AND ghost_replay_id = $2 AND is_ghost = TRUE
+			LIMIT 1
+		)`
+	result, err := tx.ExecContext(ctx, query, matchID, ghostReplayID, userID, displayName)
+	if err != nil {
+		return false, err
+	}
+	replaced, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if replaced == 0 {
+		return false, nil
+	}
+
+	query = `
+		UPDATE matches
+		SET live_player_count = live_player_count + 1, ghost_player_count = ghost_player_count - 1
+		WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, matchID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
 // GetLiveParticipants retrieves only live (non-ghost) participants for a match
 func (r *matchParticipantRepository) GetLiveParticipants(ctx context.Context, matchID uuid.UUID) ([]*models.MatchParticipant, error) {
 	participants := []*models.MatchParticipant{}
 	query := `
 		SELECT match_id, user_id, is_ghost, ghost_replay_id, player_display_name,
-		       buyin_amount, heat1_score, heat2_score, heat3_score, total_score,
+		       buyin_amount, heat1_score, heat2_score, heat3_score,
+		       heat1_lock_time, heat2_lock_time, heat3_lock_time, total_score,
 		       final_position, prize_amount, burn_reward, created_at
 		FROM match_participants 
 		WHERE match_id = $1 AND is_ghost = FALSE
 		ORDER BY created_at ASC`
 
-	err := r.db.SelectContext(ctx, &participants, query, matchID)
+	err := r.db.reader(ctx).SelectContext(ctx, &participants, query, matchID)
 	return participants, err
 }
 
@@ -226,22 +415,24 @@ func (r *matchParticipantRepository) GetGhostParticipants(ctx context.Context, m
 	participants := []*models.MatchParticipant{}
 	query := `
 		SELECT match_id, user_id, is_ghost, ghost_replay_id, player_display_name,
-		       buyin_amount, heat1_score, heat2_score, heat3_score, total_score,
+		       buyin_amount, heat1_score, heat2_score, heat3_score,
+		       heat1_lock_time, heat2_lock_time, heat3_lock_time, total_score,
 		       final_position, prize_amount, burn_reward, created_at
 		FROM match_participants 
 		WHERE match_id = $1 AND is_ghost = TRUE
 		ORDER BY created_at ASC`
 
-	err := r.db.SelectContext(ctx, &participants, query, matchID)
+	err := r.db.reader(ctx).SelectContext(ctx, &participants, query, matchID)
 	return participants, err
 }
 
-// GetStandings retrieves participants ordered by total score (for standings)
+// GetStandings retrieves participants ranked by total score, then Heat 3, Heat 2 and Heat 1 score
 func (r *matchParticipantRepository) GetStandings(ctx context.Context, matchID uuid.UUID) ([]*models.MatchParticipant, error) {
 	participants := []*models.MatchParticipant{}
 	query := `
 		SELECT match_id, user_id, is_ghost, ghost_replay_id, player_display_name,
-		       buyin_amount, heat1_score, heat2_score, heat3_score, total_score,
+		       buyin_amount, heat1_score, heat2_score, heat3_score,
+		       heat1_lock_time, heat2_lock_time, heat3_lock_time, total_score,
 		       final_position, prize_amount, burn_reward, created_at
 		FROM match_participants 
 		WHERE match_id = $1
@@ -252,7 +443,7 @@ func (r *matchParticipantRepository) GetStandings(ctx context.Context, matchID u
 			heat1_score DESC NULLS LAST,
 			created_at ASC`
 
-	err := r.db.SelectContext(ctx, &participants, query, matchID)
+	err := r.db.reader(ctx).SelectContext(ctx, &participants, query, matchID)
 	return participants, err
 }
 
@@ -273,7 +464,10 @@ func (r *matchParticipantRepository) GetUserStats(ctx context.Context, userID uu
 		FROM match_participants 
 		WHERE user_id = $1 AND is_ghost = FALSE AND final_position IS NOT NULL`
 
-	row := r.db.QueryRowContext(ctx, query, userID)
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
+	row := r.db.reader(ctx).QueryRowContext(ctx, query, userID)
 	err := row.Scan(
 		&stats.TotalMatches,
 		&stats.TotalWins,
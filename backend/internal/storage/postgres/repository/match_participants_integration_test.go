@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+type MatchParticipantRepositoryIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper        *TestDBHelper
+	participantRepo MatchParticipantRepository
+	matchRepo       MatchRepository
+	userRepo        UserRepository
+}
+
+func TestMatchParticipantRepositoryIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(MatchParticipantRepositoryIntegrationTestSuite))
+}
+
+func (suite *MatchParticipantRepositoryIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	suite.participantRepo = NewMatchParticipantRepository(suite.dbHelper.DB)
+	suite.matchRepo = NewMatchRepository(suite.dbHelper.DB)
+	suite.userRepo = NewUserRepository(suite.dbHelper.DB)
+}
+
+func (suite *MatchParticipantRepositoryIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *MatchParticipantRepositoryIntegrationTestSuite) SetupTest() {
+	suite.dbHelper.CleanupTables("match_participants", "matches", "users")
+}
+
+// createUsers creates n users to take part in matches
+func (suite *MatchParticipantRepositoryIntegrationTestSuite) createUsers(n int) []uuid.UUID {
+	userIDs := make([]uuid.UUID, n)
+	for i := range userIDs {
+		user := &models.User{
+			ID:                uuid.New(),
+			TelegramID:        int64(1000 + i),
+			TelegramFirstName: "Racer",
+			CreatedAt:         time.Now().UTC(),
+			UpdatedAt:         time.Now().UTC(),
+		}
+		require.NoError(suite.T(), suite.userRepo.Create(context.Background(), user))
+		userIDs[i] = user.ID
+	}
+	return userIDs
+}
+
+func (suite *MatchParticipantRepositoryIntegrationTestSuite) createMatch() uuid.UUID {
+	match := &models.Match{
+		ID:               uuid.New(),
+		League:           models.LeagueRookie,
+		Status:           models.MatchStatusInProgress,
+		LivePlayerCount:  10,
+		GhostPlayerCount: 0,
+		PrizePool:        decimal.NewFromInt(92),
+		RakeAmount:       decimal.NewFromInt(8),
+		CrashSeed:        "test-crash-seed",
+		CrashSeedHash:    "test-crash-seed-hash",
+		CreatedAt:        time.Now().UTC(),
+	}
+	require.NoError(suite.T(), suite.matchRepo.Create(context.Background(), match))
+	return match.ID
+}
+
+// standingsRankAbove reports whether participant a ranks above b: higher total, then higher Heat 3,
+// Heat 2 and Heat 1 score, missing scores last
+func standingsRankAbove(a, b *models.MatchParticipant) bool {
+	for _, scores := range [][2]*decimal.Decimal{
+		{a.TotalScore, b.TotalScore},
+		{a.Heat3Score, b.Heat3Score},
+		{a.Heat2Score, b.Heat2Score},
+		{a.Heat1Score, b.Heat1Score},
+	} {
+		sa, sb := scores[0], scores[1]
+		switch {
+		case sa == nil && sb == nil:
+			continue
+		case sa == nil:
+			return false
+		case sb == nil:
+			return true
+		case !sa.Equal(*sb):
+			return sa.GreaterThan(*sb)
+		}
+	}
+	return false
+}
+
+func (suite *MatchParticipantRepositoryIntegrationTestSuite) TestGetStandings_MatchesScoreRanking() {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(42))
+	userIDs := suite.createUsers(10)
+
+	// Scores come from a few values so totals and whole score lines tie often
+	randomScore := func() *decimal.Decimal {
+		if rng.Intn(8) == 0 {
+			return nil
+		}
+		score := decimal.NewFromInt(int64(rng.Intn(3) * 50))
+		return &score
+	}
+
+	for round := 0; round < 25; round++ {
+		matchID := suite.createMatch()
+		joinedAt := time.Now().UTC().Truncate(time.Millisecond)
+
+		participants := make([]*models.MatchParticipant, 0, len(userIDs))
+		for i, userID := range userIDs {
+			participant := &models.MatchParticipant{
+				MatchID:           matchID,
+				UserID:            &userID,
+				PlayerDisplayName: "Racer",
+				BuyinAmount:       decimal.NewFromInt(10),
+				Heat1Score:        randomScore(),
+				Heat2Score:        randomScore(),
+				Heat3Score:        randomScore(),
+				CreatedAt:         joinedAt.Add(time.Duration(i) * time.Second),
+			}
+			if rng.Intn(8) != 0 {
+				total := decimal.Zero
+				for _, score := range []*decimal.Decimal{participant.Heat1Score, participant.Heat2Score, participant.Heat3Score} {
+					if score != nil {
+						total = total.Add(*score)
+					}
+				}
+				participant.TotalScore = &total
+			}
+			participants = append(participants, participant)
+		}
+		require.NoError(suite.T(), suite.participantRepo.CreateBatch(ctx, participants))
+
+		standings, err := suite.participantRepo.GetStandings(ctx, matchID)
+		require.NoError(suite.T(), err)
+		require.Len(suite.T(), standings, len(participants))
+
+		for i := 1; i < len(standings); i++ {
+			prev, next := standings[i-1], standings[i]
+			assert.False(suite.T(), standingsRankAbove(next, prev), "round %d position %d", round, i+1)
+
+			// Full ties keep the order participants joined in
+			if !standingsRankAbove(prev, next) {
+				assert.True(suite.T(), prev.CreatedAt.Before(next.CreatedAt), "round %d position %d", round, i+1)
+			}
+		}
+	}
+}
+
+// joinMatch seats users in a match as live participants
+func (suite *MatchParticipantRepositoryIntegrationTestSuite) joinMatch(matchID uuid.UUID, userIDs ...uuid.UUID) {
+	participants := make([]*models.MatchParticipant, 0, len(userIDs))
+	for _, userID := range userIDs {
+		participants = append(participants, &models.MatchParticipant{
+			MatchID:           matchID,
+			UserID:            &userID,
+			PlayerDisplayName: "Racer",
+			BuyinAmount:       decimal.NewFromInt(10),
+			CreatedAt:         time.Now().UTC(),
+		})
+	}
+	require.NoError(suite.T(), suite.participantRepo.CreateBatch(context.Background(), participants))
+}
+
+func (suite *MatchParticipantRepositoryIntegrationTestSuite) TestGetActiveMatchForUser() {
+	ctx := context.Background()
+	userIDs := suite.createUsers(2)
+	racer, finisher := userIDs[0], userIDs[1]
+
+	finishedID := suite.createMatch()
+	suite.joinMatch(finishedID, racer, finisher)
+	require.NoError(suite.T(), suite.matchRepo.UpdateStatus(ctx, finishedID, string(models.MatchStatusCompleted)))
+
+	activeID := suite.createMatch()
+	suite.joinMatch(activeID, racer)
+
+	matchID, err := suite.participantRepo.GetActiveMatchForUser(ctx, racer)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), matchID)
+	assert.Equal(suite.T(), activeID, *matchID)
+
+	// Settled matches are not active
+	matchID, err = suite.participantRepo.GetActiveMatchForUser(ctx, finisher)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), matchID)
+
+	require.NoError(suite.T(), suite.matchRepo.UpdateStatus(ctx, activeID, string(models.MatchStatusCompleted)))
+	matchID, err = suite.participantRepo.GetActiveMatchForUser(ctx, racer)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), matchID)
+}
+
+func (suite *MatchParticipantRepositoryIntegrationTestSuite) TestGetActiveMatchForUser_Forming() {
+	ctx := context.Background()
+	racer := suite.createUsers(1)[0]
+
+	formingID := suite.createMatch()
+	require.NoError(suite.T(), suite.matchRepo.UpdateStatus(ctx, formingID, string(models.MatchStatusForming)))
+	suite.joinMatch(formingID, racer)
+
+	matchID, err := suite.participantRepo.GetActiveMatchForUser(ctx, racer)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), matchID)
+	assert.Equal(suite.T(), formingID, *matchID)
+}
@@ -24,33 +24,38 @@ type MatchSettlementRepository interface {
 
 // matchSettlementRepository implements MatchSettlementRepository
 type matchSettlementRepository struct {
-	db *sqlx.DB
+	db *timeoutDB
 }
 
 // NewMatchSettlementRepository creates a new match settlement repository
-func NewMatchSettlementRepository(db *sqlx.DB) MatchSettlementRepository {
-	return &matchSettlementRepository{db: db}
+func NewMatchSettlementRepository(db *sqlx.DB, opts ...Option) MatchSettlementRepository {
+	return &matchSettlementRepository{db: newTimeoutDB(db, opts...)}
 }
 
 // Create creates a new match settlement record
 func (r *matchSettlementRepository) Create(ctx context.Context, settlement *models.MatchSettlement) error {
+	burnRewardTable := settlement.BurnRewardTable
+	if len(burnRewardTable) == 0 {
+		burnRewardTable = []byte("{}")
+	}
+
 	query := `
-		INSERT INTO match_settlements (match_id, settled_at)
-		VALUES (:match_id, :settled_at)`
+		INSERT INTO match_settlements (match_id, settled_at, burn_reward_table)
+		VALUES ($1, $2, $3)`
 
-	_, err := r.db.NamedExecContext(ctx, query, settlement)
-	return err
+	_, err := r.db.ExecContext(ctx, query, settlement.MatchID, settlement.SettledAt, []byte(burnRewardTable))
+	return mapConstraintError(err)
 }
 
 // GetByMatchID retrieves a settlement by match ID
 func (r *matchSettlementRepository) GetByMatchID(ctx context.Context, matchID uuid.UUID) (*models.MatchSettlement, error) {
 	settlement := &models.MatchSettlement{}
 	query := `
-		SELECT match_id, settled_at
+		SELECT match_id, settled_at, burn_reward_table
 		FROM match_settlements 
 		WHERE match_id = $1`
 
-	err := r.db.GetContext(ctx, settlement, query, matchID)
+	err := r.db.reader(ctx).GetContext(ctx, settlement, query, matchID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -16,6 +17,10 @@ type MatchRepository interface {
 	// Create creates a new match
 	Create(ctx context.Context, match *models.Match) error
 
+	// CreateWithParticipants creates a new match together with its participants in one transaction,
+	// so a match is never left without its grid
+	CreateWithParticipants(ctx context.Context, match *models.Match, participants []*models.MatchParticipant) error
+
 	// GetByID retrieves a match by ID
 	GetByID(ctx context.Context, matchID uuid.UUID) (*models.Match, error)
 
@@ -48,41 +53,65 @@ type LeagueStats struct {
 	TotalRakeAmount decimal.Decimal `json:"total_rake_amount"`
 }
 
+// insertMatchQuery inserts a match row from its named fields
+const insertMatchQuery = `
+	INSERT INTO matches (id, league, status, live_player_count, ghost_player_count,
+	                    prize_pool, rake_amount, rake_percentage, crash_seed, crash_seed_hash,
+	                    started_at, completed_at, created_at)
+	VALUES (:id, :league, :status, :live_player_count, :ghost_player_count,
+	        :prize_pool, :rake_amount, :rake_percentage, :crash_seed, :crash_seed_hash,
+	        :started_at, :completed_at, :created_at)`
+
 // matchRepository implements MatchRepository
 type matchRepository struct {
-	db *sqlx.DB
+	db *timeoutDB
 }
 
 // NewMatchRepository creates a new match repository
-func NewMatchRepository(db *sqlx.DB) MatchRepository {
-	return &matchRepository{db: db}
+func NewMatchRepository(db *sqlx.DB, opts ...Option) MatchRepository {
+	return &matchRepository{db: newTimeoutDB(db, opts...)}
 }
 
 // Create creates a new match
 func (r *matchRepository) Create(ctx context.Context, match *models.Match) error {
-	query := `
-		INSERT INTO matches (id, league, status, live_player_count, ghost_player_count,
-		                    prize_pool, rake_amount, crash_seed, crash_seed_hash,
-		                    started_at, completed_at, created_at)
-		VALUES (:id, :league, :status, :live_player_count, :ghost_player_count,
-		        :prize_pool, :rake_amount, :crash_seed, :crash_seed_hash,
-		        :started_at, :completed_at, :created_at)`
-
-	_, err := r.db.NamedExecContext(ctx, query, match)
+	_, err := r.db.NamedExecContext(ctx, insertMatchQuery, match)
 	return err
 }
 
+// CreateWithParticipants creates a new match together with its participants in one transaction
+func (r *matchRepository) CreateWithParticipants(ctx context.Context, match *models.Match, participants []*models.MatchParticipant) error {
+	// The whole transaction shares a single query timeout
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.NamedExecContext(ctx, insertMatchQuery, match); err != nil {
+		return fmt.Errorf("failed to insert match: %w", mapConstraintError(err))
+	}
+
+	if err := insertParticipants(ctx, tx, participants); err != nil {
+		return fmt.Errorf("failed to insert match participants: %w", mapConstraintError(err))
+	}
+
+	return tx.Commit()
+}
+
 // GetByID retrieves a match by ID
 func (r *matchRepository) GetByID(ctx context.Context, matchID uuid.UUID) (*models.Match, error) {
 	match := &models.Match{}
 	query := `
 		SELECT id, league, status, live_player_count, ghost_player_count,
-		       prize_pool, rake_amount, crash_seed, crash_seed_hash,
+		       prize_pool, rake_amount, rake_percentage, crash_seed, crash_seed_hash,
 		       started_at, completed_at, created_at
 		FROM matches 
 		WHERE id = $1`
 
-	err := r.db.GetContext(ctx, match, query, matchID)
+	err := r.db.reader(ctx).GetContext(ctx, match, query, matchID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -119,13 +148,13 @@ func (r *matchRepository) GetActiveMatches(ctx context.Context) ([]*models.Match
 	matches := []*models.Match{}
 	query := `
 		SELECT id, league, status, live_player_count, ghost_player_count,
-		       prize_pool, rake_amount, crash_seed, crash_seed_hash,
+		       prize_pool, rake_amount, rake_percentage, crash_seed, crash_seed_hash,
 		       started_at, completed_at, created_at
 		FROM matches 
 		WHERE status IN ('FORMING', 'IN_PROGRESS')
 		ORDER BY created_at ASC`
 
-	err := r.db.SelectContext(ctx, &matches, query)
+	err := r.db.reader(ctx).SelectContext(ctx, &matches, query)
 	return matches, err
 }
 
@@ -134,7 +163,7 @@ func (r *matchRepository) GetMatchHistory(ctx context.Context, userID uuid.UUID,
 	matches := []*models.Match{}
 	query := `
 		SELECT m.id, m.league, m.status, m.live_player_count, m.ghost_player_count,
-		       m.prize_pool, m.rake_amount, m.crash_seed, m.crash_seed_hash,
+		       m.prize_pool, m.rake_amount, m.rake_percentage, m.crash_seed, m.crash_seed_hash,
 		       m.started_at, m.completed_at, m.created_at
 		FROM matches m
 		INNER JOIN match_participants mp ON m.id = mp.match_id
@@ -142,7 +171,7 @@ func (r *matchRepository) GetMatchHistory(ctx context.Context, userID uuid.UUID,
 		ORDER BY m.created_at DESC
 		LIMIT $2 OFFSET $3`
 
-	err := r.db.SelectContext(ctx, &matches, query, userID, limit, offset)
+	err := r.db.reader(ctx).SelectContext(ctx, &matches, query, userID, limit, offset)
 	return matches, err
 }
 
@@ -161,7 +190,10 @@ func (r *matchRepository) GetLeagueStats(ctx context.Context, league string) (*L
 		FROM matches 
 		WHERE league = $1`
 
-	row := r.db.QueryRowContext(ctx, query, league)
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
+	row := r.db.reader(ctx).QueryRowContext(ctx, query, league)
 	err := row.Scan(
 		&stats.TotalMatches,
 		&stats.ActiveMatches,
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WithReadReplica sends a repository's read-only listing, history and stats queries to a
+// read replica; writes, and reads that guard writes, stay on the primary. A nil replica
+// keeps every query on the primary.
+func WithReadReplica(replica *sqlx.DB) Option {
+	return func(db *timeoutDB) {
+		db.replica = replica
+	}
+}
+
+// primaryReadsKey marks contexts whose reads must see the primary's latest writes
+type primaryReadsKey struct{}
+
+// WithPrimaryReads returns a context whose repository reads go to the primary even when a
+// replica is configured. Flows that read rows they have just written use it, as the replica
+// may lag behind the primary.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// primaryReads reports whether ctx asks for reads that see the primary's latest writes
+func primaryReads(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return primary
+}
+
+// reader returns the handle read-only queries run on: the replica when one is configured,
+// unless ctx asks for primary reads
+func (db *timeoutDB) reader(ctx context.Context) *timeoutDB {
+	if db.replica == nil {
+		return db
+	}
+	if primaryReads(ctx) {
+		return db
+	}
+	return &timeoutDB{DB: db.replica, timeouts: db.timeouts}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/megaherz/ndr/internal/constants"
+)
+
+var errStubQuery = errors.New("stub database")
+
+// recordingConnector counts the statements run on a database; every statement fails
+type recordingConnector struct {
+	mu      sync.Mutex
+	queries int
+}
+
+func (c *recordingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &recordingConn{connector: c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver { return nil }
+
+func (c *recordingConnector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queries
+}
+
+func (c *recordingConnector) record() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries++
+}
+
+type recordingConn struct {
+	connector *recordingConnector
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.connector.record()
+	return nil, errStubQuery
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.connector.record()
+	return nil, errStubQuery
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) { return nil, errStubQuery }
+func (c *recordingConn) Close() error                              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)                 { return nil, errStubQuery }
+
+func newRecordingDB(t *testing.T) (*sqlx.DB, *recordingConnector) {
+	connector := &recordingConnector{}
+	db := sqlx.NewDb(sql.OpenDB(connector), "postgres")
+	t.Cleanup(func() { _ = db.Close() })
+	return db, connector
+}
+
+func TestReadReplica_ReadsUseReplicaWhenProvided(t *testing.T) {
+	primaryDB, primary := newRecordingDB(t)
+	replicaDB, replica := newRecordingDB(t)
+	ctx := context.Background()
+
+	users := NewUserRepository(primaryDB, WithReadReplica(replicaDB))
+	matches := NewMatchRepository(primaryDB, WithReadReplica(replicaDB))
+	participants := NewMatchParticipantRepository(primaryDB, WithReadReplica(replicaDB))
+	ledger := NewLedgerRepository(primaryDB, WithReadReplica(replicaDB))
+
+	_, _ = users.GetByID(ctx, uuid.New())
+	_, _ = users.List(ctx, 10, 0)
+	_, _ = matches.GetMatchHistory(ctx, uuid.New(), 10, 0)
+	_, _ = participants.GetStandings(ctx, uuid.New())
+	_, _ = participants.GetUserStats(ctx, uuid.New())
+	_, _ = ledger.GetUserEntries(ctx, uuid.New(), 10, 0)
+	assert.Equal(t, 6, replica.count())
+	assert.Zero(t, primary.count())
+
+	// Writes and the balance reads that guard them stay on the primary
+	_ = users.BanUser(ctx, uuid.New(), "cheating")
+	_, _ = ledger.GetUserBalance(ctx, uuid.New(), constants.CurrencyFUEL)
+	assert.Equal(t, 2, primary.count())
+	assert.Equal(t, 6, replica.count())
+}
+
+func TestReadReplica_PrimaryReadsBypassReplica(t *testing.T) {
+	primaryDB, primary := newRecordingDB(t)
+	replicaDB, replica := newRecordingDB(t)
+	participants := NewMatchParticipantRepository(primaryDB, WithReadReplica(replicaDB))
+
+	_, _ = participants.GetStandings(WithPrimaryReads(context.Background()), uuid.New())
+	assert.Equal(t, 1, primary.count())
+	assert.Zero(t, replica.count())
+}
+
+func TestReadReplica_NoReplicaReadsFromPrimary(t *testing.T) {
+	primaryDB, primary := newRecordingDB(t)
+	users := NewUserRepository(primaryDB, WithReadReplica(nil))
+
+	_, _ = users.GetByID(context.Background(), uuid.New())
+	assert.Equal(t, 1, primary.count())
+}
+
+func TestGetUserBalance_UnsupportedCurrencySkipsQuery(t *testing.T) {
+	db, primary := newRecordingDB(t)
+	ledger := NewLedgerRepository(db)
+
+	_, err := ledger.GetUserBalance(context.Background(), uuid.New(), "GOLD")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+	assert.Zero(t, primary.count())
+}
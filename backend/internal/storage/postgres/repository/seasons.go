@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/shopspring/decimal"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+// Season errors returned by season writes
+var (
+	ErrSeasonNotFound = errors.New("season not found")
+	ErrSeasonClosed   = errors.New("season already closed")
+)
+
+// SeasonRepository defines the interface for season data access and season-scoped stats.
+// A match counts towards a season when it completed within the season's window.
+type SeasonRepository interface {
+	// Create creates a new season. Only one season may be open at a time; creating a second
+	// open season returns ErrDuplicate.
+	Create(ctx context.Context, season *models.Season) error
+
+	// GetByID retrieves a season by ID
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Season, error)
+
+	// GetCurrent retrieves the open season, nil if there is none
+	GetCurrent(ctx context.Context) (*models.Season, error)
+
+	// Close ends an open season now and returns it as closed
+	Close(ctx context.Context, id uuid.UUID) (*models.Season, error)
+
+	// GetLeaderboard ranks the live players of a season's completed matches by prize money won,
+	// then wins and podiums
+	GetLeaderboard(ctx context.Context, season *models.Season, limit int) ([]*LeaderboardEntry, error)
+
+	// GetUserStats retrieves statistics for a user across a season's completed matches
+	GetUserStats(ctx context.Context, season *models.Season, userID uuid.UUID) (*UserStats, error)
+}
+
+// LeaderboardEntry represents a player's standing in a season
+type LeaderboardEntry struct {
+	Rank            int             `db:"-" json:"rank"`
+	UserID          uuid.UUID       `db:"user_id" json:"user_id"`
+	DisplayName     string          `db:"display_name" json:"display_name"` // Name of the player's latest match
+	TotalMatches    int64           `db:"total_matches" json:"total_matches"`
+	TotalWins       int64           `db:"total_wins" json:"total_wins"`
+	TotalPodiums    int64           `db:"total_podiums" json:"total_podiums"`
+	TotalEarnings   decimal.Decimal `db:"total_earnings" json:"total_earnings"`
+	TotalBurnEarned decimal.Decimal `db:"total_burn_earned" json:"total_burn_earned"`
+}
+
+// seasonMatchesFilter restricts match participants joined as mp to finished live players of
+// matches completed in the season window given as $1 (start) and $2 (end, NULL while open)
+const seasonMatchesFilter = `
+		mp.is_ghost = FALSE
+		AND mp.final_position IS NOT NULL
+		AND m.status = 'COMPLETED'
+		AND m.completed_at >= $1
+		AND ($2::timestamp IS NULL OR m.completed_at < $2)`
+
+// seasonRepository implements SeasonRepository
+type seasonRepository struct {
+	db *timeoutDB
+}
+
+// NewSeasonRepository creates a new season repository
+func NewSeasonRepository(db *sqlx.DB, opts ...Option) SeasonRepository {
+	return &seasonRepository{db: newTimeoutDB(db, opts...)}
+}
+
+// Create creates a new season
+func (r *seasonRepository) Create(ctx context.Context, season *models.Season) error {
+	query := `
+		INSERT INTO seasons (id, name, starts_at, ends_at, created_at)
+		VALUES (:id, :name, :starts_at, :ends_at, :created_at)`
+
+	_, err := r.db.NamedExecContext(ctx, query, season)
+	return mapConstraintError(err)
+}
+
+// GetByID retrieves a season by ID
+func (r *seasonRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Season, error) {
+	season := &models.Season{}
+	query := `
+		SELECT id, name, starts_at, ends_at, created_at
+		FROM seasons
+		WHERE id = $1`
+
+	err := r.db.GetContext(ctx, season, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return season, nil
+}
+
+// GetCurrent retrieves the open season, nil if there is none
+func (r *seasonRepository) GetCurrent(ctx context.Context) (*models.Season, error) {
+	season := &models.Season{}
+	query := `
+		SELECT id, name, starts_at, ends_at, created_at
+		FROM seasons
+		WHERE ends_at IS NULL`
+
+	err := r.db.GetContext(ctx, season, query)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return season, nil
+}
+
+// Close ends an open season now and returns it as closed
+func (r *seasonRepository) Close(ctx context.Context, id uuid.UUID) (*models.Season, error) {
+	// The end never precedes the start, even when the application and database clocks disagree
+	season := &models.Season{}
+	query := `
+		UPDATE seasons
+		SET ends_at = GREATEST(NOW(), starts_at + INTERVAL '1 microsecond')
+		WHERE id = $1 AND ends_at IS NULL
+		RETURNING id, name, starts_at, ends_at, created_at`
+
+	err := r.db.GetContext(ctx, season, query, id)
+	if err == nil {
+		return season, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to close season: %w", err)
+	}
+
+	// Nothing was updated: tell a missing season from one that is already closed
+	existing, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get season: %w", err)
+	}
+	if existing == nil {
+		return nil, ErrSeasonNotFound
+	}
+	return nil, ErrSeasonClosed
+}
+
+// GetLeaderboard ranks the live players of a season's completed matches
+func (r *seasonRepository) GetLeaderboard(ctx context.Context, season *models.Season, limit int) ([]*LeaderboardEntry, error) {
+	entries := []*LeaderboardEntry{}
+	query := `
+		SELECT
+			mp.user_id,
+			(ARRAY_AGG(mp.player_display_name ORDER BY m.completed_at DESC))[1] AS display_name,
+			COUNT(*) AS total_matches,
+			COUNT(*) FILTER (WHERE mp.final_position = 1) AS total_wins,
+			COUNT(*) FILTER (WHERE mp.final_position <= 3) AS total_podiums,
+			COALESCE(SUM(mp.prize_amount), 0) AS total_earnings,
+			COALESCE(SUM(mp.burn_reward), 0) AS total_burn_earned
+		FROM match_participants mp
+		JOIN matches m ON m.id = mp.match_id
+		WHERE` + seasonMatchesFilter + `
+		GROUP BY mp.user_id
+		ORDER BY total_earnings DESC, total_wins DESC, total_podiums DESC, mp.user_id ASC
+		LIMIT $3`
+
+	err := r.db.reader(ctx).SelectContext(ctx, &entries, query, season.StartsAt, season.EndsAt, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, entry := range entries {
+		entry.Rank = i + 1
+	}
+	return entries, nil
+}
+
+// GetUserStats retrieves statistics for a user across a season's completed matches
+func (r *seasonRepository) GetUserStats(ctx context.Context, season *models.Season, userID uuid.UUID) (*UserStats, error) {
+	stats := &UserStats{UserID: userID}
+
+	query := `
+		SELECT
+			COUNT(*) as total_matches,
+			COUNT(CASE WHEN mp.final_position = 1 THEN 1 END) as total_wins,
+			COUNT(CASE WHEN mp.final_position <= 3 THEN 1 END) as total_podiums,
+			COALESCE(SUM(mp.prize_amount), 0) as total_earnings,
+			COALESCE(SUM(mp.burn_reward), 0) as total_burn_earned,
+			COALESCE(AVG(mp.final_position), 0) as avg_position,
+			COALESCE(MIN(mp.final_position), 0) as best_position,
+			COALESCE(MAX(mp.final_position), 0) as worst_position
+		FROM match_participants mp
+		JOIN matches m ON m.id = mp.match_id
+		WHERE mp.user_id = $3 AND` + seasonMatchesFilter
+
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
+	row := r.db.reader(ctx).QueryRowContext(ctx, query, season.StartsAt, season.EndsAt, userID)
+	err := row.Scan(
+		&stats.TotalMatches,
+		&stats.TotalWins,
+		&stats.TotalPodiums,
+		&stats.TotalEarnings,
+		&stats.TotalBurnEarned,
+		&stats.AvgPosition,
+		&stats.BestPosition,
+		&stats.WorstPosition,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
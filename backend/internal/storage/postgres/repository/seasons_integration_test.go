@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/megaherz/ndr/internal/storage/postgres/models"
+)
+
+type SeasonRepositoryIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper        *TestDBHelper
+	seasonRepo      SeasonRepository
+	matchRepo       MatchRepository
+	participantRepo MatchParticipantRepository
+	userRepo        UserRepository
+}
+
+func TestSeasonRepositoryIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(SeasonRepositoryIntegrationTestSuite))
+}
+
+func (suite *SeasonRepositoryIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+
+	suite.seasonRepo = NewSeasonRepository(suite.dbHelper.DB)
+	suite.matchRepo = NewMatchRepository(suite.dbHelper.DB)
+	suite.participantRepo = NewMatchParticipantRepository(suite.dbHelper.DB)
+	suite.userRepo = NewUserRepository(suite.dbHelper.DB)
+}
+
+func (suite *SeasonRepositoryIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *SeasonRepositoryIntegrationTestSuite) SetupTest() {
+	suite.dbHelper.CleanupTables("seasons", "match_participants", "matches", "users")
+}
+
+func (suite *SeasonRepositoryIntegrationTestSuite) createUser(telegramID int64) uuid.UUID {
+	user := &models.User{
+		ID:                uuid.New(),
+		TelegramID:        telegramID,
+		TelegramFirstName: "Racer",
+		CreatedAt:         time.Now().UTC(),
+		UpdatedAt:         time.Now().UTC(),
+	}
+	require.NoError(suite.T(), suite.userRepo.Create(context.Background(), user))
+	return user.ID
+}
+
+func (suite *SeasonRepositoryIntegrationTestSuite) createSeason(name string, startsAt time.Time, endsAt *time.Time) *models.Season {
+	season := &models.Season{
+		ID:        uuid.New(),
+		Name:      name,
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		CreatedAt: time.Now().UTC(),
+	}
+	require.NoError(suite.T(), suite.seasonRepo.Create(context.Background(), season))
+	return season
+}
+
+// seasonResult is a live player's finish in a match
+type seasonResult struct {
+	userID   uuid.UUID
+	position int
+	prize    int64
+}
+
+// createFinishedMatch records a match with the given status and completion time and its players' finishes
+func (suite *SeasonRepositoryIntegrationTestSuite) createFinishedMatch(status models.MatchStatus, completedAt time.Time, results ...seasonResult) {
+	ctx := context.Background()
+	startedAt := completedAt.Add(-time.Minute)
+	match := &models.Match{
+		ID:               uuid.New(),
+		League:           models.LeagueRookie,
+		Status:           status,
+		LivePlayerCount:  10,
+		GhostPlayerCount: 0,
+		PrizePool:        decimal.NewFromInt(92),
+		RakeAmount:       decimal.NewFromInt(8),
+		CrashSeed:        "test-crash-seed",
+		CrashSeedHash:    "test-crash-seed-hash",
+		StartedAt:        &startedAt,
+		CompletedAt:      &completedAt,
+		CreatedAt:        startedAt,
+	}
+	require.NoError(suite.T(), suite.matchRepo.Create(ctx, match))
+
+	for _, result := range results {
+		userID := result.userID
+		position := result.position
+		require.NoError(suite.T(), suite.participantRepo.Create(ctx, &models.MatchParticipant{
+			MatchID:           match.ID,
+			UserID:            &userID,
+			PlayerDisplayName: "Racer",
+			BuyinAmount:       decimal.NewFromInt(10),
+			FinalPosition:     &position,
+			PrizeAmount:       decimal.NewFromInt(result.prize),
+			CreatedAt:         startedAt,
+		}))
+	}
+}
+
+func (suite *SeasonRepositoryIntegrationTestSuite) TestLeaderboard_CountsOnlyMatchesInSeason() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	day := 24 * time.Hour
+
+	previousEnd := now.Add(-10 * day)
+	previous := suite.createSeason("Season 1", now.Add(-30*day), &previousEnd)
+	current := suite.createSeason("Season 2", previousEnd, nil)
+
+	alice, bob, carol := suite.createUser(1001), suite.createUser(1002), suite.createUser(1003)
+
+	// Before the current season: only counts towards the previous one
+	suite.createFinishedMatch(models.MatchStatusCompleted, now.Add(-20*day),
+		seasonResult{userID: alice, position: 1, prize: 100})
+	// During the current season
+	suite.createFinishedMatch(models.MatchStatusCompleted, now.Add(-5*day),
+		seasonResult{userID: bob, position: 1, prize: 50},
+		seasonResult{userID: alice, position: 2, prize: 20})
+	suite.createFinishedMatch(models.MatchStatusCompleted, now.Add(-day),
+		seasonResult{userID: alice, position: 1, prize: 40},
+		seasonResult{userID: carol, position: 3, prize: 5})
+	// Aborted matches never count, even within the window
+	suite.createFinishedMatch(models.MatchStatusAborted, now.Add(-2*day),
+		seasonResult{userID: carol, position: 1, prize: 500})
+
+	leaderboard, err := suite.seasonRepo.GetLeaderboard(ctx, current, 10)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), leaderboard, 3)
+
+	assert.Equal(suite.T(), alice, leaderboard[0].UserID)
+	assert.Equal(suite.T(), 1, leaderboard[0].Rank)
+	assert.Equal(suite.T(), int64(2), leaderboard[0].TotalMatches)
+	assert.Equal(suite.T(), int64(1), leaderboard[0].TotalWins)
+	assert.Equal(suite.T(), int64(2), leaderboard[0].TotalPodiums)
+	assert.True(suite.T(), leaderboard[0].TotalEarnings.Equal(decimal.NewFromInt(60)))
+	assert.Equal(suite.T(), "Racer", leaderboard[0].DisplayName)
+
+	assert.Equal(suite.T(), bob, leaderboard[1].UserID)
+	assert.Equal(suite.T(), 2, leaderboard[1].Rank)
+	assert.True(suite.T(), leaderboard[1].TotalEarnings.Equal(decimal.NewFromInt(50)))
+
+	assert.Equal(suite.T(), carol, leaderboard[2].UserID)
+	assert.Equal(suite.T(), int64(1), leaderboard[2].TotalMatches)
+	assert.True(suite.T(), leaderboard[2].TotalEarnings.Equal(decimal.NewFromInt(5)))
+
+	top, err := suite.seasonRepo.GetLeaderboard(ctx, current, 1)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), top, 1)
+	assert.Equal(suite.T(), alice, top[0].UserID)
+
+	// The previous season only sees the match completed within its window
+	leaderboard, err = suite.seasonRepo.GetLeaderboard(ctx, previous, 10)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), leaderboard, 1)
+	assert.Equal(suite.T(), alice, leaderboard[0].UserID)
+	assert.True(suite.T(), leaderboard[0].TotalEarnings.Equal(decimal.NewFromInt(100)))
+
+	stats, err := suite.seasonRepo.GetUserStats(ctx, current, alice)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(2), stats.TotalMatches)
+	assert.Equal(suite.T(), int64(1), stats.TotalWins)
+	assert.Equal(suite.T(), 1, stats.BestPosition)
+	assert.Equal(suite.T(), 2, stats.WorstPosition)
+	assert.True(suite.T(), stats.TotalEarnings.Equal(decimal.NewFromInt(60)))
+
+	stats, err = suite.seasonRepo.GetUserStats(ctx, previous, carol)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(0), stats.TotalMatches)
+}
+
+func (suite *SeasonRepositoryIntegrationTestSuite) TestClose_EndsTheOpenSeason() {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	season := suite.createSeason("Season 1", now.Add(-time.Hour), nil)
+
+	current, err := suite.seasonRepo.GetCurrent(ctx)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), current)
+	assert.Equal(suite.T(), season.ID, current.ID)
+
+	// Only one season may be open at a time
+	err = suite.seasonRepo.Create(ctx, &models.Season{ID: uuid.New(), Name: "Season 2", StartsAt: now, CreatedAt: now})
+	assert.ErrorIs(suite.T(), err, ErrDuplicate)
+
+	closed, err := suite.seasonRepo.Close(ctx, season.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), closed.EndsAt)
+	assert.True(suite.T(), closed.EndsAt.After(closed.StartsAt))
+	assert.False(suite.T(), closed.IsOpen())
+
+	current, err = suite.seasonRepo.GetCurrent(ctx)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), current)
+
+	_, err = suite.seasonRepo.Close(ctx, season.ID)
+	assert.ErrorIs(suite.T(), err, ErrSeasonClosed)
+	_, err = suite.seasonRepo.Close(ctx, uuid.New())
+	assert.ErrorIs(suite.T(), err, ErrSeasonNotFound)
+
+	// The next season can start once the previous one is closed
+	suite.createSeason("Season 2", now, nil)
+}
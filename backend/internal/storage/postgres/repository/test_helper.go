@@ -4,10 +4,7 @@ import (
 	"fmt"
 	"io/fs"
 	"log"
-	"os"
-	"path/filepath"
 	"sort"
-	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +13,8 @@ import (
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
 	"github.com/stretchr/testify/require"
+
+	"github.com/megaherz/ndr/internal/storage/postgres"
 )
 
 // TestDBHelper provides shared database setup and teardown for integration tests
@@ -101,21 +100,13 @@ func (h *TestDBHelper) CleanupTables(tables ...string) {
 	}
 }
 
-// applyMigrations reads and applies all migration files in the correct order
+// applyMigrations reads and applies all embedded migration files in the correct order,
+// so the helper works from any package directory
 func (h *TestDBHelper) applyMigrations() error {
-	migrationsPath := "../migrations"
+	migrations := postgres.Migrations()
 
 	// Read migration files
-	var migrationFiles []string
-	err := filepath.WalkDir(migrationsPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if !d.IsDir() && strings.HasSuffix(d.Name(), ".up.sql") {
-			migrationFiles = append(migrationFiles, path)
-		}
-		return nil
-	})
+	migrationFiles, err := fs.Glob(migrations, "*.up.sql")
 	if err != nil {
 		return fmt.Errorf("failed to read migrations directory: %w", err)
 	}
@@ -127,7 +118,7 @@ func (h *TestDBHelper) applyMigrations() error {
 	for _, migrationFile := range migrationFiles {
 		log.Printf("Applying migration: %s", migrationFile)
 
-		content, err := os.ReadFile(migrationFile)
+		content, err := fs.ReadFile(migrations, migrationFile)
 		if err != nil {
 			return fmt.Errorf("failed to read migration file %s: %w", migrationFile, err)
 		}
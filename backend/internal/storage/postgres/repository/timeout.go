@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Default query timeouts, kept well below the HTTP request timeout
+const (
+	DefaultQueryTimeout    = 10 * time.Second
+	DefaultMaxQueryTimeout = 30 * time.Second
+)
+
+// QueryTimeouts bounds how long a single repository query may run
+type QueryTimeouts struct {
+	// Default is applied when the caller's context has no deadline
+	Default time.Duration
+
+	// Max caps caller-provided deadlines so no query can outlive it
+	Max time.Duration
+}
+
+// DefaultQueryTimeouts returns the timeouts used when none are configured
+func DefaultQueryTimeouts() QueryTimeouts {
+	return QueryTimeouts{
+		Default: DefaultQueryTimeout,
+		Max:     DefaultMaxQueryTimeout,
+	}
+}
+
+// Option configures a repository
+type Option func(*timeoutDB)
+
+// WithQueryTimeouts overrides the per-query timeouts of a repository.
+// Zero values fall back to the defaults.
+func WithQueryTimeouts(timeouts QueryTimeouts) Option {
+	return func(db *timeoutDB) {
+		if timeouts.Default > 0 {
+			db.timeouts.Default = timeouts.Default
+		}
+		if timeouts.Max > 0 {
+			db.timeouts.Max = timeouts.Max
+		}
+	}
+}
+
+// timeoutDB wraps sqlx.DB so every query runs under a bounded context
+type timeoutDB struct {
+	*sqlx.DB
+	timeouts QueryTimeouts
+	replica  *sqlx.DB
+}
+
+// newTimeoutDB wraps db with the default query timeouts and applies opts
+func newTimeoutDB(db *sqlx.DB, opts ...Option) *timeoutDB {
+	tdb := &timeoutDB{DB: db, timeouts: DefaultQueryTimeouts()}
+	for _, opt := range opts {
+		opt(tdb)
+	}
+	return tdb
+}
+
+// withTimeout derives a query context from ctx. Contexts without a deadline get
+// the default timeout; deadlines further away than the max are shortened to it.
+func (db *timeoutDB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := db.timeouts.Default
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining <= db.timeouts.Max {
+			return context.WithCancel(ctx)
+		}
+		timeout = db.timeouts.Max
+	}
+
+	if timeout > db.timeouts.Max {
+		timeout = db.timeouts.Max
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// GetContext runs a single-row query under the query timeout
+func (db *timeoutDB) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return timeoutError(ctx, db.DB.GetContext(ctx, dest, query, args...))
+}
+
+// SelectContext runs a multi-row query under the query timeout
+func (db *timeoutDB) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	return timeoutError(ctx, db.DB.SelectContext(ctx, dest, query, args...))
+}
+
+// ExecContext runs a statement under the query timeout
+func (db *timeoutDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	result, err := db.DB.ExecContext(ctx, query, args...)
+	return result, timeoutError(ctx, err)
+}
+
+// NamedExecContext runs a named statement under the query timeout
+func (db *timeoutDB) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+	result, err := db.DB.NamedExecContext(ctx, query, arg)
+	return result, timeoutError(ctx, err)
+}
+
+// timeoutError makes an expired query context visible through errors.Is, since
+// the driver may report it as a canceled statement instead
+func timeoutError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil && !errors.Is(err, ctx.Err()) {
+		return fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+	return err
+}
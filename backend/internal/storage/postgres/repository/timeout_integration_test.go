@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type QueryTimeoutIntegrationTestSuite struct {
+	suite.Suite
+	dbHelper *TestDBHelper
+}
+
+func TestQueryTimeoutIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(QueryTimeoutIntegrationTestSuite))
+}
+
+func (suite *QueryTimeoutIntegrationTestSuite) SetupSuite() {
+	suite.dbHelper = NewTestDBHelper(suite.T())
+	suite.dbHelper.SetupDatabase()
+}
+
+func (suite *QueryTimeoutIntegrationTestSuite) TearDownSuite() {
+	suite.dbHelper.TeardownDatabase()
+}
+
+func (suite *QueryTimeoutIntegrationTestSuite) TestSlowQueryTimesOut() {
+	db := newTimeoutDB(suite.dbHelper.DB, WithQueryTimeouts(QueryTimeouts{
+		Default: 200 * time.Millisecond,
+		Max:     time.Second,
+	}))
+
+	var result string
+	start := time.Now()
+	err := db.GetContext(context.Background(), &result, `SELECT pg_sleep(5)::text`)
+	elapsed := time.Since(start)
+
+	assert.Error(suite.T(), err)
+	assert.ErrorIs(suite.T(), err, context.DeadlineExceeded)
+	assert.Less(suite.T(), elapsed, 2*time.Second)
+}
+
+func (suite *QueryTimeoutIntegrationTestSuite) TestCallerDeadlineIsCappedByMax() {
+	db := newTimeoutDB(suite.dbHelper.DB, WithQueryTimeouts(QueryTimeouts{
+		Default: 200 * time.Millisecond,
+		Max:     500 * time.Millisecond,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	_, err := db.ExecContext(ctx, `SELECT pg_sleep(5)`)
+
+	assert.ErrorIs(suite.T(), err, context.DeadlineExceeded)
+	assert.Less(suite.T(), time.Since(start), 2*time.Second)
+}
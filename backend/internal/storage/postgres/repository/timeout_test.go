@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTimeoutDB(timeouts QueryTimeouts) *timeoutDB {
+	return newTimeoutDB(nil, WithQueryTimeouts(timeouts))
+}
+
+func TestTimeoutDB_AppliesDefaultWithoutDeadline(t *testing.T) {
+	db := newTestTimeoutDB(QueryTimeouts{Default: 2 * time.Second, Max: 5 * time.Second})
+
+	ctx, cancel := db.withTimeout(context.Background())
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), deadline, 100*time.Millisecond)
+}
+
+func TestTimeoutDB_KeepsShorterCallerDeadline(t *testing.T) {
+	db := newTestTimeoutDB(QueryTimeouts{Default: 2 * time.Second, Max: 5 * time.Second})
+
+	parent, parentCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer parentCancel()
+	parentDeadline, _ := parent.Deadline()
+
+	ctx, cancel := db.withTimeout(parent)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.Equal(t, parentDeadline, deadline)
+}
+
+func TestTimeoutDB_CapsLongCallerDeadline(t *testing.T) {
+	db := newTestTimeoutDB(QueryTimeouts{Default: 2 * time.Second, Max: 5 * time.Second})
+
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer parentCancel()
+
+	ctx, cancel := db.withTimeout(parent)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(5*time.Second), deadline, 100*time.Millisecond)
+}
+
+func TestTimeoutDB_DefaultNeverExceedsMax(t *testing.T) {
+	db := newTestTimeoutDB(QueryTimeouts{Default: time.Minute, Max: 3 * time.Second})
+
+	ctx, cancel := db.withTimeout(context.Background())
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(3*time.Second), deadline, 100*time.Millisecond)
+}
+
+func TestWithQueryTimeouts_ZeroValuesKeepDefaults(t *testing.T) {
+	db := newTestTimeoutDB(QueryTimeouts{})
+
+	assert.Equal(t, DefaultQueryTimeouts(), db.timeouts)
+}
+
+func TestTimeoutError_WrapsExpiredContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	err := timeoutError(ctx, errors.New("pq: canceling statement due to user request"))
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "canceling statement")
+	assert.NoError(t, timeoutError(ctx, nil))
+}
@@ -3,10 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
 
 	"github.com/megaherz/ndr/internal/storage/postgres/models"
 )
@@ -23,38 +27,68 @@ type UserRepository interface {
 	GetByTelegramID(ctx context.Context, telegramID int64) (*models.User, error)
 
 	// UpdateTelegramInfo updates user's Telegram information
-	UpdateTelegramInfo(ctx context.Context, userID uuid.UUID, username, firstName, lastName, photoURL string) error
+	UpdateTelegramInfo(ctx context.Context, userID uuid.UUID, username, firstName, lastName, photoURL, languageCode string, isPremium bool) error
 
 	// GetOrCreateByTelegramID gets an existing user or creates a new one
-	GetOrCreateByTelegramID(ctx context.Context, telegramID int64, username, firstName, lastName, photoURL string) (*models.User, error)
+	GetOrCreateByTelegramID(ctx context.Context, telegramID int64, username, firstName, lastName, photoURL, languageCode string, isPremium bool) (*models.User, error)
 
-	// List retrieves users with pagination
+	// GetOrCreateWithWallet gets an existing user or creates a new one together with their wallet,
+	// credited with signupGrant FUEL and its ledger entry, in one transaction.
+	// It reports whether this call created the user.
+	GetOrCreateWithWallet(ctx context.Context, telegramID int64, username, firstName, lastName, photoURL, languageCode string, isPremium bool, signupGrant decimal.Decimal) (*models.User, bool, error)
+
+	// BanUser bans a user with a reason, keeping the original ban time if already banned.
+	// Returns ErrUserNotFound if the user does not exist.
+	BanUser(ctx context.Context, userID uuid.UUID, reason string) error
+
+	// UnbanUser lifts a user's ban. Returns ErrUserNotFound if the user does not exist.
+	UnbanUser(ctx context.Context, userID uuid.UUID) error
+
+	// SetReplayOptOut sets whether a user's runs are kept from being recorded as ghost replays.
+	// Returns ErrUserNotFound if the user does not exist.
+	SetReplayOptOut(ctx context.Context, userID uuid.UUID, optOut bool) error
+
+	// GetReplayOptOuts returns which of the given users opted out of replay recording.
+	// Users who did not opt out or do not exist are left out of the map.
+	GetReplayOptOuts(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+
+	// List retrieves users with offset pagination, newest first
 	List(ctx context.Context, limit, offset int) ([]*models.User, error)
 
+	// ListAfter retrieves users with keyset pagination, newest first. afterCreatedAt and afterID are
+	// the created_at and ID of the last user of the previous page; a zero afterCreatedAt starts
+	// from the newest user. Unlike List, pages neither skip nor repeat users inserted meanwhile.
+	ListAfter(ctx context.Context, afterCreatedAt time.Time, afterID uuid.UUID, limit int) ([]*models.User, error)
+
 	// Count returns the total number of users
 	Count(ctx context.Context) (int64, error)
 }
 
+// ErrUserNotFound is returned by user writes that target a user that does not exist
+var ErrUserNotFound = errors.New("user not found")
+
 // userRepository implements UserRepository
 type userRepository struct {
-	db *sqlx.DB
+	db *timeoutDB
 }
 
 // NewUserRepository creates a new user repository
-func NewUserRepository(db *sqlx.DB) UserRepository {
-	return &userRepository{db: db}
+func NewUserRepository(db *sqlx.DB, opts ...Option) UserRepository {
+	return &userRepository{db: newTimeoutDB(db, opts...)}
 }
 
 // Create creates a new user
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
 		INSERT INTO users (id, telegram_id, telegram_username, telegram_first_name, 
-		                  telegram_last_name, telegram_photo_url, created_at, updated_at)
+		                  telegram_last_name, telegram_photo_url, telegram_language_code,
+		                  telegram_is_premium, created_at, updated_at)
 		VALUES (:id, :telegram_id, :telegram_username, :telegram_first_name, 
-		        :telegram_last_name, :telegram_photo_url, :created_at, :updated_at)`
+		        :telegram_last_name, :telegram_photo_url, :telegram_language_code,
+		        :telegram_is_premium, :created_at, :updated_at)`
 
 	_, err := r.db.NamedExecContext(ctx, query, user)
-	return err
+	return mapConstraintError(err)
 }
 
 // GetByID retrieves a user by ID
@@ -62,11 +96,12 @@ func (r *userRepository) GetByID(ctx context.Context, userID uuid.UUID) (*models
 	user := &models.User{}
 	query := `
 		SELECT id, telegram_id, telegram_username, telegram_first_name, 
-		       telegram_last_name, telegram_photo_url, created_at, updated_at
+		       telegram_last_name, telegram_photo_url, telegram_language_code,
+		       telegram_is_premium, banned_at, banned_reason, replay_opt_out, created_at, updated_at
 		FROM users 
 		WHERE id = $1`
 
-	err := r.db.GetContext(ctx, user, query, userID)
+	err := r.db.reader(ctx).GetContext(ctx, user, query, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -82,11 +117,12 @@ func (r *userRepository) GetByTelegramID(ctx context.Context, telegramID int64)
 	user := &models.User{}
 	query := `
 		SELECT id, telegram_id, telegram_username, telegram_first_name, 
-		       telegram_last_name, telegram_photo_url, created_at, updated_at
+		       telegram_last_name, telegram_photo_url, telegram_language_code,
+		       telegram_is_premium, banned_at, banned_reason, replay_opt_out, created_at, updated_at
 		FROM users 
 		WHERE telegram_id = $1`
 
-	err := r.db.GetContext(ctx, user, query, telegramID)
+	err := r.db.reader(ctx).GetContext(ctx, user, query, telegramID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -98,13 +134,15 @@ func (r *userRepository) GetByTelegramID(ctx context.Context, telegramID int64)
 }
 
 // UpdateTelegramInfo updates user's Telegram information
-func (r *userRepository) UpdateTelegramInfo(ctx context.Context, userID uuid.UUID, username, firstName, lastName, photoURL string) error {
+func (r *userRepository) UpdateTelegramInfo(ctx context.Context, userID uuid.UUID, username, firstName, lastName, photoURL, languageCode string, isPremium bool) error {
 	query := `
 		UPDATE users 
 		SET telegram_username = $2, 
 		    telegram_first_name = $3, 
 		    telegram_last_name = $4,
 		    telegram_photo_url = $5,
+		    telegram_language_code = $6,
+		    telegram_is_premium = $7,
 		    updated_at = NOW()
 		WHERE id = $1`
 
@@ -112,31 +150,53 @@ func (r *userRepository) UpdateTelegramInfo(ctx context.Context, userID uuid.UUI
 		sql.NullString{String: username, Valid: username != ""},
 		firstName,
 		sql.NullString{String: lastName, Valid: lastName != ""},
-		sql.NullString{String: photoURL, Valid: photoURL != ""})
+		sql.NullString{String: photoURL, Valid: photoURL != ""},
+		sql.NullString{String: languageCode, Valid: languageCode != ""},
+		isPremium)
 	return err
 }
 
 // GetOrCreateByTelegramID gets an existing user or creates a new one
-func (r *userRepository) GetOrCreateByTelegramID(ctx context.Context, telegramID int64, username, firstName, lastName, photoURL string) (*models.User, error) {
+func (r *userRepository) GetOrCreateByTelegramID(ctx context.Context, telegramID int64, username, firstName, lastName, photoURL, languageCode string, isPremium bool) (*models.User, error) {
+	user, _, err := r.getOrCreate(ctx, telegramID, username, firstName, lastName, photoURL, languageCode, isPremium, func(newUser *models.User) error {
+		return r.Create(ctx, newUser)
+	})
+	return user, err
+}
+
+// GetOrCreateWithWallet gets an existing user or creates a new one together with their wallet
+func (r *userRepository) GetOrCreateWithWallet(ctx context.Context, telegramID int64, username, firstName, lastName, photoURL, languageCode string, isPremium bool, signupGrant decimal.Decimal) (*models.User, bool, error) {
+	return r.getOrCreate(ctx, telegramID, username, firstName, lastName, photoURL, languageCode, isPremium, func(newUser *models.User) error {
+		return r.createWithWallet(ctx, newUser, signupGrant)
+	})
+}
+
+// getOrCreate refreshes an existing user's Telegram info or creates the user with create.
+// It reports whether this call created the user.
+func (r *userRepository) getOrCreate(ctx context.Context, telegramID int64, username, firstName, lastName, photoURL, languageCode string, isPremium bool, create func(*models.User) error) (*models.User, bool, error) {
+	// A lagging replica could miss a just-created user and make this create a duplicate
+	ctx = WithPrimaryReads(ctx)
+
 	// First, try to get existing user
 	existingUser, err := r.GetByTelegramID(ctx, telegramID)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	if existingUser != nil {
 		// Update Telegram info in case it changed
-		err = r.UpdateTelegramInfo(ctx, existingUser.ID, username, firstName, lastName, photoURL)
+		err = r.UpdateTelegramInfo(ctx, existingUser.ID, username, firstName, lastName, photoURL, languageCode, isPremium)
 		if err != nil {
-			return nil, err
+			return nil, false, err
 		}
 
 		// Return updated user data
-		return r.GetByID(ctx, existingUser.ID)
+		user, err := r.GetByID(ctx, existingUser.ID)
+		return user, false, err
 	}
 
 	// User doesn't exist, create new one
-	var usernamePtr, lastNamePtr, photoURLPtr *string
+	var usernamePtr, lastNamePtr, photoURLPtr, languageCodePtr *string
 	if username != "" {
 		usernamePtr = &username
 	}
@@ -146,24 +206,169 @@ func (r *userRepository) GetOrCreateByTelegramID(ctx context.Context, telegramID
 	if photoURL != "" {
 		photoURLPtr = &photoURL
 	}
+	if languageCode != "" {
+		languageCodePtr = &languageCode
+	}
 
 	newUser := &models.User{
-		ID:                uuid.New(),
-		TelegramID:        telegramID,
-		TelegramUsername:  usernamePtr,
-		TelegramFirstName: firstName,
-		TelegramLastName:  lastNamePtr,
-		TelegramPhotoURL:  photoURLPtr,
-		CreatedAt:         time.Now(),
-		UpdatedAt:         time.Now(),
+		ID:                   uuid.New(),
+		TelegramID:           telegramID,
+		TelegramUsername:     usernamePtr,
+		TelegramFirstName:    firstName,
+		TelegramLastName:     lastNamePtr,
+		TelegramPhotoURL:     photoURLPtr,
+		TelegramLanguageCode: languageCodePtr,
+		TelegramIsPremium:    isPremium,
+		CreatedAt:            time.Now(),
+		UpdatedAt:            time.Now(),
 	}
 
-	err = r.Create(ctx, newUser)
+	err = create(newUser)
+	if errors.Is(err, ErrDuplicate) {
+		// A concurrent first login created the user first
+		user, err := r.GetByTelegramID(ctx, telegramID)
+		return user, false, err
+	}
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	return newUser, true, nil
+}
+
+// createWithWallet inserts a user, their wallet and the signup grant ledger entry in one transaction
+func (r *userRepository) createWithWallet(ctx context.Context, user *models.User, signupGrant decimal.Decimal) error {
+	// The whole transaction shares a single query timeout
+	ctx, cancel := r.db.withTimeout(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	userQuery := `
+		INSERT INTO users (id, telegram_id, telegram_username, telegram_first_name,
+		                  telegram_last_name, telegram_photo_url, telegram_language_code,
+		                  telegram_is_premium, created_at, updated_at)
+		VALUES (:id, :telegram_id, :telegram_username, :telegram_first_name,
+		        :telegram_last_name, :telegram_photo_url, :telegram_language_code,
+		        :telegram_is_premium, :created_at, :updated_at)`
+
+	if _, err := tx.NamedExecContext(ctx, userQuery, user); err != nil {
+		return mapConstraintError(err)
+	}
+
+	walletQuery := `
+		INSERT INTO wallets (user_id, ton_balance, fuel_balance, burn_balance,
+		                    rookie_races_completed, created_at, updated_at)
+		VALUES ($1, 0, $2, 0, 0, $3, $3)`
+
+	if _, err := tx.ExecContext(ctx, walletQuery, user.ID, signupGrant, user.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create wallet: %w", mapConstraintError(err))
+	}
+
+	// The wallet starts with the grant, so the ledger entry's running balance equals it
+	if signupGrant.IsPositive() {
+		ledgerQuery := `
+			INSERT INTO ledger_entries (user_id, currency, amount, operation_type,
+			                           description, balance_after, created_at)
+			VALUES ($1, $2, $3, $4, 'Signup FUEL grant', $3, $5)`
+
+		_, err := tx.ExecContext(ctx, ledgerQuery, user.ID, models.CurrencyFUEL, signupGrant, models.OperationSignupGrant, user.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to record signup grant: %w", mapConstraintError(err))
+		}
+	}
+
+	return tx.Commit()
+}
+
+// BanUser bans a user with a reason
+func (r *userRepository) BanUser(ctx context.Context, userID uuid.UUID, reason string) error {
+	query := `
+		UPDATE users
+		SET banned_at = COALESCE(banned_at, NOW()),
+		    banned_reason = $2,
+		    updated_at = NOW()
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, userID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to ban user: %w", err)
+	}
+	return requireUserAffected(result)
+}
+
+// UnbanUser lifts a user's ban
+func (r *userRepository) UnbanUser(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE users
+		SET banned_at = NULL,
+		    banned_reason = NULL,
+		    updated_at = NOW()
+		WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unban user: %w", err)
 	}
+	return requireUserAffected(result)
+}
+
+// SetReplayOptOut sets whether a user's runs are kept from being recorded as ghost replays
+func (r *userRepository) SetReplayOptOut(ctx context.Context, userID uuid.UUID, optOut bool) error {
+	query := `
+		UPDATE users
+		SET replay_opt_out = $2,
+		    updated_at = NOW()
+		WHERE id = $1`
 
-	return newUser, nil
+	result, err := r.db.ExecContext(ctx, query, userID, optOut)
+	if err != nil {
+		return fmt.Errorf("failed to set replay opt-out: %w", err)
+	}
+	return requireUserAffected(result)
+}
+
+// GetReplayOptOuts returns which of the given users opted out of replay recording
+func (r *userRepository) GetReplayOptOuts(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	optOuts := make(map[uuid.UUID]bool)
+	if len(userIDs) == 0 {
+		return optOuts, nil
+	}
+
+	ids := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = id.String()
+	}
+
+	var optedOut []uuid.UUID
+	query := `
+		SELECT id
+		FROM users
+		WHERE id = ANY($1::uuid[]) AND replay_opt_out`
+
+	if err := r.db.reader(ctx).SelectContext(ctx, &optedOut, query, pq.Array(ids)); err != nil {
+		return nil, fmt.Errorf("failed to get replay opt-outs: %w", err)
+	}
+	for _, id := range optedOut {
+		optOuts[id] = true
+	}
+	return optOuts, nil
+}
+
+// requireUserAffected returns ErrUserNotFound if an update matched no user
+func requireUserAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
 }
 
 // List retrieves users with pagination
@@ -171,12 +376,42 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*models
 	users := []*models.User{}
 	query := `
 		SELECT id, telegram_id, telegram_username, telegram_first_name, 
-		       telegram_last_name, telegram_photo_url, created_at, updated_at
+		       telegram_last_name, telegram_photo_url, telegram_language_code,
+		       telegram_is_premium, banned_at, banned_reason, replay_opt_out, created_at, updated_at
 		FROM users 
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 		LIMIT $1 OFFSET $2`
 
-	err := r.db.SelectContext(ctx, &users, query, limit, offset)
+	err := r.db.reader(ctx).SelectContext(ctx, &users, query, limit, offset)
+	return users, err
+}
+
+// ListAfter retrieves users with keyset pagination
+func (r *userRepository) ListAfter(ctx context.Context, afterCreatedAt time.Time, afterID uuid.UUID, limit int) ([]*models.User, error) {
+	users := []*models.User{}
+	if afterCreatedAt.IsZero() {
+		query := `
+			SELECT id, telegram_id, telegram_username, telegram_first_name,
+			       telegram_last_name, telegram_photo_url, telegram_language_code,
+			       telegram_is_premium, banned_at, banned_reason, replay_opt_out, created_at, updated_at
+			FROM users
+			ORDER BY created_at DESC, id DESC
+			LIMIT $1`
+
+		err := r.db.reader(ctx).SelectContext(ctx, &users, query, limit)
+		return users, err
+	}
+
+	query := `
+		SELECT id, telegram_id, telegram_username, telegram_first_name,
+		       telegram_last_name, telegram_photo_url, telegram_language_code,
+		       telegram_is_premium, banned_at, banned_reason, replay_opt_out, created_at, updated_at
+		FROM users
+		WHERE (created_at, id) < ($1, $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3`
+
+	err := r.db.reader(ctx).SelectContext(ctx, &users, query, afterCreatedAt, afterID, limit)
 	return users, err
 }
 
@@ -185,6 +420,6 @@ func (r *userRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
 	query := `SELECT COUNT(*) FROM users`
 
-	err := r.db.GetContext(ctx, &count, query)
+	err := r.db.reader(ctx).GetContext(ctx, &count, query)
 	return count, err
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -93,6 +94,7 @@ func (suite *UserRepositoryIntegrationTestSuite) TestCreate_DuplicateTelegramID(
 
 	err = suite.repository.Create(ctx, user2)
 	assert.Error(suite.T(), err)
+	assert.ErrorIs(suite.T(), err, ErrDuplicate)
 	assert.Contains(suite.T(), err.Error(), "duplicate key")
 }
 
@@ -204,7 +206,7 @@ func (suite *UserRepositoryIntegrationTestSuite) TestUpdateTelegramInfo() {
 	newFirstName := "New"
 	newLastName := "UpdatedName"
 
-	err = suite.repository.UpdateTelegramInfo(ctx, user.ID, newUsername, newFirstName, newLastName, "")
+	err = suite.repository.UpdateTelegramInfo(ctx, user.ID, newUsername, newFirstName, newLastName, "https://t.me/i/userpic/new.jpg", "de", true)
 	require.NoError(suite.T(), err)
 
 	// Verify update
@@ -215,11 +217,103 @@ func (suite *UserRepositoryIntegrationTestSuite) TestUpdateTelegramInfo() {
 	assert.Equal(suite.T(), newUsername, *updatedUser.TelegramUsername)
 	assert.Equal(suite.T(), newFirstName, updatedUser.TelegramFirstName)
 	assert.Equal(suite.T(), newLastName, *updatedUser.TelegramLastName)
+	require.NotNil(suite.T(), updatedUser.TelegramPhotoURL)
+	assert.Equal(suite.T(), "https://t.me/i/userpic/new.jpg", *updatedUser.TelegramPhotoURL)
+	require.NotNil(suite.T(), updatedUser.TelegramLanguageCode)
+	assert.Equal(suite.T(), "de", *updatedUser.TelegramLanguageCode)
+	assert.True(suite.T(), updatedUser.TelegramIsPremium)
 
 	// Verify updated_at was changed
 	assert.True(suite.T(), updatedUser.UpdatedAt.After(user.UpdatedAt))
 }
 
+func (suite *UserRepositoryIntegrationTestSuite) TestBanAndUnbanUser() {
+	ctx := context.Background()
+
+	user := &models.User{
+		ID:                uuid.New(),
+		TelegramID:        123456789,
+		TelegramFirstName: "Cheater",
+		CreatedAt:         time.Now().UTC(),
+		UpdatedAt:         time.Now().UTC(),
+	}
+	require.NoError(suite.T(), suite.repository.Create(ctx, user))
+
+	// Ban the user
+	require.NoError(suite.T(), suite.repository.BanUser(ctx, user.ID, "botting"))
+
+	banned, err := suite.repository.GetByID(ctx, user.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), banned)
+	assert.True(suite.T(), banned.IsBanned())
+	require.NotNil(suite.T(), banned.BannedReason)
+	assert.Equal(suite.T(), "botting", *banned.BannedReason)
+
+	// Banning again updates the reason but keeps the original ban time
+	require.NoError(suite.T(), suite.repository.BanUser(ctx, user.ID, "botting and multi-accounting"))
+	rebanned, err := suite.repository.GetByTelegramID(ctx, user.TelegramID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), rebanned)
+	assert.Equal(suite.T(), "botting and multi-accounting", *rebanned.BannedReason)
+	assert.True(suite.T(), banned.BannedAt.Equal(*rebanned.BannedAt))
+
+	// Unban the user
+	require.NoError(suite.T(), suite.repository.UnbanUser(ctx, user.ID))
+
+	unbanned, err := suite.repository.GetByID(ctx, user.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), unbanned)
+	assert.False(suite.T(), unbanned.IsBanned())
+	assert.Nil(suite.T(), unbanned.BannedReason)
+}
+
+func (suite *UserRepositoryIntegrationTestSuite) TestBanUser_NotFound() {
+	ctx := context.Background()
+
+	assert.ErrorIs(suite.T(), suite.repository.BanUser(ctx, uuid.New(), "botting"), ErrUserNotFound)
+	assert.ErrorIs(suite.T(), suite.repository.UnbanUser(ctx, uuid.New()), ErrUserNotFound)
+}
+
+func (suite *UserRepositoryIntegrationTestSuite) TestReplayOptOut() {
+	ctx := context.Background()
+
+	var userIDs []uuid.UUID
+	for i := int64(0); i < 3; i++ {
+		user := &models.User{
+			ID:                uuid.New(),
+			TelegramID:        223344550 + i,
+			TelegramFirstName: "Racer",
+			CreatedAt:         time.Now().UTC(),
+			UpdatedAt:         time.Now().UTC(),
+		}
+		require.NoError(suite.T(), suite.repository.Create(ctx, user))
+		userIDs = append(userIDs, user.ID)
+	}
+
+	// Runs are recorded by default
+	user, err := suite.repository.GetByID(ctx, userIDs[0])
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), user.ReplayOptOut)
+
+	require.NoError(suite.T(), suite.repository.SetReplayOptOut(ctx, userIDs[0], true))
+	require.NoError(suite.T(), suite.repository.SetReplayOptOut(ctx, userIDs[2], true))
+	require.NoError(suite.T(), suite.repository.SetReplayOptOut(ctx, userIDs[2], false))
+
+	user, err = suite.repository.GetByID(ctx, userIDs[0])
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), user.ReplayOptOut)
+
+	optOuts, err := suite.repository.GetReplayOptOuts(ctx, append(userIDs, uuid.New()))
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[uuid.UUID]bool{userIDs[0]: true}, optOuts)
+
+	optOuts, err = suite.repository.GetReplayOptOuts(ctx, nil)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), optOuts)
+
+	assert.ErrorIs(suite.T(), suite.repository.SetReplayOptOut(ctx, uuid.New(), true), ErrUserNotFound)
+}
+
 func (suite *UserRepositoryIntegrationTestSuite) TestGetOrCreateByTelegramID_ExistingUser() {
 	ctx := context.Background()
 
@@ -250,6 +344,8 @@ func (suite *UserRepositoryIntegrationTestSuite) TestGetOrCreateByTelegramID_Exi
 		"Updated",
 		"Name",
 		"",
+		"",
+		false,
 	)
 
 	require.NoError(suite.T(), err)
@@ -274,7 +370,9 @@ func (suite *UserRepositoryIntegrationTestSuite) TestGetOrCreateByTelegramID_New
 		"new_user",
 		"New",
 		"User",
-		"",
+		"https://t.me/i/userpic/new_user.jpg",
+		"en",
+		true,
 	)
 
 	require.NoError(suite.T(), err)
@@ -291,6 +389,13 @@ func (suite *UserRepositoryIntegrationTestSuite) TestGetOrCreateByTelegramID_New
 	require.NoError(suite.T(), err)
 	require.NotNil(suite.T(), dbUser)
 	assert.Equal(suite.T(), user.ID, dbUser.ID)
+
+	// Profile details round-trip through the database
+	require.NotNil(suite.T(), dbUser.TelegramPhotoURL)
+	assert.Equal(suite.T(), "https://t.me/i/userpic/new_user.jpg", *dbUser.TelegramPhotoURL)
+	require.NotNil(suite.T(), dbUser.TelegramLanguageCode)
+	assert.Equal(suite.T(), "en", *dbUser.TelegramLanguageCode)
+	assert.True(suite.T(), dbUser.TelegramIsPremium)
 }
 
 func (suite *UserRepositoryIntegrationTestSuite) TestGetOrCreateByTelegramID_EmptyOptionalFields() {
@@ -306,6 +411,8 @@ func (suite *UserRepositoryIntegrationTestSuite) TestGetOrCreateByTelegramID_Emp
 		"FirstOnly",
 		"", // empty last name
 		"", // empty photo URL
+		"", // empty language code
+		false,
 	)
 
 	require.NoError(suite.T(), err)
@@ -315,6 +422,76 @@ func (suite *UserRepositoryIntegrationTestSuite) TestGetOrCreateByTelegramID_Emp
 	assert.Nil(suite.T(), user.TelegramUsername)
 	assert.Equal(suite.T(), "FirstOnly", user.TelegramFirstName)
 	assert.Nil(suite.T(), user.TelegramLastName)
+	assert.Nil(suite.T(), user.TelegramLanguageCode)
+	assert.False(suite.T(), user.TelegramIsPremium)
+}
+
+func (suite *UserRepositoryIntegrationTestSuite) TestGetOrCreateWithWallet_NewUser() {
+	ctx := context.Background()
+	walletRepo := NewWalletRepository(suite.dbHelper.DB)
+	ledgerRepo := NewLedgerRepository(suite.dbHelper.DB)
+	grant := decimal.RequireFromString("100.00")
+
+	user, created, err := suite.repository.GetOrCreateWithWallet(ctx, 987654321, "racer", "Racer", "", "", "en", false, grant)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), user)
+	assert.True(suite.T(), created)
+
+	// The wallet starts with the grant and the ledger records it
+	wallet, err := walletRepo.GetByUserID(ctx, user.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), wallet)
+	assert.True(suite.T(), grant.Equal(wallet.FuelBalance))
+	assert.True(suite.T(), wallet.TonBalance.IsZero())
+	assert.True(suite.T(), wallet.BurnBalance.IsZero())
+
+	entries, err := ledgerRepo.GetUserEntries(ctx, user.ID, 10, 0)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), entries, 1)
+	assert.Equal(suite.T(), models.OperationSignupGrant, entries[0].OperationType)
+	assert.Equal(suite.T(), models.CurrencyFUEL, entries[0].Currency)
+	assert.True(suite.T(), grant.Equal(entries[0].Amount))
+	assert.True(suite.T(), grant.Equal(entries[0].BalanceAfter))
+
+	// Logging in again returns the same user without another wallet or grant
+	again, created, err := suite.repository.GetOrCreateWithWallet(ctx, 987654321, "racer", "Racer", "", "", "en", true, grant)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), created)
+	assert.Equal(suite.T(), user.ID, again.ID)
+	assert.True(suite.T(), again.TelegramIsPremium)
+
+	entries, err = ledgerRepo.GetUserEntries(ctx, user.ID, 10, 0)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+}
+
+func (suite *UserRepositoryIntegrationTestSuite) TestGetOrCreateWithWallet_ZeroGrant() {
+	ctx := context.Background()
+
+	user, created, err := suite.repository.GetOrCreateWithWallet(ctx, 987654321, "", "Racer", "", "", "", false, decimal.Zero)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), created)
+
+	wallet, err := NewWalletRepository(suite.dbHelper.DB).GetByUserID(ctx, user.ID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), wallet)
+	assert.True(suite.T(), wallet.FuelBalance.IsZero())
+
+	entries, err := NewLedgerRepository(suite.dbHelper.DB).GetUserEntries(ctx, user.ID, 10, 0)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entries)
+}
+
+func (suite *UserRepositoryIntegrationTestSuite) TestGetOrCreateWithWallet_RollsBackUserWhenWalletFails() {
+	ctx := context.Background()
+
+	// A negative grant violates the wallet balance check after the user row was inserted
+	_, _, err := suite.repository.GetOrCreateWithWallet(ctx, 987654321, "", "Racer", "", "", "", false, decimal.RequireFromString("-1.00"))
+	assert.ErrorIs(suite.T(), err, ErrNegativeBalance)
+
+	user, err := suite.repository.GetByTelegramID(ctx, 987654321)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), user, "the user must not exist without a wallet")
 }
 
 func (suite *UserRepositoryIntegrationTestSuite) TestList() {
@@ -367,6 +544,101 @@ func (suite *UserRepositoryIntegrationTestSuite) TestList() {
 	assert.Equal(suite.T(), "User1", retrievedUsers[0].TelegramFirstName)
 }
 
+// createUsersAt creates a user per creation time with consecutive Telegram IDs
+func (suite *UserRepositoryIntegrationTestSuite) createUsersAt(telegramIDBase int64, createdAt ...time.Time) {
+	for i, at := range createdAt {
+		user := &models.User{
+			ID:                uuid.New(),
+			TelegramID:        telegramIDBase + int64(i),
+			TelegramFirstName: fmt.Sprintf("User%d", telegramIDBase+int64(i)),
+			CreatedAt:         at,
+			UpdatedAt:         at,
+		}
+		require.NoError(suite.T(), suite.repository.Create(context.Background(), user))
+	}
+}
+
+// listAllAfter walks every keyset page of the given size
+func (suite *UserRepositoryIntegrationTestSuite) listAllAfter(pageSize int) []*models.User {
+	var all []*models.User
+	var afterCreatedAt time.Time
+	var afterID uuid.UUID
+	for {
+		page, err := suite.repository.ListAfter(context.Background(), afterCreatedAt, afterID, pageSize)
+		require.NoError(suite.T(), err)
+		all = append(all, page...)
+		if len(page) < pageSize {
+			return all
+		}
+		last := page[len(page)-1]
+		afterCreatedAt, afterID = last.CreatedAt, last.ID
+	}
+}
+
+func (suite *UserRepositoryIntegrationTestSuite) TestListAfter_MatchesOffsetPagination() {
+	ctx := context.Background()
+
+	// Several users share a creation time, so pages must break ties by ID
+	base := time.Now().UTC().Truncate(time.Second)
+	suite.createUsersAt(100, base, base, base, base.Add(-time.Hour), base.Add(-time.Hour), base.Add(-2*time.Hour), base.Add(time.Hour))
+
+	offsetUsers, err := suite.repository.List(ctx, 100, 0)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), offsetUsers, 7)
+
+	for _, pageSize := range []int{1, 2, 3, 7, 10} {
+		keysetUsers := suite.listAllAfter(pageSize)
+		require.Len(suite.T(), keysetUsers, len(offsetUsers), "page size %d", pageSize)
+		for i := range offsetUsers {
+			assert.Equal(suite.T(), offsetUsers[i].ID, keysetUsers[i].ID, "page size %d, position %d", pageSize, i)
+		}
+	}
+
+	// Each keyset page equals the offset page at the same position
+	var afterCreatedAt time.Time
+	var afterID uuid.UUID
+	for offset := 0; offset < len(offsetUsers); offset += 3 {
+		keysetPage, err := suite.repository.ListAfter(ctx, afterCreatedAt, afterID, 3)
+		require.NoError(suite.T(), err)
+		offsetPage, err := suite.repository.List(ctx, 3, offset)
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), offsetPage, keysetPage)
+
+		last := keysetPage[len(keysetPage)-1]
+		afterCreatedAt, afterID = last.CreatedAt, last.ID
+	}
+}
+
+func (suite *UserRepositoryIntegrationTestSuite) TestListAfter_StableUnderInsertion() {
+	ctx := context.Background()
+
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	suite.createUsersAt(200, base, base.Add(-time.Minute), base.Add(-2*time.Minute), base.Add(-3*time.Minute))
+
+	keysetFirst, err := suite.repository.ListAfter(ctx, time.Time{}, uuid.Nil, 2)
+	require.NoError(suite.T(), err)
+	offsetFirst, err := suite.repository.List(ctx, 2, 0)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), offsetFirst, keysetFirst)
+
+	// A new player signs up between page requests
+	suite.createUsersAt(300, time.Now().UTC())
+
+	last := keysetFirst[len(keysetFirst)-1]
+	keysetSecond, err := suite.repository.ListAfter(ctx, last.CreatedAt, last.ID, 2)
+	require.NoError(suite.T(), err)
+	offsetSecond, err := suite.repository.List(ctx, 2, 2)
+	require.NoError(suite.T(), err)
+
+	// The keyset page continues exactly where the first page ended
+	require.Len(suite.T(), keysetSecond, 2)
+	assert.Equal(suite.T(), "User202", keysetSecond[0].TelegramFirstName)
+	assert.Equal(suite.T(), "User203", keysetSecond[1].TelegramFirstName)
+
+	// The offset page shifted and repeats the last user of the first page
+	assert.Equal(suite.T(), last.ID, offsetSecond[0].ID)
+}
+
 func (suite *UserRepositoryIntegrationTestSuite) TestCount() {
 	ctx := context.Background()
 
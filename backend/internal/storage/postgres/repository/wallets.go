@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 
 	"github.com/megaherz/ndr/internal/storage/postgres/models"
@@ -16,27 +17,39 @@ type WalletRepository interface {
 	// GetByUserID retrieves a wallet by user ID
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Wallet, error)
 
+	// GetByUserIDs retrieves the wallets of several users in one query, keyed by user ID.
+	// Users without a wallet are left out of the map.
+	GetByUserIDs(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*models.Wallet, error)
+
 	// Create creates a new wallet for a user
 	Create(ctx context.Context, wallet *models.Wallet) error
 
-	// UpdateBalances updates wallet balances atomically
+	// UpdateBalances updates wallet balances atomically, bumping the wallet version
 	UpdateBalances(ctx context.Context, userID uuid.UUID, tonDelta, fuelDelta, burnDelta decimal.Decimal) error
 
+	// UpdateBalancesReturning updates wallet balances atomically and returns the wallet as the update left it.
+	// It returns nil if the user has no wallet.
+	UpdateBalancesReturning(ctx context.Context, userID uuid.UUID, tonDelta, fuelDelta, burnDelta decimal.Decimal) (*models.Wallet, error)
+
 	// IncrementRookieRaces increments the rookie races completed counter
 	IncrementRookieRaces(ctx context.Context, userID uuid.UUID) error
 
+	// IncrementRookieRacesIfBelowCap increments the rookie races completed counter unless it already
+	// reached the cap of 3, and reports whether it was incremented. Reaching the cap is not an error.
+	IncrementRookieRacesIfBelowCap(ctx context.Context, userID uuid.UUID) (bool, error)
+
 	// SetTONWalletAddress sets the connected TON wallet address
 	SetTONWalletAddress(ctx context.Context, userID uuid.UUID, address string) error
 }
 
 // walletRepository implements WalletRepository
 type walletRepository struct {
-	db *sqlx.DB
+	db *timeoutDB
 }
 
 // NewWalletRepository creates a new wallet repository
-func NewWalletRepository(db *sqlx.DB) WalletRepository {
-	return &walletRepository{db: db}
+func NewWalletRepository(db *sqlx.DB, opts ...Option) WalletRepository {
+	return &walletRepository{db: newTimeoutDB(db, opts...)}
 }
 
 // GetByUserID retrieves a wallet by user ID
@@ -44,7 +57,7 @@ func (r *walletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*
 	wallet := &models.Wallet{}
 	query := `
 		SELECT user_id, ton_balance, fuel_balance, burn_balance, 
-		       rookie_races_completed, ton_wallet_address, created_at, updated_at
+		       rookie_races_completed, ton_wallet_address, version, created_at, updated_at
 		FROM wallets 
 		WHERE user_id = $1`
 
@@ -59,6 +72,36 @@ func (r *walletRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*
 	return wallet, nil
 }
 
+// GetByUserIDs retrieves the wallets of several users in one query, keyed by user ID
+func (r *walletRepository) GetByUserIDs(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]*models.Wallet, error) {
+	wallets := make(map[uuid.UUID]*models.Wallet, len(userIDs))
+	if len(userIDs) == 0 {
+		return wallets, nil
+	}
+
+	ids := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		ids[i] = userID.String()
+	}
+
+	var rows []*models.Wallet
+	query := `
+		SELECT user_id, ton_balance, fuel_balance, burn_balance,
+		       rookie_races_completed, ton_wallet_address, version, created_at, updated_at
+		FROM wallets
+		WHERE user_id = ANY($1::uuid[])`
+
+	err := r.db.SelectContext(ctx, &rows, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, wallet := range rows {
+		wallets[wallet.UserID] = wallet
+	}
+	return wallets, nil
+}
+
 // Create creates a new wallet for a user
 func (r *walletRepository) Create(ctx context.Context, wallet *models.Wallet) error {
 	query := `
@@ -68,7 +111,7 @@ func (r *walletRepository) Create(ctx context.Context, wallet *models.Wallet) er
 		        :rookie_races_completed, :ton_wallet_address, :created_at, :updated_at)`
 
 	_, err := r.db.NamedExecContext(ctx, query, wallet)
-	return err
+	return mapConstraintError(err)
 }
 
 // UpdateBalances updates wallet balances atomically
@@ -78,11 +121,37 @@ func (r *walletRepository) UpdateBalances(ctx context.Context, userID uuid.UUID,
 		SET ton_balance = ton_balance + $2,
 		    fuel_balance = fuel_balance + $3,
 		    burn_balance = burn_balance + $4,
+		    version = version + 1,
 		    updated_at = NOW()
 		WHERE user_id = $1`
 
 	_, err := r.db.ExecContext(ctx, query, userID, tonDelta, fuelDelta, burnDelta)
-	return err
+	return mapConstraintError(err)
+}
+
+// UpdateBalancesReturning updates wallet balances atomically and returns the updated wallet
+func (r *walletRepository) UpdateBalancesReturning(ctx context.Context, userID uuid.UUID, tonDelta, fuelDelta, burnDelta decimal.Decimal) (*models.Wallet, error) {
+	wallet := &models.Wallet{}
+	query := `
+		UPDATE wallets 
+		SET ton_balance = ton_balance + $2,
+		    fuel_balance = fuel_balance + $3,
+		    burn_balance = burn_balance + $4,
+		    version = version + 1,
+		    updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING user_id, ton_balance, fuel_balance, burn_balance,
+		          rookie_races_completed, ton_wallet_address, version, created_at, updated_at`
+
+	err := r.db.GetContext(ctx, wallet, query, userID, tonDelta, fuelDelta, burnDelta)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, mapConstraintError(err)
+	}
+
+	return wallet, nil
 }
 
 // IncrementRookieRaces increments the rookie races completed counter
@@ -94,7 +163,28 @@ func (r *walletRepository) IncrementRookieRaces(ctx context.Context, userID uuid
 		WHERE user_id = $1`
 
 	_, err := r.db.ExecContext(ctx, query, userID)
-	return err
+	return mapConstraintError(err)
+}
+
+// IncrementRookieRacesIfBelowCap increments the rookie races completed counter unless it reached the cap.
+// The cap is checked in the UPDATE itself, so concurrent settlements never push the counter past it.
+// A user without a wallet is reported as not incremented.
+func (r *walletRepository) IncrementRookieRacesIfBelowCap(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE wallets 
+		SET rookie_races_completed = rookie_races_completed + 1,
+		    updated_at = NOW()
+		WHERE user_id = $1 AND rookie_races_completed < 3`
+
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return false, mapConstraintError(err)
+	}
+	incremented, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return incremented > 0, nil
 }
 
 // SetTONWalletAddress sets the connected TON wallet address
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -111,6 +113,7 @@ func (suite *WalletRepositoryIntegrationTestSuite) TestCreate_DuplicateUserID()
 	err = suite.walletRepo.Create(ctx, wallet2)
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "duplicate key")
+	assert.ErrorIs(suite.T(), err, ErrDuplicate)
 }
 
 func (suite *WalletRepositoryIntegrationTestSuite) TestCreate_InvalidUserID() {
@@ -129,6 +132,7 @@ func (suite *WalletRepositoryIntegrationTestSuite) TestCreate_InvalidUserID() {
 	err := suite.walletRepo.Create(ctx, wallet)
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "foreign key")
+	assert.ErrorIs(suite.T(), err, ErrForeignKeyViolation)
 }
 
 func (suite *WalletRepositoryIntegrationTestSuite) TestGetByUserID() {
@@ -177,6 +181,76 @@ func (suite *WalletRepositoryIntegrationTestSuite) TestGetByUserID_NotFound() {
 	assert.Nil(suite.T(), wallet)
 }
 
+// createWallets creates n users with wallets holding 1..n FUEL and returns their IDs
+func (suite *WalletRepositoryIntegrationTestSuite) createWallets(n int) []uuid.UUID {
+	ctx := context.Background()
+	userIDs := make([]uuid.UUID, 0, n)
+	for i := 1; i <= n; i++ {
+		userID := uuid.New()
+		require.NoError(suite.T(), suite.userRepo.Create(ctx, &models.User{
+			ID:                userID,
+			TelegramID:        int64(1000 + i),
+			TelegramFirstName: "Racer",
+			CreatedAt:         time.Now().UTC(),
+			UpdatedAt:         time.Now().UTC(),
+		}))
+		require.NoError(suite.T(), suite.walletRepo.Create(ctx, &models.Wallet{
+			UserID:      userID,
+			FuelBalance: decimal.NewFromInt(int64(i)),
+			CreatedAt:   time.Now().UTC(),
+			UpdatedAt:   time.Now().UTC(),
+		}))
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
+
+func (suite *WalletRepositoryIntegrationTestSuite) TestGetByUserIDs() {
+	ctx := context.Background()
+	userIDs := suite.createWallets(3)
+
+	// The test user has no wallet, and neither does an unknown user
+	wallets, err := suite.walletRepo.GetByUserIDs(ctx, append(userIDs, suite.testUserID, uuid.New()))
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), wallets, 3)
+
+	for i, userID := range userIDs {
+		require.Contains(suite.T(), wallets, userID)
+		assert.Equal(suite.T(), userID, wallets[userID].UserID)
+		assert.True(suite.T(), wallets[userID].FuelBalance.Equal(decimal.NewFromInt(int64(i+1))))
+	}
+
+	wallets, err = suite.walletRepo.GetByUserIDs(ctx, nil)
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), wallets)
+}
+
+// TestGetByUserIDs_Benchmark compares one bulk read with a GetByUserID call per user
+func (suite *WalletRepositoryIntegrationTestSuite) TestGetByUserIDs_Benchmark() {
+	ctx := context.Background()
+	userIDs := suite.createWallets(10)
+
+	loop := testing.Benchmark(func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, userID := range userIDs {
+				if _, err := suite.walletRepo.GetByUserID(ctx, userID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	bulk := testing.Benchmark(func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := suite.walletRepo.GetByUserIDs(ctx, userIDs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	suite.T().Logf("10 wallets: GetByUserID loop %s, GetByUserIDs %s", loop, bulk)
+	assert.Less(suite.T(), bulk.NsPerOp(), loop.NsPerOp())
+}
+
 func (suite *WalletRepositoryIntegrationTestSuite) TestUpdateBalances() {
 	ctx := context.Background()
 
@@ -219,6 +293,9 @@ func (suite *WalletRepositoryIntegrationTestSuite) TestUpdateBalances() {
 
 	// Verify updated_at was changed
 	assert.True(suite.T(), updatedWallet.UpdatedAt.After(initialWallet.UpdatedAt))
+
+	// Every balance change bumps the version
+	assert.Equal(suite.T(), int64(1), updatedWallet.Version)
 }
 
 func (suite *WalletRepositoryIntegrationTestSuite) TestUpdateBalances_NegativeResult() {
@@ -245,6 +322,7 @@ func (suite *WalletRepositoryIntegrationTestSuite) TestUpdateBalances_NegativeRe
 	err = suite.walletRepo.UpdateBalances(ctx, suite.testUserID, tonDelta, fuelDelta, burnDelta)
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "check constraint")
+	assert.ErrorIs(suite.T(), err, ErrNegativeBalance)
 }
 
 func (suite *WalletRepositoryIntegrationTestSuite) TestUpdateBalances_NonExistentUser() {
@@ -264,6 +342,56 @@ func (suite *WalletRepositoryIntegrationTestSuite) TestUpdateBalances_NonExisten
 	assert.Nil(suite.T(), wallet)
 }
 
+func (suite *WalletRepositoryIntegrationTestSuite) TestUpdateBalancesReturning() {
+	ctx := context.Background()
+
+	err := suite.walletRepo.Create(ctx, &models.Wallet{
+		UserID:      suite.testUserID,
+		TonBalance:  decimal.NewFromFloat(1.00),
+		FuelBalance: decimal.NewFromFloat(200.00),
+		BurnBalance: decimal.NewFromFloat(50.00),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	})
+	require.NoError(suite.T(), err)
+
+	returned, err := suite.walletRepo.UpdateBalancesReturning(ctx, suite.testUserID, decimal.Zero, decimal.NewFromFloat(-75.25), decimal.NewFromInt(10))
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), returned)
+
+	// The returned wallet is what a subsequent read sees
+	stored, err := suite.walletRepo.GetByUserID(ctx, suite.testUserID)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "124.75", returned.FuelBalance.StringFixed(2))
+	assert.Equal(suite.T(), stored.FuelBalance.StringFixed(2), returned.FuelBalance.StringFixed(2))
+	assert.Equal(suite.T(), stored.BurnBalance.StringFixed(2), returned.BurnBalance.StringFixed(2))
+	assert.Equal(suite.T(), stored.TonBalance.StringFixed(2), returned.TonBalance.StringFixed(2))
+	assert.Equal(suite.T(), int64(1), returned.Version)
+	assert.Equal(suite.T(), stored.Version, returned.Version)
+}
+
+func (suite *WalletRepositoryIntegrationTestSuite) TestUpdateBalancesReturning_Errors() {
+	ctx := context.Background()
+
+	// No wallet, nothing returned
+	wallet, err := suite.walletRepo.UpdateBalancesReturning(ctx, uuid.New(), decimal.Zero, decimal.NewFromInt(5), decimal.Zero)
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), wallet)
+
+	// Overdrawing maps to ErrNegativeBalance like UpdateBalances
+	err = suite.walletRepo.Create(ctx, &models.Wallet{
+		UserID:      suite.testUserID,
+		FuelBalance: decimal.NewFromInt(10),
+		CreatedAt:   time.Now().UTC(),
+		UpdatedAt:   time.Now().UTC(),
+	})
+	require.NoError(suite.T(), err)
+
+	wallet, err = suite.walletRepo.UpdateBalancesReturning(ctx, suite.testUserID, decimal.Zero, decimal.NewFromInt(-11), decimal.Zero)
+	assert.ErrorIs(suite.T(), err, ErrNegativeBalance)
+	assert.Nil(suite.T(), wallet)
+}
+
 func (suite *WalletRepositoryIntegrationTestSuite) TestIncrementRookieRaces() {
 	ctx := context.Background()
 
@@ -297,6 +425,9 @@ func (suite *WalletRepositoryIntegrationTestSuite) TestIncrementRookieRaces() {
 
 	// Verify updated_at was changed
 	assert.True(suite.T(), updatedWallet.UpdatedAt.After(initialWallet.UpdatedAt))
+
+	// Every balance change bumps the version
+	assert.Equal(suite.T(), int64(1), updatedWallet.Version)
 }
 
 func (suite *WalletRepositoryIntegrationTestSuite) TestIncrementRookieRaces_MaxLimit() {
@@ -320,6 +451,89 @@ func (suite *WalletRepositoryIntegrationTestSuite) TestIncrementRookieRaces_MaxL
 	err = suite.walletRepo.IncrementRookieRaces(ctx, suite.testUserID)
 	assert.Error(suite.T(), err)
 	assert.Contains(suite.T(), err.Error(), "check constraint")
+	assert.ErrorIs(suite.T(), err, ErrRookieRaceLimit)
+}
+
+func (suite *WalletRepositoryIntegrationTestSuite) TestIncrementRookieRacesIfBelowCap() {
+	ctx := context.Background()
+
+	initialWallet := &models.Wallet{
+		UserID:               suite.testUserID,
+		TonBalance:           decimal.NewFromFloat(100.00),
+		FuelBalance:          decimal.NewFromFloat(200.00),
+		BurnBalance:          decimal.NewFromFloat(0.00),
+		RookieRacesCompleted: 2,
+		CreatedAt:            time.Now().UTC(),
+		UpdatedAt:            time.Now().UTC(),
+	}
+
+	err := suite.walletRepo.Create(ctx, initialWallet)
+	require.NoError(suite.T(), err)
+
+	// The last race below the cap still counts
+	incremented, err := suite.walletRepo.IncrementRookieRacesIfBelowCap(ctx, suite.testUserID)
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), incremented)
+
+	wallet, err := suite.walletRepo.GetByUserID(ctx, suite.testUserID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), wallet)
+	assert.Equal(suite.T(), 3, wallet.RookieRacesCompleted)
+}
+
+func (suite *WalletRepositoryIntegrationTestSuite) TestIncrementRookieRacesIfBelowCap_AtCap() {
+	ctx := context.Background()
+
+	initialWallet := &models.Wallet{
+		UserID:               suite.testUserID,
+		TonBalance:           decimal.NewFromFloat(100.00),
+		FuelBalance:          decimal.NewFromFloat(200.00),
+		BurnBalance:          decimal.NewFromFloat(0.00),
+		RookieRacesCompleted: 3,
+		CreatedAt:            time.Now().UTC(),
+		UpdatedAt:            time.Now().UTC(),
+	}
+
+	err := suite.walletRepo.Create(ctx, initialWallet)
+	require.NoError(suite.T(), err)
+
+	// At the cap the counter is left alone without an error
+	incremented, err := suite.walletRepo.IncrementRookieRacesIfBelowCap(ctx, suite.testUserID)
+	require.NoError(suite.T(), err)
+	assert.False(suite.T(), incremented)
+
+	wallet, err := suite.walletRepo.GetByUserID(ctx, suite.testUserID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), wallet)
+	assert.Equal(suite.T(), 3, wallet.RookieRacesCompleted)
+}
+
+func (suite *WalletRepositoryIntegrationTestSuite) TestIncrementRookieRacesIfBelowCap_Concurrent() {
+	ctx := context.Background()
+	userID := suite.createWallets(1)[0]
+
+	// Racing settlements never push the counter past the cap
+	var wg sync.WaitGroup
+	var incrementedCount atomic.Int32
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			incremented, err := suite.walletRepo.IncrementRookieRacesIfBelowCap(ctx, userID)
+			assert.NoError(suite.T(), err)
+			if incremented {
+				incrementedCount.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(suite.T(), int32(3), incrementedCount.Load())
+
+	wallet, err := suite.walletRepo.GetByUserID(ctx, userID)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), wallet)
+	assert.Equal(suite.T(), 3, wallet.RookieRacesCompleted)
 }
 
 func (suite *WalletRepositoryIntegrationTestSuite) TestSetTONWalletAddress() {
@@ -357,6 +571,9 @@ func (suite *WalletRepositoryIntegrationTestSuite) TestSetTONWalletAddress() {
 
 	// Verify updated_at was changed
 	assert.True(suite.T(), updatedWallet.UpdatedAt.After(initialWallet.UpdatedAt))
+
+	// Every balance change bumps the version
+	assert.Equal(suite.T(), int64(1), updatedWallet.Version)
 }
 
 func (suite *WalletRepositoryIntegrationTestSuite) TestSetTONWalletAddress_UpdateExisting() {
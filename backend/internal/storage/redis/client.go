@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"time"
 
@@ -17,18 +18,23 @@ type Client struct {
 
 // Config holds Redis client configuration
 type Config struct {
-	Addr     string
-	Password string
-	DB       int
+	Addr      string
+	Username  string // ACL username (Redis 6+)
+	Password  string
+	DB        int
+	TLSConfig *tls.Config // Enables TLS when set (rediss:// URLs)
+
+	// Connection pool settings; zero values use the go-redis defaults
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	MaxRetries   int
 }
 
 // NewClient creates a new Redis client wrapper
 func NewClient(cfg Config, logger *logrus.Logger) (*Client, error) {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.Addr,
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+	rdb := redis.NewClient(newOptions(cfg))
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -39,8 +45,10 @@ func NewClient(cfg Config, logger *logrus.Logger) (*Client, error) {
 	}
 
 	logger.WithFields(logrus.Fields{
-		"addr": cfg.Addr,
-		"db":   cfg.DB,
+		"addr":      cfg.Addr,
+		"db":        cfg.DB,
+		"tls":       cfg.TLSConfig != nil,
+		"pool_size": rdb.Options().PoolSize,
 	}).Info("Connected to Redis")
 
 	return &Client{
@@ -49,6 +57,27 @@ func NewClient(cfg Config, logger *logrus.Logger) (*Client, error) {
 	}, nil
 }
 
+// newOptions converts the client config into go-redis options
+func newOptions(cfg Config) *redis.Options {
+	return &redis.Options{
+		Addr:         cfg.Addr,
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		TLSConfig:    cfg.TLSConfig,
+		PoolSize:     cfg.PoolSize,
+		MinIdleConns: cfg.MinIdleConns,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		MaxRetries:   cfg.MaxRetries,
+	}
+}
+
+// Options returns the effective options of the underlying Redis client
+func (c *Client) Options() *redis.Options {
+	return c.client.Options()
+}
+
 // Close closes the Redis connection
 func (c *Client) Close() error {
 	return c.client.Close()
@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientOptions_CustomPoolSettings(t *testing.T) {
+	cfg := Config{
+		Addr:         "localhost:6379",
+		Username:     "app",
+		Password:     "secret",
+		DB:           3,
+		PoolSize:     42,
+		MinIdleConns: 7,
+		DialTimeout:  2 * time.Second,
+		ReadTimeout:  750 * time.Millisecond,
+		MaxRetries:   5,
+	}
+
+	client := &Client{client: redis.NewClient(newOptions(cfg))}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	options := client.Options()
+	assert.Equal(t, "localhost:6379", options.Addr)
+	assert.Equal(t, "app", options.Username)
+	assert.Equal(t, "secret", options.Password)
+	assert.Equal(t, 3, options.DB)
+	assert.Equal(t, 42, options.PoolSize)
+	assert.Equal(t, 7, options.MinIdleConns)
+	assert.Equal(t, 2*time.Second, options.DialTimeout)
+	assert.Equal(t, 750*time.Millisecond, options.ReadTimeout)
+	assert.Equal(t, 5, options.MaxRetries)
+}
+
+func TestClientOptions_Defaults(t *testing.T) {
+	client := &Client{client: redis.NewClient(newOptions(Config{Addr: "localhost:6379"}))}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	options := client.Options()
+	assert.Greater(t, options.PoolSize, 0)
+	assert.Equal(t, 5*time.Second, options.DialTimeout)
+	assert.Equal(t, 3, options.MaxRetries)
+}